@@ -0,0 +1,112 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package inject
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookinject"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	containerSpec string
+	dryRun        bool
+}
+
+// NewCommand constructs an inject command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build the inject command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'inject' command
+	c := cli.Command{
+		Name:  "inject",
+		Usage: "A createRuntime hook that injects the CDI devices requested for a container. The same CDI modifications as in the cdi mode of the NVIDIA Container Runtime are determined and applied to the container",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "container-spec",
+			Usage:       "Specify the path to the OCI container state. If empty or '-' the state will be read from STDIN",
+			Destination: &opts.containerSpec,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Log the edits for the container instead of applying these",
+			Destination: &opts.dryRun,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	s, err := oci.LoadContainerState(opts.containerSpec)
+	if err != nil {
+		return fmt.Errorf("failed to load container state: %v", err)
+	}
+
+	spec, err := s.LoadSpec()
+	if err != nil {
+		return fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	edits, err := hookinject.GetEdits(m.logger, cfg, spec)
+	if err != nil {
+		return fmt.Errorf("failed to determine CDI edits: %v", err)
+	}
+	if edits.IsEmpty() {
+		m.logger.Debugf("No CDI devices requested; exiting")
+		return nil
+	}
+
+	if opts.dryRun {
+		m.logger.Infof("Devices: %+v", edits.Devices)
+		m.logger.Infof("Mounts: %+v", edits.Mounts)
+		m.logger.Infof("Hooks: %+v", edits.Hooks)
+		return nil
+	}
+
+	injector, err := hookinject.NewInjector(m.logger, s)
+	if err != nil {
+		return err
+	}
+	return injector.Apply(edits)
+}
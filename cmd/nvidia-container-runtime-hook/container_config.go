@@ -10,7 +10,11 @@ import (
 	"strings"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/policy"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
 	"golang.org/x/mod/semver"
 )
 
@@ -43,10 +47,11 @@ type nvidiaConfig struct {
 }
 
 type containerConfig struct {
-	Pid    int
-	Rootfs string
-	Env    map[string]string
-	Nvidia *nvidiaConfig
+	Pid         int
+	Rootfs      string
+	Env         map[string]string
+	Annotations map[string]string
+	Nvidia      *nvidiaConfig
 }
 
 // Root from OCI runtime spec
@@ -85,10 +90,11 @@ type Mount struct {
 // We use pointers to structs, similarly to the latest version of runtime-spec:
 // https://github.com/opencontainers/runtime-spec/blob/v1.0.0/specs-go/config.go#L5-L28
 type Spec struct {
-	Version *string  `json:"ociVersion"`
-	Process *Process `json:"process,omitempty"`
-	Root    *Root    `json:"root,omitempty"`
-	Mounts  []Mount  `json:"mounts,omitempty"`
+	Version     *string           `json:"ociVersion"`
+	Process     *Process          `json:"process,omitempty"`
+	Root        *Root             `json:"root,omitempty"`
+	Mounts      []Mount           `json:"mounts,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // HookState holds state information about the hook
@@ -255,6 +261,24 @@ func getDevices(hookConfig *HookConfig, image image.CUDA, mounts []Mount, privil
 	return nil
 }
 
+// resolveBusIDs replaces the PCI bus IDs in the specified comma-separated list of devices with the
+// UUIDs of the corresponding GPUs. The GPUs are looked up in the information files of the NVIDIA
+// kernel module under the specified root.
+func resolveBusIDs(root string, devices string) (string, error) {
+	var resolved []string
+	for _, id := range strings.Split(devices, ",") {
+		if busID, ok := image.ParseBusID(strings.TrimSpace(id)); ok {
+			gpu, _, err := devicestate.ResolveBusID(root, busID)
+			if err != nil {
+				return "", err
+			}
+			id = gpu.UUID
+		}
+		resolved = append(resolved, id)
+	}
+	return strings.Join(resolved, ","), nil
+}
+
 func getMigConfigDevices(env map[string]string) *string {
 	if devices, ok := env[envNVMigConfigDevices]; ok {
 		return &devices
@@ -303,6 +327,10 @@ func getNvidiaConfig(hookConfig *HookConfig, image image.CUDA, mounts []Mount, p
 		// 'nil' devices means this is not a GPU container.
 		return nil
 	}
+	devices, err := resolveBusIDs("/", devices)
+	if err != nil {
+		log.Panicln("could not resolve PCI bus IDs:", err)
+	}
 
 	var migConfigDevices string
 	if d := getMigConfigDevices(image); d != nil {
@@ -339,6 +367,32 @@ func getNvidiaConfig(hookConfig *HookConfig, image image.CUDA, mounts []Mount, p
 	}
 }
 
+// getDeprecations returns the deprecated request mechanisms used by the container.
+func getDeprecations(hookConfig *HookConfig, image image.CUDA) []deprecation.ID {
+	var deprecations []deprecation.ID
+
+	var usesSwarmEnvvar bool
+	for _, envvar := range hookConfig.getSwarmResourceEnvvars() {
+		if _, exists := image[envvar]; exists {
+			usesSwarmEnvvar = true
+			break
+		}
+	}
+	if usesSwarmEnvvar {
+		deprecations = append(deprecations, deprecation.SwarmResourceEnvvars)
+	}
+
+	if image.IsLegacy() {
+		_, devicesSpecified := image[envNVVisibleDevices]
+		_, capabilitiesSpecified := image[envNVDriverCapabilities]
+		if (!devicesSpecified && !usesSwarmEnvvar) || !capabilitiesSpecified {
+			deprecations = append(deprecations, deprecation.LegacyImageDefaults)
+		}
+	}
+
+	return deprecations
+}
+
 func getContainerConfig(hook HookConfig) (config containerConfig) {
 	var h HookState
 	d := json.NewDecoder(os.Stdin)
@@ -360,9 +414,25 @@ func getContainerConfig(hook HookConfig) (config containerConfig) {
 
 	privileged := isPrivileged(s)
 	return containerConfig{
-		Pid:    h.Pid,
-		Rootfs: s.Root.Path,
-		Env:    image,
-		Nvidia: getNvidiaConfig(&hook, image, s.Mounts, privileged),
+		Pid:         h.Pid,
+		Rootfs:      s.Root.Path,
+		Env:         image,
+		Annotations: s.Annotations,
+		Nvidia:      getNvidiaConfig(&hook, image, s.Mounts, privileged),
+	}
+}
+
+// checkDeviceLimit checks the devices requested by a GPU container against the device limit that
+// applies to the container. A request for all devices counts as the number of GPUs on the node.
+func checkDeviceLimit(hookConfig *HookConfig, container containerConfig) error {
+	limit := policy.DeviceLimit(&hookConfig.NVIDIAContainerRuntime, container.Annotations)
+
+	var driverRoot string
+	if hookConfig.NvidiaContainerCLI.Root != nil {
+		driverRoot = *hookConfig.NvidiaContainerCLI.Root
+	}
+	countAll := func() (int, error) {
+		return policy.CountGPUs(nvml.New(), "/", driverRoot)
 	}
+	return policy.CheckDeviceLimit(limit, strings.Split(container.Nvidia.Devices, ","), countAll)
 }
@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/driverroot"
 	"github.com/stretchr/testify/require"
 )
 
@@ -944,6 +947,22 @@ func TestGetDevicesFromEnvvar(t *testing.T) {
 	}
 }
 
+func TestResolveBusIDs(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, driverroot.Create(root, driverroot.WithGPUs(2)))
+
+	devices, err := resolveBusIDs(root, "0,0000:02:00.0,GPU-edfee158")
+	require.NoError(t, err)
+	require.Equal(t, "0,GPU-00000001-0000-0000-0000-000000000000,GPU-edfee158", devices)
+
+	devices, err = resolveBusIDs(root, "all")
+	require.NoError(t, err)
+	require.Equal(t, "all", devices)
+
+	_, err = resolveBusIDs(root, "0000:65:00.0")
+	require.Error(t, err)
+}
+
 func TestGetDriverCapabilities(t *testing.T) {
 
 	supportedCapabilities := "compute,utility,display,video"
@@ -1059,3 +1078,111 @@ func TestGetDriverCapabilities(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDeprecations(t *testing.T) {
+	swarmResource := "DOCKER_RESOURCE_GPU"
+
+	testCases := []struct {
+		description          string
+		env                  map[string]string
+		hookConfig           *HookConfig
+		expectedDeprecations []deprecation.ID
+	}{
+		{
+			description: "no deprecated mechanisms",
+			env: map[string]string{
+				envCUDAVersion:          "9.0",
+				envNVVisibleDevices:     "all",
+				envNVDriverCapabilities: "compute,utility",
+			},
+			hookConfig: &HookConfig{},
+		},
+		{
+			description: "legacy image defaults",
+			env: map[string]string{
+				envCUDAVersion: "9.0",
+			},
+			hookConfig:           &HookConfig{},
+			expectedDeprecations: []deprecation.ID{deprecation.LegacyImageDefaults},
+		},
+		{
+			description: "swarm resource envvar",
+			env: map[string]string{
+				envNVVisibleDevices: "all",
+				swarmResource:       "GPU-0",
+			},
+			hookConfig:           &HookConfig{SwarmResource: &swarmResource},
+			expectedDeprecations: []deprecation.ID{deprecation.SwarmResourceEnvvars},
+		},
+		{
+			description: "swarm resource envvar not set",
+			env: map[string]string{
+				envNVVisibleDevices: "all",
+			},
+			hookConfig: &HookConfig{SwarmResource: &swarmResource},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			deprecations := getDeprecations(tc.hookConfig, image.CUDA(tc.env))
+			require.Equal(t, tc.expectedDeprecations, deprecations)
+		})
+	}
+}
+
+func TestCheckDeviceLimit(t *testing.T) {
+	driverRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(driverRoot, "dev"), 0755))
+	for _, name := range []string{"nvidia0", "nvidia1", "nvidia2"} {
+		require.NoError(t, os.WriteFile(filepath.Join(driverRoot, "dev", name), nil, 0644))
+	}
+
+	hookConfig := getDefaultHookConfig()
+	hookConfig.NvidiaContainerCLI.Root = &driverRoot
+	hookConfig.NVIDIAContainerRuntime.DeviceLimits.MaxDevicesPerContainer = 2
+	hookConfig.NVIDIAContainerRuntime.DeviceLimits.Annotations = map[string]int{"example.com/tier=large": 0}
+	hookConfig.NVIDIAContainerRuntime.DeviceLimits.TrustedAnnotationKeys = []string{"example.com/tier"}
+
+	testCases := []struct {
+		description   string
+		devices       string
+		annotations   map[string]string
+		expectedError bool
+	}{
+		{
+			description: "devices within limit",
+			devices:     "0,1",
+		},
+		{
+			description:   "devices exceed limit",
+			devices:       "GPU-0,GPU-1,GPU-2",
+			expectedError: true,
+		},
+		{
+			description:   "all exceeds limit",
+			devices:       "all",
+			expectedError: true,
+		},
+		{
+			description: "annotation override removes limit",
+			devices:     "all",
+			annotations: map[string]string{"example.com/tier": "large"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			container := containerConfig{
+				Annotations: tc.annotations,
+				Nvidia:      &nvidiaConfig{Devices: tc.devices},
+			}
+			err := checkDeviceLimit(&hookConfig, container)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path"
 	"reflect"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
 )
 
 const (
 	configPath = "/etc/nvidia-container-runtime/config.toml"
 	driverPath = "/run/nvidia/driver"
+
+	// defaultLdconfigPath is the path of the ldconfig executable used for an ldconfig strategy if
+	// nvidia-container-cli.ldconfig is not set.
+	defaultLdconfigPath = "/sbin/ldconfig"
 )
 
 var defaultPaths = [...]string{
@@ -77,14 +81,14 @@ func getHookConfig() (config HookConfig) {
 
 	if len(*configflag) > 0 {
 		config = getDefaultHookConfig()
-		_, err = toml.DecodeFile(*configflag, &config)
+		err = decodeConfigFile(*configflag, &config)
 		if err != nil {
 			log.Panicln("couldn't open configuration file:", err)
 		}
 	} else {
 		for _, p := range defaultPaths {
 			config = getDefaultHookConfig()
-			_, err = toml.DecodeFile(p, &config)
+			err = decodeConfigFile(p, &config)
 			if err == nil {
 				break
 			} else if !os.IsNotExist(err) {
@@ -102,9 +106,23 @@ func getHookConfig() (config HookConfig) {
 		log.Panicf("Invalid value for config option '%v'; %v (supported: %v)\n", configName, config.SupportedDriverCapabilities, allDriverCapabilities)
 	}
 
+	if err := config.applyLegacyModeConfig(); err != nil {
+		log.Panicln(err)
+	}
+
 	return config
 }
 
+// decodeConfigFile decodes the specified config file after expanding references
+// to variables and relative paths in its values.
+func decodeConfigFile(path string, hookConfig *HookConfig) error {
+	tree, err := config.LoadTOMLFile(path)
+	if err != nil {
+		return err
+	}
+	return tree.Unmarshal(hookConfig)
+}
+
 // getConfigOption returns the toml config option associated with the
 // specified struct field.
 func (c HookConfig) getConfigOption(fieldName string) string {
@@ -138,3 +156,54 @@ func (c *HookConfig) getSwarmResourceEnvvars() []string {
 
 	return envvars
 }
+
+// applyLegacyModeConfig validates the options of the legacy mode and restricts the supported
+// driver capabilities to the allowed capabilities if these are specified.
+func (c *HookConfig) applyLegacyModeConfig() error {
+	legacy := c.NVIDIAContainerRuntime.Modes.Legacy
+
+	switch legacy.CUDACompatMode {
+	case "", config.CUDACompatModeLdconfig, config.CUDACompatModeMount, config.CUDACompatModeDisabled:
+	default:
+		return fmt.Errorf("invalid value for config option 'nvidia-container-runtime.modes.legacy.cuda-compat-mode'; %v (supported: %v)",
+			legacy.CUDACompatMode, []string{config.CUDACompatModeLdconfig, config.CUDACompatModeMount, config.CUDACompatModeDisabled})
+	}
+
+	switch legacy.LdconfigStrategy {
+	case "", config.LdconfigStrategyHost, config.LdconfigStrategyContainer:
+	default:
+		return fmt.Errorf("invalid value for config option 'nvidia-container-runtime.modes.legacy.ldconfig-strategy'; %v (supported: %v)",
+			legacy.LdconfigStrategy, []string{config.LdconfigStrategyHost, config.LdconfigStrategyContainer})
+	}
+
+	if len(legacy.AllowedCapabilities) > 0 {
+		allowed := DriverCapabilities(strings.Join(legacy.AllowedCapabilities, ","))
+		c.SupportedDriverCapabilities = c.SupportedDriverCapabilities.Intersection(allowed)
+	}
+
+	return nil
+}
+
+// getLdconfig returns the ldconfig path that is passed to the nvidia-container-cli. If an ldconfig
+// strategy is configured for the legacy mode, the path is prefixed with '@' to select the executable
+// on the host (relative to the driver root) or used as is to select the executable in the container.
+func (c *HookConfig) getLdconfig() *string {
+	ldconfig := c.NvidiaContainerCLI.Ldconfig
+	strategy := c.NVIDIAContainerRuntime.Modes.Legacy.LdconfigStrategy
+	if strategy == "" {
+		return ldconfig
+	}
+
+	path := defaultLdconfigPath
+	if ldconfig != nil && *ldconfig != "" {
+		path = strings.TrimPrefix(*ldconfig, "@")
+	}
+	if strategy == config.LdconfigStrategyHost {
+		var root string
+		if c.NvidiaContainerCLI.Root != nil {
+			root = *c.NvidiaContainerCLI.Root
+		}
+		path = config.ResolveLdconfigPath(root, "@"+path)
+	}
+	return &path
+}
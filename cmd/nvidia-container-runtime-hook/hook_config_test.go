@@ -19,8 +19,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	gotoml "github.com/pelletier/go-toml"
 	"github.com/stretchr/testify/require"
 )
 
@@ -61,6 +64,35 @@ func TestGetHookConfig(t *testing.T) {
 			},
 			expectedDriverCapabilities: DriverCapabilities("utility,compute"),
 		},
+		{
+			lines: []string{
+				"[nvidia-container-runtime.modes.legacy]",
+				"allowed-capabilities = [\"utility\", \"video\"]",
+			},
+			expectedDriverCapabilities: DriverCapabilities("utility,video"),
+		},
+		{
+			lines: []string{
+				"supported-driver-capabilities = \"utility,compute\"",
+				"[nvidia-container-runtime.modes.legacy]",
+				"allowed-capabilities = [\"utility\", \"video\"]",
+			},
+			expectedDriverCapabilities: DriverCapabilities("utility"),
+		},
+		{
+			lines: []string{
+				"[nvidia-container-runtime.modes.legacy]",
+				"cuda-compat-mode = \"not-a-mode\"",
+			},
+			expectedPanic: true,
+		},
+		{
+			lines: []string{
+				"[nvidia-container-runtime.modes.legacy]",
+				"ldconfig-strategy = \"not-a-strategy\"",
+			},
+			expectedPanic: true,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -104,6 +136,64 @@ func TestGetHookConfig(t *testing.T) {
 	}
 }
 
+func TestGetLdconfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		root        string
+		ldconfig    string
+		strategy    string
+		expected    string
+	}{
+		{
+			description: "no strategy uses ldconfig as is",
+			ldconfig:    "@/sbin/ldconfig.real",
+			expected:    "@/sbin/ldconfig.real",
+		},
+		{
+			description: "no strategy and no ldconfig",
+		},
+		{
+			description: "host strategy uses default ldconfig",
+			strategy:    config.LdconfigStrategyHost,
+			expected:    "@/sbin/ldconfig",
+		},
+		{
+			description: "host strategy is relative to driver root",
+			root:        "/run/nvidia/driver",
+			ldconfig:    "/sbin/ldconfig.real",
+			strategy:    config.LdconfigStrategyHost,
+			expected:    "@/run/nvidia/driver/sbin/ldconfig.real",
+		},
+		{
+			description: "container strategy strips host prefix",
+			ldconfig:    "@/sbin/ldconfig.real",
+			strategy:    config.LdconfigStrategyContainer,
+			expected:    "/sbin/ldconfig.real",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			hookConfig := getDefaultHookConfig()
+			if tc.root != "" {
+				hookConfig.NvidiaContainerCLI.Root = &tc.root
+			}
+			if tc.ldconfig != "" {
+				hookConfig.NvidiaContainerCLI.Ldconfig = &tc.ldconfig
+			}
+			hookConfig.NVIDIAContainerRuntime.Modes.Legacy.LdconfigStrategy = tc.strategy
+
+			ldconfig := hookConfig.getLdconfig()
+			if tc.expected == "" {
+				require.Nil(t, ldconfig)
+				return
+			}
+			require.NotNil(t, ldconfig)
+			require.Equal(t, tc.expected, *ldconfig)
+		})
+	}
+}
+
 func TestGetSwarmResourceEnvvars(t *testing.T) {
 	testCases := []struct {
 		value    string
@@ -159,3 +249,36 @@ func TestGetSwarmResourceEnvvars(t *testing.T) {
 		})
 	}
 }
+
+func TestHookConfigFromView(t *testing.T) {
+	contents := strings.Join([]string{
+		"disable-require = true",
+		"swarm-resource = \"DOCKER_RESOURCE_GPU\"",
+		"supported-driver-capabilities = \"compute,utility\"",
+		"[nvidia-container-cli]",
+		"root = \"/run/nvidia/driver\"",
+		"environment = [\"FOO=bar\"]",
+		"ldconfig = \"@/run/nvidia/driver/sbin/ldconfig\"",
+		"no-cgroups = true",
+		"[nvidia-container-runtime]",
+		"log-level = \"debug\"",
+		"mode = \"legacy\"",
+		"[nvidia-container-runtime-hook]",
+		"skip-mode-detection = true",
+	}, "\n")
+
+	tree, err := gotoml.Load(contents)
+	require.NoError(t, err)
+	view, err := config.GetHookConfigFrom(tree)
+	require.NoError(t, err)
+
+	expected := getDefaultHookConfig()
+	require.NoError(t, tree.Unmarshal(&expected))
+
+	// Options that are not read by the hook are not included in the view.
+	actual := getDefaultHookConfig()
+	actual.NVIDIAContainerRuntime.LogLevel = "debug"
+	require.NoError(t, view.Unmarshal(&actual))
+
+	require.EqualValues(t, expected, actual)
+}
@@ -0,0 +1,209 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+// functionReads maps the functions to which the hook passes a (partial) config to the config
+// option that these read.
+var functionReads = map[string]string{
+	"policy.DeviceLimit":         "nvidia-container-runtime.device-limits",
+	"nvmlthrottle.NewFromConfig": "nvidia-container-runtime.nvml-throttle",
+}
+
+// TestHookViewIncludesReadOptions checks that the hook config generated by 'nvidia-ctk config sync-hook'
+// includes all the config options that are read by the hook. The options are determined from the
+// selector expressions on the HookConfig in the source of the hook.
+func TestHookViewIncludesReadOptions(t *testing.T) {
+	options := getReadOptions(t)
+	require.NotEmpty(t, options)
+
+	var keys []string
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		isTable := options[key]
+		t.Run(key, func(t *testing.T) {
+			tree, err := toml.TreeFromMap(map[string]interface{}{})
+			require.NoError(t, err)
+			if isTable {
+				tree.Set(key+".option", "value")
+			} else {
+				tree.Set(key, "value")
+			}
+
+			hookConfig, err := config.GetHookConfigFrom(tree)
+			require.NoError(t, err)
+			require.True(t, hookConfig.Has(key), "option %v is read by the hook but not included in the hook config", key)
+		})
+	}
+}
+
+// getReadOptions returns the config options that are read by the hook. The value indicates whether
+// the option is a table that is read as a whole.
+func getReadOptions(t *testing.T) map[string]bool {
+	fset := token.NewFileSet()
+	packages, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	options := make(map[string]bool)
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			var stack []ast.Node
+			ast.Inspect(file, func(n ast.Node) bool {
+				if n == nil {
+					stack = stack[:len(stack)-1]
+					return true
+				}
+				defer func() { stack = append(stack, n) }()
+
+				selector, ok := n.(*ast.SelectorExpr)
+				if !ok || isSelectorOf(stack, selector) {
+					return true
+				}
+				key, fieldType := resolveOption(selector)
+				if key == "" {
+					return true
+				}
+				if fieldType.Kind() != reflect.Struct {
+					options[key] = false
+					return true
+				}
+				// A table that is assigned to a variable is assumed to be read completely.
+				function := getCalledFunction(stack)
+				if function == "" {
+					for _, leaf := range getLeafOptions(key, fieldType) {
+						options[leaf] = false
+					}
+					return true
+				}
+				// For a table that is passed to a function, the options read by the function are used.
+				read, ok := functionReads[function]
+				if !ok || !strings.HasPrefix(read, key) {
+					t.Errorf("%v: %v is passed to %q which is not in functionReads", fset.Position(selector.Pos()), key, function)
+					return true
+				}
+				options[read] = true
+				return true
+			})
+		}
+	}
+
+	return options
+}
+
+// isSelectorOf checks whether the specified selector is the operand of the innermost enclosing selector.
+func isSelectorOf(stack []ast.Node, selector *ast.SelectorExpr) bool {
+	if len(stack) == 0 {
+		return false
+	}
+	parent, ok := stack[len(stack)-1].(*ast.SelectorExpr)
+	return ok && parent.X == selector
+}
+
+// resolveOption returns the config option and the type of the field of the HookConfig referenced by
+// the specified selector expression. Selectors that do not refer to a field of the HookConfig return
+// an empty key.
+func resolveOption(selector *ast.SelectorExpr) (string, reflect.Type) {
+	var names []string
+	var expr ast.Expr = selector
+	for {
+		s, ok := expr.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		names = append([]string{s.Sel.Name}, names...)
+		expr = s.X
+	}
+
+	var path []string
+	fieldType := reflect.TypeOf(HookConfig{})
+	for _, name := range names {
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.Struct {
+			break
+		}
+		field, ok := fieldType.FieldByName(name)
+		if !ok {
+			break
+		}
+		path = append(path, strings.Split(field.Tag.Get("toml"), ",")[0])
+		fieldType = field.Type
+	}
+	if len(path) == 0 {
+		return "", nil
+	}
+	return strings.Join(path, "."), fieldType
+}
+
+// getLeafOptions returns the options that are not tables in the table with the specified key and type.
+func getLeafOptions(key string, tableType reflect.Type) []string {
+	var leaves []string
+	for i := 0; i < tableType.NumField(); i++ {
+		field := tableType.Field(i)
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			leaves = append(leaves, getLeafOptions(key+"."+name, field.Type)...)
+			continue
+		}
+		leaves = append(leaves, key+"."+name)
+	}
+	return leaves
+}
+
+// getCalledFunction returns the name of the function for the innermost call expression in the stack.
+func getCalledFunction(stack []ast.Node) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		call, ok := stack[i].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := fun.X.(*ast.Ident); ok {
+				return x.Name + "." + fun.Sel.Name
+			}
+			return fun.Sel.Name
+		case *ast.Ident:
+			return fun.Name
+		}
+	}
+	return ""
+}
@@ -13,8 +13,12 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/kernel"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/nvmlthrottle"
 )
 
 var (
@@ -85,6 +89,27 @@ func doPrestart() {
 		return
 	}
 
+	if err := checkDeviceLimit(&hook, container); err != nil {
+		log.Panicln("device limit exceeded:", err)
+	}
+
+	// Check for kernel constraints that prevent the NVIDIA kernel module from being loaded
+	// before nvidia-container-cli fails with a less specific error.
+	if err := kernel.CheckModuleLoading("/"); err != nil {
+		log.Panicf("ERROR [%v]: %v", events.KernelConstraint, err)
+	}
+
+	tracker := deprecation.NewTracker(deprecation.DefaultStateFile)
+	for _, id := range getDeprecations(&hook, container.Env) {
+		warning, err := tracker.Report(id)
+		if err != nil && *debugflag {
+			log.Printf("failed to record use of deprecated feature %v: %v", id, err)
+		}
+		if warning != "" {
+			log.Printf("WARNING [%v]: %v", events.DeprecatedFeatureUsed, warning)
+		}
+	}
+
 	rootfs := getRootfsPath(container)
 
 	args := []string{getCLIPath(cli)}
@@ -110,8 +135,11 @@ func doPrestart() {
 	}
 	args = append(args, "configure")
 
-	if cli.Ldconfig != nil {
-		args = append(args, fmt.Sprintf("--ldconfig=%s", *cli.Ldconfig))
+	if ldconfig := hook.getLdconfig(); ldconfig != nil {
+		args = append(args, fmt.Sprintf("--ldconfig=%s", *ldconfig))
+	}
+	if mode := hook.NVIDIAContainerRuntime.Modes.Legacy.CUDACompatMode; mode != "" {
+		args = append(args, fmt.Sprintf("--cuda-compat-mode=%s", mode))
 	}
 	if cli.NoCgroups {
 		args = append(args, "--no-cgroups")
@@ -143,10 +171,32 @@ func doPrestart() {
 	args = append(args, rootfs)
 
 	env := append(os.Environ(), cli.Environment...)
+	slot := acquireNVMLSlot(&hook)
 	err = syscall.Exec(args[0], args, env)
+	runtime.KeepAlive(slot)
 	log.Panicln("exec failed:", err)
 }
 
+// acquireNVMLSlot waits for a slot of the NVML throttle if one is configured. The slot is inherited
+// by nvidia-container-cli and released when it exits. If no slot can be acquired, a warning is logged
+// and the container is created regardless.
+func acquireNVMLSlot(hook *HookConfig) *nvmlthrottle.Slot {
+	throttle, err := nvmlthrottle.NewFromConfig(&hook.NVIDIAContainerRuntime)
+	if err != nil {
+		log.Panicln("invalid nvml-throttle config:", err)
+	}
+
+	slot, err := throttle.Acquire()
+	if err != nil {
+		log.Printf("WARNING [%v]: %v", events.NVMLThrottleBypassed, err)
+		return nil
+	}
+	if err := slot.KeepOnExec(); err != nil {
+		log.Printf("WARNING [%v]: %v", events.NVMLThrottleBypassed, err)
+	}
+	return slot
+}
+
 func usage() {
 	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
 	flag.PrintDefaults()
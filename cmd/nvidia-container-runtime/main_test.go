@@ -20,7 +20,6 @@ import (
 const (
 	nvidiaRuntime            = "nvidia-container-runtime"
 	nvidiaHook               = "nvidia-container-runtime-hook"
-	bundlePathSuffix         = "test/output/bundle/"
 	specFile                 = "config.json"
 	unmodifiedSpecFileSuffix = "test/input/test_spec.json"
 )
@@ -68,26 +67,18 @@ func TestMain(m *testing.M) {
 	}
 
 	// RUN TESTS
-	exitCode := m.Run()
-
-	// TEST CLEANUP
-	os.Remove(specFile)
-
-	os.Exit(exitCode)
+	os.Exit(m.Run())
 }
 
 // case 1) nvidia-container-runtime run --bundle
 // case 2) nvidia-container-runtime create --bundle
 //   - Confirm the runtime handles bad input correctly
 func TestBadInput(t *testing.T) {
-	err := cfg.generateNewRuntimeSpec()
-	if err != nil {
-		t.Fatal(err)
-	}
+	cfg.generateNewRuntimeSpec(t)
 
 	cmdCreate := exec.Command(nvidiaRuntime, "create", "--bundle")
 	t.Logf("executing: %s\n", strings.Join(cmdCreate.Args, " "))
-	err = cmdCreate.Run()
+	err := cmdCreate.Run()
 	require.Error(t, err, "runtime should return an error")
 }
 
@@ -97,28 +88,25 @@ func TestBadInput(t *testing.T) {
 // case 2) nvidia-container-runtime create --bundle <bundle-name> <ctr-name>
 //   - Confirm the runtime inserts the NVIDIA prestart hook correctly
 func TestGoodInput(t *testing.T) {
-	err := cfg.generateNewRuntimeSpec()
-	if err != nil {
-		t.Fatalf("error generating runtime spec: %v", err)
-	}
+	bundle := cfg.generateNewRuntimeSpec(t)
 
-	cmdRun := exec.Command(nvidiaRuntime, "run", "--bundle", cfg.bundlePath(), "testcontainer")
+	cmdRun := exec.Command(nvidiaRuntime, "run", "--bundle", bundle, "testcontainer")
 	t.Logf("executing: %s\n", strings.Join(cmdRun.Args, " "))
 	output, err := cmdRun.CombinedOutput()
 	require.NoErrorf(t, err, "runtime should not return an error", "output=%v", string(output))
 
 	// Check config.json and confirm there are no hooks
-	spec, err := cfg.getRuntimeSpec()
+	spec, err := getRuntimeSpec(bundle)
 	require.NoError(t, err, "should be no errors when reading and parsing spec from config.json")
 	require.Empty(t, spec.Hooks, "there should be no hooks in config.json")
 
-	cmdCreate := exec.Command(nvidiaRuntime, "create", "--bundle", cfg.bundlePath(), "testcontainer")
+	cmdCreate := exec.Command(nvidiaRuntime, "create", "--bundle", bundle, "testcontainer")
 	t.Logf("executing: %s\n", strings.Join(cmdCreate.Args, " "))
 	err = cmdCreate.Run()
 	require.NoError(t, err, "runtime should not return an error")
 
 	// Check config.json for NVIDIA prestart hook
-	spec, err = cfg.getRuntimeSpec()
+	spec, err = getRuntimeSpec(bundle)
 	require.NoError(t, err, "should be no errors when reading and parsing spec from config.json")
 	require.NotEmpty(t, spec.Hooks, "there should be hooks in config.json")
 	require.Equal(t, 1, nvidiaHookCount(spec.Hooks), "exactly one nvidia prestart hook should be inserted correctly into config.json")
@@ -126,13 +114,9 @@ func TestGoodInput(t *testing.T) {
 
 // NVIDIA prestart hook already present in config file
 func TestDuplicateHook(t *testing.T) {
-	err := cfg.generateNewRuntimeSpec()
-	if err != nil {
-		t.Fatal(err)
-	}
+	bundle := cfg.generateNewRuntimeSpec(t)
 
-	var spec specs.Spec
-	spec, err = cfg.getRuntimeSpec()
+	spec, err := getRuntimeSpec(bundle)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -147,7 +131,7 @@ func TestDuplicateHook(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	jsonFile, err := os.OpenFile(cfg.specFilePath(), os.O_RDWR, 0644)
+	jsonFile, err := os.OpenFile(specFilePath(bundle), os.O_RDWR, 0644)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -157,13 +141,13 @@ func TestDuplicateHook(t *testing.T) {
 	}
 
 	// Test how runtime handles already existing prestart hook in config.json
-	cmdCreate := exec.Command(nvidiaRuntime, "create", "--bundle", cfg.bundlePath(), "testcontainer")
+	cmdCreate := exec.Command(nvidiaRuntime, "create", "--bundle", bundle, "testcontainer")
 	t.Logf("executing: %s\n", strings.Join(cmdCreate.Args, " "))
 	output, err := cmdCreate.CombinedOutput()
 	require.NoErrorf(t, err, "runtime should not return an error", "output=%v", string(output))
 
 	// Check config.json for NVIDIA prestart hook
-	spec, err = cfg.getRuntimeSpec()
+	spec, err = getRuntimeSpec(bundle)
 	require.NoError(t, err, "should be no errors when reading and parsing spec from config.json")
 	require.NotEmpty(t, spec.Hooks, "there should be hooks in config.json")
 	require.Equal(t, 1, nvidiaHookCount(spec.Hooks), "exactly one nvidia prestart hook should be inserted correctly into config.json")
@@ -176,8 +160,8 @@ func addNVIDIAHook(spec *specs.Spec) error {
 	return m.Modify(spec)
 }
 
-func (c testConfig) getRuntimeSpec() (specs.Spec, error) {
-	filePath := c.specFilePath()
+func getRuntimeSpec(bundle string) (specs.Spec, error) {
+	filePath := specFilePath(bundle)
 
 	var spec specs.Spec
 	jsonFile, err := os.OpenFile(filePath, os.O_RDWR, 0644)
@@ -204,32 +188,23 @@ func (c testConfig) getRuntimeSpec() (specs.Spec, error) {
 	return spec, err
 }
 
-func (c testConfig) bundlePath() string {
-	return filepath.Join(c.root, bundlePathSuffix)
-}
-
-func (c testConfig) specFilePath() string {
-	return filepath.Join(c.bundlePath(), specFile)
+func specFilePath(bundle string) string {
+	return filepath.Join(bundle, specFile)
 }
 
 func (c testConfig) unmodifiedSpecFile() string {
 	return filepath.Join(c.root, unmodifiedSpecFileSuffix)
 }
 
-func (c testConfig) generateNewRuntimeSpec() error {
-	var err error
+// generateNewRuntimeSpec creates a bundle in a temporary directory that contains a copy of the
+// unmodified spec and returns the path of the bundle.
+func (c testConfig) generateNewRuntimeSpec(t *testing.T) string {
+	bundle := t.TempDir()
 
-	err = os.MkdirAll(c.bundlePath(), 0755)
-	if err != nil {
-		return err
-	}
+	cmd := exec.Command("cp", c.unmodifiedSpecFile(), specFilePath(bundle))
+	require.NoError(t, cmd.Run())
 
-	cmd := exec.Command("cp", c.unmodifiedSpecFile(), c.specFilePath())
-	err = cmd.Run()
-	if err != nil {
-		return err
-	}
-	return nil
+	return bundle
 }
 
 // Return number of valid NVIDIA prestart hooks in runtime spec
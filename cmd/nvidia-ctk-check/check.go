@@ -0,0 +1,331 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/ldcache"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	visibleDevicesEnvvar = "NVIDIA_VISIBLE_DEVICES"
+
+	// defaultDriverCapabilities are the capabilities injected if NVIDIA_DRIVER_CAPABILITIES is not set.
+	defaultDriverCapabilities = "compute,utility"
+
+	statusPass = "pass"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// libraryDirs are the directories that are searched for libraries that are not in the ldcache.
+var libraryDirs = append(lookup.LibraryDirs(), "/usr/lib", "/lib")
+
+// binaryDirs are the directories that are searched for binaries.
+var binaryDirs = []string{
+	"/usr/local/sbin",
+	"/usr/local/bin",
+	"/usr/sbin",
+	"/usr/bin",
+	"/sbin",
+	"/bin",
+}
+
+// requiredFiles defines the libraries and binaries that are expected in the container for each
+// driver capability. Capabilities that are not listed are not checked.
+var requiredFiles = map[image.DriverCapability]struct {
+	libraries []string
+	binaries  []string
+}{
+	image.DriverCapabilityCompute: {
+		libraries: []string{"libcuda.so.1", "libnvidia-ptxjitcompiler.so.1"},
+	},
+	image.DriverCapabilityUtility: {
+		libraries: []string{"libnvidia-ml.so.1"},
+		binaries:  []string{"nvidia-smi"},
+	},
+	image.DriverCapabilityVideo: {
+		libraries: []string{"libnvcuvid.so.1", "libnvidia-encode.so.1"},
+	},
+	image.DriverCapabilityGraphics: {
+		libraries: []string{"libGLX_nvidia.so.0", "libEGL_nvidia.so.0"},
+	},
+}
+
+// check is the result of a single check.
+type check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// report is the result of all checks performed in a container.
+type report struct {
+	Passed                bool     `json:"passed"`
+	DriverVersion         string   `json:"driverVersion,omitempty"`
+	RequestedDevices      []string `json:"requestedDevices"`
+	RequestedCapabilities []string `json:"requestedCapabilities"`
+	Checks                []check  `json:"checks"`
+}
+
+// checker checks the GPU support in a container filesystem.
+type checker struct {
+	logger *logrus.Logger
+	// root is the root of the container filesystem. This is / when run in a container.
+	root string
+	// env is the environment of the container.
+	env []string
+}
+
+// run performs all checks and returns the report.
+func (c *checker) run() (*report, error) {
+	cudaImage, err := image.NewCUDAImageFromEnv(c.env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container environment: %v", err)
+	}
+
+	r := &report{
+		Passed:                true,
+		RequestedDevices:      cudaImage.DevicesFromEnvvars(visibleDevicesEnvvar).List(),
+		RequestedCapabilities: getCapabilities(cudaImage),
+	}
+
+	r.add(c.checkDevices(r.RequestedDevices))
+	libcuda, version := c.checkLibcuda()
+	r.add(libcuda)
+	r.DriverVersion = version
+	for _, capability := range r.RequestedCapabilities {
+		r.add(c.checkCapability(image.DriverCapability(capability), version))
+	}
+
+	return r, nil
+}
+
+// add adds the specified check to the report. The report fails if any check fails.
+func (r *report) add(c check) {
+	if c.Status == statusFail {
+		r.Passed = false
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// getCapabilities returns the sorted list of requested capabilities. The value all is expanded.
+func getCapabilities(cudaImage image.CUDA) []string {
+	value, ok := cudaImage["NVIDIA_DRIVER_CAPABILITIES"]
+	if !ok || value == "" {
+		value = defaultDriverCapabilities
+	}
+
+	var capabilities []string
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(value, ",") {
+		c = strings.TrimSpace(c)
+		if c == string(image.DriverCapabilityAll) {
+			for all := range requiredFiles {
+				if !seen[string(all)] {
+					seen[string(all)] = true
+					capabilities = append(capabilities, string(all))
+				}
+			}
+			continue
+		}
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		capabilities = append(capabilities, c)
+	}
+	sort.Strings(capabilities)
+	return capabilities
+}
+
+// checkDevices checks that the control device and at least one GPU device node are visible if
+// devices are requested.
+func (c *checker) checkDevices(requested []string) check {
+	result := check{
+		Name: "devices",
+	}
+	if len(requested) == 0 {
+		result.Status = statusSkip
+		result.Message = "no devices requested"
+		return result
+	}
+
+	if _, err := os.Stat(filepath.Join(c.root, "/dev/nvidiactl")); err != nil {
+		result.Status = statusFail
+		result.Message = "/dev/nvidiactl is not visible"
+		return result
+	}
+
+	gpus, _ := filepath.Glob(filepath.Join(c.root, "/dev/nvidia[0-9]*"))
+	if len(requested) == 1 && requested[0] == "" {
+		// The none value requests the driver without any GPUs.
+		result.Status = statusPass
+		result.Message = fmt.Sprintf("no GPUs requested; %d GPU device nodes visible", len(gpus))
+		return result
+	}
+	if len(gpus) == 0 {
+		result.Status = statusFail
+		result.Message = fmt.Sprintf("no GPU device nodes visible for requested devices %v", strings.Join(requested, ","))
+		return result
+	}
+
+	var names []string
+	for _, gpu := range gpus {
+		names = append(names, strings.TrimPrefix(gpu, c.root))
+	}
+	result.Status = statusPass
+	result.Message = fmt.Sprintf("%d GPU device nodes visible: %v", len(gpus), strings.Join(names, ", "))
+	return result
+}
+
+// checkLibcuda checks that libcuda.so.1 resolves and returns the driver version determined from
+// the name of the resolved library.
+func (c *checker) checkLibcuda() (check, string) {
+	result := check{
+		Name: "libcuda",
+	}
+
+	path, err := c.resolveLibrary("libcuda.so.1")
+	if err != nil {
+		result.Status = statusFail
+		result.Message = err.Error()
+		return result, ""
+	}
+
+	version := strings.TrimPrefix(filepath.Base(path), "libcuda.so.")
+	if version == "1" {
+		version = ""
+	}
+	result.Status = statusPass
+	result.Message = fmt.Sprintf("libcuda.so.1 resolves to %v", strings.TrimPrefix(path, c.root))
+	return result, version
+}
+
+// checkCapability checks that the libraries and binaries for the specified capability are present.
+// If the driver version is known, the libraries are also checked to match this version.
+func (c *checker) checkCapability(capability image.DriverCapability, version string) check {
+	result := check{
+		Name: "capability:" + string(capability),
+	}
+	required, ok := requiredFiles[capability]
+	if !ok {
+		result.Status = statusSkip
+		result.Message = "no checks defined for capability"
+		return result
+	}
+
+	var missing []string
+	for _, library := range required.libraries {
+		path, err := c.resolveLibrary(library)
+		if err != nil {
+			missing = append(missing, library)
+			continue
+		}
+		if version != "" && !strings.HasSuffix(path, "."+version) {
+			missing = append(missing, fmt.Sprintf("%v (version mismatch: %v)", library, filepath.Base(path)))
+		}
+	}
+	for _, binary := range required.binaries {
+		if !c.hasBinary(binary) {
+			missing = append(missing, binary)
+		}
+	}
+
+	if len(missing) > 0 {
+		result.Status = statusFail
+		result.Message = "missing " + strings.Join(missing, ", ")
+		return result
+	}
+	result.Status = statusPass
+	result.Message = "found " + strings.Join(append(required.libraries, required.binaries...), ", ")
+	return result
+}
+
+// resolveLibrary resolves the specified library using the ldcache in the container. If the library
+// is not in the ldcache, the directories in LD_LIBRARY_PATH and the standard library directories
+// are searched. The returned path is the result of resolving all symlinks.
+func (c *checker) resolveLibrary(name string) (string, error) {
+	if cache, err := ldcache.New(c.logger, c.root); err == nil {
+		if _, libs64 := cache.Lookup(name); len(libs64) > 0 {
+			return libs64[0], nil
+		}
+	}
+
+	dirs := filepath.SplitList(c.getenv("LD_LIBRARY_PATH"))
+	dirs = append(dirs, libraryDirs...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path, err := filepath.EvalSymlinks(filepath.Join(c.root, dir, name))
+		if err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%v not found in ldcache or library path", name)
+}
+
+// hasBinary checks whether the specified binary exists in the PATH of the container.
+func (c *checker) hasBinary(name string) bool {
+	dirs := filepath.SplitList(c.getenv("PATH"))
+	dirs = append(dirs, binaryDirs...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(c.root, dir, name))
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getenv returns the value of the specified variable in the container environment.
+func (c *checker) getenv(key string) string {
+	for _, e := range c.env {
+		if strings.HasPrefix(e, key+"=") {
+			return strings.TrimPrefix(e, key+"=")
+		}
+	}
+	return ""
+}
+
+// printText writes the report in a human-readable format.
+func (r *report) printText(w io.Writer) {
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "[%v] %v: %v\n", strings.ToUpper(c.Status), c.Name, c.Message)
+	}
+	if r.DriverVersion != "" {
+		fmt.Fprintf(w, "Driver version: %v\n", r.DriverVersion)
+	}
+	if r.Passed {
+		fmt.Fprintln(w, "All checks passed")
+	} else {
+		fmt.Fprintln(w, "Some checks failed")
+	}
+}
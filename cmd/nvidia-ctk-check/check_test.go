@@ -0,0 +1,143 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/driverroot"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description      string
+		createRoot       bool
+		modifyRoot       func(string) error
+		env              []string
+		expectedPassed   bool
+		expectedVersion  string
+		expectedStatuses map[string]string
+	}{
+		{
+			description:     "complete driver root passes",
+			createRoot:      true,
+			env:             []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=all"},
+			expectedPassed:  true,
+			expectedVersion: "550.54",
+			expectedStatuses: map[string]string{
+				"devices":             statusPass,
+				"libcuda":             statusPass,
+				"capability:compute":  statusPass,
+				"capability:graphics": statusPass,
+				"capability:utility":  statusPass,
+				"capability:video":    statusPass,
+			},
+		},
+		{
+			description:     "default capabilities are checked",
+			createRoot:      true,
+			env:             []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			expectedPassed:  true,
+			expectedVersion: "550.54",
+			expectedStatuses: map[string]string{
+				"devices":            statusPass,
+				"libcuda":            statusPass,
+				"capability:compute": statusPass,
+				"capability:utility": statusPass,
+			},
+		},
+		{
+			description:     "unchecked capability is skipped",
+			createRoot:      true,
+			env:             []string{"NVIDIA_DRIVER_CAPABILITIES=compute,display"},
+			expectedPassed:  true,
+			expectedVersion: "550.54",
+			expectedStatuses: map[string]string{
+				"devices":            statusSkip,
+				"libcuda":            statusPass,
+				"capability:compute": statusPass,
+				"capability:display": statusSkip,
+			},
+		},
+		{
+			description:    "empty root fails",
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=utility"},
+			expectedPassed: false,
+			expectedStatuses: map[string]string{
+				"devices":            statusFail,
+				"libcuda":            statusFail,
+				"capability:utility": statusFail,
+			},
+		},
+		{
+			description: "mismatched library version fails",
+			createRoot:  true,
+			modifyRoot: func(root string) error {
+				if err := os.WriteFile(filepath.Join(root, "/usr/lib64/libnvidia-ml.so.535.104"), nil, 0755); err != nil {
+					return err
+				}
+				link := filepath.Join(root, "/usr/lib64/libnvidia-ml.so.1")
+				if err := os.Remove(link); err != nil {
+					return err
+				}
+				return os.Symlink("libnvidia-ml.so.535.104", link)
+			},
+			env:             []string{"NVIDIA_DRIVER_CAPABILITIES=utility"},
+			expectedPassed:  false,
+			expectedVersion: "550.54",
+			expectedStatuses: map[string]string{
+				"devices":            statusSkip,
+				"libcuda":            statusPass,
+				"capability:utility": statusFail,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			if tc.createRoot {
+				require.NoError(t, driverroot.Create(root, driverroot.WithDriverVersion("550.54"), driverroot.WithGPUs(2)))
+			}
+			if tc.modifyRoot != nil {
+				require.NoError(t, tc.modifyRoot(root))
+			}
+
+			c := checker{
+				logger: logger,
+				root:   root,
+				env:    tc.env,
+			}
+			r, err := c.run()
+			require.NoError(t, err)
+
+			statuses := make(map[string]string)
+			for _, check := range r.Checks {
+				statuses[check.Name] = check.Status
+			}
+			require.Equal(t, tc.expectedStatuses, statuses)
+			require.Equal(t, tc.expectedPassed, r.Passed)
+			require.Equal(t, tc.expectedVersion, r.DriverVersion)
+		})
+	}
+}
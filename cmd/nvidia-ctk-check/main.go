@@ -0,0 +1,73 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	formatflag = flag.String("format", "text", "the format of the report [text | json]")
+	rootflag   = flag.String("root", "/", "the root of the filesystem to check")
+)
+
+// nvidia-ctk-check is a statically linked binary that is injected into containers that request the
+// utility capability. It checks that the requested GPU support is available in the container and
+// exits with a non-zero exit code if any of the checks fail. This allows it to be used as an
+// initContainer or as a preflight check in an entrypoint.
+func main() {
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	c := checker{
+		logger: logger,
+		root:   *rootflag,
+		env:    os.Environ(),
+	}
+	r, err := c.run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	switch *formatflag {
+	case "json":
+		output, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to render report: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(output))
+	case "text":
+		r.printText(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q\n", *formatflag)
+		os.Exit(2)
+	}
+
+	if !r.Passed {
+		os.Exit(1)
+	}
+}
@@ -18,6 +18,10 @@ package cdi
 
 import (
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/generate"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/index"
+	packagebundle "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/package"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/serve"
+	verifybundle "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/verify-bundle"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -44,6 +48,10 @@ func (m command) build() *cli.Command {
 
 	hook.Subcommands = []*cli.Command{
 		generate.NewCommand(m.logger),
+		index.NewCommand(m.logger),
+		packagebundle.NewCommand(m.logger),
+		serve.NewCommand(m.logger),
+		verifybundle.NewCommand(m.logger),
 	}
 
 	return &hook
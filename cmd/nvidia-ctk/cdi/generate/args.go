@@ -0,0 +1,86 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/urfave/cli/v2"
+)
+
+// argsExcluded are the flags that are not recorded for a generated specification since these do
+// not affect its contents.
+var argsExcluded = map[string]bool{
+	"output":         true,
+	"watch":          true,
+	"watch-interval": true,
+}
+
+// ArgsFile returns the path of the file in which the arguments used to generate the CDI
+// specification at the specified path are recorded. The file is hidden and does not have a
+// CDI specification extension so that it is not loaded as a specification.
+func ArgsFile(output string) string {
+	return filepath.Join(filepath.Dir(output), "."+filepath.Base(output)+".args")
+}
+
+// LoadArgs loads the arguments used to generate the CDI specification at the specified path.
+// These can be passed to 'nvidia-ctk cdi generate' to regenerate the specification.
+func LoadArgs(output string) ([]string, error) {
+	contents, err := os.ReadFile(ArgsFile(output))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if err := json.Unmarshal(contents, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %v", err)
+	}
+	return args, nil
+}
+
+// saveArgs records the specified arguments for the CDI specification at the specified path.
+func saveArgs(output string, args []string) error {
+	contents, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	_, err = engine.WriteFile(ArgsFile(output), contents)
+	return err
+}
+
+// getArgs returns the flags that were set for the command as arguments.
+func getArgs(c *cli.Context) []string {
+	var args []string
+	for _, flag := range c.Command.Flags {
+		name := flag.Names()[0]
+		if argsExcluded[name] || !c.IsSet(name) {
+			continue
+		}
+		switch value := c.Value(name).(type) {
+		case cli.StringSlice:
+			for _, v := range value.Value() {
+				args = append(args, fmt.Sprintf("--%v=%v", name, v))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%v=%v", name, value))
+		}
+	}
+	return args
+}
@@ -0,0 +1,68 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"path/filepath"
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestArgs(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	var args []string
+	c := command{logger: logger}.build()
+	c.Before = nil
+	c.Action = func(c *cli.Context) error {
+		args = getArgs(c)
+		return nil
+	}
+	app := cli.NewApp()
+	app.Commands = []*cli.Command{c}
+
+	err := app.Run([]string{
+		"nvidia-ctk", "generate",
+		"--output", "/etc/cdi/nvidia.yaml",
+		"--driver-root=/run/nvidia/driver",
+		"--device-name-strategy", "uuid",
+		"--include-driver-binaries", "nvidia-smi",
+		"--include-driver-binaries", "nvidia-debugdump",
+		"--mig-device-aliases",
+		"--watch",
+	})
+	require.NoError(t, err)
+	require.Equal(t,
+		[]string{
+			"--device-name-strategy=uuid",
+			"--driver-root=/run/nvidia/driver",
+			"--include-driver-binaries=nvidia-smi",
+			"--include-driver-binaries=nvidia-debugdump",
+			"--mig-device-aliases=true",
+		},
+		args,
+	)
+
+	output := filepath.Join(t.TempDir(), "nvidia.yaml")
+	require.NoError(t, saveArgs(output, args))
+	loaded, err := LoadArgs(output)
+	require.NoError(t, err)
+	require.Equal(t, args, loaded)
+}
@@ -21,21 +21,26 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+	ctkconfig "github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/edits"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
 	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi"
 	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/spec"
-	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/transform"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
 	specs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
-	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
 )
 
 const (
-	allDeviceName = "all"
+	// stdoutOutput is the output that writes the generated CDI specification to STDOUT.
+	stdoutOutput = "-"
 )
 
 type command struct {
@@ -49,6 +54,30 @@ type config struct {
 	driverRoot         string
 	nvidiaCTKPath      string
 	mode               string
+	capabilities       string
+	checksumManifest   string
+	hookUser           string
+	hookCapabilities   cli.StringSlice
+	hookTimeout        time.Duration
+	hookFailurePolicy  string
+	disabledHooks      cli.StringSlice
+	noTimestamps       bool
+	reproducible       bool
+
+	includePersistencedSocket  bool
+	includeFabricManagerSocket bool
+	includeFirmware            bool
+	includeDriverBinaries      cli.StringSlice
+	embedNodeProperties        bool
+	deviceEnv                  cli.StringSlice
+	migDeviceAliases           bool
+	migParentDevices           bool
+
+	watch         bool
+	watchInterval time.Duration
+
+	// args are the arguments that are recorded for the generated specification.
+	args []string
 }
 
 // NewCommand constructs a generate-cdi command with the specified logger
@@ -78,7 +107,7 @@ func (m command) build() *cli.Command {
 	c.Flags = []cli.Flag{
 		&cli.StringFlag{
 			Name:        "output",
-			Usage:       "Specify the file to output the generated CDI specification to. If this is '' the specification is output to STDOUT",
+			Usage:       "Specify the file to output the generated CDI specification to. If this is '' or '-' the specification is output to STDOUT",
 			Destination: &cfg.output,
 		},
 		&cli.StringFlag{
@@ -90,7 +119,7 @@ func (m command) build() *cli.Command {
 		&cli.StringFlag{
 			Name:        "mode",
 			Aliases:     []string{"discovery-mode"},
-			Usage:       "The mode to use when discovering the available entities. One of [auto | nvml | wsl]. If mode is set to 'auto' the mode will be determined based on the system configuration.",
+			Usage:       "The mode to use when discovering the available entities. One of [auto | nvml | wsl | management | utility]. If mode is set to 'auto' the mode will be determined based on the system configuration.",
 			Value:       nvcdi.ModeAuto,
 			Destination: &cfg.mode,
 		},
@@ -110,12 +139,120 @@ func (m command) build() *cli.Command {
 			Usage:       "Specify the path to use for the nvidia-ctk in the generated CDI specification. If this is left empty, the path will be searched.",
 			Destination: &cfg.nvidiaCTKPath,
 		},
+		&cli.StringFlag{
+			Name:        "capabilities",
+			Usage:       "Specify a comma-separated list of driver capabilities to include in the generated CDI specification. Edits that are only required for other capabilities (e.g. graphics libraries) are removed. One or more of [compute | utility | video | graphics | display | ngx | compat32 | all].",
+			Value:       string(image.DriverCapabilityAll),
+			Destination: &cfg.capabilities,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-manifest",
+			Usage:       "Specify the file to which the SHA256 checksums of the files mounted by the generated CDI specification are written. If this is '' no checksum manifest is generated.",
+			Destination: &cfg.checksumManifest,
+		},
+		&cli.StringFlag{
+			Name:        "hook-user",
+			Usage:       "Specify the user (uid[:gid]) as which the nvidia-ctk hooks in the generated CDI specification are run. If this is '' the hooks are run with the privileges of the runtime.",
+			Destination: &cfg.hookUser,
+		},
+		&cli.StringSliceFlag{
+			Name:        "hook-capabilities",
+			Usage:       "Specify the capabilities that are retained when the nvidia-ctk hooks are run as the --hook-user.",
+			Value:       cli.NewStringSlice(privileges.DefaultCapabilities...),
+			Destination: &cfg.hookCapabilities,
+		},
+		&cli.DurationFlag{
+			Name:        "hook-timeout",
+			Usage:       "Specify the maximum duration (e.g. 30s) of the nvidia-ctk hooks in the generated CDI specification. If this is 0, no timeout is applied.",
+			Destination: &cfg.hookTimeout,
+		},
+		&cli.StringFlag{
+			Name:        "hook-failure-policy",
+			Usage:       "Specify how a failure or timeout of the nvidia-ctk hooks in the generated CDI specification is handled. One of [fail-closed | fail-open].",
+			Value:       string(hookpolicy.FailClosed),
+			Destination: &cfg.hookFailurePolicy,
+		},
+		&cli.StringSliceFlag{
+			Name:        "disable-hook",
+			Usage:       "Specify an nvidia-ctk hook (e.g. create-symlinks) to omit from the generated CDI specification. If this is not specified, the hooks listed in nvidia-container-runtime.hooks.disable and disable-for-mode.cdi of the config are omitted.",
+			Destination: &cfg.disabledHooks,
+		},
+		&cli.BoolFlag{
+			Name:        "reproducible",
+			Usage:       "Normalize the generated CDI specification (e.g. by cleaning paths and sorting mount options and hook arguments) so that specifications generated for identical systems are byte-identical.",
+			Destination: &cfg.reproducible,
+		},
+		&cli.BoolFlag{
+			Name:        "no-timestamps",
+			Usage:       "Omit the timestamps from the log output. The log output is always written to STDERR.",
+			Destination: &cfg.noTimestamps,
+		},
+		&cli.BoolFlag{
+			Name:        "include-persistenced-socket",
+			Usage:       "Include the nvidia-persistenced socket in the generated CDI specification if present.",
+			Value:       true,
+			Destination: &cfg.includePersistencedSocket,
+		},
+		&cli.BoolFlag{
+			Name:        "include-fabricmanager-socket",
+			Usage:       "Include the nvidia-fabricmanager socket in the generated CDI specification if present.",
+			Value:       true,
+			Destination: &cfg.includeFabricManagerSocket,
+		},
+		&cli.BoolFlag{
+			Name:        "include-firmware",
+			Usage:       "Include the GSP firmware files for the driver in the generated CDI specification.",
+			Value:       true,
+			Destination: &cfg.includeFirmware,
+		},
+		&cli.StringSliceFlag{
+			Name:        "include-driver-binaries",
+			Usage:       "Specify the driver binaries (e.g. nvidia-smi) to include in the generated CDI specification.",
+			Value:       cli.NewStringSlice(discover.DriverBinaries...),
+			Destination: &cfg.includeDriverBinaries,
+		},
+		&cli.BoolFlag{
+			Name:        "embed-node-properties",
+			Usage:       "Embed the properties of the node (driver and CUDA version, compute capability, and brand) in the generated CDI specification. This allows the NVIDIA_REQUIRE_* requirements of images to be checked against the specification.",
+			Destination: &cfg.embedNodeProperties,
+		},
+		&cli.StringSliceFlag{
+			Name:        "device-env",
+			Usage:       "Specify an environment variable template (e.g. NVIDIA_DEVICE_UUID={{.UUID}}) to add to the edits of each GPU and MIG device. The Name, Index, UUID, ParentUUID, PCIBusID, and Minor of the device can be referenced.",
+			Destination: &cfg.deviceEnv,
+		},
+		&cli.BoolFlag{
+			Name:        "mig-device-aliases",
+			Usage:       "Also include each MIG device under the names MIG-<uuid> and <gpu>:<gi>.<ci> in the generated CDI specification.",
+			Destination: &cfg.migDeviceAliases,
+		},
+		&cli.BoolFlag{
+			Name:        "mig-parent-devices",
+			Usage:       "Include a device named <gpu>:mig for each MIG-enabled GPU that includes all MIG devices of the GPU in the generated CDI specification.",
+			Destination: &cfg.migParentDevices,
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "Keep running and regenerate the CDI specification when GPUs are added or removed, MIG devices are reconfigured, or the driver is upgraded. Requires --output to be set.",
+			Destination: &cfg.watch,
+		},
+		&cli.DurationFlag{
+			Name:        "watch-interval",
+			Usage:       "The interval at which the driver state is checked for changes when --watch is specified",
+			Value:       defaultWatchInterval,
+			Destination: &cfg.watchInterval,
+		},
 	}
 
 	return &c
 }
 
 func (m command) validateFlags(c *cli.Context, cfg *config) error {
+	// The log output is written to STDERR so that the specification can be piped to other tools.
+	m.logger.SetOutput(os.Stderr)
+	if cfg.noTimestamps {
+		m.logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	}
 
 	cfg.format = strings.ToLower(cfg.format)
 	switch cfg.format {
@@ -131,6 +268,7 @@ func (m command) validateFlags(c *cli.Context, cfg *config) error {
 	case nvcdi.ModeNvml:
 	case nvcdi.ModeWsl:
 	case nvcdi.ModeManagement:
+	case nvcdi.ModeUtility:
 	default:
 		return fmt.Errorf("invalid discovery mode: %v", cfg.mode)
 	}
@@ -140,7 +278,48 @@ func (m command) validateFlags(c *cli.Context, cfg *config) error {
 		return err
 	}
 
+	if _, err := parseCapabilities(cfg.capabilities); err != nil {
+		return err
+	}
+
+	if _, err := privileges.New(cfg.hookUser, cfg.hookCapabilities.Value()); err != nil {
+		return err
+	}
+
+	if _, err := hookpolicy.New(cfg.hookTimeout, cfg.hookFailurePolicy); err != nil {
+		return err
+	}
+
+	if !c.IsSet("disable-hook") {
+		toolkitConfig, err := ctkconfig.GetConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		cfg.disabledHooks = *cli.NewStringSlice(toolkitConfig.NVIDIAContainerRuntimeConfig.DisabledHooks("cdi")...)
+	}
+	if err := discover.ValidateNvidiaCTKHooks(cfg.disabledHooks.Value()); err != nil {
+		return err
+	}
+
+	if err := validateDriverBinaries(cfg.includeDriverBinaries.Value()); err != nil {
+		return err
+	}
+
+	if err := nvcdi.ValidateDeviceEnvTemplates(cfg.deviceEnv.Value()...); err != nil {
+		return err
+	}
+
+	if cfg.watch {
+		if cfg.output == "" || cfg.output == stdoutOutput {
+			return fmt.Errorf("an output file must be specified when watching for changes")
+		}
+		if cfg.watchInterval <= 0 {
+			return fmt.Errorf("invalid watch interval: %v", cfg.watchInterval)
+		}
+	}
+
 	cfg.nvidiaCTKPath = discover.FindNvidiaCTK(m.logger, cfg.nvidiaCTKPath)
+	cfg.args = getArgs(c)
 
 	if outputFileFormat := formatFromFilename(cfg.output); outputFileFormat != "" {
 		m.logger.Debugf("Inferred output format as %q from output file name", outputFileFormat)
@@ -155,13 +334,32 @@ func (m command) validateFlags(c *cli.Context, cfg *config) error {
 }
 
 func (m command) run(c *cli.Context, cfg *config) error {
+	if cfg.watch {
+		return m.watch(cfg)
+	}
+	return m.generate(cfg)
+}
+
+// generate generates the CDI specification (and checksum manifest) and writes it to the configured output.
+func (m command) generate(cfg *config) error {
 	spec, err := m.generateSpec(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to generate CDI spec: %v", err)
 	}
 	m.logger.Infof("Generated CDI spec with version %v", spec.Raw().Version)
 
-	if cfg.output == "" {
+	if cfg.checksumManifest != "" {
+		manifest, err := checksum.New(getMountHostPaths(spec.Raw()))
+		if err != nil {
+			return fmt.Errorf("failed to generate checksum manifest: %v", err)
+		}
+		if err := manifest.Save(cfg.checksumManifest); err != nil {
+			return fmt.Errorf("failed to save checksum manifest: %v", err)
+		}
+		m.logger.Infof("Generated checksum manifest for %d files at %v", len(manifest.Files), cfg.checksumManifest)
+	}
+
+	if cfg.output == "" || cfg.output == stdoutOutput {
 		_, err := spec.WriteTo(os.Stdout)
 		if err != nil {
 			return fmt.Errorf("failed to write CDI spec to STDOUT: %v", err)
@@ -169,7 +367,29 @@ func (m command) run(c *cli.Context, cfg *config) error {
 		return nil
 	}
 
-	return spec.Save(cfg.output)
+	if err := spec.Save(cfg.output); err != nil {
+		return err
+	}
+	// The arguments are recorded so that the specification can be regenerated with the same
+	// options (e.g. by 'nvidia-ctk system reset-gpu').
+	if err := saveArgs(cfg.output, cfg.args); err != nil {
+		m.logger.Warningf("Failed to record arguments for CDI specification %v: %v", cfg.output, err)
+	}
+	return nil
+}
+
+// getMountHostPaths returns the host paths of all mounts in the specified CDI specification.
+func getMountHostPaths(raw *specs.Spec) []string {
+	var paths []string
+	for _, m := range raw.ContainerEdits.Mounts {
+		paths = append(paths, m.HostPath)
+	}
+	for _, d := range raw.Devices {
+		for _, m := range d.ContainerEdits.Mounts {
+			paths = append(paths, m.HostPath)
+		}
+	}
+	return paths
 }
 
 func formatFromFilename(filename string) string {
@@ -185,86 +405,124 @@ func formatFromFilename(filename string) string {
 }
 
 func (m command) generateSpec(cfg *config) (spec.Interface, error) {
-	deviceNamer, err := nvcdi.NewDeviceNamer(cfg.deviceNameStrategy)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create device namer: %v", err)
-	}
-
-	nvmllib := nvml.New()
-	if r := nvmllib.Init(); r != nvml.SUCCESS {
-		return nil, r
+	class := "gpu"
+	if cfg.mode == nvcdi.ModeUtility {
+		class = "utility"
 	}
-	defer nvmllib.Shutdown()
-
-	devicelib := device.New(device.WithNvml(nvmllib))
 
-	cdilib := nvcdi.New(
+	s, err := nvcdi.GenerateSpec(
 		nvcdi.WithLogger(m.logger),
 		nvcdi.WithDriverRoot(cfg.driverRoot),
 		nvcdi.WithNVIDIACTKPath(cfg.nvidiaCTKPath),
-		nvcdi.WithDeviceNamer(deviceNamer),
-		nvcdi.WithDeviceLib(devicelib),
-		nvcdi.WithNvmlLib(nvmllib),
+		nvcdi.WithDeviceNameStrategy(cfg.deviceNameStrategy),
 		nvcdi.WithMode(string(cfg.mode)),
+		nvcdi.WithVendor("nvidia.com"),
+		nvcdi.WithClass(class),
+		nvcdi.WithSpecFormat(cfg.format),
+		nvcdi.WithPersistencedSocket(cfg.includePersistencedSocket),
+		nvcdi.WithFabricManagerSocket(cfg.includeFabricManagerSocket),
+		nvcdi.WithFirmware(cfg.includeFirmware),
+		nvcdi.WithDriverBinaries(cfg.includeDriverBinaries.Value()...),
+		nvcdi.WithDeviceEnvTemplates(cfg.deviceEnv.Value()...),
+		nvcdi.WithMIGDeviceAliases(cfg.migDeviceAliases),
+		nvcdi.WithMIGParentDevices(cfg.migParentDevices),
 	)
-
-	deviceSpecs, err := cdilib.GetAllDeviceSpecs()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create device CDI specs: %v", err)
-	}
-	var hasAll bool
-	for _, deviceSpec := range deviceSpecs {
-		if deviceSpec.Name == allDeviceName {
-			hasAll = true
-			break
-		}
+		return nil, err
 	}
-	if !hasAll {
-		allDevice, err := MergeDeviceSpecs(deviceSpecs, allDeviceName)
+
+	if cfg.embedNodeProperties {
+		properties, err := getNodeProperties()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create CDI specification for %q device: %v", allDeviceName, err)
+			return nil, fmt.Errorf("failed to get node properties: %v", err)
 		}
-		deviceSpecs = append(deviceSpecs, allDevice)
+		m.logger.Infof("Embedding node properties: %v", properties)
+		s.Raw().ContainerEdits.Env = append(s.Raw().ContainerEdits.Env, requirements.NodePropertiesEnvvar+"="+properties.String())
 	}
 
-	commonEdits, err := cdilib.GetCommonEdits()
+	capabilities, err := parseCapabilities(cfg.capabilities)
+	if err != nil {
+		return nil, err
+	}
+	err = transform.NewCapabilitiesTransformer(capabilities).Transform(s.Raw())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create edits common for entities: %v", err)
+		return nil, fmt.Errorf("failed to remove edits for unused capabilities: %v", err)
 	}
 
-	return spec.New(
-		spec.WithVendor("nvidia.com"),
-		spec.WithClass("gpu"),
-		spec.WithDeviceSpecs(deviceSpecs),
-		spec.WithEdits(*commonEdits.ContainerEdits),
-		spec.WithFormat(cfg.format),
-	)
-}
+	err = transform.NewDisabledHooksTransformer(cfg.disabledHooks.Value()...).Transform(s.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove disabled hooks: %v", err)
+	}
 
-// MergeDeviceSpecs creates a device with the specified name which combines the edits from the previous devices.
-// If a device of the specified name already exists, an error is returned.
-func MergeDeviceSpecs(deviceSpecs []specs.Device, mergedDeviceName string) (specs.Device, error) {
-	if err := cdi.ValidateDeviceName(mergedDeviceName); err != nil {
-		return specs.Device{}, fmt.Errorf("invalid device name %q: %v", mergedDeviceName, err)
+	hookPrivileges, err := privileges.New(cfg.hookUser, cfg.hookCapabilities.Value())
+	if err != nil {
+		return nil, err
 	}
-	for _, d := range deviceSpecs {
-		if d.Name == mergedDeviceName {
-			return specs.Device{}, fmt.Errorf("device %q already exists", mergedDeviceName)
-		}
+	err = transform.NewHookPrivilegesTransformer(hookPrivileges).Transform(s.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update hook privileges: %v", err)
+	}
+
+	hookPolicy, err := hookpolicy.New(cfg.hookTimeout, cfg.hookFailurePolicy)
+	if err != nil {
+		return nil, err
+	}
+	err = transform.NewHookPolicyTransformer(hookPolicy).Transform(s.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update hook policy: %v", err)
+	}
+
+	// The devices and edits are ordered so that the generated spec can be compared across runs.
+	orderer := transform.NewSortTransformer()
+	if cfg.reproducible {
+		orderer = transform.NewReproducibleTransformer()
+	}
+	err = orderer.Transform(s.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("failed to order CDI spec: %v", err)
 	}
 
-	mergedEdits := edits.NewContainerEdits()
+	return s, nil
+}
 
-	for _, d := range deviceSpecs {
-		edit := cdi.ContainerEdits{
-			ContainerEdits: &d.ContainerEdits,
+// parseCapabilities parses a comma-separated list of driver capabilities.
+func parseCapabilities(value string) (image.DriverCapabilities, error) {
+	capabilities := make(image.DriverCapabilities)
+	for _, c := range strings.Split(value, ",") {
+		capability := image.DriverCapability(strings.ToLower(strings.TrimSpace(c)))
+		switch capability {
+		case image.DriverCapabilityAll:
+		case image.DriverCapabilityCompat32:
+		case image.DriverCapabilityCompute:
+		case image.DriverCapabilityDisplay:
+		case image.DriverCapabilityGraphics:
+		case image.DriverCapabilityNgx:
+		case image.DriverCapabilityUtility:
+		case image.DriverCapabilityVideo:
+		default:
+			return nil, fmt.Errorf("invalid driver capability: %q", c)
 		}
-		mergedEdits.Append(&edit)
+		capabilities[capability] = true
 	}
+	return capabilities, nil
+}
 
-	merged := specs.Device{
-		Name:           mergedDeviceName,
-		ContainerEdits: *mergedEdits.ContainerEdits,
+// validateDriverBinaries checks that the specified binaries are driver binaries.
+func validateDriverBinaries(binaries []string) error {
+	known := make(map[string]bool)
+	for _, b := range discover.DriverBinaries {
+		known[b] = true
+	}
+	for _, b := range binaries {
+		if !known[b] {
+			return fmt.Errorf("invalid driver binary %q; supported binaries are %v", b, discover.DriverBinaries)
+		}
 	}
-	return merged, nil
+	return nil
+}
+
+// MergeDeviceSpecs creates a device with the specified name which combines the edits from the previous devices.
+// If a device of the specified name already exists, an error is returned.
+func MergeDeviceSpecs(deviceSpecs []specs.Device, mergedDeviceName string) (specs.Device, error) {
+	return nvcdi.MergeDeviceSpecs(deviceSpecs, mergedDeviceName)
 }
@@ -115,3 +115,35 @@ func TestMergeDeviceSpecs(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDriverBinaries(t *testing.T) {
+	testCases := []struct {
+		description   string
+		binaries      []string
+		expectedError bool
+	}{
+		{
+			description: "no binaries",
+		},
+		{
+			description: "driver binaries are valid",
+			binaries:    []string{"nvidia-smi", "nvidia-debugdump"},
+		},
+		{
+			description:   "unknown binary is invalid",
+			binaries:      []string{"nvidia-smi", "nvidia-ctk"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateDriverBinaries(tc.binaries)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+)
+
+// getNodeProperties queries NVML for the properties of the node against which the NVIDIA_REQUIRE_*
+// requirements of images are checked. The compute capability and brand are those of the first GPU.
+func getNodeProperties() (*requirements.Properties, error) {
+	if r := nvml.Init(); r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvml.Shutdown()
+
+	driverVersion, r := nvml.SystemGetDriverVersion()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get driver version: %v", r)
+	}
+	cudaVersion, r := nvml.SystemGetCudaDriverVersion()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get CUDA version: %v", r)
+	}
+
+	properties := requirements.Properties{
+		Driver: driverVersion,
+		CUDA:   formatCUDAVersion(cudaVersion),
+	}
+
+	device, r := nvml.DeviceGetHandleByIndex(0)
+	if r != nvml.SUCCESS {
+		return &properties, nil
+	}
+	if major, minor, r := device.GetCudaComputeCapability(); r == nvml.SUCCESS {
+		properties.Arch = fmt.Sprintf("%d.%d", major, minor)
+	}
+	if brand, r := device.GetBrand(); r == nvml.SUCCESS {
+		properties.Brand = brandName(brand)
+	}
+	return &properties, nil
+}
+
+// formatCUDAVersion formats a CUDA version as returned by NVML (e.g. 12010) as major.minor (e.g. 12.1).
+func formatCUDAVersion(version int) string {
+	return fmt.Sprintf("%d.%d", version/1000, version%1000/10)
+}
+
+// brandName returns the name of the specified brand as used in NVIDIA_REQUIRE_* brand requirements.
+func brandName(brand nvml.BrandType) string {
+	switch brand {
+	case nvml.BRAND_QUADRO:
+		return "quadro"
+	case nvml.BRAND_TESLA:
+		return "tesla"
+	case nvml.BRAND_NVS:
+		return "nvs"
+	case nvml.BRAND_GRID:
+		return "grid"
+	case nvml.BRAND_GEFORCE:
+		return "geforce"
+	case nvml.BRAND_TITAN:
+		return "titan"
+	case nvml.BRAND_NVIDIA_VAPPS:
+		return "nvidiavapps"
+	case nvml.BRAND_NVIDIA_VPC:
+		return "nvidiavpc"
+	case nvml.BRAND_NVIDIA_VCS:
+		return "nvidiavcs"
+	case nvml.BRAND_NVIDIA_VWS:
+		return "nvidiavws"
+	case nvml.BRAND_NVIDIA_CLOUD_GAMING:
+		return "nvidiacloudgaming"
+	case nvml.BRAND_QUADRO_RTX:
+		return "quadrortx"
+	case nvml.BRAND_NVIDIA_RTX:
+		return "nvidiartx"
+	case nvml.BRAND_NVIDIA:
+		return "nvidia"
+	case nvml.BRAND_GEFORCE_RTX:
+		return "geforcertx"
+	case nvml.BRAND_TITAN_RTX:
+		return "titanrtx"
+	}
+	return "unknown"
+}
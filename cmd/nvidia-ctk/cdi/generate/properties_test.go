@@ -0,0 +1,49 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCUDAVersion(t *testing.T) {
+	require.Equal(t, "12.1", formatCUDAVersion(12010))
+	require.Equal(t, "11.8", formatCUDAVersion(11080))
+	require.Equal(t, "10.0", formatCUDAVersion(10000))
+}
+
+func TestBrandName(t *testing.T) {
+	testCases := []struct {
+		brand    nvml.BrandType
+		expected string
+	}{
+		{nvml.BRAND_TESLA, "tesla"},
+		{nvml.BRAND_GEFORCE_RTX, "geforcertx"},
+		{nvml.BRAND_NVIDIA_CLOUD_GAMING, "nvidiacloudgaming"},
+		{nvml.BRAND_UNKNOWN, "unknown"},
+		{nvml.BRAND_COUNT, "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, brandName(tc.brand))
+		})
+	}
+}
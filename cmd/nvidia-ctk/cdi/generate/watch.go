@@ -0,0 +1,163 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultWatchInterval = 30 * time.Second
+
+	nvidiaCapsDir = "nvidia-caps"
+)
+
+// driverState describes the parts of the system that affect the generated CDI specification.
+type driverState struct {
+	procRoot string
+	devRoot  string
+}
+
+// fingerprint returns a hash of the driver state. This includes the driver version, the GPUs and MIG
+// devices (GPU and compute instances) known to the driver, and the NVIDIA device nodes. Entries that
+// do not exist (e.g. while the driver is not loaded) are skipped.
+func (d driverState) fingerprint() string {
+	var entries []string
+
+	if version, err := os.ReadFile(filepath.Join(d.procRoot, "driver/nvidia/version")); err == nil {
+		entries = append(entries, "version="+string(version))
+	}
+
+	for _, dir := range []string{"driver/nvidia/gpus", "driver/nvidia/capabilities"} {
+		root := filepath.Join(d.procRoot, dir)
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				entries = append(entries, "proc="+strings.TrimPrefix(path, d.procRoot))
+			}
+			return nil
+		})
+	}
+
+	for _, pattern := range []string{"nvidia*", filepath.Join(nvidiaCapsDir, "*")} {
+		paths, _ := filepath.Glob(filepath.Join(d.devRoot, pattern))
+		for _, path := range paths {
+			entries = append(entries, "dev="+strings.TrimPrefix(path, d.devRoot))
+		}
+	}
+
+	sort.Strings(entries)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(entries, "\n"))))
+}
+
+// watch generates the CDI specification and regenerates it whenever the driver state changes. Since
+// procfs does not support inotify, the driver state is checked at the watch interval. Changes to the
+// NVIDIA device nodes and SIGHUP trigger an immediate check.
+func (m command) watch(cfg *config) error {
+	state := driverState{
+		procRoot: "/proc",
+		devRoot:  filepath.Join(cfg.driverRoot, "dev"),
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create FS watcher: %v", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(state.devRoot); err != nil {
+		return fmt.Errorf("failed to watch %v: %v", state.devRoot, err)
+	}
+	m.watchCapsDir(watcher, state.devRoot)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(cfg.watchInterval)
+	defer ticker.Stop()
+
+	var last string
+	check := func(force bool) {
+		current := state.fingerprint()
+		if !force && current == last {
+			return
+		}
+		m.logger.Infof("Driver state changed; regenerating CDI specification")
+		if err := m.generate(cfg); err != nil {
+			// The specification is regenerated at the next check.
+			m.logger.Warningf("Failed to regenerate CDI specification: %v", err)
+			last = ""
+			return
+		}
+		last = current
+	}
+
+	check(true)
+	for {
+		select {
+		case event := <-watcher.Events:
+			name := filepath.Base(event.Name)
+			if name == nvidiaCapsDir && event.Op&fsnotify.Create == fsnotify.Create {
+				m.watchCapsDir(watcher, state.devRoot)
+			}
+			if !strings.HasPrefix(name, "nvidia") && filepath.Base(filepath.Dir(event.Name)) != nvidiaCapsDir {
+				continue
+			}
+			check(false)
+
+		case err := <-watcher.Errors:
+			m.logger.Errorf("inotify: %s", err)
+
+		case <-ticker.C:
+			check(false)
+
+		case s := <-sigs:
+			switch s {
+			case syscall.SIGHUP:
+				m.logger.Infof("Received SIGHUP, regenerating CDI specification.")
+				check(true)
+			default:
+				m.logger.Infof("Received signal %q, shutting down.", s)
+				return nil
+			}
+		}
+	}
+}
+
+// watchCapsDir adds the nvidia-caps device node directory to the watcher if it exists. The directory
+// is only created once MIG capability device nodes are created.
+func (m command) watchCapsDir(watcher *fsnotify.Watcher, devRoot string) {
+	capsDir := filepath.Join(devRoot, nvidiaCapsDir)
+	if _, err := os.Stat(capsDir); err != nil {
+		return
+	}
+	if err := watcher.Add(capsDir); err != nil {
+		m.logger.Warningf("Failed to watch %v: %v", capsDir, err)
+	}
+}
@@ -0,0 +1,92 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverStateFingerprint(t *testing.T) {
+	root := t.TempDir()
+	state := driverState{
+		procRoot: filepath.Join(root, "proc"),
+		devRoot:  filepath.Join(root, "dev"),
+	}
+
+	write := func(path string, contents string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	mkdir := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, path), 0755))
+	}
+
+	unloaded := state.fingerprint()
+
+	write("proc/driver/nvidia/version", "NVRM version: NVIDIA UNIX x86_64 Kernel Module  525.60.13\n")
+	write("proc/driver/nvidia/gpus/0000:3b:00.0/information", "Device Minor: 0\n")
+	write("dev/nvidia0", "")
+	write("dev/nvidiactl", "")
+	loaded := state.fingerprint()
+	require.NotEqual(t, unloaded, loaded)
+	require.Equal(t, loaded, state.fingerprint())
+
+	// Changes to the contents of information files are ignored.
+	write("proc/driver/nvidia/gpus/0000:3b:00.0/information", "Device Minor: 0\nIRQ: 42\n")
+	require.Equal(t, loaded, state.fingerprint())
+
+	testCases := []struct {
+		description string
+		change      func()
+	}{
+		{
+			description: "GPU added",
+			change: func() {
+				mkdir("proc/driver/nvidia/gpus/0000:5e:00.0")
+				write("dev/nvidia1", "")
+			},
+		},
+		{
+			description: "MIG devices created",
+			change: func() {
+				mkdir("proc/driver/nvidia/capabilities/gpu0/mig/gi1/ci0")
+				write("dev/nvidia-caps/nvidia-cap12", "")
+			},
+		},
+		{
+			description: "driver upgraded",
+			change: func() {
+				write("proc/driver/nvidia/version", "NVRM version: NVIDIA UNIX x86_64 Kernel Module  530.30.02\n")
+			},
+		},
+	}
+
+	previous := loaded
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			tc.change()
+			current := state.fingerprint()
+			require.NotEqual(t, previous, current)
+			previous = current
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package index
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	specDirs       cli.StringSlice
+	output         string
+	failOnConflict bool
+}
+
+// NewCommand constructs an index command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'index' command
+	c := cli.Command{
+		Name:  "index",
+		Usage: "Index the CDI specifications of all vendors in the spec dirs and report conflicts between them",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "spec-dir",
+			Usage:       "Specify the CDI spec dirs to index in order of increasing priority. If this is not specified, the spec dirs from the config.toml file are used.",
+			Destination: &opts.specDirs,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "Specify the file to which the index is written. If this is not specified, the index-file from the config.toml file or " + cdiindex.DefaultPath + " is used.",
+			Destination: &opts.output,
+		},
+		&cli.BoolFlag{
+			Name:        "fail-on-conflict",
+			Usage:       "Return an error if conflicts that are not resolved by the priority of the spec dirs are detected. The index is written regardless.",
+			Destination: &opts.failOnConflict,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	cdiConfig := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI
+
+	if !c.IsSet("spec-dir") {
+		specDirs := cdiConfig.SpecDirs
+		if len(specDirs) == 0 {
+			specDirs = cdi.DefaultSpecDirs
+		}
+		opts.specDirs = *cli.NewStringSlice(specDirs...)
+	}
+
+	if opts.output == "" {
+		opts.output = cdiConfig.IndexFile
+	}
+	if opts.output == "" {
+		opts.output = cdiindex.DefaultPath
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	index, err := cdiindex.Build(opts.specDirs.Value())
+	if err != nil {
+		return fmt.Errorf("failed to index CDI specifications: %v", err)
+	}
+
+	var devices int
+	for _, s := range index.Specs {
+		if s.Error != "" {
+			m.logger.Warningf("Failed to load CDI specification %v: %v", s.Path, s.Error)
+		}
+		devices += len(s.Devices)
+	}
+
+	var unresolved int
+	for _, conflict := range index.Conflicts {
+		if conflict.Resolved {
+			m.logger.Infof("Conflict: %v", conflict)
+			continue
+		}
+		unresolved++
+		m.logger.Warningf("Conflict: %v", conflict)
+	}
+
+	if err := index.Save(opts.output); err != nil {
+		return fmt.Errorf("failed to save index: %v", err)
+	}
+	m.logger.Infof("Indexed %d specifications with %d devices in %v (%d conflicts) to %v", len(index.Specs), devices, opts.specDirs.Value(), len(index.Conflicts), opts.output)
+
+	if opts.failOnConflict && unresolved > 0 {
+		return fmt.Errorf("detected %d unresolved conflicts", unresolved)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package packagebundle
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdibundle"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type config struct {
+	output string
+	specs  cli.StringSlice
+}
+
+// NewCommand constructs a package command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	cfg := config{}
+
+	// Create the 'package' command
+	c := cli.Command{
+		Name:  "package",
+		Usage: "Package CDI specifications and a manifest of the host files referenced by them for use in air-gapped environments",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &cfg)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "The path of the bundle to create",
+			Value:       "nvidia-cdi-bundle.tar",
+			Destination: &cfg.output,
+		},
+		&cli.StringSliceFlag{
+			Name:        "spec",
+			Usage:       "The path to a CDI specification to include in the bundle. This can be specified multiple times.",
+			Value:       cli.NewStringSlice("/etc/cdi/nvidia.yaml"),
+			Destination: &cfg.specs,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, cfg *config) error {
+	f, err := os.Create(cfg.output)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %v", err)
+	}
+	defer f.Close()
+
+	manifest, err := cdibundle.Create(f, cfg.specs.Value())
+	if err != nil {
+		os.Remove(cfg.output)
+		return fmt.Errorf("failed to create bundle: %v", err)
+	}
+
+	m.logger.Infof("Created bundle %v with %d specifications and %d files", cfg.output, len(cfg.specs.Value()), len(manifest.Files))
+	return nil
+}
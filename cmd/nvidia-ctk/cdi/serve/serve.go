@@ -0,0 +1,156 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package serve
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiregistry"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdispecdirs"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	socket   string
+	specDirs cli.StringSlice
+	strict   bool
+	// validator checks the spec dirs against the allowed-spec-dirs and spec-dir-permissions in the config.
+	validator *cdispecdirs.Validator
+}
+
+// NewCommand constructs a serve command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'serve' command
+	c := cli.Command{
+		Name:  "serve",
+		Usage: "Run a daemon that owns the CDI registry and answers injection queries from the NVIDIA Container Runtime and nvidia-cdi-hook over a Unix socket",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "socket",
+			Usage:       "Specify the path of the Unix socket on which the daemon listens. If this is not specified, the registry-socket from the config.toml file or " + cdiregistry.DefaultSocketPath + " is used.",
+			Destination: &opts.socket,
+		},
+		&cli.StringSliceFlag{
+			Name:        "spec-dir",
+			Usage:       "Specify the CDI spec dirs to load in order of increasing priority. If this is not specified, the spec dirs from the config.toml file are used.",
+			Destination: &opts.specDirs,
+		},
+		&cli.BoolFlag{
+			Name:        "strict",
+			Usage:       "Reject all requests while any of the CDI specifications cannot be loaded. If this is not specified, the strict setting of the cdi mode from the config.toml file is used.",
+			Destination: &opts.strict,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	cdiConfig := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI
+
+	if !c.IsSet("spec-dir") {
+		specDirs := cdiConfig.SpecDirs
+		if len(specDirs) == 0 {
+			specDirs = cdi.DefaultSpecDirs
+		}
+		opts.specDirs = *cli.NewStringSlice(specDirs...)
+	}
+	if !c.IsSet("strict") {
+		opts.strict = cdiConfig.Strict
+	}
+
+	if opts.socket == "" {
+		opts.socket = cdiConfig.RegistrySocket
+	}
+	if opts.socket == "" {
+		opts.socket = cdiregistry.DefaultSocketPath
+	}
+
+	opts.validator, err = cdispecdirs.NewValidator(m.logger, cfg)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// run serves queries until SIGINT or SIGTERM is received. SIGHUP triggers a reload of the CDI
+// specifications.
+func (m command) run(c *cli.Context, opts *options) error {
+	server, err := cdiregistry.NewServer(m.logger, opts.validator, opts.specDirs.Value(), opts.strict)
+	if err != nil {
+		return err
+	}
+
+	listener, err := cdiregistry.Listen(opts.socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(opts.socket)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		for s := range sigs {
+			if s == syscall.SIGHUP {
+				m.logger.Infof("Received SIGHUP, reloading CDI specifications.")
+				if err := server.Refresh(); err != nil {
+					m.logger.Warningf("Failed to reload CDI specifications: %v", err)
+				}
+				continue
+			}
+			m.logger.Infof("Received signal %q, shutting down.", s)
+			listener.Close()
+			return
+		}
+	}()
+
+	m.logger.Infof("Serving CDI registry queries on %v", opts.socket)
+	return server.Serve(listener)
+}
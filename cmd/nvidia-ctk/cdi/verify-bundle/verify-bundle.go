@@ -0,0 +1,110 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package verifybundle
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdibundle"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type config struct {
+	bundle string
+	root   string
+}
+
+// NewCommand constructs a verify-bundle command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	cfg := config{}
+
+	// Create the 'verify-bundle' command
+	c := cli.Command{
+		Name:      "verify-bundle",
+		Usage:     "Verify that the host files referenced by the CDI specifications in a bundle are present and unmodified",
+		ArgsUsage: "BUNDLE",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &cfg)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &cfg)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "root",
+			Usage:       "The root relative to which the host files are verified. This allows, for example, an unpacked golden image to be verified.",
+			Value:       "/",
+			Destination: &cfg.root,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, cfg *config) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("exactly one bundle must be specified")
+	}
+	cfg.bundle = c.Args().First()
+	return nil
+}
+
+func (m command) run(c *cli.Context, cfg *config) error {
+	f, err := os.Open(cfg.bundle)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %v", err)
+	}
+	defer f.Close()
+
+	dir, err := os.MkdirTemp("", "nvidia-cdi-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bundle, err := cdibundle.Extract(f, dir)
+	if err != nil {
+		return fmt.Errorf("failed to extract bundle: %v", err)
+	}
+
+	problems := bundle.Verify(cfg.root)
+	for _, p := range problems {
+		m.logger.Errorf("%v", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("bundle verification failed with %d problems", len(problems))
+	}
+
+	m.logger.Infof("Verified %d specifications and %d files", len(bundle.SpecPaths), len(bundle.Manifest.Files))
+	return nil
+}
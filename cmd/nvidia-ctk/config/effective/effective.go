@@ -0,0 +1,136 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package effective
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	configPath string
+	mode       string
+	format     string
+}
+
+// NewCommand constructs an effective command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build the effective command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'effective' command
+	c := cli.Command{
+		Name:  "effective",
+		Usage: "Print the effective NVIDIA Container Toolkit config together with the source of each value",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "The path to the config file. If this is '' the path used by the NVIDIA Container Runtime is used",
+			Destination: &opts.configPath,
+		},
+		&cli.StringFlag{
+			Name:        "mode",
+			Usage:       "Override the mode specified in the config. This matches the behaviour of the nvidia-container-runtime.cdi and nvidia-container-runtime.legacy executables",
+			Destination: &opts.mode,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+
+	overrides := make(map[string]interface{})
+	if opts.mode != "" {
+		overrides["nvidia-container-runtime.mode"] = opts.mode
+	}
+
+	effective, err := config.GetEffectiveConfig(opts.configPath, overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load effective config: %v", err)
+	}
+
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(effective)
+	}
+	return writeTable(c.App.Writer, effective)
+}
+
+// writeTable writes the effective config values and their sources as a table.
+func writeTable(w io.Writer, effective *config.EffectiveConfig) error {
+	fmt.Fprintf(w, "# Config file: %v\n", effective.Path)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, value := range effective.Values {
+		source := string(value.Source)
+		if value.Origin != "" {
+			source = fmt.Sprintf("%v (%v)", value.Source, value.Origin)
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", value.Key, formatValue(value.Value), source)
+	}
+	return tw.Flush()
+}
+
+// formatValue renders the specified value using its JSON representation. For the supported
+// value types this matches the TOML representation.
+func formatValue(value interface{}) string {
+	rendered, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(rendered)
+}
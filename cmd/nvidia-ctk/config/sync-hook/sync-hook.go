@@ -0,0 +1,111 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package synchook
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultConfigPath = "/etc/nvidia-container-runtime/config.toml"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	configPath string
+	output     string
+}
+
+// NewCommand constructs a sync-hook command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build the sync-hook command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'sync-hook' command
+	c := cli.Command{
+		Name:  "sync-hook",
+		Usage: "Generate the config for the nvidia-container-runtime-hook from the NVIDIA Container Toolkit config",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "The path to the NVIDIA Container Toolkit config file from which the hook config is generated",
+			Value:       defaultConfigPath,
+			Destination: &opts.configPath,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "The path to which the hook config is written. If this is '' the config is written to STDOUT",
+			Destination: &opts.output,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	tree, err := config.LoadTOMLFile(opts.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config %v: %v", opts.configPath, err)
+	}
+
+	hookConfig, err := config.GetHookConfigFrom(tree)
+	if err != nil {
+		return fmt.Errorf("failed to generate hook config: %v", err)
+	}
+
+	if opts.output == "" {
+		_, err := hookConfig.WriteTo(c.App.Writer)
+		return err
+	}
+
+	contents, err := hookConfig.ToTomlString()
+	if err != nil {
+		return fmt.Errorf("failed to render hook config: %v", err)
+	}
+	// The lock also creates the output directory if required.
+	lock, err := engine.Lock(opts.output)
+	if err != nil {
+		return fmt.Errorf("failed to lock hook config: %v", err)
+	}
+	defer lock.Unlock()
+	if _, err := engine.WriteFile(opts.output, []byte(contents)); err != nil {
+		return fmt.Errorf("failed to write hook config: %v", err)
+	}
+	m.logger.Infof("Wrote nvidia-container-runtime-hook config to %v", opts.output)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+)
+
+// checkCDIConflicts checks for conflicts between the CDI specifications in the spec dirs. The CDI
+// spec index is used if it is up to date; otherwise the spec dirs are indexed.
+func (m command) checkCDIConflicts(opts *options) result {
+	var index *cdiindex.Index
+	var stale string
+	if opts.cdiIndexFile != "" {
+		index, stale = loadCurrentIndex(opts.cdiIndexFile, opts.cdiSpecDirs)
+	}
+
+	if index == nil {
+		var err error
+		index, err = cdiindex.Build(opts.cdiSpecDirs)
+		if err != nil {
+			return result{
+				status:  statusFail,
+				message: fmt.Sprintf("failed to index CDI specifications: %v", err),
+			}
+		}
+	}
+
+	var unresolved []string
+	var resolved []string
+	for _, conflict := range index.Conflicts {
+		if conflict.Resolved {
+			resolved = append(resolved, conflict.String())
+			continue
+		}
+		unresolved = append(unresolved, conflict.String())
+	}
+
+	r := result{
+		status:  statusPass,
+		message: fmt.Sprintf("no unresolved conflicts between %d specifications in %v", len(index.Specs), opts.cdiSpecDirs),
+	}
+	if len(unresolved) > 0 {
+		r.status = statusFail
+		r.message = fmt.Sprintf("%d unresolved conflicts between specifications in %v", len(unresolved), opts.cdiSpecDirs)
+	} else if stale != "" {
+		r.status = statusWarn
+		r.message = stale
+	}
+	r.details = append(r.details, unresolved...)
+	r.details = append(r.details, resolved...)
+	return r
+}
+
+// loadCurrentIndex loads the CDI spec index at the specified path. If the index cannot be loaded or
+// is out of date for the specified spec dirs, nil and the reason are returned.
+func loadCurrentIndex(path string, specDirs []string) (*cdiindex.Index, string) {
+	index, err := cdiindex.Load(path)
+	if err != nil {
+		return nil, fmt.Sprintf("CDI spec index %v could not be loaded (%v); run 'nvidia-ctk cdi index' to create it", path, err)
+	}
+	current, err := index.IsCurrent(specDirs)
+	if err != nil || !current {
+		return nil, fmt.Sprintf("CDI spec index %v is out of date; run 'nvidia-ctk cdi index' to update it", path)
+	}
+	return index, ""
+}
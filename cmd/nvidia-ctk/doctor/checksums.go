@@ -0,0 +1,58 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+)
+
+// checkDriverChecksums verifies the driver files against the checksum manifest.
+func (m command) checkDriverChecksums(opts *options) result {
+	if opts.checksumManifest == "" {
+		return result{
+			status:  statusSkip,
+			message: "no checksum manifest configured",
+		}
+	}
+
+	manifest, err := checksum.Load(opts.checksumManifest)
+	if err != nil {
+		return result{
+			status:  statusFail,
+			message: fmt.Sprintf("failed to load checksum manifest: %v", err),
+		}
+	}
+
+	mismatches := manifest.Verify()
+	if len(mismatches) == 0 {
+		return result{
+			status:  statusPass,
+			message: fmt.Sprintf("%d files match %v", len(manifest.Files), opts.checksumManifest),
+		}
+	}
+
+	r := result{
+		status:  statusFail,
+		message: fmt.Sprintf("%d of %d files do not match %v; regenerate the CDI specification and checksum manifest if the driver was upgraded", len(mismatches), len(manifest.Files), opts.checksumManifest),
+	}
+	for _, mismatch := range mismatches {
+		r.details = append(r.details, mismatch.String())
+	}
+	return r
+}
@@ -0,0 +1,59 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+)
+
+// checkDeprecations reports the deprecated features that have been used since boot.
+func (m command) checkDeprecations(opts *options) result {
+	counts, err := deprecation.NewTracker(opts.deprecationStateFile).Counts()
+	if err != nil {
+		return result{
+			status:  statusWarn,
+			message: fmt.Sprintf("failed to read the uses of deprecated features: %v", err),
+		}
+	}
+	return summarizeDeprecations(counts)
+}
+
+// summarizeDeprecations returns the result for the specified counts of deprecated feature uses.
+func summarizeDeprecations(counts map[deprecation.ID]int) result {
+	var details []string
+	for _, d := range deprecation.All() {
+		count := counts[d.ID]
+		if count == 0 {
+			continue
+		}
+		details = append(details, fmt.Sprintf("%v: used %d times; %v", d.ID, count, d))
+	}
+
+	if len(details) == 0 {
+		return result{
+			status:  statusPass,
+			message: "no deprecated features have been used since boot",
+		}
+	}
+	return result{
+		status:  statusWarn,
+		message: fmt.Sprintf("%d deprecated features have been used since boot", len(details)),
+		details: details,
+	}
+}
@@ -0,0 +1,160 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	checksumManifest     string
+	deprecationStateFile string
+	cdiSpecDirs          []string
+	cdiIndexFile         string
+	featureGates         *features.Gates
+}
+
+// status is the outcome of a single check.
+type status string
+
+const (
+	statusPass = status("PASS")
+	statusWarn = status("WARN")
+	statusFail = status("FAIL")
+	statusSkip = status("SKIP")
+)
+
+// result is the result of running a single check.
+type result struct {
+	status  status
+	message string
+	details []string
+}
+
+// check defines a named diagnostic check.
+type check struct {
+	name string
+	run  func(*options) result
+}
+
+// NewCommand constructs a doctor command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{
+		deprecationStateFile: deprecation.DefaultStateFile,
+	}
+
+	// Create the 'doctor' command
+	c := cli.Command{
+		Name:  "doctor",
+		Usage: "Diagnose the installation and configuration of the NVIDIA Container Toolkit",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "checksum-manifest",
+			Usage:       "The checksum manifest against which driver files are verified. If this is not specified, the manifest configured in the config.toml file is used.",
+			Destination: &opts.checksumManifest,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	if !c.IsSet("checksum-manifest") {
+		opts.checksumManifest = cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest
+	}
+
+	opts.cdiSpecDirs = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	if len(opts.cdiSpecDirs) == 0 {
+		opts.cdiSpecDirs = cdi.DefaultSpecDirs
+	}
+	opts.cdiIndexFile = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.IndexFile
+
+	opts.featureGates, err = cfg.FeatureGates()
+	if err != nil {
+		return fmt.Errorf("invalid features config: %v", err)
+	}
+	if err := opts.featureGates.SetFromString(c.String("feature-gates")); err != nil {
+		return fmt.Errorf("invalid feature gates: %v", err)
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	checks := []check{
+		{name: "driver-checksums", run: m.checkDriverChecksums},
+		{name: "component-versions", run: m.checkComponentVersions},
+		{name: "deprecations", run: m.checkDeprecations},
+		{name: "cdi-conflicts", run: m.checkCDIConflicts},
+		{name: "features", run: m.checkFeatures},
+		{name: "kernel", run: m.checkKernel},
+	}
+
+	failed := runChecks(c.App.Writer, checks, opts)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// runChecks runs the specified checks, writes the results to w, and returns the number of failed checks.
+func runChecks(w io.Writer, checks []check, opts *options) int {
+	var failed int
+	for _, check := range checks {
+		r := check.run(opts)
+		if r.status == statusFail {
+			failed++
+		}
+		fmt.Fprintf(w, "[%v] %v: %v\n", r.status, check.name, r.message)
+		for _, d := range r.details {
+			fmt.Fprintf(w, "\t%v\n", d)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,260 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/kernel"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDriverChecksums(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	dir := t.TempDir()
+	libcuda := filepath.Join(dir, "libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	manifest, err := checksum.New([]string{libcuda})
+	require.NoError(t, err)
+	manifestPath := filepath.Join(dir, "checksums.json")
+	require.NoError(t, manifest.Save(manifestPath))
+
+	testCases := []struct {
+		description     string
+		manifest        string
+		contents        string
+		expectedStatus  status
+		expectedDetails int
+	}{
+		{
+			description:    "no manifest",
+			expectedStatus: statusSkip,
+		},
+		{
+			description:    "missing manifest",
+			manifest:       filepath.Join(dir, "missing.json"),
+			expectedStatus: statusFail,
+		},
+		{
+			description:    "matching files",
+			manifest:       manifestPath,
+			contents:       "libcuda",
+			expectedStatus: statusPass,
+		},
+		{
+			description:     "modified files",
+			manifest:        manifestPath,
+			contents:        "tampered",
+			expectedStatus:  statusFail,
+			expectedDetails: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(libcuda, []byte(tc.contents), 0644))
+
+			r := m.checkDriverChecksums(&options{checksumManifest: tc.manifest})
+			require.Equal(t, tc.expectedStatus, r.status)
+			require.Len(t, r.details, tc.expectedDetails)
+		})
+	}
+}
+
+func TestCheckCDIConflicts(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	spec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+`
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nvidia.yaml"), []byte(spec), 0644))
+
+	indexFile := filepath.Join(t.TempDir(), "cdi-index.json")
+	index, err := cdiindex.Build([]string{dir})
+	require.NoError(t, err)
+	require.NoError(t, index.Save(indexFile))
+
+	r := m.checkCDIConflicts(&options{cdiSpecDirs: []string{dir}, cdiIndexFile: indexFile})
+	require.Equal(t, statusPass, r.status)
+
+	r = m.checkCDIConflicts(&options{cdiSpecDirs: []string{dir}, cdiIndexFile: filepath.Join(dir, "missing.json")})
+	require.Equal(t, statusWarn, r.status)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte(spec), 0644))
+	r = m.checkCDIConflicts(&options{cdiSpecDirs: []string{dir}, cdiIndexFile: indexFile})
+	require.Equal(t, statusFail, r.status)
+	require.Len(t, r.details, 1)
+}
+
+func TestCheckFeatures(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	gates, err := features.New(nil)
+	require.NoError(t, err)
+	r := m.checkFeatures(&options{featureGates: gates})
+	require.Equal(t, statusPass, r.status)
+	require.Empty(t, r.details)
+
+	require.NoError(t, gates.SetFromString("nri-plugin=true"))
+	r = m.checkFeatures(&options{featureGates: gates})
+	require.Equal(t, statusWarn, r.status)
+	require.Equal(t, []string{"nri-plugin (alpha)"}, r.details)
+}
+
+func TestRunChecks(t *testing.T) {
+	checks := []check{
+		{name: "a", run: func(*options) result { return result{status: statusPass, message: "ok"} }},
+		{name: "b", run: func(*options) result {
+			return result{status: statusFail, message: "not ok", details: []string{"detail"}}
+		}},
+	}
+
+	w := &bytes.Buffer{}
+	failed := runChecks(w, checks, &options{})
+	require.Equal(t, 1, failed)
+	require.Equal(t, "[PASS] a: ok\n[FAIL] b: not ok\n\tdetail\n", w.String())
+}
+
+func TestCompareComponentVersions(t *testing.T) {
+	testCases := []struct {
+		description    string
+		expected       string
+		components     []info.Component
+		expectedStatus status
+	}{
+		{
+			description:    "unknown nvidia-ctk version",
+			expected:       "unknown",
+			expectedStatus: statusSkip,
+		},
+		{
+			description: "matching versions",
+			expected:    "1.13.0",
+			components: []info.Component{
+				{Name: "nvidia-container-runtime", Path: "/usr/bin/nvidia-container-runtime", Version: "1.13.0"},
+				{Name: "nvidia-container-runtime-hook"},
+			},
+			expectedStatus: statusPass,
+		},
+		{
+			description: "partial upgrade",
+			expected:    "1.13.0",
+			components: []info.Component{
+				{Name: "nvidia-container-runtime", Path: "/usr/bin/nvidia-container-runtime", Version: "1.13.0"},
+				{Name: "nvidia-container-runtime-hook", Path: "/usr/bin/nvidia-container-runtime-hook", Version: "1.12.1"},
+			},
+			expectedStatus: statusFail,
+		},
+		{
+			description: "unknown component version",
+			expected:    "1.13.0",
+			components: []info.Component{
+				{Name: "nvidia-container-cli", Path: "/usr/bin/nvidia-container-cli"},
+			},
+			expectedStatus: statusWarn,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := compareComponentVersions(tc.expected, tc.components)
+			require.Equal(t, tc.expectedStatus, r.status)
+		})
+	}
+}
+
+func TestCheckDeprecations(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	stateFile := filepath.Join(t.TempDir(), "deprecations.json")
+	opts := &options{deprecationStateFile: stateFile}
+
+	r := m.checkDeprecations(opts)
+	require.Equal(t, statusPass, r.status)
+
+	tracker := deprecation.NewTracker(stateFile)
+	for i := 0; i < 3; i++ {
+		_, err := tracker.Record(deprecation.SwarmResourceEnvvars)
+		require.NoError(t, err)
+	}
+
+	r = m.checkDeprecations(opts)
+	require.Equal(t, statusWarn, r.status)
+	require.Len(t, r.details, 1)
+	require.Contains(t, r.details[0], "swarm-resource-envvars: used 3 times")
+}
+
+func TestSummarizeKernelConstraints(t *testing.T) {
+	testCases := []struct {
+		description    string
+		loaded         bool
+		constraints    kernel.Constraints
+		expectedStatus status
+	}{
+		{
+			description:    "module loaded with constraints",
+			loaded:         true,
+			constraints:    kernel.Constraints{Lockdown: kernel.LockdownIntegrity, SecureBoot: true, SignaturesEnforced: true},
+			expectedStatus: statusPass,
+		},
+		{
+			description:    "module not loaded without constraints",
+			constraints:    kernel.Constraints{Lockdown: kernel.LockdownNone},
+			expectedStatus: statusWarn,
+		},
+		{
+			description:    "module not loaded with signatures enforced",
+			constraints:    kernel.Constraints{Lockdown: kernel.LockdownIntegrity, SignaturesEnforced: true},
+			expectedStatus: statusFail,
+		},
+		{
+			description:    "module not loaded on PREEMPT_RT kernel",
+			constraints:    kernel.Constraints{PreemptRT: true},
+			expectedStatus: statusFail,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := summarizeKernelConstraints(tc.loaded, &tc.constraints)
+			require.Equal(t, tc.expectedStatus, r.status)
+			require.Contains(t, r.details, fmt.Sprintf("PREEMPT_RT: %v", tc.constraints.PreemptRT))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
+)
+
+// checkFeatures reports the features that are enabled. Since alpha features are experimental,
+// a warning is reported if any of these are enabled.
+func (m command) checkFeatures(opts *options) result {
+	var enabled int
+	var alpha int
+	var details []string
+	for _, s := range opts.featureGates.Status() {
+		if !s.Enabled {
+			continue
+		}
+		enabled++
+		if s.Stage == features.StageAlpha {
+			alpha++
+		}
+		details = append(details, fmt.Sprintf("%v (%v)", s.Feature, s.Stage))
+	}
+
+	if alpha > 0 {
+		return result{
+			status:  statusWarn,
+			message: fmt.Sprintf("%d alpha features are enabled; these are experimental and may change or be removed", alpha),
+			details: details,
+		}
+	}
+	return result{
+		status:  statusPass,
+		message: fmt.Sprintf("%d features are enabled", enabled),
+		details: details,
+	}
+}
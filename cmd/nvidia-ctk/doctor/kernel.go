@@ -0,0 +1,64 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/kernel"
+)
+
+// checkKernel checks whether the NVIDIA kernel module is loaded and reports the constraints of the
+// running kernel that prevent it from being loaded on demand.
+func (m command) checkKernel(opts *options) result {
+	loaded := kernel.ModuleLoaded("/", kernel.NVIDIAModule)
+	return summarizeKernelConstraints(loaded, kernel.Detect("/"))
+}
+
+// summarizeKernelConstraints returns the result of the kernel check. A failure is reported if the
+// NVIDIA kernel module is not loaded and a constraint prevents it from being loaded.
+func summarizeKernelConstraints(loaded bool, c *kernel.Constraints) result {
+	var details []string
+	if c.Lockdown != "" {
+		details = append(details, fmt.Sprintf("lockdown: %v", c.Lockdown))
+	}
+	details = append(details,
+		fmt.Sprintf("secure boot: %v", c.SecureBoot),
+		fmt.Sprintf("module signatures enforced: %v", c.SignaturesEnforced),
+		fmt.Sprintf("PREEMPT_RT: %v", c.PreemptRT),
+	)
+
+	if loaded {
+		return result{
+			status:  statusPass,
+			message: "the NVIDIA kernel module is loaded",
+			details: details,
+		}
+	}
+	if err := c.ModuleLoadingError(); err != nil {
+		return result{
+			status:  statusFail,
+			message: err.Error(),
+			details: details,
+		}
+	}
+	return result{
+		status:  statusWarn,
+		message: "the NVIDIA kernel module is not loaded",
+		details: details,
+	}
+}
@@ -0,0 +1,96 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+)
+
+// checkComponentVersions checks that the installed components have the same version as nvidia-ctk.
+func (m command) checkComponentVersions(opts *options) result {
+	locator := lookup.NewExecutableLocator(m.logger, "")
+
+	var components []info.Component
+	for _, name := range info.Components() {
+		c := info.Component{Name: name}
+		paths, err := locator.Locate(name)
+		if err != nil || len(paths) == 0 {
+			m.logger.Debugf("Could not locate %v: %v", name, err)
+			components = append(components, c)
+			continue
+		}
+		c.Path = paths[0]
+		c.Version, err = info.GetComponentVersion(name, c.Path)
+		if err != nil {
+			m.logger.Debugf("Could not determine version of %v: %v", c.Path, err)
+		}
+		components = append(components, c)
+	}
+
+	return compareComponentVersions(info.GetVersion(), components)
+}
+
+// compareComponentVersions compares the version of each component to the expected version.
+// Components that are not installed are ignored.
+func compareComponentVersions(expected string, components []info.Component) result {
+	if expected == "" || expected == "unknown" {
+		return result{
+			status:  statusSkip,
+			message: "the version of nvidia-ctk is unknown",
+		}
+	}
+
+	var mismatched, unknown int
+	var details []string
+	for _, c := range components {
+		switch {
+		case c.Path == "":
+			details = append(details, fmt.Sprintf("%v: not found", c.Name))
+		case c.Version == "":
+			unknown++
+			details = append(details, fmt.Sprintf("%v (%v): unknown version", c.Name, c.Path))
+		case !info.VersionsMatch(expected, c.Version):
+			mismatched++
+			details = append(details, fmt.Sprintf("%v (%v): %v", c.Name, c.Path, c.Version))
+		default:
+			details = append(details, fmt.Sprintf("%v (%v): %v", c.Name, c.Path, c.Version))
+		}
+	}
+
+	switch {
+	case mismatched > 0:
+		return result{
+			status:  statusFail,
+			message: fmt.Sprintf("%d components do not match nvidia-ctk version %v; this may indicate a partial upgrade", mismatched, expected),
+			details: details,
+		}
+	case unknown > 0:
+		return result{
+			status:  statusWarn,
+			message: fmt.Sprintf("the version of %d components could not be determined", unknown),
+			details: details,
+		}
+	}
+	return result{
+		status:  statusPass,
+		message: fmt.Sprintf("installed components match nvidia-ctk version %v", expected),
+		details: details,
+	}
+}
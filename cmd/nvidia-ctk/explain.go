@@ -0,0 +1,48 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+)
+
+// explain writes the detail and remediation for the specified event ID to w.
+// If the ID is "list", a summary of all known events is written instead.
+func explain(w io.Writer, id string) error {
+	if strings.ToLower(id) == "list" {
+		for _, e := range events.All() {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", e.ID, e.Name, e.Summary)
+		}
+		return nil
+	}
+
+	e, ok := events.Lookup(events.ID(strings.ToUpper(id)))
+	if !ok {
+		return fmt.Errorf("unknown event ID %q; use '--explain list' to list known events", id)
+	}
+
+	fmt.Fprintf(w, "%v (%v): %v\n\n", e.ID, e.Name, e.Summary)
+	fmt.Fprintf(w, "%v\n", e.Detail)
+	if e.Remediation != "" {
+		fmt.Fprintf(w, "\nRemediation:\n%v\n", e.Remediation)
+	}
+	return nil
+}
@@ -24,7 +24,7 @@ import (
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover/csv"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
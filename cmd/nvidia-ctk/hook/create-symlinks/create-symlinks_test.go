@@ -0,0 +1,127 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package symlinks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCreateSymlinksForDriverRoot(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostDir := t.TempDir()
+	libDir := filepath.Join(hostDir, "lib")
+	require.NoError(t, os.MkdirAll(libDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "libcuda.so.1.1"), []byte("libcuda"), 0644))
+	require.NoError(t, os.Symlink("libcuda.so.1.1", filepath.Join(libDir, "libcuda.so.1")))
+
+	csvFile := filepath.Join(t.TempDir(), "drivers.csv")
+	csvContents := "lib, " + filepath.Join(libDir, "libcuda.so.1.1") + "\n" +
+		"sym, " + filepath.Join(libDir, "libcuda.so.1") + "\n"
+	require.NoError(t, os.WriteFile(csvFile, []byte(csvContents), 0644))
+
+	csvDiscoverer, err := discover.NewFromCSVFiles(logger, []string{csvFile}, "")
+	require.NoError(t, err)
+	symlinksHook, err := discover.NewCreateSymlinksHook(logger, []string{csvFile}, csvDiscoverer, &discover.Config{NvidiaCTKPath: "nvidia-ctk"})
+	require.NoError(t, err)
+
+	stagingDir := filepath.Join(t.TempDir(), "staging")
+	d := discover.NewDriverRootDiscoverer(logger, discover.Merge(csvDiscoverer, symlinksHook), stagingDir, "/usr/local/nvidia")
+
+	mounts, err := d.Mounts()
+	require.NoError(t, err)
+	require.Len(t, mounts, 1)
+	driverRoot := mounts[0].HostPath
+
+	hooks, err := d.Hooks()
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+
+	// The hook is run for a container with a writable root.
+	bundleDir := t.TempDir()
+	containerRoot := filepath.Join(bundleDir, "rootfs")
+	require.NoError(t, os.MkdirAll(containerRoot, 0755))
+	writeJSON(t, filepath.Join(bundleDir, "config.json"), specs.Spec{Root: &specs.Root{Path: "rootfs"}})
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	writeJSON(t, stateFile, specs.State{Bundle: bundleDir})
+
+	var args []string
+	for i, arg := range hooks[0].Args {
+		if arg == "create-symlinks" {
+			args = append([]string{"nvidia-ctk"}, hooks[0].Args[i:]...)
+			break
+		}
+	}
+	require.NotEmpty(t, args)
+	args = append(args, "--container-spec", stateFile)
+
+	app := cli.App{
+		Commands: []*cli.Command{NewCommand(logger)},
+	}
+	require.NoError(t, app.Run(args))
+
+	// No links are created in the driver root, since this is mounted read-only.
+	require.NoDirExists(t, filepath.Join(containerRoot, "usr/local/nvidia"))
+
+	// The links are created at their original paths. Links to staged files refer to the files in the
+	// driver root, while other links (e.g. to links created by the hook) are unchanged.
+	testCases := []struct {
+		link            string
+		expectedTarget  string
+		targetIsInMount bool
+	}{
+		{
+			link:            filepath.Join(libDir, "libcuda.so.1"),
+			expectedTarget:  filepath.Join("/usr/local/nvidia", libDir, "libcuda.so.1.1"),
+			targetIsInMount: true,
+		},
+		{
+			link:           filepath.Join(libDir, "libcuda.so"),
+			expectedTarget: "libcuda.so.1",
+		},
+	}
+	for _, tc := range testCases {
+		target, err := os.Readlink(filepath.Join(containerRoot, tc.link))
+		require.NoError(t, err)
+		require.Equal(t, tc.expectedTarget, target)
+		if !tc.targetIsInMount {
+			continue
+		}
+
+		// The target resolves to the staged file once the driver root is mounted.
+		relative, err := filepath.Rel("/usr/local/nvidia", target)
+		require.NoError(t, err)
+		contents, err := os.ReadFile(filepath.Join(driverRoot, relative))
+		require.NoError(t, err)
+		require.Equal(t, "libcuda", string(contents))
+	}
+}
+
+func writeJSON(t *testing.T, filename string, v interface{}) {
+	contents, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filename, contents, 0644))
+}
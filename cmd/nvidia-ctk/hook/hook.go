@@ -17,10 +17,19 @@
 package hook
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
 	chmod "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/hook/chmod"
 
 	symlinks "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/hook/create-symlinks"
 	ldcache "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/hook/update-ldcache"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -29,6 +38,13 @@ type hookCommand struct {
 	logger *logrus.Logger
 }
 
+type options struct {
+	user          string
+	capabilities  string
+	timeout       time.Duration
+	failurePolicy string
+}
+
 // NewCommand constructs a hook command with the specified logger
 func NewCommand(logger *logrus.Logger) *cli.Command {
 	c := hookCommand{
@@ -39,10 +55,42 @@ func NewCommand(logger *logrus.Logger) *cli.Command {
 
 // build
 func (m hookCommand) build() *cli.Command {
+	opts := options{}
+
 	// Create the 'hook' command
 	hook := cli.Command{
 		Name:  "hook",
 		Usage: "A collection of hooks that may be injected into an OCI spec",
+		Before: func(c *cli.Context) error {
+			if err := m.supervise(&opts); err != nil {
+				return err
+			}
+			return m.dropPrivileges(&opts)
+		},
+	}
+
+	hook.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        privileges.UserFlag,
+			Usage:       "Run the hook as the specified user (uid[:gid]) instead of the user invoking the hook",
+			Destination: &opts.user,
+		},
+		&cli.StringFlag{
+			Name:        privileges.CapabilitiesFlag,
+			Usage:       "A comma-separated list of capabilities (e.g. CAP_DAC_OVERRIDE) that are retained when the hook is run as the specified user",
+			Destination: &opts.capabilities,
+		},
+		&cli.DurationFlag{
+			Name:        hookpolicy.TimeoutFlag,
+			Usage:       "The maximum duration of the hook (e.g. 30s). If this is 0, no timeout is applied",
+			Destination: &opts.timeout,
+		},
+		&cli.StringFlag{
+			Name:        hookpolicy.FailurePolicyFlag,
+			Usage:       "Specify how a failure or timeout of the hook is handled. One of [fail-closed | fail-open]. With fail-open the failure is logged and ignored",
+			Value:       string(hookpolicy.FailClosed),
+			Destination: &opts.failurePolicy,
+		},
 	}
 
 	hook.Subcommands = []*cli.Command{
@@ -53,3 +101,79 @@ func (m hookCommand) build() *cli.Command {
 
 	return &hook
 }
+
+// supervise re-executes the hook with the specified timeout and handles failures according to the
+// specified failure policy. This process exits with the exit code of the re-executed hook, or with
+// hookpolicy.TimeoutExitCode if the timeout is exceeded.
+func (m hookCommand) supervise(opts *options) error {
+	if hookpolicy.Supervised() {
+		return nil
+	}
+	p, err := hookpolicy.New(opts.timeout, opts.failurePolicy)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine executable: %v", err)
+	}
+
+	m.logger.Debugf("Running hook with timeout %v and failure policy %v", p.Timeout, p.FailurePolicy)
+	err = p.Run(p.Command(executable, os.Args[1:]))
+	if err == nil {
+		os.Exit(0)
+	}
+	if p.FailurePolicy == hookpolicy.FailOpen {
+		m.logger.Warnf("Ignoring hook failure with failure policy %v: %v", p.FailurePolicy, err)
+		os.Exit(0)
+	}
+
+	var timeoutErr *hookpolicy.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		m.logger.Errorf("%v", err)
+		os.Exit(hookpolicy.TimeoutExitCode)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return fmt.Errorf("failed to run hook: %v", err)
+}
+
+// dropPrivileges re-executes the hook as the specified user with only the specified capabilities.
+// The exit code of the re-executed hook is returned to the caller.
+func (m hookCommand) dropPrivileges(opts *options) error {
+	if opts.user == "" || privileges.Dropped() {
+		return nil
+	}
+
+	var capabilities []string
+	if opts.capabilities != "" {
+		capabilities = strings.Split(opts.capabilities, ",")
+	}
+	p, err := privileges.New(opts.user, capabilities)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine executable: %v", err)
+	}
+
+	m.logger.Debugf("Running hook as %d:%d with capabilities %v", p.UID, p.GID, p.Capabilities)
+	err = p.Command(executable, os.Args[1:]).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run hook with reduced privileges: %v", err)
+	}
+	os.Exit(0)
+	return nil
+}
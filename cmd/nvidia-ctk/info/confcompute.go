@@ -0,0 +1,79 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// confComputeInfo holds the confidential compute state of the system as reported by nvidia-smi.
+type confComputeInfo struct {
+	// State is the confidential compute state (e.g. ON, OFF, or DEVTOOLS).
+	State string `json:"state"`
+	// Environment is the confidential compute environment (e.g. PRODUCTION or INTERNAL).
+	Environment string `json:"environment,omitempty"`
+	// GPUsReady is the ready state of the GPUs for confidential compute workloads (e.g. ready or not-ready).
+	GPUsReady string `json:"gpusReady,omitempty"`
+}
+
+// getConfComputeInfo queries the confidential compute state using the specified nvidia-smi
+// executable. The state is queried using the 'nvidia-smi conf-compute' subcommand which is not
+// supported by all drivers. The environment and GPU ready state are optional.
+func getConfComputeInfo(nvidiaSMI string) (*confComputeInfo, error) {
+	output, err := exec.Command(nvidiaSMI, "conf-compute", "-f").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query confidential compute state: %v", err)
+	}
+	for _, flag := range []string{"-e", "-grs"} {
+		if o, err := exec.Command(nvidiaSMI, "conf-compute", flag).Output(); err == nil {
+			output = append(output, o...)
+		}
+	}
+
+	info := parseConfCompute(output)
+	if info.State == "" {
+		return nil, fmt.Errorf("unexpected output from %v conf-compute: %q", nvidiaSMI, output)
+	}
+	return info, nil
+}
+
+// parseConfCompute parses the key: value output of the 'nvidia-smi conf-compute' subcommand.
+func parseConfCompute(output []byte) *confComputeInfo {
+	info := &confComputeInfo{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		switch {
+		case strings.Contains(key, "ready"):
+			info.GPUsReady = value
+		case strings.Contains(key, "environment"):
+			info.Environment = value
+		case strings.Contains(key, "status") || strings.Contains(key, "state"):
+			info.State = value
+		}
+	}
+	return info
+}
@@ -0,0 +1,52 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfCompute(t *testing.T) {
+	testCases := []struct {
+		description string
+		output      string
+		expected    *confComputeInfo
+	}{
+		{
+			description: "empty output",
+			expected:    &confComputeInfo{},
+		},
+		{
+			description: "state only",
+			output:      "CC status: OFF\n",
+			expected:    &confComputeInfo{State: "OFF"},
+		},
+		{
+			description: "state, environment, and ready state",
+			output:      "CC status: ON\nCC Environment: PRODUCTION\nConfidential Compute GPUs Ready state: ready\n",
+			expected:    &confComputeInfo{State: "ON", Environment: "PRODUCTION", GPUsReady: "ready"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.EqualValues(t, tc.expected, parseConfCompute([]byte(tc.output)))
+		})
+	}
+}
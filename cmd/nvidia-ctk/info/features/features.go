@@ -0,0 +1,118 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	format string
+}
+
+// NewCommand constructs a features command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'features' command
+	c := cli.Command{
+		Name:  "features",
+		Usage: "List the known features and whether these are enabled by the config.toml file and the --feature-gates flag",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	gates, err := cfg.FeatureGates()
+	if err != nil {
+		return fmt.Errorf("invalid features config: %v", err)
+	}
+	if err := gates.SetFromString(c.String("feature-gates")); err != nil {
+		return fmt.Errorf("invalid feature gates: %v", err)
+	}
+
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(gates.Status())
+	}
+	return render(c.App.Writer, gates.Status())
+}
+
+// render writes the specified feature statuses to the writer as a table.
+func render(w io.Writer, statuses []features.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tSTAGE\tDEFAULT\tENABLED\tDESCRIPTION")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", s.Feature, s.Stage, s.Default, s.Enabled, s.Description)
+	}
+	return tw.Flush()
+}
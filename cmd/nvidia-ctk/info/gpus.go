@@ -0,0 +1,292 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/numa"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/procfs"
+	"github.com/sirupsen/logrus"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// gpuInfo holds a snapshot of the state of a GPU or MIG device.
+type gpuInfo struct {
+	index       string
+	uuid        string
+	name        string
+	memoryUsed  uint64
+	memoryTotal uint64
+	// utilization is nil if the utilization is not available (e.g. for MIG devices).
+	utilization *uint32
+	// topology is nil if the NUMA topology is not available (e.g. for MIG devices).
+	topology   *numa.GPU
+	containers []string
+	// mig is nil if the GPU is not MIG-capable.
+	mig        *migInfo
+	migDevices []gpuInfo
+}
+
+// collect queries NVML for the state of each GPU (and its MIG devices). Since the utilization of
+// a GPU is not exposed by the NVML interface, this is queried using the specified nvidia-smi
+// executable. The containers holding each GPU are determined from the processes under the
+// specified proc root.
+func collect(logger *logrus.Logger, nvmllib nvml.Interface, nvidiaSMI string, procRoot string) ([]gpuInfo, error) {
+	if r := nvmllib.Init(); r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvmllib.Shutdown()
+
+	count, r := nvmllib.DeviceGetCount()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get device count: %v", r)
+	}
+
+	utilization, err := getUtilization(nvidiaSMI)
+	if err != nil {
+		logger.Debugf("GPU utilization not available: %v", err)
+	}
+
+	var gpus []gpuInfo
+	for i := 0; i < count; i++ {
+		device, r := nvmllib.DeviceGetHandleByIndex(i)
+		if r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device %d: %v", i, r)
+		}
+		gpu, err := getGPUInfo(device, fmt.Sprintf("%d", i))
+		if err != nil {
+			return nil, err
+		}
+		if u, ok := utilization[gpu.uuid]; ok {
+			gpu.utilization = &u
+		}
+
+		if pciInfo, r := device.GetPciInfo(); r == nvml.SUCCESS {
+			topology, err := numa.GetGPU("/", info.GetBusID(pciInfo))
+			if err != nil {
+				logger.Debugf("Failed to determine NUMA topology of device %d: %v", i, err)
+			} else {
+				gpu.topology = &topology
+			}
+		}
+
+		if minor, r := device.GetMinorNumber(); r == nvml.SUCCESS {
+			gpu.containers = getContainers(logger, procRoot, fmt.Sprintf("/dev/nvidia%d", minor))
+		}
+
+		gpu.mig, err = getMIGInfo(device, gpu.index)
+		if err != nil {
+			return nil, err
+		}
+
+		gpu.migDevices, err = getMIGDevices(device, gpu.index)
+		if err != nil {
+			return nil, err
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// getGPUInfo returns the info for the specified device. Values that are not supported by the
+// device are ignored.
+func getGPUInfo(device nvml.Device, index string) (gpuInfo, error) {
+	uuid, r := device.GetUUID()
+	if r != nvml.SUCCESS {
+		return gpuInfo{}, fmt.Errorf("failed to get UUID of device %v: %v", index, r)
+	}
+	gpu := gpuInfo{
+		index: index,
+		uuid:  uuid,
+	}
+	if name, r := device.GetName(); r == nvml.SUCCESS {
+		gpu.name = name
+	}
+	if memory, r := device.GetMemoryInfo(); r == nvml.SUCCESS {
+		gpu.memoryUsed = memory.Used
+		gpu.memoryTotal = memory.Total
+	}
+	return gpu, nil
+}
+
+// getUtilization queries the utilization of the GPUs using the specified nvidia-smi executable.
+// The utilization is returned by GPU UUID.
+func getUtilization(nvidiaSMI string) (map[string]uint32, error) {
+	output, err := exec.Command(nvidiaSMI, "--query-gpu=uuid,utilization.gpu", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU utilization: %v", err)
+	}
+	return parseUtilization(output), nil
+}
+
+// parseUtilization parses the uuid, utilization CSV output of nvidia-smi. GPUs for which the
+// utilization is not supported (e.g. [N/A]) are ignored.
+func parseUtilization(output []byte) map[string]uint32 {
+	utilization := make(map[string]uint32)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ",", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil {
+			continue
+		}
+		utilization[strings.TrimSpace(parts[0])] = uint32(value)
+	}
+	return utilization
+}
+
+// getMIGDevices returns the info for the MIG devices of the specified GPU.
+func getMIGDevices(device nvml.Device, parentIndex string) ([]gpuInfo, error) {
+	mode, _, r := device.GetMigMode()
+	if r != nvml.SUCCESS || mode != nvml.DEVICE_MIG_ENABLE {
+		return nil, nil
+	}
+	maxCount, r := device.GetMaxMigDeviceCount()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get MIG device count for device %v: %v", parentIndex, r)
+	}
+
+	var migs []gpuInfo
+	for j := 0; j < maxCount; j++ {
+		mig, r := device.GetMigDeviceHandleByIndex(j)
+		if r == nvml.ERROR_NOT_FOUND {
+			continue
+		}
+		if r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get MIG device %d of device %v: %v", j, parentIndex, r)
+		}
+		migGPU, err := getGPUInfo(mig, fmt.Sprintf("%v:%d", parentIndex, j))
+		if err != nil {
+			return nil, err
+		}
+		migs = append(migs, migGPU)
+	}
+	return migs, nil
+}
+
+// getContainers returns the IDs of the containers that have the specified device node open.
+func getContainers(logger *logrus.Logger, procRoot string, devicePath string) []string {
+	processes, err := procfs.FindDeviceProcesses(procRoot, devicePath)
+	if err != nil {
+		logger.Debugf("Failed to determine processes using %v: %v", devicePath, err)
+		return nil
+	}
+	seen := make(map[string]bool)
+	var containers []string
+	for _, p := range processes {
+		id := p.ContainerOrHost()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		containers = append(containers, id)
+	}
+	sort.Strings(containers)
+	return containers
+}
+
+// render writes a table of the specified GPUs to the writer. The confidential compute state is
+// included if known.
+func render(w io.Writer, now time.Time, gpus []gpuInfo, cc *confComputeInfo) error {
+	fmt.Fprintf(w, "%v\n\n", now.Format(time.RFC1123))
+	if cc != nil {
+		state := cc.State
+		if cc.Environment != "" {
+			state += fmt.Sprintf(" (%v)", cc.Environment)
+		}
+		fmt.Fprintf(w, "Confidential compute: %v\n\n", state)
+	}
+	if len(gpus) == 0 {
+		fmt.Fprintln(w, "No GPUs found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GPU\tNAME\tUUID\tMEMORY\tUTIL\tNUMA\tCONTAINERS")
+	for _, gpu := range gpus {
+		writeRow(tw, gpu, "")
+		for _, mig := range gpu.migDevices {
+			writeRow(tw, mig, "  ")
+		}
+	}
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, gpu gpuInfo, indent string) {
+	utilization := "-"
+	if gpu.utilization != nil {
+		utilization = fmt.Sprintf("%d%%", *gpu.utilization)
+	}
+	containers := "-"
+	if len(gpu.containers) > 0 {
+		var ids []string
+		for _, id := range gpu.containers {
+			ids = append(ids, shortID(id))
+		}
+		containers = strings.Join(ids, ",")
+	}
+	fmt.Fprintf(w, "%v%v\t%v\t%v\t%v / %v MiB\t%v\t%v\t%v\n",
+		indent, gpu.index, gpu.name, gpu.uuid,
+		gpu.memoryUsed/(1024*1024), gpu.memoryTotal/(1024*1024),
+		utilization, formatTopology(gpu.topology), containers,
+	)
+}
+
+// formatTopology returns the NUMA node of the CPUs closest to the GPU and, for GPUs with coherent
+// memory (e.g. GH200), the NUMA node of the GPU memory.
+func formatTopology(topology *numa.GPU) string {
+	if topology == nil {
+		return "-"
+	}
+	var parts []string
+	if topology.CPUNode >= 0 {
+		parts = append(parts, fmt.Sprintf("cpu:%d", topology.CPUNode))
+	}
+	if topology.IsCoherent() {
+		memory := fmt.Sprintf("mem:%d", topology.MemoryNode)
+		if topology.MemoryStatus != numa.StatusOnline {
+			memory += fmt.Sprintf("(%v)", topology.MemoryStatus)
+		}
+		parts = append(parts, memory)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ",")
+}
+
+// shortID returns the short (12 character) form of a container ID.
+func shortID(id string) string {
+	if len(id) > 12 && !strings.HasPrefix(id, "<") {
+		return id[:12]
+	}
+	return id
+}
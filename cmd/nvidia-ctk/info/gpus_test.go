@@ -0,0 +1,128 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/numa"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	utilization := uint32(42)
+
+	testCases := []struct {
+		description string
+		gpus        []gpuInfo
+		cc          *confComputeInfo
+		expected    string
+	}{
+		{
+			description: "no gpus",
+			expected:    "Wed, 01 Mar 2023 12:00:00 UTC\n\nNo GPUs found\n",
+		},
+		{
+			description: "confidential compute state",
+			cc:          &confComputeInfo{State: "ON", Environment: "PRODUCTION"},
+			expected:    "Wed, 01 Mar 2023 12:00:00 UTC\n\nConfidential compute: ON (PRODUCTION)\n\nNo GPUs found\n",
+		},
+		{
+			description: "gpus with MIG devices and containers",
+			gpus: []gpuInfo{
+				{
+					index:       "0",
+					name:        "A100",
+					uuid:        "GPU-0",
+					memoryUsed:  1024 * 1024 * 1024,
+					memoryTotal: 40 * 1024 * 1024 * 1024,
+					utilization: &utilization,
+					containers:  []string{"0123456789abcdef0123", "<host>"},
+				},
+				{
+					index:       "1",
+					name:        "A100",
+					uuid:        "GPU-1",
+					memoryTotal: 40 * 1024 * 1024 * 1024,
+					migDevices: []gpuInfo{
+						{
+							index:       "1:0",
+							uuid:        "MIG-0",
+							memoryTotal: 5 * 1024 * 1024 * 1024,
+						},
+					},
+				},
+			},
+			expected: `Wed, 01 Mar 2023 12:00:00 UTC
+
+GPU    NAME  UUID   MEMORY            UTIL  NUMA  CONTAINERS
+0      A100  GPU-0  1024 / 40960 MiB  42%   -     0123456789ab,<host>
+1      A100  GPU-1  0 / 40960 MiB     -     -     -
+  1:0        MIG-0  0 / 5120 MiB      -     -     -
+`,
+		},
+		{
+			description: "gpus with numa topology",
+			gpus: []gpuInfo{
+				{
+					index:       "0",
+					name:        "GH200",
+					uuid:        "GPU-0",
+					memoryTotal: 96 * 1024 * 1024 * 1024,
+					topology:    &numa.GPU{BusID: "0009:01:00.0", CPUNode: 0, MemoryNode: 1, MemoryStatus: "online"},
+				},
+				{
+					index:       "1",
+					name:        "GH200",
+					uuid:        "GPU-1",
+					memoryTotal: 96 * 1024 * 1024 * 1024,
+					topology:    &numa.GPU{BusID: "0019:01:00.0", CPUNode: 2, MemoryNode: 3, MemoryStatus: "offline"},
+				},
+				{
+					index:       "2",
+					name:        "A100",
+					uuid:        "GPU-2",
+					memoryTotal: 40 * 1024 * 1024 * 1024,
+					topology:    &numa.GPU{BusID: "0000:01:00.0", CPUNode: 0, MemoryNode: -1},
+				},
+			},
+			expected: `Wed, 01 Mar 2023 12:00:00 UTC
+
+GPU  NAME   UUID   MEMORY         UTIL  NUMA                  CONTAINERS
+0    GH200  GPU-0  0 / 98304 MiB  -     cpu:0,mem:1           -
+1    GH200  GPU-1  0 / 98304 MiB  -     cpu:2,mem:3(offline)  -
+2    A100   GPU-2  0 / 40960 MiB  -     cpu:0                 -
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, render(buf, now, tc.gpus, tc.cc))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestParseUtilization(t *testing.T) {
+	output := "GPU-0, 42\nGPU-1, [N/A]\nGPU-2, 0\n"
+	require.Equal(t, map[string]uint32{"GPU-0": 42, "GPU-2": 0}, parseUtilization([]byte(output)))
+}
@@ -17,14 +17,42 @@
 package info
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/info/features"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/info/latency"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/info/modes"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+const (
+	defaultInterval = 2 * time.Second
+
+	formatTable = "table"
+	formatJSON  = "json"
+
+	// clearScreen is the ANSI escape sequence used to clear the terminal between updates.
+	clearScreen = "\033[H\033[2J"
 )
 
 type command struct {
 	logger *logrus.Logger
 }
 
+type options struct {
+	gpus      bool
+	watch     bool
+	interval  time.Duration
+	format    string
+	nvidiaSMI string
+}
+
 // NewCommand constructs an info command with the specified logger
 func NewCommand(logger *logrus.Logger) *cli.Command {
 	c := command{
@@ -35,13 +63,116 @@ func NewCommand(logger *logrus.Logger) *cli.Command {
 
 // build
 func (m command) build() *cli.Command {
-	// Create the 'hook' command
-	hook := cli.Command{
+	opts := options{}
+
+	// Create the 'info' command
+	info := cli.Command{
 		Name:  "info",
 		Usage: "Provide information about the system",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	info.Flags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "gpus",
+			Usage:       "Show the state of the GPUs as queried using NVML. This requires the NVIDIA driver to be installed",
+			Destination: &opts.gpus,
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "Continuously refresh the GPU information until interrupted. Requires --gpus",
+			Destination: &opts.watch,
+		},
+		&cli.DurationFlag{
+			Name:        "interval",
+			Usage:       "The interval at which the GPU information is refreshed in watch mode",
+			Value:       defaultInterval,
+			Destination: &opts.interval,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]. The json format includes the supported MIG profiles and instantiated GPU and compute instances of each GPU",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+		&cli.StringFlag{
+			Name:        "nvidia-smi",
+			Usage:       "The path to the nvidia-smi executable used to query the GPU utilization and the confidential compute state",
+			Value:       "nvidia-smi",
+			Destination: &opts.nvidiaSMI,
+		},
+	}
+
+	info.Subcommands = []*cli.Command{
+		features.NewCommand(m.logger),
+		latency.NewCommand(m.logger),
+		modes.NewCommand(m.logger),
+	}
+
+	return &info
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	if !opts.gpus {
+		if opts.watch || c.IsSet("format") {
+			return fmt.Errorf("the --watch and --format flags require --gpus")
+		}
+		return cli.ShowSubcommandHelp(c)
+	}
+
+	switch opts.format {
+	case formatTable:
+	case formatJSON:
+		if opts.watch {
+			return fmt.Errorf("the %v format is not supported in watch mode", opts.format)
+		}
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+
+	nvmllib := nvml.New()
+	if !opts.watch {
+		return m.printSnapshot(c, opts, nvmllib)
+	}
+
+	if opts.interval <= 0 {
+		return fmt.Errorf("invalid interval %v", opts.interval)
 	}
 
-	hook.Subcommands = []*cli.Command{}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
 
-	return &hook
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		fmt.Fprint(c.App.Writer, clearScreen)
+		if err := m.printSnapshot(c, opts, nvmllib); err != nil {
+			return err
+		}
+		select {
+		case <-sigs:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m command) printSnapshot(c *cli.Context, opts *options, nvmllib nvml.Interface) error {
+	gpus, err := collect(m.logger, nvmllib, opts.nvidiaSMI, "/proc")
+	if err != nil {
+		return err
+	}
+
+	cc, err := getConfComputeInfo(opts.nvidiaSMI)
+	if err != nil {
+		m.logger.Debugf("Confidential compute state not available: %v", err)
+	}
+
+	if opts.format == formatJSON {
+		return renderJSON(c.App.Writer, time.Now(), gpus, cc)
+	}
+	return render(c.App.Writer, time.Now(), gpus, cc)
 }
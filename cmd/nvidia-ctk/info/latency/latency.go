@@ -0,0 +1,138 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	stateFile string
+	format    string
+}
+
+// NewCommand constructs a latency command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'latency' command
+	c := cli.Command{
+		Name:  "latency",
+		Usage: "Summarize the time spent by the NVIDIA Container Runtime in each phase of the creation of recent containers",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "state-file",
+			Usage:       "The file in which the startup latency is recorded. If this is not specified, the path from the config is used",
+			Destination: &opts.stateFile,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+
+	if opts.stateFile == "" {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		opts.stateFile = cfg.NVIDIAContainerRuntimeConfig.StartupLatency.StateFile
+	}
+	if opts.stateFile == "" {
+		opts.stateFile = latency.DefaultStateFile
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	entries, err := latency.NewStore(opts.stateFile).Entries()
+	if err != nil {
+		return fmt.Errorf("failed to read startup latency: %v", err)
+	}
+	if len(entries) == 0 {
+		m.logger.Warningf("No startup latency recorded in %v; is nvidia-container-runtime.startup-latency.enabled set?", opts.stateFile)
+	}
+
+	summaries := latency.Summarize(entries)
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	}
+	return render(c.App.Writer, summaries)
+}
+
+// render writes the specified summaries to the writer as a table.
+func render(w io.Writer, summaries []latency.Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tCOUNT\tP50\tP90\tP99\tMAX")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", s.Phase, s.Count, format(s.P50), format(s.P90), format(s.P99), format(s.Max))
+	}
+	return tw.Flush()
+}
+
+// format returns the specified duration rounded to microseconds.
+func format(d latency.Milliseconds) string {
+	return time.Duration(d).Round(time.Microsecond).String()
+}
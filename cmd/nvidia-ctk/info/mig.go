@@ -0,0 +1,251 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"fmt"
+	"math"
+
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// migInfo holds the MIG state of a MIG-capable GPU.
+type migInfo struct {
+	Enabled      bool          `json:"enabled"`
+	Profiles     []migProfile  `json:"profiles,omitempty"`
+	GPUInstances []gpuInstance `json:"gpuInstances,omitempty"`
+}
+
+// migProfile describes a GPU instance profile supported by a GPU.
+type migProfile struct {
+	ID                 int         `json:"id"`
+	Name               string      `json:"name"`
+	Slices             int         `json:"slices"`
+	MemoryMB           uint64      `json:"memoryMB"`
+	Multiprocessors    int         `json:"multiprocessors"`
+	MaxInstances       int         `json:"maxInstances"`
+	AvailableInstances int         `json:"availableInstances"`
+	Placements         []placement `json:"placements,omitempty"`
+}
+
+// placement describes the memory slices occupied by a GPU instance.
+type placement struct {
+	Start int `json:"start"`
+	Size  int `json:"size"`
+}
+
+// gpuInstance describes an instantiated GPU instance (GI).
+type gpuInstance struct {
+	ID               int               `json:"id"`
+	ProfileID        int               `json:"profileId"`
+	Profile          string            `json:"profile"`
+	Placement        placement         `json:"placement"`
+	ComputeInstances []computeInstance `json:"computeInstances,omitempty"`
+}
+
+// computeInstance describes an instantiated compute instance (CI) of a GPU instance.
+type computeInstance struct {
+	ID        int    `json:"id"`
+	ProfileID int    `json:"profileId"`
+	Profile   string `json:"profile"`
+}
+
+// getMIGInfo returns the supported MIG profiles and the instantiated GPU and compute instances of
+// the specified device. If the device is not MIG-capable, nil is returned.
+func getMIGInfo(device nvml.Device, index string) (*migInfo, error) {
+	mode, _, r := device.GetMigMode()
+	if r == nvml.ERROR_NOT_SUPPORTED {
+		return nil, nil
+	}
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get MIG mode of device %v: %v", index, r)
+	}
+	info := migInfo{
+		Enabled: mode == nvml.DEVICE_MIG_ENABLE,
+	}
+
+	memory, r := device.GetMemoryInfo()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get memory info of device %v: %v", index, r)
+	}
+
+	for id := 0; id < nvml.GPU_INSTANCE_PROFILE_COUNT; id++ {
+		profileInfo, r := device.GetGpuInstanceProfileInfo(id)
+		if r == nvml.ERROR_NOT_SUPPORTED || r == nvml.ERROR_INVALID_ARGUMENT {
+			continue
+		}
+		if r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get GPU instance profile %d of device %v: %v", id, index, r)
+		}
+
+		profile := migProfile{
+			ID:              id,
+			Name:            migProfileName(id, profileInfo.SliceCount, profileInfo.SliceCount, profileInfo.MemorySizeMB, memory.Total),
+			Slices:          int(profileInfo.SliceCount),
+			MemoryMB:        profileInfo.MemorySizeMB,
+			Multiprocessors: int(profileInfo.MultiprocessorCount),
+			MaxInstances:    int(profileInfo.InstanceCount),
+		}
+		if !info.Enabled {
+			info.Profiles = append(info.Profiles, profile)
+			continue
+		}
+
+		if profileInfo.InstanceCount > 0 {
+			if placements, r := device.GetGpuInstancePossiblePlacements(&profileInfo); r == nvml.SUCCESS {
+				for _, p := range placements {
+					profile.Placements = append(profile.Placements, placement{Start: int(p.Start), Size: int(p.Size)})
+				}
+			}
+		}
+		info.Profiles = append(info.Profiles, profile)
+
+		gis, err := getGPUInstances(device, index, id, &profileInfo, memory.Total)
+		if err != nil {
+			return nil, err
+		}
+		info.GPUInstances = append(info.GPUInstances, gis...)
+	}
+
+	for i := range info.Profiles {
+		info.Profiles[i].AvailableInstances = availableInstances(info.Profiles[i], info.GPUInstances)
+	}
+
+	return &info, nil
+}
+
+// availableInstances returns the number of GPU instances of the specified profile that can still be
+// created given the existing GPU instances. Since the remaining capacity is not exposed by the NVML
+// interface, this is the number of possible placements of the profile that can be used together
+// without overlapping each other or the placements of the existing GPU instances.
+func availableInstances(profile migProfile, gis []gpuInstance) int {
+	remaining := profile.MaxInstances
+	var occupied []placement
+	for _, gi := range gis {
+		if gi.ProfileID == profile.ID {
+			remaining--
+		}
+		occupied = append(occupied, gi.Placement)
+	}
+
+	var available int
+	for _, p := range profile.Placements {
+		if available >= remaining {
+			break
+		}
+		if p.overlapsAny(occupied) {
+			continue
+		}
+		occupied = append(occupied, p)
+		available++
+	}
+	return available
+}
+
+// overlapsAny checks whether the placement overlaps any of the specified placements.
+func (p placement) overlapsAny(placements []placement) bool {
+	for _, o := range placements {
+		if p.Start < o.Start+o.Size && o.Start < p.Start+p.Size {
+			return true
+		}
+	}
+	return false
+}
+
+// getGPUInstances returns the instantiated GPU instances for the specified profile.
+func getGPUInstances(device nvml.Device, index string, profileID int, profileInfo *nvml.GpuInstanceProfileInfo, totalMemory uint64) ([]gpuInstance, error) {
+	if profileInfo.InstanceCount == 0 {
+		return nil, nil
+	}
+	instances, r := device.GetGpuInstances(profileInfo)
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get GPU instances for profile %d of device %v: %v", profileID, index, r)
+	}
+
+	var gis []gpuInstance
+	for _, instance := range instances {
+		info, r := instance.GetInfo()
+		if r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get GPU instance info of device %v: %v", index, r)
+		}
+		gi := gpuInstance{
+			ID:        int(info.Id),
+			ProfileID: profileID,
+			Profile:   migProfileName(profileID, profileInfo.SliceCount, profileInfo.SliceCount, profileInfo.MemorySizeMB, totalMemory),
+			Placement: placement{Start: int(info.Placement.Start), Size: int(info.Placement.Size)},
+		}
+
+		for ciProfileID := 0; ciProfileID < nvml.COMPUTE_INSTANCE_PROFILE_COUNT; ciProfileID++ {
+			ciProfileInfo, r := instance.GetComputeInstanceProfileInfo(ciProfileID, nvml.COMPUTE_INSTANCE_ENGINE_PROFILE_SHARED)
+			if r == nvml.ERROR_NOT_SUPPORTED || r == nvml.ERROR_INVALID_ARGUMENT {
+				continue
+			}
+			if r != nvml.SUCCESS {
+				return nil, fmt.Errorf("failed to get compute instance profile %d of GPU instance %d: %v", ciProfileID, gi.ID, r)
+			}
+			if ciProfileInfo.InstanceCount == 0 {
+				continue
+			}
+			cis, r := instance.GetComputeInstances(&ciProfileInfo)
+			if r != nvml.SUCCESS {
+				return nil, fmt.Errorf("failed to get compute instances of GPU instance %d: %v", gi.ID, r)
+			}
+			for _, ci := range cis {
+				ciInfo, r := ci.GetInfo()
+				if r != nvml.SUCCESS {
+					return nil, fmt.Errorf("failed to get compute instance info of GPU instance %d: %v", gi.ID, r)
+				}
+				gi.ComputeInstances = append(gi.ComputeInstances, computeInstance{
+					ID:        int(ciInfo.Id),
+					ProfileID: ciProfileID,
+					Profile:   migProfileName(profileID, ciProfileInfo.SliceCount, profileInfo.SliceCount, profileInfo.MemorySizeMB, totalMemory),
+				})
+			}
+		}
+		gis = append(gis, gi)
+	}
+	return gis, nil
+}
+
+// migProfileName returns the canonical name of a MIG profile (e.g. 1g.10gb, 1c.2g.20gb, or 1g.10gb+me).
+// The memory size is expressed as the fraction (in eighths) of the total device memory, as is done by
+// nvidia-smi.
+func migProfileName(giProfileID int, ciSlices uint32, giSlices uint32, memorySizeMB uint64, totalMemory uint64) string {
+	var suffix string
+	if giProfileID == nvml.GPU_INSTANCE_PROFILE_1_SLICE_REV1 {
+		suffix = "+me"
+	}
+	gb := migMemorySizeGB(memorySizeMB, totalMemory)
+	if ciSlices == giSlices {
+		return fmt.Sprintf("%dg.%dgb%s", giSlices, gb, suffix)
+	}
+	return fmt.Sprintf("%dc.%dg.%dgb%s", ciSlices, giSlices, gb, suffix)
+}
+
+// migMemorySizeGB returns the memory size of a MIG profile in GB.
+func migMemorySizeGB(memorySizeMB uint64, totalMemory uint64) uint64 {
+	const fracDenominator = 8
+	const oneMB = 1024 * 1024
+	const oneGB = 1024 * 1024 * 1024
+	if totalMemory == 0 {
+		return 0
+	}
+	fraction := (float64(memorySizeMB) * oneMB) / float64(totalMemory)
+	fraction = math.Ceil(fraction*fracDenominator) / fracDenominator
+	totalGB := float64((totalMemory + oneGB - 1) / oneGB)
+	return uint64(math.Round(fraction * totalGB))
+}
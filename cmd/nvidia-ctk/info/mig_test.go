@@ -0,0 +1,140 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+func TestMigProfileName(t *testing.T) {
+	const a100 = 40 * 1024 * 1024 * 1024
+	const h100 = 80 * 1024 * 1024 * 1024
+
+	testCases := []struct {
+		description  string
+		giProfileID  int
+		ciSlices     uint32
+		giSlices     uint32
+		memorySizeMB uint64
+		totalMemory  uint64
+		expected     string
+	}{
+		{
+			description:  "a100 1g.5gb",
+			giProfileID:  nvml.GPU_INSTANCE_PROFILE_1_SLICE,
+			ciSlices:     1,
+			giSlices:     1,
+			memorySizeMB: 4864,
+			totalMemory:  a100,
+			expected:     "1g.5gb",
+		},
+		{
+			description:  "a100 7g.40gb",
+			giProfileID:  nvml.GPU_INSTANCE_PROFILE_7_SLICE,
+			ciSlices:     7,
+			giSlices:     7,
+			memorySizeMB: 40192,
+			totalMemory:  a100,
+			expected:     "7g.40gb",
+		},
+		{
+			description:  "h100 compute instance",
+			giProfileID:  nvml.GPU_INSTANCE_PROFILE_3_SLICE,
+			ciSlices:     1,
+			giSlices:     3,
+			memorySizeMB: 40448,
+			totalMemory:  h100,
+			expected:     "1c.3g.40gb",
+		},
+		{
+			description:  "media extensions",
+			giProfileID:  nvml.GPU_INSTANCE_PROFILE_1_SLICE_REV1,
+			ciSlices:     1,
+			giSlices:     1,
+			memorySizeMB: 9856,
+			totalMemory:  h100,
+			expected:     "1g.10gb+me",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, migProfileName(tc.giProfileID, tc.ciSlices, tc.giSlices, tc.memorySizeMB, tc.totalMemory))
+		})
+	}
+}
+
+func TestAvailableInstances(t *testing.T) {
+	// The possible placements of the 1g.5gb and 3g.20gb profiles of an A100.
+	oneSlice := migProfile{
+		ID:           nvml.GPU_INSTANCE_PROFILE_1_SLICE,
+		MaxInstances: 7,
+		Placements:   []placement{{0, 1}, {1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}, {6, 1}},
+	}
+	threeSlice := migProfile{
+		ID:           nvml.GPU_INSTANCE_PROFILE_3_SLICE,
+		MaxInstances: 2,
+		Placements:   []placement{{0, 4}, {4, 4}},
+	}
+
+	testCases := []struct {
+		description string
+		profile     migProfile
+		gis         []gpuInstance
+		expected    int
+	}{
+		{
+			description: "no gpu instances",
+			profile:     oneSlice,
+			expected:    7,
+		},
+		{
+			description: "overlapping gpu instance",
+			profile:     oneSlice,
+			gis: []gpuInstance{
+				{ProfileID: nvml.GPU_INSTANCE_PROFILE_3_SLICE, Placement: placement{0, 4}},
+			},
+			expected: 3,
+		},
+		{
+			description: "gpu instances of the same profile",
+			profile:     threeSlice,
+			gis: []gpuInstance{
+				{ProfileID: nvml.GPU_INSTANCE_PROFILE_3_SLICE, Placement: placement{4, 4}},
+			},
+			expected: 1,
+		},
+		{
+			description: "no placement available",
+			profile:     threeSlice,
+			gis: []gpuInstance{
+				{ProfileID: nvml.GPU_INSTANCE_PROFILE_1_SLICE, Placement: placement{2, 1}},
+				{ProfileID: nvml.GPU_INSTANCE_PROFILE_1_SLICE, Placement: placement{5, 1}},
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, availableInstances(tc.profile, tc.gis))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	format string
+}
+
+// NewCommand constructs a modes command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'modes' command
+	c := cli.Command{
+		Name:  "modes",
+		Usage: "Report which runtime modes are viable on this host and why, including the mode that 'auto' resolves to",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	specDirs := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	if len(specDirs) == 0 {
+		specDirs = cdi.DefaultSpecDirs
+	}
+
+	checks := checker{
+		host:             platform{},
+		cdiSpecDirs:      specDirs,
+		csvMountSpecPath: cfg.NVIDIAContainerRuntimeConfig.Modes.CSV.MountSpecPath,
+		sysfsRoot:        "/sys",
+	}
+	matrix := checks.getSupportMatrix(cfg.NVIDIAContainerRuntimeConfig.Mode)
+
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(matrix)
+	}
+	return render(c.App.Writer, matrix)
+}
+
+// render writes the specified support matrix to the writer as a table.
+func render(w io.Writer, matrix *supportMatrix) error {
+	fmt.Fprintf(w, "Configured mode: %v\n", matrix.ConfiguredMode)
+	fmt.Fprintf(w, "Auto-detected mode: %v (%v)\n\n", matrix.AutoMode, matrix.AutoReason)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tVIABLE\tREASONS")
+	for _, s := range matrix.Modes {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", s.Mode, s.Viable, strings.Join(s.Reasons, "; "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,142 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modes
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHost struct {
+	dxcore bool
+	nvml   bool
+	tegra  bool
+}
+
+func (h fakeHost) HasDXCore() (bool, string)     { return h.dxcore, "dxcore" }
+func (h fakeHost) HasNvml() (bool, string)       { return h.nvml, "nvml" }
+func (h fakeHost) IsTegraSystem() (bool, string) { return h.tegra, "tegra" }
+
+func TestGetSupportMatrix(t *testing.T) {
+	root := t.TempDir()
+
+	specDir := filepath.Join(root, "cdi")
+	require.NoError(t, os.MkdirAll(specDir, 0755))
+	spec := `{"cdiVersion": "0.5.0", "kind": "nvidia.com/gpu", "devices": [{"name": "0", "containerEdits": {"deviceNodes": [{"path": "/dev/nvidia0"}]}}, {"name": "all", "containerEdits": {"deviceNodes": [{"path": "/dev/nvidia0"}]}}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.json"), []byte(spec), 0644))
+
+	csvDir := filepath.Join(root, "csv")
+	require.NoError(t, os.MkdirAll(csvDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(csvDir, "l4t.csv"), nil, 0644))
+
+	for _, d := range []struct {
+		address string
+		vendor  string
+	}{
+		{"0000:01:00.0", "0x10de"},
+		{"0000:02:00.0", "0x8086"},
+	} {
+		path := filepath.Join(root, "sys/bus/pci/drivers/vfio-pci", d.address)
+		require.NoError(t, os.MkdirAll(path, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "vendor"), []byte(d.vendor+"\n"), 0644))
+	}
+
+	testCases := []struct {
+		description      string
+		host             fakeHost
+		cdiSpecDirs      []string
+		csvMountSpecPath string
+		sysfsRoot        string
+		expected         *supportMatrix
+	}{
+		{
+			description:      "nothing detected",
+			cdiSpecDirs:      []string{filepath.Join(root, "missing")},
+			csvMountSpecPath: filepath.Join(root, "missing"),
+			sysfsRoot:        filepath.Join(root, "missing"),
+			expected: &supportMatrix{
+				ConfiguredMode: "auto",
+				AutoMode:       "legacy",
+				AutoReason:     "not a Tegra-based system without NVML",
+				Modes: []modeSupport{
+					{Mode: "legacy", Reasons: []string{"nvml"}},
+					{Mode: "csv", Reasons: []string{"tegra", "no CSV files found in " + filepath.Join(root, "missing")}},
+					{Mode: "cdi", Reasons: []string{"no CDI specifications defining nvidia.com/* devices found in [" + filepath.Join(root, "missing") + "]"}},
+					{Mode: "wsl", Reasons: []string{"dxcore"}},
+					{Mode: "vfio", Reasons: []string{"vfio-pci driver not loaded: " + filepath.Join(root, "missing", "bus/pci/drivers/vfio-pci") + " not found"}},
+				},
+			},
+		},
+		{
+			description:      "tegra system without nvml",
+			host:             fakeHost{tegra: true},
+			cdiSpecDirs:      []string{specDir},
+			csvMountSpecPath: csvDir,
+			sysfsRoot:        filepath.Join(root, "sys"),
+			expected: &supportMatrix{
+				ConfiguredMode: "auto",
+				AutoMode:       "csv",
+				AutoReason:     "Tegra-based system without NVML",
+				Modes: []modeSupport{
+					{Mode: "legacy", Reasons: []string{"nvml"}},
+					{Mode: "csv", Viable: true, Reasons: []string{"tegra", "found 1 CSV files in " + csvDir}},
+					{Mode: "cdi", Viable: true, Reasons: []string{"found 1 CDI specifications defining 2 devices in [" + specDir + "]"}},
+					{Mode: "wsl", Reasons: []string{"dxcore"}},
+					{Mode: "vfio", Viable: true, Reasons: []string{"found 1 NVIDIA devices bound to vfio-pci: 0000:01:00.0"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			c := checker{
+				host:             tc.host,
+				cdiSpecDirs:      tc.cdiSpecDirs,
+				csvMountSpecPath: tc.csvMountSpecPath,
+				sysfsRoot:        tc.sysfsRoot,
+			}
+			require.Equal(t, tc.expected, c.getSupportMatrix("auto"))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	matrix := &supportMatrix{
+		ConfiguredMode: "auto",
+		AutoMode:       "legacy",
+		AutoReason:     "not a Tegra-based system without NVML",
+		Modes: []modeSupport{
+			{Mode: "legacy", Viable: true, Reasons: []string{"found NVML library"}},
+			{Mode: "csv", Reasons: []string{"not tegra", "no CSV files"}},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	require.NoError(t, render(&buf, matrix))
+	require.Equal(t, `Configured mode: auto
+Auto-detected mode: legacy (not a Tegra-based system without NVML)
+
+MODE    VIABLE  REASONS
+legacy  true    found NVML library
+csv     false   not tegra; no CSV files
+`, buf.String())
+}
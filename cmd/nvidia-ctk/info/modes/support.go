@@ -0,0 +1,197 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover/csv"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+)
+
+const (
+	modeLegacy = "legacy"
+	modeCSV    = "csv"
+	modeCDI    = "cdi"
+	modeWSL    = "wsl"
+	modeVFIO   = "vfio"
+
+	nvidiaVendorID = "0x10de"
+	nvidiaCDIKind  = "nvidia.com/"
+)
+
+// supportMatrix reports which modes are viable on the host and the mode that the auto mode resolves to.
+type supportMatrix struct {
+	ConfiguredMode string        `json:"configuredMode"`
+	AutoMode       string        `json:"autoMode"`
+	AutoReason     string        `json:"autoReason"`
+	Modes          []modeSupport `json:"modes"`
+}
+
+// modeSupport reports whether a mode is viable on the host together with the reasons for the result.
+type modeSupport struct {
+	Mode    string   `json:"mode"`
+	Viable  bool     `json:"viable"`
+	Reasons []string `json:"reasons"`
+}
+
+// host defines the checks of the host platform that determine the viable modes.
+type host interface {
+	HasDXCore() (bool, string)
+	HasNvml() (bool, string)
+	IsTegraSystem() (bool, string)
+}
+
+// platform implements the host checks using the platform checks of the info package.
+type platform struct{}
+
+func (platform) HasDXCore() (bool, string)     { return info.HasDXCore() }
+func (platform) HasNvml() (bool, string)       { return info.HasNvml() }
+func (platform) IsTegraSystem() (bool, string) { return info.IsTegraSystem() }
+
+// checker determines the viable modes for a host.
+type checker struct {
+	host             host
+	cdiSpecDirs      []string
+	csvMountSpecPath string
+	sysfsRoot        string
+}
+
+// getSupportMatrix checks which modes are viable on the host. The auto mode is resolved as is done
+// by the NVIDIA Container Runtime (see info.ResolveAutoMode).
+func (c checker) getSupportMatrix(configuredMode string) *supportMatrix {
+	hasNvml, nvmlReason := c.host.HasNvml()
+	isTegra, tegraReason := c.host.IsTegraSystem()
+	hasDXCore, dxcoreReason := c.host.HasDXCore()
+
+	matrix := supportMatrix{
+		ConfiguredMode: configuredMode,
+		AutoMode:       modeLegacy,
+		AutoReason:     "not a Tegra-based system without NVML",
+	}
+	if isTegra && !hasNvml {
+		matrix.AutoMode = modeCSV
+		matrix.AutoReason = "Tegra-based system without NVML"
+	}
+
+	matrix.Modes = []modeSupport{
+		{
+			Mode:    modeLegacy,
+			Viable:  hasNvml,
+			Reasons: []string{nvmlReason},
+		},
+		c.checkCSV(isTegra, tegraReason),
+		c.checkCDI(),
+		{
+			Mode:    modeWSL,
+			Viable:  hasDXCore,
+			Reasons: []string{dxcoreReason},
+		},
+		c.checkVFIO(),
+	}
+	return &matrix
+}
+
+// checkCSV checks whether CSV mode is viable. This requires a Tegra-based system and CSV files in
+// the mount spec path.
+func (c checker) checkCSV(isTegra bool, tegraReason string) modeSupport {
+	s := modeSupport{
+		Mode:    modeCSV,
+		Reasons: []string{tegraReason},
+	}
+
+	files, err := csv.GetFileList(c.csvMountSpecPath)
+	switch {
+	case err != nil:
+		s.Reasons = append(s.Reasons, err.Error())
+	case len(files) == 0:
+		s.Reasons = append(s.Reasons, fmt.Sprintf("no CSV files found in %v", c.csvMountSpecPath))
+	default:
+		s.Reasons = append(s.Reasons, fmt.Sprintf("found %d CSV files in %v", len(files), c.csvMountSpecPath))
+	}
+
+	s.Viable = isTegra && err == nil && len(files) > 0
+	return s
+}
+
+// checkCDI checks whether CDI mode is viable. This requires a CDI specification that defines
+// nvidia.com devices in the CDI spec dirs.
+func (c checker) checkCDI() modeSupport {
+	s := modeSupport{
+		Mode: modeCDI,
+	}
+
+	index, err := cdiindex.Build(c.cdiSpecDirs)
+	if err != nil {
+		s.Reasons = []string{fmt.Sprintf("failed to load CDI specifications: %v", err)}
+		return s
+	}
+
+	var specs, devices int
+	for _, spec := range index.Specs {
+		if !strings.HasPrefix(spec.Kind, nvidiaCDIKind) || len(spec.Devices) == 0 {
+			continue
+		}
+		specs++
+		devices += len(spec.Devices)
+	}
+	if specs == 0 {
+		s.Reasons = []string{fmt.Sprintf("no CDI specifications defining %v* devices found in %v", nvidiaCDIKind, c.cdiSpecDirs)}
+		return s
+	}
+
+	s.Viable = true
+	s.Reasons = []string{fmt.Sprintf("found %d CDI specifications defining %d devices in %v", specs, devices, c.cdiSpecDirs)}
+	return s
+}
+
+// checkVFIO checks whether NVIDIA GPUs are bound to the vfio-pci driver for passthrough.
+func (c checker) checkVFIO() modeSupport {
+	s := modeSupport{
+		Mode: modeVFIO,
+	}
+
+	driverPath := filepath.Join(c.sysfsRoot, "bus/pci/drivers/vfio-pci")
+	entries, err := os.ReadDir(driverPath)
+	if err != nil {
+		s.Reasons = []string{fmt.Sprintf("vfio-pci driver not loaded: %v not found", driverPath)}
+		return s
+	}
+
+	var devices []string
+	for _, e := range entries {
+		vendor, err := os.ReadFile(filepath.Join(driverPath, e.Name(), "vendor"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(vendor)) == nvidiaVendorID {
+			devices = append(devices, e.Name())
+		}
+	}
+	if len(devices) == 0 {
+		s.Reasons = []string{"no NVIDIA devices bound to vfio-pci"}
+		return s
+	}
+
+	s.Viable = true
+	s.Reasons = []string{fmt.Sprintf("found %d NVIDIA devices bound to vfio-pci: %v", len(devices), strings.Join(devices, ", "))}
+	return s
+}
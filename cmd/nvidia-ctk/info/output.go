@@ -0,0 +1,91 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// inventory is the structured (JSON) representation of the state of the GPUs in the system.
+type inventory struct {
+	Timestamp           time.Time        `json:"timestamp"`
+	ConfidentialCompute *confComputeInfo `json:"confidentialCompute,omitempty"`
+	GPUs                []gpuOutput      `json:"gpus"`
+}
+
+// gpuOutput is the structured representation of a GPU or MIG device.
+type gpuOutput struct {
+	Index       string      `json:"index"`
+	UUID        string      `json:"uuid"`
+	Name        string      `json:"name,omitempty"`
+	MemoryUsed  uint64      `json:"memoryUsed"`
+	MemoryTotal uint64      `json:"memoryTotal"`
+	Utilization *uint32     `json:"utilization,omitempty"`
+	NUMA        *numaOutput `json:"numa,omitempty"`
+	Containers  []string    `json:"containers,omitempty"`
+	MIG         *migInfo    `json:"mig,omitempty"`
+	MIGDevices  []gpuOutput `json:"migDevices,omitempty"`
+}
+
+// numaOutput is the structured representation of the NUMA topology of a GPU.
+type numaOutput struct {
+	CPUNode      int    `json:"cpuNode"`
+	MemoryNode   int    `json:"memoryNode"`
+	MemoryStatus string `json:"memoryStatus,omitempty"`
+}
+
+// renderJSON writes the specified GPUs and confidential compute state to the writer as JSON.
+func renderJSON(w io.Writer, now time.Time, gpus []gpuInfo, cc *confComputeInfo) error {
+	inv := inventory{
+		Timestamp:           now,
+		ConfidentialCompute: cc,
+		GPUs:                []gpuOutput{},
+	}
+	for _, gpu := range gpus {
+		inv.GPUs = append(inv.GPUs, toOutput(gpu))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(inv)
+}
+
+func toOutput(gpu gpuInfo) gpuOutput {
+	o := gpuOutput{
+		Index:       gpu.index,
+		UUID:        gpu.uuid,
+		Name:        gpu.name,
+		MemoryUsed:  gpu.memoryUsed,
+		MemoryTotal: gpu.memoryTotal,
+		Utilization: gpu.utilization,
+		Containers:  gpu.containers,
+		MIG:         gpu.mig,
+	}
+	if gpu.topology != nil {
+		o.NUMA = &numaOutput{
+			CPUNode:      gpu.topology.CPUNode,
+			MemoryNode:   gpu.topology.MemoryNode,
+			MemoryStatus: gpu.topology.MemoryStatus,
+		}
+	}
+	for _, mig := range gpu.migDevices {
+		o.MIGDevices = append(o.MIGDevices, toOutput(mig))
+	}
+	return o
+}
@@ -0,0 +1,76 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderJSON(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	gpus := []gpuInfo{
+		{
+			index:       "0",
+			name:        "A100",
+			uuid:        "GPU-0",
+			memoryTotal: 40 * 1024 * 1024 * 1024,
+			mig: &migInfo{
+				Enabled: true,
+				Profiles: []migProfile{
+					{ID: 0, Name: "1g.5gb", Slices: 1, MemoryMB: 4864, MaxInstances: 7, AvailableInstances: 6},
+				},
+				GPUInstances: []gpuInstance{
+					{
+						ID:               13,
+						Profile:          "1g.5gb",
+						Placement:        placement{Start: 6, Size: 1},
+						ComputeInstances: []computeInstance{{ID: 0, Profile: "1g.5gb"}},
+					},
+				},
+			},
+			migDevices: []gpuInfo{
+				{index: "0:0", uuid: "MIG-0"},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, renderJSON(buf, now, gpus, &confComputeInfo{State: "OFF"}))
+
+	var inv inventory
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &inv))
+	require.EqualValues(t, inventory{
+		Timestamp:           now,
+		ConfidentialCompute: &confComputeInfo{State: "OFF"},
+		GPUs: []gpuOutput{
+			{
+				Index:       "0",
+				UUID:        "GPU-0",
+				Name:        "A100",
+				MemoryTotal: 40 * 1024 * 1024 * 1024,
+				MIG:         gpus[0].mig,
+				MIGDevices:  []gpuOutput{{Index: "0:0", UUID: "MIG-0"}},
+			},
+		},
+	}, inv)
+}
@@ -17,13 +17,21 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi"
+	configCLI "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/doctor"
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/hook"
 	infoCLI "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/nomad"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/policy"
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/state"
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/test"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
 
 	log "github.com/sirupsen/logrus"
@@ -37,6 +45,10 @@ var logger = log.New()
 type config struct {
 	// Debug indicates whether the CLI is started in "debug" mode
 	Debug bool
+	// Explain is the ID of a log event to print details for
+	Explain string
+	// FeatureGates overrides the features enabled in the config file
+	FeatureGates string
 }
 
 func main() {
@@ -60,6 +72,17 @@ func main() {
 			Destination: &config.Debug,
 			EnvVars:     []string{"NVIDIA_CTK_DEBUG"},
 		},
+		&cli.StringFlag{
+			Name:        "explain",
+			Usage:       "Print the detail and remediation for the specified log event ID (e.g. NVCT2003). Use 'list' to list all events",
+			Destination: &config.Explain,
+		},
+		&cli.StringFlag{
+			Name:        "feature-gates",
+			Usage:       "A comma-separated list of feature=bool pairs that override the features enabled in the config.toml file (e.g. hookless-cdi=true)",
+			Destination: &config.FeatureGates,
+			EnvVars:     []string{"NVIDIA_CTK_FEATURE_GATES"},
+		},
 	}
 
 	// Set log-level for all subcommands
@@ -69,9 +92,25 @@ func main() {
 			logLevel = log.DebugLevel
 		}
 		logger.SetLevel(logLevel)
+
+		gates, err := features.New(nil)
+		if err != nil {
+			return err
+		}
+		if err := gates.SetFromString(config.FeatureGates); err != nil {
+			return fmt.Errorf("invalid feature gates: %v", err)
+		}
 		return nil
 	}
 
+	// Print event details if requested, otherwise show the help
+	c.Action = func(c *cli.Context) error {
+		if config.Explain != "" {
+			return explain(c.App.Writer, config.Explain)
+		}
+		return cli.ShowAppHelp(c)
+	}
+
 	// Define the subcommands
 	c.Commands = []*cli.Command{
 		hook.NewCommand(logger),
@@ -79,6 +118,12 @@ func main() {
 		infoCLI.NewCommand(logger),
 		cdi.NewCommand(logger),
 		system.NewCommand(logger),
+		doctor.NewCommand(logger),
+		policy.NewCommand(logger),
+		state.NewCommand(logger),
+		nomad.NewCommand(logger),
+		configCLI.NewCommand(logger),
+		test.NewCommand(logger),
 	}
 
 	// Run the CLI
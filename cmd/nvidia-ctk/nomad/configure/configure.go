@@ -0,0 +1,187 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/nomad"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultDockerConfigFilePath = "/etc/docker/daemon.json"
+	defaultNomadConfigFilePath  = "/etc/nomad.d/nvidia.hcl"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	dryRun               bool
+	runtime              string
+	dockerConfigFilePath string
+	nomadConfigFilePath  string
+	allowRuntimes        cli.StringSlice
+	nvidiaOptions        nvidia.Options
+}
+
+// NewCommand constructs a configure command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'configure' command
+	c := cli.Command{
+		Name:  "configure",
+		Usage: "Configure the container engine and the Nomad client to run GPU tasks using the NVIDIA Container Runtime",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "output the updated configs instead of writing them",
+			Destination: &opts.dryRun,
+		},
+		&cli.StringFlag{
+			Name:        "runtime",
+			Usage:       "the container engine used by the Nomad task driver; only docker is supported",
+			Value:       "docker",
+			Destination: &opts.runtime,
+		},
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "path to the config file for the container engine",
+			Value:       defaultDockerConfigFilePath,
+			Destination: &opts.dockerConfigFilePath,
+		},
+		&cli.StringFlag{
+			Name:        "nomad-config",
+			Usage:       "path to which the Nomad client config is written",
+			Value:       defaultNomadConfigFilePath,
+			Destination: &opts.nomadConfigFilePath,
+		},
+		&cli.StringSliceFlag{
+			Name:        "allow-runtime",
+			Usage:       "a runtime that docker tasks are allowed to use in addition to the NVIDIA Container Runtime. Can be specified multiple times",
+			Value:       cli.NewStringSlice("runc"),
+			Destination: &opts.allowRuntimes,
+		},
+		&cli.StringFlag{
+			Name:        "nvidia-runtime-name",
+			Usage:       "specify the name of the NVIDIA runtime that will be added",
+			Value:       nvidia.RuntimeName,
+			Destination: &opts.nvidiaOptions.RuntimeName,
+		},
+		&cli.StringFlag{
+			Name:        "nvidia-runtime-path",
+			Aliases:     []string{"runtime-path"},
+			Usage:       "specify the path to the NVIDIA runtime executable",
+			Value:       nvidia.RuntimeExecutable,
+			Destination: &opts.nvidiaOptions.RuntimePath,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.runtime {
+	case "docker":
+	case "containerd":
+		return fmt.Errorf("the Nomad containerd driver does not support selecting the NVIDIA Container Runtime; use the docker driver instead")
+	default:
+		return fmt.Errorf("unsupported runtime: %v", opts.runtime)
+	}
+	if opts.nvidiaOptions.RuntimeName == "" {
+		return fmt.Errorf("the NVIDIA runtime name must be specified")
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	// The lock is held from loading the docker config until both configs have been written so that
+	// concurrent updates (e.g. by nvidia-ctk runtime configure) are serialized.
+	if !opts.dryRun {
+		lock, err := engine.Lock(opts.dockerConfigFilePath, opts.nomadConfigFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to lock configs: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
+	cfg, err := docker.New(
+		docker.WithPath(opts.dockerConfigFilePath),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to load config for %v: %v", opts.runtime, err)
+	}
+
+	runtime := opts.nvidiaOptions.Runtime()
+	err = cfg.AddRuntime(runtime.Name, runtime.Path, false)
+	if err != nil {
+		return fmt.Errorf("unable to update config for %v: %v", opts.runtime, err)
+	}
+
+	client := nomad.ClientConfig{
+		RuntimeName:   runtime.Name,
+		AllowRuntimes: opts.allowRuntimes.Value(),
+	}
+
+	if opts.dryRun {
+		output, err := json.MarshalIndent(cfg, "", "    ")
+		if err != nil {
+			return fmt.Errorf("unable to render config for %v: %v", opts.runtime, err)
+		}
+		fmt.Fprintf(c.App.Writer, "# %v: %v\n%s\n", opts.runtime, opts.dockerConfigFilePath, output)
+		fmt.Fprintf(c.App.Writer, "# nomad: %v\n%s", opts.nomadConfigFilePath, client.Render())
+		return nil
+	}
+
+	_, err = cfg.Save(opts.dockerConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to flush config for %v: %v", opts.runtime, err)
+	}
+	m.logger.Infof("Wrote updated config to %v", opts.dockerConfigFilePath)
+
+	if _, err := engine.WriteFile(opts.nomadConfigFilePath, []byte(client.Render())); err != nil {
+		return fmt.Errorf("unable to write Nomad config: %v", err)
+	}
+	m.logger.Infof("Wrote Nomad client config to %v", opts.nomadConfigFilePath)
+
+	m.logger.Infof("It is recommended that %v and the Nomad client be restarted", opts.runtime)
+	return nil
+}
@@ -0,0 +1,130 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package mapdevices
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/nomad"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	deviceNameStrategy string
+	kind               string
+	fromEnv            bool
+}
+
+// NewCommand constructs a map-devices command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'map-devices' command
+	c := cli.Command{
+		Name:      "map-devices",
+		Usage:     "Map the device IDs assigned by the Nomad NVIDIA device plugin to fully-qualified CDI device names",
+		ArgsUsage: "[DEVICE_ID...]",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "device-name-strategy",
+			Usage:       "The strategy used to generate the device names in the CDI specification. One of [index | uuid | type-index]",
+			Value:       nvcdi.DeviceNameStrategyIndex,
+			Destination: &opts.deviceNameStrategy,
+		},
+		&cli.StringFlag{
+			Name:        "kind",
+			Usage:       "The CDI device kind of the devices in the CDI specification",
+			Value:       nomad.DefaultKind,
+			Destination: &opts.kind,
+		},
+		&cli.BoolFlag{
+			Name:        "from-env",
+			Usage:       "Map the device IDs in the NVIDIA_VISIBLE_DEVICES environment variable set by the device plugin",
+			Destination: &opts.fromEnv,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	if _, err := nvcdi.NewDeviceNamer(opts.deviceNameStrategy); err != nil {
+		return err
+	}
+	if opts.fromEnv && c.NArg() > 0 {
+		return fmt.Errorf("device IDs cannot be specified as arguments when --from-env is set")
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	ids := c.Args().Slice()
+	if opts.fromEnv {
+		ids = []string{os.Getenv("NVIDIA_VISIBLE_DEVICES")}
+	}
+
+	namer, err := nvcdi.NewDeviceNamer(opts.deviceNameStrategy)
+	if err != nil {
+		return err
+	}
+
+	nvmllib := nvml.New()
+	if r := nvmllib.Init(); r != nvml.SUCCESS {
+		return fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvmllib.Shutdown()
+
+	mapper, err := nomad.NewDeviceMapper(device.New(device.WithNvml(nvmllib)), namer, opts.kind)
+	if err != nil {
+		return err
+	}
+
+	names, err := mapper.Map(ids...)
+	if err != nil {
+		return fmt.Errorf("failed to map device IDs: %v", err)
+	}
+	if len(names) > 0 {
+		fmt.Fprintln(c.App.Writer, strings.Join(names, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package evaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/policy"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	imageEnvFile  string
+	cdiSpec       string
+	driverVersion string
+	cudaVersion   string
+}
+
+// NewCommand constructs an evaluate command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'evaluate' command
+	c := cli.Command{
+		Name:  "evaluate",
+		Usage: "Evaluate the configured policies for a container image and output whether it is allowed",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "image-env-file",
+			Usage:       "The path to a JSON file containing the environment of the image as a list of KEY=VALUE strings or as an object. If set to -, the environment is read from stdin.",
+			Required:    true,
+			Destination: &opts.imageEnvFile,
+		},
+		&cli.StringFlag{
+			Name:        "cdi-spec",
+			Usage:       "The path to a CDI specification generated with --embed-node-properties. The image requirements are evaluated against the node properties embedded in the specification.",
+			Destination: &opts.cdiSpec,
+		},
+		&cli.StringFlag{
+			Name:        "driver-version",
+			Usage:       "Override the driver version against which the image requirements are evaluated",
+			Destination: &opts.driverVersion,
+		},
+		&cli.StringFlag{
+			Name:        "cuda-version",
+			Usage:       "Override the CUDA version against which the image requirements are evaluated",
+			Destination: &opts.cudaVersion,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	env, err := loadImageEnv(opts.imageEnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to load image environment: %v", err)
+	}
+	i, err := image.NewCUDAImageFromEnv(env)
+	if err != nil {
+		return fmt.Errorf("failed to create image: %v", err)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	properties := requirements.Properties{
+		Driver: cfg.NVIDIAContainerRuntimeConfig.Policy.DriverVersion,
+		CUDA:   cfg.NVIDIAContainerRuntimeConfig.Policy.CUDAVersion,
+	}
+	if opts.cdiSpec != "" {
+		specProperties, err := loadSpecProperties(opts.cdiSpec)
+		if err != nil {
+			return fmt.Errorf("failed to load node properties from CDI spec: %v", err)
+		}
+		properties = *specProperties
+	}
+	if opts.driverVersion != "" {
+		properties.Driver = opts.driverVersion
+	}
+	if opts.cudaVersion != "" {
+		properties.CUDA = opts.cudaVersion
+	}
+
+	result, err := policy.EvaluateWithProperties(m.logger, cfg, i, properties)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policies: %v", err)
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %v", err)
+	}
+	fmt.Fprintf(c.App.Writer, "%s\n", output)
+
+	if !result.Allowed {
+		return fmt.Errorf("image denied by policy")
+	}
+	return nil
+}
+
+// loadImageEnv reads the image environment from the specified file. The environment is either a list of
+// KEY=VALUE strings (as in the Env field of an image config) or an object mapping keys to values.
+func loadImageEnv(path string) ([]string, error) {
+	var contents []byte
+	var err error
+	if path == "-" {
+		contents, err = io.ReadAll(os.Stdin)
+	} else {
+		contents, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+	if err := json.Unmarshal(contents, &env); err == nil {
+		return env, nil
+	}
+
+	var envMap map[string]string
+	if err := json.Unmarshal(contents, &envMap); err != nil {
+		return nil, fmt.Errorf("expected a list of KEY=VALUE strings or an object: %v", err)
+	}
+	for k, v := range envMap {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// loadSpecProperties returns the node properties embedded in the common container edits of the
+// specified CDI specification.
+func loadSpecProperties(path string) (*requirements.Properties, error) {
+	spec, err := cdi.ReadSpec(path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := requirements.GetPropertiesFromEnv(spec.ContainerEdits.Env)
+	if err != nil {
+		return nil, err
+	}
+	if properties == nil {
+		return nil, fmt.Errorf("no node properties embedded in %v", path)
+	}
+	return properties, nil
+}
@@ -0,0 +1,108 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package evaluate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadImageEnv(t *testing.T) {
+	testCases := []struct {
+		description   string
+		contents      string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			description: "list of strings",
+			contents:    `["NVIDIA_VISIBLE_DEVICES=all", "PATH=/bin"]`,
+			expected:    []string{"NVIDIA_VISIBLE_DEVICES=all", "PATH=/bin"},
+		},
+		{
+			description: "object",
+			contents:    `{"PATH": "/bin", "NVIDIA_VISIBLE_DEVICES": "all"}`,
+			expected:    []string{"NVIDIA_VISIBLE_DEVICES=all", "PATH=/bin"},
+		},
+		{
+			description:   "invalid contents",
+			contents:      `"NVIDIA_VISIBLE_DEVICES=all"`,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "env.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0644))
+
+			env, err := loadImageEnv(path)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, env)
+		})
+	}
+}
+
+func TestLoadSpecProperties(t *testing.T) {
+	testCases := []struct {
+		description   string
+		env           string
+		expected      *requirements.Properties
+		expectedError bool
+	}{
+		{
+			description: "embedded properties",
+			env:         `["NVIDIA_NODE_PROPERTIES=cuda=12.0,driver=525.60.13,brand=tesla"]`,
+			expected:    &requirements.Properties{CUDA: "12.0", Driver: "525.60.13", Brand: "tesla"},
+		},
+		{
+			description:   "no embedded properties",
+			env:           `["PATH=/bin"]`,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			contents := `{
+				"cdiVersion": "0.5.0",
+				"kind": "nvidia.com/gpu",
+				"devices": [{"name": "all", "containerEdits": {"deviceNodes": [{"path": "/dev/nvidia0"}]}}],
+				"containerEdits": {"env": ` + tc.env + `}
+			}`
+			path := filepath.Join(t.TempDir(), "nvidia.json")
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+
+			properties, err := loadSpecProperties(path)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, properties)
+		})
+	}
+}
@@ -17,14 +17,19 @@
 package configure
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/crio"
+	ctkconfig "github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
-	"github.com/pelletier/go-toml"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/podman"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -32,8 +37,8 @@ import (
 const (
 	defaultRuntime = "docker"
 
-	defaultDockerConfigFilePath = "/etc/docker/daemon.json"
-	defaultCrioConfigFilePath   = "/etc/crio/crio.conf"
+	defaultCrioConfigFilePath       = "/etc/crio/crio.conf"
+	defaultContainerdConfigFilePath = "/etc/containerd/config.toml"
 )
 
 type command struct {
@@ -52,9 +57,22 @@ func NewCommand(logger *logrus.Logger) *cli.Command {
 // environment variables, or command line config
 type config struct {
 	dryRun         bool
+	printSnippet   bool
 	runtime        string
 	configFilePath string
+	configLayout   string
+	hostFlavor     string
+	socket         string
+	dockerContext  string
+	containerdPath string
+	cdiSpecDirs    cli.StringSlice
 	nvidiaOptions  nvidia.Options
+	runtimeNames   cli.StringSlice
+	// nvidiaRuntimes are the NVIDIA runtimes that are added to the engine configs. These are parsed
+	// from the runtime names.
+	nvidiaRuntimes []nvidia.Runtime
+	preHooks       cli.StringSlice
+	postHooks      cli.StringSlice
 }
 
 func (m command) build() *cli.Command {
@@ -73,25 +91,63 @@ func (m command) build() *cli.Command {
 	configure.Flags = []cli.Flag{
 		&cli.BoolFlag{
 			Name:        "dry-run",
-			Usage:       "update the runtime configuration as required but don't write changes to disk",
+			Usage:       "print a unified diff of the changes to the runtime configuration but don't write changes to disk. The command fails if any changes would be made",
 			Destination: &config.dryRun,
 		},
+		&cli.BoolFlag{
+			Name:        "print-snippet",
+			Usage:       "print the fragment that is added to the config of the runtime for the specified options instead of updating the config. No config files are read or written. This can only be specified for a single runtime",
+			Destination: &config.printSnippet,
+		},
 		&cli.StringFlag{
 			Name:        "runtime",
-			Usage:       "the target runtime engine. One of [crio, docker]",
+			Usage:       "the target runtime engine. One of [containerd, crio, docker, podman]. Multiple engines can be configured in a single transaction by specifying a comma-separated list",
 			Value:       defaultRuntime,
 			Destination: &config.runtime,
 		},
 		&cli.StringFlag{
 			Name:        "config",
-			Usage:       "path to the config file for the target runtime",
+			Usage:       "path to the config file for the target runtime. This can only be specified for a single runtime",
 			Destination: &config.configFilePath,
 		},
 		&cli.StringFlag{
+			Name:        "config-layout",
+			Usage:       "the installation layout used to determine the path to the config file if --config is not specified. One of [auto, default, snap, microk8s, rpm-ostree]. With auto, the layout is detected for each runtime",
+			Value:       configLayoutAuto,
+			Destination: &config.configLayout,
+		},
+		&cli.StringFlag{
+			Name:        "host-flavor",
+			Usage:       "the flavor of the host on which docker is configured. This determines the default config file path and the name of the engine daemon. One of [default, balena]",
+			Value:       docker.FlavorDefault,
+			Destination: &config.hostFlavor,
+		},
+		&cli.StringFlag{
+			Name:        "socket",
+			Usage:       "the host or socket of the docker daemon (e.g. unix:///run/user/1000/docker.sock). This is used to check whether the daemon has applied the updated config. If this is the socket of a rootless daemon and --config is not specified, the config of the rootless daemon is updated",
+			Destination: &config.socket,
+		},
+		&cli.StringFlag{
+			Name:        "docker-context",
+			Usage:       "the name of the docker context from which the host of the docker daemon is determined. This cannot be used together with --socket",
+			Destination: &config.dockerContext,
+		},
+		&cli.StringFlag{
+			Name:        "containerd-path",
+			Usage:       "the path to the containerd executable. This is used to validate the settings of the added runtimes against the containerd version. If the containerd config is empty, this is also used to determine the config version; version 3 is used for containerd 2.0 and later",
+			Value:       "containerd",
+			Destination: &config.containerdPath,
+		},
+		&cli.StringSliceFlag{
+			Name:        "cdi-spec-dir",
+			Usage:       "specify a directory that podman searches for CDI specifications. If this is not specified, the spec-dirs of the cdi mode in the NVIDIA Container Runtime config or the default CDI spec dirs are used. Can be specified multiple times",
+			Destination: &config.cdiSpecDirs,
+		},
+		&cli.StringSliceFlag{
 			Name:        "nvidia-runtime-name",
-			Usage:       "specify the name of the NVIDIA runtime that will be added",
-			Value:       nvidia.RuntimeName,
-			Destination: &config.nvidiaOptions.RuntimeName,
+			Usage:       "specify the name of the NVIDIA runtime that will be added. A mode can be specified as NAME=MODE, with MODE one of [auto, cdi, legacy], to add a runtime that uses the executable forcing this mode (e.g. nvidia-container-runtime.cdi). Can be specified multiple times to add several runtimes; the first runtime is set as the default runtime if --set-as-default is specified",
+			Value:       cli.NewStringSlice(nvidia.RuntimeName),
+			Destination: &config.runtimeNames,
 		},
 		&cli.StringFlag{
 			Name:        "runtime-path",
@@ -104,110 +160,366 @@ func (m command) build() *cli.Command {
 			Usage:       "set the specified runtime as the default runtime",
 			Destination: &config.nvidiaOptions.SetAsDefault,
 		},
+		&cli.StringSliceFlag{
+			Name:        "pre-hook",
+			Usage:       "specify a command to run before the updated configs are written. If the command fails, no changes are made. Can be specified multiple times",
+			Destination: &config.preHooks,
+		},
+		&cli.StringSliceFlag{
+			Name:        "post-hook",
+			Usage:       "specify a command to run after the updated configs are written. If the command fails, the original configs are restored. Can be specified multiple times",
+			Destination: &config.postHooks,
+		},
 	}
 
 	return &configure
 }
 
 func (m command) configureWrapper(c *cli.Context, config *config) error {
-	switch config.runtime {
-	case "crio":
-		return m.configureCrio(c, config)
-	case "docker":
-		return m.configureDocker(c, config)
+	runtimes, err := parseRuntimes(config.runtime)
+	if err != nil {
+		return err
 	}
+	if len(runtimes) > 1 && config.configFilePath != "" {
+		return fmt.Errorf("the --config option cannot be used when configuring multiple runtimes")
+	}
+	if config.configFilePath != "" && config.configLayout != configLayoutAuto {
+		return fmt.Errorf("the --config and --config-layout options cannot be used together")
+	}
+	if (config.socket != "" || config.dockerContext != "") && !contains(runtimes, "docker") {
+		return fmt.Errorf("the --socket and --docker-context options can only be used when configuring docker")
+	}
+	if c.IsSet("cdi-spec-dir") && !contains(runtimes, "podman") {
+		return fmt.Errorf("the --cdi-spec-dir option can only be used when configuring podman")
+	}
+	config.nvidiaRuntimes, err = parseRuntimeClasses(config.runtimeNames.Value(), config.nvidiaOptions.RuntimePath)
+	if err != nil {
+		return err
+	}
+	config.nvidiaOptions.RuntimeName = config.nvidiaRuntimes[0].Name
 
-	return fmt.Errorf("unrecognized runtime '%v'", config.runtime)
-}
+	if config.printSnippet {
+		if err := validateSnippetFlags(c, runtimes); err != nil {
+			return err
+		}
+		return printSnippet(os.Stdout, c, runtimes[0], config)
+	}
+
+	// All engine configs are loaded and updated before any changes are written to disk so that
+	// invalid configs do not result in a partial update.
+	var engines []*engineConfig
+	for _, runtime := range runtimes {
+		path := config.configFilePath
+		var socket string
+		if runtime == "docker" {
+			socket, err = getDockerSocket(config.socket, config.dockerContext, config.hostFlavor)
+			if err != nil {
+				return err
+			}
+			if path == "" && config.configLayout == configLayoutAuto {
+				path, err = getRootlessDockerConfigPath(socket)
+				if err != nil {
+					return err
+				}
+				if path != "" {
+					m.logger.Infof("Detected rootless docker daemon at %v", socket)
+				}
+			}
+		}
 
-// configureDocker updates the docker config to enable the NVIDIA Container Runtime
-func (m command) configureDocker(c *cli.Context, config *config) error {
-	configFilePath := config.configFilePath
-	if configFilePath == "" {
-		configFilePath = defaultDockerConfigFilePath
+		e, err := resolveEngineConfig(runtime, path, config.hostFlavor, config.configLayout)
+		if err != nil {
+			return fmt.Errorf("unable to load config for %v: %v", runtime, err)
+		}
+		if socket != "" {
+			e.validate = newDockerValidator(socket, config.nvidiaOptions)
+		}
+		engines = append(engines, e)
 	}
 
-	cfg, err := docker.New(
-		docker.WithPath(configFilePath),
-	)
-	if err != nil {
-		return fmt.Errorf("unable to load config: %v", err)
+	// Concurrent updates of the configs (e.g. by multiple provisioning agents) are serialized by
+	// holding a lock from loading the configs until the updated configs have been written.
+	if !config.dryRun {
+		var paths []string
+		for _, e := range engines {
+			paths = append(paths, e.path)
+		}
+		lock, err := engine.Lock(paths...)
+		if err != nil {
+			return fmt.Errorf("unable to lock configs: %v", err)
+		}
+		defer lock.Unlock()
 	}
 
-	err = cfg.AddRuntime(
-		config.nvidiaOptions.RuntimeName,
-		config.nvidiaOptions.RuntimePath,
-		config.nvidiaOptions.SetAsDefault,
-	)
-	if err != nil {
-		return fmt.Errorf("unable to update config: %v", err)
+	for _, e := range engines {
+		if err := e.load(config.containerdPath); err != nil {
+			return fmt.Errorf("unable to load config for %v: %v", e.runtime, err)
+		}
+		m.logEngineConfig(e)
+
+		if err := addRuntime(c, e.cfg, config); err != nil {
+			return fmt.Errorf("unable to update config for %v: %v", e.runtime, err)
+		}
+	}
+
+	for _, runtime := range config.nvidiaRuntimes {
+		m.checkRuntimeVersion(runtime.Path)
 	}
 
 	if config.dryRun {
-		output, err := json.MarshalIndent(cfg, "", "    ")
+		return dryRun(os.Stdout, engines)
+	}
+
+	h := hooks{
+		pre:  config.preHooks.Value(),
+		post: config.postHooks.Value(),
+	}
+	return m.save(engines, h)
+}
+
+// addRuntime adds the NVIDIA runtimes to the specified engine config. If requested, the first runtime
+// is set as the default runtime. For podman, the CDI spec dirs are also set.
+func addRuntime(c *cli.Context, cfg engine.Interface, config *config) error {
+	for i, runtime := range config.nvidiaRuntimes {
+		err := cfg.AddRuntime(
+			runtime.Name,
+			runtime.Path,
+			config.nvidiaOptions.SetAsDefault && i == 0,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if podmanConfig, ok := cfg.(*podman.Config); ok {
+		specDirs, err := getCDISpecDirs(c, config)
 		if err != nil {
-			return fmt.Errorf("unable to convert to JSON: %v", err)
+			return err
+		}
+		podmanConfig.SetCDISpecDirs(specDirs...)
+	}
+	return nil
+}
+
+// validateSnippetFlags checks that the options that apply to config files are not specified
+// together with --print-snippet.
+func validateSnippetFlags(c *cli.Context, runtimes []string) error {
+	if len(runtimes) > 1 {
+		return fmt.Errorf("the --print-snippet option cannot be used when configuring multiple runtimes")
+	}
+	for _, flag := range []string{"dry-run", "config", "config-layout", "socket", "docker-context", "pre-hook", "post-hook"} {
+		if c.IsSet(flag) {
+			return fmt.Errorf("the --print-snippet and --%v options cannot be used together", flag)
 		}
-		os.Stdout.WriteString(fmt.Sprintf("%s\n", output))
-		return nil
 	}
-	n, err := cfg.Save(configFilePath)
+	return nil
+}
+
+// printSnippet writes the fragment that is added to the config of the specified runtime to the
+// specified writer. The fragment is rendered from an empty config so that settings that are copied
+// from an existing config (e.g. the options of the runc runtime for containerd) are not included.
+func printSnippet(w io.Writer, c *cli.Context, runtime string, config *config) error {
+	cfg, err := newEmptyEngineConfig(runtime, config.containerdPath)
+	if err != nil {
+		return fmt.Errorf("unable to create config for %v: %v", runtime, err)
+	}
+	if err := addRuntime(c, cfg, config); err != nil {
+		return fmt.Errorf("unable to update config for %v: %v", runtime, err)
+	}
+
+	output, err := cfg.Render("")
 	if err != nil {
-		return fmt.Errorf("unable to flush config: %v", err)
+		return fmt.Errorf("unable to render config for %v: %v", runtime, err)
+	}
+	if len(output) > 0 && output[len(output)-1] != '\n' {
+		output = append(output, '\n')
 	}
+	_, err = w.Write(output)
+	return err
+}
 
-	if n == 0 {
-		m.logger.Infof("Removed empty config from %v", configFilePath)
+// logEngineConfig logs the path of the config file for the specified engine and how it was chosen.
+func (m command) logEngineConfig(e *engineConfig) {
+	if e.layout == "" {
+		m.logger.Infof("Using config file %v for %v", e.path, e.runtime)
 	} else {
-		m.logger.Infof("Wrote updated config to %v", configFilePath)
+		m.logger.Infof("Using config file %v for %v (%v layout)", e.path, e.runtime, e.layout)
+	}
+	if e.source != e.path {
+		m.logger.Infof("Config file %v does not exist; loading the config for %v from %v", e.path, e.runtime, e.source)
 	}
-	m.logger.Infof("It is recommended that the docker daemon be restarted.")
-
-	return nil
 }
 
-// configureCrio updates the crio config to enable the NVIDIA Container Runtime
-func (m command) configureCrio(c *cli.Context, config *config) error {
-	configFilePath := config.configFilePath
-	if configFilePath == "" {
-		configFilePath = defaultCrioConfigFilePath
+// checkRuntimeVersion warns if the version of the NVIDIA Container Runtime that is being
+// configured does not match the version of nvidia-ctk. This is typically caused by a partial upgrade.
+func (m command) checkRuntimeVersion(runtimePath string) {
+	expected := info.GetVersion()
+	if expected == "unknown" {
+		return
 	}
 
-	cfg, err := crio.New(
-		crio.WithPath(configFilePath),
-	)
+	locator := lookup.NewExecutableLocator(m.logger, "")
+	paths, err := locator.Locate(runtimePath)
+	if err != nil || len(paths) == 0 {
+		m.logger.Warningf("Could not locate the NVIDIA Container Runtime at %v: %v", runtimePath, err)
+		return
+	}
+
+	version, err := info.GetComponentVersion("nvidia-container-runtime", paths[0])
 	if err != nil {
-		return fmt.Errorf("unable to load config: %v", err)
+		m.logger.Debugf("Could not determine the version of %v: %v", paths[0], err)
+		return
 	}
+	if !info.VersionsMatch(expected, version) {
+		m.logger.Warningf("The version of %v (%v) does not match the version of nvidia-ctk (%v); the NVIDIA Container Toolkit may be partially upgraded", paths[0], version, expected)
+	}
+}
 
-	err = cfg.AddRuntime(
-		config.nvidiaOptions.RuntimeName,
-		config.nvidiaOptions.RuntimePath,
-		config.nvidiaOptions.SetAsDefault,
-	)
+// getCDISpecDirs returns the CDI spec dirs that are registered with podman. If these are not
+// specified, the spec dirs of the NVIDIA Container Runtime config are used.
+func getCDISpecDirs(c *cli.Context, config *config) ([]string, error) {
+	if c.IsSet("cdi-spec-dir") {
+		return config.cdiSpecDirs.Value(), nil
+	}
+
+	toolkitConfig, err := ctkconfig.GetConfig()
 	if err != nil {
-		return fmt.Errorf("unable to update config: %v", err)
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+	if specDirs := toolkitConfig.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs; len(specDirs) > 0 {
+		return specDirs, nil
 	}
+	return cdi.DefaultSpecDirs, nil
+}
 
-	if config.dryRun {
-		output, err := toml.Marshal(cfg)
+// parseRuntimes returns the list of runtimes from the specified comma-separated value.
+func parseRuntimes(value string) ([]string, error) {
+	var runtimes []string
+	seen := make(map[string]bool)
+	for _, runtime := range strings.Split(value, ",") {
+		runtime = strings.TrimSpace(runtime)
+		if runtime == "" || seen[runtime] {
+			continue
+		}
+		switch runtime {
+		case "containerd", "crio", "docker", "podman":
+		default:
+			return nil, fmt.Errorf("unrecognized runtime '%v'", runtime)
+		}
+		seen[runtime] = true
+		runtimes = append(runtimes, runtime)
+	}
+	if len(runtimes) == 0 {
+		return nil, fmt.Errorf("no runtime specified")
+	}
+	return runtimes, nil
+}
+
+// contains checks whether the specified runtimes include the specified runtime.
+func contains(runtimes []string, runtime string) bool {
+	for _, r := range runtimes {
+		if r == runtime {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRun writes a unified diff of the changes to the config of each engine to the specified
+// writer. If any config would be changed, an error listing the changed configs is returned.
+func dryRun(w io.Writer, engines []*engineConfig) error {
+	var changed []string
+	for _, e := range engines {
+		diff, err := engine.Diff(e.cfg, e.path)
 		if err != nil {
-			return fmt.Errorf("unable to convert to TOML: %v", err)
+			return fmt.Errorf("unable to render config for %v: %v", e.runtime, err)
+		}
+		if diff == "" {
+			continue
 		}
-		os.Stdout.WriteString(fmt.Sprintf("%s\n", output))
-		return nil
+		fmt.Fprint(w, diff)
+		changed = append(changed, e.path)
 	}
-	n, err := cfg.Save(configFilePath)
-	if err != nil {
-		return fmt.Errorf("unable to flush config: %v", err)
+	if len(changed) > 0 {
+		return fmt.Errorf("changes would be made to %v", strings.Join(changed, ", "))
 	}
+	return nil
+}
 
-	if n == 0 {
-		m.logger.Infof("Removed empty config from %v", configFilePath)
-	} else {
-		m.logger.Infof("Wrote updated config to %v", configFilePath)
+// save writes the updated configs for the specified engines to disk. The pre-configure hooks
+// are run before any configs are written. If writing any of the configs or running any of the
+// post-configure hooks fails, the configs that have already been written are restored.
+func (m command) save(engines []*engineConfig, h hooks) error {
+	if err := m.runHooks("pre", h.pre, engines); err != nil {
+		return err
+	}
+
+	var backups []*backup
+	for _, e := range engines {
+		b, err := newBackup(e.path)
+		if err != nil {
+			m.rollback(backups)
+			return fmt.Errorf("unable to back up config for %v: %v", e.runtime, err)
+		}
+		backups = append(backups, b)
+
+		n, err := e.cfg.Save(e.path)
+		if err != nil {
+			m.rollback(backups)
+			return fmt.Errorf("unable to flush config for %v: %v", e.runtime, err)
+		}
+
+		if n == 0 {
+			m.logger.Infof("Removed empty config from %v", e.path)
+		} else {
+			m.logger.Infof("Wrote updated config to %v", e.path)
+		}
+	}
+
+	if err := m.runHooks("post", h.post, engines); err != nil {
+		m.rollback(backups)
+		return err
+	}
+
+	for _, e := range engines {
+		m.checkApplied(e)
 	}
-	m.logger.Infof("It is recommended that the cri-o daemon be restarted.")
 
 	return nil
 }
+
+// checkApplied checks whether the daemon of the specified engine has applied the updated config.
+// If this cannot be determined, it is recommended that the daemon be restarted.
+func (m command) checkApplied(e *engineConfig) {
+	if e.daemon == "" {
+		m.logger.Infof("The updated config is used for containers that are created by %v from now on.", e.runtime)
+		return
+	}
+	if e.validate == nil {
+		m.logger.Infof("It is recommended that the %v daemon be restarted.", e.daemon)
+		return
+	}
+
+	applied, err := e.validate()
+	if err != nil {
+		m.logger.Debugf("Could not check whether the %v daemon applied the updated config: %v", e.daemon, err)
+		m.logger.Infof("It is recommended that the %v daemon be restarted.", e.daemon)
+		return
+	}
+	if !applied {
+		m.logger.Warningf("The %v daemon has not applied the updated config; the daemon must be restarted for the changes to take effect.", e.daemon)
+		return
+	}
+	m.logger.Infof("The %v daemon has applied the updated config.", e.daemon)
+}
+
+// rollback restores the specified backups in reverse order.
+func (m command) rollback(backups []*backup) {
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		if err := b.restore(); err != nil {
+			m.logger.Errorf("Failed to restore %v: %v", b.path, err)
+			continue
+		}
+		m.logger.Infof("Restored original config at %v", b.path)
+	}
+}
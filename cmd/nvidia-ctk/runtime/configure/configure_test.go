@@ -0,0 +1,346 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuntimes(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			value:    "docker",
+			expected: []string{"docker"},
+		},
+		{
+			value:    "docker, containerd,docker",
+			expected: []string{"docker", "containerd"},
+		},
+		{
+			value:    "docker,podman",
+			expected: []string{"docker", "podman"},
+		},
+		{
+			value:         "docker,lxc",
+			expectedError: true,
+		},
+		{
+			value:         ",",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			runtimes, err := parseRuntimes(tc.value)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, runtimes)
+		})
+	}
+}
+
+func TestSaveRollback(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "daemon.json")
+	original := []byte("{\n    \"runtimes\": {}\n}")
+	require.NoError(t, os.WriteFile(dockerConfig, original, 0644))
+	containerdConfig := filepath.Join(dir, "config.toml")
+
+	docker, err := loadEngineConfig("docker", dockerConfig, "", configLayoutAuto, "")
+	require.NoError(t, err)
+	containerd, err := loadEngineConfig("containerd", containerdConfig, "", configLayoutAuto, "")
+	require.NoError(t, err)
+	for _, e := range []*engineConfig{docker, containerd} {
+		require.NoError(t, e.cfg.AddRuntime(nvidia.RuntimeName, nvidia.RuntimeExecutable, false))
+	}
+
+	// Writing to a path in a missing directory fails, and the docker config is restored.
+	failing := *containerd
+	failing.path = filepath.Join(dir, "missing", "config.toml")
+
+	err = m.save([]*engineConfig{docker, &failing}, hooks{})
+	require.Error(t, err)
+
+	contents, err := os.ReadFile(dockerConfig)
+	require.NoError(t, err)
+	require.Equal(t, original, contents)
+
+	// If all configs are written, both files are updated.
+	require.NoError(t, m.save([]*engineConfig{docker, containerd}, hooks{}))
+
+	contents, err = os.ReadFile(dockerConfig)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), nvidia.RuntimeExecutable)
+
+	contents, err = os.ReadFile(containerdConfig)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), nvidia.RuntimeExecutable)
+}
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "daemon.json.real")
+	original := []byte("{}")
+	require.NoError(t, os.WriteFile(target, original, 0600))
+	config := filepath.Join(dir, "daemon.json")
+	require.NoError(t, os.Symlink(target, config))
+
+	b, err := newBackup(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(target, []byte("{\"runtimes\": {}}"), 0600))
+
+	require.NoError(t, b.restore())
+
+	// The symlink is retained and the file it refers to is restored.
+	link, err := os.Readlink(config)
+	require.NoError(t, err)
+	require.Equal(t, target, link)
+
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	contents, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, original, contents)
+
+	// A config that is removed by the update is restored with its original mode.
+	dropIn := filepath.Join(dir, "99-nvidia.toml")
+	require.NoError(t, os.WriteFile(dropIn, original, 0600))
+	b, err = newBackup(dropIn)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(dropIn))
+
+	require.NoError(t, b.restore())
+
+	info, err = os.Stat(dropIn)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLoadEngineConfigHostFlavor(t *testing.T) {
+	testCases := []struct {
+		hostFlavor     string
+		expectedPath   string
+		expectedDaemon string
+		expectedError  bool
+	}{
+		{
+			hostFlavor:     "",
+			expectedPath:   "/etc/docker/daemon.json",
+			expectedDaemon: "docker",
+		},
+		{
+			hostFlavor:     "balena",
+			expectedPath:   "/mnt/data/balena-engine/daemon.json",
+			expectedDaemon: "balena-engine",
+		},
+		{
+			hostFlavor:    "unknown",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.hostFlavor, func(t *testing.T) {
+			e, err := loadEngineConfig("docker", "", tc.hostFlavor, configLayoutDefault, "")
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPath, e.path)
+			require.Equal(t, tc.expectedDaemon, e.daemon)
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	dir := t.TempDir()
+
+	var engines []*engineConfig
+	for _, runtime := range []string{"docker", "crio", "podman"} {
+		e, err := loadEngineConfig(runtime, filepath.Join(dir, runtime), "", configLayoutAuto, "")
+		require.NoError(t, err)
+		require.NoError(t, e.cfg.AddRuntime(nvidia.RuntimeName, nvidia.RuntimeExecutable, false))
+		engines = append(engines, e)
+	}
+
+	buf := &bytes.Buffer{}
+	require.Error(t, dryRun(buf, engines))
+
+	require.Contains(t, buf.String(), "+++ "+filepath.Join(dir, "docker"))
+	require.Contains(t, buf.String(), "+++ "+filepath.Join(dir, "crio"))
+	require.Contains(t, buf.String(), "+++ "+filepath.Join(dir, "podman"))
+	require.Contains(t, buf.String(), `+    nvidia = ["`+nvidia.RuntimeExecutable+`"]`)
+	require.NoFileExists(t, filepath.Join(dir, "docker"))
+	require.NoFileExists(t, filepath.Join(dir, "crio"))
+	require.NoFileExists(t, filepath.Join(dir, "podman"))
+
+	// Once the configs are written, no changes would be made.
+	require.NoError(t, m.save(engines, hooks{}))
+
+	buf.Reset()
+	require.NoError(t, dryRun(buf, engines))
+	require.Empty(t, buf.String())
+}
+
+func TestSaveHooks(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	testCases := []struct {
+		description     string
+		hooks           hooks
+		expectedError   bool
+		expectedUpdated bool
+	}{
+		{
+			description:     "no hooks",
+			expectedUpdated: true,
+		},
+		{
+			description: "successful hooks",
+			hooks: hooks{
+				pre:  []string{"true"},
+				post: []string{"true", "true"},
+			},
+			expectedUpdated: true,
+		},
+		{
+			description: "failing pre hook",
+			hooks: hooks{
+				pre: []string{"true", "exit 1"},
+			},
+			expectedError: true,
+		},
+		{
+			description: "failing post hook restores config",
+			hooks: hooks{
+				post: []string{"exit 1"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			dockerConfig := filepath.Join(dir, "daemon.json")
+			original := []byte("{\n    \"runtimes\": {}\n}")
+			require.NoError(t, os.WriteFile(dockerConfig, original, 0644))
+
+			docker, err := loadEngineConfig("docker", dockerConfig, "", configLayoutAuto, "")
+			require.NoError(t, err)
+			require.NoError(t, docker.cfg.AddRuntime(nvidia.RuntimeName, nvidia.RuntimeExecutable, false))
+
+			err = m.save([]*engineConfig{docker}, tc.hooks)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			contents, err := os.ReadFile(dockerConfig)
+			require.NoError(t, err)
+			if tc.expectedUpdated {
+				require.Contains(t, string(contents), nvidia.RuntimeExecutable)
+			} else {
+				require.Equal(t, original, contents)
+			}
+		})
+	}
+}
+
+func TestRunHooksEnvironment(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	m := command{logger: logger}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	engines := []*engineConfig{
+		{runtime: "docker", path: "/etc/docker/daemon.json"},
+		{runtime: "containerd", path: "/etc/containerd/config.toml"},
+	}
+
+	command := "echo $NVIDIA_CTK_HOOK_STAGE $NVIDIA_CTK_RUNTIMES $NVIDIA_CTK_CONFIG_PATHS > " + output
+	require.NoError(t, m.runHooks("post", []string{command}, engines))
+
+	contents, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, "post docker,containerd /etc/docker/daemon.json,/etc/containerd/config.toml\n", string(contents))
+}
+
+func TestPrintSnippet(t *testing.T) {
+	testCases := []struct {
+		runtime  string
+		expected string
+	}{
+		{
+			runtime:  "docker",
+			expected: "{\n    \"default-runtime\": \"nvidia\",\n    \"runtimes\": {\n        \"nvidia\": {\n            \"args\": [],\n            \"path\": \"/usr/bin/nvidia-container-runtime\"\n        }\n    }\n}\n",
+		},
+		{
+			runtime:  "containerd",
+			expected: "default_runtime_name = \"nvidia\"",
+		},
+		{
+			runtime:  "crio",
+			expected: "[crio.runtime.runtimes.nvidia]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.runtime, func(t *testing.T) {
+			cfg := config{
+				nvidiaOptions: nvidia.Options{
+					SetAsDefault: true,
+				},
+				nvidiaRuntimes: []nvidia.Runtime{
+					{Name: nvidia.RuntimeName, Path: "/usr/bin/nvidia-container-runtime"},
+				},
+			}
+
+			output := bytes.NewBuffer(nil)
+			require.NoError(t, printSnippet(output, nil, tc.runtime, &cfg))
+			require.Contains(t, output.String(), tc.expected)
+			require.True(t, strings.HasSuffix(output.String(), "\n"))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+)
+
+const dockerAPITimeout = 5 * time.Second
+
+// rootlessDockerSocketPattern matches the socket of a rootless docker daemon in the runtime
+// directory of the user (e.g. /run/user/1000/docker.sock).
+var rootlessDockerSocketPattern = regexp.MustCompile(`^/run/user/([0-9]+)/docker\.sock$`)
+
+// getDockerSocket returns the path of the socket of the docker daemon that is configured. This is
+// determined by the specified socket or docker context, falling back to the socket for the host flavor.
+func getDockerSocket(socket string, dockerContext string, hostFlavor string) (string, error) {
+	if socket != "" && dockerContext != "" {
+		return "", fmt.Errorf("the --socket and --docker-context options cannot be used together")
+	}
+
+	flavor, err := docker.GetFlavor(hostFlavor)
+	if err != nil {
+		return "", err
+	}
+
+	host := socket
+	if dockerContext != "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine the docker config dir: %v", err)
+		}
+		host, err = docker.GetContextHost(filepath.Join(home, ".docker"), dockerContext, flavor.Socket)
+		if err != nil {
+			return "", err
+		}
+	}
+	if host == "" {
+		return flavor.Socket, nil
+	}
+	return docker.GetSocketPath(host)
+}
+
+// getRootlessDockerConfigPath returns the path of the daemon.json file of the rootless docker daemon
+// listening on the specified socket. If the socket is not that of a rootless daemon, an empty string
+// is returned.
+func getRootlessDockerConfigPath(socket string) (string, error) {
+	match := rootlessDockerSocketPattern.FindStringSubmatch(socket)
+	if match == nil {
+		return "", nil
+	}
+	u, err := user.LookupId(match[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the owner of rootless docker socket %v: %v", socket, err)
+	}
+	return filepath.Join(u.HomeDir, ".config", "docker", "daemon.json"), nil
+}
+
+// newDockerValidator returns a function that checks using the Docker API whether the docker daemon
+// listening on the specified socket has applied the runtime config. An error is returned if the API
+// is not available.
+func newDockerValidator(socket string, options nvidia.Options) func() (bool, error) {
+	return func() (bool, error) {
+		info, err := docker.GetInfo(socket, dockerAPITimeout)
+		if err != nil {
+			return false, fmt.Errorf("failed to query docker API at %v: %v", socket, err)
+		}
+		return dockerConfigApplied(info, options), nil
+	}
+}
+
+// dockerConfigApplied checks whether the NVIDIA runtime is configured for the daemon and, if it is
+// set as the default, whether it is the default runtime.
+func dockerConfigApplied(info *docker.Info, options nvidia.Options) bool {
+	if _, ok := info.Runtimes[options.RuntimeName]; !ok {
+		return false
+	}
+	if options.SetAsDefault && info.DefaultRuntime != options.RuntimeName {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+	"github.com/sirupsen/logrus"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDockerSocket(t *testing.T) {
+	testCases := []struct {
+		description   string
+		socket        string
+		dockerContext string
+		hostFlavor    string
+		expected      string
+		expectedError bool
+	}{
+		{
+			description: "default flavor socket",
+			expected:    "/var/run/docker.sock",
+		},
+		{
+			description: "socket url",
+			socket:      "unix:///run/user/1000/docker.sock",
+			expected:    "/run/user/1000/docker.sock",
+		},
+		{
+			description:   "tcp socket is not supported",
+			socket:        "tcp://127.0.0.1:2375",
+			expectedError: true,
+		},
+		{
+			description:   "default context",
+			dockerContext: docker.DefaultContext,
+			expected:      "/var/run/docker.sock",
+		},
+		{
+			description:   "socket and context cannot be combined",
+			socket:        "/var/run/docker.sock",
+			dockerContext: docker.DefaultContext,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			socket, err := getDockerSocket(tc.socket, tc.dockerContext, tc.hostFlavor)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, socket)
+		})
+	}
+}
+
+func TestGetRootlessDockerConfigPath(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+
+	path, err := getRootlessDockerConfigPath("/var/run/docker.sock")
+	require.NoError(t, err)
+	require.Equal(t, "", path)
+
+	path, err = getRootlessDockerConfigPath(fmt.Sprintf("/run/user/%v/docker.sock", current.Uid))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(current.HomeDir, ".config", "docker", "daemon.json"), path)
+}
+
+func TestDockerConfigApplied(t *testing.T) {
+	info := &docker.Info{
+		DefaultRuntime: "runc",
+		Runtimes: map[string]docker.InfoRuntime{
+			"runc":   {Path: "runc"},
+			"nvidia": {Path: "nvidia-container-runtime"},
+		},
+	}
+
+	testCases := []struct {
+		description string
+		options     nvidia.Options
+		expected    bool
+	}{
+		{
+			description: "runtime is configured",
+			options:     nvidia.Options{RuntimeName: "nvidia"},
+			expected:    true,
+		},
+		{
+			description: "runtime is not configured",
+			options:     nvidia.Options{RuntimeName: "nvidia-experimental"},
+			expected:    false,
+		},
+		{
+			description: "runtime is not the default",
+			options:     nvidia.Options{RuntimeName: "nvidia", SetAsDefault: true},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, dockerConfigApplied(info, tc.options))
+		})
+	}
+}
+
+func TestCheckApplied(t *testing.T) {
+	testCases := []struct {
+		description   string
+		validate      func() (bool, error)
+		expectedLevel logrus.Level
+	}{
+		{
+			description:   "no validator",
+			expectedLevel: logrus.InfoLevel,
+		},
+		{
+			description:   "api unavailable",
+			validate:      func() (bool, error) { return false, os.ErrNotExist },
+			expectedLevel: logrus.InfoLevel,
+		},
+		{
+			description:   "config not applied",
+			validate:      func() (bool, error) { return false, nil },
+			expectedLevel: logrus.WarnLevel,
+		},
+		{
+			description:   "config applied",
+			validate:      func() (bool, error) { return true, nil },
+			expectedLevel: logrus.InfoLevel,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			logger, hook := testlog.NewNullLogger()
+			m := command{logger: logger}
+
+			m.checkApplied(&engineConfig{daemon: "docker", validate: tc.validate})
+
+			require.NotNil(t, hook.LastEntry())
+			require.Equal(t, tc.expectedLevel, hook.LastEntry().Level)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/containerd"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/crio"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/podman"
+)
+
+// engineConfig represents the config of a single container engine that is updated.
+type engineConfig struct {
+	runtime string
+	path    string
+	// source is the path from which the config was loaded. This differs from path if the config
+	// file does not exist yet and the defaults for the layout are stored elsewhere.
+	source string
+	// layout is the name of the config layout used to determine the path. This is empty if the
+	// path was specified explicitly.
+	layout string
+	cfg    engine.Interface
+	// daemon is the name of the daemon that must be restarted for changes to be applied.
+	daemon string
+	// validate checks whether the daemon has applied the updated config. This is nil if this
+	// cannot be checked for the engine.
+	validate func() (bool, error)
+}
+
+// loadEngineConfig loads the config for the specified runtime. If the path is empty, the path is
+// determined by the specified config layout, with the layout being detected if this is empty or auto.
+// For docker, the default path and daemon name are determined by the specified host flavor and
+// config layouts are only considered for the default flavor. For containerd, the specified containerd
+// executable is used to determine the version of empty configs. For rootless podman, the drop-in file
+// in the config dir of the user is used.
+func loadEngineConfig(runtime string, path string, hostFlavor string, layoutName string, containerdPath string) (*engineConfig, error) {
+	e, err := resolveEngineConfig(runtime, path, hostFlavor, layoutName)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.load(containerdPath); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// resolveEngineConfig determines the path of the config for the specified runtime, as well as the
+// path from which it is loaded, without reading the config. See loadEngineConfig.
+func resolveEngineConfig(runtime string, path string, hostFlavor string, layoutName string) (*engineConfig, error) {
+	e := engineConfig{
+		runtime: runtime,
+		path:    path,
+	}
+
+	switch runtime {
+	case "containerd":
+		e.daemon = "containerd"
+	case "crio":
+		e.daemon = "cri-o"
+	case "docker":
+		flavor, err := docker.GetFlavor(hostFlavor)
+		if err != nil {
+			return nil, err
+		}
+		if flavor.Name != docker.FlavorDefault && e.path == "" {
+			e.path = flavor.ConfigFilePath
+		}
+		e.daemon = flavor.Daemon
+	case "podman":
+		// Podman does not run a daemon and reads its config for each command.
+		if e.path == "" && os.Geteuid() != 0 {
+			path, err := podman.GetRootlessConfigFilePath()
+			if err != nil {
+				return nil, err
+			}
+			e.path = path
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized runtime '%v'", runtime)
+	}
+
+	e.source = e.path
+	if e.path == "" {
+		layout, err := getConfigLayout("/", runtime, layoutName)
+		if err != nil {
+			return nil, err
+		}
+		e.layout = layout.name
+		e.path = layout.path
+		if e.path == "" {
+			e.path = getDefaultConfigFilePath(runtime)
+		}
+		if layout.daemon != "" {
+			e.daemon = layout.daemon
+		}
+		e.source = layout.getSource("/", e.path)
+	}
+
+	return &e, nil
+}
+
+// load loads the config of the engine from its source. For containerd, the specified containerd
+// executable is used to determine the version of empty configs.
+func (e *engineConfig) load(containerdPath string) error {
+	var err error
+	switch e.runtime {
+	case "containerd":
+		e.cfg, err = containerd.New(
+			containerd.WithPath(e.source),
+			containerd.WithContainerdPath(containerdPath),
+		)
+	case "crio":
+		e.cfg, err = crio.New(
+			crio.WithPath(e.source),
+		)
+	case "podman":
+		e.cfg, err = podman.New(
+			podman.WithPath(e.source),
+		)
+	case "docker":
+		e.cfg, err = docker.New(
+			docker.WithPath(e.source),
+		)
+	}
+	return err
+}
+
+// newEmptyEngineConfig creates an empty config for the specified runtime without reading any
+// config files. For containerd, the specified containerd executable is used to determine the
+// version of the config.
+func newEmptyEngineConfig(runtime string, containerdPath string) (engine.Interface, error) {
+	switch runtime {
+	case "containerd":
+		return containerd.New(
+			containerd.WithContainerdPath(containerdPath),
+		)
+	case "crio":
+		return crio.New()
+	case "podman":
+		return podman.New()
+	case "docker":
+		return docker.New()
+	}
+	return nil, fmt.Errorf("unrecognized runtime '%v'", runtime)
+}
+
+// LoadDefaultConfig loads the config of the specified runtime from the file that is updated if no
+// config file is specified. The path is determined by the specified host flavor and config layout as
+// for the configure command. The path of the config file is returned together with the loaded config.
+func LoadDefaultConfig(runtime string, hostFlavor string, layoutName string) (string, engine.Interface, error) {
+	e, err := loadEngineConfig(runtime, "", hostFlavor, layoutName, "containerd")
+	if err != nil {
+		return "", nil, err
+	}
+	return e.path, e.cfg, nil
+}
+
+// getDefaultConfigFilePath returns the default path of the config file for the specified runtime.
+func getDefaultConfigFilePath(runtime string) string {
+	switch runtime {
+	case "containerd":
+		return defaultContainerdConfigFilePath
+	case "crio":
+		return defaultCrioConfigFilePath
+	case "podman":
+		return podman.DefaultConfigFilePath
+	}
+	flavor, _ := docker.GetFlavor(docker.FlavorDefault)
+	return flavor.ConfigFilePath
+}
+
+// backup stores the original contents of a config file so that it can be restored if
+// the update of another engine fails.
+type backup struct {
+	path     string
+	exists   bool
+	mode     os.FileMode
+	contents []byte
+}
+
+// newBackup reads the current contents of the specified file.
+func newBackup(path string) (*backup, error) {
+	b := backup{
+		path: path,
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return &b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b.exists = true
+	b.mode = info.Mode().Perm()
+	b.contents = contents
+
+	return &b, nil
+}
+
+// restore writes the original contents of the file using engine.WriteFile so that the file is
+// replaced atomically and a symlinked config is restored in place. If the file did not exist, it is
+// removed.
+func (b backup) restore() error {
+	if !b.exists {
+		err := os.Remove(b.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if _, err := engine.WriteFile(b.path, b.contents); err != nil {
+		return err
+	}
+	// The mode of the file is preserved by engine.WriteFile unless the file was removed by the
+	// update (e.g. an empty drop-in config).
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm() == b.mode {
+		return nil
+	}
+	return os.Chmod(b.path, b.mode)
+}
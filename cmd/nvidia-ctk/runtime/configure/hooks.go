@@ -0,0 +1,65 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// hooks defines the commands that are run before and after the engine configs are updated.
+// These allow for integration with immutable or transactional distributions where changes
+// to /etc need to be committed (e.g. using transactional-update) or validated.
+type hooks struct {
+	pre  []string
+	post []string
+}
+
+// runHooks runs the specified commands in order using sh. The runtimes and config paths that
+// are being updated are passed to the commands in the environment. The first command that
+// fails causes an error to be returned.
+func (m command) runHooks(stage string, commands []string, engines []*engineConfig) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	var runtimes []string
+	var paths []string
+	for _, e := range engines {
+		runtimes = append(runtimes, e.runtime)
+		paths = append(paths, e.path)
+	}
+	env := append(os.Environ(),
+		"NVIDIA_CTK_HOOK_STAGE="+stage,
+		"NVIDIA_CTK_RUNTIMES="+strings.Join(runtimes, ","),
+		"NVIDIA_CTK_CONFIG_PATHS="+strings.Join(paths, ","),
+	)
+
+	for _, command := range commands {
+		m.logger.Infof("Running %v-configure hook: %v", stage, command)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%v-configure hook %q failed: %v", stage, command, err)
+		}
+	}
+	return nil
+}
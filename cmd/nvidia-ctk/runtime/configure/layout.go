@@ -0,0 +1,131 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	configLayoutAuto      = "auto"
+	configLayoutDefault   = "default"
+	configLayoutSnap      = "snap"
+	configLayoutMicroK8s  = "microk8s"
+	configLayoutRpmOstree = "rpm-ostree"
+
+	// ostreeBootedPath exists on hosts that are booted using (rpm-)ostree.
+	ostreeBootedPath = "/run/ostree-booted"
+)
+
+// configLayout defines the location of the config file of an engine for a particular type of
+// installation.
+type configLayout struct {
+	name string
+	// path is the path of the config file that is updated. If this is empty, the default path for
+	// the engine is used.
+	path string
+	// fallback is the path from which the config is loaded if path does not exist. On rpm-ostree
+	// hosts, the default configs are shipped in /usr/etc and /etc only contains modified files.
+	fallback string
+	// daemon is the name of the daemon that must be restarted. If this is empty, the default
+	// daemon for the engine is used.
+	daemon string
+	// indicator is a path that only exists if the layout is in use.
+	indicator string
+}
+
+// configLayouts defines the non-default config layouts for each engine in the order in which they
+// are detected.
+var configLayouts = map[string][]configLayout{
+	"containerd": {
+		{
+			// MicroK8s generates the containerd config from a template on startup.
+			name:      configLayoutMicroK8s,
+			path:      "/var/snap/microk8s/current/args/containerd-template.toml",
+			daemon:    "snap.microk8s.daemon-containerd",
+			indicator: "/var/snap/microk8s/current/args/containerd-template.toml",
+		},
+		{
+			name:      configLayoutRpmOstree,
+			fallback:  "/usr" + defaultContainerdConfigFilePath,
+			indicator: ostreeBootedPath,
+		},
+	},
+	"crio": {
+		{
+			name:      configLayoutRpmOstree,
+			fallback:  "/usr" + defaultCrioConfigFilePath,
+			indicator: ostreeBootedPath,
+		},
+	},
+	"docker": {
+		{
+			name:      configLayoutSnap,
+			path:      "/var/snap/docker/current/config/daemon.json",
+			daemon:    "snap.docker.dockerd",
+			indicator: "/var/snap/docker/current/config",
+		},
+		{
+			name:      configLayoutRpmOstree,
+			fallback:  "/usr/etc/docker/daemon.json",
+			indicator: ostreeBootedPath,
+		},
+	},
+}
+
+// getConfigLayout returns the config layout with the specified name for the specified runtime. If the
+// name is empty or auto, the layout is detected by checking for the indicator of each layout under the
+// specified root. The default layout is returned if no other layout is detected.
+func getConfigLayout(root string, runtime string, name string) (*configLayout, error) {
+	switch name {
+	case "", configLayoutAuto:
+		for _, layout := range configLayouts[runtime] {
+			if _, err := os.Stat(filepath.Join(root, layout.indicator)); err == nil {
+				l := layout
+				return &l, nil
+			}
+		}
+		return &configLayout{name: configLayoutDefault}, nil
+	case configLayoutDefault:
+		return &configLayout{name: configLayoutDefault}, nil
+	}
+
+	for _, layout := range configLayouts[runtime] {
+		if layout.name == name {
+			l := layout
+			return &l, nil
+		}
+	}
+	return nil, fmt.Errorf("config layout '%v' is not supported for %v", name, runtime)
+}
+
+// getSource returns the path from which the config at the specified path is loaded. This is the
+// fallback path of the layout if path does not exist under the specified root, but the fallback does.
+func (l configLayout) getSource(root string, path string) string {
+	if l.fallback == "" {
+		return path
+	}
+	if _, err := os.Stat(filepath.Join(root, path)); err == nil {
+		return path
+	}
+	if _, err := os.Stat(filepath.Join(root, l.fallback)); err == nil {
+		return l.fallback
+	}
+	return path
+}
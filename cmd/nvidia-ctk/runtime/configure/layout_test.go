@@ -0,0 +1,159 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfigLayout(t *testing.T) {
+	testCases := []struct {
+		description    string
+		runtime        string
+		layout         string
+		files          []string
+		expectedLayout string
+		expectedPath   string
+		expectedDaemon string
+		expectedError  bool
+	}{
+		{
+			description:    "default layout is detected if no indicators exist",
+			runtime:        "containerd",
+			expectedLayout: configLayoutDefault,
+		},
+		{
+			description:    "microk8s is detected for containerd",
+			runtime:        "containerd",
+			files:          []string{"/var/snap/microk8s/current/args/containerd-template.toml", ostreeBootedPath},
+			expectedLayout: configLayoutMicroK8s,
+			expectedPath:   "/var/snap/microk8s/current/args/containerd-template.toml",
+			expectedDaemon: "snap.microk8s.daemon-containerd",
+		},
+		{
+			description:    "microk8s is not detected for docker",
+			runtime:        "docker",
+			files:          []string{"/var/snap/microk8s/current/args/containerd-template.toml"},
+			expectedLayout: configLayoutDefault,
+		},
+		{
+			description:    "snap is detected for docker",
+			runtime:        "docker",
+			files:          []string{"/var/snap/docker/current/config/daemon.json"},
+			expectedLayout: configLayoutSnap,
+			expectedPath:   "/var/snap/docker/current/config/daemon.json",
+			expectedDaemon: "snap.docker.dockerd",
+		},
+		{
+			description:    "rpm-ostree is detected for crio",
+			runtime:        "crio",
+			files:          []string{ostreeBootedPath},
+			expectedLayout: configLayoutRpmOstree,
+		},
+		{
+			description:    "explicit layout is used without detection",
+			runtime:        "docker",
+			layout:         configLayoutSnap,
+			expectedLayout: configLayoutSnap,
+			expectedPath:   "/var/snap/docker/current/config/daemon.json",
+			expectedDaemon: "snap.docker.dockerd",
+		},
+		{
+			description:    "explicit default layout overrides detection",
+			runtime:        "docker",
+			layout:         configLayoutDefault,
+			files:          []string{"/var/snap/docker/current/config/daemon.json"},
+			expectedLayout: configLayoutDefault,
+		},
+		{
+			description:   "unsupported layout for runtime is an error",
+			runtime:       "containerd",
+			layout:        configLayoutSnap,
+			expectedError: true,
+		},
+		{
+			description:   "unknown layout is an error",
+			runtime:       "docker",
+			layout:        "unknown",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range tc.files {
+				createFile(t, filepath.Join(root, file))
+			}
+
+			layout, err := getConfigLayout(root, tc.runtime, tc.layout)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedLayout, layout.name)
+			require.Equal(t, tc.expectedPath, layout.path)
+			require.Equal(t, tc.expectedDaemon, layout.daemon)
+		})
+	}
+}
+
+func TestConfigLayoutGetSource(t *testing.T) {
+	testCases := []struct {
+		description    string
+		files          []string
+		expectedSource string
+	}{
+		{
+			description:    "path is used if neither file exists",
+			expectedSource: "/etc/crio/crio.conf",
+		},
+		{
+			description:    "fallback is used if path does not exist",
+			files:          []string{"/usr/etc/crio/crio.conf"},
+			expectedSource: "/usr/etc/crio/crio.conf",
+		},
+		{
+			description:    "path takes precedence over fallback",
+			files:          []string{"/etc/crio/crio.conf", "/usr/etc/crio/crio.conf"},
+			expectedSource: "/etc/crio/crio.conf",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range tc.files {
+				createFile(t, filepath.Join(root, file))
+			}
+
+			layout, err := getConfigLayout(root, "crio", configLayoutRpmOstree)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSource, layout.getSource(root, defaultCrioConfigFilePath))
+		})
+	}
+}
+
+func createFile(t *testing.T, path string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+}
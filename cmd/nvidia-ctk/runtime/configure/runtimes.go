@@ -0,0 +1,61 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+)
+
+// parseRuntimeClasses returns the NVIDIA runtimes that are added to the engine configs for the
+// values of the --nvidia-runtime-name flag. Each value is either the name of a runtime or NAME=MODE.
+// The executable of each runtime is derived from the specified runtime path and the mode so that
+// runtimes with a mode other than auto use the executable that forces this mode.
+func parseRuntimeClasses(values []string, runtimePath string) ([]nvidia.Runtime, error) {
+	var runtimes []nvidia.Runtime
+	seen := make(map[string]bool)
+	for _, value := range values {
+		name, mode, _ := strings.Cut(strings.TrimSpace(value), "=")
+		name = strings.TrimSpace(name)
+		mode = strings.TrimSpace(mode)
+		if name == "" {
+			return nil, fmt.Errorf("invalid runtime %q: the runtime name is empty", value)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("runtime %q is specified more than once", name)
+		}
+		seen[name] = true
+
+		if mode == "" {
+			mode = nvidia.ModeAuto
+		}
+		path, err := nvidia.ModeExecutable(runtimePath, mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid runtime %q: %v", value, err)
+		}
+		runtimes = append(runtimes, nvidia.Runtime{
+			Name: name,
+			Path: path,
+		})
+	}
+	if len(runtimes) == 0 {
+		return nil, fmt.Errorf("no NVIDIA runtime name specified")
+	}
+	return runtimes, nil
+}
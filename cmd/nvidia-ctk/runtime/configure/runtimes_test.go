@@ -0,0 +1,76 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package configure
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuntimeClasses(t *testing.T) {
+	testCases := []struct {
+		values        string
+		runtimePath   string
+		expected      []nvidia.Runtime
+		expectedError bool
+	}{
+		{
+			values:      "nvidia",
+			runtimePath: "nvidia-container-runtime",
+			expected:    []nvidia.Runtime{{Name: "nvidia", Path: "nvidia-container-runtime"}},
+		},
+		{
+			values:      "nvidia=auto,nvidia-cdi=cdi,nvidia-legacy=legacy",
+			runtimePath: "/usr/bin/nvidia-container-runtime",
+			expected: []nvidia.Runtime{
+				{Name: "nvidia", Path: "/usr/bin/nvidia-container-runtime"},
+				{Name: "nvidia-cdi", Path: "/usr/bin/nvidia-container-runtime.cdi"},
+				{Name: "nvidia-legacy", Path: "/usr/bin/nvidia-container-runtime.legacy"},
+			},
+		},
+		{
+			values:        "nvidia=csv",
+			runtimePath:   "nvidia-container-runtime",
+			expectedError: true,
+		},
+		{
+			values:        "nvidia,nvidia=cdi",
+			runtimePath:   "nvidia-container-runtime",
+			expectedError: true,
+		},
+		{
+			values:        "=cdi",
+			runtimePath:   "nvidia-container-runtime",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.values, func(t *testing.T) {
+			runtimes, err := parseRuntimeClasses(strings.Split(tc.values, ","), tc.runtimePath)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, runtimes)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package migrateconfig
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/containerd"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/tomlfmt"
+	"github.com/pelletier/go-toml"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultContainerdConfigFilePath = "/etc/containerd/config.toml"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	runtime        string
+	configFilePath string
+	toVersion      int
+	wholeFile      bool
+	containerdPath string
+	dryRun         bool
+}
+
+// NewCommand constructs a migrate-config command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'migrate-config' command
+	c := cli.Command{
+		Name:  "migrate-config",
+		Usage: "Migrate the config of the specified container engine to a later config version",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "runtime",
+			Usage:       "the target runtime engine; only containerd is supported",
+			Value:       "containerd",
+			Destination: &opts.runtime,
+		},
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "path to the config file for the target runtime",
+			Value:       defaultContainerdConfigFilePath,
+			Destination: &opts.configFilePath,
+		},
+		&cli.IntFlag{
+			Name:        "to-version",
+			Usage:       "the config version to migrate to (2 or 3)",
+			Required:    true,
+			Destination: &opts.toVersion,
+		},
+		&cli.BoolFlag{
+			Name:        "whole-file",
+			Usage:       "migrate the settings that are not managed by the NVIDIA Container Toolkit using 'containerd config migrate'. If not set, an error is raised if such settings are present.",
+			Destination: &opts.wholeFile,
+		},
+		&cli.StringFlag{
+			Name:        "containerd-path",
+			Usage:       "the path to the containerd executable used to migrate the whole file",
+			Value:       "containerd",
+			Destination: &opts.containerdPath,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "print a unified diff of the changes to the config file instead of updating it. The command fails if any changes would be made",
+			Destination: &opts.dryRun,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	if opts.runtime != "containerd" {
+		return fmt.Errorf("unsupported runtime: %v", opts.runtime)
+	}
+	if opts.toVersion != 2 && opts.toVersion != 3 {
+		return fmt.Errorf("unsupported config version: %v", opts.toVersion)
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	// The lock is held while the config is migrated so that concurrent updates of the config are not lost.
+	if !opts.dryRun {
+		lock, err := engine.Lock(opts.configFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
+	tree, err := loadConfig(opts.configFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	var migrateOther containerd.MigrateFunc
+	if opts.wholeFile {
+		migrateOther = m.containerdMigrate(opts.containerdPath)
+	}
+
+	migrated, err := containerd.Migrate(tree, opts.toVersion, migrateOther)
+	if err != nil {
+		return fmt.Errorf("failed to migrate config: %v", err)
+	}
+
+	output, err := tomlfmt.RenderFile(opts.configFilePath, migrated)
+	if err != nil {
+		return fmt.Errorf("unable to render config: %v", err)
+	}
+
+	if opts.dryRun {
+		diff, err := engine.DiffFile(opts.configFilePath, []byte(output))
+		if err != nil {
+			return fmt.Errorf("unable to compare config: %v", err)
+		}
+		if diff == "" {
+			return nil
+		}
+		fmt.Fprint(c.App.Writer, diff)
+		return fmt.Errorf("changes would be made to %v", opts.configFilePath)
+	}
+
+	if _, err := engine.WriteFile(opts.configFilePath, []byte(output)); err != nil {
+		return fmt.Errorf("unable to write config: %v", err)
+	}
+	m.logger.Infof("Migrated config %v to version %v", opts.configFilePath, opts.toVersion)
+	return nil
+}
+
+// loadConfig loads the containerd config from the specified path. A file that does not
+// exist is treated as an empty config.
+func loadConfig(path string) (*toml.Tree, error) {
+	tree, err := toml.LoadFile(path)
+	if os.IsNotExist(err) {
+		return toml.TreeFromMap(nil)
+	}
+	return tree, err
+}
+
+// containerdMigrate returns a function that migrates a config using the migration logic
+// of the specified containerd executable.
+func (m command) containerdMigrate(containerdPath string) containerd.MigrateFunc {
+	return func(tree *toml.Tree) (*toml.Tree, error) {
+		dir, err := os.MkdirTemp("", "nvidia-ctk-migrate-config-")
+		if err != nil {
+			return nil, fmt.Errorf("unable to create temporary directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		contents, err := tree.ToTomlString()
+		if err != nil {
+			return nil, fmt.Errorf("unable to render config: %v", err)
+		}
+		path := filepath.Join(dir, "config.toml")
+		if _, err := engine.WriteFile(path, []byte(contents)); err != nil {
+			return nil, fmt.Errorf("unable to write config: %v", err)
+		}
+
+		m.logger.Debugf("Running %v config migrate", containerdPath)
+		output, err := exec.Command(containerdPath, "--config", path, "config", "migrate").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run %v config migrate: %v", containerdPath, err)
+		}
+		return toml.LoadBytes(output)
+	}
+}
@@ -16,13 +16,26 @@
 
 package nvidia
 
+import "fmt"
+
 const (
 	// RuntimeName is the default name to use in configs for the NVIDIA Container Runtime
 	RuntimeName = "nvidia"
 	// RuntimeExecutable is the default NVIDIA Container Runtime executable file name
 	RuntimeExecutable = "nvidia-container-runtime"
+
+	// ModeAuto is the mode of a runtime that uses the mode from the NVIDIA Container Runtime config
+	ModeAuto = "auto"
 )
 
+// modeExecutableSuffixes maps the modes that can be forced for a runtime to the suffixes of the
+// executables that force these modes (e.g. nvidia-container-runtime.cdi).
+var modeExecutableSuffixes = map[string]string{
+	ModeAuto: "",
+	"cdi":    ".cdi",
+	"legacy": ".legacy",
+}
+
 // Options specifies the options for the NVIDIA Container Runtime w.r.t a container engine such as docker.
 type Options struct {
 	SetAsDefault bool
@@ -63,6 +76,19 @@ func (o Options) Runtime() Runtime {
 	return r
 }
 
+// ModeExecutable returns the path of the executable that runs the NVIDIA Container Runtime at the
+// specified path in the specified mode. For the auto mode, the path is returned unchanged.
+func ModeExecutable(runtimePath string, mode string) (string, error) {
+	suffix, ok := modeExecutableSuffixes[mode]
+	if !ok {
+		return "", fmt.Errorf("unsupported runtime mode %q; one of [auto, cdi, legacy] is expected", mode)
+	}
+	if runtimePath == "" {
+		runtimePath = RuntimeExecutable
+	}
+	return runtimePath + suffix, nil
+}
+
 // DockerRuntimesConfig generatest the expected docker config for the specified runtime
 func (r Runtime) DockerRuntimesConfig() map[string]interface{} {
 	runtimes := make(map[string]interface{})
@@ -0,0 +1,124 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	spec string
+	mode string
+}
+
+// NewCommand constructs a patch command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'patch' command
+	c := cli.Command{
+		Name:  "patch",
+		Usage: "Output the RFC6902 JSON Patch of the modifications that the NVIDIA Container Runtime would apply to an OCI specification",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "spec",
+			Usage:       "The path to the OCI specification (config.json) to process. If set to -, the specification is read from stdin.",
+			Value:       "config.json",
+			Destination: &opts.spec,
+		},
+		&cli.StringFlag{
+			Name:        "mode",
+			Usage:       "Override the mode of the NVIDIA Container Runtime specified in the config",
+			Destination: &opts.mode,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	spec, err := loadSpec(opts.spec)
+	if err != nil {
+		return fmt.Errorf("failed to load OCI specification: %v", err)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	if opts.mode != "" {
+		cfg.NVIDIAContainerRuntimeConfig.Mode = opts.mode
+	}
+
+	patch, err := runtime.ComputePatch(m.logger, cfg, spec)
+	if err != nil {
+		return fmt.Errorf("failed to compute modifications: %v", err)
+	}
+
+	output, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %v", err)
+	}
+	fmt.Fprintf(c.App.Writer, "%s\n", output)
+	return nil
+}
+
+// loadSpec reads the OCI specification from the specified path or from stdin if the path is -.
+func loadSpec(path string) (*specs.Spec, error) {
+	var contents []byte
+	var err error
+	if path == "-" {
+		contents, err = io.ReadAll(os.Stdin)
+	} else {
+		contents, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var spec specs.Spec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
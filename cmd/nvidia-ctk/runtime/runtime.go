@@ -18,6 +18,9 @@ package runtime
 
 import (
 	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/configure"
+	migrateconfig "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/migrate-config"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/patch"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/status"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -43,6 +46,9 @@ func (m runtimeCommand) build() *cli.Command {
 
 	runtime.Subcommands = []*cli.Command{
 		configure.NewCommand(m.logger),
+		patch.NewCommand(m.logger),
+		migrateconfig.NewCommand(m.logger),
+		status.NewCommand(m.logger),
 	}
 
 	return &runtime
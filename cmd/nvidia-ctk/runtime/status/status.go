@@ -0,0 +1,299 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/configure"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/runtime/nvidia"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// engineExecutables are the executables that indicate that a container engine is installed.
+var engineExecutables = map[string]string{
+	"containerd": "containerd",
+	"crio":       "crio",
+	"docker":     "dockerd",
+	"podman":     "podman",
+}
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	runtime      string
+	runtimes     []string
+	hostFlavor   string
+	configLayout string
+	format       string
+}
+
+// engineStatus is the status of the NVIDIA runtimes for a single container engine.
+type engineStatus struct {
+	Engine         string          `json:"engine"`
+	Executable     string          `json:"executable,omitempty"`
+	Config         string          `json:"config"`
+	ConfigExists   bool            `json:"configExists"`
+	DefaultRuntime string          `json:"defaultRuntime,omitempty"`
+	CDIEnabled     bool            `json:"cdiEnabled"`
+	Runtimes       []runtimeStatus `json:"runtimes"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// runtimeStatus is the status of an NVIDIA runtime that is registered with a container engine.
+type runtimeStatus struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Default bool   `json:"default"`
+}
+
+// NewCommand constructs a status command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'status' command
+	c := cli.Command{
+		Name:  "status",
+		Usage: "Report the NVIDIA runtimes that are registered with each container engine on the host",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "runtime",
+			Usage:       "the container engine to report. One of [containerd, crio, docker, podman]. Multiple engines can be specified as a comma-separated list. If this is not specified, all engines that are installed or have a config file are reported",
+			Destination: &opts.runtime,
+		},
+		&cli.StringFlag{
+			Name:        "host-flavor",
+			Usage:       "the flavor of the host on which docker is installed. This determines the default config file path. One of [default, balena]",
+			Value:       docker.FlavorDefault,
+			Destination: &opts.hostFlavor,
+		},
+		&cli.StringFlag{
+			Name:        "config-layout",
+			Usage:       "the installation layout used to determine the path to the config files. One of [auto, default, snap, microk8s, rpm-ostree]",
+			Value:       "auto",
+			Destination: &opts.configLayout,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	for _, runtime := range strings.Split(opts.runtime, ",") {
+		runtime = strings.TrimSpace(runtime)
+		if runtime == "" {
+			continue
+		}
+		if _, ok := engineExecutables[runtime]; !ok {
+			return fmt.Errorf("unrecognized runtime '%v'", runtime)
+		}
+		opts.runtimes = append(opts.runtimes, runtime)
+	}
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	runtimes := opts.runtimes
+	explicit := len(runtimes) > 0
+	if !explicit {
+		for runtime := range engineExecutables {
+			runtimes = append(runtimes, runtime)
+		}
+		sort.Strings(runtimes)
+	}
+
+	// The engine config packages use the standard logger to report how each config is loaded, which
+	// is only of interest when debugging the status command.
+	if !m.logger.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.SetLevel(logrus.ErrorLevel)
+	}
+
+	locator := lookup.NewExecutableLocator(m.logger, "")
+	statuses := []engineStatus{}
+	for _, runtime := range runtimes {
+		s := m.getEngineStatus(locator, runtime, opts)
+		if !explicit && s.Executable == "" && !s.ConfigExists {
+			m.logger.Debugf("Skipping %v: not installed", runtime)
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+	return render(c.App.Writer, statuses)
+}
+
+// getEngineStatus loads the config of the specified container engine and determines the status of
+// the NVIDIA runtimes that are registered in it.
+func (m command) getEngineStatus(locator lookup.Locator, runtime string, opts *options) engineStatus {
+	s := engineStatus{
+		Engine: runtime,
+	}
+	if paths, err := locator.Locate(engineExecutables[runtime]); err == nil && len(paths) > 0 {
+		s.Executable = paths[0]
+	}
+
+	path, cfg, err := configure.LoadDefaultConfig(runtime, opts.hostFlavor, opts.configLayout)
+	if err != nil {
+		s.Error = fmt.Sprintf("failed to load config: %v", err)
+		return s
+	}
+	s.Config = path
+	if _, err := os.Stat(path); err == nil {
+		s.ConfigExists = true
+	}
+
+	return updateEngineStatus(s, cfg, func(path string) string {
+		return m.getRuntimeVersion(locator, path)
+	})
+}
+
+// updateEngineStatus adds the default runtime, CDI support, and the registered NVIDIA runtimes of the
+// specified config to the engine status. The version of each runtime is determined using the
+// specified function.
+func updateEngineStatus(s engineStatus, cfg engine.Interface, getVersion func(string) string) engineStatus {
+	s.DefaultRuntime = cfg.DefaultRuntime()
+	s.Runtimes = []runtimeStatus{}
+
+	inspector, ok := cfg.(engine.Inspector)
+	if !ok {
+		return s
+	}
+	s.CDIEnabled = inspector.CDIEnabled()
+
+	for name, path := range inspector.Runtimes() {
+		if !isNVIDIARuntime(path) {
+			continue
+		}
+		s.Runtimes = append(s.Runtimes, runtimeStatus{
+			Name:    name,
+			Path:    path,
+			Version: getVersion(path),
+			Default: name == s.DefaultRuntime,
+		})
+	}
+	sort.Slice(s.Runtimes, func(i, j int) bool {
+		return s.Runtimes[i].Name < s.Runtimes[j].Name
+	})
+	return s
+}
+
+// getRuntimeVersion returns the version of the NVIDIA Container Runtime executable at the specified
+// path. If the path is not absolute, the executable is located in the PATH. If the version cannot be
+// determined, "" is returned.
+func (m command) getRuntimeVersion(locator lookup.Locator, path string) string {
+	paths, err := locator.Locate(path)
+	if err != nil || len(paths) == 0 {
+		m.logger.Debugf("Could not locate %v: %v", path, err)
+		return ""
+	}
+	version, err := info.GetComponentVersion(nvidia.RuntimeExecutable, paths[0])
+	if err != nil {
+		m.logger.Debugf("Could not determine the version of %v: %v", paths[0], err)
+		return ""
+	}
+	return version
+}
+
+// isNVIDIARuntime checks whether the specified executable is an NVIDIA Container Runtime executable
+// (e.g. nvidia-container-runtime or nvidia-container-runtime.cdi).
+func isNVIDIARuntime(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), nvidia.RuntimeExecutable)
+}
+
+// render writes the specified engine statuses to the writer as a table. A row is written for each
+// registered NVIDIA runtime, or a single row if no NVIDIA runtimes are registered.
+func render(w io.Writer, statuses []engineStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENGINE\tCONFIG\tDEFAULT\tCDI\tRUNTIME\tPATH\tVERSION")
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Fprintf(tw, "%v\t%v\t-\t-\t-\t-\t-\n", s.Engine, s.Error)
+			continue
+		}
+		config := s.Config
+		if !s.ConfigExists {
+			config += " (not found)"
+		}
+		if len(s.Runtimes) == 0 {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t-\t-\t-\n", s.Engine, config, valueOrDash(s.DefaultRuntime), s.CDIEnabled)
+			continue
+		}
+		for _, r := range s.Runtimes {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", s.Engine, config, valueOrDash(s.DefaultRuntime), s.CDIEnabled, r.Name, r.Path, valueOrDash(r.Version))
+		}
+	}
+	return tw.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,93 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package status
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateEngineStatus(t *testing.T) {
+	cfg := &docker.Config{
+		"default-runtime": "nvidia",
+		"runtimes": map[string]interface{}{
+			"nvidia": map[string]interface{}{
+				"path": "/usr/bin/nvidia-container-runtime",
+			},
+			"nvidia-cdi": map[string]interface{}{
+				"path": "nvidia-container-runtime.cdi",
+			},
+			"crun": map[string]interface{}{
+				"path": "/usr/bin/crun",
+			},
+		},
+		"features": map[string]interface{}{
+			"cdi": true,
+		},
+	}
+
+	versions := map[string]string{
+		"/usr/bin/nvidia-container-runtime": "1.13.0",
+	}
+	s := updateEngineStatus(engineStatus{Engine: "docker"}, cfg, func(path string) string {
+		return versions[path]
+	})
+
+	expected := engineStatus{
+		Engine:         "docker",
+		DefaultRuntime: "nvidia",
+		CDIEnabled:     true,
+		Runtimes: []runtimeStatus{
+			{Name: "nvidia", Path: "/usr/bin/nvidia-container-runtime", Version: "1.13.0", Default: true},
+			{Name: "nvidia-cdi", Path: "nvidia-container-runtime.cdi"},
+		},
+	}
+	require.Equal(t, expected, s)
+}
+
+func TestRender(t *testing.T) {
+	statuses := []engineStatus{
+		{
+			Engine:       "containerd",
+			Config:       "/etc/containerd/config.toml",
+			ConfigExists: true,
+			Runtimes: []runtimeStatus{
+				{Name: "nvidia", Path: "/usr/bin/nvidia-container-runtime", Version: "1.13.0"},
+			},
+		},
+		{
+			Engine:         "docker",
+			Config:         "/etc/docker/daemon.json",
+			DefaultRuntime: "runc",
+		},
+		{
+			Engine: "podman",
+			Error:  "failed to load config: invalid",
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, render(buf, statuses))
+	require.Equal(t, `ENGINE      CONFIG                               DEFAULT  CDI    RUNTIME  PATH                               VERSION
+containerd  /etc/containerd/config.toml          -        false  nvidia   /usr/bin/nvidia-container-runtime  1.13.0
+docker      /etc/docker/daemon.json (not found)  runc     false  -        -                                  -
+podman      failed to load config: invalid       -        -      -        -                                  -
+`, buf.String())
+}
@@ -0,0 +1,210 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package impacted
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	device    string
+	stateFile string
+	format    string
+	root      string
+	procRoot  string
+}
+
+// impact describes the containers impacted by a GPU.
+type impact struct {
+	UUID       string `json:"uuid,omitempty"`
+	BusID      string `json:"busId"`
+	DeviceNode string `json:"deviceNode,omitempty"`
+	// Unavailable indicates whether the GPU is marked as unavailable in the device state.
+	Unavailable bool       `json:"unavailable"`
+	RemovedAt   *time.Time `json:"removedAt,omitempty"`
+	CDIDevices  []string   `json:"cdiDevices,omitempty"`
+	Containers  []string   `json:"containers"`
+}
+
+// NewCommand constructs an impacted command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build creates the CLI command
+func (m command) build() *cli.Command {
+	opts := options{
+		root:     "/",
+		procRoot: "/proc",
+	}
+
+	// Create the 'impacted' command
+	c := cli.Command{
+		Name:  "impacted",
+		Usage: "List the containers impacted by a GPU that was removed from the node or reported an XID error",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "device",
+			Usage:       "The UUID (or PCI bus ID) of the GPU",
+			Required:    true,
+			Destination: &opts.device,
+		},
+		&cli.StringFlag{
+			Name:        "state-file",
+			Usage:       "The device state file. If this is not specified, the state file configured in the config.toml file is used.",
+			Destination: &opts.stateFile,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "The output format. One of [table | json]",
+			Value:       formatTable,
+			Destination: &opts.format,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.format {
+	case formatTable, formatJSON:
+	default:
+		return fmt.Errorf("invalid format: %v", opts.format)
+	}
+
+	if !c.IsSet("state-file") {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		opts.stateFile = cfg.NVIDIAContainerRuntimeConfig.DeviceState.StateFile
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	result, err := m.getImpact(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.format == formatJSON {
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return render(c.App.Writer, result)
+}
+
+// getImpact determines the containers impacted by the specified GPU. If the GPU is marked as
+// unavailable, the containers recorded when it was removed are returned. Otherwise the GPU is
+// still present (e.g. after an XID error) and the containers using it are determined from the
+// processes that have its device node open.
+func (m command) getImpact(opts *options) (*impact, error) {
+	state, err := devicestate.NewStore(opts.stateFile).Load()
+	if err != nil {
+		return nil, err
+	}
+	d := state.Get(opts.device)
+	if d == nil {
+		d = state.Get(info.NormalizeBusID(opts.device))
+	}
+	if d != nil {
+		removedAt := d.RemovedAt
+		return &impact{
+			UUID:        d.UUID,
+			BusID:       d.BusID,
+			DeviceNode:  d.DeviceNode,
+			Unavailable: true,
+			RemovedAt:   &removedAt,
+			CDIDevices:  d.CDIDevices,
+			Containers:  d.Containers,
+		}, nil
+	}
+
+	gpus, err := devicestate.GetGPUs(opts.root)
+	if err != nil {
+		return nil, err
+	}
+	for _, gpu := range gpus {
+		if gpu.UUID != opts.device && gpu.BusID != info.NormalizeBusID(opts.device) {
+			continue
+		}
+		result := impact{
+			UUID:       gpu.UUID,
+			BusID:      gpu.BusID,
+			DeviceNode: gpu.DeviceNode(),
+		}
+		if result.DeviceNode == "" {
+			return nil, fmt.Errorf("the device node of GPU %v is unknown", opts.device)
+		}
+		result.Containers, err = devicestate.FindContainers(opts.procRoot, result.DeviceNode)
+		if err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+	return nil, fmt.Errorf("GPU %v not found", opts.device)
+}
+
+// render writes the specified impact to the writer as a table.
+func render(w io.Writer, result *impact) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "GPU:\t%v\n", result.UUID)
+	fmt.Fprintf(tw, "Bus ID:\t%v\n", result.BusID)
+	fmt.Fprintf(tw, "Device node:\t%v\n", result.DeviceNode)
+	if result.Unavailable {
+		fmt.Fprintf(tw, "Removed at:\t%v\n", result.RemovedAt.Format(time.RFC3339))
+		fmt.Fprintf(tw, "CDI devices:\t%v\n", strings.Join(result.CDIDevices, ", "))
+	}
+	fmt.Fprintln(tw, "Impacted containers:")
+	for _, id := range result.Containers {
+		fmt.Fprintf(tw, "\t%v\n", id)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,146 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package impacted
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetImpact(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	gpuDir := filepath.Join(root, "proc/driver/nvidia/gpus/0000:5e:00.0")
+	require.NoError(t, os.MkdirAll(gpuDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(gpuDir, "information"), []byte("GPU UUID:        GPU-7ab1e2c4\nBus Location:    0000:5e:00.0\nDevice Minor:    0\n"), 0644))
+
+	stateFile := filepath.Join(t.TempDir(), "device-state.json")
+	removedAt := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := devicestate.NewStore(stateFile).Update(func(s *devicestate.State) error {
+		s.MarkUnavailable(devicestate.Device{
+			UUID:       "GPU-edfee158",
+			BusID:      "0000:3b:00.0",
+			DeviceNode: "/dev/nvidia1",
+			CDIDevices: []string{"nvidia.com/gpu=1", "nvidia.com/gpu=all"},
+			Containers: []string{"abc"},
+			RemovedAt:  removedAt,
+		})
+		return nil
+	})
+	require.NoError(t, err)
+
+	c := command{logger: logger}
+
+	testCases := []struct {
+		description    string
+		device         string
+		expectedImpact *impact
+		expectedError  bool
+	}{
+		{
+			description: "unavailable device by UUID",
+			device:      "GPU-edfee158",
+			expectedImpact: &impact{
+				UUID:        "GPU-edfee158",
+				BusID:       "0000:3b:00.0",
+				DeviceNode:  "/dev/nvidia1",
+				Unavailable: true,
+				RemovedAt:   &removedAt,
+				CDIDevices:  []string{"nvidia.com/gpu=1", "nvidia.com/gpu=all"},
+				Containers:  []string{"abc"},
+			},
+		},
+		{
+			description: "unavailable device by bus ID",
+			device:      "0000:3B:00.0",
+			expectedImpact: &impact{
+				UUID:        "GPU-edfee158",
+				BusID:       "0000:3b:00.0",
+				DeviceNode:  "/dev/nvidia1",
+				Unavailable: true,
+				RemovedAt:   &removedAt,
+				CDIDevices:  []string{"nvidia.com/gpu=1", "nvidia.com/gpu=all"},
+				Containers:  []string{"abc"},
+			},
+		},
+		{
+			description: "present device",
+			device:      "GPU-7ab1e2c4",
+			expectedImpact: &impact{
+				UUID:       "GPU-7ab1e2c4",
+				BusID:      "0000:5e:00.0",
+				DeviceNode: "/dev/nvidia0",
+			},
+		},
+		{
+			description:   "unknown device",
+			device:        "GPU-unknown",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			opts := options{
+				device:    tc.device,
+				stateFile: stateFile,
+				root:      root,
+				procRoot:  filepath.Join(root, "proc"),
+			}
+			result, err := c.getImpact(&opts)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedImpact, result)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	removedAt := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	result := impact{
+		UUID:        "GPU-edfee158",
+		BusID:       "0000:3b:00.0",
+		DeviceNode:  "/dev/nvidia1",
+		Unavailable: true,
+		RemovedAt:   &removedAt,
+		CDIDevices:  []string{"nvidia.com/gpu=1"},
+		Containers:  []string{"abc", "def"},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, render(buf, &result))
+	require.Equal(t, `GPU:          GPU-edfee158
+Bus ID:       0000:3b:00.0
+Device node:  /dev/nvidia1
+Removed at:   2023-03-01T12:00:00Z
+CDI devices:  nvidia.com/gpu=1
+Impacted containers:
+  abc
+  def
+`, buf.String())
+}
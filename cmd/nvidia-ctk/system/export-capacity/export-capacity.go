@@ -0,0 +1,197 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package exportcapacity
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/capacity"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+const (
+	defaultOutput   = "/etc/nvidia/capacity.json"
+	defaultInterval = 30 * time.Second
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	output    string
+	watch     bool
+	interval  time.Duration
+	specDirs  cli.StringSlice
+	nvidiaSMI string
+
+	cdiSpecDirs []string
+}
+
+// NewCommand constructs an export-capacity command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'export-capacity' command
+	c := cli.Command{
+		Name:  "export-capacity",
+		Usage: "Export a machine-readable inventory of the GPU capacity of the node for external schedulers",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "The path of the capacity file. If set to -, the capacity is written to stdout.",
+			Value:       defaultOutput,
+			Destination: &opts.output,
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "Continuously regenerate the capacity file until interrupted. The file is only rewritten if the capacity changes.",
+			Destination: &opts.watch,
+		},
+		&cli.DurationFlag{
+			Name:        "interval",
+			Usage:       "The interval at which the capacity is queried in watch mode",
+			Value:       defaultInterval,
+			Destination: &opts.interval,
+		},
+		&cli.StringSliceFlag{
+			Name:        "spec-dir",
+			Usage:       "The CDI spec dirs used to determine the CDI device names of the GPUs. If not specified, the spec dirs in the config are used.",
+			Destination: &opts.specDirs,
+		},
+		&cli.StringFlag{
+			Name:        "nvidia-smi",
+			Usage:       "The path to the nvidia-smi executable used to query the NVLinks of the GPUs",
+			Value:       "nvidia-smi",
+			Destination: &opts.nvidiaSMI,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	if opts.watch {
+		if opts.output == "-" {
+			return fmt.Errorf("writing to stdout is not supported in watch mode")
+		}
+		if opts.interval <= 0 {
+			return fmt.Errorf("invalid interval %v", opts.interval)
+		}
+	}
+
+	opts.cdiSpecDirs = opts.specDirs.Value()
+	if len(opts.cdiSpecDirs) > 0 {
+		return nil
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	opts.cdiSpecDirs = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	if len(opts.cdiSpecDirs) == 0 {
+		opts.cdiSpecDirs = cdi.DefaultSpecDirs
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	if !opts.watch {
+		return m.export(c, opts)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.export(c, opts); err != nil {
+			m.logger.Warningf("Failed to export capacity: %v", err)
+		}
+		select {
+		case <-sigs:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// export collects the capacity of the node and writes it to the output.
+func (m command) export(c *cli.Context, opts *options) error {
+	nodeCapacity, err := capacity.Collect(nvml.New(), opts.nvidiaSMI)
+	if err != nil {
+		return fmt.Errorf("failed to collect capacity: %v", err)
+	}
+
+	index, err := cdiindex.Build(opts.cdiSpecDirs)
+	if err != nil {
+		m.logger.Warningf("Failed to determine CDI devices: %v", err)
+	} else {
+		var names []string
+		for _, spec := range index.Specs {
+			names = append(names, spec.Devices...)
+		}
+		nodeCapacity.AddCDIDevices(names)
+	}
+
+	if opts.output == "-" {
+		contents, err := nodeCapacity.Marshal()
+		if err != nil {
+			return err
+		}
+		_, err = c.App.Writer.Write(contents)
+		return err
+	}
+
+	updated, err := nodeCapacity.Save(opts.output)
+	if err != nil {
+		return fmt.Errorf("failed to write capacity file: %v", err)
+	}
+	if updated {
+		m.logger.Infof("Wrote capacity of %d GPUs to %v", len(nodeCapacity.GPUs), opts.output)
+	} else {
+		m.logger.Debugf("Capacity is unchanged; not updating %v", opts.output)
+	}
+	return nil
+}
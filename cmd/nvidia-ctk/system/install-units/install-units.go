@@ -0,0 +1,186 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package installunits
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultUnitDir   = "/etc/systemd/system"
+	defaultCDIOutput = "/etc/cdi/nvidia.yaml"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	unitDir         string
+	cdiOutput       string
+	refreshInterval time.Duration
+	watch           bool
+	drain           bool
+	capacityOutput  string
+	watchDevices    bool
+	enable          bool
+	dryRun          bool
+}
+
+// NewCommand constructs an install-units command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	// Create the 'install-units' command
+	c := cli.Command{
+		Name:  "install-units",
+		Usage: "Install systemd units for boot-time CDI specification generation and /dev/char symlink creation",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "unit-dir",
+			Usage:       "The directory to which the systemd units are written",
+			Value:       defaultUnitDir,
+			Destination: &opts.unitDir,
+		},
+		&cli.StringFlag{
+			Name:        "cdi-output",
+			Usage:       "The path to which the CDI specification is written by the generated units",
+			Value:       defaultCDIOutput,
+			Destination: &opts.cdiOutput,
+		},
+		&cli.DurationFlag{
+			Name:        "refresh-interval",
+			Usage:       "If set, a timer is installed to regenerate the CDI specification at the specified interval (e.g. 1h)",
+			Destination: &opts.refreshInterval,
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "If set, the CDI specification is regenerated whenever the driver state changes (e.g. after MIG devices are reconfigured) by a long-running service instead of once at boot",
+			Destination: &opts.watch,
+		},
+		&cli.BoolFlag{
+			Name:        "drain",
+			Usage:       "If set, a unit is installed that removes the CDI specification when the system is shut down so that no devices are injected while the driver is unavailable",
+			Destination: &opts.drain,
+		},
+		&cli.StringFlag{
+			Name:        "capacity-output",
+			Usage:       "If set, a unit is installed that keeps the GPU capacity file at the specified path (e.g. /etc/nvidia/capacity.json) up to date",
+			Destination: &opts.capacityOutput,
+		},
+		&cli.BoolFlag{
+			Name:        "watch-devices",
+			Usage:       "If set, a unit is installed that records GPUs that are removed from the node so that these are no longer injected into containers",
+			Destination: &opts.watchDevices,
+		},
+		&cli.BoolFlag{
+			Name:        "enable",
+			Usage:       "Reload the systemd configuration and enable the installed units",
+			Value:       true,
+			Destination: &opts.enable,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Print the generated units instead of installing them",
+			Destination: &opts.dryRun,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	if opts.watch && opts.refreshInterval > 0 {
+		return fmt.Errorf("watch and refresh-interval are mutually exclusive")
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	unitConfig := newUnitConfig(cfg, opts)
+	unitConfig.NvidiaCTKPath = discover.FindNvidiaCTK(m.logger, cfg.NVIDIACTKConfig.Path)
+
+	units, err := unitConfig.render()
+	if err != nil {
+		return fmt.Errorf("failed to generate units: %v", err)
+	}
+
+	if opts.dryRun {
+		for _, u := range units {
+			fmt.Fprintf(c.App.Writer, "# %v\n%v\n", filepath.Join(opts.unitDir, u.name), u.contents)
+		}
+		return nil
+	}
+
+	var enable []string
+	for _, u := range units {
+		path := filepath.Join(opts.unitDir, u.name)
+		m.logger.Infof("Writing %v", path)
+		if err := os.WriteFile(path, []byte(u.contents), 0644); err != nil {
+			return fmt.Errorf("failed to write unit %v: %v", u.name, err)
+		}
+		enable = append(enable, u.name)
+	}
+
+	if !opts.enable {
+		return nil
+	}
+
+	if err := m.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return m.systemctl(append([]string{"enable"}, enable...)...)
+}
+
+func (m command) systemctl(args ...string) error {
+	m.logger.Infof("Running systemctl %v", args)
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run systemctl %v: %v: %s", args, err, output)
+	}
+	return nil
+}
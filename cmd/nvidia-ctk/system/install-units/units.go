@@ -0,0 +1,215 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package installunits
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+)
+
+const (
+	devCharUnitName    = "nvidia-dev-char-symlinks.service"
+	cdiRefreshUnitName = "nvidia-cdi-refresh.service"
+	cdiRefreshTimer    = "nvidia-cdi-refresh.timer"
+	cdiDrainUnitName   = "nvidia-cdi-drain.service"
+	capacityUnitName   = "nvidia-capacity-export.service"
+	deviceWatchUnit    = "nvidia-device-watch.service"
+)
+
+// unitConfig holds the values used to generate the systemd units.
+type unitConfig struct {
+	NvidiaCTKPath    string
+	DriverRoot       string
+	CDIOutput        string
+	ChecksumManifest string
+	RefreshInterval  string
+	Drain            bool
+	CapacityOutput   string
+	WatchDevices     bool
+	Watch            bool
+}
+
+// unit is a generated systemd unit.
+type unit struct {
+	name     string
+	contents string
+}
+
+var unitTemplates = []struct {
+	name     string
+	template string
+	include  func(*unitConfig) bool
+}{
+	{
+		name: devCharUnitName,
+		template: `[Unit]
+Description=Create /dev/char symlinks for NVIDIA device nodes
+After=systemd-modules-load.service
+
+[Service]
+Type=oneshot
+ExecStart={{ .NvidiaCTKPath }} system create-dev-char-symlinks --driver-root={{ .DriverRoot }}
+
+[Install]
+WantedBy=multi-user.target
+`,
+	},
+	{
+		name: cdiRefreshUnitName,
+		template: `[Unit]
+Description=Generate the NVIDIA CDI specification
+After=systemd-modules-load.service {{ .DevCharUnit }}
+
+[Service]
+{{- if .Watch }}
+Type=simple
+ExecStart={{ .NvidiaCTKPath }} cdi generate --driver-root={{ .DriverRoot }} --output={{ .CDIOutput }}{{ if .ChecksumManifest }} --checksum-manifest={{ .ChecksumManifest }}{{ end }} --watch
+Restart=on-failure
+{{- else }}
+Type=oneshot
+ExecStart={{ .NvidiaCTKPath }} cdi generate --driver-root={{ .DriverRoot }} --output={{ .CDIOutput }}{{ if .ChecksumManifest }} --checksum-manifest={{ .ChecksumManifest }}{{ end }}
+{{- end }}
+
+[Install]
+WantedBy=multi-user.target
+`,
+	},
+	{
+		name: cdiRefreshTimer,
+		template: `[Unit]
+Description=Periodically regenerate the NVIDIA CDI specification
+
+[Timer]
+OnUnitActiveSec={{ .RefreshInterval }}
+Unit={{ .RefreshUnit }}
+
+[Install]
+WantedBy=timers.target
+`,
+		include: func(c *unitConfig) bool { return c.RefreshInterval != "" },
+	},
+	{
+		name: cdiDrainUnitName,
+		template: `[Unit]
+Description=Remove the NVIDIA CDI specification on shutdown
+After={{ .RefreshUnit }}
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/true
+ExecStop=/bin/rm -f {{ .CDIOutput }}
+
+[Install]
+WantedBy=multi-user.target
+`,
+		include: func(c *unitConfig) bool { return c.Drain },
+	},
+	{
+		name: capacityUnitName,
+		template: `[Unit]
+Description=Export the GPU capacity of the node for external schedulers
+After={{ .RefreshUnit }}
+
+[Service]
+Type=simple
+ExecStart={{ .NvidiaCTKPath }} system export-capacity --watch --output={{ .CapacityOutput }}
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`,
+		include: func(c *unitConfig) bool { return c.CapacityOutput != "" },
+	},
+	{
+		name: deviceWatchUnit,
+		template: `[Unit]
+Description=Record NVIDIA GPUs that are removed from the node
+After={{ .RefreshUnit }}
+
+[Service]
+Type=simple
+ExecStart={{ .NvidiaCTKPath }} system watch-devices
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`,
+		include: func(c *unitConfig) bool { return c.WatchDevices },
+	},
+}
+
+// newUnitConfig creates the unit config from the toolkit config and command line options.
+func newUnitConfig(cfg *config.Config, opts *options) *unitConfig {
+	driverRoot := cfg.NVIDIAContainerCLIConfig.Root
+	if driverRoot == "" {
+		driverRoot = "/"
+	}
+
+	c := unitConfig{
+		NvidiaCTKPath:    cfg.NVIDIACTKConfig.Path,
+		DriverRoot:       driverRoot,
+		CDIOutput:        opts.cdiOutput,
+		ChecksumManifest: cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest,
+		Drain:            opts.drain,
+		CapacityOutput:   opts.capacityOutput,
+		WatchDevices:     opts.watchDevices,
+		Watch:            opts.watch,
+	}
+	if opts.refreshInterval > 0 {
+		c.RefreshInterval = opts.refreshInterval.String()
+	}
+	return &c
+}
+
+// render generates the systemd units for the config.
+func (c *unitConfig) render() ([]unit, error) {
+	values := struct {
+		*unitConfig
+		DevCharUnit string
+		RefreshUnit string
+	}{
+		unitConfig:  c,
+		DevCharUnit: devCharUnitName,
+		RefreshUnit: cdiRefreshUnitName,
+	}
+
+	var units []unit
+	for _, u := range unitTemplates {
+		if u.include != nil && !u.include(c) {
+			continue
+		}
+
+		t, err := template.New(u.name).Parse(u.template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for %v: %v", u.name, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, values); err != nil {
+			return nil, fmt.Errorf("failed to generate %v: %v", u.name, err)
+		}
+
+		units = append(units, unit{
+			name:     u.name,
+			contents: buf.String(),
+		})
+	}
+	return units, nil
+}
@@ -0,0 +1,124 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package installunits
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderUnits(t *testing.T) {
+	testCases := []struct {
+		description   string
+		opts          options
+		manifest      string
+		expectedUnits []string
+		expectedLines map[string][]string
+	}{
+		{
+			description: "default units",
+			opts: options{
+				cdiOutput: "/etc/cdi/nvidia.yaml",
+			},
+			expectedUnits: []string{devCharUnitName, cdiRefreshUnitName},
+			expectedLines: map[string][]string{
+				devCharUnitName:    {"ExecStart=/usr/bin/nvidia-ctk system create-dev-char-symlinks --driver-root=/run/nvidia/driver"},
+				cdiRefreshUnitName: {"ExecStart=/usr/bin/nvidia-ctk cdi generate --driver-root=/run/nvidia/driver --output=/etc/cdi/nvidia.yaml"},
+			},
+		},
+		{
+			description: "timer, drain, and checksum manifest",
+			opts: options{
+				cdiOutput:       "/var/run/cdi/nvidia.yaml",
+				refreshInterval: 30 * time.Minute,
+				drain:           true,
+			},
+			manifest:      "/etc/cdi/nvidia.checksums.json",
+			expectedUnits: []string{devCharUnitName, cdiRefreshUnitName, cdiRefreshTimer, cdiDrainUnitName},
+			expectedLines: map[string][]string{
+				cdiRefreshUnitName: {"ExecStart=/usr/bin/nvidia-ctk cdi generate --driver-root=/run/nvidia/driver --output=/var/run/cdi/nvidia.yaml --checksum-manifest=/etc/cdi/nvidia.checksums.json"},
+				cdiRefreshTimer:    {"OnUnitActiveSec=30m0s", "Unit=nvidia-cdi-refresh.service"},
+				cdiDrainUnitName:   {"ExecStop=/bin/rm -f /var/run/cdi/nvidia.yaml"},
+			},
+		},
+		{
+			description: "capacity export",
+			opts: options{
+				cdiOutput:      "/etc/cdi/nvidia.yaml",
+				capacityOutput: "/etc/nvidia/capacity.json",
+			},
+			expectedUnits: []string{devCharUnitName, cdiRefreshUnitName, capacityUnitName},
+			expectedLines: map[string][]string{
+				capacityUnitName: {"ExecStart=/usr/bin/nvidia-ctk system export-capacity --watch --output=/etc/nvidia/capacity.json"},
+			},
+		},
+		{
+			description: "watch",
+			opts: options{
+				cdiOutput: "/etc/cdi/nvidia.yaml",
+				watch:     true,
+			},
+			expectedUnits: []string{devCharUnitName, cdiRefreshUnitName},
+			expectedLines: map[string][]string{
+				cdiRefreshUnitName: {"Type=simple", "ExecStart=/usr/bin/nvidia-ctk cdi generate --driver-root=/run/nvidia/driver --output=/etc/cdi/nvidia.yaml --watch", "Restart=on-failure"},
+			},
+		},
+		{
+			description: "device watch",
+			opts: options{
+				cdiOutput:    "/etc/cdi/nvidia.yaml",
+				watchDevices: true,
+			},
+			expectedUnits: []string{devCharUnitName, cdiRefreshUnitName, deviceWatchUnit},
+			expectedLines: map[string][]string{
+				deviceWatchUnit: {"ExecStart=/usr/bin/nvidia-ctk system watch-devices", "After=nvidia-cdi-refresh.service"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerCLIConfig: config.ContainerCLIConfig{
+					Root: "/run/nvidia/driver",
+				},
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest = tc.manifest
+
+			c := newUnitConfig(cfg, &tc.opts)
+			c.NvidiaCTKPath = "/usr/bin/nvidia-ctk"
+
+			units, err := c.render()
+			require.NoError(t, err)
+
+			var names []string
+			for _, u := range units {
+				names = append(names, u.name)
+				lines := strings.Split(u.contents, "\n")
+				for _, expected := range tc.expectedLines[u.name] {
+					require.Contains(t, lines, expected)
+				}
+			}
+			require.Equal(t, tc.expectedUnits, names)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package resetgpu
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/cdi/generate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/procfs"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	nvlib "gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+const (
+	defaultCDIOutput    = "/etc/cdi/nvidia.yaml"
+	defaultGracePeriod  = 10 * time.Second
+	defaultEvictTimeout = 30 * time.Second
+
+	pciRescanPath = "/sys/bus/pci/rescan"
+)
+
+// defaultNeverEvict are the names of the processes that are never evicted. These are daemons that
+// manage or monitor the GPUs and are also run in containers (e.g. by the GPU Operator).
+var defaultNeverEvict = []string{
+	"nvidia-persistenced",
+	"nv-hostengine",
+	"dcgm-exporter",
+	"Xorg",
+}
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	device       string
+	evict        bool
+	gracePeriod  time.Duration
+	evictTimeout time.Duration
+	neverEvict   cli.StringSlice
+	cdiOutput    string
+	procRoot     string
+	devRoot      string
+}
+
+// NewCommand constructs a reset-gpu command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{
+		procRoot: "/proc",
+		devRoot:  "/dev",
+	}
+
+	// Create the 'reset-gpu' command
+	c := cli.Command{
+		Name:  "reset-gpu",
+		Usage: "Reset a GPU, optionally evicting the containers that are using it, and regenerate the CDI specification",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "device",
+			Usage:       "The UUID of the GPU to reset",
+			Required:    true,
+			Destination: &opts.device,
+		},
+		&cli.BoolFlag{
+			Name:        "evict",
+			Usage:       "Terminate the containerized processes (and the containers) that are using the GPU. If not set, the reset fails if the GPU is in use. Processes that are not running in a container are never terminated.",
+			Destination: &opts.evict,
+		},
+		&cli.DurationFlag{
+			Name:        "grace-period",
+			Usage:       "The time to wait after sending SIGTERM to the processes using the GPU before these are killed",
+			Value:       defaultGracePeriod,
+			Destination: &opts.gracePeriod,
+		},
+		&cli.DurationFlag{
+			Name:        "evict-timeout",
+			Usage:       "The time to wait for the processes to exit after these have been killed before the reset fails",
+			Value:       defaultEvictTimeout,
+			Destination: &opts.evictTimeout,
+		},
+		&cli.StringSliceFlag{
+			Name:        "never-evict",
+			Usage:       "The name of a process that is never terminated, even if running in a container. The reset fails if such a process is using the GPU.",
+			Value:       cli.NewStringSlice(defaultNeverEvict...),
+			Destination: &opts.neverEvict,
+		},
+		&cli.StringFlag{
+			Name:        "cdi-output",
+			Usage:       "The path of the CDI specification to regenerate after the reset. The specification is only regenerated if it exists. Set to an empty string to skip regeneration.",
+			Value:       defaultCDIOutput,
+			Destination: &opts.cdiOutput,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	minor, err := getMinorNumber(opts.device)
+	if err != nil {
+		return err
+	}
+	devicePath := fmt.Sprintf("%v/nvidia%d", opts.devRoot, minor)
+
+	processes, err := procfs.FindDeviceProcesses(opts.procRoot, devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to determine processes using %v: %v", devicePath, err)
+	}
+	for _, p := range processes {
+		m.logger.Infof("Process %v (%v, container %v) is using %v", p.PID, p.Name, p.ContainerOrHost(), opts.device)
+	}
+
+	evictable, retained := selectEvictable(processes, opts.neverEvict.Value())
+	if len(retained) > 0 {
+		var descriptions []string
+		for _, p := range retained {
+			descriptions = append(descriptions, fmt.Sprintf("%v (%v, container %v)", p.PID, p.Name, p.ContainerOrHost()))
+		}
+		return fmt.Errorf("GPU %v is in use by processes that are not evicted: %v; stop these before resetting the GPU", opts.device, strings.Join(descriptions, ", "))
+	}
+	if len(evictable) > 0 {
+		if !opts.evict {
+			return fmt.Errorf("GPU %v is in use by %d processes; specify --evict to terminate these", opts.device, len(evictable))
+		}
+		if err := m.evict(evictable, opts.gracePeriod, opts.evictTimeout); err != nil {
+			return err
+		}
+	}
+
+	m.logger.Infof("Resetting GPU %v", opts.device)
+	if err := resetGPU(opts.device); err != nil {
+		return fmt.Errorf("failed to reset GPU %v: %v", opts.device, err)
+	}
+
+	return m.regenerateCDISpec(opts.cdiOutput)
+}
+
+// selectEvictable splits the specified processes into the processes that may be evicted and those
+// that must not be. Only processes that are running in a container and are not included in the
+// specified list of process names are evicted. Host processes (e.g. nvidia-persistenced or Xorg)
+// are never evicted.
+func selectEvictable(processes []procfs.Process, neverEvict []string) ([]procfs.Process, []procfs.Process) {
+	excluded := make(map[string]bool)
+	for _, name := range neverEvict {
+		excluded[name] = true
+	}
+
+	var evictable, retained []procfs.Process
+	for _, p := range processes {
+		if p.ContainerID == "" || excluded[p.Name] {
+			retained = append(retained, p)
+			continue
+		}
+		evictable = append(evictable, p)
+	}
+	return evictable, retained
+}
+
+// getMinorNumber returns the minor number of the device node for the GPU with the specified UUID.
+func getMinorNumber(uuid string) (int, error) {
+	nvmllib := nvlib.New()
+	if r := nvmllib.Init(); r != nvlib.SUCCESS {
+		return 0, fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvmllib.Shutdown()
+
+	device, r := nvmllib.DeviceGetHandleByUUID(uuid)
+	if r != nvlib.SUCCESS {
+		return 0, fmt.Errorf("failed to get device %v: %v", uuid, r)
+	}
+	minor, r := device.GetMinorNumber()
+	if r != nvlib.SUCCESS {
+		return 0, fmt.Errorf("failed to get minor number for device %v: %v", uuid, r)
+	}
+	return minor, nil
+}
+
+// resetGPU resets the GPU with the specified UUID using NVML. The GPU is drained and removed from
+// the kernel's view before it is rediscovered, which reinitializes the GPU. The go-nvlib wrapper
+// does not include the required functions, meaning that these are called using go-nvml directly.
+func resetGPU(uuid string) error {
+	if r := nvml.Init(); r != nvml.SUCCESS {
+		return fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(r))
+	}
+	defer nvml.Shutdown()
+
+	device, r := nvml.DeviceGetHandleByUUID(uuid)
+	if r != nvml.SUCCESS {
+		return fmt.Errorf("failed to get device: %v", nvml.ErrorString(r))
+	}
+	pciInfo, r := device.GetPciInfo()
+	if r != nvml.SUCCESS {
+		return fmt.Errorf("failed to get PCI info: %v", nvml.ErrorString(r))
+	}
+
+	if r := nvml.DeviceModifyDrainState(&pciInfo, nvml.FEATURE_ENABLED); r != nvml.SUCCESS {
+		return fmt.Errorf("failed to drain GPU: %v", nvml.ErrorString(r))
+	}
+	if r := nvml.DeviceRemoveGpu_v2(&pciInfo, nvml.DETACH_GPU_REMOVE, nvml.PCIE_LINK_KEEP); r != nvml.SUCCESS {
+		// The drain state is reverted so that the GPU remains usable.
+		nvml.DeviceModifyDrainState(&pciInfo, nvml.FEATURE_DISABLED)
+		return fmt.Errorf("failed to remove GPU: %v", nvml.ErrorString(r))
+	}
+	// The GPU is rediscovered by rescanning the PCI bus. This is equivalent to nvmlDeviceDiscoverGpus,
+	// but the go-nvml binding for this function does not allow the PCI info of the GPU to be specified.
+	if err := os.WriteFile(pciRescanPath, []byte("1"), 0200); err != nil {
+		return fmt.Errorf("failed to rediscover GPU: %v", err)
+	}
+	return nil
+}
+
+// evict sends SIGTERM to the specified processes and sends SIGKILL to any processes that are still
+// running once the grace period has elapsed. An error is returned if the processes are still
+// running after the specified timeout, since the GPU cannot be reset while it is in use.
+func (m command) evict(processes []procfs.Process, gracePeriod time.Duration, timeout time.Duration) error {
+	for _, p := range processes {
+		m.logger.Infof("Sending SIGTERM to process %v", p.PID)
+		if err := syscall.Kill(p.PID, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("failed to terminate process %v: %v", p.PID, err)
+		}
+	}
+
+	deadline := time.Now().Add(gracePeriod)
+	for {
+		running := procfs.Running(processes)
+		if len(running) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			for _, p := range running {
+				m.logger.Warningf("Sending SIGKILL to process %v", p.PID)
+				if err := syscall.Kill(p.PID, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+					return fmt.Errorf("failed to kill process %v: %v", p.PID, err)
+				}
+			}
+			return waitForExit(running, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// waitForExit waits for the specified processes to exit.
+func waitForExit(processes []procfs.Process, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		running := procfs.Running(processes)
+		if len(running) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			var pids []string
+			for _, p := range running {
+				pids = append(pids, fmt.Sprintf("%v", p.PID))
+			}
+			return fmt.Errorf("processes %v did not exit within %v", strings.Join(pids, ", "), timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// regenerateCDISpec regenerates the CDI specification at the specified path if it exists. This
+// ensures that the device nodes and UUIDs in the specification match the GPU after the reset.
+// The specification is regenerated using the arguments recorded when it was generated.
+func (m command) regenerateCDISpec(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		m.logger.Debugf("Skipping regeneration of CDI specification %v: %v", path, err)
+		return nil
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	nvidiaCTKPath := discover.FindNvidiaCTK(m.logger, cfg.NVIDIACTKConfig.Path)
+
+	args, err := generate.LoadArgs(path)
+	if err != nil {
+		m.logger.Warningf("Regenerating CDI specification %v with the default options: failed to load recorded arguments: %v", path, err)
+	}
+	args = append([]string{"cdi", "generate"}, args...)
+	args = append(args, "--output", path)
+
+	m.logger.Infof("Regenerating CDI specification %v", path)
+	output, err := exec.Command(nvidiaCTKPath, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to regenerate CDI specification: %v: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package resetgpu
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/procfs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectEvictable(t *testing.T) {
+	containerID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	processes := []procfs.Process{
+		{PID: 100, Name: "python", ContainerID: containerID},
+		{PID: 200, Name: "nvidia-persistenced"},
+		{PID: 300, Name: "dcgm-exporter", ContainerID: containerID},
+		{PID: 400, Name: "python"},
+	}
+
+	evictable, retained := selectEvictable(processes, defaultNeverEvict)
+	require.Equal(t, []procfs.Process{processes[0]}, evictable)
+	require.Equal(t, []procfs.Process{processes[1], processes[2], processes[3]}, retained)
+}
@@ -0,0 +1,129 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package stagedriver
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover/csv"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultOutput = "/run/nvidia/driver-stage"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type config struct {
+	output        string
+	driverRoot    string
+	mountSpecPath string
+}
+
+// NewCommand constructs a stage-driver command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	cfg := config{}
+
+	// Create the 'stage-driver' command
+	c := cli.Command{
+		Name:  "stage-driver",
+		Usage: "Stage the driver files injected in csv mode in a single directory tree for use by the NVIDIA Container Runtime",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &cfg)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "The path at which the driver files are staged. Any existing staged files at this path are replaced.",
+			Value:       defaultOutput,
+			Destination: &cfg.output,
+		},
+		&cli.StringFlag{
+			Name:        "driver-root",
+			Usage:       "The path to the driver root from which the driver files are staged.",
+			Value:       "/",
+			Destination: &cfg.driverRoot,
+			EnvVars:     []string{"DRIVER_ROOT"},
+		},
+		&cli.StringFlag{
+			Name:        "csv.mount-spec-path",
+			Usage:       "The path to the folder containing the CSV mount specs from which the driver files are discovered.",
+			Value:       csv.DefaultMountSpecPath,
+			Destination: &cfg.mountSpecPath,
+		},
+	}
+
+	return &c
+}
+
+// run stages the driver files that are injected by the NVIDIA Container Runtime in csv mode, since the staged
+// driver root is only used in this mode. The files are discovered from the CSV mount specs instead of using
+// NVML, which is not available on the systems where csv mode is used. All CSV files are considered so that
+// the files requested by containers with NVIDIA_REQUIRE_JETPACK=csv-mounts=all are also staged.
+func (m command) run(c *cli.Context, cfg *config) error {
+	version := discover.KernelModuleVersion()
+	if version == "" {
+		m.logger.Warnf("Failed to determine the version of the loaded kernel module; the staged driver root will not be checked against the loaded driver")
+	}
+
+	csvFiles, err := csv.GetFileList(cfg.mountSpecPath)
+	if err != nil {
+		return fmt.Errorf("failed to get list of CSV files: %v", err)
+	}
+
+	driverFiles, err := discover.NewFromCSVFiles(m.logger, csvFiles, cfg.driverRoot)
+	if err != nil {
+		return fmt.Errorf("failed to create discoverer for CSV files: %v", err)
+	}
+
+	// The graphics files are located using the ldcache of the driver root. If this is not available,
+	// only the files from the CSV mount specs are staged.
+	var graphicsMounts discover.Discover = discover.None{}
+	if d, err := discover.NewGraphicsMountsDiscoverer(m.logger, cfg.driverRoot); err != nil {
+		m.logger.Warnf("Not staging graphics files: failed to create discoverer for graphics mounts: %v", err)
+	} else {
+		graphicsMounts = d
+	}
+
+	mounts, err := discover.Merge(driverFiles, graphicsMounts).Mounts()
+	if err != nil {
+		return fmt.Errorf("failed to discover driver files: %v", err)
+	}
+
+	manifest, err := discover.Stage(m.logger, mounts, version, cfg.output)
+	if err != nil {
+		return fmt.Errorf("failed to stage driver files: %v", err)
+	}
+
+	m.logger.Infof("Staged %d files for driver version %v at %v", len(manifest.Files), version, cfg.output)
+	return nil
+}
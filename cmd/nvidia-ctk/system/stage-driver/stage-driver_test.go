@@ -0,0 +1,64 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+
+package stagedriver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestStageDriverFromCSVFiles(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	driverRoot := t.TempDir()
+	libcuda := filepath.Join(driverRoot, "usr/lib/libcuda.so.1.1")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	mountSpecPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(mountSpecPath, "drivers.csv"), []byte("lib, /usr/lib/libcuda.so.1.1\n"), 0644))
+
+	output := filepath.Join(t.TempDir(), "driver-stage")
+
+	app := cli.App{
+		Commands: []*cli.Command{NewCommand(logger)},
+	}
+	err := app.Run([]string{
+		"nvidia-ctk", "stage-driver",
+		"--driver-root", driverRoot,
+		"--csv.mount-spec-path", mountSpecPath,
+		"--output", output,
+	})
+	require.NoError(t, err)
+
+	manifest, err := discover.LoadStagedManifest(output)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 1)
+	require.Equal(t, "/usr/lib/libcuda.so.1.1", manifest.Files[0].Path)
+	require.Equal(t, libcuda, manifest.Files[0].HostPath)
+
+	contents, err := os.ReadFile(filepath.Join(output, "root", "usr/lib/libcuda.so.1.1"))
+	require.NoError(t, err)
+	require.Equal(t, "libcuda", string(contents))
+}
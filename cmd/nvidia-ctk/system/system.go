@@ -18,6 +18,11 @@ package system
 
 import (
 	devchar "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/create-dev-char-symlinks"
+	exportcapacity "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/export-capacity"
+	installunits "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/install-units"
+	resetgpu "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/reset-gpu"
+	stagedriver "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/stage-driver"
+	watchdevices "github.com/NVIDIA/nvidia-container-toolkit/cmd/nvidia-ctk/system/watch-devices"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -43,6 +48,11 @@ func (m command) build() *cli.Command {
 
 	system.Subcommands = []*cli.Command{
 		devchar.NewCommand(m.logger),
+		stagedriver.NewCommand(m.logger),
+		installunits.NewCommand(m.logger),
+		resetgpu.NewCommand(m.logger),
+		exportcapacity.NewCommand(m.logger),
+		watchdevices.NewCommand(m.logger),
 	}
 
 	return &system
@@ -0,0 +1,104 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package watchdevices
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sourceUdev receives the events after these were processed by udev.
+	sourceUdev = "udev"
+	// sourceKernel receives the events directly from the kernel. This does not require udev to be running.
+	sourceKernel = "kernel"
+
+	// The netlink multicast groups for kernel and udev events.
+	kernelEventGroup = 1
+	udevEventGroup   = 2
+
+	// udevHeaderPrefix is the prefix of the header of the events sent by udev.
+	udevHeaderPrefix = "libudev\x00"
+	// udevHeaderSize is the size of the header fields up to and including properties_len.
+	udevHeaderSize = 24
+)
+
+// uevent holds the properties (e.g. ACTION, SUBSYSTEM, and PCI_SLOT_NAME) of a device event.
+type uevent map[string]string
+
+// newUeventSocket opens a netlink socket that receives the device events from the specified source.
+func newUeventSocket(source string) (int, error) {
+	group := uint32(udevEventGroup)
+	if source == sourceKernel {
+		group = kernelEventGroup
+	}
+
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create netlink socket: %v", err)
+	}
+	addr := unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: group,
+	}
+	if err := unix.Bind(fd, &addr); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to bind netlink socket: %v", err)
+	}
+	return fd, nil
+}
+
+// parseUevent parses a device event as sent by the kernel (action@devpath followed by KEY=VALUE
+// properties) or by udev (a libudev header followed by KEY=VALUE properties). The properties are
+// separated by NUL bytes. The fields of the udev header other than the prefix and magic are in
+// host byte order, which is little endian for all supported architectures.
+func parseUevent(msg []byte) (uevent, error) {
+	properties := msg
+	if bytes.HasPrefix(msg, []byte(udevHeaderPrefix)) {
+		if len(msg) < udevHeaderSize {
+			return nil, fmt.Errorf("truncated udev event")
+		}
+		offset := binary.LittleEndian.Uint32(msg[16:20])
+		length := binary.LittleEndian.Uint32(msg[20:24])
+		if uint64(offset)+uint64(length) > uint64(len(msg)) {
+			return nil, fmt.Errorf("invalid udev event properties")
+		}
+		properties = msg[offset : offset+length]
+	}
+
+	event := make(uevent)
+	for _, field := range bytes.Split(properties, []byte{0}) {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		event[key] = value
+	}
+	if event["ACTION"] == "" {
+		return nil, fmt.Errorf("event has no action")
+	}
+	return event, nil
+}
+
+// isNVIDIAGPU checks whether the event is for an NVIDIA PCI device.
+func (e uevent) isNVIDIAGPU() bool {
+	return e["SUBSYSTEM"] == "pci" && strings.HasPrefix(strings.ToUpper(e["PCI_ID"]), "10DE:")
+}
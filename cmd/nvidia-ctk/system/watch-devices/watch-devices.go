@@ -0,0 +1,268 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package watchdevices
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sys/unix"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type options struct {
+	stateFile   string
+	source      string
+	cdiSpecDirs []string
+	root        string
+	procRoot    string
+}
+
+// NewCommand constructs a watch-devices command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	opts := options{
+		root:     "/",
+		procRoot: "/proc",
+	}
+
+	// Create the 'watch-devices' command
+	c := cli.Command{
+		Name:  "watch-devices",
+		Usage: "Watch for GPUs being removed from the node and record these (and the impacted CDI devices and containers) in the device state file",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(c, &opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(c, &opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "state-file",
+			Usage:       "The device state file. If this is not specified, the state file configured in the config.toml file is used.",
+			Destination: &opts.stateFile,
+		},
+		&cli.StringFlag{
+			Name:        "source",
+			Usage:       "The source of the device events. One of [udev | kernel]",
+			Value:       sourceUdev,
+			Destination: &opts.source,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(c *cli.Context, opts *options) error {
+	switch opts.source {
+	case sourceUdev, sourceKernel:
+	default:
+		return fmt.Errorf("invalid source: %v", opts.source)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	if !c.IsSet("state-file") {
+		opts.stateFile = cfg.NVIDIAContainerRuntimeConfig.DeviceState.StateFile
+	}
+	opts.cdiSpecDirs = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	if len(opts.cdiSpecDirs) == 0 {
+		opts.cdiSpecDirs = cdi.DefaultSpecDirs
+	}
+	return nil
+}
+
+func (m command) run(c *cli.Context, opts *options) error {
+	fd, err := newUeventSocket(opts.source)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	w := watcher{
+		logger:      m.logger,
+		store:       devicestate.NewStore(opts.stateFile),
+		root:        opts.root,
+		procRoot:    opts.procRoot,
+		cdiSpecDirs: opts.cdiSpecDirs,
+		now:         time.Now,
+	}
+	if err := w.refreshGPUs(); err != nil {
+		return err
+	}
+	m.logger.Infof("Watching %v events for %d GPUs", opts.source, len(w.gpus))
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err == unix.EINTR || err == unix.ENOBUFS {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive device event: %v", err)
+		}
+		event, err := parseUevent(buf[:n])
+		if err != nil {
+			m.logger.Debugf("Ignoring device event: %v", err)
+			continue
+		}
+		if err := w.handle(event); err != nil {
+			m.logger.Warningf("Failed to handle %v event for %v: %v", event["ACTION"], event["PCI_SLOT_NAME"], err)
+		}
+	}
+}
+
+// watcher records the GPUs that are removed from the node in the device state.
+type watcher struct {
+	logger      *logrus.Logger
+	store       *devicestate.Store
+	root        string
+	procRoot    string
+	cdiSpecDirs []string
+	now         func() time.Time
+	// gpus are the GPUs known to the driver indexed by bus ID. Since the information files of a GPU
+	// may no longer be available once it is removed, these are read when the watcher starts and
+	// whenever the driver is bound to a GPU.
+	gpus map[string]devicestate.GPU
+}
+
+// handle handles a device event. Only remove events and events for the nvidia driver being bound to
+// a device are handled for NVIDIA GPUs.
+func (w *watcher) handle(event uevent) error {
+	if !event.isNVIDIAGPU() {
+		return nil
+	}
+	busID := info.NormalizeBusID(event["PCI_SLOT_NAME"])
+	if busID == "" {
+		return nil
+	}
+
+	switch event["ACTION"] {
+	case "remove":
+		return w.handleRemove(busID)
+	case "bind":
+		if event["DRIVER"] != "nvidia" {
+			return nil
+		}
+		return w.handleBind(busID)
+	}
+	return nil
+}
+
+// handleRemove marks the GPU with the specified bus ID as unavailable.
+func (w *watcher) handleRemove(busID string) error {
+	device := devicestate.Device{
+		BusID:     busID,
+		RemovedAt: w.now().UTC(),
+	}
+	if gpu, ok := w.gpus[busID]; ok {
+		device.UUID = gpu.UUID
+		device.DeviceNode = gpu.DeviceNode()
+	}
+	if device.DeviceNode != "" {
+		device.CDIDevices = w.getCDIDevices(device.DeviceNode)
+		device.Containers = w.getContainers(device.DeviceNode)
+	}
+
+	w.logger.WithField(events.Field, events.GPURemoved).Warningf("GPU %v (%v) was removed; impacted CDI devices: %v; impacted containers: %v", device.UUID, busID, device.CDIDevices, device.Containers)
+	return w.store.Update(func(s *devicestate.State) error {
+		s.MarkUnavailable(device)
+		return nil
+	})
+}
+
+// handleBind marks the GPU with the specified bus ID as available after the driver was bound to it.
+func (w *watcher) handleBind(busID string) error {
+	if err := w.refreshGPUs(); err != nil {
+		w.logger.Warningf("Failed to refresh GPUs: %v", err)
+	}
+	return w.store.Update(func(s *devicestate.State) error {
+		if s.MarkAvailable(busID) {
+			w.logger.Infof("GPU %v is available", busID)
+		}
+		return nil
+	})
+}
+
+// refreshGPUs reads the GPUs known to the driver.
+func (w *watcher) refreshGPUs() error {
+	gpus, err := devicestate.GetGPUs(w.root)
+	if err != nil {
+		return err
+	}
+	w.gpus = gpus
+	return nil
+}
+
+// getCDIDevices returns the CDI devices in the spec dirs that include the specified device node.
+func (w *watcher) getCDIDevices(deviceNode string) []string {
+	cache, err := cdi.NewCache(cdi.WithSpecDirs(w.cdiSpecDirs...), cdi.WithAutoRefresh(false))
+	if err != nil {
+		w.logger.Debugf("The following error was triggered when loading the CDI specifications: %v", err)
+	}
+	if cache == nil {
+		return nil
+	}
+
+	var devices []string
+	for _, name := range cache.ListDevices() {
+		d := cache.GetDevice(name)
+		if d == nil {
+			continue
+		}
+		for _, n := range d.ContainerEdits.DeviceNodes {
+			if n.Path == deviceNode || n.HostPath == deviceNode {
+				devices = append(devices, name)
+				break
+			}
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// getContainers returns the IDs of the containers with processes that have the specified device node open.
+func (w *watcher) getContainers(deviceNode string) []string {
+	containers, err := devicestate.FindContainers(w.procRoot, deviceNode)
+	if err != nil {
+		w.logger.Debugf("%v", err)
+		return nil
+	}
+	return containers
+}
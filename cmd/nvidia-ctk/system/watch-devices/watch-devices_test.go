@@ -0,0 +1,164 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package watchdevices
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUevent(t *testing.T) {
+	properties := []byte("ACTION=remove\x00SUBSYSTEM=pci\x00PCI_ID=10DE:1DB4\x00PCI_SLOT_NAME=0000:3b:00.0\x00")
+	udevEvent := make([]byte, 40)
+	copy(udevEvent, udevHeaderPrefix)
+	binary.LittleEndian.PutUint32(udevEvent[16:20], 40)
+	binary.LittleEndian.PutUint32(udevEvent[20:24], uint32(len(properties)))
+	udevEvent = append(udevEvent, properties...)
+
+	expected := uevent{
+		"ACTION":        "remove",
+		"SUBSYSTEM":     "pci",
+		"PCI_ID":        "10DE:1DB4",
+		"PCI_SLOT_NAME": "0000:3b:00.0",
+	}
+
+	testCases := []struct {
+		description   string
+		msg           []byte
+		expectedEvent uevent
+		expectedError bool
+	}{
+		{
+			description:   "kernel event",
+			msg:           append([]byte("remove@/devices/pci0000:3a/0000:3a:00.0/0000:3b:00.0\x00"), properties...),
+			expectedEvent: expected,
+		},
+		{
+			description:   "udev event",
+			msg:           udevEvent,
+			expectedEvent: expected,
+		},
+		{
+			description:   "truncated udev event",
+			msg:           udevEvent[:20],
+			expectedError: true,
+		},
+		{
+			description:   "invalid udev properties",
+			msg:           udevEvent[:48],
+			expectedError: true,
+		},
+		{
+			description:   "event without action",
+			msg:           []byte("SUBSYSTEM=pci\x00"),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			event, err := parseUevent(tc.msg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEvent, event)
+		})
+	}
+}
+
+func TestWatcherHandle(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	gpuDir := filepath.Join(root, "proc/driver/nvidia/gpus/0000:3b:00.0")
+	require.NoError(t, os.MkdirAll(gpuDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(gpuDir, "information"), []byte("GPU UUID:        GPU-edfee158\nBus Location:    0000:3b:00.0\nDevice Minor:    1\n"), 0644))
+
+	specDir := t.TempDir()
+	spec := `cdiVersion: "0.5.0"
+kind: nvidia.com/gpu
+devices:
+- name: "0"
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+- name: "1"
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia1
+- name: all
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+    - path: /dev/nvidia1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(spec), 0644))
+
+	removedAt := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := devicestate.NewStore(filepath.Join(t.TempDir(), "device-state.json"))
+	w := watcher{
+		logger:      logger,
+		store:       store,
+		root:        root,
+		procRoot:    filepath.Join(root, "proc"),
+		cdiSpecDirs: []string{specDir},
+		now:         func() time.Time { return removedAt },
+	}
+	require.NoError(t, w.refreshGPUs())
+
+	// Events for other devices are ignored.
+	require.NoError(t, w.handle(uevent{"ACTION": "remove", "SUBSYSTEM": "pci", "PCI_ID": "8086:2030", "PCI_SLOT_NAME": "0000:3a:00.0"}))
+	state, err := store.Load()
+	require.NoError(t, err)
+	require.Empty(t, state.Unavailable)
+
+	require.NoError(t, w.handle(uevent{"ACTION": "remove", "SUBSYSTEM": "pci", "PCI_ID": "10DE:1DB4", "PCI_SLOT_NAME": "0000:3B:00.0"}))
+	state, err = store.Load()
+	require.NoError(t, err)
+	require.Equal(t,
+		[]devicestate.Device{
+			{
+				UUID:       "GPU-edfee158",
+				BusID:      "0000:3b:00.0",
+				DeviceNode: "/dev/nvidia1",
+				CDIDevices: []string{"nvidia.com/gpu=1", "nvidia.com/gpu=all"},
+				RemovedAt:  removedAt,
+			},
+		},
+		state.Unavailable,
+	)
+
+	// Binding a driver other than the nvidia driver does not change the state.
+	require.NoError(t, w.handle(uevent{"ACTION": "bind", "SUBSYSTEM": "pci", "PCI_ID": "10DE:1DB4", "PCI_SLOT_NAME": "0000:3b:00.0", "DRIVER": "vfio-pci"}))
+	state, err = store.Load()
+	require.NoError(t, err)
+	require.Len(t, state.Unavailable, 1)
+
+	require.NoError(t, w.handle(uevent{"ACTION": "bind", "SUBSYSTEM": "pci", "PCI_ID": "10DE:1DB4", "PCI_SLOT_NAME": "0000:3b:00.0", "DRIVER": "nvidia"}))
+	state, err = store.Load()
+	require.NoError(t, err)
+	require.Empty(t, state.Unavailable)
+}
@@ -0,0 +1,101 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package fakedriverroot
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/driverroot"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+type command struct {
+	logger *logrus.Logger
+}
+
+type config struct {
+	root          string
+	driverVersion string
+	gpus          int
+	mig           bool
+}
+
+// NewCommand constructs a create-fake-driver-root command with the specified logger
+func NewCommand(logger *logrus.Logger) *cli.Command {
+	c := command{
+		logger: logger,
+	}
+	return c.build()
+}
+
+// build
+func (m command) build() *cli.Command {
+	cfg := config{}
+
+	// Create the 'create-fake-driver-root' command
+	c := cli.Command{
+		Name:  "create-fake-driver-root",
+		Usage: "Create a fake driver root with device nodes, proc files, and driver libraries for testing without GPUs",
+		Action: func(c *cli.Context) error {
+			return m.run(c, &cfg)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "root",
+			Usage:       "The path at which the fake driver root is created. Existing files at this path are overwritten.",
+			Required:    true,
+			Destination: &cfg.root,
+		},
+		&cli.StringFlag{
+			Name:        "driver-version",
+			Usage:       "The driver version of the fake driver. This is used for the versioned library names and the driver version file.",
+			Value:       driverroot.DefaultDriverVersion,
+			Destination: &cfg.driverVersion,
+		},
+		&cli.IntFlag{
+			Name:        "gpus",
+			Usage:       "The number of GPUs for which device nodes and information files are created.",
+			Value:       1,
+			Destination: &cfg.gpus,
+		},
+		&cli.BoolFlag{
+			Name:        "mig",
+			Usage:       "Create the MIG capability device nodes and proc files as for GPUs with MIG mode enabled.",
+			Destination: &cfg.mig,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(c *cli.Context, cfg *config) error {
+	err := driverroot.Create(
+		cfg.root,
+		driverroot.WithDriverVersion(cfg.driverVersion),
+		driverroot.WithGPUs(cfg.gpus),
+		driverroot.WithMIG(cfg.mig),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create fake driver root: %v", err)
+	}
+
+	m.logger.Infof("Created fake driver root for driver version %v with %d GPUs at %v", cfg.driverVersion, cfg.gpus, cfg.root)
+	return nil
+}
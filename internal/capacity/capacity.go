@@ -0,0 +1,175 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package capacity provides a normalized, machine-readable inventory of the GPU capacity of a
+// node that can be consumed by schedulers other than Kubernetes (e.g. Nomad).
+package capacity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+)
+
+// SchemaVersion is the version of the capacity file format. It is incremented if fields are
+// removed or their meaning changes.
+const SchemaVersion = "v1"
+
+// Capacity is the GPU capacity of a node.
+type Capacity struct {
+	SchemaVersion string `json:"schemaVersion"`
+	Hostname      string `json:"hostname,omitempty"`
+	DriverVersion string `json:"driverVersion"`
+	CUDAVersion   string `json:"cudaVersion,omitempty"`
+	GPUs          []GPU  `json:"gpus"`
+}
+
+// GPU is a full GPU of the node.
+type GPU struct {
+	Index       int    `json:"index"`
+	UUID        string `json:"uuid"`
+	Name        string `json:"name,omitempty"`
+	PCIBusID    string `json:"pciBusId,omitempty"`
+	MemoryBytes uint64 `json:"memoryBytes"`
+	// MIGEnabled indicates whether MIG mode is enabled. The GPU can only be allocated as a whole if
+	// MIG mode is disabled.
+	MIGEnabled bool        `json:"migEnabled"`
+	MIGDevices []MIGDevice `json:"migDevices,omitempty"`
+	// Links are the active NVLinks of the GPU.
+	Links []Link `json:"links,omitempty"`
+	// CDIDevices are the fully-qualified names of the CDI devices that refer to the GPU.
+	CDIDevices []string `json:"cdiDevices,omitempty"`
+}
+
+// MIGDevice is a MIG device (i.e. a compute instance of a GPU instance) of a GPU.
+type MIGDevice struct {
+	Index             string   `json:"index"`
+	UUID              string   `json:"uuid"`
+	Profile           string   `json:"profile,omitempty"`
+	GPUInstanceID     int      `json:"gpuInstanceId"`
+	ComputeInstanceID int      `json:"computeInstanceId"`
+	MemoryBytes       uint64   `json:"memoryBytes"`
+	CDIDevices        []string `json:"cdiDevices,omitempty"`
+}
+
+// Link is an active NVLink of a GPU.
+type Link struct {
+	Index          int    `json:"index"`
+	RemotePCIBusID string `json:"remotePciBusId"`
+	// RemoteUUID is the UUID of the remote GPU if the link connects two GPUs of the node.
+	RemoteUUID string `json:"remoteUuid,omitempty"`
+}
+
+// AddCDIDevices associates the specified fully-qualified CDI device names with the GPUs and MIG
+// devices that they refer to. A device refers to a GPU or MIG device if its name is generated by
+// one of the supported naming strategies (e.g. 0, gpu0, or the UUID for a GPU and 0:1, mig0:1, or
+// the UUID for a MIG device). Other names (e.g. all) are ignored.
+func (c *Capacity) AddCDIDevices(names []string) {
+	for i := range c.GPUs {
+		gpu := &c.GPUs[i]
+		index := strconv.Itoa(gpu.Index)
+		gpu.CDIDevices = matchCDIDevices(names, index, "gpu"+index, gpu.UUID)
+		for j := range gpu.MIGDevices {
+			mig := &gpu.MIGDevices[j]
+			mig.CDIDevices = matchCDIDevices(names, mig.Index, "mig"+mig.Index, mig.UUID)
+		}
+	}
+}
+
+func matchCDIDevices(names []string, ids ...string) []string {
+	var matches []string
+	for _, name := range names {
+		_, _, device, err := cdi.ParseQualifiedName(name)
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			if id != "" && device == id {
+				matches = append(matches, name)
+				break
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// resolveLinks sets the UUIDs of the remote GPUs of the NVLinks that connect GPUs of the node.
+func (c *Capacity) resolveLinks() {
+	uuids := make(map[string]string)
+	for _, gpu := range c.GPUs {
+		uuids[gpu.PCIBusID] = gpu.UUID
+	}
+	for i := range c.GPUs {
+		for j := range c.GPUs[i].Links {
+			link := &c.GPUs[i].Links[j]
+			link.RemoteUUID = uuids[link.RemotePCIBusID]
+		}
+	}
+}
+
+// Marshal returns the JSON representation of the capacity.
+func (c *Capacity) Marshal() ([]byte, error) {
+	contents, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal capacity: %v", err)
+	}
+	return append(contents, '\n'), nil
+}
+
+// Save writes the capacity to the specified path if it differs from the contents of the file.
+// The capacity is written to a temporary file that is renamed into place so that readers never
+// observe a partially-written file. The return value indicates whether the file was updated.
+func (c *Capacity) Save(path string) (bool, error) {
+	contents, err := c.Marshal()
+	if err != nil {
+		return false, err
+	}
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, contents) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".capacity-*.json")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("failed to write capacity: %v", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("failed to set permissions: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, fmt.Errorf("failed to close temporary file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,139 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddCDIDevices(t *testing.T) {
+	c := Capacity{
+		GPUs: []GPU{
+			{
+				Index: 0,
+				UUID:  "GPU-0",
+			},
+			{
+				Index:      1,
+				UUID:       "GPU-1",
+				MIGEnabled: true,
+				MIGDevices: []MIGDevice{
+					{Index: "1:0", UUID: "MIG-0"},
+				},
+			},
+		},
+	}
+
+	c.AddCDIDevices([]string{
+		"nvidia.com/gpu=all",
+		"nvidia.com/gpu=0",
+		"nvidia.com/gpu=GPU-0",
+		"nvidia.com/gpu=gpu1",
+		"nvidia.com/gpu=1:0",
+		"nvidia.com/gpu=mig1:0",
+		"nvidia.com/gpu=MIG-0",
+		"example.com/device=10",
+		"invalid",
+	})
+
+	require.Equal(t, []string{"nvidia.com/gpu=0", "nvidia.com/gpu=GPU-0"}, c.GPUs[0].CDIDevices)
+	require.Equal(t, []string{"nvidia.com/gpu=gpu1"}, c.GPUs[1].CDIDevices)
+	require.Equal(t,
+		[]string{"nvidia.com/gpu=1:0", "nvidia.com/gpu=MIG-0", "nvidia.com/gpu=mig1:0"},
+		c.GPUs[1].MIGDevices[0].CDIDevices,
+	)
+}
+
+func TestResolveLinks(t *testing.T) {
+	c := Capacity{
+		GPUs: []GPU{
+			{
+				UUID:     "GPU-0",
+				PCIBusID: "0000:01:00.0",
+				Links: []Link{
+					{Index: 0, RemotePCIBusID: "0000:02:00.0"},
+					{Index: 1, RemotePCIBusID: "0000:80:00.0"},
+				},
+			},
+			{
+				UUID:     "GPU-1",
+				PCIBusID: "0000:02:00.0",
+				Links: []Link{
+					{Index: 0, RemotePCIBusID: "0000:01:00.0"},
+				},
+			},
+		},
+	}
+
+	c.resolveLinks()
+
+	require.Equal(t, "GPU-1", c.GPUs[0].Links[0].RemoteUUID)
+	require.Equal(t, "", c.GPUs[0].Links[1].RemoteUUID)
+	require.Equal(t, "GPU-0", c.GPUs[1].Links[0].RemoteUUID)
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nvidia", "capacity.json")
+
+	c := Capacity{
+		SchemaVersion: SchemaVersion,
+		DriverVersion: "535.104.05",
+		GPUs:          []GPU{{UUID: "GPU-0", MemoryBytes: 1024}},
+	}
+
+	updated, err := c.Save(path)
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	expected, err := c.Marshal()
+	require.NoError(t, err)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, contents)
+
+	updated, err = c.Save(path)
+	require.NoError(t, err)
+	require.False(t, updated)
+
+	c.GPUs[0].MemoryBytes = 2048
+	updated, err = c.Save(path)
+	require.NoError(t, err)
+	require.True(t, updated)
+}
+
+func TestFormatCUDAVersion(t *testing.T) {
+	require.Equal(t, "12.2", formatCUDAVersion(12020))
+	require.Equal(t, "11.8", formatCUDAVersion(11080))
+}
+
+func TestParseLinks(t *testing.T) {
+	output := `GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-0)
+	 Link 0: Remote Device 00000000:C8:00.0: Link 10
+	 Link 1: <inactive>
+GPU 1: NVIDIA A100-SXM4-40GB (UUID: GPU-1)
+	 Link 10: Remote Device 00000000:07:00.0: Link 0
+`
+	expected := map[string][]Link{
+		"GPU-0": {{Index: 0, RemotePCIBusID: "0000:c8:00.0"}},
+		"GPU-1": {{Index: 10, RemotePCIBusID: "0000:07:00.0"}},
+	}
+	require.Equal(t, expected, parseLinks([]byte(output)))
+}
@@ -0,0 +1,203 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package capacity
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// Collect queries NVML for the capacity of the node. Since the NVLinks of a GPU are not exposed by
+// the NVML interface, these are queried using the specified nvidia-smi executable. If this fails,
+// no links are included.
+func Collect(nvmllib nvml.Interface, nvidiaSMI string) (*Capacity, error) {
+	if r := nvmllib.Init(); r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvmllib.Shutdown()
+
+	c := Capacity{
+		SchemaVersion: SchemaVersion,
+		GPUs:          []GPU{},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		c.Hostname = hostname
+	}
+
+	version, r := nvmllib.SystemGetDriverVersion()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get driver version: %v", r)
+	}
+	c.DriverVersion = version
+	if cudaVersion, r := nvmllib.SystemGetCudaDriverVersion(); r == nvml.SUCCESS {
+		c.CUDAVersion = formatCUDAVersion(cudaVersion)
+	}
+
+	count, r := nvmllib.DeviceGetCount()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get device count: %v", r)
+	}
+	for i := 0; i < count; i++ {
+		device, r := nvmllib.DeviceGetHandleByIndex(i)
+		if r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device %d: %v", i, r)
+		}
+		gpu, err := getGPU(device, i)
+		if err != nil {
+			return nil, err
+		}
+		c.GPUs = append(c.GPUs, gpu)
+	}
+
+	if links, err := getLinks(nvidiaSMI); err == nil {
+		for i := range c.GPUs {
+			c.GPUs[i].Links = links[c.GPUs[i].UUID]
+		}
+	}
+	c.resolveLinks()
+
+	return &c, nil
+}
+
+func getGPU(device nvml.Device, index int) (GPU, error) {
+	uuid, r := device.GetUUID()
+	if r != nvml.SUCCESS {
+		return GPU{}, fmt.Errorf("failed to get UUID of device %d: %v", index, r)
+	}
+	gpu := GPU{
+		Index: index,
+		UUID:  uuid,
+	}
+	if name, r := device.GetName(); r == nvml.SUCCESS {
+		gpu.Name = name
+	}
+	if pciInfo, r := device.GetPciInfo(); r == nvml.SUCCESS {
+		gpu.PCIBusID = info.GetBusID(pciInfo)
+	}
+	if memory, r := device.GetMemoryInfo(); r == nvml.SUCCESS {
+		gpu.MemoryBytes = memory.Total
+	}
+
+	mode, _, r := device.GetMigMode()
+	if r != nvml.SUCCESS || mode != nvml.DEVICE_MIG_ENABLE {
+		return gpu, nil
+	}
+	gpu.MIGEnabled = true
+
+	maxCount, r := device.GetMaxMigDeviceCount()
+	if r != nvml.SUCCESS {
+		return GPU{}, fmt.Errorf("failed to get MIG device count for device %d: %v", index, r)
+	}
+	for j := 0; j < maxCount; j++ {
+		mig, r := device.GetMigDeviceHandleByIndex(j)
+		if r == nvml.ERROR_NOT_FOUND {
+			continue
+		}
+		if r != nvml.SUCCESS {
+			return GPU{}, fmt.Errorf("failed to get MIG device %d of device %d: %v", j, index, r)
+		}
+		migDevice, err := getMIGDevice(mig, fmt.Sprintf("%d:%d", index, j))
+		if err != nil {
+			return GPU{}, err
+		}
+		gpu.MIGDevices = append(gpu.MIGDevices, migDevice)
+	}
+	return gpu, nil
+}
+
+func getMIGDevice(mig nvml.Device, index string) (MIGDevice, error) {
+	uuid, r := mig.GetUUID()
+	if r != nvml.SUCCESS {
+		return MIGDevice{}, fmt.Errorf("failed to get UUID of MIG device %v: %v", index, r)
+	}
+	device := MIGDevice{
+		Index: index,
+		UUID:  uuid,
+	}
+	// The name of a MIG device includes its profile (e.g. NVIDIA A100-SXM4-40GB MIG 1g.5gb).
+	if name, r := mig.GetName(); r == nvml.SUCCESS {
+		if _, profile, found := strings.Cut(name, " MIG "); found {
+			device.Profile = profile
+		}
+	}
+	if gi, r := mig.GetGpuInstanceId(); r == nvml.SUCCESS {
+		device.GPUInstanceID = gi
+	}
+	if ci, r := mig.GetComputeInstanceId(); r == nvml.SUCCESS {
+		device.ComputeInstanceID = ci
+	}
+	if memory, r := mig.GetMemoryInfo(); r == nvml.SUCCESS {
+		device.MemoryBytes = memory.Total
+	}
+	return device, nil
+}
+
+// getLinks queries the active NVLinks of the GPUs using the 'nvidia-smi nvlink --remote' subcommand.
+// The links are returned by GPU UUID.
+func getLinks(nvidiaSMI string) (map[string][]Link, error) {
+	output, err := exec.Command(nvidiaSMI, "nvlink", "--remote").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NVLinks: %v", err)
+	}
+	return parseLinks(output), nil
+}
+
+var (
+	// gpuLinePattern matches the lines identifying a GPU (e.g. GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-...)).
+	gpuLinePattern = regexp.MustCompile(`^GPU \d+: .*\(UUID: (\S+)\)$`)
+	// linkLinePattern matches the lines describing an active link (e.g. Link 0: Remote Device 00000000:07:00.0: Link 6).
+	linkLinePattern = regexp.MustCompile(`^Link (\d+): Remote Device ([0-9A-Fa-f:.]+): Link \d+$`)
+)
+
+// parseLinks parses the output of the 'nvidia-smi nvlink --remote' subcommand. Lines for inactive
+// links are ignored.
+func parseLinks(output []byte) map[string][]Link {
+	links := make(map[string][]Link)
+	var uuid string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := gpuLinePattern.FindStringSubmatch(line); match != nil {
+			uuid = match[1]
+			continue
+		}
+		match := linkLinePattern.FindStringSubmatch(line)
+		if match == nil || uuid == "" {
+			continue
+		}
+		index, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		links[uuid] = append(links[uuid], Link{Index: index, RemotePCIBusID: info.NormalizeBusID(match[2])})
+	}
+	return links
+}
+
+// formatCUDAVersion returns the CUDA version reported by NVML (e.g. 12020) as a string (e.g. 12.2).
+func formatCUDAVersion(version int) string {
+	return fmt.Sprintf("%d.%d", version/1000, (version%1000)/10)
+}
@@ -0,0 +1,238 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdibundle
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+const (
+	// ManifestFile is the name of the checksum manifest in a bundle.
+	ManifestFile = "manifest.json"
+	// SpecDir is the directory in a bundle containing the CDI specifications.
+	SpecDir = "specs"
+)
+
+// Bundle represents an extracted CDI spec bundle.
+type Bundle struct {
+	// SpecPaths are the paths of the extracted CDI specifications.
+	SpecPaths []string
+	// Manifest records the checksums and sizes of the host files referenced by the specifications.
+	// Only regular files are included since other files such as sockets are created at runtime.
+	Manifest *checksum.Manifest
+
+	// dir is the directory to which the bundle was extracted.
+	dir string
+}
+
+// Create writes a bundle containing the specified CDI specifications and a manifest of the host files
+// referenced by them to w. The specifications are stored below SpecDir at their absolute path (e.g.
+// specs/etc/cdi/nvidia.yaml) so that specifications with the same name in different spec dirs are
+// retained.
+func Create(w io.Writer, specPaths []string) (*checksum.Manifest, error) {
+	var hostPaths []string
+	for _, specPath := range specPaths {
+		spec, err := cdi.ReadSpec(specPath, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CDI specification %v: %v", specPath, err)
+		}
+		hostPaths = append(hostPaths, HostPaths(spec.Spec)...)
+	}
+
+	manifest, err := checksum.New(hostPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %v", err)
+	}
+
+	tw := tar.NewWriter(w)
+	written := make(map[string]bool)
+	for _, specPath := range specPaths {
+		name, err := specArchiveName(specPath)
+		if err != nil {
+			return nil, err
+		}
+		if written[name] {
+			return nil, fmt.Errorf("CDI specification %v specified more than once", specPath)
+		}
+		written[name] = true
+
+		contents, err := os.ReadFile(specPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(tw, name, contents); err != nil {
+			return nil, err
+		}
+	}
+
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+	if err := writeFile(tw, ManifestFile, contents); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %v", err)
+	}
+	return manifest, nil
+}
+
+// specArchiveName returns the name of the specified CDI specification in a bundle.
+func specArchiveName(specPath string) (string, error) {
+	absPath, err := filepath.Abs(specPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path of CDI specification %v: %v", specPath, err)
+	}
+	return path.Join(SpecDir, filepath.ToSlash(absPath)), nil
+}
+
+// Extract extracts the bundle read from r to the specified directory. The CDI specifications are
+// extracted to their path below SpecDir relative to the directory.
+func Extract(r io.Reader, dir string) (*Bundle, error) {
+	bundle := &Bundle{dir: dir}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		contents, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v from bundle: %v", header.Name, err)
+		}
+
+		name := path.Clean(header.Name)
+		switch {
+		case name == ManifestFile:
+			var manifest checksum.Manifest
+			if err := json.Unmarshal(contents, &manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse manifest: %v", err)
+			}
+			bundle.Manifest = &manifest
+		case strings.HasPrefix(name, SpecDir+"/") && isSpecFile(name):
+			// Since the name is cleaned, it cannot refer to a path outside of SpecDir.
+			specPath := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, SpecDir+"/")))
+			if err := os.MkdirAll(filepath.Dir(specPath), 0755); err != nil {
+				return nil, fmt.Errorf("failed to extract %v: %v", name, err)
+			}
+			if err := os.WriteFile(specPath, contents, 0644); err != nil {
+				return nil, fmt.Errorf("failed to extract %v: %v", name, err)
+			}
+			bundle.SpecPaths = append(bundle.SpecPaths, specPath)
+		}
+	}
+
+	if bundle.Manifest == nil {
+		return nil, fmt.Errorf("bundle does not contain a %v", ManifestFile)
+	}
+	if len(bundle.SpecPaths) == 0 {
+		return nil, fmt.Errorf("bundle does not contain any CDI specifications")
+	}
+	return bundle, nil
+}
+
+// Verify checks that the CDI specifications in the bundle are valid and that the host files in the
+// manifest exist with the expected sizes and checksums relative to the specified root. A description
+// of each problem found is returned.
+func (b *Bundle) Verify(root string) []string {
+	var problems []string
+	for _, specPath := range b.SpecPaths {
+		if _, err := cdi.ReadSpec(specPath, 0); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid CDI specification %v: %v", b.specName(specPath), err))
+		}
+	}
+
+	for _, mismatch := range b.Manifest.VerifyRoot(root) {
+		problems = append(problems, mismatch.String())
+	}
+	return problems
+}
+
+// specName returns the path of the specified extracted CDI specification relative to the directory
+// to which the bundle was extracted.
+func (b *Bundle) specName(specPath string) string {
+	name, err := filepath.Rel(b.dir, specPath)
+	if err != nil {
+		return specPath
+	}
+	return name
+}
+
+// HostPaths returns the paths of the host files referenced by the mounts and hooks in the specified CDI specification.
+func HostPaths(raw *specs.Spec) []string {
+	edits := []specs.ContainerEdits{raw.ContainerEdits}
+	for _, d := range raw.Devices {
+		edits = append(edits, d.ContainerEdits)
+	}
+
+	var paths []string
+	for _, e := range edits {
+		for _, m := range e.Mounts {
+			paths = append(paths, m.HostPath)
+		}
+		for _, h := range e.Hooks {
+			paths = append(paths, h.Path)
+		}
+	}
+	return paths
+}
+
+func writeFile(tw *tar.Writer, name string, contents []byte) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header for %v: %v", name, err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return fmt.Errorf("failed to write %v: %v", name, err)
+	}
+	return nil
+}
+
+// isSpecFile checks whether the specified name has a supported CDI specification extension.
+func isSpecFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
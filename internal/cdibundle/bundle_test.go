@@ -0,0 +1,109 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdibundle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExtractVerify(t *testing.T) {
+	hostRoot := t.TempDir()
+	libcuda := filepath.Join(hostRoot, "usr/lib64/libcuda.so.999.99")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	specPath := filepath.Join(t.TempDir(), "nvidia.yaml")
+	spec := fmt.Sprintf(`---
+cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: all
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+containerEdits:
+  mounts:
+  - hostPath: %v
+    containerPath: /usr/lib64/libcuda.so.999.99
+    options: [ro, nosuid, nodev, bind]
+  - hostPath: /run/nvidia-persistenced/socket
+    containerPath: /run/nvidia-persistenced/socket
+`, libcuda)
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0644))
+
+	buf := &bytes.Buffer{}
+	manifest, err := Create(buf, []string{specPath})
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 1)
+	require.Equal(t, libcuda, manifest.Files[0].Path)
+	require.EqualValues(t, 7, manifest.Files[0].Size)
+
+	extractDir := t.TempDir()
+	bundle, err := Extract(bytes.NewReader(buf.Bytes()), extractDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(extractDir, specPath)}, bundle.SpecPaths)
+	require.EqualValues(t, manifest, bundle.Manifest)
+
+	require.Empty(t, bundle.Verify("/"))
+
+	require.NoError(t, os.WriteFile(libcuda, []byte("LIBCUDA"), 0644))
+	problems := bundle.Verify("/")
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], libcuda)
+}
+
+func TestExtractInvalidBundle(t *testing.T) {
+	_, err := Extract(bytes.NewReader(nil), t.TempDir())
+	require.Error(t, err)
+}
+
+func TestCreateRetainsSpecDirs(t *testing.T) {
+	spec := `---
+cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: all
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+`
+	var specPaths []string
+	for _, dir := range []string{"etc/cdi", "var/run/cdi"} {
+		specPath := filepath.Join(t.TempDir(), dir, "nvidia.yaml")
+		require.NoError(t, os.MkdirAll(filepath.Dir(specPath), 0755))
+		require.NoError(t, os.WriteFile(specPath, []byte(spec), 0644))
+		specPaths = append(specPaths, specPath)
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Create(buf, specPaths)
+	require.NoError(t, err)
+
+	extractDir := t.TempDir()
+	bundle, err := Extract(bytes.NewReader(buf.Bytes()), extractDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(extractDir, specPaths[0]), filepath.Join(extractDir, specPaths[1])}, bundle.SpecPaths)
+
+	_, err = Create(&bytes.Buffer{}, []string{specPaths[0], specPaths[0]})
+	require.Error(t, err)
+}
@@ -0,0 +1,359 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdiindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+)
+
+// DefaultPath is the default path of the CDI spec index.
+const DefaultPath = "/var/run/nvidia-container-toolkit/cdi-index.json"
+
+// ConflictType defines the type of a conflict between CDI specifications.
+type ConflictType string
+
+const (
+	// ConflictDevice indicates that a device with the same fully-qualified name is defined in more than one spec.
+	ConflictDevice = ConflictType("device")
+	// ConflictMount indicates that different host paths are mounted at the same container path by more than one spec.
+	ConflictMount = ConflictType("mount")
+)
+
+// Index records the CDI specifications (of all vendors) in a set of spec dirs together with the
+// devices that these define and the conflicts between them.
+type Index struct {
+	SpecDirs  []string   `json:"specDirs"`
+	Specs     []Spec     `json:"specs"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// Spec represents a single CDI specification file in the index.
+type Spec struct {
+	Path     string    `json:"path"`
+	Priority int       `json:"priority"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+	Kind     string    `json:"kind,omitempty"`
+	Devices  []string  `json:"devices,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Conflict describes a conflict between CDI specifications.
+type Conflict struct {
+	Type ConflictType `json:"type"`
+	// Name is the fully-qualified device name or the container path of the mount.
+	Name  string   `json:"name"`
+	Specs []string `json:"specs"`
+	// Resolved indicates that the conflict is resolved by the priority of the spec dirs.
+	// Unresolved device conflicts cause the device to be unavailable.
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+func (c Conflict) String() string {
+	var resolution string
+	if c.Resolved {
+		resolution = " (resolved by spec dir priority)"
+	}
+	switch c.Type {
+	case ConflictDevice:
+		return fmt.Sprintf("device %v is defined in %v%v", c.Name, strings.Join(c.Specs, ", "), resolution)
+	case ConflictMount:
+		return fmt.Sprintf("different host paths are mounted at %v by %v", c.Name, strings.Join(c.Specs, ", "))
+	}
+	return fmt.Sprintf("%v %v: %v", c.Type, c.Name, strings.Join(c.Specs, ", "))
+}
+
+// Build creates an index for the CDI specifications in the specified spec dirs. As is the case for
+// the CDI registry, the priority of a spec is the index of its spec dir. Specs that cannot be
+// loaded are included in the index with the associated error.
+func Build(specDirs []string) (*Index, error) {
+	files, err := listSpecFiles(specDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	index := Index{
+		SpecDirs: specDirs,
+	}
+	var loaded []*cdi.Spec
+	for _, f := range files {
+		spec, err := cdi.ReadSpec(f.Path, f.Priority)
+		if err != nil {
+			f.Error = err.Error()
+			index.Specs = append(index.Specs, f)
+			continue
+		}
+		f.Kind = spec.Kind
+		for _, d := range spec.Devices {
+			f.Devices = append(f.Devices, cdi.QualifiedName(spec.GetVendor(), spec.GetClass(), d.Name))
+		}
+		index.Specs = append(index.Specs, f)
+		loaded = append(loaded, spec)
+	}
+
+	index.Conflicts = append(index.Conflicts, deviceConflicts(index.Specs)...)
+	index.Conflicts = append(index.Conflicts, mountConflicts(loaded)...)
+
+	return &index, nil
+}
+
+// Load loads the index from the specified path.
+func Load(path string) (*Index, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index Index
+	if err := json.Unmarshal(contents, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CDI spec index: %v", err)
+	}
+	return &index, nil
+}
+
+// Save writes the index to the specified path. The index is written to a temporary file that is
+// renamed into place so that readers never observe a partially-written index.
+func (i *Index) Save(path string) error {
+	contents, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CDI spec index: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cdi-index-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write CDI spec index: %v", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set permissions: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %v", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// IsCurrent checks whether the index is up to date for the specified spec dirs. This is the case
+// if the index was built for the same spec dirs and no spec files were added, removed, or modified
+// since. Only the spec dirs are listed and the spec files are not read.
+func (i *Index) IsCurrent(specDirs []string) (bool, error) {
+	if !equal(i.SpecDirs, specDirs) {
+		return false, nil
+	}
+	files, err := listSpecFiles(specDirs)
+	if err != nil {
+		return false, err
+	}
+	if len(files) != len(i.Specs) {
+		return false, nil
+	}
+	for j, f := range files {
+		indexed := i.Specs[j]
+		if f.Path != indexed.Path || f.Priority != indexed.Priority || f.Size != indexed.Size || !f.ModTime.Equal(indexed.ModTime) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Lookup returns the path of the spec that defines the specified fully-qualified device name.
+// If the device is defined in more than one spec, the spec from the spec dir with the highest
+// priority is returned. If the device is not defined or the conflict cannot be resolved, false is returned.
+func (i *Index) Lookup(device string) (string, bool) {
+	for _, c := range i.Conflicts {
+		if c.Type == ConflictDevice && c.Name == device && !c.Resolved {
+			return "", false
+		}
+	}
+
+	var path string
+	priority := -1
+	for _, s := range i.Specs {
+		if s.Priority <= priority {
+			continue
+		}
+		for _, d := range s.Devices {
+			if d == device {
+				path = s.Path
+				priority = s.Priority
+				break
+			}
+		}
+	}
+	return path, path != ""
+}
+
+// listSpecFiles lists the CDI spec files (i.e. files with a .json or .yaml extension) in the
+// specified spec dirs. Subdirectories and spec dirs that do not exist are ignored.
+func listSpecFiles(specDirs []string) ([]Spec, error) {
+	var files []Spec
+	for priority, dir := range specDirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec dir %v: %v", dir, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if ext := filepath.Ext(e.Name()); ext != ".json" && ext != ".yaml" {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat %v: %v", path, err)
+			}
+			files = append(files, Spec{
+				Path:     path,
+				Priority: priority,
+				Size:     info.Size(),
+				ModTime:  info.ModTime(),
+			})
+		}
+	}
+	return files, nil
+}
+
+// deviceConflicts returns the devices that are defined in more than one spec. A conflict is
+// resolved if the spec with the highest priority is the only spec with that priority.
+func deviceConflicts(specs []Spec) []Conflict {
+	definedIn := make(map[string][]Spec)
+	var names []string
+	for _, s := range specs {
+		for _, d := range s.Devices {
+			if _, ok := definedIn[d]; !ok {
+				names = append(names, d)
+			}
+			definedIn[d] = append(definedIn[d], s)
+		}
+	}
+	sort.Strings(names)
+
+	var conflicts []Conflict
+	for _, name := range names {
+		specs := definedIn[name]
+		if len(specs) < 2 {
+			continue
+		}
+		c := Conflict{
+			Type: ConflictDevice,
+			Name: name,
+		}
+		highest := -1
+		var atHighest int
+		for _, s := range specs {
+			c.Specs = append(c.Specs, s.Path)
+			switch {
+			case s.Priority > highest:
+				highest = s.Priority
+				atHighest = 1
+			case s.Priority == highest:
+				atHighest++
+			}
+		}
+		c.Resolved = atHighest == 1
+		conflicts = append(conflicts, c)
+	}
+	return conflicts
+}
+
+// mountConflicts returns the container paths at which different host paths are mounted by more
+// than one spec. Mounts of the same host path (e.g. driver libraries included in the specs of
+// different classes) are not considered conflicts.
+func mountConflicts(specs []*cdi.Spec) []Conflict {
+	type mount struct {
+		hostPath string
+		spec     string
+	}
+	mounts := make(map[string][]mount)
+	var paths []string
+	for _, s := range specs {
+		addMount := func(hostPath string, containerPath string) {
+			if _, ok := mounts[containerPath]; !ok {
+				paths = append(paths, containerPath)
+			}
+			for _, m := range mounts[containerPath] {
+				if m.spec == s.GetPath() && m.hostPath == hostPath {
+					return
+				}
+			}
+			mounts[containerPath] = append(mounts[containerPath], mount{hostPath: hostPath, spec: s.GetPath()})
+		}
+		for _, m := range s.ContainerEdits.Mounts {
+			addMount(m.HostPath, m.ContainerPath)
+		}
+		for _, d := range s.Devices {
+			for _, m := range d.ContainerEdits.Mounts {
+				addMount(m.HostPath, m.ContainerPath)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	var conflicts []Conflict
+	for _, path := range paths {
+		hostPaths := make(map[string]bool)
+		specs := make(map[string]bool)
+		var c Conflict
+		for _, m := range mounts[path] {
+			hostPaths[m.hostPath] = true
+			if !specs[m.spec] {
+				specs[m.spec] = true
+				c.Specs = append(c.Specs, m.spec)
+			}
+		}
+		if len(hostPaths) < 2 || len(specs) < 2 {
+			continue
+		}
+		c.Type = ConflictMount
+		c.Name = path
+		conflicts = append(conflicts, c)
+	}
+	return conflicts
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdiindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const nvidiaSpec = `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+containerEdits:
+  mounts:
+  - hostPath: /usr/lib64/libcuda.so.999.99
+    containerPath: /usr/lib64/libcuda.so.999.99
+`
+
+const otherVendorSpec = `cdiVersion: 0.5.0
+kind: example.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    deviceNodes:
+    - path: /dev/example0
+    mounts:
+    - hostPath: /opt/example/libcuda.so
+      containerPath: /usr/lib64/libcuda.so.999.99
+`
+
+const overrideSpec = `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+`
+
+func TestBuild(t *testing.T) {
+	etcDir := t.TempDir()
+	runDir := t.TempDir()
+	writeSpec(t, filepath.Join(etcDir, "nvidia.yaml"), nvidiaSpec)
+	writeSpec(t, filepath.Join(etcDir, "example.yaml"), otherVendorSpec)
+	writeSpec(t, filepath.Join(etcDir, "invalid.json"), "{")
+	writeSpec(t, filepath.Join(etcDir, "nvidia.yaml.lock"), "1")
+	writeSpec(t, filepath.Join(runDir, "nvidia.yaml"), overrideSpec)
+
+	index, err := Build([]string{etcDir, runDir, filepath.Join(t.TempDir(), "missing")})
+	require.NoError(t, err)
+
+	require.Len(t, index.Specs, 4)
+	require.Equal(t, filepath.Join(etcDir, "example.yaml"), index.Specs[0].Path)
+	require.Equal(t, []string{"example.com/gpu=gpu0"}, index.Specs[0].Devices)
+	require.Equal(t, filepath.Join(etcDir, "invalid.json"), index.Specs[1].Path)
+	require.NotEmpty(t, index.Specs[1].Error)
+	require.Equal(t, "nvidia.com/gpu", index.Specs[2].Kind)
+	require.Equal(t, 1, index.Specs[3].Priority)
+
+	require.EqualValues(t, []Conflict{
+		{
+			Type:     ConflictDevice,
+			Name:     "nvidia.com/gpu=gpu0",
+			Specs:    []string{filepath.Join(etcDir, "nvidia.yaml"), filepath.Join(runDir, "nvidia.yaml")},
+			Resolved: true,
+		},
+		{
+			Type:  ConflictMount,
+			Name:  "/usr/lib64/libcuda.so.999.99",
+			Specs: []string{filepath.Join(etcDir, "example.yaml"), filepath.Join(etcDir, "nvidia.yaml")},
+		},
+	}, index.Conflicts)
+
+	path, ok := index.Lookup("nvidia.com/gpu=gpu0")
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(runDir, "nvidia.yaml"), path)
+
+	_, ok = index.Lookup("nvidia.com/gpu=gpu1")
+	require.False(t, ok)
+}
+
+func TestUnresolvedDeviceConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, filepath.Join(dir, "nvidia.yaml"), nvidiaSpec)
+	writeSpec(t, filepath.Join(dir, "other.yaml"), overrideSpec)
+
+	index, err := Build([]string{dir})
+	require.NoError(t, err)
+
+	require.Len(t, index.Conflicts, 1)
+	require.False(t, index.Conflicts[0].Resolved)
+
+	_, ok := index.Lookup("nvidia.com/gpu=gpu0")
+	require.False(t, ok)
+}
+
+func TestSaveLoadIsCurrent(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, filepath.Join(dir, "nvidia.yaml"), nvidiaSpec)
+
+	index, err := Build([]string{dir})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "index", "cdi-index.json")
+	require.NoError(t, index.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+
+	current, err := loaded.IsCurrent([]string{dir})
+	require.NoError(t, err)
+	require.True(t, current)
+
+	current, err = loaded.IsCurrent([]string{dir, "/var/run/cdi"})
+	require.NoError(t, err)
+	require.False(t, current)
+
+	// Modifying a spec makes the index stale.
+	writeSpec(t, filepath.Join(dir, "nvidia.yaml"), overrideSpec)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "nvidia.yaml"), later, later))
+
+	current, err = loaded.IsCurrent([]string{dir})
+	require.NoError(t, err)
+	require.False(t, current)
+
+	// Adding a spec makes the index stale.
+	index, err = Build([]string{dir})
+	require.NoError(t, err)
+	writeSpec(t, filepath.Join(dir, "example.yaml"), otherVendorSpec)
+
+	current, err = index.IsCurrent([]string{dir})
+	require.NoError(t, err)
+	require.False(t, current)
+}
+
+func writeSpec(t *testing.T, path string, contents string) {
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+}
@@ -0,0 +1,85 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package cdiregistry implements a daemon that owns the state of the CDI registry and answers
+// injection queries from the NVIDIA Container Runtime and the nvidia-cdi-hook over a Unix socket.
+// Since the CDI specifications are only parsed when these change instead of for each container that
+// is created, the cost of loading large specifications is amortized. The daemon also allows policies
+// such as strict mode to be enforced centrally. Clients are expected to fall back to loading the CDI
+// specifications themselves if the daemon is not available.
+package cdiregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	cdispecs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+const (
+	// DefaultSocketPath is the default path of the socket on which the daemon listens.
+	DefaultSocketPath = "/run/nvidia-container-toolkit/cdi-registry.sock"
+	// DefaultTimeout is the maximum duration of a query, including connecting to the daemon.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Request queries the edits for a set of CDI devices.
+type Request struct {
+	// Devices are the fully-qualified names of the requested devices.
+	Devices []string `json:"devices"`
+}
+
+// Response is the answer of the daemon to a Request.
+type Response struct {
+	// Edits are the edits of the requested devices and of the specs that define these, combined in
+	// the order in which the CDI registry applies them.
+	Edits *cdispecs.ContainerEdits `json:"edits,omitempty"`
+	// Unresolved are the requested devices that are not defined in any CDI specification.
+	Unresolved []string `json:"unresolved,omitempty"`
+	// SpecErrors maps the paths of the CDI specifications that could not be loaded to the errors.
+	SpecErrors map[string][]string `json:"specErrors,omitempty"`
+	// Error is set if the daemon rejected the request (e.g. due to invalid specifications in strict
+	// mode). No edits are returned in this case.
+	Error string `json:"error,omitempty"`
+}
+
+// Query requests the edits for the specified devices from the daemon listening on the specified
+// socket. An error is returned if the daemon is not available or does not respond within the
+// specified timeout. Requests that are rejected by the daemon are indicated by the Error of the
+// response.
+func Query(socket string, devices []string, timeout time.Duration) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socket, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to CDI registry daemon: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %v", err)
+	}
+
+	if err := json.NewEncoder(conn).Encode(Request{Devices: devices}); err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+
+	var response Response
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	return &response, nil
+}
@@ -0,0 +1,193 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdiregistry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdispecdirs"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/sirupsen/logrus"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: "0"
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+- name: "1"
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia1
+containerEdits:
+  env:
+  - NVIDIA_VISIBLE_DEVICES=void
+`
+
+func TestQuery(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description        string
+		invalidSpec        bool
+		strict             bool
+		devices            []string
+		expectedEnv        []string
+		expectedNodes      []string
+		expectedUnresolved []string
+		expectedSpecErrors bool
+		expectedError      bool
+	}{
+		{
+			description:   "single device",
+			devices:       []string{"nvidia.com/gpu=0"},
+			expectedEnv:   []string{"NVIDIA_VISIBLE_DEVICES=void"},
+			expectedNodes: []string{"/dev/nvidia0"},
+		},
+		{
+			description:   "spec edits are included once",
+			devices:       []string{"nvidia.com/gpu=1", "nvidia.com/gpu=0"},
+			expectedEnv:   []string{"NVIDIA_VISIBLE_DEVICES=void"},
+			expectedNodes: []string{"/dev/nvidia1", "/dev/nvidia0"},
+		},
+		{
+			description:        "unresolved device",
+			devices:            []string{"nvidia.com/gpu=0", "nvidia.com/gpu=2"},
+			expectedUnresolved: []string{"nvidia.com/gpu=2"},
+		},
+		{
+			description:        "invalid spec is reported",
+			invalidSpec:        true,
+			devices:            []string{"nvidia.com/gpu=0"},
+			expectedEnv:        []string{"NVIDIA_VISIBLE_DEVICES=void"},
+			expectedNodes:      []string{"/dev/nvidia0"},
+			expectedSpecErrors: true,
+		},
+		{
+			description:        "invalid spec is rejected in strict mode",
+			invalidSpec:        true,
+			strict:             true,
+			devices:            []string{"nvidia.com/gpu=0"},
+			expectedSpecErrors: true,
+			expectedError:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			specDir := filepath.Join(dir, "cdi")
+			require.NoError(t, os.MkdirAll(specDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(testSpec), 0644))
+			if tc.invalidSpec {
+				require.NoError(t, os.WriteFile(filepath.Join(specDir, "invalid.yaml"), []byte("kind: invalid"), 0644))
+			}
+
+			socket := filepath.Join(dir, "cdi-registry.sock")
+			listener, err := Listen(socket)
+			require.NoError(t, err)
+			defer listener.Close()
+
+			server, err := NewServer(logger, newValidator(t, logger, false), []string{specDir}, tc.strict)
+			require.NoError(t, err)
+			go func() {
+				_ = server.Serve(listener)
+			}()
+
+			response, err := Query(socket, tc.devices, DefaultTimeout)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedSpecErrors, len(response.SpecErrors) > 0)
+			require.Equal(t, tc.expectedError, response.Error != "")
+			require.EqualValues(t, tc.expectedUnresolved, response.Unresolved)
+			if tc.expectedEnv == nil && tc.expectedNodes == nil {
+				require.Nil(t, response.Edits)
+				return
+			}
+
+			require.NotNil(t, response.Edits)
+			require.EqualValues(t, tc.expectedEnv, response.Edits.Env)
+			var nodes []string
+			for _, n := range response.Edits.DeviceNodes {
+				nodes = append(nodes, n.Path)
+			}
+			require.EqualValues(t, tc.expectedNodes, nodes)
+		})
+	}
+}
+
+func TestServerValidatesSpecs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("spec dirs are required to be owned by root")
+	}
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	specDir := filepath.Join(dir, "cdi")
+	require.NoError(t, os.MkdirAll(specDir, 0755))
+	specFile := filepath.Join(specDir, "nvidia.yaml")
+	require.NoError(t, os.WriteFile(specFile, []byte(testSpec), 0644))
+	require.NoError(t, os.Chmod(specFile, 0644))
+
+	socket := filepath.Join(dir, "cdi-registry.sock")
+	listener, err := Listen(socket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	validator := newValidator(t, logger, true)
+	server, err := NewServer(logger, validator, []string{specDir}, false)
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	response, err := Query(socket, []string{"nvidia.com/gpu=0"}, DefaultTimeout)
+	require.NoError(t, err)
+	require.Empty(t, response.Error)
+	require.NotNil(t, response.Edits)
+
+	// The spec is checked for each request since it may be modified once the server is started.
+	require.NoError(t, os.Chmod(specFile, 0666))
+	response, err = Query(socket, []string{"nvidia.com/gpu=0"}, DefaultTimeout)
+	require.NoError(t, err)
+	require.Contains(t, response.Error, "nvidia.yaml")
+	require.Nil(t, response.Edits)
+
+	require.Error(t, server.Refresh())
+	_, err = NewServer(logger, validator, []string{specDir}, false)
+	require.Error(t, err)
+}
+
+func newValidator(t *testing.T, logger *logrus.Logger, enforce bool) *cdispecdirs.Validator {
+	cfg := &config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.Enforce = enforce
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.MaxMode = "0755"
+	validator, err := cdispecdirs.NewValidator(logger, cfg)
+	require.NoError(t, err)
+	return validator
+}
+
+func TestQueryUnavailable(t *testing.T) {
+	_, err := Query(filepath.Join(t.TempDir(), "missing.sock"), []string{"nvidia.com/gpu=0"}, DefaultTimeout)
+	require.Error(t, err)
+}
@@ -0,0 +1,206 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdiregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdispecdirs"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/sirupsen/logrus"
+)
+
+// Server answers the queries of clients using a CDI cache. A dedicated cache is used instead of the
+// CDI registry since the registry is shared by all users in the process.
+type Server struct {
+	logger    *logrus.Logger
+	validator *cdispecdirs.Validator
+	specDirs  []string
+	cache     *cdi.Cache
+	strict    bool
+}
+
+// NewServer creates a server for the CDI specifications in the specified spec dirs. The spec dirs are
+// checked using the specified validator, as is done by the NVIDIA Container Runtime. The specifications
+// are reloaded automatically when these change, and the specifications of the requested devices are
+// checked again for each request. In strict mode, all requests are rejected while any of the
+// specifications cannot be loaded.
+func NewServer(logger *logrus.Logger, validator *cdispecdirs.Validator, specDirs []string, strict bool) (*Server, error) {
+	if err := validator.Validate(specDirs...); err != nil {
+		return nil, err
+	}
+	cache, err := cdi.NewCache(
+		cdi.WithSpecDirs(specDirs...),
+		cdi.WithAutoRefresh(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDI cache: %v", err)
+	}
+	s := Server{
+		logger:    logger,
+		validator: validator,
+		specDirs:  specDirs,
+		cache:     cache,
+		strict:    strict,
+	}
+	s.logLoaded()
+	return &s, nil
+}
+
+// Refresh reloads the CDI specifications from the spec dirs. This also watches spec dirs that
+// have been created since the server was started.
+func (s *Server) Refresh() error {
+	if err := s.validator.Validate(s.specDirs...); err != nil {
+		return err
+	}
+	if err := s.cache.Configure(cdi.WithSpecDirs(s.specDirs...)); err != nil {
+		return fmt.Errorf("failed to refresh CDI cache: %v", err)
+	}
+	s.logLoaded()
+	return nil
+}
+
+// logLoaded logs the number of loaded devices and the errors for the specs that could not be loaded.
+func (s *Server) logLoaded() {
+	for path, errs := range s.cache.GetErrors() {
+		for _, err := range errs {
+			s.logger.Warningf("Failed to load CDI specification %v: %v", path, err)
+		}
+	}
+	s.logger.Infof("Loaded %d CDI devices from %v", len(s.cache.ListDevices()), s.specDirs)
+}
+
+// Listen creates the Unix socket at the specified path. A stale socket left behind by a previous
+// instance of the daemon is removed. Only the owner of the socket (typically root) can connect.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %v: %v", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions of %v: %v", path, err)
+	}
+	return listener, nil
+}
+
+// Serve accepts connections on the specified listener and answers a single query for each
+// connection. Serve returns once the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to accept connection: %v", err)
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle reads a request from the specified connection and writes the response.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(DefaultTimeout)); err != nil {
+		s.logger.Debugf("Failed to set deadline: %v", err)
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		s.logger.Debugf("Failed to read request: %v", err)
+		return
+	}
+
+	response := s.query(request.Devices)
+	if err := json.NewEncoder(conn).Encode(response); err != nil {
+		s.logger.Debugf("Failed to write response: %v", err)
+	}
+}
+
+// query returns the combined edits for the specified devices. As is the case when injecting devices
+// using the CDI registry, the edits of the spec of a device are included once before the edits of
+// the first device of the spec.
+func (s *Server) query(devices []string) *Response {
+	var response Response
+
+	specErrors := s.cache.GetErrors()
+	if len(specErrors) > 0 {
+		response.SpecErrors = make(map[string][]string)
+		for path, errs := range specErrors {
+			for _, err := range errs {
+				response.SpecErrors[path] = append(response.SpecErrors[path], err.Error())
+			}
+		}
+	}
+	if s.strict && len(specErrors) > 0 {
+		var paths []string
+		for path := range specErrors {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		response.Error = fmt.Sprintf("invalid CDI specifications in strict mode: %v", strings.Join(paths, ", "))
+		s.logger.Warningf("Rejected request for %v: %v", devices, response.Error)
+		return &response
+	}
+
+	var edits *cdi.ContainerEdits
+	applied := make(map[*cdi.Spec]bool)
+	for _, name := range devices {
+		device := s.cache.GetDevice(name)
+		if device == nil {
+			response.Unresolved = append(response.Unresolved, name)
+			continue
+		}
+		if spec := device.GetSpec(); !applied[spec] {
+			if err := s.validator.ValidateSpec(spec.GetPath()); err != nil {
+				response.Error = fmt.Sprintf("invalid CDI specification for %v: %v", name, err)
+				s.logger.Warningf("Rejected request for %v: %v", devices, response.Error)
+				return &response
+			}
+			applied[spec] = true
+			edits = edits.Append(&cdi.ContainerEdits{ContainerEdits: &spec.ContainerEdits})
+		}
+		edits = edits.Append(&cdi.ContainerEdits{ContainerEdits: &device.ContainerEdits})
+	}
+	if len(response.Unresolved) > 0 {
+		s.logger.Warningf("Unresolvable CDI devices requested: %v", response.Unresolved)
+		return &response
+	}
+	if edits != nil {
+		response.Edits = edits.ContainerEdits
+	}
+
+	s.logger.Debugf("Answered request for %v", devices)
+	return &response
+}
@@ -0,0 +1,212 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package cdispecdirs checks that CDI specifications are only loaded from locations where these cannot
+// be added or modified by users other than root. The same checks are applied by the NVIDIA Container
+// Runtime, the nvidia-cdi-hook, and the CDI registry daemon.
+package cdispecdirs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+)
+
+// Validator checks spec dirs and spec files against the allowed-spec-dirs and, if enforced, the
+// spec-dir-permissions in the config.
+type Validator struct {
+	logger      *logrus.Logger
+	allowedDirs []string
+	enforce     bool
+	maxMode     os.FileMode
+}
+
+// NewValidator creates a validator for the CDI config. An error is returned for an invalid max-mode.
+func NewValidator(logger *logrus.Logger, cfg *config.Config) (*Validator, error) {
+	cdiConfig := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI
+
+	v := Validator{
+		logger:      logger,
+		allowedDirs: cdiConfig.AllowedSpecDirs,
+		enforce:     cdiConfig.SpecDirPermissions.Enforce,
+	}
+	if !v.enforce {
+		return &v, nil
+	}
+
+	maxMode, err := strconv.ParseUint(cdiConfig.SpecDirPermissions.MaxMode, 8, 32)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid spec-dir-permissions.max-mode %q: %v", cdiConfig.SpecDirPermissions.MaxMode, err))
+	}
+	v.maxMode = os.FileMode(maxMode).Perm()
+	return &v, nil
+}
+
+// Validate checks that the specified spec dirs are allowed. If permissions are enforced, the spec dirs,
+// the spec files in these, and all their parent directories are checked as for checkPermissions.
+func (v *Validator) Validate(specDirs ...string) error {
+	for _, dir := range specDirs {
+		if err := v.checkAllowed(dir); err != nil {
+			return err
+		}
+	}
+	if !v.enforce {
+		return nil
+	}
+
+	for _, dir := range specDirs {
+		if err := v.checkSpecDir(dir); err != nil {
+			v.logger.WithField(events.Field, events.CDISpecDirRejected).Errorf("Rejecting CDI spec dir %v: %v", dir, err)
+			return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid CDI spec dir %v: %v", dir, err))
+		}
+	}
+	return nil
+}
+
+// ValidateSpec checks that the specified spec file is in an allowed spec dir. If permissions are
+// enforced, the spec file and all its parent directories are checked as for checkPermissions.
+func (v *Validator) ValidateSpec(path string) error {
+	if err := v.checkAllowed(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if !v.enforce {
+		return nil
+	}
+
+	if err := v.checkSpecFile(path); err != nil {
+		v.logger.WithField(events.Field, events.CDISpecDirRejected).Errorf("Rejecting CDI spec %v: %v", path, err)
+		return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid CDI spec %v: %v", path, err))
+	}
+	return nil
+}
+
+// checkAllowed checks whether the specified spec dir is in the allowed spec dirs, if these are set.
+func (v *Validator) checkAllowed(dir string) error {
+	if len(v.allowedDirs) == 0 {
+		return nil
+	}
+	for _, allowed := range v.allowedDirs {
+		if filepath.Clean(allowed) == filepath.Clean(dir) {
+			return nil
+		}
+	}
+	v.logger.WithField(events.Field, events.CDISpecDirRejected).Errorf("CDI spec dir %v is not in the allowed spec dirs %v", dir, v.allowedDirs)
+	return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("CDI spec dir %v is not allowed", dir))
+}
+
+// checkSpecDir checks the permissions of the specified spec dir and of the spec files in it. For a spec
+// dir that does not exist, the existing parent directories are checked since a user that can create
+// the spec dir could add specifications to it.
+func (v *Validator) checkSpecDir(dir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if os.IsNotExist(err) {
+		return checkParents(dir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve: %v", err)
+	}
+	if err := v.checkPermissions(resolved, true); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(resolved)
+	if err != nil {
+		return fmt.Errorf("failed to read: %v", err)
+	}
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); ext != ".json" && ext != ".yaml" {
+			continue
+		}
+		if err := v.checkSpecFile(filepath.Join(resolved, entry.Name())); err != nil {
+			return fmt.Errorf("%v: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// checkSpecFile checks the permissions of the specified spec file. Symlinks are resolved so that the
+// target of the symlink is checked.
+func (v *Validator) checkSpecFile(path string) error {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve: %v", err)
+	}
+	return v.checkPermissions(resolved, false)
+}
+
+// checkPermissions checks that the specified path is owned by root and that its permissions do not
+// exceed the max-mode. The parent directories of the path are checked as for checkParents.
+func (v *Validator) checkPermissions(path string, isDir bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat: %v", err)
+	}
+	if isDir && !info.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	if !isDir && !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file")
+	}
+	if err := checkOwner(info); err != nil {
+		return err
+	}
+	if extra := info.Mode().Perm() &^ v.maxMode; extra != 0 {
+		return fmt.Errorf("mode %04o exceeds %04o", uint32(info.Mode().Perm()), uint32(v.maxMode))
+	}
+	return checkParents(path)
+}
+
+// checkParents checks that the existing parent directories of the specified path are owned by root
+// and are not writable by other users. A directory with the sticky bit set (e.g. /tmp) may be writable
+// by other users since these cannot rename or remove the entries of root in such a directory.
+func checkParents(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat parent directory %v: %v", dir, err)
+		}
+		if err == nil {
+			if err := checkOwner(info); err != nil {
+				return fmt.Errorf("parent directory %v is %v", dir, err)
+			}
+			if info.Mode().Perm()&0022 != 0 && info.Mode()&os.ModeSticky == 0 {
+				return fmt.Errorf("parent directory %v is writable by users other than root", dir)
+			}
+		}
+		if dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// checkOwner checks that the file with the specified info is owned by root.
+func checkOwner(info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 {
+		return fmt.Errorf("owned by uid %d instead of root", stat.Uid)
+	}
+	return nil
+}
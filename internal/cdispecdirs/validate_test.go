@@ -0,0 +1,206 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cdispecdirs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	secure := filepath.Join(dir, "secure")
+	require.NoError(t, os.Mkdir(secure, 0755))
+	require.NoError(t, os.Chmod(secure, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(secure, "nvidia.yaml"), nil, 0644))
+	writable := filepath.Join(dir, "writable")
+	require.NoError(t, os.Mkdir(writable, 0755))
+	require.NoError(t, os.Chmod(writable, 0777))
+
+	writableSpec := filepath.Join(dir, "writable-spec")
+	require.NoError(t, os.Mkdir(writableSpec, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(writableSpec, "nvidia.yaml"), nil, 0644))
+	require.NoError(t, os.Chmod(filepath.Join(writableSpec, "nvidia.yaml"), 0666))
+	// Files that are not CDI specifications are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(secure, "README"), nil, 0644))
+	require.NoError(t, os.Chmod(filepath.Join(secure, "README"), 0666))
+
+	symlinkedSpec := filepath.Join(dir, "symlinked-spec")
+	require.NoError(t, os.Mkdir(symlinkedSpec, 0755))
+	require.NoError(t, os.Symlink(filepath.Join(writableSpec, "nvidia.yaml"), filepath.Join(symlinkedSpec, "nvidia.yaml")))
+
+	insecureParent := filepath.Join(writable, "cdi")
+	require.NoError(t, os.Mkdir(insecureParent, 0755))
+
+	sticky := filepath.Join(dir, "sticky")
+	require.NoError(t, os.Mkdir(sticky, 0755))
+	require.NoError(t, os.Chmod(sticky, 0777|os.ModeSticky))
+	stickyParent := filepath.Join(sticky, "cdi")
+	require.NoError(t, os.Mkdir(stickyParent, 0755))
+
+	isRoot := os.Geteuid() == 0
+
+	testCases := []struct {
+		description     string
+		specDirs        []string
+		allowedSpecDirs []string
+		enforce         bool
+		maxMode         string
+		expectedError   bool
+	}{
+		{
+			description: "no restrictions",
+			specDirs:    []string{writable},
+		},
+		{
+			description:     "allowed spec dir",
+			specDirs:        []string{secure + "/"},
+			allowedSpecDirs: []string{secure},
+		},
+		{
+			description:     "disallowed spec dir",
+			specDirs:        []string{secure, writable},
+			allowedSpecDirs: []string{secure},
+			expectedError:   true,
+		},
+		{
+			description: "missing spec dir is ignored",
+			specDirs:    []string{filepath.Join(dir, "missing")},
+			enforce:     true,
+			maxMode:     "0755",
+		},
+		{
+			description:   "writable spec dir is rejected",
+			specDirs:      []string{writable},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: true,
+		},
+		{
+			description:   "secure spec dir with stricter max-mode",
+			specDirs:      []string{secure},
+			enforce:       true,
+			maxMode:       "0700",
+			expectedError: true,
+		},
+		{
+			description:   "writable spec file is rejected",
+			specDirs:      []string{writableSpec},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: true,
+		},
+		{
+			description:   "symlink to writable spec file is rejected",
+			specDirs:      []string{symlinkedSpec},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: true,
+		},
+		{
+			description:   "writable parent directory is rejected",
+			specDirs:      []string{insecureParent},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: true,
+		},
+		{
+			description:   "missing spec dir with writable parent directory is rejected",
+			specDirs:      []string{filepath.Join(writable, "missing")},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: true,
+		},
+		{
+			description:   "sticky parent directory",
+			specDirs:      []string{stickyParent},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: !isRoot,
+		},
+		{
+			description:   "invalid max-mode",
+			specDirs:      []string{secure},
+			enforce:       true,
+			maxMode:       "rwxr-xr-x",
+			expectedError: true,
+		},
+		{
+			description:   "secure spec dir",
+			specDirs:      []string{secure},
+			enforce:       true,
+			maxMode:       "0755",
+			expectedError: !isRoot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.AllowedSpecDirs = tc.allowedSpecDirs
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.Enforce = tc.enforce
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.MaxMode = tc.maxMode
+
+			v, err := NewValidator(logger, cfg)
+			if err == nil {
+				err = v.Validate(tc.specDirs...)
+			}
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindConfig, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "nvidia.yaml")
+	require.NoError(t, os.WriteFile(spec, nil, 0644))
+	require.NoError(t, os.Chmod(spec, 0644))
+
+	cfg := &config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.AllowedSpecDirs = []string{dir}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.Enforce = true
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.MaxMode = "0755"
+
+	v, err := NewValidator(logger, cfg)
+	require.NoError(t, err)
+
+	if os.Geteuid() == 0 {
+		require.NoError(t, v.ValidateSpec(spec))
+	}
+
+	require.NoError(t, os.Chmod(spec, 0666))
+	require.Error(t, v.ValidateSpec(spec))
+
+	other := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(other, "nvidia.yaml"), nil, 0644))
+	require.Error(t, v.ValidateSpec(filepath.Join(other, "nvidia.yaml")))
+}
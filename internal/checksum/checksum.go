@@ -0,0 +1,186 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package checksum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Manifest records the SHA256 checksums of a set of files.
+type Manifest struct {
+	Files []File `json:"files"`
+}
+
+// File represents the checksum for a single file.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+// Mismatch describes a file whose checksum does not match the manifest.
+type Mismatch struct {
+	Path     string
+	Expected string
+	Actual   string
+	Err      error
+}
+
+func (m Mismatch) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%v: %v", m.Path, m.Err)
+	}
+	return fmt.Sprintf("%v: expected sha256 %v, got %v", m.Path, m.Expected, m.Actual)
+}
+
+// New creates a manifest for the specified files. Paths that are not regular files are skipped.
+func New(paths []string) (*Manifest, error) {
+	seen := make(map[string]bool)
+	var files []File
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		sum, err := SHA256(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate checksum for %v: %v", path, err)
+		}
+		files = append(files, File{Path: path, SHA256: sum, Size: info.Size()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return &Manifest{Files: files}, nil
+}
+
+// Load loads a manifest from the specified path.
+func Load(path string) (*Manifest, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(contents, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse checksum manifest: %v", err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to the specified path.
+func (m *Manifest) Save(path string) error {
+	contents, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checksum manifest: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	return os.WriteFile(path, contents, 0644)
+}
+
+// Lookup returns the entry for the specified path.
+func (m *Manifest) Lookup(path string) (File, bool) {
+	for _, f := range m.Files {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// Verify checks the specified paths against the manifest. Paths that are not
+// included in the manifest are ignored. If no paths are specified, all files
+// in the manifest are verified.
+func (m *Manifest) Verify(paths ...string) []Mismatch {
+	return m.VerifyRoot("", paths...)
+}
+
+// VerifyRoot checks the specified paths against the manifest with the files
+// located relative to the specified root. This allows, for example, the files
+// of an unpacked image to be verified.
+func (m *Manifest) VerifyRoot(root string, paths ...string) []Mismatch {
+	if len(paths) == 0 {
+		for _, f := range m.Files {
+			paths = append(paths, f.Path)
+		}
+	}
+
+	var mismatches []Mismatch
+	for _, path := range paths {
+		expected, ok := m.Lookup(path)
+		if !ok {
+			continue
+		}
+		if mismatch := expected.verify(filepath.Join("/", root, path)); mismatch != nil {
+			mismatches = append(mismatches, *mismatch)
+		}
+	}
+	return mismatches
+}
+
+// verify checks the file at the specified location against the expected size and checksum.
+func (f File) verify(location string) *Mismatch {
+	if f.Size != 0 {
+		info, err := os.Stat(location)
+		if err != nil {
+			return &Mismatch{Path: f.Path, Expected: f.SHA256, Err: err}
+		}
+		if info.Size() != f.Size {
+			return &Mismatch{Path: f.Path, Expected: f.SHA256, Err: fmt.Errorf("expected size %d, got %d", f.Size, info.Size())}
+		}
+	}
+
+	actual, err := SHA256(location)
+	if err != nil {
+		return &Mismatch{Path: f.Path, Expected: f.SHA256, Err: err}
+	}
+	if actual != f.SHA256 {
+		return &Mismatch{Path: f.Path, Expected: f.SHA256, Actual: actual}
+	}
+	return nil
+}
+
+// SHA256 returns the hex-encoded SHA256 checksum of the specified file.
+func SHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	libcuda := filepath.Join(dir, "libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+	nvidiaSMI := filepath.Join(dir, "nvidia-smi")
+	require.NoError(t, os.WriteFile(nvidiaSMI, []byte("nvidia-smi"), 0755))
+
+	m, err := New([]string{nvidiaSMI, libcuda, dir, filepath.Join(dir, "missing"), libcuda})
+	require.NoError(t, err)
+	require.Len(t, m.Files, 2)
+	require.Equal(t, libcuda, m.Files[0].Path)
+	require.Equal(t, nvidiaSMI, m.Files[1].Path)
+
+	manifestPath := filepath.Join(dir, "manifests", "checksums.json")
+	require.NoError(t, m.Save(manifestPath))
+
+	loaded, err := Load(manifestPath)
+	require.NoError(t, err)
+	require.EqualValues(t, m, loaded)
+
+	require.Empty(t, loaded.Verify())
+	require.Empty(t, loaded.Verify("/not/in/manifest"))
+
+	require.NoError(t, os.WriteFile(libcuda, []byte("LIBCUDA"), 0644))
+	require.NoError(t, os.Remove(nvidiaSMI))
+
+	mismatches := loaded.Verify()
+	require.Len(t, mismatches, 2)
+	require.Equal(t, libcuda, mismatches[0].Path)
+	require.NotEmpty(t, mismatches[0].Actual)
+	require.NoError(t, mismatches[0].Err)
+	require.Equal(t, nvidiaSMI, mismatches[1].Path)
+	require.Error(t, mismatches[1].Err)
+
+	require.Len(t, loaded.Verify(libcuda), 1)
+
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda-tampered"), 0644))
+	mismatches = loaded.Verify(libcuda)
+	require.Len(t, mismatches, 1)
+	require.Error(t, mismatches[0].Err)
+	require.Contains(t, mismatches[0].Err.Error(), "expected size")
+}
+
+func TestVerifyRoot(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "usr/lib64"), 0755))
+	libcuda := filepath.Join(root, "usr/lib64/libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	sum, err := SHA256(libcuda)
+	require.NoError(t, err)
+
+	m := &Manifest{
+		Files: []File{
+			{Path: "/usr/lib64/libcuda.so.999.99", SHA256: sum, Size: 7},
+			{Path: "/usr/bin/nvidia-smi", SHA256: sum, Size: 7},
+		},
+	}
+
+	mismatches := m.VerifyRoot(root)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, "/usr/bin/nvidia-smi", mismatches[0].Path)
+	require.True(t, os.IsNotExist(mismatches[0].Err))
+}
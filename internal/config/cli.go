@@ -22,20 +22,24 @@ import (
 
 // ContainerCLIConfig stores the options for the nvidia-container-cli
 type ContainerCLIConfig struct {
-	Root string
+	Root string `toml:"root"`
 }
 
 // getContainerCLIConfigFrom reads the nvidia container runtime config from the specified toml Tree.
-func getContainerCLIConfigFrom(toml *toml.Tree) *ContainerCLIConfig {
+func getContainerCLIConfigFrom(toml *toml.Tree) (*ContainerCLIConfig, error) {
 	cfg := getDefaultContainerCLIConfig()
 
 	if toml == nil {
-		return cfg
+		return cfg, nil
 	}
 
-	cfg.Root = toml.GetDefault("nvidia-container-cli.root", cfg.Root).(string)
+	var err error
+	cfg.Root, err = getString(toml, "nvidia-container-cli.root", cfg.Root)
+	if err != nil {
+		return nil, err
+	}
 
-	return cfg
+	return cfg, nil
 }
 
 // getDefaultContainerCLIConfig defines the default values for the config
@@ -22,6 +22,8 @@ import (
 	"os"
 	"path"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
 	"github.com/pelletier/go-toml"
 )
 
@@ -51,24 +53,37 @@ type Config struct {
 	NVIDIACTKConfig                  CTKConfig          `toml:"nvidia-ctk"`
 	NVIDIAContainerRuntimeConfig     RuntimeConfig      `toml:"nvidia-container-runtime"`
 	NVIDIAContainerRuntimeHookConfig RuntimeHookConfig  `toml:"nvidia-container-runtime-hook"`
+	DebugConfig                      DebugConfig        `toml:"debug"`
+	// Devices defines the extra mounts and environment variables that are added when a
+	// device is injected. These are keyed by the device UUID.
+	Devices map[string]DeviceConfig `toml:"devices"`
+	// Features enables or disables features by name. Features that are not set use their default.
+	Features map[string]bool `toml:"features"`
+
+	// deprecations are the deprecated features used in the config.
+	deprecations []deprecation.ID
+}
+
+// deprecatedOptions maps deprecated config options to the associated deprecated feature.
+var deprecatedOptions = map[string]deprecation.ID{
+	"nvidia-container-runtime.experimental": deprecation.RuntimeExperimentalConfig,
 }
 
 // GetConfig sets up the config struct. Values are read from a toml file
 // or set via the environment.
 func GetConfig() (*Config, error) {
-	if XDGConfigDir := os.Getenv(configOverride); len(XDGConfigDir) != 0 {
-		configDir = XDGConfigDir
-	}
+	configFilePath := GetConfigFilePath()
 
-	configFilePath := path.Join(configDir, configFilePath)
+	if _, err := os.Stat(configFilePath); err != nil {
+		return getDefaultConfig(), nil
+	}
 
-	tomlFile, err := os.Open(configFilePath)
+	toml, err := LoadTOMLFile(configFilePath)
 	if err != nil {
-		return getDefaultConfig(), nil
+		return nil, fmt.Errorf("failed to read config values: %v", err)
 	}
-	defer tomlFile.Close()
 
-	cfg, err := loadConfigFrom(tomlFile)
+	cfg, err := getConfigFrom(toml)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config values: %v", err)
 	}
@@ -76,12 +91,28 @@ func GetConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// GetConfigFilePath returns the path of the config file. This is config.toml in the
+// nvidia-container-runtime folder of XDG_CONFIG_HOME if set and /etc otherwise.
+func GetConfigFilePath() string {
+	if XDGConfigDir := os.Getenv(configOverride); len(XDGConfigDir) != 0 {
+		configDir = XDGConfigDir
+	}
+
+	return path.Join(configDir, configFilePath)
+}
+
 // loadRuntimeConfigFrom reads the config from the specified Reader
+// Since the location of the config is not known, relative paths are not resolved.
 func loadConfigFrom(reader io.Reader) (*Config, error) {
-	toml, err := toml.LoadReader(reader)
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	toml, err := loadTOML(contents)
 	if err != nil {
 		return nil, err
 	}
+	newExpander("").expandTree(toml)
 
 	return getConfigFrom(toml)
 }
@@ -94,10 +125,30 @@ func getConfigFrom(toml *toml.Tree) (*Config, error) {
 		return cfg, nil
 	}
 
-	cfg.AcceptEnvvarUnprivileged = toml.GetDefault("accept-nvidia-visible-devices-envvar-when-unprivileged", cfg.AcceptEnvvarUnprivileged).(bool)
+	acceptEnvvarUnprivileged, err := getBool(toml, "accept-nvidia-visible-devices-envvar-when-unprivileged", cfg.AcceptEnvvarUnprivileged)
+	if err != nil {
+		return nil, err
+	}
+	cfg.AcceptEnvvarUnprivileged = acceptEnvvarUnprivileged
+
+	containerCLIConfig, err := getContainerCLIConfigFrom(toml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nvidia-container-cli config: %v", err)
+	}
+	cfg.NVIDIAContainerCLIConfig = *containerCLIConfig
+
+	ctkConfig, err := getCTKConfigFrom(toml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nvidia-ctk config: %v", err)
+	}
+	cfg.NVIDIACTKConfig = *ctkConfig
+
+	debugConfig, err := getDebugConfigFrom(toml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load debug config: %v", err)
+	}
+	cfg.DebugConfig = *debugConfig
 
-	cfg.NVIDIAContainerCLIConfig = *getContainerCLIConfigFrom(toml)
-	cfg.NVIDIACTKConfig = *getCTKConfigFrom(toml)
 	runtimeConfig, err := getRuntimeConfigFrom(toml)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load nvidia-container-runtime config: %v", err)
@@ -110,9 +161,37 @@ func getConfigFrom(toml *toml.Tree) (*Config, error) {
 	}
 	cfg.NVIDIAContainerRuntimeHookConfig = *runtimeHookConfig
 
+	devices, err := getDevicesConfigFrom(toml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices config: %v", err)
+	}
+	cfg.Devices = devices
+
+	featuresConfig, err := getFeaturesConfigFrom(toml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load features config: %v", err)
+	}
+	cfg.Features = featuresConfig
+
+	for option, id := range deprecatedOptions {
+		if toml.Has(option) {
+			cfg.deprecations = append(cfg.deprecations, id)
+		}
+	}
+
 	return cfg, nil
 }
 
+// Deprecations returns the deprecated features that are used in the config.
+func (c *Config) Deprecations() []deprecation.ID {
+	return c.deprecations
+}
+
+// FeatureGates returns the feature gates defined by the features config.
+func (c *Config) FeatureGates() (*features.Gates, error) {
+	return features.New(c.Features)
+}
+
 // getDefaultConfig defines the default values for the config
 func getDefaultConfig() *Config {
 	c := Config{
@@ -120,6 +199,7 @@ func getDefaultConfig() *Config {
 		NVIDIAContainerCLIConfig:     *getDefaultContainerCLIConfig(),
 		NVIDIACTKConfig:              *getDefaultCTKConfig(),
 		NVIDIAContainerRuntimeConfig: *GetDefaultRuntimeConfig(),
+		DebugConfig:                  *getDefaultDebugConfig(),
 	}
 
 	return &c
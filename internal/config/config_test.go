@@ -17,12 +17,14 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,17 +68,59 @@ func TestGetConfig(t *testing.T) {
 					LogLevel:      "info",
 					Runtimes:      []string{"docker-runc", "runc"},
 					Mode:          "auto",
+					MountStrategy: "individual",
+					ErrorFormat:   "text",
+					ChecksumVerification: checksumVerificationConfig{
+						Policy: "warn",
+					},
+					IMEX: imexConfig{
+						ConfigDir: "/etc/nvidia-imex",
+					},
+					WorkloadTuning: workloadTuningConfig{
+						Memlock: "unlimited",
+					},
+					ImageLabels: imageLabelsConfig{
+						Precedence: "envvar",
+					},
+					EnvPolicy: envPolicyConfig{
+						NVIDIA:      "override",
+						SearchPaths: "append",
+						Other:       "override",
+					},
+					DriverRootMount: driverRootMountConfig{
+						StagingDir:    "/run/nvidia-container-toolkit/driver-root",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					DriverCopy: driverCopyConfig{
+						Dir:           "/run/nvidia-container-toolkit/driver-copy",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					StagedDriverRoot: "/run/nvidia/driver-stage",
 					Modes: modesConfig{
 						CSV: csvModeConfig{
 							MountSpecPath: "/etc/nvidia-container-runtime/host-files-for-container.d",
 						},
 						CDI: cdiModeConfig{
-							DefaultKind: "nvidia.com/gpu",
+							DefaultKind: cdiKinds{"nvidia.com/gpu"},
+							SpecDirPermissions: specDirPermissionsConfig{
+								MaxMode: "0755",
+							},
+							DeviceWait: deviceWaitConfig{
+								Interval: "250ms",
+							},
 						},
 					},
 				},
 				NVIDIACTKConfig: CTKConfig{
 					Path: "nvidia-ctk",
+					Hooks: hookConfig{
+						Capabilities:  []string{"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_FOWNER", "CAP_SYS_CHROOT"},
+						FailurePolicy: "fail-closed",
+					},
+				},
+				DebugConfig: DebugConfig{
+					BundleDir: "/var/log/nvidia-container-toolkit/bundles",
+					RedactEnv: []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*KEY*", "*CREDENTIAL*"},
 				},
 			},
 		},
@@ -91,9 +135,80 @@ func TestGetConfig(t *testing.T) {
 				"nvidia-container-runtime.log-level = \"debug\"",
 				"nvidia-container-runtime.runtimes = [\"/some/runtime\",]",
 				"nvidia-container-runtime.mode = \"not-auto\"",
+				"nvidia-container-runtime.mount-strategy = \"driver-root\"",
+				"nvidia-container-runtime.driver-copy.dir = \"/foo/driver-copy\"",
+				"nvidia-container-runtime.error-format = \"json\"",
+				"nvidia-container-runtime.modification-timeout = \"30s\"",
+				"nvidia-container-runtime.request-report.enabled = true",
+				"nvidia-container-runtime.request-report.metrics-file = \"/foo/metrics.prom\"",
+				"nvidia-container-runtime.request-report.translate-legacy = true",
+				"nvidia-container-runtime.startup-latency.enabled = true",
+				"nvidia-container-runtime.startup-latency.state-file = \"/foo/startup-latency.jsonl\"",
+				"nvidia-container-runtime.device-state.state-file = \"/foo/device-state.json\"",
+				"nvidia-container-runtime.allocations.enabled = true",
+				"nvidia-container-runtime.allocations.state-dir = \"/foo/allocations\"",
+				"nvidia-container-runtime.nvml-throttle.max-concurrent = 4",
+				"nvidia-container-runtime.nvml-throttle.lock-dir = \"/foo/nvml-throttle\"",
+				"nvidia-container-runtime.nvml-throttle.timeout = \"30s\"",
+				"nvidia-container-runtime.foreign-architecture.driver-roots = { arm64 = \"/opt/nvidia/arm64\" }",
+				"nvidia-container-runtime.foreign-architecture.cdi-spec-dirs = { arm64 = \"/etc/cdi/arm64\" }",
+				"nvidia-container-runtime.workload-tuning.enabled = true",
+				"nvidia-container-runtime.workload-tuning.memlock = \"1073741824\"",
+				"nvidia-container-runtime.workload-tuning.sysctls = { \"net.ipv4.tcp_rmem\" = \"4096 87380 16777216\" }",
+				"nvidia-container-runtime.device-limits.max-devices-per-container = 2",
+				"nvidia-container-runtime.device-limits.namespaces = { training = 8 }",
+				"nvidia-container-runtime.device-limits.annotations = { \"example.com/tier=large\" = 4 }",
+				"nvidia-container-runtime.gpu-sharing.enabled = true",
+				"nvidia-container-runtime.env-policy.nvidia = \"keep\"",
+				"nvidia-container-runtime.env-policy.search-paths = \"prepend\"",
+				"nvidia-container-runtime.env-policy.other = \"keep\"",
+				"nvidia-container-runtime.env-policy.variables = { LD_PRELOAD = \"override\" }",
+				"nvidia-container-runtime.driver-binaries.deny = [\"nvidia-smi\"]",
+				"nvidia-container-runtime.read-only-injection = true",
+				"nvidia-container-runtime.id-mapped-mounts = true",
+				"nvidia-container-runtime.library-prefix = \"/usr/lib/nvidia-host\"",
+				"nvidia-container-runtime.hook-ordering.position = \"last\"",
+				"nvidia-container-runtime.driver-roots = [{path = \"/run/nvidia/driver\", priority = 10}, {path = \"/\"}]",
+				"nvidia-container-runtime.hook-ordering.deduplicate = true",
+				"nvidia-container-runtime.hooks.disable = [\"create-symlinks\"]",
+				"nvidia-container-runtime.hooks.disable-for-mode = { csv = [\"update-ldcache\"] }",
+				"nvidia-container-runtime.imex.config-dir = \"/foo/imex\"",
+				"nvidia-container-runtime.imex.domain = \"nvl72-a\"",
+				"nvidia-container-runtime.image-labels.enabled = true",
+				"nvidia-container-runtime.image-labels.precedence = \"label\"",
+				"nvidia-container-runtime.policy.forbidden-capabilities = [\"display\"]",
+				"nvidia-container-runtime.policy.allowed-devices = [\"GPU-*\"]",
+				"nvidia-container-runtime.policy.driver-version = \"525.85.12\"",
+				"nvidia-container-runtime.policy.cuda-version = \"12.0\"",
+				"nvidia-container-runtime.checksum-verification.manifest = \"/foo/checksums.json\"",
+				"nvidia-container-runtime.checksum-verification.policy = \"fail\"",
 				"nvidia-container-runtime.modes.cdi.default-kind = \"example.vendor.com/device\"",
+				"nvidia-container-runtime.modes.cdi.allowed-spec-dirs = [\"/etc/cdi\"]",
+				"nvidia-container-runtime.modes.cdi.spec-dir-permissions.enforce = true",
+				"nvidia-container-runtime.modes.cdi.spec-dir-permissions.max-mode = \"0750\"",
+				"nvidia-container-runtime.modes.cdi.device-wait.timeout = \"30s\"",
+				"nvidia-container-runtime.modes.cdi.device-wait.interval = \"1s\"",
+				"nvidia-container-runtime.modes.cdi.index-file = \"/foo/cdi-index.json\"",
+				"nvidia-container-runtime.modes.cdi.strict = true",
+				"nvidia-container-runtime.modes.cdi.registry-socket = \"/foo/cdi-registry.sock\"",
+				"nvidia-container-runtime.modes.cdi.override-dirs = [\"/etc/cdi-overrides/site\"]",
+				"nvidia-container-runtime.modes.cdi.tenant-override-dirs = { training = [\"/etc/cdi-overrides/training\"] }",
 				"nvidia-container-runtime.modes.csv.mount-spec-path = \"/not/etc/nvidia-container-runtime/host-files-for-container.d\"",
+				"nvidia-container-runtime.modes.legacy.cuda-compat-mode = \"ldconfig\"",
+				"nvidia-container-runtime.modes.legacy.allowed-capabilities = [\"compute\", \"utility\"]",
+				"nvidia-container-runtime.modes.legacy.ldconfig-strategy = \"container\"",
 				"nvidia-ctk.path = \"/foo/bar/nvidia-ctk\"",
+				"nvidia-ctk.hooks.user = \"65534:65534\"",
+				"nvidia-ctk.hooks.capabilities = [\"CAP_DAC_OVERRIDE\"]",
+				"nvidia-ctk.hooks.timeout = \"30s\"",
+				"nvidia-ctk.hooks.failure-policy = \"fail-open\"",
+				"debug.capture-bundle = true",
+				"debug.bundle-dir = \"/foo/bundles\"",
+				"debug.redact-env = [\"NGC_*\"]",
+				"debug.spec-dump-dir = \"/foo/specs\"",
+				"devices.GPU-0.env = [\"LICENSE=/licenses/gpu0\"]",
+				"devices.GPU-0.extra-mounts = [{host-path = \"/etc/licenses/gpu0\", container-path = \"/licenses/gpu0\"}]",
+				"features.hookless-cdi = true",
 			},
 			expectedConfig: &Config{
 				AcceptEnvvarUnprivileged: false,
@@ -101,22 +216,164 @@ func TestGetConfig(t *testing.T) {
 					Root: "/bar/baz",
 				},
 				NVIDIAContainerRuntimeConfig: RuntimeConfig{
-					DebugFilePath: "/foo/bar",
-					LogLevel:      "debug",
-					Runtimes:      []string{"/some/runtime"},
-					Mode:          "not-auto",
+					DebugFilePath:       "/foo/bar",
+					LogLevel:            "debug",
+					Runtimes:            []string{"/some/runtime"},
+					Mode:                "not-auto",
+					MountStrategy:       "driver-root",
+					ErrorFormat:         "json",
+					ModificationTimeout: "30s",
+					ReadOnlyInjection:   true,
+					IDMappedMounts:      true,
+					LibraryPrefix:       "/usr/lib/nvidia-host",
+					HookOrdering: hookOrderingConfig{
+						Position:    "last",
+						Deduplicate: true,
+					},
+					Hooks: hooksConfig{
+						Disable:        []string{"create-symlinks"},
+						DisableForMode: map[string][]string{"csv": {"update-ldcache"}},
+					},
+					ChecksumVerification: checksumVerificationConfig{
+						Manifest: "/foo/checksums.json",
+						Policy:   "fail",
+					},
+					IMEX: imexConfig{
+						ConfigDir: "/foo/imex",
+						Domain:    "nvl72-a",
+					},
+					ImageLabels: imageLabelsConfig{
+						Enabled:    true,
+						Precedence: "label",
+					},
+					Policy: policyConfig{
+						ForbiddenCapabilities: []string{"display"},
+						AllowedDevices:        []string{"GPU-*"},
+						DriverVersion:         "525.85.12",
+						CUDAVersion:           "12.0",
+					},
+					DriverRootMount: driverRootMountConfig{
+						StagingDir:    "/run/nvidia-container-toolkit/driver-root",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					DriverCopy: driverCopyConfig{
+						Dir:           "/foo/driver-copy",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					StagedDriverRoot: "/run/nvidia/driver-stage",
+					DriverRoots: []DriverRoot{
+						{Path: "/run/nvidia/driver", Priority: 10},
+						{Path: "/"},
+					},
+					RequestReport: requestReportConfig{
+						Enabled:         true,
+						MetricsFile:     "/foo/metrics.prom",
+						TranslateLegacy: true,
+					},
+					StartupLatency: startupLatencyConfig{
+						Enabled:   true,
+						StateFile: "/foo/startup-latency.jsonl",
+					},
+					DeviceState: deviceStateConfig{
+						StateFile: "/foo/device-state.json",
+					},
+					Allocations: allocationsConfig{
+						Enabled:  true,
+						StateDir: "/foo/allocations",
+					},
+					NVMLThrottle: nvmlThrottleConfig{
+						MaxConcurrent: 4,
+						LockDir:       "/foo/nvml-throttle",
+						Timeout:       "30s",
+					},
+					ForeignArchitecture: foreignArchitectureConfig{
+						DriverRoots: map[string]string{"arm64": "/opt/nvidia/arm64"},
+						CDISpecDirs: map[string]string{"arm64": "/etc/cdi/arm64"},
+					},
+					WorkloadTuning: workloadTuningConfig{
+						Enabled: true,
+						Memlock: "1073741824",
+						Sysctls: map[string]string{
+							"net.ipv4.tcp_rmem": "4096 87380 16777216",
+						},
+					},
+					DeviceLimits: deviceLimitsConfig{
+						MaxDevicesPerContainer: 2,
+						Namespaces:             map[string]int{"training": 8},
+						Annotations:            map[string]int{"example.com/tier=large": 4},
+					},
+					GPUSharing: gpuSharingConfig{
+						Enabled: true,
+					},
+					EnvPolicy: envPolicyConfig{
+						NVIDIA:      "keep",
+						SearchPaths: "prepend",
+						Other:       "keep",
+						Variables:   map[string]string{"LD_PRELOAD": "override"},
+					},
+					DriverBinaries: driverBinariesConfig{
+						Deny: []string{"nvidia-smi"},
+					},
 					Modes: modesConfig{
 						CSV: csvModeConfig{
 							MountSpecPath: "/not/etc/nvidia-container-runtime/host-files-for-container.d",
 						},
 						CDI: cdiModeConfig{
-							DefaultKind: "example.vendor.com/device",
+							DefaultKind:     cdiKinds{"example.vendor.com/device"},
+							AllowedSpecDirs: []string{"/etc/cdi"},
+							IndexFile:       "/foo/cdi-index.json",
+							Strict:          true,
+							RegistrySocket:  "/foo/cdi-registry.sock",
+							OverrideDirs:    []string{"/etc/cdi-overrides/site"},
+							TenantOverrideDirs: map[string][]string{
+								"training": {"/etc/cdi-overrides/training"},
+							},
+							SpecDirPermissions: specDirPermissionsConfig{
+								Enforce: true,
+								MaxMode: "0750",
+							},
+							DeviceWait: deviceWaitConfig{
+								Timeout:  "30s",
+								Interval: "1s",
+							},
+						},
+						Legacy: legacyModeConfig{
+							CUDACompatMode:      "ldconfig",
+							AllowedCapabilities: []string{"compute", "utility"},
+							LdconfigStrategy:    "container",
 						},
 					},
 				},
 				NVIDIACTKConfig: CTKConfig{
 					Path: "/foo/bar/nvidia-ctk",
+					Hooks: hookConfig{
+						User:          "65534:65534",
+						Capabilities:  []string{"CAP_DAC_OVERRIDE"},
+						Timeout:       "30s",
+						FailurePolicy: "fail-open",
+					},
+				},
+				DebugConfig: DebugConfig{
+					CaptureBundle: true,
+					BundleDir:     "/foo/bundles",
+					RedactEnv:     []string{"NGC_*"},
+					SpecDumpDir:   "/foo/specs",
+				},
+				Devices: map[string]DeviceConfig{
+					"GPU-0": {
+						Env: []string{"LICENSE=/licenses/gpu0"},
+						ExtraMounts: []DeviceMount{
+							{
+								HostPath:      "/etc/licenses/gpu0",
+								ContainerPath: "/licenses/gpu0",
+							},
+						},
+					},
+				},
+				Features: map[string]bool{
+					"hookless-cdi": true,
 				},
+				deprecations: []deprecation.ID{deprecation.RuntimeExperimentalConfig},
 			},
 		},
 		{
@@ -132,12 +389,122 @@ func TestGetConfig(t *testing.T) {
 				"log-level = \"debug\"",
 				"runtimes = [\"/some/runtime\",]",
 				"mode = \"not-auto\"",
+				"mount-strategy = \"driver-root\"",
+				"error-format = \"json\"",
+				"modification-timeout = \"30s\"",
+				"read-only-injection = true",
+				"id-mapped-mounts = true",
+				"library-prefix = \"/usr/lib/nvidia-host\"",
+				"[[nvidia-container-runtime.driver-roots]]",
+				"path = \"/run/nvidia/driver\"",
+				"priority = 10",
+				"[[nvidia-container-runtime.driver-roots]]",
+				"path = \"/\"",
+				"[nvidia-container-runtime.driver-copy]",
+				"dir = \"/foo/driver-copy\"",
+				"[nvidia-container-runtime.hook-ordering]",
+				"position = \"last\"",
+				"deduplicate = true",
+				"[nvidia-container-runtime.hooks]",
+				"disable = [\"create-symlinks\"]",
+				"[nvidia-container-runtime.hooks.disable-for-mode]",
+				"csv = [\"update-ldcache\"]",
+				"[nvidia-container-runtime.request-report]",
+				"enabled = true",
+				"metrics-file = \"/foo/metrics.prom\"",
+				"translate-legacy = true",
+				"[nvidia-container-runtime.startup-latency]",
+				"enabled = true",
+				"state-file = \"/foo/startup-latency.jsonl\"",
+				"[nvidia-container-runtime.device-state]",
+				"state-file = \"/foo/device-state.json\"",
+				"[nvidia-container-runtime.allocations]",
+				"enabled = true",
+				"state-dir = \"/foo/allocations\"",
+				"[nvidia-container-runtime.nvml-throttle]",
+				"max-concurrent = 4",
+				"lock-dir = \"/foo/nvml-throttle\"",
+				"timeout = \"30s\"",
+				"[nvidia-container-runtime.foreign-architecture.driver-roots]",
+				"arm64 = \"/opt/nvidia/arm64\"",
+				"[nvidia-container-runtime.foreign-architecture.cdi-spec-dirs]",
+				"arm64 = \"/etc/cdi/arm64\"",
+				"[nvidia-container-runtime.workload-tuning]",
+				"enabled = true",
+				"memlock = \"1073741824\"",
+				"[nvidia-container-runtime.workload-tuning.sysctls]",
+				"\"net.ipv4.tcp_rmem\" = \"4096 87380 16777216\"",
+				"[nvidia-container-runtime.device-limits]",
+				"max-devices-per-container = 2",
+				"[nvidia-container-runtime.device-limits.namespaces]",
+				"training = 8",
+				"[nvidia-container-runtime.device-limits.annotations]",
+				"\"example.com/tier=large\" = 4",
+				"[nvidia-container-runtime.gpu-sharing]",
+				"enabled = true",
+				"[nvidia-container-runtime.env-policy]",
+				"nvidia = \"keep\"",
+				"search-paths = \"prepend\"",
+				"other = \"keep\"",
+				"[nvidia-container-runtime.env-policy.variables]",
+				"LD_PRELOAD = \"override\"",
+				"[nvidia-container-runtime.imex]",
+				"config-dir = \"/foo/imex\"",
+				"domain = \"nvl72-a\"",
+				"[nvidia-container-runtime.image-labels]",
+				"enabled = true",
+				"precedence = \"label\"",
+				"[nvidia-container-runtime.policy]",
+				"forbidden-capabilities = [\"display\"]",
+				"allowed-devices = [\"GPU-*\"]",
+				"driver-version = \"525.85.12\"",
+				"cuda-version = \"12.0\"",
+				"[nvidia-container-runtime.checksum-verification]",
+				"manifest = \"/foo/checksums.json\"",
+				"policy = \"fail\"",
+				"[nvidia-container-runtime.driver-binaries]",
+				"allow = [\"nvidia-smi\", \"nvidia-debugdump\"]",
+				"deny = [\"nvidia-smi\"]",
 				"[nvidia-container-runtime.modes.cdi]",
 				"default-kind = \"example.vendor.com/device\"",
+				"allowed-spec-dirs = [\"/etc/cdi\"]",
+				"index-file = \"/foo/cdi-index.json\"",
+				"strict = true",
+				"registry-socket = \"/foo/cdi-registry.sock\"",
+				"override-dirs = [\"/etc/cdi-overrides/site\"]",
+				"[nvidia-container-runtime.modes.cdi.tenant-override-dirs]",
+				"training = [\"/etc/cdi-overrides/training\"]",
+				"[nvidia-container-runtime.modes.cdi.spec-dir-permissions]",
+				"enforce = true",
+				"max-mode = \"0750\"",
+				"[nvidia-container-runtime.modes.cdi.device-wait]",
+				"timeout = \"30s\"",
+				"interval = \"1s\"",
 				"[nvidia-container-runtime.modes.csv]",
 				"mount-spec-path = \"/not/etc/nvidia-container-runtime/host-files-for-container.d\"",
+				"[nvidia-container-runtime.modes.legacy]",
+				"cuda-compat-mode = \"ldconfig\"",
+				"allowed-capabilities = [\"compute\", \"utility\"]",
+				"ldconfig-strategy = \"container\"",
 				"[nvidia-ctk]",
 				"path = \"/foo/bar/nvidia-ctk\"",
+				"[nvidia-ctk.hooks]",
+				"user = \"65534:65534\"",
+				"capabilities = [\"CAP_DAC_OVERRIDE\"]",
+				"timeout = \"30s\"",
+				"failure-policy = \"fail-open\"",
+				"[debug]",
+				"capture-bundle = true",
+				"bundle-dir = \"/foo/bundles\"",
+				"redact-env = [\"NGC_*\"]",
+				"spec-dump-dir = \"/foo/specs\"",
+				"[devices.GPU-0]",
+				"env = [\"LICENSE=/licenses/gpu0\"]",
+				"[[devices.GPU-0.extra-mounts]]",
+				"host-path = \"/etc/licenses/gpu0\"",
+				"container-path = \"/licenses/gpu0\"",
+				"[features]",
+				"hookless-cdi = true",
 			},
 			expectedConfig: &Config{
 				AcceptEnvvarUnprivileged: false,
@@ -145,23 +512,174 @@ func TestGetConfig(t *testing.T) {
 					Root: "/bar/baz",
 				},
 				NVIDIAContainerRuntimeConfig: RuntimeConfig{
-					DebugFilePath: "/foo/bar",
-					LogLevel:      "debug",
-					Runtimes:      []string{"/some/runtime"},
-					Mode:          "not-auto",
+					DebugFilePath:       "/foo/bar",
+					LogLevel:            "debug",
+					Runtimes:            []string{"/some/runtime"},
+					Mode:                "not-auto",
+					MountStrategy:       "driver-root",
+					ErrorFormat:         "json",
+					ModificationTimeout: "30s",
+					ReadOnlyInjection:   true,
+					IDMappedMounts:      true,
+					LibraryPrefix:       "/usr/lib/nvidia-host",
+					HookOrdering: hookOrderingConfig{
+						Position:    "last",
+						Deduplicate: true,
+					},
+					Hooks: hooksConfig{
+						Disable:        []string{"create-symlinks"},
+						DisableForMode: map[string][]string{"csv": {"update-ldcache"}},
+					},
+					ChecksumVerification: checksumVerificationConfig{
+						Manifest: "/foo/checksums.json",
+						Policy:   "fail",
+					},
+					IMEX: imexConfig{
+						ConfigDir: "/foo/imex",
+						Domain:    "nvl72-a",
+					},
+					ImageLabels: imageLabelsConfig{
+						Enabled:    true,
+						Precedence: "label",
+					},
+					Policy: policyConfig{
+						ForbiddenCapabilities: []string{"display"},
+						AllowedDevices:        []string{"GPU-*"},
+						DriverVersion:         "525.85.12",
+						CUDAVersion:           "12.0",
+					},
+					DriverRootMount: driverRootMountConfig{
+						StagingDir:    "/run/nvidia-container-toolkit/driver-root",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					DriverCopy: driverCopyConfig{
+						Dir:           "/foo/driver-copy",
+						ContainerPath: "/usr/local/nvidia",
+					},
+					StagedDriverRoot: "/run/nvidia/driver-stage",
+					DriverRoots: []DriverRoot{
+						{Path: "/run/nvidia/driver", Priority: 10},
+						{Path: "/"},
+					},
+					RequestReport: requestReportConfig{
+						Enabled:         true,
+						MetricsFile:     "/foo/metrics.prom",
+						TranslateLegacy: true,
+					},
+					StartupLatency: startupLatencyConfig{
+						Enabled:   true,
+						StateFile: "/foo/startup-latency.jsonl",
+					},
+					DeviceState: deviceStateConfig{
+						StateFile: "/foo/device-state.json",
+					},
+					Allocations: allocationsConfig{
+						Enabled:  true,
+						StateDir: "/foo/allocations",
+					},
+					NVMLThrottle: nvmlThrottleConfig{
+						MaxConcurrent: 4,
+						LockDir:       "/foo/nvml-throttle",
+						Timeout:       "30s",
+					},
+					ForeignArchitecture: foreignArchitectureConfig{
+						DriverRoots: map[string]string{"arm64": "/opt/nvidia/arm64"},
+						CDISpecDirs: map[string]string{"arm64": "/etc/cdi/arm64"},
+					},
+					WorkloadTuning: workloadTuningConfig{
+						Enabled: true,
+						Memlock: "1073741824",
+						Sysctls: map[string]string{
+							"net.ipv4.tcp_rmem": "4096 87380 16777216",
+						},
+					},
+					DeviceLimits: deviceLimitsConfig{
+						MaxDevicesPerContainer: 2,
+						Namespaces:             map[string]int{"training": 8},
+						Annotations:            map[string]int{"example.com/tier=large": 4},
+					},
+					GPUSharing: gpuSharingConfig{
+						Enabled: true,
+					},
+					EnvPolicy: envPolicyConfig{
+						NVIDIA:      "keep",
+						SearchPaths: "prepend",
+						Other:       "keep",
+						Variables:   map[string]string{"LD_PRELOAD": "override"},
+					},
+					DriverBinaries: driverBinariesConfig{
+						Allow: []string{"nvidia-smi", "nvidia-debugdump"},
+						Deny:  []string{"nvidia-smi"},
+					},
 					Modes: modesConfig{
 						CSV: csvModeConfig{
 							MountSpecPath: "/not/etc/nvidia-container-runtime/host-files-for-container.d",
 						},
 						CDI: cdiModeConfig{
-							DefaultKind: "example.vendor.com/device",
+							DefaultKind:     cdiKinds{"example.vendor.com/device"},
+							AllowedSpecDirs: []string{"/etc/cdi"},
+							IndexFile:       "/foo/cdi-index.json",
+							Strict:          true,
+							RegistrySocket:  "/foo/cdi-registry.sock",
+							OverrideDirs:    []string{"/etc/cdi-overrides/site"},
+							TenantOverrideDirs: map[string][]string{
+								"training": {"/etc/cdi-overrides/training"},
+							},
+							SpecDirPermissions: specDirPermissionsConfig{
+								Enforce: true,
+								MaxMode: "0750",
+							},
+							DeviceWait: deviceWaitConfig{
+								Timeout:  "30s",
+								Interval: "1s",
+							},
+						},
+						Legacy: legacyModeConfig{
+							CUDACompatMode:      "ldconfig",
+							AllowedCapabilities: []string{"compute", "utility"},
+							LdconfigStrategy:    "container",
 						},
 					},
 				},
 				NVIDIACTKConfig: CTKConfig{
 					Path: "/foo/bar/nvidia-ctk",
+					Hooks: hookConfig{
+						User:          "65534:65534",
+						Capabilities:  []string{"CAP_DAC_OVERRIDE"},
+						Timeout:       "30s",
+						FailurePolicy: "fail-open",
+					},
+				},
+				DebugConfig: DebugConfig{
+					CaptureBundle: true,
+					BundleDir:     "/foo/bundles",
+					RedactEnv:     []string{"NGC_*"},
+					SpecDumpDir:   "/foo/specs",
 				},
+				Devices: map[string]DeviceConfig{
+					"GPU-0": {
+						Env: []string{"LICENSE=/licenses/gpu0"},
+						ExtraMounts: []DeviceMount{
+							{
+								HostPath:      "/etc/licenses/gpu0",
+								ContainerPath: "/licenses/gpu0",
+							},
+						},
+					},
+				},
+				Features: map[string]bool{
+					"hookless-cdi": true,
+				},
+				deprecations: []deprecation.ID{deprecation.RuntimeExperimentalConfig},
+			},
+		},
+		{
+			description: "unknown feature is an error",
+			contents: []string{
+				"[features]",
+				"unknown-feature = true",
 			},
+			expectedError: fmt.Errorf("unknown feature"),
 		},
 	}
 
@@ -180,3 +698,56 @@ func TestGetConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCDIDefaultKinds(t *testing.T) {
+	testCases := []struct {
+		description   string
+		contents      string
+		expectedKinds cdiKinds
+	}{
+		{
+			description:   "single kind",
+			contents:      `nvidia-container-runtime.modes.cdi.default-kind = "nvidia.com/gpu"`,
+			expectedKinds: cdiKinds{"nvidia.com/gpu"},
+		},
+		{
+			description:   "list of kinds",
+			contents:      `nvidia-container-runtime.modes.cdi.default-kind = ["nvidia.com/gpu", "nvidia.com/mig"]`,
+			expectedKinds: cdiKinds{"nvidia.com/gpu", "nvidia.com/mig"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := loadConfigFrom(strings.NewReader(tc.contents))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedKinds, cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DefaultKind)
+		})
+	}
+}
+
+func TestConfigDeprecations(t *testing.T) {
+	testCases := []struct {
+		description          string
+		contents             string
+		expectedDeprecations []deprecation.ID
+	}{
+		{
+			description: "no deprecated options",
+			contents:    `nvidia-container-runtime.mode = "cdi"`,
+		},
+		{
+			description:          "experimental option",
+			contents:             "nvidia-container-runtime.experimental = true",
+			expectedDeprecations: []deprecation.ID{deprecation.RuntimeExperimentalConfig},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := loadConfigFrom(strings.NewReader(tc.contents))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDeprecations, cfg.Deprecations())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/redact"
+	"github.com/pelletier/go-toml"
+)
+
+// DebugConfig stores the options for debugging the NVIDIA Container Toolkit
+type DebugConfig struct {
+	// CaptureBundle indicates whether a debug bundle is captured when the NVIDIA Container Runtime fails.
+	CaptureBundle bool `toml:"capture-bundle"`
+	// BundleDir is the directory under which debug bundles are stored.
+	BundleDir string `toml:"bundle-dir"`
+	// RedactEnv is the list of glob patterns for the names of environment variables whose values are
+	// masked when OCI specifications or CDI edits are logged.
+	RedactEnv []string `toml:"redact-env"`
+	// SpecDumpDir is the directory under which the input and modified OCI specifications are written
+	// when these are modified by the NVIDIA Container Runtime. If empty, the specifications are not written.
+	SpecDumpDir string `toml:"spec-dump-dir"`
+}
+
+// getDebugConfigFrom reads the debug config from the specified toml Tree.
+func getDebugConfigFrom(toml *toml.Tree) (*DebugConfig, error) {
+	cfg := getDefaultDebugConfig()
+
+	if toml == nil {
+		return cfg, nil
+	}
+
+	var err error
+	cfg.CaptureBundle, err = getBool(toml, "debug.capture-bundle", cfg.CaptureBundle)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BundleDir, err = getString(toml, "debug.bundle-dir", cfg.BundleDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RedactEnv, err = getStringSlice(toml, "debug.redact-env", cfg.RedactEnv)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SpecDumpDir, err = getString(toml, "debug.spec-dump-dir", cfg.SpecDumpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// getDefaultDebugConfig defines the default values for the config
+func getDefaultDebugConfig() *DebugConfig {
+	c := DebugConfig{
+		CaptureBundle: false,
+		BundleDir:     "/var/log/nvidia-container-toolkit/bundles",
+		RedactEnv:     append([]string{}, redact.DefaultPatterns...),
+	}
+
+	return &c
+}
@@ -0,0 +1,79 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/pelletier/go-toml"
+)
+
+// DeviceConfig stores the site-specific modifications that are applied whenever a
+// particular device is injected into a container.
+type DeviceConfig struct {
+	// ExtraMounts is the list of additional mounts for the device (e.g. licensing or calibration data).
+	ExtraMounts []DeviceMount `toml:"extra-mounts"`
+	// Env is the list of additional environment variables (in the form NAME=VALUE) for the device.
+	Env []string `toml:"env"`
+}
+
+// DeviceMount defines an additional mount for a device.
+type DeviceMount struct {
+	// HostPath is the path on the host that is mounted.
+	HostPath string `toml:"host-path"`
+	// ContainerPath is the path in the container at which the host path is mounted.
+	// If this is empty, the host path is used.
+	ContainerPath string `toml:"container-path"`
+	// Options are the mount options. If this is empty, the mount is a read-only bind mount.
+	Options []string `toml:"options"`
+}
+
+// devicesDummy allows us to unmarshal only the device configs from a *toml.Tree
+type devicesDummy struct {
+	Devices map[string]DeviceConfig `toml:"devices"`
+}
+
+// getDevicesConfigFrom reads the per-device configs from the specified toml Tree.
+// The configs are keyed by the device UUID (e.g. GPU-xxxx).
+func getDevicesConfigFrom(toml *toml.Tree) (map[string]DeviceConfig, error) {
+	if toml == nil || !toml.Has("devices") {
+		return nil, nil
+	}
+
+	var d devicesDummy
+	if err := toml.Unmarshal(&d); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal devices config: %v", err)
+	}
+
+	return d.Devices, nil
+}
+
+// GetContainerPath returns the path in the container at which the host path is mounted.
+func (m DeviceMount) GetContainerPath() string {
+	if m.ContainerPath == "" {
+		return m.HostPath
+	}
+	return m.ContainerPath
+}
+
+// GetOptions returns the mount options. If no options are specified, a read-only bind mount is used.
+func (m DeviceMount) GetOptions() []string {
+	if len(m.Options) == 0 {
+		return []string{"ro", "nosuid", "nodev", "bind"}
+	}
+	return m.Options
+}
@@ -0,0 +1,283 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Source describes where the effective value of a config option is set.
+type Source string
+
+// The following sources are supported for config values.
+const (
+	// SourceDefault indicates that the option is not set and the default value is used.
+	SourceDefault = Source("default")
+	// SourceFile indicates that the value is set in the config file.
+	SourceFile = Source("file")
+	// SourceEnvironment indicates that the value in the config file references
+	// one or more environment variables that are set.
+	SourceEnvironment = Source("environment")
+	// SourceCommandLine indicates that the value is overridden on the command line.
+	SourceCommandLine = Source("command-line")
+)
+
+// bareKey matches TOML keys that do not require quoting.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// EffectiveValue represents the effective value of a single config option.
+type EffectiveValue struct {
+	// Key is the fully-qualified TOML key of the option.
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// Source is where the value is set.
+	Source Source `json:"source"`
+	// Origin provides details of the source such as the path of the config file or the
+	// environment variables that are referenced.
+	Origin string `json:"origin,omitempty"`
+}
+
+// EffectiveConfig represents the config after the config file, the environment, and
+// command line overrides have been applied to the defaults.
+type EffectiveConfig struct {
+	// Path is the path of the config file.
+	Path   string           `json:"path"`
+	Config *Config          `json:"-"`
+	Values []EffectiveValue `json:"values"`
+}
+
+// GetEffectiveConfig loads the config file at the specified path, applies the specified overrides
+// and returns the effective value of each option together with its source. The overrides are keyed
+// by the fully-qualified TOML key of the option. If the path is empty, the path returned by
+// GetConfigFilePath is used. A missing config file is not an error.
+func GetEffectiveConfig(path string, overrides map[string]interface{}) (*EffectiveConfig, error) {
+	if path == "" {
+		path = GetConfigFilePath()
+	}
+
+	raw, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	default:
+		raw, err = loadTOML(contents)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		expanded, err = LoadTOMLFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range overrides {
+		expanded.Set(key, value)
+	}
+
+	cfg, err := getConfigFrom(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config values: %v", err)
+	}
+
+	e := effectiveValues{
+		raw:       raw,
+		expanded:  expanded,
+		overrides: overrides,
+		path:      path,
+		lookup:    os.LookupEnv,
+	}
+	values, err := e.get(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := EffectiveConfig{
+		Path:   path,
+		Config: cfg,
+		Values: values,
+	}
+	return &effective, nil
+}
+
+// effectiveValues determines the effective values of config options and their sources.
+type effectiveValues struct {
+	raw       *toml.Tree
+	expanded  *toml.Tree
+	overrides map[string]interface{}
+	path      string
+	lookup    func(string) (string, bool)
+}
+
+// get returns the effective values for the specified config. This includes the options
+// defined by the config as well as options that are only set in the config file (for
+// example options read by the nvidia-container-runtime-hook).
+func (e effectiveValues) get(cfg *Config) ([]EffectiveValue, error) {
+	contents, err := toml.Marshal(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %v", err)
+	}
+	effective, err := loadTOML(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load marshalled config: %v", err)
+	}
+
+	paths := make(map[string][]string)
+	for _, path := range leafPaths(effective, nil) {
+		paths[formatKey(path)] = path
+	}
+	for _, path := range leafPaths(e.raw, nil) {
+		if _, exists := paths[formatKey(path)]; !exists {
+			effective.SetPath(path, e.expanded.GetPath(path))
+			paths[formatKey(path)] = path
+		}
+	}
+
+	var keys []string
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var values []EffectiveValue
+	for _, key := range keys {
+		path := paths[key]
+		source, origin := e.source(key, path)
+		value := EffectiveValue{
+			Key:    key,
+			Value:  toValue(effective.GetPath(path)),
+			Source: source,
+			Origin: origin,
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// source returns the source of the option at the specified path.
+func (e effectiveValues) source(key string, path []string) (Source, string) {
+	if _, ok := e.overrides[key]; ok {
+		return SourceCommandLine, ""
+	}
+	if !e.raw.HasPath(path) {
+		return SourceDefault, ""
+	}
+
+	var variables []string
+	for _, s := range toStrings(e.raw.GetPath(path)) {
+		for _, match := range variableReference.FindAllStringSubmatch(s, -1) {
+			name := match[1]
+			if _, ok := e.lookup(name); ok {
+				variables = append(variables, "$"+name)
+				continue
+			}
+			// The runtime directory is XDG_RUNTIME_DIR if this is set.
+			if name == RuntimeDirVariable {
+				if v, ok := e.lookup("XDG_RUNTIME_DIR"); ok && v != "" {
+					variables = append(variables, "$XDG_RUNTIME_DIR")
+				}
+			}
+		}
+	}
+	if len(variables) > 0 {
+		return SourceEnvironment, strings.Join(variables, ", ")
+	}
+	return SourceFile, e.path
+}
+
+// leafPaths returns the paths of all values in the specified tree that are not tables.
+// Arrays of tables are considered values.
+func leafPaths(tree *toml.Tree, prefix []string) [][]string {
+	var paths [][]string
+	for _, key := range tree.Keys() {
+		path := append(append([]string{}, prefix...), key)
+		if subtree, ok := tree.GetPath([]string{key}).(*toml.Tree); ok {
+			paths = append(paths, leafPaths(subtree, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// formatKey returns the TOML key for the specified path, quoting elements as required.
+func formatKey(path []string) string {
+	var parts []string
+	for _, p := range path {
+		if !bareKey.MatchString(p) {
+			p = fmt.Sprintf("%q", p)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// toValue converts arrays of tables to slices of maps so that values can be rendered.
+// Empty arrays are returned as empty slices instead of nil.
+func toValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		if v == nil {
+			return []interface{}{}
+		}
+	case []*toml.Tree:
+		maps := []map[string]interface{}{}
+		for _, tree := range v {
+			maps = append(maps, tree.ToMap())
+		}
+		return maps
+	}
+	return value
+}
+
+// toStrings returns the string values contained in the specified value.
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var strs []string
+		for _, element := range v {
+			strs = append(strs, toStrings(element)...)
+		}
+		return strs
+	case []*toml.Tree:
+		var strs []string
+		for _, tree := range v {
+			for _, path := range leafPaths(tree, nil) {
+				strs = append(strs, toStrings(tree.GetPath(path))...)
+			}
+		}
+		return strs
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEffectiveConfig(t *testing.T) {
+	t.Setenv("NVCT_TEST_LOG_DIR", "/var/log/nvidia")
+
+	testCases := []struct {
+		description     string
+		contents        string
+		overrides       map[string]interface{}
+		expectedMode    string
+		expectedSources map[string]EffectiveValue
+	}{
+		{
+			description:  "missing config file uses defaults",
+			expectedMode: "auto",
+			expectedSources: map[string]EffectiveValue{
+				"nvidia-container-runtime.mode": {Value: "auto", Source: SourceDefault},
+			},
+		},
+		{
+			description: "values are read from the config file",
+			contents: `
+[nvidia-container-runtime]
+mode = "cdi"
+debug = "${NVCT_TEST_LOG_DIR}/runtime.log"
+log-level = "${NVCT_TEST_UNDEFINED:-debug}"
+`,
+			expectedMode: "cdi",
+			expectedSources: map[string]EffectiveValue{
+				"nvidia-container-runtime.mode":      {Value: "cdi", Source: SourceFile},
+				"nvidia-container-runtime.debug":     {Value: "/var/log/nvidia/runtime.log", Source: SourceEnvironment, Origin: "$NVCT_TEST_LOG_DIR"},
+				"nvidia-container-runtime.log-level": {Value: "debug", Source: SourceFile},
+				"nvidia-container-runtime.runtimes":  {Value: []interface{}{"docker-runc", "runc"}, Source: SourceDefault},
+			},
+		},
+		{
+			description: "command line overrides take precedence",
+			contents: `
+[nvidia-container-runtime]
+mode = "cdi"
+`,
+			overrides: map[string]interface{}{
+				"nvidia-container-runtime.mode": "legacy",
+			},
+			expectedMode: "legacy",
+			expectedSources: map[string]EffectiveValue{
+				"nvidia-container-runtime.mode": {Value: "legacy", Source: SourceCommandLine},
+			},
+		},
+		{
+			description: "options not used by the runtime are included",
+			contents: `
+[nvidia-container-cli]
+ldconfig = "@/sbin/ldconfig.real"
+
+[devices."MIG-GPU-0/1/0"]
+env = ["A=B"]
+`,
+			expectedMode: "auto",
+			expectedSources: map[string]EffectiveValue{
+				"nvidia-container-cli.ldconfig": {Value: "@/sbin/ldconfig.real", Source: SourceFile},
+				"nvidia-container-cli.root":     {Value: "", Source: SourceDefault},
+				`devices."MIG-GPU-0/1/0".env`:   {Value: []interface{}{"A=B"}, Source: SourceFile},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if tc.contents != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0644))
+			}
+
+			effective, err := GetEffectiveConfig(path, tc.overrides)
+			require.NoError(t, err)
+			require.Equal(t, path, effective.Path)
+			require.Equal(t, tc.expectedMode, effective.Config.NVIDIAContainerRuntimeConfig.Mode)
+
+			values := make(map[string]EffectiveValue)
+			for _, value := range effective.Values {
+				values[value.Key] = value
+			}
+			for key, expected := range tc.expectedSources {
+				require.Contains(t, values, key)
+				value := values[key]
+				require.EqualValues(t, expected.Value, value.Value, key)
+				require.Equal(t, expected.Source, value.Source, key)
+				if expected.Source == SourceFile {
+					expected.Origin = path
+				}
+				require.Equal(t, expected.Origin, value.Origin, key)
+			}
+		})
+	}
+}
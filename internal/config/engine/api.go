@@ -22,4 +22,16 @@ type Interface interface {
 	AddRuntime(string, string, bool) error
 	RemoveRuntime(string) error
 	Save(string) (int64, error)
+	// Render returns the contents that Save writes to the specified path. If the contents are
+	// empty, Save removes the file instead.
+	Render(string) ([]byte, error)
+}
+
+// Inspector is implemented by runtime configs that can report the configured runtimes and whether
+// CDI is enabled for the container engine.
+type Inspector interface {
+	// Runtimes returns the path of the executable of each configured runtime by name.
+	Runtimes() map[string]string
+	// CDIEnabled checks whether the container engine injects CDI devices.
+	CDIEnabled() bool
 }
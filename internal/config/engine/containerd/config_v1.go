@@ -27,6 +27,7 @@ import (
 type ConfigV1 Config
 
 var _ engine.Interface = (*ConfigV1)(nil)
+var _ engine.Inspector = (*ConfigV1)(nil)
 
 // AddRuntime adds a runtime to the containerd config
 func (c *ConfigV1) AddRuntime(name string, path string, setAsDefault bool) error {
@@ -57,8 +58,11 @@ func (c *ConfigV1) AddRuntime(name string, path string, setAsDefault bool) error
 	}
 	config.SetPath([]string{"plugins", "cri", "containerd", "runtimes", name, "container_annotations"}, cdiAnnotations)
 
-	config.SetPath([]string{"plugins", "cri", "containerd", "runtimes", name, "options", "BinaryName"}, path)
-	config.SetPath([]string{"plugins", "cri", "containerd", "runtimes", name, "options", "Runtime"}, path)
+	runtimePath := []string{"plugins", "cri", "containerd", "runtimes", name}
+	setExecutable(&config, runtimePath, path, "BinaryName", "Runtime")
+	if err := validateRuntime(&config, runtimePath, c.containerdVersion); err != nil {
+		return fmt.Errorf("invalid settings for runtime %v: %v", name, err)
+	}
 
 	if setAsDefault && c.UseDefaultRuntimeName {
 		config.SetPath([]string{"plugins", "cri", "containerd", "default_runtime_name"}, name)
@@ -70,8 +74,11 @@ func (c *ConfigV1) AddRuntime(name string, path string, setAsDefault bool) error
 			config.SetPath([]string{"plugins", "cri", "containerd", "default_runtime", "runtime_engine"}, "")
 			config.SetPath([]string{"plugins", "cri", "containerd", "default_runtime", "privileged_without_host_devices"}, false)
 		}
-		config.SetPath([]string{"plugins", "cri", "containerd", "default_runtime", "options", "BinaryName"}, path)
-		config.SetPath([]string{"plugins", "cri", "containerd", "default_runtime", "options", "Runtime"}, path)
+		defaultRuntimePath := []string{"plugins", "cri", "containerd", "default_runtime"}
+		setExecutable(&config, defaultRuntimePath, path, "BinaryName", "Runtime")
+		if err := validateRuntime(&config, defaultRuntimePath, c.containerdVersion); err != nil {
+			return fmt.Errorf("invalid settings for default runtime: %v", err)
+		}
 	}
 
 	*c.Tree = config
@@ -86,6 +93,16 @@ func (c ConfigV1) DefaultRuntime() string {
 	return ""
 }
 
+// Runtimes returns the executables of the runtimes configured in the containerd config by name
+func (c ConfigV1) Runtimes() map[string]string {
+	return runtimes(c.Tree, 1)
+}
+
+// CDIEnabled checks whether CDI is enabled for the CRI plugin in the containerd config
+func (c ConfigV1) CDIEnabled() bool {
+	return cdiEnabled(c.Tree, 1, false)
+}
+
 // RemoveRuntime removes a runtime from the docker config
 func (c *ConfigV1) RemoveRuntime(name string) error {
 	if c == nil || c.Tree == nil {
@@ -131,6 +148,11 @@ func (c *ConfigV1) RemoveRuntime(name string) error {
 	return nil
 }
 
+// Render returns the config as it would be written to the specified path
+func (c ConfigV1) Render(path string) ([]byte, error) {
+	return (Config)(c).Render(path)
+}
+
 // Save wrotes the config to a file
 func (c ConfigV1) Save(path string) (int64, error) {
 	return (Config)(c).Save(path)
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/tomlfmt"
 	"github.com/pelletier/go-toml"
 )
 
@@ -52,7 +54,11 @@ func (c *Config) AddRuntime(name string, path string, setAsDefault bool) error {
 	}
 	config.SetPath([]string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "runtimes", name, "container_annotations"}, cdiAnnotations)
 
-	config.SetPath([]string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "runtimes", name, "options", "BinaryName"}, path)
+	runtimePath := []string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "runtimes", name}
+	setExecutable(&config, runtimePath, path, "BinaryName")
+	if err := validateRuntime(&config, runtimePath, c.containerdVersion); err != nil {
+		return fmt.Errorf("invalid settings for runtime %v: %v", name, err)
+	}
 
 	if setAsDefault {
 		config.SetPath([]string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "default_runtime_name"}, name)
@@ -70,6 +76,16 @@ func (c Config) DefaultRuntime() string {
 	return ""
 }
 
+// Runtimes returns the executables of the runtimes configured in the containerd config by name
+func (c Config) Runtimes() map[string]string {
+	return runtimes(c.Tree, 2)
+}
+
+// CDIEnabled checks whether CDI is enabled for the CRI plugin in the containerd config
+func (c Config) CDIEnabled() bool {
+	return cdiEnabled(c.Tree, 2, false)
+}
+
 // RemoveRuntime removes a runtime from the docker config
 func (c *Config) RemoveRuntime(name string) error {
 	if c == nil || c.Tree == nil {
@@ -102,12 +118,20 @@ func (c *Config) RemoveRuntime(name string) error {
 	return nil
 }
 
+// Render returns the config as it would be written to the specified path
+func (c Config) Render(path string) ([]byte, error) {
+	output, err := tomlfmt.RenderFile(path, c.Tree)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert to TOML: %v", err)
+	}
+	return []byte(output), nil
+}
+
 // Save writes the config to the specified path
 func (c Config) Save(path string) (int64, error) {
-	config := c.Tree
-	output, err := config.ToTomlString()
+	output, err := c.Render(path)
 	if err != nil {
-		return 0, fmt.Errorf("unable to convert to TOML: %v", err)
+		return 0, err
 	}
 
 	if len(output) == 0 {
@@ -118,16 +142,5 @@ func (c Config) Save(path string) (int64, error) {
 		return 0, nil
 	}
 
-	f, err := os.Create(path)
-	if err != nil {
-		return 0, fmt.Errorf("unable to open '%v' for writing: %v", path, err)
-	}
-	defer f.Close()
-
-	n, err := f.WriteString(output)
-	if err != nil {
-		return 0, fmt.Errorf("unable to write output: %v", err)
-	}
-
-	return int64(n), err
+	return engine.WriteFile(path, output)
 }
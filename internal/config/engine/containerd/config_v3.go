@@ -0,0 +1,144 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/pelletier/go-toml"
+)
+
+// ConfigV3 represents a version 3 containerd config as introduced in containerd 2.0.
+// In contrast to version 2 configs, the runtimes are configured for the
+// io.containerd.cri.v1.runtime plugin.
+type ConfigV3 Config
+
+var _ engine.Interface = (*ConfigV3)(nil)
+var _ engine.Inspector = (*ConfigV3)(nil)
+
+// AddRuntime adds a runtime to the containerd config
+func (c *ConfigV3) AddRuntime(name string, path string, setAsDefault bool) error {
+	if c == nil || c.Tree == nil {
+		return fmt.Errorf("config is nil")
+	}
+	config := *c.Tree
+
+	config.Set("version", int64(3))
+
+	switch runc := config.GetPath(c.runtimePath("runc")).(type) {
+	case *toml.Tree:
+		runc, _ = toml.Load(runc.String())
+		config.SetPath(c.runtimePath(name), runc)
+	}
+
+	if config.GetPath(c.runtimePath(name)) == nil {
+		config.SetPath(c.runtimePath(name, "runtime_type"), c.RuntimeType)
+		config.SetPath(c.runtimePath(name, "privileged_without_host_devices"), false)
+	}
+
+	cdiAnnotations := []interface{}{"cdi.k8s.io/*"}
+	containerAnnotations, ok := config.GetPath(c.runtimePath(name, "container_annotations")).([]interface{})
+	if ok && containerAnnotations != nil {
+		cdiAnnotations = append(containerAnnotations, cdiAnnotations...)
+	}
+	config.SetPath(c.runtimePath(name, "container_annotations"), cdiAnnotations)
+
+	setExecutable(&config, c.runtimePath(name), path, "BinaryName")
+	if err := validateRuntime(&config, c.runtimePath(name), c.containerdVersion); err != nil {
+		return fmt.Errorf("invalid settings for runtime %v: %v", name, err)
+	}
+
+	if setAsDefault {
+		config.SetPath(c.defaultRuntimeNamePath(), name)
+	}
+
+	*c.Tree = config
+	return nil
+}
+
+// DefaultRuntime returns the default runtime for the containerd config
+func (c ConfigV3) DefaultRuntime() string {
+	if runtime, ok := c.GetPath(c.defaultRuntimeNamePath()).(string); ok {
+		return runtime
+	}
+	return ""
+}
+
+// Runtimes returns the executables of the runtimes configured in the containerd config by name
+func (c ConfigV3) Runtimes() map[string]string {
+	return runtimes(c.Tree, 3)
+}
+
+// CDIEnabled checks whether CDI is enabled for the CRI plugin in the containerd config. CDI is enabled
+// by default in containerd 2.0.
+func (c ConfigV3) CDIEnabled() bool {
+	return cdiEnabled(c.Tree, 3, true)
+}
+
+// RemoveRuntime removes a runtime from the containerd config
+func (c *ConfigV3) RemoveRuntime(name string) error {
+	if c == nil || c.Tree == nil {
+		return nil
+	}
+
+	config := *c.Tree
+
+	config.DeletePath(c.runtimePath(name))
+	if runtime, ok := config.GetPath(c.defaultRuntimeNamePath()).(string); ok {
+		if runtime == name {
+			config.DeletePath(c.defaultRuntimeNamePath())
+		}
+	}
+
+	runtimePath := c.runtimePath(name)
+	for i := 0; i < len(runtimePath); i++ {
+		if runtimes, ok := config.GetPath(runtimePath[:len(runtimePath)-i]).(*toml.Tree); ok {
+			if len(runtimes.Keys()) == 0 {
+				config.DeletePath(runtimePath[:len(runtimePath)-i])
+			}
+		}
+	}
+
+	if len(config.Keys()) == 1 && config.Keys()[0] == "version" {
+		config.Delete("version")
+	}
+
+	*c.Tree = config
+	return nil
+}
+
+// Render returns the config as it would be written to the specified path
+func (c ConfigV3) Render(path string) ([]byte, error) {
+	return (Config)(c).Render(path)
+}
+
+// Save writes the config to the specified path
+func (c ConfigV3) Save(path string) (int64, error) {
+	return (Config)(c).Save(path)
+}
+
+// runtimePath returns the path of the specified setting of the named runtime.
+func (c ConfigV3) runtimePath(name string, keys ...string) []string {
+	path := append(criRuntimePath(3), "runtimes", name)
+	return append(path, keys...)
+}
+
+// defaultRuntimeNamePath returns the path of the default runtime name.
+func (c ConfigV3) defaultRuntimeNamePath() []string {
+	return append(criRuntimePath(3), "default_runtime_name")
+}
@@ -26,6 +26,45 @@ type Config struct {
 	*toml.Tree
 	RuntimeType           string
 	UseDefaultRuntimeName bool
+
+	// containerdVersion is the version of containerd that uses the config. This is nil if the
+	// version is not known, in which case the version-specific settings are not validated.
+	containerdVersion *containerdVersion
+}
+
+var _ engine.Inspector = (*Config)(nil)
+
+// runtimes returns the executables of the runtimes configured for the CRI plugin in the specified config
+// version by name.
+func runtimes(tree *toml.Tree, version int) map[string]string {
+	configured := make(map[string]string)
+	if tree == nil {
+		return configured
+	}
+	runtimesTree, ok := tree.GetPath(append(criRuntimePath(version), "runtimes")).(*toml.Tree)
+	if !ok {
+		return configured
+	}
+	for _, name := range runtimesTree.Keys() {
+		if settings, ok := runtimesTree.GetPath([]string{name}).(*toml.Tree); ok {
+			configured[name] = getExecutable(settings)
+		}
+	}
+	return configured
+}
+
+// cdiEnabled returns the value of the enable_cdi option of the CRI plugin in the specified config
+// version. If this is not set, the specified default is returned.
+func cdiEnabled(tree *toml.Tree, version int, defaultValue bool) bool {
+	if tree == nil {
+		return defaultValue
+	}
+	path := criRuntimePath(version)
+	enabled, ok := tree.GetPath(append(path[:len(path)-1], "enable_cdi")).(bool)
+	if !ok {
+		return defaultValue
+	}
+	return enabled
 }
 
 // New creates a containerd config with the specified options
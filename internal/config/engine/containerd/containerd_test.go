@@ -0,0 +1,97 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		description        string
+		config             string
+		expectedRuntimes   map[string]string
+		expectedCDIEnabled bool
+	}{
+		{
+			description: "version 2 config",
+			config: `version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  enable_cdi = true
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+  runtime_type = "io.containerd.runc.v2"
+`,
+			expectedRuntimes: map[string]string{
+				"nvidia": "/usr/bin/nvidia-container-runtime",
+				"runc":   "",
+			},
+			expectedCDIEnabled: true,
+		},
+		{
+			description: "version 1 config",
+			config: `version = 1
+[plugins.cri.containerd.runtimes.nvidia.options]
+  Runtime = "/usr/bin/nvidia-container-runtime"
+`,
+			expectedRuntimes: map[string]string{
+				"nvidia": "/usr/bin/nvidia-container-runtime",
+			},
+		},
+		{
+			description: "version 3 config enables cdi by default",
+			config: `version = 3
+[plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime.cdi"
+`,
+			expectedRuntimes: map[string]string{
+				"nvidia": "/usr/bin/nvidia-container-runtime.cdi",
+			},
+			expectedCDIEnabled: true,
+		},
+		{
+			description: "version 3 config with cdi disabled",
+			config: `version = 3
+[plugins."io.containerd.cri.v1.runtime"]
+  enable_cdi = false
+`,
+			expectedRuntimes:   map[string]string{},
+			expectedCDIEnabled: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0644))
+
+			cfg, err := New(WithPath(path))
+			require.NoError(t, err)
+
+			inspector, ok := cfg.(engine.Inspector)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedRuntimes, inspector.Runtimes())
+			require.Equal(t, tc.expectedCDIEnabled, inspector.CDIEnabled())
+		})
+	}
+}
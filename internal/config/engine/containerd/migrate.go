@@ -0,0 +1,213 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	// nvidiaRuntimeExecutablePrefix identifies the runtimes that are managed by the NVIDIA Container Toolkit
+	// (e.g. nvidia-container-runtime, nvidia-container-runtime.cdi).
+	nvidiaRuntimeExecutablePrefix = "nvidia-container-runtime"
+
+	// legacyRuntimeType is the runtime type of the v1 shim which was removed in containerd 2.0.
+	legacyRuntimeType = "io.containerd.runtime.v1.linux"
+)
+
+// MigrateFunc migrates the settings that are not managed by the NVIDIA Container Toolkit to a later config version.
+type MigrateFunc func(*toml.Tree) (*toml.Tree, error)
+
+// nvidiaRuntime is a runtime managed by the NVIDIA Container Toolkit that is migrated.
+type nvidiaRuntime struct {
+	name      string
+	settings  *toml.Tree
+	isDefault bool
+}
+
+// criRuntimePath returns the path of the containerd settings of the CRI plugin for the specified config version.
+func criRuntimePath(version int) []string {
+	switch version {
+	case 1:
+		return []string{"plugins", "cri", "containerd"}
+	case 2:
+		return []string{"plugins", "io.containerd.grpc.v1.cri", "containerd"}
+	default:
+		return []string{"plugins", "io.containerd.cri.v1.runtime", "containerd"}
+	}
+}
+
+// Migrate migrates the runtimes in the specified containerd config that are managed by the NVIDIA Container
+// Toolkit to the specified config version. All other settings are migrated using the specified function.
+// If this is nil, an error is returned if the config contains any other settings. Only migrations to a
+// later version are supported.
+func Migrate(tree *toml.Tree, to int, migrateOther MigrateFunc) (*toml.Tree, error) {
+	from, err := (&Config{Tree: tree}).parseVersion(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config version: %v", err)
+	}
+	if to < 2 || to > 3 {
+		return nil, fmt.Errorf("unsupported target version %v", to)
+	}
+	if from < 1 || from > 3 {
+		return nil, fmt.Errorf("unsupported config version %v", from)
+	}
+	if from > to {
+		return nil, fmt.Errorf("cannot migrate config from version %v to earlier version %v", from, to)
+	}
+	if from == to {
+		return tree, nil
+	}
+
+	runtimes := extractNVIDIARuntimes(tree, from)
+
+	if hasOtherSettings(tree) {
+		if migrateOther == nil {
+			return nil, fmt.Errorf("the config contains settings that are not managed by the NVIDIA Container Toolkit")
+		}
+		tree, err = migrateOther(tree)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate config: %v", err)
+		}
+		migrated, err := (&Config{Tree: tree}).parseVersion(2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version of migrated config: %v", err)
+		}
+		if migrated != to {
+			return nil, fmt.Errorf("config was migrated to version %v instead of %v", migrated, to)
+		}
+	} else {
+		tree, _ = toml.TreeFromMap(map[string]interface{}{})
+	}
+
+	path := criRuntimePath(to)
+	for _, r := range runtimes {
+		tree.SetPath(append(path, "runtimes", r.name), migrateRuntimeSettings(r.settings, to))
+		if r.isDefault {
+			tree.SetPath(append(path, "default_runtime_name"), r.name)
+		}
+	}
+	tree.Set("version", int64(to))
+
+	return tree, nil
+}
+
+// extractNVIDIARuntimes removes the runtimes that are managed by the NVIDIA Container Toolkit from the
+// specified config and returns these ordered by name. For version 1 configs, a deprecated default_runtime
+// that invokes the NVIDIA Container Runtime is converted to a runtime named nvidia.
+func extractNVIDIARuntimes(tree *toml.Tree, version int) []nvidiaRuntime {
+	path := criRuntimePath(version)
+	defaultRuntimeName, _ := tree.GetPath(append(path, "default_runtime_name")).(string)
+
+	var runtimes []nvidiaRuntime
+	if configured, ok := tree.GetPath(append(path, "runtimes")).(*toml.Tree); ok {
+		for _, name := range configured.Keys() {
+			settings, ok := configured.GetPath([]string{name}).(*toml.Tree)
+			if !ok || !isNVIDIARuntime(settings) {
+				continue
+			}
+			copied, _ := toml.Load(settings.String())
+			runtimes = append(runtimes, nvidiaRuntime{
+				name:      name,
+				settings:  copied,
+				isDefault: name == defaultRuntimeName,
+			})
+			tree.DeletePath(append(path, "runtimes", name))
+			if name == defaultRuntimeName {
+				tree.DeletePath(append(path, "default_runtime_name"))
+			}
+		}
+	}
+
+	if version == 1 {
+		if settings, ok := tree.GetPath(append(path, "default_runtime")).(*toml.Tree); ok && isNVIDIARuntime(settings) {
+			tree.DeletePath(append(path, "default_runtime"))
+			var found bool
+			for i := range runtimes {
+				if getExecutable(runtimes[i].settings) == getExecutable(settings) {
+					runtimes[i].isDefault = true
+					found = true
+				}
+			}
+			if !found {
+				copied, _ := toml.Load(settings.String())
+				runtimes = append(runtimes, nvidiaRuntime{name: "nvidia", settings: copied, isDefault: true})
+			}
+		}
+	}
+
+	// Remove the tables that are empty after the runtimes were removed.
+	runtimesPath := append(path, "runtimes")
+	for i := len(runtimesPath); i > 0; i-- {
+		if t, ok := tree.GetPath(runtimesPath[:i]).(*toml.Tree); ok && len(t.Keys()) == 0 {
+			tree.DeletePath(runtimesPath[:i])
+		}
+	}
+
+	sort.Slice(runtimes, func(i, j int) bool {
+		return runtimes[i].name < runtimes[j].name
+	})
+	return runtimes
+}
+
+// migrateRuntimeSettings updates the settings of a runtime for the specified config version.
+// The Runtime option of the v1 shim is replaced by BinaryName, and the runtime_root and runtime_engine
+// settings which were removed in containerd 2.0 are removed for version 3 configs.
+func migrateRuntimeSettings(settings *toml.Tree, to int) *toml.Tree {
+	if binaryName := getExecutable(settings); binaryName != "" {
+		settings.SetPath([]string{"options", "BinaryName"}, binaryName)
+	}
+	settings.DeletePath([]string{"options", "Runtime"})
+	if runtimeType, _ := settings.Get("runtime_type").(string); runtimeType == legacyRuntimeType || runtimeType == "" {
+		settings.Set("runtime_type", defaultRuntimeType)
+	}
+	if to >= 3 {
+		settings.Delete("runtime_root")
+		settings.Delete("runtime_engine")
+	}
+	return settings
+}
+
+// isNVIDIARuntime checks whether the specified runtime settings invoke the NVIDIA Container Runtime.
+func isNVIDIARuntime(settings *toml.Tree) bool {
+	return strings.HasPrefix(filepath.Base(getExecutable(settings)), nvidiaRuntimeExecutablePrefix)
+}
+
+// getExecutable returns the executable invoked by the specified runtime settings. The BinaryName option
+// takes precedence over the Runtime option of the v1 shim.
+func getExecutable(settings *toml.Tree) string {
+	if binaryName, ok := settings.GetPath([]string{"options", "BinaryName"}).(string); ok && binaryName != "" {
+		return binaryName
+	}
+	runtime, _ := settings.GetPath([]string{"options", "Runtime"}).(string)
+	return runtime
+}
+
+// hasOtherSettings checks whether the specified config contains settings other than the version.
+func hasOtherSettings(tree *toml.Tree) bool {
+	for _, key := range tree.Keys() {
+		if key != "version" {
+			return true
+		}
+	}
+	return false
+}
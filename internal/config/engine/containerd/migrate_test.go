@@ -0,0 +1,248 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	testCases := []struct {
+		description   string
+		config        string
+		to            int
+		migrateOther  MigrateFunc
+		expected      map[string]interface{}
+		expectedError bool
+	}{
+		{
+			description: "v1 NVIDIA runtimes are migrated to v2",
+			config: `
+version = 1
+[plugins.cri.containerd]
+  default_runtime_name = "nvidia"
+[plugins.cri.containerd.runtimes.nvidia]
+  runtime_type = "io.containerd.runc.v2"
+  runtime_root = ""
+[plugins.cri.containerd.runtimes.nvidia.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+  Runtime = "/usr/bin/nvidia-container-runtime"
+`,
+			to: 2,
+			expected: map[string]interface{}{
+				"version": int64(2),
+				"plugins": map[string]interface{}{
+					"io.containerd.grpc.v1.cri": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"default_runtime_name": "nvidia",
+							"runtimes": map[string]interface{}{
+								"nvidia": map[string]interface{}{
+									"runtime_type": "io.containerd.runc.v2",
+									"runtime_root": "",
+									"options": map[string]interface{}{
+										"BinaryName": "/usr/bin/nvidia-container-runtime",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "v1 default_runtime is converted",
+			config: `
+[plugins.cri.containerd.default_runtime]
+  runtime_type = "io.containerd.runtime.v1.linux"
+[plugins.cri.containerd.default_runtime.options]
+  Runtime = "nvidia-container-runtime"
+`,
+			to: 3,
+			expected: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"default_runtime_name": "nvidia",
+							"runtimes": map[string]interface{}{
+								"nvidia": map[string]interface{}{
+									"runtime_type": "io.containerd.runc.v2",
+									"options": map[string]interface{}{
+										"BinaryName": "nvidia-container-runtime",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "v2 NVIDIA runtimes are migrated to v3",
+			config: `
+version = 2
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-cdi]
+  runtime_type = "io.containerd.runc.v2"
+  runtime_root = ""
+  runtime_engine = ""
+  privileged_without_host_devices = false
+  container_annotations = ["cdi.k8s.io/*"]
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-cdi.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime.cdi"
+`,
+			to: 3,
+			expected: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"nvidia-cdi": map[string]interface{}{
+									"runtime_type":                    "io.containerd.runc.v2",
+									"privileged_without_host_devices": false,
+									"container_annotations":           []interface{}{"cdi.k8s.io/*"},
+									"options": map[string]interface{}{
+										"BinaryName": "/usr/bin/nvidia-container-runtime.cdi",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "other settings require migration function",
+			config: `
+version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "pause:3.9"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+  BinaryName = "nvidia-container-runtime"
+`,
+			to:            3,
+			expectedError: true,
+		},
+		{
+			description: "other settings are migrated using migration function",
+			config: `
+version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "pause:3.9"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+  runtime_type = "io.containerd.runc.v2"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia]
+  runtime_type = "io.containerd.runc.v2"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+  BinaryName = "nvidia-container-runtime"
+`,
+			to: 3,
+			migrateOther: func(tree *toml.Tree) (*toml.Tree, error) {
+				require.Nil(t, tree.GetPath([]string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "runtimes", "nvidia"}))
+				return toml.Load(`
+version = 3
+[plugins."io.containerd.cri.v1.images".pinned_images]
+  sandbox = "pause:3.9"
+[plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
+  runtime_type = "io.containerd.runc.v2"
+`)
+			},
+			expected: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.images": map[string]interface{}{
+						"pinned_images": map[string]interface{}{
+							"sandbox": "pause:3.9",
+						},
+					},
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"runc": map[string]interface{}{
+									"runtime_type": "io.containerd.runc.v2",
+								},
+								"nvidia": map[string]interface{}{
+									"runtime_type": "io.containerd.runc.v2",
+									"options": map[string]interface{}{
+										"BinaryName": "nvidia-container-runtime",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "migration function must return target version",
+			config: `
+version = 1
+[plugins.cri]
+  sandbox_image = "pause:3.9"
+`,
+			to: 3,
+			migrateOther: func(tree *toml.Tree) (*toml.Tree, error) {
+				return toml.Load(`version = 2`)
+			},
+			expectedError: true,
+		},
+		{
+			description: "same version is unchanged",
+			config: `
+version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "pause:3.9"
+`,
+			to: 2,
+			expected: map[string]interface{}{
+				"version": int64(2),
+				"plugins": map[string]interface{}{
+					"io.containerd.grpc.v1.cri": map[string]interface{}{
+						"sandbox_image": "pause:3.9",
+					},
+				},
+			},
+		},
+		{
+			description:   "downgrade is not supported",
+			config:        `version = 3`,
+			to:            2,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			tree, err := toml.Load(tc.config)
+			require.NoError(t, err)
+
+			migrated, err := Migrate(tree, tc.to, tc.migrateOther)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected, err := toml.TreeFromMap(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, expected.String(), migrated.String())
+		})
+	}
+}
@@ -33,12 +33,13 @@ type builder struct {
 	path            string
 	runtimeType     string
 	useLegacyConfig bool
+	containerdPath  string
 }
 
 // Option defines a function that can be used to configure the config builder
 type Option func(*builder)
 
-// WithPath sets the path for the config builder
+// WithPath sets the path for the config builder. If no path is set, an empty config is created.
 func WithPath(path string) Option {
 	return func(b *builder) {
 		b.path = path
@@ -59,23 +60,30 @@ func WithUseLegacyConfig(useLegacyConfig bool) Option {
 	}
 }
 
-func (b *builder) build() (engine.Interface, error) {
-	if b.path == "" {
-		return nil, fmt.Errorf("config path is empty")
+// WithContainerdPath sets the path of the containerd executable that is queried for its version
+// to select the config version if the config is empty. If this is not set, version 2 is used.
+func WithContainerdPath(containerdPath string) Option {
+	return func(b *builder) {
+		b.containerdPath = containerdPath
 	}
+}
 
+func (b *builder) build() (engine.Interface, error) {
 	if b.runtimeType == "" {
 		b.runtimeType = defaultRuntimeType
 	}
 
-	config, err := loadConfig(b.path)
+	config, err := b.loadConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %v", err)
 	}
 	config.RuntimeType = b.runtimeType
 	config.UseDefaultRuntimeName = !b.useLegacyConfig
 
-	version, err := config.parseVersion(b.useLegacyConfig)
+	containerdVersion, versionErr := b.getContainerdVersion()
+	config.containerdVersion = containerdVersion
+
+	version, err := config.parseVersion(b.getDefaultVersion(containerdVersion, versionErr))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config version: %v", err)
 	}
@@ -84,11 +92,26 @@ func (b *builder) build() (engine.Interface, error) {
 		return (*ConfigV1)(config), nil
 	case 2:
 		return config, nil
+	case 3:
+		return (*ConfigV3)(config), nil
 	}
 
 	return nil, fmt.Errorf("unsupported config version: %v", version)
 }
 
+// loadConfig loads the config from the path of the builder. If no path is set, an empty config
+// is returned.
+func (b *builder) loadConfig() (*Config, error) {
+	if b.path == "" {
+		empty, err := toml.TreeFromMap(map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Tree: empty}, nil
+	}
+	return loadConfig(b.path)
+}
+
 // loadConfig loads the containerd config from disk
 func loadConfig(config string) (*Config, error) {
 	log.Infof("Loading config: %v", config)
@@ -117,13 +140,38 @@ func loadConfig(config string) (*Config, error) {
 	return &cfg, nil
 }
 
-// parseVersion returns the version of the config
-func (c *Config) parseVersion(useLegacyConfig bool) (int, error) {
-	defaultVersion := 2
-	if useLegacyConfig {
-		defaultVersion = 1
+// getContainerdVersion returns the version of the configured containerd executable. If no
+// executable is configured, nil is returned.
+func (b *builder) getContainerdVersion() (*containerdVersion, error) {
+	if b.containerdPath == "" {
+		return nil, nil
 	}
+	return getContainerdVersion(b.containerdPath)
+}
+
+// getDefaultVersion returns the version used for empty configs. Version 1 is used for legacy
+// configs. Otherwise version 3 is used if the configured containerd executable is containerd 2.0
+// or later, falling back to version 2.
+func (b *builder) getDefaultVersion(containerdVersion *containerdVersion, err error) int {
+	if b.useLegacyConfig {
+		return 1
+	}
+	if err != nil {
+		log.Warnf("Unable to determine containerd version; using config version 2: %v", err)
+		return 2
+	}
+	if containerdVersion == nil {
+		return 2
+	}
+	if containerdVersion.major >= 2 {
+		return 3
+	}
+	return 2
+}
 
+// parseVersion returns the version of the config. The specified default version is used if the
+// config is empty.
+func (c *Config) parseVersion(defaultVersion int) (int, error) {
 	switch v := c.Get("version").(type) {
 	case nil:
 		switch len(c.Keys()) {
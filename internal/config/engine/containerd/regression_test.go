@@ -0,0 +1,181 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+// inspectableConfig is a containerd config that can report its configured runtimes.
+type inspectableConfig interface {
+	engine.Interface
+	engine.Inspector
+}
+
+// loadRegressionConfig loads the specified config using the containerd config types of this package.
+// The loaded config, its TOML tree, and the path of the containerd settings of the CRI plugin are returned.
+func loadRegressionConfig(t *testing.T, path string) (inspectableConfig, *toml.Tree, []string) {
+	cfg, err := New(WithPath(path))
+	require.NoError(t, err)
+
+	switch c := cfg.(type) {
+	case *ConfigV1:
+		return c, c.Tree, criRuntimePath(1)
+	case *Config:
+		return c, c.Tree, criRuntimePath(2)
+	case *ConfigV3:
+		return c, c.Tree, criRuntimePath(3)
+	}
+	require.Failf(t, "unexpected config type", "%T", cfg)
+	return nil, nil, nil
+}
+
+// getRuntimeSettings returns the settings of the specified runtime of the CRI plugin as a map. If the
+// runtime is not configured, nil is returned.
+func getRuntimeSettings(tree *toml.Tree, criPath []string, name string) map[string]interface{} {
+	settings, ok := tree.GetPath(append(append([]string{}, criPath...), "runtimes", name)).(*toml.Tree)
+	if !ok {
+		return nil
+	}
+	return settings.ToMap()
+}
+
+// withoutPaths returns the contents of the specified tree as a map with the specified paths removed.
+func withoutPaths(t *testing.T, tree *toml.Tree, paths ...[]string) map[string]interface{} {
+	copied, err := toml.Load(tree.String())
+	require.NoError(t, err)
+	for _, path := range paths {
+		if copied.HasPath(path) {
+			require.NoError(t, copied.DeletePath(path))
+		}
+	}
+	return copied.ToMap()
+}
+
+func TestRegressionConfigs(t *testing.T) {
+	const (
+		runtimeName = "nvidia"
+		runtimePath = "/usr/bin/nvidia-container-runtime"
+	)
+
+	configs, err := filepath.Glob("testdata/*.toml")
+	require.NoError(t, err)
+	require.NotEmpty(t, configs)
+
+	for _, config := range configs {
+		for _, setAsDefault := range []bool{false, true} {
+			name := strings.TrimSuffix(filepath.Base(config), ".toml")
+			if setAsDefault {
+				name += "-default"
+			}
+			t.Run(name, func(t *testing.T) {
+				original, originalTree, criPath := loadRegressionConfig(t, config)
+
+				contents, err := os.ReadFile(config)
+				require.NoError(t, err)
+				path := filepath.Join(t.TempDir(), "config.toml")
+				require.NoError(t, os.WriteFile(path, contents, 0644))
+
+				cfg, err := New(WithPath(path))
+				require.NoError(t, err)
+				require.NoError(t, cfg.AddRuntime(runtimeName, runtimePath, setAsDefault))
+				_, err = cfg.Save(path)
+				require.NoError(t, err)
+				requireCommentsPreserved(t, contents, path)
+
+				updated, updatedTree, _ := loadRegressionConfig(t, path)
+				require.Equal(t, runtimePath, updated.Runtimes()[runtimeName])
+				require.Equal(t, original.CDIEnabled(), updated.CDIEnabled())
+
+				nvidia := getRuntimeSettings(updatedTree, criPath, runtimeName)
+				require.IsType(t, "", nvidia["runtime_type"])
+				require.Contains(t, nvidia["container_annotations"], "cdi.k8s.io/*")
+				if runc := getRuntimeSettings(originalTree, criPath, "runc"); runc != nil {
+					require.Equal(t, runc["runtime_type"], nvidia["runtime_type"])
+					options, _ := runc["options"].(map[string]interface{})
+					nvidiaOptions, _ := nvidia["options"].(map[string]interface{})
+					for k, v := range options {
+						if k == "BinaryName" {
+							continue
+						}
+						require.Equal(t, v, nvidiaOptions[k], "option %v", k)
+					}
+				}
+				for name := range original.Runtimes() {
+					require.Equal(t, getRuntimeSettings(originalTree, criPath, name), getRuntimeSettings(updatedTree, criPath, name), "runtime %v", name)
+				}
+				if setAsDefault {
+					require.Equal(t, runtimeName, updated.DefaultRuntime())
+				} else {
+					require.Equal(t, original.DefaultRuntime(), updated.DefaultRuntime())
+				}
+
+				cfg, err = New(WithPath(path))
+				require.NoError(t, err)
+				require.NoError(t, cfg.RemoveRuntime(runtimeName))
+				_, err = cfg.Save(path)
+				require.NoError(t, err)
+				requireCommentsPreserved(t, contents, path)
+				// Removing the runtime restores the original config. Note that the version
+				// is set when a runtime is added to a config that does not specify one.
+				if !setAsDefault && hasVersion(t, contents) {
+					reverted, err := os.ReadFile(path)
+					require.NoError(t, err)
+					require.Equal(t, string(contents), string(reverted))
+				}
+
+				reverted, revertedTree, _ := loadRegressionConfig(t, path)
+				require.NotContains(t, reverted.Runtimes(), runtimeName)
+				require.NotEqual(t, runtimeName, reverted.DefaultRuntime())
+				// Apart from the version and the default runtime (which is removed if the NVIDIA runtime
+				// was set as the default), the original config is restored.
+				ignored := [][]string{
+					{"version"},
+					append(append([]string{}, criPath...), "default_runtime_name"),
+				}
+				require.Equal(t, withoutPaths(t, originalTree, ignored...), withoutPaths(t, revertedTree, ignored...))
+			})
+		}
+	}
+}
+
+// requireCommentsPreserved checks that all comments in the original config are retained in
+// the config at the specified path.
+func requireCommentsPreserved(t *testing.T, original []byte, path string) {
+	updated, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(original), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		require.Contains(t, string(updated), line)
+	}
+}
+
+// hasVersion checks whether the specified config sets the version.
+func hasVersion(t *testing.T, contents []byte) bool {
+	config, err := toml.LoadBytes(contents)
+	require.NoError(t, err)
+	return config.Has("version")
+}
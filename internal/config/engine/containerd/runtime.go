@@ -0,0 +1,120 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"fmt"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	runtimeTypeRuncV1  = "io.containerd.runc.v1"
+	runtimeTypeRuncV2  = "io.containerd.runc.v2"
+	runtimeTypeLinuxV1 = "io.containerd.runtime.v1.linux"
+)
+
+// runcOptions are the options supported by the runc shims (io.containerd.runc.v1 and io.containerd.runc.v2).
+var runcOptions = map[string]bool{
+	"BinaryName":     true,
+	"CriuImagePath":  true,
+	"CriuPath":       true,
+	"CriuWorkPath":   true,
+	"IoGid":          true,
+	"IoUid":          true,
+	"NoNewKeyring":   true,
+	"NoPivotRoot":    true,
+	"Root":           true,
+	"ShimCgroup":     true,
+	"SystemdCgroup":  true,
+	"TaskApiAddress": true,
+	"TaskApiVersion": true,
+}
+
+// runtimeOptions are the options supported by the known runtime types. Since containerd fails to
+// start a runtime with options that are not supported by its runtime type, these are validated.
+var runtimeOptions = map[string]map[string]bool{
+	runtimeTypeRuncV1: runcOptions,
+	runtimeTypeRuncV2: runcOptions,
+	runtimeTypeLinuxV1: {
+		"CriuPath":      true,
+		"Runtime":       true,
+		"RuntimeRoot":   true,
+		"SystemdCgroup": true,
+	},
+}
+
+// setExecutable sets the executable of the runtime at the specified path in the option supported by
+// the runtime type of the runtime. This is the Runtime option for the io.containerd.runtime.v1.linux
+// runtime type and the BinaryName option for the runc shims. For other runtime types, the executable
+// is set in the specified default options.
+func setExecutable(config *toml.Tree, runtimePath []string, path string, defaultOptions ...string) {
+	options := defaultOptions
+	runtimeType, _ := config.GetPath(subPath(runtimePath, "runtime_type")).(string)
+	switch runtimeType {
+	case runtimeTypeLinuxV1:
+		options = []string{"Runtime"}
+	case runtimeTypeRuncV1, runtimeTypeRuncV2:
+		options = []string{"BinaryName"}
+	}
+	for _, option := range options {
+		config.SetPath(subPath(runtimePath, "options", option), path)
+	}
+}
+
+// validateRuntime checks that the settings of the runtime at the specified path are supported by its
+// runtime type and, if the version is known, by the specified version of containerd.
+func validateRuntime(config *toml.Tree, runtimePath []string, version *containerdVersion) error {
+	runtime, ok := config.GetPath(runtimePath).(*toml.Tree)
+	if !ok {
+		return nil
+	}
+	runtimeType, _ := runtime.Get("runtime_type").(string)
+
+	if version != nil {
+		switch {
+		case version.atLeast(2, 0) && (runtimeType == runtimeTypeRuncV1 || runtimeType == runtimeTypeLinuxV1):
+			return fmt.Errorf("runtime type %q is not supported by containerd %v; use %q instead", runtimeType, version, runtimeTypeRuncV2)
+		case runtime.Has("sandbox_mode") && version.atLeast(2, 0):
+			return fmt.Errorf("the sandbox_mode setting is not supported by containerd %v; use sandboxer instead", version)
+		case runtime.Has("sandbox_mode") && !version.atLeast(1, 7):
+			return fmt.Errorf("the sandbox_mode setting requires containerd 1.7 or later; found containerd %v", version)
+		case runtime.Has("sandboxer") && !version.atLeast(2, 0):
+			return fmt.Errorf("the sandboxer setting requires containerd 2.0 or later; found containerd %v", version)
+		}
+	}
+
+	supported, ok := runtimeOptions[runtimeType]
+	if !ok {
+		return nil
+	}
+	options, ok := runtime.Get("options").(*toml.Tree)
+	if !ok {
+		return nil
+	}
+	for _, option := range options.Keys() {
+		if !supported[option] {
+			return fmt.Errorf("option %v is not supported by runtime type %q", option, runtimeType)
+		}
+	}
+	return nil
+}
+
+// subPath returns the path of the specified keys below the specified path.
+func subPath(path []string, keys ...string) []string {
+	return append(append([]string{}, path...), keys...)
+}
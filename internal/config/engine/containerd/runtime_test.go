@@ -0,0 +1,192 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetExecutable(t *testing.T) {
+	testCases := []struct {
+		description     string
+		runtimeType     string
+		expectedOptions map[string]interface{}
+	}{
+		{
+			description:     "runc shim uses BinaryName",
+			runtimeType:     runtimeTypeRuncV2,
+			expectedOptions: map[string]interface{}{"BinaryName": "/usr/bin/nvidia-container-runtime"},
+		},
+		{
+			description:     "legacy linux runtime uses Runtime",
+			runtimeType:     runtimeTypeLinuxV1,
+			expectedOptions: map[string]interface{}{"Runtime": "/usr/bin/nvidia-container-runtime"},
+		},
+		{
+			description: "unknown runtime type uses default options",
+			runtimeType: "io.containerd.kata.v2",
+			expectedOptions: map[string]interface{}{
+				"BinaryName": "/usr/bin/nvidia-container-runtime",
+				"Runtime":    "/usr/bin/nvidia-container-runtime",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			config, err := toml.TreeFromMap(map[string]interface{}{})
+			require.NoError(t, err)
+			runtimePath := []string{"runtimes", "nvidia"}
+			config.SetPath(subPath(runtimePath, "runtime_type"), tc.runtimeType)
+
+			setExecutable(config, runtimePath, "/usr/bin/nvidia-container-runtime", "BinaryName", "Runtime")
+
+			options, ok := config.GetPath(subPath(runtimePath, "options")).(*toml.Tree)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedOptions, options.ToMap())
+		})
+	}
+}
+
+func TestValidateRuntime(t *testing.T) {
+	testCases := []struct {
+		description   string
+		runtime       string
+		version       *containerdVersion
+		expectedError bool
+	}{
+		{
+			description: "runc shim options",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+[options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+  SystemdCgroup = true
+`,
+			version: &containerdVersion{major: 1, minor: 6},
+		},
+		{
+			description: "Runtime option is not supported by runc shim",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+[options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+  Runtime = "/usr/bin/nvidia-container-runtime"
+`,
+			expectedError: true,
+		},
+		{
+			description: "BinaryName option is not supported by legacy linux runtime",
+			runtime: `runtime_type = "io.containerd.runtime.v1.linux"
+[options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+`,
+			expectedError: true,
+		},
+		{
+			description: "options of unknown runtime types are not validated",
+			runtime: `runtime_type = "io.containerd.kata.v2"
+[options]
+  ConfigPath = "/opt/kata/configuration.toml"
+`,
+		},
+		{
+			description: "legacy linux runtime is not supported by containerd 2.0",
+			runtime: `runtime_type = "io.containerd.runtime.v1.linux"
+[options]
+  Runtime = "/usr/bin/nvidia-container-runtime"
+`,
+			version:       &containerdVersion{major: 2, minor: 0},
+			expectedError: true,
+		},
+		{
+			description: "legacy linux runtime without containerd version",
+			runtime: `runtime_type = "io.containerd.runtime.v1.linux"
+[options]
+  Runtime = "/usr/bin/nvidia-container-runtime"
+`,
+		},
+		{
+			description: "sandbox_mode is supported by containerd 1.7",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+sandbox_mode = "podsandbox"
+`,
+			version: &containerdVersion{major: 1, minor: 7},
+		},
+		{
+			description: "sandbox_mode is not supported by containerd 1.6",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+sandbox_mode = "podsandbox"
+`,
+			version:       &containerdVersion{major: 1, minor: 6},
+			expectedError: true,
+		},
+		{
+			description: "sandbox_mode is replaced by sandboxer in containerd 2.0",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+sandbox_mode = "podsandbox"
+`,
+			version:       &containerdVersion{major: 2, minor: 0},
+			expectedError: true,
+		},
+		{
+			description: "sandboxer requires containerd 2.0",
+			runtime: `runtime_type = "io.containerd.runc.v2"
+sandboxer = "podsandbox"
+`,
+			version:       &containerdVersion{major: 1, minor: 7},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			runtime, err := toml.Load(tc.runtime)
+			require.NoError(t, err)
+			config, err := toml.TreeFromMap(map[string]interface{}{})
+			require.NoError(t, err)
+			config.SetPath([]string{"runtimes", "nvidia"}, runtime)
+
+			err = validateRuntime(config, []string{"runtimes", "nvidia"}, tc.version)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAddRuntimeLegacyLinuxRuntime(t *testing.T) {
+	config, err := toml.Load(`version = 1
+[plugins.cri.containerd.runtimes.runc]
+  runtime_type = "io.containerd.runtime.v1.linux"
+`)
+	require.NoError(t, err)
+
+	cfg := &ConfigV1{Tree: config, RuntimeType: defaultRuntimeType}
+	require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", false))
+
+	options, ok := config.GetPath([]string{"plugins", "cri", "containerd", "runtimes", "nvidia", "options"}).(*toml.Tree)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"Runtime": "/usr/bin/nvidia-container-runtime"}, options.ToMap())
+
+	// The legacy linux runtime was removed in containerd 2.0.
+	cfg.containerdVersion = &containerdVersion{major: 2, minor: 0}
+	require.Error(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", false))
+}
@@ -0,0 +1,78 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// containerdVersion represents the version of a containerd executable.
+type containerdVersion struct {
+	major int
+	minor int
+}
+
+// String returns the version in the form MAJOR.MINOR.
+func (v containerdVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// atLeast checks whether the version is the specified version or later.
+func (v containerdVersion) atLeast(major int, minor int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	return v.minor >= minor
+}
+
+// getContainerdVersion returns the version of the specified containerd executable.
+func getContainerdVersion(containerdPath string) (*containerdVersion, error) {
+	output, err := exec.Command(containerdPath, "--version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %v --version: %v", containerdPath, err)
+	}
+	return parseContainerdVersion(string(output))
+}
+
+// parseContainerdVersion returns the version from the output of containerd --version.
+// This has the form:
+//
+//	containerd github.com/containerd/containerd/v2 v2.0.0 207ad711eabd375a01713109a8a197d197ff6542
+func parseContainerdVersion(output string) (*containerdVersion, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "containerd" {
+		return nil, fmt.Errorf("unexpected version output %q", strings.TrimSpace(output))
+	}
+	parts := strings.SplitN(strings.TrimPrefix(fields[2], "v"), ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %v", fields[2], err)
+	}
+	v := containerdVersion{
+		major: major,
+	}
+	if len(parts) > 1 {
+		v.minor, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %v", fields[2], err)
+		}
+	}
+	return &v, nil
+}
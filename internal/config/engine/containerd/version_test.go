@@ -0,0 +1,141 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containerd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContainerdVersion(t *testing.T) {
+	testCases := []struct {
+		description   string
+		output        string
+		expectedMajor int
+		expectedMinor int
+		expectedError bool
+	}{
+		{
+			description:   "containerd 1.x",
+			output:        "containerd containerd.io 1.6.21 3dce8eb055cbb6872793272b4f20ed16117344f8\n",
+			expectedMajor: 1,
+			expectedMinor: 6,
+		},
+		{
+			description:   "containerd 2.x",
+			output:        "containerd github.com/containerd/containerd/v2 v2.0.0 207ad711eabd375a01713109a8a197d197ff6542\n",
+			expectedMajor: 2,
+		},
+		{
+			description:   "version with suffix",
+			output:        "containerd github.com/k3s-io/containerd v1.7.11-k3s2 64b8a811b07ba6288238eefc14d898ee0b5b99ba\n",
+			expectedMajor: 1,
+			expectedMinor: 7,
+		},
+		{
+			description:   "unexpected output",
+			output:        "docker version 24.0.5",
+			expectedError: true,
+		},
+		{
+			description:   "invalid version",
+			output:        "containerd containerd.io unknown",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			v, err := parseContainerdVersion(tc.output)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMajor, v.major)
+			require.Equal(t, tc.expectedMinor, v.minor)
+		})
+	}
+}
+
+func TestNewSelectsConfigVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	containerd2 := filepath.Join(dir, "containerd2")
+	require.NoError(t, os.WriteFile(containerd2, []byte("#!/bin/sh\necho containerd github.com/containerd/containerd/v2 v2.0.0 207ad711\n"), 0755))
+	containerd1 := filepath.Join(dir, "containerd1")
+	require.NoError(t, os.WriteFile(containerd1, []byte("#!/bin/sh\necho containerd containerd.io 1.7.2 0cae528d\n"), 0755))
+
+	v3Config := filepath.Join(dir, "v3.toml")
+	require.NoError(t, os.WriteFile(v3Config, []byte("version = 3\n"), 0644))
+
+	testCases := []struct {
+		description    string
+		path           string
+		containerdPath string
+		expectedType   interface{}
+	}{
+		{
+			description:  "version 3 config",
+			path:         v3Config,
+			expectedType: &ConfigV3{},
+		},
+		{
+			description:  "new config without containerd path",
+			path:         filepath.Join(dir, "missing.toml"),
+			expectedType: &Config{},
+		},
+		{
+			description:    "new config for containerd 2.x",
+			path:           filepath.Join(dir, "missing.toml"),
+			containerdPath: containerd2,
+			expectedType:   &ConfigV3{},
+		},
+		{
+			description:    "new config for containerd 1.x",
+			path:           filepath.Join(dir, "missing.toml"),
+			containerdPath: containerd1,
+			expectedType:   &Config{},
+		},
+		{
+			description:    "new config if containerd version cannot be determined",
+			path:           filepath.Join(dir, "missing.toml"),
+			containerdPath: filepath.Join(dir, "missing"),
+			expectedType:   &Config{},
+		},
+		{
+			description:    "version of existing config takes precedence",
+			path:           v3Config,
+			containerdPath: containerd1,
+			expectedType:   &ConfigV3{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := New(
+				WithPath(tc.path),
+				WithContainerdPath(tc.containerdPath),
+			)
+			require.NoError(t, err)
+			require.IsType(t, tc.expectedType, cfg)
+		})
+	}
+}
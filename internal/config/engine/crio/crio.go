@@ -21,6 +21,7 @@ import (
 	"os"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/tomlfmt"
 	"github.com/pelletier/go-toml"
 )
 
@@ -71,6 +72,18 @@ func (c Config) DefaultRuntime() string {
 	return ""
 }
 
+// Runtimes returns the executables of the runtimes configured in the cri-o config by name
+func (c Config) Runtimes() map[string]string {
+	config := (toml.Tree)(c)
+	return runtimes(&config)
+}
+
+// CDIEnabled checks whether cri-o injects CDI devices. This is always the case for the supported
+// versions of cri-o.
+func (c Config) CDIEnabled() bool {
+	return true
+}
+
 // RemoveRuntime removes a runtime from the cri-o config
 func (c *Config) RemoveRuntime(name string) error {
 	if c == nil {
@@ -100,12 +113,21 @@ func (c *Config) RemoveRuntime(name string) error {
 	return nil
 }
 
+// Render returns the config as it would be written to the specified path
+func (c Config) Render(path string) ([]byte, error) {
+	config := (toml.Tree)(c)
+	output, err := tomlfmt.RenderFile(path, &config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert to TOML: %v", err)
+	}
+	return []byte(output), nil
+}
+
 // Save writes the config to the specified path
 func (c Config) Save(path string) (int64, error) {
-	config := (toml.Tree)(c)
-	output, err := config.ToTomlString()
+	output, err := c.Render(path)
 	if err != nil {
-		return 0, fmt.Errorf("unable to convert to TOML: %v", err)
+		return 0, err
 	}
 
 	if len(output) == 0 {
@@ -116,16 +138,19 @@ func (c Config) Save(path string) (int64, error) {
 		return 0, nil
 	}
 
-	f, err := os.Create(path)
-	if err != nil {
-		return 0, fmt.Errorf("unable to open '%v' for writing: %v", path, err)
-	}
-	defer f.Close()
+	return engine.WriteFile(path, output)
+}
 
-	n, err := f.WriteString(output)
-	if err != nil {
-		return 0, fmt.Errorf("unable to write output: %v", err)
+// runtimes returns the runtime_path of each runtime in the specified config by name.
+func runtimes(config *toml.Tree) map[string]string {
+	configured := make(map[string]string)
+	runtimesTree, ok := config.GetPath([]string{"crio", "runtime", "runtimes"}).(*toml.Tree)
+	if !ok {
+		return configured
 	}
-
-	return int64(n), err
+	for _, name := range runtimesTree.Keys() {
+		path, _ := runtimesTree.GetPath([]string{name, "runtime_path"}).(string)
+		configured[name] = path
+	}
+	return configured
 }
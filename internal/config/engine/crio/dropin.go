@@ -0,0 +1,215 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package crio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/pelletier/go-toml"
+	log "github.com/sirupsen/logrus"
+)
+
+// DropIn represents a cri-o drop-in config file. cri-o applies the *.conf files in the drop-in directory
+// (e.g. /etc/crio/crio.conf.d) in lexical order after the main config file, with the settings in later
+// files taking precedence. Only the drop-in file is updated, with the settings from the main config file
+// and the preceding drop-in files being used to determine the effective config.
+type DropIn struct {
+	*Config
+	// base is the config that results from applying the main config file and the drop-in files that
+	// precede the drop-in file.
+	base *toml.Tree
+	// later are the drop-in files that are applied after the drop-in file.
+	later map[string]*toml.Tree
+}
+
+var _ engine.Interface = (*DropIn)(nil)
+var _ engine.Inspector = (*DropIn)(nil)
+
+// AddRuntime adds a new runtime to the drop-in file. If a runc runtime is configured in the main config
+// file or a preceding drop-in file, its settings are used for the added runtime.
+func (d *DropIn) AddRuntime(name string, path string, setAsDefault bool) error {
+	if d == nil || d.Config == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	config := (toml.Tree)(*d.Config)
+	if config.GetPath([]string{"crio", "runtime", "runtimes", "runc"}) == nil {
+		if runc, ok := d.base.GetPath([]string{"crio", "runtime", "runtimes", "runc"}).(*toml.Tree); ok {
+			runc, _ = toml.Load(runc.String())
+			config.SetPath([]string{"crio", "runtime", "runtimes", name}, runc)
+		}
+	}
+	*d.Config = (Config)(config)
+
+	if err := d.Config.AddRuntime(name, path, setAsDefault); err != nil {
+		return err
+	}
+
+	for _, file := range d.getOverridingFiles([]string{"crio", "runtime", "runtimes", name}) {
+		log.Warnf("The settings for runtime %v are overridden by drop-in file %v", name, file)
+	}
+	if setAsDefault {
+		for _, file := range d.getOverridingFiles([]string{"crio", "runtime", "default_runtime"}) {
+			log.Warnf("The default runtime is overridden by drop-in file %v", file)
+		}
+	}
+	return nil
+}
+
+// DefaultRuntime returns the effective default runtime of cri-o. This includes the main config file and
+// all drop-in files.
+func (d DropIn) DefaultRuntime() string {
+	path := []string{"crio", "runtime", "default_runtime"}
+	for _, file := range d.getOverridingFiles(path) {
+		runtime, _ := d.later[file].GetPath(path).(string)
+		return runtime
+	}
+	if runtime := d.Config.DefaultRuntime(); runtime != "" {
+		return runtime
+	}
+	runtime, _ := d.base.GetPath(path).(string)
+	return runtime
+}
+
+// Runtimes returns the executables of the effective runtimes of cri-o by name. This includes the main
+// config file and all drop-in files.
+func (d DropIn) Runtimes() map[string]string {
+	overlays := []*toml.Tree{(*toml.Tree)(d.Config)}
+	var files []string
+	for file := range d.later {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		overlays = append(overlays, d.later[file])
+	}
+
+	// The trees are copied so that merging does not modify the loaded configs.
+	effective, err := toml.Load(d.base.String())
+	if err != nil {
+		return runtimes(d.base)
+	}
+	for _, overlay := range overlays {
+		copied, err := toml.Load(overlay.String())
+		if err != nil {
+			continue
+		}
+		mergeTree(effective, copied)
+	}
+	return runtimes(effective)
+}
+
+// getOverridingFiles returns the drop-in files that are applied after the drop-in file and that
+// set the specified path. The files are returned in reverse order of precedence.
+func (d DropIn) getOverridingFiles(path []string) []string {
+	var files []string
+	for file, tree := range d.later {
+		if tree.HasPath(path) {
+			files = append(files, file)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files
+}
+
+// loadDropIn loads the drop-in file at the specified path. The drop-in files in the same directory and
+// the specified main config file are loaded to determine the effective config.
+func loadDropIn(mainConfig string, path string) (*DropIn, error) {
+	base, err := loadConfig(mainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config %v: %v", mainConfig, err)
+	}
+
+	path = filepath.Clean(path)
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	later := make(map[string]*toml.Tree)
+	for _, file := range files {
+		if file == path {
+			continue
+		}
+		tree, err := toml.LoadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load drop-in file %v: %v", file, err)
+		}
+		if file > path {
+			later[file] = tree
+			continue
+		}
+		mergeTree((*toml.Tree)(base), tree)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := DropIn{
+		Config: cfg,
+		base:   (*toml.Tree)(base),
+		later:  later,
+	}
+	return &d, nil
+}
+
+// mergeTree merges the settings of the overlay into the specified tree. Tables are merged recursively
+// and all other settings in the overlay replace the settings in the tree.
+func mergeTree(tree *toml.Tree, overlay *toml.Tree) {
+	for _, key := range overlay.Keys() {
+		value := overlay.GetPath([]string{key})
+		if overlayTable, ok := value.(*toml.Tree); ok {
+			if table, ok := tree.GetPath([]string{key}).(*toml.Tree); ok {
+				mergeTree(table, overlayTable)
+				continue
+			}
+		}
+		tree.SetPath([]string{key}, value)
+	}
+}
+
+// Render returns the drop-in file as it would be written to the specified path. If the drop-in file
+// is empty, no contents are returned.
+func (d DropIn) Render(path string) ([]byte, error) {
+	if len((*toml.Tree)(d.Config).Keys()) == 0 {
+		return nil, nil
+	}
+	return d.Config.Render(path)
+}
+
+// Save writes the drop-in file to the specified path, creating the drop-in directory if required. If the
+// drop-in file is empty, it is removed.
+func (d DropIn) Save(path string) (int64, error) {
+	if len((*toml.Tree)(d.Config).Keys()) == 0 {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("unable to remove empty file: %v", err)
+		}
+		return 0, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("unable to create drop-in directory: %v", err)
+	}
+	return d.Config.Save(path)
+}
@@ -0,0 +1,135 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package crio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropIn(t *testing.T) {
+	dir := t.TempDir()
+
+	mainConfig := filepath.Join(dir, "crio.conf")
+	mainContents := `[crio.runtime]
+default_runtime = "runc"
+
+[crio.runtime.runtimes.runc]
+runtime_path = "/usr/bin/runc"
+runtime_root = "/run/runc"
+monitor_path = "/usr/libexec/crio/conmon"
+`
+	require.NoError(t, os.WriteFile(mainConfig, []byte(mainContents), 0644))
+
+	dropInDir := filepath.Join(dir, "crio.conf.d")
+	require.NoError(t, os.MkdirAll(dropInDir, 0755))
+	site := `[crio.runtime.runtimes.runc]
+monitor_path = "/usr/local/bin/conmon"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dropInDir, "10-site.conf"), []byte(site), 0644))
+
+	dropIn := filepath.Join(dropInDir, "99-nvidia.conf")
+
+	cfg, err := New(
+		WithPath(mainConfig),
+		WithDropInPath(dropIn),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "runc", cfg.DefaultRuntime())
+
+	require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", true))
+	require.Equal(t, "nvidia", cfg.DefaultRuntime())
+
+	_, err = cfg.Save(dropIn)
+	require.NoError(t, err)
+
+	saved, err := toml.LoadFile(dropIn)
+	require.NoError(t, err)
+	require.Equal(t, "nvidia", saved.GetPath([]string{"crio", "runtime", "default_runtime"}))
+	require.Equal(t, "/usr/bin/nvidia-container-runtime", saved.GetPath([]string{"crio", "runtime", "runtimes", "nvidia", "runtime_path"}))
+	require.Equal(t, "oci", saved.GetPath([]string{"crio", "runtime", "runtimes", "nvidia", "runtime_type"}))
+	require.Equal(t, "/run/runc", saved.GetPath([]string{"crio", "runtime", "runtimes", "nvidia", "runtime_root"}))
+	require.Equal(t, "/usr/local/bin/conmon", saved.GetPath([]string{"crio", "runtime", "runtimes", "nvidia", "monitor_path"}))
+	require.Nil(t, saved.GetPath([]string{"crio", "runtime", "runtimes", "runc"}))
+
+	contents, err := os.ReadFile(mainConfig)
+	require.NoError(t, err)
+	require.Equal(t, mainContents, string(contents))
+
+	cfg, err = New(
+		WithPath(mainConfig),
+		WithDropInPath(dropIn),
+	)
+	require.NoError(t, err)
+	require.NoError(t, cfg.RemoveRuntime("nvidia"))
+	require.Equal(t, "runc", cfg.DefaultRuntime())
+
+	n, err := cfg.Save(dropIn)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.NoFileExists(t, dropIn)
+	require.FileExists(t, filepath.Join(dropInDir, "10-site.conf"))
+
+	// Saving an empty drop-in file that does not exist is a no-op.
+	_, err = cfg.Save(dropIn)
+	require.NoError(t, err)
+}
+
+func TestDropInPrecedence(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "99-zz-override.conf"), []byte("[crio.runtime]\ndefault_runtime = \"crun\"\n"), 0644))
+
+	cfg, err := New(
+		WithPath(filepath.Join(dir, "missing.conf")),
+		WithDropInPath(filepath.Join(dir, "99-nvidia.conf")),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", true))
+	require.Equal(t, "crun", cfg.DefaultRuntime())
+}
+
+func TestDropInRuntimes(t *testing.T) {
+	dir := t.TempDir()
+
+	mainConfig := filepath.Join(dir, "crio.conf")
+	require.NoError(t, os.WriteFile(mainConfig, []byte("[crio.runtime.runtimes.runc]\nruntime_path = \"/usr/bin/runc\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "99-zz-override.conf"), []byte("[crio.runtime.runtimes.nvidia]\nruntime_path = \"/opt/bin/nvidia-container-runtime\"\n"), 0644))
+
+	cfg, err := New(
+		WithPath(mainConfig),
+		WithDropInPath(filepath.Join(dir, "99-nvidia.conf")),
+	)
+	require.NoError(t, err)
+	require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", true))
+
+	inspector, ok := cfg.(engine.Inspector)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"runc":   "/usr/bin/runc",
+		"nvidia": "/opt/bin/nvidia-container-runtime",
+	}, inspector.Runtimes())
+	require.True(t, inspector.CDIEnabled())
+	require.Equal(t, map[string]string{"nvidia": "/usr/bin/nvidia-container-runtime"}, cfg.(*DropIn).Config.Runtimes(),
+		"the loaded drop-in file is not modified")
+}
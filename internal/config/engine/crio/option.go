@@ -20,12 +20,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
 	"github.com/pelletier/go-toml"
 	log "github.com/sirupsen/logrus"
 )
 
 type builder struct {
-	path string
+	path       string
+	dropInPath string
 }
 
 // Option defines a function that can be used to configure the config builder
@@ -38,10 +40,24 @@ func WithPath(path string) Option {
 	}
 }
 
-func (b *builder) build() (*Config, error) {
+// WithDropInPath sets the path of the drop-in file for the config builder. If this is set, only the
+// drop-in file is updated and the config file specified by WithPath is used as the main config file.
+func WithDropInPath(dropInPath string) Option {
+	return func(b *builder) {
+		b.dropInPath = dropInPath
+	}
+}
+
+func (b *builder) build() (engine.Interface, error) {
+	if b.dropInPath != "" {
+		return loadDropIn(b.path, b.dropInPath)
+	}
 	if b.path == "" {
-		empty := toml.Tree{}
-		return (*Config)(&empty), nil
+		empty, err := toml.TreeFromMap(map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		return (*Config)(empty), nil
 	}
 
 	return loadConfig(b.path)
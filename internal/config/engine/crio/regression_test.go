@@ -0,0 +1,146 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package crio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+// loadRegressionConfig loads the specified config using the cri-o config type of this package and
+// returns the config together with its TOML tree.
+func loadRegressionConfig(t *testing.T, path string) (*Config, *toml.Tree) {
+	cfg, err := New(WithPath(path))
+	require.NoError(t, err)
+	c, ok := cfg.(*Config)
+	require.True(t, ok, "unexpected config type %T", cfg)
+	return c, (*toml.Tree)(c)
+}
+
+// getRuntimeSettings returns the settings of the specified runtime handler as a map. If the runtime
+// handler is not configured, nil is returned.
+func getRuntimeSettings(tree *toml.Tree, name string) map[string]interface{} {
+	settings, ok := tree.GetPath([]string{"crio", "runtime", "runtimes", name}).(*toml.Tree)
+	if !ok {
+		return nil
+	}
+	return settings.ToMap()
+}
+
+// withoutPaths returns the contents of the specified tree as a map with the specified paths removed.
+func withoutPaths(t *testing.T, tree *toml.Tree, paths ...[]string) map[string]interface{} {
+	copied, err := toml.Load(tree.String())
+	require.NoError(t, err)
+	for _, path := range paths {
+		if copied.HasPath(path) {
+			require.NoError(t, copied.DeletePath(path))
+		}
+	}
+	return copied.ToMap()
+}
+
+func TestRegressionConfigs(t *testing.T) {
+	const (
+		runtimeName = "nvidia"
+		runtimePath = "/usr/bin/nvidia-container-runtime"
+	)
+
+	configs, err := filepath.Glob("testdata/*.conf")
+	require.NoError(t, err)
+	require.NotEmpty(t, configs)
+
+	for _, config := range configs {
+		for _, setAsDefault := range []bool{false, true} {
+			name := strings.TrimSuffix(filepath.Base(config), ".conf")
+			if setAsDefault {
+				name += "-default"
+			}
+			t.Run(name, func(t *testing.T) {
+				original, originalTree := loadRegressionConfig(t, config)
+
+				contents, err := os.ReadFile(config)
+				require.NoError(t, err)
+				path := filepath.Join(t.TempDir(), "crio.conf")
+				require.NoError(t, os.WriteFile(path, contents, 0644))
+
+				cfg, err := New(WithPath(path))
+				require.NoError(t, err)
+				require.NoError(t, cfg.AddRuntime(runtimeName, runtimePath, setAsDefault))
+				_, err = cfg.Save(path)
+				require.NoError(t, err)
+				requireCommentsPreserved(t, contents, path)
+
+				updated, updatedTree := loadRegressionConfig(t, path)
+				require.Equal(t, runtimePath, updated.Runtimes()[runtimeName])
+
+				nvidia := getRuntimeSettings(updatedTree, runtimeName)
+				require.Equal(t, "oci", nvidia["runtime_type"])
+				if runc := getRuntimeSettings(originalTree, "runc"); runc != nil {
+					require.Equal(t, runc["runtime_root"], nvidia["runtime_root"])
+					require.Equal(t, runc["allowed_annotations"], nvidia["allowed_annotations"])
+				}
+				for name := range original.Runtimes() {
+					require.Equal(t, getRuntimeSettings(originalTree, name), getRuntimeSettings(updatedTree, name), "runtime %v", name)
+				}
+				if setAsDefault {
+					require.Equal(t, runtimeName, updated.DefaultRuntime())
+				} else {
+					require.Equal(t, original.DefaultRuntime(), updated.DefaultRuntime())
+				}
+
+				cfg, err = New(WithPath(path))
+				require.NoError(t, err)
+				require.NoError(t, cfg.RemoveRuntime(runtimeName))
+				_, err = cfg.Save(path)
+				require.NoError(t, err)
+				requireCommentsPreserved(t, contents, path)
+				// Removing the runtime restores the original config.
+				if !setAsDefault {
+					reverted, err := os.ReadFile(path)
+					require.NoError(t, err)
+					require.Equal(t, string(contents), string(reverted))
+				}
+
+				reverted, revertedTree := loadRegressionConfig(t, path)
+				require.NotContains(t, reverted.Runtimes(), runtimeName)
+				require.NotEqual(t, runtimeName, reverted.DefaultRuntime())
+				// Apart from the default runtime (which is removed if the NVIDIA runtime was set as the
+				// default), the original config is restored.
+				ignored := []string{"crio", "runtime", "default_runtime"}
+				require.Equal(t, withoutPaths(t, originalTree, ignored), withoutPaths(t, revertedTree, ignored))
+			})
+		}
+	}
+}
+
+// requireCommentsPreserved checks that all comments in the original config are retained in
+// the config at the specified path.
+func requireCommentsPreserved(t *testing.T, original []byte, path string) {
+	updated, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(original), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		require.Contains(t, string(updated), line)
+	}
+}
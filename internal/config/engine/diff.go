@@ -0,0 +1,88 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff returns a unified diff of the changes that saving the specified config to the specified
+// path would make to the file. The diff is empty if the file would not be changed.
+func Diff(cfg Interface, path string) (string, error) {
+	updated, err := cfg.Render(path)
+	if err != nil {
+		return "", err
+	}
+	return DiffFile(path, updated)
+}
+
+// DiffFile returns a unified diff of the changes that writing the specified contents to the
+// specified path would make to the file. Empty contents indicate that the file is removed.
+func DiffFile(path string, updated []byte) (string, error) {
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to read %v: %v", path, err)
+	}
+
+	if bytes.Equal(current, updated) {
+		return "", nil
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        splitLines(current),
+		B:        splitLines(updated),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// DryRun writes a unified diff of the changes that saving the specified config to the specified
+// path would make to the specified writer. If the file would be changed, an error is returned.
+func DryRun(w io.Writer, cfg Interface, path string) error {
+	diff, err := Diff(cfg, path)
+	if err != nil {
+		return fmt.Errorf("unable to render config: %v", err)
+	}
+	if diff == "" {
+		return nil
+	}
+	fmt.Fprint(w, diff)
+	return fmt.Errorf("changes would be made to %v", path)
+}
+
+// splitLines splits the specified contents into newline-terminated lines. Empty contents (e.g. a
+// file that does not exist) have no lines.
+func splitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(contents), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		return lines[:last]
+	}
+	// The last line is terminated so that it is separated from the following line of the diff.
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,103 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type renderedConfig []byte
+
+func (c renderedConfig) DefaultRuntime() string                { return "" }
+func (c renderedConfig) AddRuntime(string, string, bool) error { return nil }
+func (c renderedConfig) RemoveRuntime(string) error            { return nil }
+func (c renderedConfig) Save(string) (int64, error)            { return 0, nil }
+func (c renderedConfig) Render(string) ([]byte, error)         { return c, nil }
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		description  string
+		current      *string
+		updated      string
+		expectedDiff string
+	}{
+		{
+			description: "missing file remains empty",
+		},
+		{
+			description: "unchanged file",
+			current:     ptr("a\nb\n"),
+			updated:     "a\nb\n",
+		},
+		{
+			description:  "new file",
+			updated:      "a\n",
+			expectedDiff: "@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			description:  "changed file",
+			current:      ptr("a\nb\n"),
+			updated:      "a\nc\n",
+			expectedDiff: "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			description:  "removed file",
+			current:      ptr("a\n"),
+			expectedDiff: "@@ -1 +0,0 @@\n-a\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if tc.current != nil {
+				require.NoError(t, os.WriteFile(path, []byte(*tc.current), 0644))
+			}
+
+			diff, err := Diff(renderedConfig(tc.updated), path)
+			require.NoError(t, err)
+			if tc.expectedDiff == "" {
+				require.Empty(t, diff)
+			} else {
+				require.Equal(t, "--- "+path+"\n+++ "+path+"\n"+tc.expectedDiff, diff)
+			}
+
+			buf := &bytes.Buffer{}
+			err = DryRun(buf, renderedConfig(tc.updated), path)
+			require.Equal(t, diff, buf.String())
+			if tc.expectedDiff == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+
+			// A dry run does not modify the file.
+			if tc.current == nil {
+				require.NoFileExists(t, path)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
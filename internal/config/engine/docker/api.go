@@ -0,0 +1,125 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	unixHostPrefix = "unix://"
+
+	// DefaultContext is the name of the docker context that uses the default socket.
+	DefaultContext = "default"
+)
+
+// Info is the subset of the system information returned by the Docker API that is used to check
+// whether the daemon has applied the runtime config.
+type Info struct {
+	Runtimes       map[string]InfoRuntime `json:"Runtimes"`
+	DefaultRuntime string                 `json:"DefaultRuntime"`
+}
+
+// InfoRuntime describes a runtime that is configured for the daemon.
+type InfoRuntime struct {
+	Path string `json:"path"`
+}
+
+// GetSocketPath returns the path of the unix socket for the specified Docker host. The host can be
+// specified as a URL (e.g. unix:///run/user/1000/docker.sock) or as a path. Only unix sockets are supported.
+func GetSocketPath(host string) (string, error) {
+	path := strings.TrimPrefix(host, unixHostPrefix)
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("unsupported docker host %q: only unix sockets are supported", host)
+	}
+	return path, nil
+}
+
+// GetContextHost returns the Docker host of the specified docker context. The contexts are read from
+// the specified docker config directory (e.g. ~/.docker). The default context uses the specified
+// default host.
+func GetContextHost(configDir string, name string, defaultHost string) (string, error) {
+	if name == DefaultContext {
+		return defaultHost, nil
+	}
+
+	metaPath := filepath.Join(configDir, "contexts", "meta", contextDigest(name), "meta.json")
+	contents, err := os.ReadFile(metaPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read docker context %q: %v", name, err)
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(contents, &meta); err != nil {
+		return "", fmt.Errorf("failed to parse docker context %q: %v", name, err)
+	}
+	host := meta.Endpoints["docker"].Host
+	if host == "" {
+		return "", fmt.Errorf("docker context %q does not define a docker endpoint", name)
+	}
+	return host, nil
+}
+
+// contextDigest returns the name of the directory in which the metadata of the specified docker
+// context is stored.
+func contextDigest(name string) string {
+	digest := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(digest[:])
+}
+
+// GetInfo queries the system information of the Docker daemon listening on the specified unix socket.
+func GetInfo(socket string, timeout time.Duration) (*Info, error) {
+	client := http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://docker/info")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from docker API: %v", resp.Status)
+	}
+
+	var info Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode docker info: %v", err)
+	}
+	return &info, nil
+}
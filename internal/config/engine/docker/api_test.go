@@ -0,0 +1,147 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package docker
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSocketPath(t *testing.T) {
+	testCases := []struct {
+		host          string
+		expected      string
+		expectedError bool
+	}{
+		{
+			host:     "unix:///run/user/1000/docker.sock",
+			expected: "/run/user/1000/docker.sock",
+		},
+		{
+			host:     "/var/run/docker.sock",
+			expected: "/var/run/docker.sock",
+		},
+		{
+			host:          "tcp://127.0.0.1:2375",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			path, err := GetSocketPath(tc.host)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, path)
+		})
+	}
+}
+
+func TestGetContextHost(t *testing.T) {
+	configDir := t.TempDir()
+
+	testCases := []struct {
+		description   string
+		name          string
+		meta          string
+		expected      string
+		expectedError bool
+	}{
+		{
+			description: "default context uses default host",
+			name:        DefaultContext,
+			expected:    "unix:///var/run/docker.sock",
+		},
+		{
+			description:   "missing context is an error",
+			name:          "missing",
+			expectedError: true,
+		},
+		{
+			description: "host is read from context metadata",
+			name:        "rootless",
+			meta:        `{"Name":"rootless","Endpoints":{"docker":{"Host":"unix:///run/user/1000/docker.sock"}}}`,
+			expected:    "unix:///run/user/1000/docker.sock",
+		},
+		{
+			description:   "context without docker endpoint is an error",
+			name:          "other",
+			meta:          `{"Name":"other","Endpoints":{}}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if tc.meta != "" {
+				dir := filepath.Join(configDir, "contexts", "meta", contextDigest(tc.name))
+				require.NoError(t, os.MkdirAll(dir, 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "meta.json"), []byte(tc.meta), 0644))
+			}
+
+			host, err := GetContextHost(configDir, tc.name, "unix:///var/run/docker.sock")
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, host)
+		})
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/info" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"DefaultRuntime": "runc",
+				"Runtimes": map[string]interface{}{
+					"runc":   map[string]string{"path": "runc"},
+					"nvidia": map[string]string{"path": "nvidia-container-runtime"},
+				},
+			})
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	info, err := GetInfo(socket, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "runc", info.DefaultRuntime)
+	require.Equal(t, InfoRuntime{Path: "nvidia-container-runtime"}, info.Runtimes["nvidia"])
+
+	_, err = GetInfo(filepath.Join(t.TempDir(), "missing.sock"), time.Second)
+	require.Error(t, err)
+}
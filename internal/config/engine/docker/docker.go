@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
 )
@@ -32,6 +33,8 @@ const (
 // TODO: This should not be public, but we need to access it from the tests in tools/container/docker
 type Config map[string]interface{}
 
+var _ engine.Inspector = (*Config)(nil)
+
 // New creates a docker config with the specified options
 func New(opts ...Option) (engine.Interface, error) {
 	b := &builder{}
@@ -81,6 +84,33 @@ func (c Config) DefaultRuntime() string {
 	return r
 }
 
+// Runtimes returns the paths of the runtimes configured in the docker config by name
+func (c Config) Runtimes() map[string]string {
+	configured := make(map[string]string)
+	runtimes, ok := c["runtimes"].(map[string]interface{})
+	if !ok {
+		return configured
+	}
+	for name, settings := range runtimes {
+		var path string
+		if settings, ok := settings.(map[string]interface{}); ok {
+			path, _ = settings["path"].(string)
+		}
+		configured[name] = path
+	}
+	return configured
+}
+
+// CDIEnabled checks whether the cdi feature is enabled in the docker config
+func (c Config) CDIEnabled() bool {
+	features, ok := c["features"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	enabled, _ := features["cdi"].(bool)
+	return enabled
+}
+
 // RemoveRuntime removes a runtime from the docker config
 func (c *Config) RemoveRuntime(name string) error {
 	if c == nil {
@@ -110,31 +140,40 @@ func (c *Config) RemoveRuntime(name string) error {
 	return nil
 }
 
+// Render returns the config as it would be written to the specified path
+func (c Config) Render(path string) ([]byte, error) {
+	output, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert to JSON: %v", err)
+	}
+	return output, nil
+}
+
 // Save writes the config to the specified path
 func (c Config) Save(path string) (int64, error) {
-	output, err := json.MarshalIndent(c, "", "    ")
+	output, err := c.Render(path)
 	if err != nil {
-		return 0, fmt.Errorf("unable to convert to JSON: %v", err)
+		return 0, err
 	}
 
 	if len(output) == 0 {
 		err := os.Remove(path)
 		if err != nil {
-			return 0, fmt.Errorf("unable to remove empty file: %v", err)
+			return 0, fmt.Errorf("unable to remove empty file: %v", readOnlyError(err))
 		}
 		return 0, nil
 	}
 
-	f, err := os.Create(path)
+	// The parent directory may not exist for engines whose config is stored on a
+	// data partition (e.g. balena-engine).
+	err = os.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
-		return 0, fmt.Errorf("unable to open %v for writing: %v", path, err)
+		return 0, fmt.Errorf("unable to create directory for %v: %v", path, readOnlyError(err))
 	}
-	defer f.Close()
 
-	n, err := f.WriteString(string(output))
+	n, err := engine.WriteFile(path, output)
 	if err != nil {
-		return 0, fmt.Errorf("unable to write output: %v", err)
+		return 0, readOnlyError(err)
 	}
-
-	return int64(n), nil
+	return n, nil
 }
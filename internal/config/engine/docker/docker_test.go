@@ -213,3 +213,54 @@ func TestUpdateConfigRuntimes(t *testing.T) {
 
 	}
 }
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		description        string
+		config             Config
+		expectedRuntimes   map[string]string
+		expectedCDIEnabled bool
+	}{
+		{
+			description:      "empty config",
+			config:           Config{},
+			expectedRuntimes: map[string]string{},
+		},
+		{
+			description: "runtimes and cdi feature",
+			config: Config{
+				"runtimes": map[string]interface{}{
+					"nvidia": map[string]interface{}{
+						"path": "/usr/bin/nvidia-container-runtime",
+						"args": []interface{}{},
+					},
+					"other": map[string]interface{}{},
+				},
+				"features": map[string]interface{}{
+					"cdi": true,
+				},
+			},
+			expectedRuntimes: map[string]string{
+				"nvidia": "/usr/bin/nvidia-container-runtime",
+				"other":  "",
+			},
+			expectedCDIEnabled: true,
+		},
+		{
+			description: "cdi feature disabled",
+			config: Config{
+				"features": map[string]interface{}{
+					"cdi": false,
+				},
+			},
+			expectedRuntimes: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedRuntimes, tc.config.Runtimes())
+			require.Equal(t, tc.expectedCDIEnabled, tc.config.CDIEnabled())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+const (
+	// FlavorDefault is the host flavor for a standard Docker installation.
+	FlavorDefault = "default"
+	// FlavorBalena is the host flavor for BalenaOS where the balena-engine runs with a
+	// read-only root filesystem.
+	FlavorBalena = "balena"
+)
+
+// Flavor defines the locations used to configure the Docker engine on a particular
+// type of host.
+type Flavor struct {
+	Name string
+	// ConfigFilePath is the path to the engine's daemon.json file.
+	ConfigFilePath string
+	// Socket is the path to the socket used to signal the engine.
+	Socket string
+	// Daemon is the name of the engine daemon.
+	Daemon string
+	// InstallRoot is the folder under which the NVIDIA Container Toolkit is installed.
+	InstallRoot string
+}
+
+var flavors = map[string]Flavor{
+	FlavorDefault: {
+		Name:           FlavorDefault,
+		ConfigFilePath: "/etc/docker/daemon.json",
+		Socket:         "/var/run/docker.sock",
+		Daemon:         "docker",
+		InstallRoot:    "/usr/local/nvidia",
+	},
+	// On BalenaOS the root filesystem is read-only and only the data partition
+	// (mounted at /mnt/data) persists across reboots and host OS updates.
+	FlavorBalena: {
+		Name:           FlavorBalena,
+		ConfigFilePath: "/mnt/data/balena-engine/daemon.json",
+		Socket:         "/var/run/balena-engine.sock",
+		Daemon:         "balena-engine",
+		InstallRoot:    "/mnt/data/nvidia",
+	},
+}
+
+// GetFlavor returns the host flavor with the specified name. An empty name
+// selects the default flavor.
+func GetFlavor(name string) (*Flavor, error) {
+	if name == "" {
+		name = FlavorDefault
+	}
+	f, ok := flavors[name]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized host flavor '%v'", name)
+	}
+	return &f, nil
+}
+
+// readOnlyError adds a description of how to configure the engine on hosts with a
+// read-only root filesystem to errors caused by writing to a read-only filesystem.
+func readOnlyError(err error) error {
+	if !errors.Is(err, syscall.EROFS) {
+		return err
+	}
+	return fmt.Errorf("%v; on hosts with a read-only root filesystem such as BalenaOS use --host-flavor=%v "+
+		"or specify a config file on a writable filesystem and start the engine with --config-file pointing to it", err, FlavorBalena)
+}
@@ -0,0 +1,76 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package docker
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlavor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		expectedConfig string
+		expectedSocket string
+		expectedError  bool
+	}{
+		{
+			name:           "",
+			expectedConfig: "/etc/docker/daemon.json",
+			expectedSocket: "/var/run/docker.sock",
+		},
+		{
+			name:           FlavorDefault,
+			expectedConfig: "/etc/docker/daemon.json",
+			expectedSocket: "/var/run/docker.sock",
+		},
+		{
+			name:           FlavorBalena,
+			expectedConfig: "/mnt/data/balena-engine/daemon.json",
+			expectedSocket: "/var/run/balena-engine.sock",
+		},
+		{
+			name:          "unknown",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := GetFlavor(tc.name)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedConfig, f.ConfigFilePath)
+			require.Equal(t, tc.expectedSocket, f.Socket)
+		})
+	}
+}
+
+func TestReadOnlyError(t *testing.T) {
+	err := fmt.Errorf("some error")
+	require.Equal(t, err, readOnlyError(err))
+
+	err = &os.PathError{Op: "open", Path: "/etc/docker/daemon.json", Err: syscall.EROFS}
+	require.Contains(t, readOnlyError(err).Error(), "--host-flavor=balena")
+}
@@ -0,0 +1,170 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+)
+
+// defaultFileMode is the mode of config files that are created by WriteFile.
+const defaultFileMode = 0644
+
+// FileLock is an advisory lock that serializes updates to one or more config files.
+type FileLock struct {
+	dirs []*os.File
+}
+
+// Lock acquires an exclusive lock that serializes updates to the config files at the specified
+// paths across processes. Since WriteFile replaces a config file, the lock is held on the directory
+// containing the file instead, with the directory being created if it does not exist. The
+// directories are locked in a fixed order so that concurrent calls for overlapping sets of config
+// files do not deadlock. The call blocks until the lock is acquired.
+func Lock(paths ...string) (*FileLock, error) {
+	var dirPaths []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		dirPath, err := filepath.Abs(filepath.Dir(resolvePath(path)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve config directory: %w", err)
+		}
+		if seen[dirPath] {
+			continue
+		}
+		seen[dirPath] = true
+		dirPaths = append(dirPaths, dirPath)
+	}
+	sort.Strings(dirPaths)
+
+	l := &FileLock{}
+	for _, dirPath := range dirPaths {
+		dir, err := lockDir(dirPath)
+		if err != nil {
+			l.Unlock()
+			return nil, err
+		}
+		l.dirs = append(l.dirs, dir)
+	}
+	return l, nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() {
+	for i := len(l.dirs) - 1; i >= 0; i-- {
+		syscall.Flock(int(l.dirs[i].Fd()), syscall.LOCK_UN)
+		l.dirs[i].Close()
+	}
+	l.dirs = nil
+}
+
+// lockDir acquires an exclusive lock on the specified directory, creating it if required.
+func lockDir(path string) (*os.File, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create config directory: %w", err)
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open config directory: %w", err)
+	}
+	if err := syscall.Flock(int(dir.Fd()), syscall.LOCK_EX); err != nil {
+		dir.Close()
+		return nil, fmt.Errorf("unable to lock config directory %v: %w", path, err)
+	}
+	return dir, nil
+}
+
+// WriteFile atomically replaces the file at the specified path with the specified contents. The
+// contents are written to a temporary file in the same directory that is flushed to disk before it
+// is renamed to the path, so that a crash leaves either the original or the updated file. The mode
+// and ownership of an existing file are preserved. If the path is a symlink, the file it refers to
+// is replaced instead of the symlink.
+func WriteFile(path string, contents []byte) (int64, error) {
+	path = resolvePath(path)
+	dir := filepath.Dir(path)
+
+	mode := os.FileMode(defaultFileMode)
+	uid, gid := -1, -1
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(stat.Uid), int(stat.Gid)
+		}
+	}
+
+	// The temporary file is hidden so that it is not picked up by engines that read all files
+	// in a drop-in directory.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, fmt.Errorf("unable to create temporary file for %v: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := tmp.Write(contents)
+	if err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("unable to write output: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("unable to set file mode: %w", err)
+	}
+	if uid != -1 && (uid != os.Geteuid() || gid != os.Getegid()) {
+		if err := tmp.Chown(uid, gid); err != nil {
+			tmp.Close()
+			return 0, fmt.Errorf("unable to set file owner: %w", err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("unable to flush output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("unable to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("unable to replace %v: %w", path, err)
+	}
+	if err := syncDir(dir); err != nil {
+		return 0, fmt.Errorf("unable to flush config directory: %w", err)
+	}
+
+	return int64(n), nil
+}
+
+// resolvePath returns the path with any symlinks resolved. If the path cannot be resolved (e.g.
+// because the file does not exist yet), it is returned unmodified.
+func resolvePath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// syncDir flushes the specified directory to disk so that a rename in the directory is persisted.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
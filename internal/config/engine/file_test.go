@@ -0,0 +1,148 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	testCases := []struct {
+		description  string
+		current      *string
+		currentMode  os.FileMode
+		expectedMode os.FileMode
+	}{
+		{
+			description:  "new file",
+			expectedMode: 0644,
+		},
+		{
+			description:  "existing file mode is preserved",
+			current:      ptr("a\n"),
+			currentMode:  0600,
+			expectedMode: 0600,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.toml")
+			if tc.current != nil {
+				require.NoError(t, os.WriteFile(path, []byte(*tc.current), tc.currentMode))
+			}
+
+			n, err := WriteFile(path, []byte("b\n"))
+			require.NoError(t, err)
+			require.EqualValues(t, 2, n)
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, "b\n", string(contents))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMode, info.Mode().Perm())
+
+			// No temporary files are left behind.
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestWriteFileMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "config.toml")
+
+	_, err := WriteFile(path, []byte("a\n"))
+	require.Error(t, err)
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, os.WriteFile(target, []byte("original"), 0600))
+
+	link := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.Symlink(target, link))
+
+	_, err := WriteFile(link, []byte("updated"))
+	require.NoError(t, err)
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+
+	contents, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "updated", string(contents))
+
+	info, err = os.Stat(target)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	// Paths in the same directory are locked once.
+	lock, err := Lock(path, filepath.Join(dir, "other.toml"))
+	require.NoError(t, err)
+	require.Len(t, lock.dirs, 1)
+
+	locked := make(chan struct{})
+	go func() {
+		other, err := Lock(path)
+		if err == nil {
+			other.Unlock()
+		}
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("lock was acquired while held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	lock.Unlock()
+
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not acquired after release")
+	}
+}
+
+func TestLockCreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "config.toml")
+
+	lock, err := Lock(path)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	require.DirExists(t, filepath.Dir(path))
+}
@@ -0,0 +1,97 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package podman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/pelletier/go-toml"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultConfigFilePath is the drop-in file that is updated for rootful podman. Since the
+	// files in containers.conf.d are merged with containers.conf, the main config file and the
+	// defaults shipped by the distribution are not modified.
+	DefaultConfigFilePath = "/etc/containers/containers.conf.d/99-nvidia.conf"
+
+	dropInFilename = "99-nvidia.conf"
+)
+
+type builder struct {
+	path string
+}
+
+// Option defines a function that can be used to configure the config builder
+type Option func(*builder)
+
+// WithPath sets the path for the config builder
+func WithPath(path string) Option {
+	return func(b *builder) {
+		b.path = path
+	}
+}
+
+func (b *builder) build() (engine.Interface, error) {
+	if b.path == "" {
+		empty, err := toml.TreeFromMap(map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		return (*Config)(empty), nil
+	}
+
+	return loadConfig(b.path)
+}
+
+// GetRootlessConfigFilePath returns the drop-in file that is updated for rootless podman. This is
+// in the containers/containers.conf.d directory of XDG_CONFIG_HOME if set and ~/.config otherwise.
+func GetRootlessConfigFilePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine user config dir: %v", err)
+	}
+	return filepath.Join(configDir, "containers", "containers.conf.d", dropInFilename), nil
+}
+
+// loadConfig loads the podman config from disk
+func loadConfig(config string) (*Config, error) {
+	log.Infof("Loading config: %v", config)
+
+	info, err := os.Stat(config)
+	if err == nil && info.IsDir() {
+		return nil, fmt.Errorf("config file is a directory")
+	}
+
+	configFile := config
+	if os.IsNotExist(err) {
+		configFile = "/dev/null"
+		log.Infof("Config file does not exist, creating new one")
+	}
+
+	cfg, err := toml.LoadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Successfully loaded config")
+
+	return (*Config)(cfg), nil
+}
@@ -0,0 +1,201 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package podman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/tomlfmt"
+	"github.com/pelletier/go-toml"
+)
+
+// Config represents the podman config (containers.conf or a file in containers.conf.d)
+type Config toml.Tree
+
+var _ engine.Interface = (*Config)(nil)
+var _ engine.Inspector = (*Config)(nil)
+
+// New creates a podman config with the specified options
+func New(opts ...Option) (engine.Interface, error) {
+	b := &builder{}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b.build()
+}
+
+// AddRuntime adds a new OCI runtime to the podman config. The runtime is added to the list of
+// runtimes in the engine table and can be selected using the podman --runtime flag.
+func (c *Config) AddRuntime(name string, path string, setAsDefault bool) error {
+	if c == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	config := (toml.Tree)(*c)
+
+	config.SetPath([]string{"engine", "runtimes", name}, []string{path})
+
+	if setAsDefault {
+		config.SetPath([]string{"engine", "runtime"}, name)
+	}
+
+	*c = (Config)(config)
+	return nil
+}
+
+// DefaultRuntime returns the default runtime for the podman config
+func (c Config) DefaultRuntime() string {
+	config := (toml.Tree)(c)
+	if runtime, ok := config.GetPath([]string{"engine", "runtime"}).(string); ok {
+		return runtime
+	}
+	return ""
+}
+
+// RemoveRuntime removes a runtime from the podman config
+func (c *Config) RemoveRuntime(name string) error {
+	if c == nil {
+		return nil
+	}
+
+	config := (toml.Tree)(*c)
+	if runtime, ok := config.GetPath([]string{"engine", "runtime"}).(string); ok {
+		if runtime == name {
+			config.DeletePath([]string{"engine", "runtime"})
+		}
+	}
+
+	config.DeletePath([]string{"engine", "runtimes", name})
+	prune(&config, []string{"engine", "runtimes"})
+
+	*c = (Config)(config)
+	return nil
+}
+
+// Runtimes returns the first path of each runtime configured in the podman config by name
+func (c Config) Runtimes() map[string]string {
+	config := (toml.Tree)(c)
+	configured := make(map[string]string)
+	runtimes, ok := config.GetPath([]string{"engine", "runtimes"}).(*toml.Tree)
+	if !ok {
+		return configured
+	}
+	for _, name := range runtimes.Keys() {
+		var path string
+		switch paths := runtimes.GetPath([]string{name}).(type) {
+		case []string:
+			if len(paths) > 0 {
+				path = paths[0]
+			}
+		case []interface{}:
+			if len(paths) > 0 {
+				path, _ = paths[0].(string)
+			}
+		}
+		configured[name] = path
+	}
+	return configured
+}
+
+// CDIEnabled checks whether podman injects CDI devices. This is always the case for the supported
+// versions of podman.
+func (c Config) CDIEnabled() bool {
+	return true
+}
+
+// SetCDISpecDirs sets the directories that podman searches for CDI specifications. If no
+// directories are specified, the setting is removed and the podman defaults apply.
+func (c *Config) SetCDISpecDirs(dirs ...string) {
+	if c == nil {
+		return
+	}
+
+	config := (toml.Tree)(*c)
+	if len(dirs) == 0 {
+		config.DeletePath([]string{"engine", "cdi_spec_dirs"})
+		prune(&config, []string{"engine"})
+	} else {
+		config.SetPath([]string{"engine", "cdi_spec_dirs"}, dirs)
+	}
+
+	*c = (Config)(config)
+}
+
+// CDISpecDirs returns the directories that podman searches for CDI specifications.
+func (c Config) CDISpecDirs() []string {
+	config := (toml.Tree)(c)
+	switch values := config.GetPath([]string{"engine", "cdi_spec_dirs"}).(type) {
+	case []string:
+		return values
+	case []interface{}:
+		var dirs []string
+		for _, v := range values {
+			if dir, ok := v.(string); ok {
+				dirs = append(dirs, dir)
+			}
+		}
+		return dirs
+	}
+	return nil
+}
+
+// Render returns the config as it would be written to the specified path
+func (c Config) Render(path string) ([]byte, error) {
+	config := (toml.Tree)(c)
+	output, err := tomlfmt.RenderFile(path, &config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert to TOML: %v", err)
+	}
+	return []byte(output), nil
+}
+
+// Save writes the config to the specified path. If the config is empty, the file is removed.
+func (c Config) Save(path string) (int64, error) {
+	output, err := c.Render(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(output) == 0 {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("unable to remove empty file: %v", err)
+		}
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("unable to create config directory: %v", err)
+	}
+
+	return engine.WriteFile(path, output)
+}
+
+// prune removes the empty tables along the specified path, starting with the innermost table.
+func prune(config *toml.Tree, path []string) {
+	for i := len(path); i > 0; i-- {
+		entry, ok := config.GetPath(path[:i]).(*toml.Tree)
+		if !ok || len(entry.Keys()) != 0 {
+			return
+		}
+		config.DeletePath(path[:i])
+	}
+}
@@ -0,0 +1,124 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package podman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRuntime(t *testing.T) {
+	testCases := []struct {
+		description    string
+		config         string
+		setAsDefault   bool
+		cdiSpecDirs    []string
+		expectedConfig string
+	}{
+		{
+			description: "empty config",
+			expectedConfig: `
+[engine]
+
+  [engine.runtimes]
+    nvidia = ["/usr/bin/nvidia-container-runtime"]
+`,
+		},
+		{
+			description:  "set as default with cdi spec dirs",
+			setAsDefault: true,
+			cdiSpecDirs:  []string{"/etc/cdi", "/var/run/cdi"},
+			expectedConfig: `
+[engine]
+  cdi_spec_dirs = ["/etc/cdi", "/var/run/cdi"]
+  runtime = "nvidia"
+
+  [engine.runtimes]
+    nvidia = ["/usr/bin/nvidia-container-runtime"]
+`,
+		},
+		{
+			description: "existing runtimes are retained",
+			config: `[engine]
+  [engine.runtimes]
+    crun = ["/usr/bin/crun"]
+`,
+			expectedConfig: `[engine]
+  [engine.runtimes]
+    crun = ["/usr/bin/crun"]
+    nvidia = ["/usr/bin/nvidia-container-runtime"]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "containers.conf.d", "99-nvidia.conf")
+			if tc.config != "" {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(tc.config), 0644))
+			}
+
+			cfg, err := New(WithPath(path))
+			require.NoError(t, err)
+
+			require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", tc.setAsDefault))
+			cfg.(*Config).SetCDISpecDirs(tc.cdiSpecDirs...)
+
+			_, err = cfg.Save(path)
+			require.NoError(t, err)
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedConfig, string(contents))
+
+			if tc.setAsDefault {
+				require.Equal(t, "nvidia", cfg.DefaultRuntime())
+			}
+			require.Equal(t, tc.cdiSpecDirs, cfg.(*Config).CDISpecDirs())
+		})
+	}
+}
+
+func TestRemoveRuntime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "99-nvidia.conf")
+
+	cfg, err := New(WithPath(path))
+	require.NoError(t, err)
+	require.NoError(t, cfg.AddRuntime("nvidia", "/usr/bin/nvidia-container-runtime", true))
+	cfg.(*Config).SetCDISpecDirs("/etc/cdi")
+	_, err = cfg.Save(path)
+	require.NoError(t, err)
+
+	cfg, err = New(WithPath(path))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/etc/cdi"}, cfg.(*Config).CDISpecDirs())
+
+	require.NoError(t, cfg.RemoveRuntime("nvidia"))
+	cfg.(*Config).SetCDISpecDirs()
+	require.Equal(t, "", cfg.DefaultRuntime())
+
+	n, err := cfg.Save(path)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,275 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package tomlfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// section is a table of the original document, consisting of the header line (if any) and
+// the lines up to the next header.
+type section struct {
+	table  []string
+	header string
+	lines  []*line
+}
+
+// line is a key-value pair or any other (e.g. comment or blank) lines in a section.
+type line struct {
+	// text contains the original text. A value may span multiple lines.
+	text []string
+	// key is the (dotted) key relative to the table of the section. This is nil for non-key lines.
+	key     []string
+	keyText string
+	indent  string
+	comment string
+	// inline indicates whether the value is an inline table.
+	inline bool
+}
+
+// parse splits the specified document into sections.
+func parse(doc string) ([]*section, error) {
+	lines := strings.Split(strings.TrimSuffix(doc, "\n"), "\n")
+
+	root := &section{}
+	sections := []*section{root}
+	current := root
+	for i := 0; i < len(lines); i++ {
+		text := lines[i]
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			current.lines = append(current.lines, &line{text: []string{text}})
+		case strings.HasPrefix(trimmed, "[["):
+			return nil, fmt.Errorf("arrays of tables are not supported")
+		case strings.HasPrefix(trimmed, "["):
+			table, rest, err := parseKey(trimmed[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid table header %q: %v", trimmed, err)
+			}
+			rest = strings.TrimSpace(rest)
+			if !strings.HasPrefix(rest, "]") {
+				return nil, fmt.Errorf("invalid table header %q", trimmed)
+			}
+			if rest = strings.TrimSpace(rest[1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("invalid table header %q", trimmed)
+			}
+			current = &section{
+				table:  table,
+				header: text,
+			}
+			sections = append(sections, current)
+		default:
+			l, end, err := parseKeyValue(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.lines = append(current.lines, l)
+			i = end
+		}
+	}
+	return sections, nil
+}
+
+// parseKeyValue parses the key-value pair starting at the specified line. The index of the
+// last line of the value is also returned.
+func parseKeyValue(lines []string, i int) (*line, int, error) {
+	text := lines[i]
+	trimmed := strings.TrimLeft(text, " \t")
+
+	key, rest, err := parseKey(trimmed)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid key in %q: %v", text, err)
+	}
+	keyText := strings.TrimSpace(trimmed[:len(trimmed)-len(rest)])
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return nil, 0, fmt.Errorf("expected '=' in %q", text)
+	}
+	value := strings.TrimLeft(rest[1:], " \t")
+
+	col := len(text) - len(value)
+	end, comment, err := scanValue(lines, i, col)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	l := line{
+		text:    lines[i : end+1],
+		key:     key,
+		keyText: keyText,
+		indent:  text[:len(text)-len(trimmed)],
+		comment: comment,
+		inline:  strings.HasPrefix(value, "{"),
+	}
+	return &l, end, nil
+}
+
+// parseKey parses a (dotted) key at the start of s and returns the key parts and the
+// remainder of s.
+func parseKey(s string) ([]string, string, error) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil, "", fmt.Errorf("unexpected end of key")
+		}
+		var part string
+		switch s[0] {
+		case '"':
+			end := 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			var err error
+			part, err = unquote(s[:end+1])
+			if err != nil {
+				return nil, "", err
+			}
+			s = s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, "", fmt.Errorf("unterminated literal key")
+			}
+			part = s[1 : end+1]
+			s = s[end+2:]
+		default:
+			end := 0
+			for ; end < len(s) && isBareKeyChar(s[end]); end++ {
+			}
+			if end == 0 {
+				return nil, "", fmt.Errorf("invalid key character %q", s[0])
+			}
+			part = s[:end]
+			s = s[end:]
+		}
+		parts = append(parts, part)
+
+		rest := strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(rest, ".") {
+			return parts, s, nil
+		}
+		s = rest[1:]
+	}
+}
+
+// unquote returns the value of a basic (double-quoted) string.
+func unquote(s string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			return "", fmt.Errorf("unsupported escape sequence in %v", s)
+		}
+	}
+	return b.String(), nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// scanValue finds the end of the value starting at the specified column of line i. The index of
+// the last line of the value and the trailing comment (if any) are returned.
+func scanValue(lines []string, i int, col int) (int, string, error) {
+	const (
+		none = iota
+		basic
+		literal
+		multilineBasic
+		multilineLiteral
+	)
+	state := none
+	depth := 0
+	for ; i < len(lines); i, col = i+1, 0 {
+		s := lines[i]
+		for j := col; j < len(s); j++ {
+			switch state {
+			case basic:
+				if s[j] == '\\' {
+					j++
+				} else if s[j] == '"' {
+					state = none
+				}
+			case literal:
+				if s[j] == '\'' {
+					state = none
+				}
+			case multilineBasic:
+				if s[j] == '\\' {
+					j++
+				} else if strings.HasPrefix(s[j:], `"""`) {
+					state = none
+					j += 2
+				}
+			case multilineLiteral:
+				if strings.HasPrefix(s[j:], "'''") {
+					state = none
+					j += 2
+				}
+			default:
+				switch {
+				case s[j] == '#':
+					if depth == 0 {
+						return i, s[j:], nil
+					}
+					j = len(s)
+				case strings.HasPrefix(s[j:], `"""`):
+					state = multilineBasic
+					j += 2
+				case strings.HasPrefix(s[j:], "'''"):
+					state = multilineLiteral
+					j += 2
+				case s[j] == '"':
+					state = basic
+				case s[j] == '\'':
+					state = literal
+				case s[j] == '[' || s[j] == '{':
+					depth++
+				case s[j] == ']' || s[j] == '}':
+					depth--
+				}
+			}
+		}
+		if state == basic || state == literal {
+			return 0, "", fmt.Errorf("unterminated string in %q", s)
+		}
+		if depth == 0 && state == none {
+			return i, "", nil
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated value")
+}
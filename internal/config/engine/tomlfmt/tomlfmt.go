@@ -0,0 +1,325 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package tomlfmt renders updated TOML configs while preserving the comments and
+// formatting of the original config file.
+package tomlfmt
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Render returns the TOML representation of the updated config. The comments, ordering, and
+// formatting of the original config are preserved for all tables and keys that are retained
+// in the updated config. Keys that were added are appended to their (existing) tables and
+// new tables are appended to the end of the document. If the original config contains
+// constructs that cannot be preserved, the canonical representation of the updated config
+// is returned instead.
+func Render(original []byte, updated *toml.Tree) (string, error) {
+	if updated == nil || len(updated.Keys()) == 0 {
+		return "", nil
+	}
+	if len(strings.TrimSpace(string(original))) > 0 {
+		if output, err := Merge(original, updated); err == nil {
+			return output, nil
+		}
+	}
+	return updated.ToTomlString()
+}
+
+// RenderFile returns the TOML representation of the updated config, preserving the comments
+// and formatting of the config file at the specified path. A file that does not exist is
+// treated as empty.
+func RenderFile(path string, updated *toml.Tree) (string, error) {
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to read %v: %v", path, err)
+	}
+	return Render(original, updated)
+}
+
+// Merge applies the updated config to the original config, preserving its comments and
+// formatting. An error is returned if this is not possible.
+func Merge(original []byte, updated *toml.Tree) (string, error) {
+	originalTree, err := toml.LoadBytes(original)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse original config: %v", err)
+	}
+
+	doc, err := parse(string(original))
+	if err != nil {
+		return "", err
+	}
+
+	m := merger{
+		original: originalTree,
+		updated:  updated,
+		emitted:  make(map[string]bool),
+		headers:  make(map[string]bool),
+	}
+	output, err := m.merge(doc)
+	if err != nil {
+		return "", err
+	}
+	// The trailing newlines of the original config are retained. This ensures that removing
+	// a table that was previously appended restores the original config.
+	trailing := len(original) - len(strings.TrimRight(string(original), "\n"))
+	if trailing == 0 {
+		trailing = 1
+	}
+	output = strings.TrimRight(output, "\n") + strings.Repeat("\n", trailing)
+
+	// We ensure that the output is equivalent to the updated config. This protects
+	// against constructs that are not handled (e.g. tables defined using dotted keys).
+	merged, err := toml.Load(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse merged config: %v", err)
+	}
+	if !reflect.DeepEqual(merged.ToMap(), updated.ToMap()) {
+		return "", fmt.Errorf("merged config does not match updated config")
+	}
+
+	return output, nil
+}
+
+type merger struct {
+	original *toml.Tree
+	updated  *toml.Tree
+	// emitted records the keys that have been written to the output.
+	emitted map[string]bool
+	// headers records the tables for which a header has been written to the output.
+	headers map[string]bool
+	out     strings.Builder
+}
+
+func (m *merger) merge(doc []*section) (string, error) {
+	for _, s := range doc {
+		if err := m.mergeSection(s); err != nil {
+			return "", err
+		}
+	}
+	if err := m.appendNewTables(nil, m.updated); err != nil {
+		return "", err
+	}
+	return m.out.String(), nil
+}
+
+// mergeSection writes the specified section of the original document to the output. Keys that
+// were removed are dropped, modified values are replaced, and new keys are appended.
+func (m *merger) mergeSection(s *section) error {
+	var table *toml.Tree
+	if len(s.table) == 0 {
+		table = m.updated
+	} else {
+		t, ok := m.updated.GetPath(s.table).(*toml.Tree)
+		if !ok {
+			// The table was removed.
+			return nil
+		}
+		table = t
+		m.headers[pathKey(s.table)] = true
+	}
+
+	var lines []string
+	if s.header != "" {
+		lines = append(lines, s.header)
+	}
+	indent := ""
+	for _, l := range s.lines {
+		if l.key == nil {
+			lines = append(lines, l.text...)
+			continue
+		}
+		indent = l.indent
+		path := join(s.table, l.key...)
+		value := m.updated.GetPath(path)
+		if value == nil {
+			continue
+		}
+		if _, ok := value.(*toml.Tree); ok && !l.inline {
+			return fmt.Errorf("unsupported table value for key %v", strings.Join(l.key, "."))
+		}
+		m.emitted[pathKey(path)] = true
+		if valuesEqual(m.original.GetPath(path), value) {
+			lines = append(lines, l.text...)
+			continue
+		}
+		rendered, err := renderValue(value)
+		if err != nil {
+			return err
+		}
+		line := l.indent + l.keyText + " = " + rendered
+		if l.comment != "" {
+			line += " " + l.comment
+		}
+		lines = append(lines, line)
+	}
+
+	// New keys are inserted before any trailing blank lines of the section.
+	var added []string
+	for _, k := range sortedKeys(table) {
+		path := join(s.table, k)
+		value := table.GetPath([]string{k})
+		if value == nil || isTable(value) || m.emitted[pathKey(path)] {
+			continue
+		}
+		rendered, err := renderValue(value)
+		if err != nil {
+			return err
+		}
+		m.emitted[pathKey(path)] = true
+		added = append(added, indent+quoteKey(k)+" = "+rendered)
+	}
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	lines = append(lines[:end], append(added, lines[end:]...)...)
+
+	for _, l := range lines {
+		m.out.WriteString(l)
+		m.out.WriteString("\n")
+	}
+	return nil
+}
+
+// appendNewTables appends the tables containing keys that were not written for any of the
+// sections of the original document.
+func (m *merger) appendNewTables(path []string, t *toml.Tree) error {
+	var values []string
+	var tables []string
+	for _, k := range sortedKeys(t) {
+		value := t.GetPath([]string{k})
+		if isTable(value) {
+			tables = append(tables, k)
+			continue
+		}
+		if m.emitted[pathKey(join(path, k))] {
+			continue
+		}
+		values = append(values, k)
+	}
+
+	needsHeader := len(path) > 0 && !m.headers[pathKey(path)] && (len(values) > 0 || len(t.Keys()) == 0)
+	if len(values) > 0 && len(path) == 0 {
+		return fmt.Errorf("unexpected root keys %v", values)
+	}
+	if needsHeader {
+		var parts []string
+		for _, p := range path {
+			parts = append(parts, quoteKey(p))
+		}
+		// Tables are separated by a blank line.
+		if out := m.out.String(); out != "" && !strings.HasSuffix(out, "\n\n") {
+			m.out.WriteString("\n")
+		}
+		m.out.WriteString("[" + strings.Join(parts, ".") + "]\n")
+		m.headers[pathKey(path)] = true
+	}
+	for _, k := range values {
+		rendered, err := renderValue(t.GetPath([]string{k}))
+		if err != nil {
+			return err
+		}
+		m.out.WriteString("  " + quoteKey(k) + " = " + rendered + "\n")
+	}
+
+	for _, k := range tables {
+		sub, ok := t.GetPath([]string{k}).(*toml.Tree)
+		if !ok {
+			return fmt.Errorf("unsupported array of tables %v", k)
+		}
+		subpath := join(path, k)
+		if m.emitted[pathKey(subpath)] {
+			// The table was written as an inline table.
+			continue
+		}
+		if err := m.appendNewTables(subpath, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTable checks whether the specified value is a (non-inline) table or an array of tables.
+func isTable(value interface{}) bool {
+	switch value.(type) {
+	case *toml.Tree, []*toml.Tree:
+		return true
+	}
+	return false
+}
+
+// valuesEqual checks whether two values are equal.
+func valuesEqual(a interface{}, b interface{}) bool {
+	if ta, ok := a.(*toml.Tree); ok {
+		tb, ok := b.(*toml.Tree)
+		return ok && reflect.DeepEqual(ta.ToMap(), tb.ToMap())
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// renderValue returns the TOML representation of the specified (non-table) value.
+func renderValue(value interface{}) (string, error) {
+	t, err := toml.TreeFromMap(map[string]interface{}{"v": value})
+	if err != nil {
+		return "", fmt.Errorf("failed to render value: %v", err)
+	}
+	s, err := t.ToTomlString()
+	if err != nil {
+		return "", fmt.Errorf("failed to render value: %v", err)
+	}
+	if !strings.HasPrefix(s, "v = ") {
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(s, "v = ")), nil
+}
+
+// sortedKeys returns the keys of the specified tree in alphabetical order.
+func sortedKeys(t *toml.Tree) []string {
+	keys := t.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteKey quotes the specified key if it is not a valid bare key.
+func quoteKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for _, c := range k {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return fmt.Sprintf("%q", k)
+		}
+	}
+	return k
+}
+
+// join returns a new path consisting of the specified path and keys.
+func join(path []string, keys ...string) []string {
+	return append(append([]string{}, path...), keys...)
+}
+
+// pathKey returns a string representation of a path that can be used as a map key.
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
@@ -0,0 +1,161 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package tomlfmt
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		description string
+		original    string
+		update      func(*toml.Tree)
+		expected    string
+	}{
+		{
+			description: "empty config returns empty string",
+			original:    "# comment\nversion = 2\n",
+			update: func(c *toml.Tree) {
+				c.Delete("version")
+			},
+			expected: "",
+		},
+		{
+			description: "unchanged config is preserved",
+			original: `# File generated by k3s
+version = 2 # the version
+
+[plugins]
+  [plugins."io.containerd.grpc.v1.cri"]
+    # the sandbox image
+    sandbox_image = "pause:3.6"
+`,
+			update: func(c *toml.Tree) {},
+			expected: `# File generated by k3s
+version = 2 # the version
+
+[plugins]
+  [plugins."io.containerd.grpc.v1.cri"]
+    # the sandbox image
+    sandbox_image = "pause:3.6"
+`,
+		},
+		{
+			description: "changed value keeps trailing comment",
+			original: `# header
+version = 2 # the version
+`,
+			update: func(c *toml.Tree) {
+				c.Set("version", int64(3))
+			},
+			expected: `# header
+version = 3 # the version
+`,
+		},
+		{
+			description: "added key is appended to existing table",
+			original: `[plugins.cri]
+  # the sandbox image
+  sandbox_image = "pause:3.6"
+
+[other]
+  key = "value"
+`,
+			update: func(c *toml.Tree) {
+				c.SetPath([]string{"plugins", "cri", "default_runtime_name"}, "nvidia")
+			},
+			expected: `[plugins.cri]
+  # the sandbox image
+  sandbox_image = "pause:3.6"
+  default_runtime_name = "nvidia"
+
+[other]
+  key = "value"
+`,
+		},
+		{
+			description: "added table is appended",
+			original: `# comment
+[plugins.cri]
+  sandbox_image = "pause:3.6"
+`,
+			update: func(c *toml.Tree) {
+				c.SetPath([]string{"plugins", "cri", "runtimes", "nvidia", "runtime_type"}, "io.containerd.runc.v2")
+			},
+			expected: `# comment
+[plugins.cri]
+  sandbox_image = "pause:3.6"
+
+[plugins.cri.runtimes.nvidia]
+  runtime_type = "io.containerd.runc.v2"
+`,
+		},
+		{
+			description: "removed table is dropped",
+			original: `# comment
+[plugins.cri]
+  sandbox_image = "pause:3.6"
+
+# the nvidia runtime
+[plugins.cri.runtimes.nvidia]
+  runtime_type = "io.containerd.runc.v2"
+`,
+			update: func(c *toml.Tree) {
+				c.DeletePath([]string{"plugins", "cri", "runtimes"})
+			},
+			expected: `# comment
+[plugins.cri]
+  sandbox_image = "pause:3.6"
+
+# the nvidia runtime
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			original, err := toml.Load(tc.original)
+			require.NoError(t, err)
+
+			tc.update(original)
+
+			output, err := Render([]byte(tc.original), original)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestRenderFallsBackToCanonical(t *testing.T) {
+	original := `# comment
+[[servers]]
+  name = "a"
+`
+	config, err := toml.Load(original)
+	require.NoError(t, err)
+
+	expected, err := config.ToTomlString()
+	require.NoError(t, err)
+
+	output, err := Render([]byte(original), config)
+	require.NoError(t, err)
+	require.Equal(t, expected, output)
+}
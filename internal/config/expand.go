@@ -0,0 +1,139 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// The following variables are defined for use in config values in addition to
+// the environment of the process reading the config. Environment variables of
+// the same name take precedence.
+const (
+	// ConfigDirVariable is the directory containing the config file.
+	ConfigDirVariable = "CONFIG_DIR"
+	// RuntimeDirVariable is the directory for runtime files. This is
+	// XDG_RUNTIME_DIR if set and /run otherwise.
+	RuntimeDirVariable = "RUNTIME_DIR"
+)
+
+// variableReference matches references of the form ${NAME} or ${NAME:-default}.
+var variableReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expander expands variable references and relative paths in config values.
+type expander struct {
+	configDir string
+	lookup    func(string) (string, bool)
+}
+
+// newExpander creates an expander for a config file in the specified directory.
+// If the directory is empty, relative paths are not resolved.
+func newExpander(configDir string) expander {
+	return expander{
+		configDir: configDir,
+		lookup:    os.LookupEnv,
+	}
+}
+
+// LoadTOMLFile loads the specified config file and expands references to
+// variables and relative paths in its string values.
+func LoadTOMLFile(path string) (*toml.Tree, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadTOML(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine config directory: %v", err)
+	}
+	newExpander(configDir).expandTree(tree)
+
+	return tree, nil
+}
+
+// expandTree expands the string values (including those in arrays and nested tables) in the specified tree in-place.
+func (e expander) expandTree(tree *toml.Tree) {
+	for _, key := range tree.Keys() {
+		path := []string{key}
+		switch value := tree.GetPath(path).(type) {
+		case string:
+			tree.SetPath(path, e.expand(value))
+		case []interface{}:
+			for i, element := range value {
+				if s, ok := element.(string); ok {
+					value[i] = e.expand(s)
+				}
+			}
+		case *toml.Tree:
+			e.expandTree(value)
+		case []*toml.Tree:
+			for _, t := range value {
+				e.expandTree(t)
+			}
+		}
+	}
+}
+
+// expand replaces references to variables in the specified value. Variables that
+// are not defined are replaced with their default value, or the empty string if no
+// default is specified. If the expanded value is a relative path (starting with ./
+// or ../) it is resolved relative to the config directory.
+func (e expander) expand(value string) string {
+	expanded := variableReference.ReplaceAllStringFunc(value, func(reference string) string {
+		match := variableReference.FindStringSubmatch(reference)
+		if v, ok := e.getVariable(match[1]); ok {
+			return v
+		}
+		return match[3]
+	})
+
+	if e.configDir != "" && (strings.HasPrefix(expanded, "./") || strings.HasPrefix(expanded, "../")) {
+		return filepath.Join(e.configDir, expanded)
+	}
+	return expanded
+}
+
+// getVariable returns the value of the specified variable from the environment or the built-in variables.
+func (e expander) getVariable(name string) (string, bool) {
+	if v, ok := e.lookup(name); ok {
+		return v, true
+	}
+
+	switch name {
+	case ConfigDirVariable:
+		if e.configDir != "" {
+			return e.configDir, true
+		}
+	case RuntimeDirVariable:
+		if v, ok := e.lookup("XDG_RUNTIME_DIR"); ok && v != "" {
+			return v, true
+		}
+		return "/run", true
+	}
+	return "", false
+}
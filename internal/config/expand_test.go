@@ -0,0 +1,165 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{
+		"DRIVER_ROOT": "/run/nvidia/driver",
+		"EMPTY":       "",
+	}
+
+	testCases := []struct {
+		description string
+		configDir   string
+		env         map[string]string
+		value       string
+		expected    string
+	}{
+		{
+			description: "no references",
+			value:       "/usr/bin/nvidia-ctk",
+			expected:    "/usr/bin/nvidia-ctk",
+		},
+		{
+			description: "unbraced references are not expanded",
+			value:       "$DRIVER_ROOT",
+			expected:    "$DRIVER_ROOT",
+		},
+		{
+			description: "environment variable",
+			value:       "${DRIVER_ROOT}/usr/lib",
+			expected:    "/run/nvidia/driver/usr/lib",
+		},
+		{
+			description: "undefined variable is empty",
+			value:       "${UNDEFINED}",
+			expected:    "",
+		},
+		{
+			description: "default for undefined variable",
+			value:       "${UNDEFINED:-/}",
+			expected:    "/",
+		},
+		{
+			description: "default is not used for empty variable",
+			value:       "${EMPTY:-/}",
+			expected:    "",
+		},
+		{
+			description: "runtime dir defaults to /run",
+			value:       "${RUNTIME_DIR}/cdi",
+			expected:    "/run/cdi",
+		},
+		{
+			description: "runtime dir uses XDG_RUNTIME_DIR",
+			env: map[string]string{
+				"XDG_RUNTIME_DIR": "/run/user/1000",
+			},
+			value:    "${RUNTIME_DIR}/cdi",
+			expected: "/run/user/1000/cdi",
+		},
+		{
+			description: "runtime dir from environment takes precedence",
+			env: map[string]string{
+				"RUNTIME_DIR":     "/var/run",
+				"XDG_RUNTIME_DIR": "/run/user/1000",
+			},
+			value:    "${RUNTIME_DIR}/cdi",
+			expected: "/var/run/cdi",
+		},
+		{
+			description: "config dir",
+			configDir:   "/etc/nvidia-container-runtime",
+			value:       "${CONFIG_DIR}/host-files-for-container.d",
+			expected:    "/etc/nvidia-container-runtime/host-files-for-container.d",
+		},
+		{
+			description: "config dir is undefined without config file",
+			value:       "${CONFIG_DIR:-/etc}/cdi",
+			expected:    "/etc/cdi",
+		},
+		{
+			description: "relative path is resolved",
+			configDir:   "/etc/nvidia-container-runtime",
+			value:       "../cdi",
+			expected:    "/etc/cdi",
+		},
+		{
+			description: "relative path is not resolved without config dir",
+			value:       "./cdi",
+			expected:    "./cdi",
+		},
+		{
+			description: "non-path values are not resolved",
+			configDir:   "/etc/nvidia-container-runtime",
+			value:       "@/sbin/ldconfig.real",
+			expected:    "@/sbin/ldconfig.real",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if tc.env == nil {
+				tc.env = env
+			}
+			e := expander{
+				configDir: tc.configDir,
+				lookup: func(name string) (string, bool) {
+					v, ok := tc.env[name]
+					return v, ok
+				},
+			}
+			require.Equal(t, tc.expected, e.expand(tc.value))
+		})
+	}
+}
+
+func TestLoadTOMLFile(t *testing.T) {
+	configDir := t.TempDir()
+	configFile := filepath.Join(configDir, "config.toml")
+
+	contents := []string{
+		`[nvidia-container-cli]`,
+		`root = "${NVIDIA_CTK_TEST_DRIVER_ROOT:-/}"`,
+		`[nvidia-container-runtime]`,
+		`runtimes = ["./runc", "crun"]`,
+		`[nvidia-container-runtime.modes.cdi]`,
+		`spec-dirs = ["${CONFIG_DIR}/cdi", "/etc/cdi"]`,
+	}
+	require.NoError(t, os.WriteFile(configFile, []byte(strings.Join(contents, "\n")), 0644))
+
+	t.Setenv("NVIDIA_CTK_TEST_DRIVER_ROOT", "/run/nvidia/driver")
+
+	tree, err := LoadTOMLFile(configFile)
+	require.NoError(t, err)
+
+	cfg, err := getConfigFrom(tree)
+	require.NoError(t, err)
+
+	require.Equal(t, "/run/nvidia/driver", cfg.NVIDIAContainerCLIConfig.Root)
+	require.Equal(t, []string{filepath.Join(configDir, "runc"), "crun"}, cfg.NVIDIAContainerRuntimeConfig.Runtimes)
+	require.Equal(t, []string{filepath.Join(configDir, "cdi"), "/etc/cdi"}, cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs)
+}
@@ -0,0 +1,48 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/features"
+	"github.com/pelletier/go-toml"
+)
+
+// featuresDummy allows us to unmarshal only the features config from a *toml.Tree
+type featuresDummy struct {
+	Features map[string]bool `toml:"features"`
+}
+
+// getFeaturesConfigFrom reads the features config from the specified toml Tree.
+// An error is returned if an unknown feature is set.
+func getFeaturesConfigFrom(toml *toml.Tree) (map[string]bool, error) {
+	if toml == nil || !toml.Has("features") {
+		return nil, nil
+	}
+
+	var f featuresDummy
+	if err := toml.Unmarshal(&f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal features config: %v", err)
+	}
+
+	if _, err := features.New(f.Features); err != nil {
+		return nil, err
+	}
+
+	return f.Features, nil
+}
@@ -0,0 +1,52 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzLoadConfig checks that loading malformed config files returns an error instead of
+// panicking and that loading a config is deterministic.
+func FuzzLoadConfig(f *testing.F) {
+	samples, _ := filepath.Glob("../../config/config.toml.*")
+	for _, sample := range samples {
+		contents, err := os.ReadFile(sample)
+		require.NoError(f, err)
+		f.Add(contents)
+	}
+	f.Add([]byte("accept-nvidia-visible-devices-envvar-when-unprivileged = \"true\""))
+	f.Add([]byte("[nvidia-container-runtime]\nmode = \"cdi\"\nruntimes = [\"runc\", 1]"))
+	f.Add([]byte("[devices.\"GPU-0\"]\nenv = [\"FOO=${BAR:-baz}\"]\n[[devices.\"GPU-0\".extra-mounts]]\nhost-path = \"./licenses\""))
+
+	f.Fuzz(func(t *testing.T, contents []byte) {
+		cfg, err := loadConfigFrom(bytes.NewReader(contents))
+		if err != nil {
+			return
+		}
+		require.NotNil(t, cfg)
+
+		again, err := loadConfigFrom(bytes.NewReader(contents))
+		require.NoError(t, err)
+		require.Equal(t, cfg, again)
+	})
+}
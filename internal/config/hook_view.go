@@ -0,0 +1,124 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// hookOption defines an option consumed by the nvidia-container-runtime-hook (or the
+// nvidia-container-cli that it invokes) and its default value. Options without a default
+// value are only included in the hook config if these are set.
+type hookOption struct {
+	key          string
+	defaultValue interface{}
+}
+
+// hookOptions lists the options consumed by the nvidia-container-runtime-hook.
+// These must be kept in sync with the HookConfig in cmd/nvidia-container-runtime-hook/hook_config.go.
+// This is checked by TestHookViewIncludesReadOptions in cmd/nvidia-container-runtime-hook.
+var hookOptions = []hookOption{
+	{"disable-require", false},
+	{"swarm-resource", nil},
+	{"accept-nvidia-visible-devices-envvar-when-unprivileged", true},
+	{"accept-nvidia-visible-devices-as-volume-mounts", false},
+	{"supported-driver-capabilities", nil},
+	{"nvidia-container-cli.root", nil},
+	{"nvidia-container-cli.path", nil},
+	{"nvidia-container-cli.environment", []interface{}{}},
+	{"nvidia-container-cli.debug", nil},
+	{"nvidia-container-cli.ldcache", nil},
+	{"nvidia-container-cli.load-kmods", true},
+	{"nvidia-container-cli.no-pivot", false},
+	{"nvidia-container-cli.no-cgroups", false},
+	{"nvidia-container-cli.user", nil},
+	{"nvidia-container-cli.ldconfig", nil},
+	{"nvidia-container-runtime.mode", GetDefaultRuntimeConfig().Mode},
+	{"nvidia-container-runtime.modes.legacy.cuda-compat-mode", nil},
+	{"nvidia-container-runtime.modes.legacy.allowed-capabilities", nil},
+	{"nvidia-container-runtime.modes.legacy.ldconfig-strategy", nil},
+	{"nvidia-container-runtime-hook.skip-mode-detection", GetDefaultRuntimeHookConfig().SkipModeDetection},
+}
+
+// hookTables lists the config tables that are consumed by the nvidia-container-runtime-hook as a whole.
+// These are included in the hook config as is if these are set.
+var hookTables = []string{
+	"nvidia-container-runtime.device-limits",
+	"nvidia-container-runtime.nvml-throttle",
+}
+
+// GetHookConfigFrom returns the config consumed by the nvidia-container-runtime-hook as derived from
+// the specified config. Only the options read by the hook are included and default values are set
+// explicitly. The ldconfig path is resolved relative to the driver root (nvidia-container-cli.root)
+// so that the hook uses the same driver root as the NVIDIA Container Runtime.
+func GetHookConfigFrom(tree *toml.Tree) (*toml.Tree, error) {
+	hookConfig, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range hookOptions {
+		value := o.defaultValue
+		if tree != nil && tree.Has(o.key) {
+			value = tree.Get(o.key)
+		}
+		if value == nil {
+			continue
+		}
+		if _, ok := value.(*toml.Tree); ok {
+			return nil, fmt.Errorf("invalid value for %v: expected a value, not a table", o.key)
+		}
+		hookConfig.Set(o.key, value)
+	}
+
+	for _, key := range hookTables {
+		if tree == nil || !tree.Has(key) {
+			continue
+		}
+		table, ok := tree.Get(key).(*toml.Tree)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %v: expected a table", key)
+		}
+		hookConfig.Set(key, table)
+	}
+
+	if ldconfig, ok := hookConfig.Get("nvidia-container-cli.ldconfig").(string); ok {
+		root, _ := hookConfig.Get("nvidia-container-cli.root").(string)
+		hookConfig.Set("nvidia-container-cli.ldconfig", ResolveLdconfigPath(root, ldconfig))
+	}
+
+	return hookConfig, nil
+}
+
+// ResolveLdconfigPath returns the ldconfig path relative to the specified driver root. Paths
+// prefixed with '@' are interpreted by the nvidia-container-cli as paths on the host and are
+// prefixed with the driver root unless these are already in the driver root.
+func ResolveLdconfigPath(root string, ldconfig string) string {
+	if root == "" || !strings.HasPrefix(ldconfig, "@") {
+		return ldconfig
+	}
+	path := strings.TrimPrefix(ldconfig, "@")
+	root = filepath.Clean(root)
+	if root == "/" || path == root || strings.HasPrefix(path, root+"/") {
+		return ldconfig
+	}
+	return "@" + filepath.Join(root, path)
+}
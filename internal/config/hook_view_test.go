@@ -0,0 +1,186 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHookConfigFrom(t *testing.T) {
+	testCases := []struct {
+		description   string
+		contents      []string
+		expected      map[string]interface{}
+		expectedError bool
+	}{
+		{
+			description: "defaults",
+			expected: map[string]interface{}{
+				"disable-require": false,
+				"accept-nvidia-visible-devices-envvar-when-unprivileged": true,
+				"accept-nvidia-visible-devices-as-volume-mounts":         false,
+				"nvidia-container-cli": map[string]interface{}{
+					"environment": []interface{}{},
+					"load-kmods":  true,
+					"no-pivot":    false,
+					"no-cgroups":  false,
+				},
+				"nvidia-container-runtime": map[string]interface{}{
+					"mode": "auto",
+				},
+				"nvidia-container-runtime-hook": map[string]interface{}{
+					"skip-mode-detection": false,
+				},
+			},
+		},
+		{
+			description: "runtime options are not included",
+			contents: []string{
+				"disable-require = true",
+				"supported-driver-capabilities = \"compute,utility\"",
+				"[nvidia-container-cli]",
+				"root = \"/run/nvidia/driver\"",
+				"path = \"/usr/local/nvidia/toolkit/nvidia-container-cli\"",
+				"debug = \"/var/log/nvidia-container-toolkit.log\"",
+				"ldconfig = \"@/sbin/ldconfig.real\"",
+				"load-kmods = false",
+				"[nvidia-container-runtime]",
+				"debug = \"/var/log/nvidia-container-runtime.log\"",
+				"log-level = \"debug\"",
+				"mode = \"legacy\"",
+				"[nvidia-container-runtime.modes.cdi]",
+				"default-kind = \"nvidia.com/gpu\"",
+				"[nvidia-ctk]",
+				"path = \"/usr/bin/nvidia-ctk\"",
+			},
+			expected: map[string]interface{}{
+				"disable-require":                                        true,
+				"supported-driver-capabilities":                          "compute,utility",
+				"accept-nvidia-visible-devices-envvar-when-unprivileged": true,
+				"accept-nvidia-visible-devices-as-volume-mounts":         false,
+				"nvidia-container-cli": map[string]interface{}{
+					"root":        "/run/nvidia/driver",
+					"path":        "/usr/local/nvidia/toolkit/nvidia-container-cli",
+					"environment": []interface{}{},
+					"debug":       "/var/log/nvidia-container-toolkit.log",
+					"ldconfig":    "@/run/nvidia/driver/sbin/ldconfig.real",
+					"load-kmods":  false,
+					"no-pivot":    false,
+					"no-cgroups":  false,
+				},
+				"nvidia-container-runtime": map[string]interface{}{
+					"mode": "legacy",
+				},
+				"nvidia-container-runtime-hook": map[string]interface{}{
+					"skip-mode-detection": false,
+				},
+			},
+		},
+		{
+			description: "tables read by the hook are included",
+			contents: []string{
+				"[nvidia-container-runtime.device-limits]",
+				"max-devices-per-container = 2",
+				"[nvidia-container-runtime.device-limits.namespaces]",
+				"training = 8",
+				"[nvidia-container-runtime.nvml-throttle]",
+				"max-concurrent = 4",
+			},
+			expected: map[string]interface{}{
+				"disable-require": false,
+				"accept-nvidia-visible-devices-envvar-when-unprivileged": true,
+				"accept-nvidia-visible-devices-as-volume-mounts":         false,
+				"nvidia-container-cli": map[string]interface{}{
+					"environment": []interface{}{},
+					"load-kmods":  true,
+					"no-pivot":    false,
+					"no-cgroups":  false,
+				},
+				"nvidia-container-runtime": map[string]interface{}{
+					"mode": "auto",
+					"device-limits": map[string]interface{}{
+						"max-devices-per-container": int64(2),
+						"namespaces": map[string]interface{}{
+							"training": int64(8),
+						},
+					},
+					"nvml-throttle": map[string]interface{}{
+						"max-concurrent": int64(4),
+					},
+				},
+				"nvidia-container-runtime-hook": map[string]interface{}{
+					"skip-mode-detection": false,
+				},
+			},
+		},
+		{
+			description: "invalid table",
+			contents: []string{
+				"[nvidia-container-runtime]",
+				"nvml-throttle = 4",
+			},
+			expectedError: true,
+		},
+		{
+			description: "invalid option",
+			contents: []string{
+				"[disable-require]",
+				"value = true",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			tree, err := toml.Load(strings.Join(tc.contents, "\n"))
+			require.NoError(t, err)
+
+			hookConfig, err := GetHookConfigFrom(tree)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expected, hookConfig.ToMap())
+		})
+	}
+}
+
+func TestResolveLdconfigPath(t *testing.T) {
+	testCases := []struct {
+		root     string
+		ldconfig string
+		expected string
+	}{
+		{root: "", ldconfig: "@/sbin/ldconfig", expected: "@/sbin/ldconfig"},
+		{root: "/", ldconfig: "@/sbin/ldconfig", expected: "@/sbin/ldconfig"},
+		{root: "/run/nvidia/driver", ldconfig: "@/sbin/ldconfig", expected: "@/run/nvidia/driver/sbin/ldconfig"},
+		{root: "/run/nvidia/driver/", ldconfig: "@/run/nvidia/driver/sbin/ldconfig", expected: "@/run/nvidia/driver/sbin/ldconfig"},
+		{root: "/run/nvidia/driver", ldconfig: "/sbin/ldconfig", expected: "/sbin/ldconfig"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.root+tc.ldconfig, func(t *testing.T) {
+			require.Equal(t, tc.expected, ResolveLdconfigPath(tc.root, tc.ldconfig))
+		})
+	}
+}
@@ -113,7 +113,8 @@ func (i CUDA) HasDisableRequire() bool {
 	return false
 }
 
-// DevicesFromEnvvars returns the devices requested by the image through environment variables
+// DevicesFromEnvvars returns the devices requested by the image through environment variables.
+// Devices that are specified by PCI bus ID are returned in the form used by the NVIDIA kernel module.
 func (i CUDA) DevicesFromEnvvars(envVars ...string) VisibleDevices {
 	// We concantenate all the devices from the specified envvars.
 	var isSet bool
@@ -127,6 +128,9 @@ func (i CUDA) DevicesFromEnvvars(envVars ...string) VisibleDevices {
 				if len(trimmed) == 0 {
 					continue
 				}
+				if busID, ok := ParseBusID(trimmed); ok {
+					trimmed = busID
+				}
 				devices = append(devices, trimmed)
 				requested[trimmed] = true
 			}
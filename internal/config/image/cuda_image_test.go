@@ -121,3 +121,34 @@ func TestGetRequirements(t *testing.T) {
 
 	}
 }
+
+func TestParseBusID(t *testing.T) {
+	testCases := []struct {
+		id       string
+		expected string
+	}{
+		{"0000:65:00.0", "0000:65:00.0"},
+		{"00000000:65:00.0", "0000:65:00.0"},
+		{"65:00.0", "0000:65:00.0"},
+		{"0000:3B:00.1", "0000:3b:00.1"},
+		{"0", ""},
+		{"1:0", ""},
+		{"GPU-edfee158", ""},
+		{"0000:65:00.8", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			busID, ok := ParseBusID(tc.id)
+			require.Equal(t, tc.expected != "", ok)
+			require.Equal(t, tc.expected, busID)
+		})
+	}
+}
+
+func TestDevicesFromEnvvarsBusIDs(t *testing.T) {
+	image, err := NewCUDAImageFromEnv([]string{"NVIDIA_VISIBLE_DEVICES=0,00000000:3B:00.0,GPU-edfee158,3b:00.0"})
+	require.NoError(t, err)
+
+	devices := image.DevicesFromEnvvars("NVIDIA_VISIBLE_DEVICES")
+	require.Equal(t, []string{"0", "0000:3b:00.0", "GPU-edfee158"}, devices.List())
+}
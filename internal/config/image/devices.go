@@ -17,9 +17,16 @@
 package image
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// busIDPattern matches PCI bus IDs with an optional domain (e.g. 0000:65:00.0, 00000000:65:00.0,
+// or 65:00.0).
+var busIDPattern = regexp.MustCompile(`^(?:([0-9a-fA-F]{1,8}):)?([0-9a-fA-F]{2}):([0-9a-fA-F]{2})\.([0-7])$`)
+
 // VisibleDevices represents the devices selected in a container image
 // through the NVIDIA_VISIBLE_DEVICES or other environment variables
 type VisibleDevices interface {
@@ -49,6 +56,22 @@ func NewVisibleDevices(envvars ...string) VisibleDevices {
 	return newDevices(envvars...)
 }
 
+// ParseBusID checks whether the specified device ID is a PCI bus ID. If it is, the bus ID is returned
+// in the form used by the NVIDIA kernel module, with a four-digit domain and lowercase hex digits
+// (e.g. 0000:65:00.0).
+func ParseBusID(id string) (string, bool) {
+	match := busIDPattern.FindStringSubmatch(id)
+	if match == nil {
+		return "", false
+	}
+	var domain uint64
+	if match[1] != "" {
+		domain, _ = strconv.ParseUint(match[1], 16, 32)
+	}
+	busID := fmt.Sprintf("%04x:%s:%s.%s", domain, match[2], match[3], match[4])
+	return strings.ToLower(busID), true
+}
+
 type all struct{}
 
 // List returns ["all"] for all devices
@@ -93,6 +116,9 @@ func newDevices(idOrCommaSeparated ...string) devices {
 	i := 0
 	for _, commaSeparated := range idOrCommaSeparated {
 		for _, id := range strings.Split(commaSeparated, ",") {
+			if _, exists := lookup[id]; exists {
+				continue
+			}
 			lookup[id] = i
 			i++
 		}
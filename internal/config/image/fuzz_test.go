@@ -0,0 +1,58 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzDevicesFromEnvvars checks that the devices requested through NVIDIA_VISIBLE_DEVICES are
+// returned as a list of unique, non-empty device IDs.
+func FuzzDevicesFromEnvvars(f *testing.F) {
+	f.Add("all", false)
+	f.Add("none", false)
+	f.Add("void", true)
+	f.Add("", true)
+	f.Add("0,1", false)
+	f.Add(" GPU-8dbc5d32-9ad4-4a4d-9d6c-4b5c0e1f2a3b , MIG-GPU-8dbc5d32/1/0", false)
+	f.Add("nvidia.com/gpu=0", false)
+
+	f.Fuzz(func(t *testing.T, value string, legacy bool) {
+		env := []string{"NVIDIA_VISIBLE_DEVICES=" + value}
+		if legacy {
+			env = append(env, "CUDA_VERSION=9.0")
+		}
+		image, err := NewCUDAImageFromEnv(env)
+		require.NoError(t, err)
+
+		devices := image.DevicesFromEnvvars("NVIDIA_VISIBLE_DEVICES")
+		if _, ok := devices.(none); ok {
+			return
+		}
+
+		seen := make(map[string]bool)
+		for _, id := range devices.List() {
+			require.NotEmpty(t, id)
+			require.NotContains(t, id, ",")
+			require.False(t, seen[id], "duplicate device %q", id)
+			require.True(t, devices.Has(id))
+			seen[id] = true
+		}
+	})
+}
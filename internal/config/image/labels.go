@@ -0,0 +1,71 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package image
+
+import (
+	"strings"
+)
+
+// The image labels that can be used to request devices and driver capabilities.
+const (
+	// LabelDevices is the image label used to request devices. This has the same format as
+	// the NVIDIA_VISIBLE_DEVICES environment variable.
+	LabelDevices = "com.nvidia.devices"
+	// LabelDriverCapabilities is the image label used to request driver capabilities. This has
+	// the same format as the NVIDIA_DRIVER_CAPABILITIES environment variable.
+	LabelDriverCapabilities = "com.nvidia.capabilities"
+	// LabelCUDAVersion is the label used by nvidia-docker v1 images to specify the CUDA version.
+	LabelCUDAVersion = "com.nvidia.cuda.version"
+	// LabelVolumesNeeded is the label used by nvidia-docker v1 images to request the driver volume.
+	LabelVolumesNeeded = "com.nvidia.volumes.needed"
+
+	envNVVisibleDevices = "NVIDIA_VISIBLE_DEVICES"
+)
+
+// EnvFromLabels returns the environment variables equivalent to the device and capability
+// requests in the specified image labels. Images with the nvidia-docker v1
+// com.nvidia.volumes.needed="nvidia_driver" label that do not explicitly request devices
+// are considered to request all devices.
+func EnvFromLabels(labels map[string]string) map[string]string {
+	env := make(map[string]string)
+
+	if v, ok := labels[LabelCUDAVersion]; ok && v != "" {
+		env[envCUDAVersion] = v
+	}
+	if v, ok := labels[LabelDriverCapabilities]; ok && v != "" {
+		env[envNVDriverCapabilities] = v
+	}
+
+	if v, ok := labels[LabelDevices]; ok && v != "" {
+		env[envNVVisibleDevices] = v
+	} else if needsDriverVolume(labels[LabelVolumesNeeded]) {
+		env[envNVVisibleDevices] = "all"
+	}
+
+	return env
+}
+
+// needsDriverVolume checks whether the nvidia_driver volume is included in the specified
+// (space-separated) list of volumes.
+func needsDriverVolume(volumes string) bool {
+	for _, v := range strings.Fields(volumes) {
+		if v == "nvidia_driver" {
+			return true
+		}
+	}
+	return false
+}
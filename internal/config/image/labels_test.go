@@ -0,0 +1,75 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvFromLabels(t *testing.T) {
+	testCases := []struct {
+		description string
+		labels      map[string]string
+		expected    map[string]string
+	}{
+		{
+			description: "no labels",
+			expected:    map[string]string{},
+		},
+		{
+			description: "devices and capabilities",
+			labels: map[string]string{
+				"com.nvidia.devices":      "0,1",
+				"com.nvidia.capabilities": "compute,utility",
+				"org.opencontainers.ref":  "latest",
+			},
+			expected: map[string]string{
+				"NVIDIA_VISIBLE_DEVICES":     "0,1",
+				"NVIDIA_DRIVER_CAPABILITIES": "compute,utility",
+			},
+		},
+		{
+			description: "nvidia-docker v1 image",
+			labels: map[string]string{
+				"com.nvidia.volumes.needed": "nvidia_driver",
+				"com.nvidia.cuda.version":   "8.0.61",
+			},
+			expected: map[string]string{
+				"NVIDIA_VISIBLE_DEVICES": "all",
+				"CUDA_VERSION":           "8.0.61",
+			},
+		},
+		{
+			description: "devices take precedence over driver volume",
+			labels: map[string]string{
+				"com.nvidia.volumes.needed": "nvidia_driver",
+				"com.nvidia.devices":        "none",
+			},
+			expected: map[string]string{
+				"NVIDIA_VISIBLE_DEVICES": "none",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, EnvFromLabels(tc.labels))
+		})
+	}
+}
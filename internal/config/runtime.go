@@ -28,6 +28,60 @@ const (
 	runcExecutableName       = "runc"
 
 	auto = "auto"
+
+	// MountStrategyIndividual injects each discovered driver file using a separate bind mount.
+	MountStrategyIndividual = "individual"
+	// MountStrategyDriverRoot injects the discovered driver files as a single read-only bind mount
+	// of a staged driver root.
+	MountStrategyDriverRoot = "driver-root"
+	// MountStrategyCopy injects copies of the discovered driver files as a single read-only bind mount
+	// of a driver root assembled in a tmpfs shared by the containers for a driver version.
+	MountStrategyCopy = "copy"
+
+	// ErrorFormatText reports errors as log entries on stderr.
+	ErrorFormatText = "text"
+	// ErrorFormatJSON reports errors as a machine-readable JSON object on stderr.
+	ErrorFormatJSON = "json"
+
+	// ChecksumPolicyWarn logs a warning if the checksum of an injected file does not match the manifest.
+	ChecksumPolicyWarn = "warn"
+	// ChecksumPolicyFail fails the creation of a container if the checksum of an injected file does not match the manifest.
+	ChecksumPolicyFail = "fail"
+
+	// ImageLabelPrecedenceEnvvar indicates that environment variables take precedence over image labels.
+	ImageLabelPrecedenceEnvvar = "envvar"
+	// ImageLabelPrecedenceLabel indicates that image labels take precedence over environment variables.
+	ImageLabelPrecedenceLabel = "label"
+
+	// EnvPolicyOverride indicates that an injected environment variable replaces the value set in the container.
+	EnvPolicyOverride = "override"
+	// EnvPolicyKeep indicates that the value set in the container is kept and the injected value is ignored.
+	EnvPolicyKeep = "keep"
+	// EnvPolicyAppend indicates that the injected value is appended to the value set in the container.
+	EnvPolicyAppend = "append"
+	// EnvPolicyPrepend indicates that the injected value is prepended to the value set in the container.
+	EnvPolicyPrepend = "prepend"
+
+	// CUDACompatModeLdconfig indicates that the directory containing the CUDA forward compatibility
+	// libraries in the container is added to the ldcache of the container.
+	CUDACompatModeLdconfig = "ldconfig"
+	// CUDACompatModeMount indicates that the CUDA forward compatibility libraries in the container are
+	// mounted over the driver libraries.
+	CUDACompatModeMount = "mount"
+	// CUDACompatModeDisabled indicates that the CUDA forward compatibility libraries in the container are ignored.
+	CUDACompatModeDisabled = "disabled"
+
+	// LdconfigStrategyHost indicates that the ldconfig executable on the host is used to update the
+	// ldcache of the container.
+	LdconfigStrategyHost = "host"
+	// LdconfigStrategyContainer indicates that the ldconfig executable in the container is used to
+	// update the ldcache of the container.
+	LdconfigStrategyContainer = "container"
+
+	// HookPositionFirst moves the NVIDIA hooks before all other hooks of the same type.
+	HookPositionFirst = "first"
+	// HookPositionLast moves the NVIDIA hooks after all other hooks of the same type.
+	HookPositionLast = "last"
 )
 
 // RuntimeConfig stores the config options for the NVIDIA Container Runtime
@@ -39,25 +93,404 @@ type RuntimeConfig struct {
 	Runtimes []string    `toml:"runtimes"`
 	Mode     string      `toml:"mode"`
 	Modes    modesConfig `toml:"modes"`
+	// MountStrategy defines how discovered driver files are injected into a container.
+	// One of [individual | driver-root | copy].
+	MountStrategy   string                `toml:"mount-strategy"`
+	DriverRootMount driverRootMountConfig `toml:"driver-root-mount"`
+	DriverCopy      driverCopyConfig      `toml:"driver-copy"`
+	// StagedDriverRoot is the path to a driver root staged by `nvidia-ctk system stage-driver`.
+	// If present, the staged files are preferred over the files on the host.
+	StagedDriverRoot string `toml:"staged-driver-root"`
+	// DriverRoots defines the driver roots that are searched for the files injected in csv mode and by
+	// the graphics, GDS, and MOFED modifiers. If this is empty, nvidia-container-cli.root is used.
+	DriverRoots []DriverRoot `toml:"driver-roots"`
+	// ErrorFormat defines how errors are reported on stderr. One of [text | json].
+	ErrorFormat string `toml:"error-format"`
+	// ModificationTimeout is the maximum duration (e.g. "30s") of loading the config and discovering
+	// and applying the modifications to the OCI specification. If this is empty, no timeout is applied.
+	ModificationTimeout string `toml:"modification-timeout"`
+	// RequestReport configures the reporting of the mechanisms used by containers to request devices.
+	RequestReport requestReportConfig `toml:"request-report"`
+	// StartupLatency configures the recording of the time spent in each phase of the creation of a container.
+	StartupLatency startupLatencyConfig `toml:"startup-latency"`
+	// DeviceState configures the state file in which removed GPUs are recorded.
+	DeviceState deviceStateConfig `toml:"device-state"`
+	// Allocations configures the recording of the devices allocated to each container.
+	Allocations allocationsConfig `toml:"allocations"`
+	// NVMLThrottle limits the number of concurrent operations that initialize NVML when containers are created.
+	NVMLThrottle nvmlThrottleConfig `toml:"nvml-throttle"`
+	// DriverBinaries controls which driver binaries (e.g. nvidia-smi) are injected into containers.
+	DriverBinaries driverBinariesConfig `toml:"driver-binaries"`
+	// ReadOnlyInjection indicates whether all injected mounts are forced to be read-only, nosuid, and nodev
+	// and the device cgroup rules for injected devices are restricted to read and write access.
+	ReadOnlyInjection bool `toml:"read-only-injection"`
+	// IDMappedMounts indicates whether the mounts for injected files use the user namespace ID mappings
+	// of the container. This requires a low-level runtime and kernel that support ID-mapped mounts.
+	IDMappedMounts bool `toml:"id-mapped-mounts"`
+	// LibraryPrefix is the directory in the container under which injected driver libraries are
+	// mounted instead of the standard library directories (e.g. /usr/lib/x86_64-linux-gnu). If this
+	// is empty, the libraries are mounted at their paths on the host.
+	LibraryPrefix string `toml:"library-prefix"`
+	// HookOrdering controls the ordering of the NVIDIA hooks relative to other hooks in the OCI specification.
+	HookOrdering hookOrderingConfig `toml:"hook-ordering"`
+	// Hooks configures the nvidia-ctk hooks that are added to containers.
+	Hooks hooksConfig `toml:"hooks"`
+	// ChecksumVerification configures the verification of injected files against a checksum manifest.
+	ChecksumVerification checksumVerificationConfig `toml:"checksum-verification"`
+	// ForeignArchitecture configures the injection into containers whose image architecture differs from
+	// that of the host (e.g. arm64 images run using binfmt_misc and qemu-user emulation).
+	ForeignArchitecture foreignArchitectureConfig `toml:"foreign-architecture"`
+	// WorkloadTuning configures the resource limits and sysctls set for containers that use GPUDirect RDMA or GDS.
+	WorkloadTuning workloadTuningConfig `toml:"workload-tuning"`
+	// DeviceLimits configures the maximum number of devices that a single container may request.
+	DeviceLimits deviceLimitsConfig `toml:"device-limits"`
+	// GPUSharing configures the environment variables set for containers with shared GPU allocations.
+	GPUSharing gpuSharingConfig `toml:"gpu-sharing"`
+	// EnvPolicy defines how injected environment variables interact with those set in the container.
+	EnvPolicy envPolicyConfig `toml:"env-policy"`
+	// IMEX configures the injection of IMEX channels and the associated configuration.
+	IMEX imexConfig `toml:"imex"`
+	// ImageLabels configures the use of image labels as a source of device requests.
+	ImageLabels imageLabelsConfig `toml:"image-labels"`
+	// Policy defines the policies that are evaluated for container images requesting devices.
+	Policy policyConfig `toml:"policy"`
+}
+
+// policyConfig defines the policies that are evaluated for container images using `nvidia-ctk policy evaluate`.
+// The NVIDIA Container Runtime also refuses to create containers that violate these policies.
+type policyConfig struct {
+	// ForbiddenCapabilities is the list of driver capabilities (e.g. display) that images may not request.
+	ForbiddenCapabilities []string `toml:"forbidden-capabilities"`
+	// AllowedDevices is the list of device selectors (e.g. all, 0, or GPU-*) that images may request.
+	// Glob patterns are supported. If this is empty, all device selectors are allowed.
+	AllowedDevices []string `toml:"allowed-devices"`
+	// DriverVersion is the driver version against which the requirements of images (e.g. NVIDIA_REQUIRE_CUDA)
+	// are evaluated. If this is empty, the driver requirements of images are not evaluated.
+	DriverVersion string `toml:"driver-version"`
+	// CUDAVersion is the CUDA driver API version against which the CUDA requirements of images are evaluated.
+	CUDAVersion string `toml:"cuda-version"`
+}
+
+// imageLabelsConfig defines the options for requesting devices using image labels
+type imageLabelsConfig struct {
+	// Enabled indicates whether image labels (as propagated to the OCI specification annotations)
+	// are used to request devices and driver capabilities.
+	Enabled bool `toml:"enabled"`
+	// Precedence defines whether image labels or environment variables take precedence if a
+	// request is specified using both. One of [envvar | label].
+	Precedence string `toml:"precedence"`
+}
+
+// foreignArchitectureConfig defines the driver stacks used for containers of other architectures
+type foreignArchitectureConfig struct {
+	// DriverRoots maps architectures (e.g. arm64) to the driver roots that contain the driver for the
+	// architecture. These are used instead of the configured driver roots in csv mode and by the
+	// graphics, GDS, MOFED, and IMEX modifiers.
+	DriverRoots map[string]string `toml:"driver-roots"`
+	// CDISpecDirs maps architectures to the directories that contain the CDI specifications generated
+	// for the driver of the architecture. These are used instead of the configured spec dirs in cdi mode.
+	CDISpecDirs map[string]string `toml:"cdi-spec-dirs"`
+}
+
+// workloadTuningConfig defines the options for tuning containers that request MOFED or GDS devices
+type workloadTuningConfig struct {
+	// Enabled indicates whether the resource limits and sysctls are set for containers that
+	// request MOFED (NVIDIA_MOFED=enabled) or GDS (NVIDIA_GDS=enabled) devices.
+	Enabled bool `toml:"enabled"`
+	// Memlock is the RLIMIT_MEMLOCK limit (in bytes or unlimited) that is required to register
+	// memory for RDMA. The limit of a container is only ever raised.
+	Memlock string `toml:"memlock"`
+	// Sysctls are the namespaced sysctls (e.g. net.ipv4.tcp_rmem) that are set for the container
+	// unless these are set in the OCI specification.
+	Sysctls map[string]string `toml:"sysctls"`
+}
+
+// deviceLimitsConfig defines the maximum number of devices that a single container may request
+type deviceLimitsConfig struct {
+	// MaxDevicesPerContainer is the maximum number of devices that a container may request. A request
+	// for all devices counts as the number of GPUs on the node. If this is 0, the number is not limited.
+	MaxDevicesPerContainer int `toml:"max-devices-per-container"`
+	// Namespaces overrides the maximum for containers in the specified Kubernetes namespaces as
+	// indicated by the io.kubernetes.pod.namespace annotation.
+	Namespaces map[string]int `toml:"namespaces"`
+	// Annotations overrides the maximum for containers with the specified annotations. The keys
+	// are of the form key=value or key, with the latter matching any value. These overrides take
+	// precedence over the namespace overrides.
+	Annotations map[string]int `toml:"annotations"`
+	// TrustedAnnotationKeys are the annotation keys for which the annotation overrides are applied.
+	// Since annotations can be set by the workload, only keys that cannot be set by users (e.g.
+	// because these are set or validated by an admission controller) should be listed.
+	TrustedAnnotationKeys []string `toml:"trusted-annotation-keys"`
+}
+
+// gpuSharingConfig defines the options for containers with shared (e.g. time-sliced or MPS) GPU allocations
+type gpuSharingConfig struct {
+	// Enabled indicates whether environment variables such as CUDA_VISIBLE_DEVICES are set for
+	// containers whose allocation is marked as shared using the nvidia.com/gpu-sharing.* annotations.
+	Enabled bool `toml:"enabled"`
+}
+
+// envPolicyConfig defines how environment variables injected into a container (e.g. by CDI specifications)
+// interact with the environment variables set by the image or the user. Each policy is one of
+// [override | keep | append | prepend].
+type envPolicyConfig struct {
+	// NVIDIA is the policy for the NVIDIA_* and CUDA_* environment variables.
+	NVIDIA string `toml:"nvidia"`
+	// SearchPaths is the policy for the PATH, LD_LIBRARY_PATH, and LD_PRELOAD environment variables.
+	SearchPaths string `toml:"search-paths"`
+	// Other is the policy for all other environment variables.
+	Other string `toml:"other"`
+	// Variables overrides the policy for individual environment variables.
+	Variables map[string]string `toml:"variables"`
+}
+
+// imexConfig defines the options for injecting IMEX channels
+type imexConfig struct {
+	// ConfigDir is the directory containing the IMEX configuration files that are injected
+	// into containers that request IMEX channels.
+	ConfigDir string `toml:"config-dir"`
+	// Domain is the multi-node NVLink domain that this node belongs to. If set, this is
+	// exposed to containers that request IMEX channels as NVIDIA_IMEX_DOMAIN.
+	Domain string `toml:"domain"`
+}
+
+// DriverRoot defines a driver root and the priority used to resolve conflicts with other driver roots.
+type DriverRoot struct {
+	// Path is the path of the driver root on the host.
+	Path string `toml:"path"`
+	// Priority is used to select between files that are found in more than one driver root.
+	// Files from the driver root with the highest priority are selected.
+	Priority int `toml:"priority"`
+}
+
+// hookOrderingConfig defines the options for ordering and deduplicating the NVIDIA hooks in the OCI specification
+type hookOrderingConfig struct {
+	// Position defines where the NVIDIA hooks are placed relative to the other hooks of the same type
+	// (e.g. createContainer). One of [first | last]. If this is empty, the hooks are left in place.
+	Position string `toml:"position"`
+	// Deduplicate indicates whether repeated NVIDIA hooks with the same path, arguments, and
+	// environment are removed. Only the first occurrence of each hook is retained.
+	Deduplicate bool `toml:"deduplicate"`
+}
+
+// hooksConfig defines the options for the nvidia-ctk hooks that are added to containers
+type hooksConfig struct {
+	// Disable is the list of nvidia-ctk hooks (e.g. create-symlinks) that are not added to containers
+	// in any mode. The hooks are also omitted from CDI specifications generated by nvidia-ctk.
+	Disable []string `toml:"disable"`
+	// DisableForMode maps a runtime mode (e.g. csv or cdi) to the nvidia-ctk hooks that are not added
+	// to containers in that mode in addition to the hooks listed in Disable.
+	DisableForMode map[string][]string `toml:"disable-for-mode"`
+}
+
+// checksumVerificationConfig defines the options for verifying the checksums of injected files
+type checksumVerificationConfig struct {
+	// Manifest is the path to a checksum manifest generated by `nvidia-ctk cdi generate --checksum-manifest`.
+	// If this is empty, no verification is performed.
+	Manifest string `toml:"manifest"`
+	// Policy defines the action taken on a mismatch. One of [warn | fail].
+	Policy string `toml:"policy"`
+}
+
+// driverBinariesConfig defines an allowlist and denylist for injected driver binaries
+type driverBinariesConfig struct {
+	// Allow is the list of driver binaries that may be injected. If this is empty, all driver
+	// binaries not in the Deny list are injected.
+	Allow []string `toml:"allow"`
+	// Deny is the list of driver binaries that must not be injected. This takes precedence over Allow.
+	Deny []string `toml:"deny"`
+}
+
+// requestReportConfig defines the options for reporting device request mechanisms
+type requestReportConfig struct {
+	// Enabled indicates whether the device request mechanism for each container is logged.
+	Enabled bool `toml:"enabled"`
+	// MetricsFile is the path to a file in the Prometheus text format in which the number of
+	// device requests per mechanism is recorded. If this is empty, no metrics are exported.
+	MetricsFile string `toml:"metrics-file"`
+	// TranslateLegacy indicates whether containers that request devices using legacy
+	// NVIDIA_VISIBLE_DEVICES values in legacy mode are served using the equivalent CDI device
+	// requests if all of these are defined in the CDI spec dirs.
+	TranslateLegacy bool `toml:"translate-legacy"`
+}
+
+// startupLatencyConfig defines the options for recording the startup latency of containers
+type startupLatencyConfig struct {
+	// Enabled indicates whether the time spent in each phase is added as an annotation to the
+	// OCI specification and recorded in the state file.
+	Enabled bool `toml:"enabled"`
+	// StateFile is the path to the file in which the times for recently created containers are
+	// recorded. If this is empty, /run/nvidia-container-toolkit/startup-latency.jsonl is used.
+	StateFile string `toml:"state-file"`
+}
+
+// deviceStateConfig defines the options for the state of the devices on the node
+type deviceStateConfig struct {
+	// StateFile is the path to the file in which the GPUs removed from the node are recorded by
+	// `nvidia-ctk system watch-devices`. The devices recorded in this file are not injected in cdi
+	// mode. If this is empty, /run/nvidia-container-toolkit/device-state.json is used.
+	StateFile string `toml:"state-file"`
+}
+
+// allocationsConfig defines the options for recording the devices allocated to each container
+type allocationsConfig struct {
+	// Enabled indicates whether the devices injected into a container are added as an annotation to the
+	// OCI specification and recorded in the state dir.
+	Enabled bool `toml:"enabled"`
+	// StateDir is the directory in which the allocation of each container is recorded. If this is empty,
+	// /run/nvidia-container-toolkit/allocations is used.
+	StateDir string `toml:"state-dir"`
+}
+
+// nvmlThrottleConfig defines the options for limiting the concurrent initialization of NVML
+type nvmlThrottleConfig struct {
+	// MaxConcurrent is the maximum number of container creates on the node that initialize NVML (e.g. by
+	// invoking nvidia-container-cli) concurrently. If this is 0, the number is not limited.
+	MaxConcurrent int `toml:"max-concurrent"`
+	// LockDir is the directory in which the slot files are locked. If this is empty,
+	// /run/nvidia-container-toolkit/nvml-throttle is used.
+	LockDir string `toml:"lock-dir"`
+	// Timeout is the maximum duration (e.g. "60s") that is waited for a slot. If no slot is available
+	// before the timeout expires, the container is created regardless. If this is empty, 60s is used.
+	Timeout string `toml:"timeout"`
+}
+
+// driverRootMountConfig defines the options for the driver-root mount strategy
+type driverRootMountConfig struct {
+	// StagingDir is the host directory in which driver roots are assembled for injection.
+	StagingDir string `toml:"staging-dir"`
+	// ContainerPath is the path in the container at which the driver root is mounted.
+	ContainerPath string `toml:"container-path"`
+}
+
+// driverCopyConfig defines the options for the copy mount strategy
+type driverCopyConfig struct {
+	// Dir is the host directory in which the copies of the driver files are stored. A tmpfs is
+	// mounted at this directory if it is not already on a tmpfs.
+	Dir string `toml:"dir"`
+	// ContainerPath is the path in the container at which the copies are mounted.
+	ContainerPath string `toml:"container-path"`
 }
 
 // modesConfig defines (optional) per-mode configs
 type modesConfig struct {
-	CSV csvModeConfig `toml:"csv"`
-	CDI cdiModeConfig `toml:"cdi"`
+	CSV    csvModeConfig    `toml:"csv"`
+	CDI    cdiModeConfig    `toml:"cdi"`
+	Legacy legacyModeConfig `toml:"legacy"`
+}
+
+// legacyModeConfig defines the options for the legacy mode. These are applied by the
+// nvidia-container-runtime-hook when invoking the nvidia-container-cli.
+type legacyModeConfig struct {
+	// CUDACompatMode defines how the CUDA forward compatibility libraries in the container are
+	// handled. One of [ldconfig, mount, disabled]. If this is empty, the nvidia-container-cli
+	// default is used.
+	CUDACompatMode string `toml:"cuda-compat-mode"`
+	// AllowedCapabilities restricts the driver capabilities that can be requested by a container in
+	// addition to supported-driver-capabilities. If this is empty, no additional restriction is applied.
+	AllowedCapabilities []string `toml:"allowed-capabilities"`
+	// LdconfigStrategy defines whether the ldconfig executable on the host or in the container is used
+	// to update the ldcache of the container. One of [host, container]. If this is empty, the
+	// nvidia-container-cli.ldconfig option is used as is.
+	LdconfigStrategy string `toml:"ldconfig-strategy"`
 }
 
 type cdiModeConfig struct {
 	// SpecDirs allows for the default spec dirs for CDI to be overridden
 	SpecDirs []string `toml:"spec-dirs"`
-	// DefaultKind sets the default kind to be used when constructing fully-qualified CDI device names
-	DefaultKind string `toml:"default-kind"`
+	// AllowedSpecDirs is the list of directories that may be used as spec dirs. If this is
+	// non-empty, configuring a spec dir that is not in this list is an error.
+	AllowedSpecDirs []string `toml:"allowed-spec-dirs"`
+	// SpecDirPermissions configures the checks on the ownership and permissions of the spec dirs.
+	SpecDirPermissions specDirPermissionsConfig `toml:"spec-dir-permissions"`
+	// DefaultKind sets the default kinds to be used when constructing fully-qualified CDI device names.
+	// This can be a single kind or an ordered list of kinds.
+	DefaultKind cdiKinds `toml:"default-kind"`
+	// DeviceWait configures waiting for the device nodes of requested devices to be created
+	// on the host before these are injected.
+	DeviceWait deviceWaitConfig `toml:"device-wait"`
+	// AnnotationPrefixes defines additional annotation prefixes (e.g. devices.nvidia.com/) that are
+	// parsed for CDI device requests. The cdi.k8s.io/ prefix always takes precedence, followed by the
+	// prefixes in the order listed.
+	AnnotationPrefixes []string `toml:"annotation-prefixes"`
+	// IndexFile is the path to a CDI spec index generated by `nvidia-ctk cdi index`. If the index is
+	// up to date for the spec dirs, only the specs that define the requested devices are loaded.
+	IndexFile string `toml:"index-file"`
+	// Strict indicates that container creation fails if any of the CDI specifications in the spec dirs
+	// cannot be loaded. By default, invalid specifications are ignored and the devices that these
+	// define are unavailable.
+	Strict bool `toml:"strict"`
+	// RegistrySocket is the path to the Unix socket of a CDI registry daemon started using
+	// `nvidia-ctk cdi serve`. If this is set, the edits for the requested devices are queried from
+	// the daemon and the CDI specifications are only loaded if the daemon is not available.
+	RegistrySocket string `toml:"registry-socket"`
+	// OverrideDirs are directories with CDI specifications whose edits are applied on top of the edits
+	// of the requested devices. The directories are applied in order so that later directories take
+	// precedence. This allows site-specific mounts and environment variables to be added to devices
+	// without modifying generated specifications.
+	OverrideDirs []string `toml:"override-dirs"`
+	// TenantOverrideDirs defines additional override dirs for containers in the specified Kubernetes
+	// namespaces as indicated by the io.kubernetes.pod.namespace annotation. These are applied after
+	// the OverrideDirs.
+	TenantOverrideDirs map[string][]string `toml:"tenant-override-dirs"`
+}
+
+// deviceWaitConfig defines the options for waiting for the device nodes of CDI devices
+type deviceWaitConfig struct {
+	// Timeout is the maximum duration (e.g. "30s") to wait for missing device nodes. If this is
+	// empty or zero, missing device nodes are not waited for.
+	Timeout string `toml:"timeout"`
+	// Interval is the duration between checks for the presence of missing device nodes.
+	Interval string `toml:"interval"`
+}
+
+// specDirPermissionsConfig defines the checks applied to CDI spec dirs before they are consulted
+type specDirPermissionsConfig struct {
+	// Enforce indicates whether spec dirs that are not owned by root or have permissions
+	// exceeding MaxMode are rejected.
+	Enforce bool `toml:"enforce"`
+	// MaxMode is the octal representation of the most permissive mode allowed for a spec dir.
+	MaxMode string `toml:"max-mode"`
+}
+
+// cdiKinds is an ordered list of CDI device kinds. In the config this can be
+// specified as either a single string or a list of strings.
+type cdiKinds []string
+
+// UnmarshalTOML allows a single kind to be specified as a string.
+// Note that when decoding using go-toml this is only called for non-array values.
+func (k *cdiKinds) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*k = cdiKinds{v}
+	case []interface{}:
+		var kinds cdiKinds
+		for _, kind := range v {
+			s, ok := kind.(string)
+			if !ok {
+				return fmt.Errorf("invalid CDI kind: %v", kind)
+			}
+			kinds = append(kinds, s)
+		}
+		*k = kinds
+	default:
+		return fmt.Errorf("invalid CDI kinds: %v", data)
+	}
+	return nil
 }
 
 type csvModeConfig struct {
 	MountSpecPath string `toml:"mount-spec-path"`
 }
 
+// DisabledHooks returns the nvidia-ctk hooks that are not added to containers in the specified mode.
+func (c *RuntimeConfig) DisabledHooks(mode string) []string {
+	var disabled []string
+	disabled = append(disabled, c.Hooks.Disable...)
+	disabled = append(disabled, c.Hooks.DisableForMode[mode]...)
+	return disabled
+}
+
 // dummy allows us to unmarshal only a RuntimeConfig from a *toml.Tree
 type dummy struct {
 	Runtime RuntimeConfig `toml:"nvidia-container-runtime"`
@@ -91,13 +524,47 @@ func GetDefaultRuntimeConfig() *RuntimeConfig {
 			dockerRuncExecutableName,
 			runcExecutableName,
 		},
-		Mode: auto,
+		Mode:          auto,
+		MountStrategy: MountStrategyIndividual,
+		ErrorFormat:   ErrorFormatText,
+		ChecksumVerification: checksumVerificationConfig{
+			Policy: ChecksumPolicyWarn,
+		},
+		IMEX: imexConfig{
+			ConfigDir: "/etc/nvidia-imex",
+		},
+		WorkloadTuning: workloadTuningConfig{
+			Memlock: "unlimited",
+		},
+		ImageLabels: imageLabelsConfig{
+			Precedence: ImageLabelPrecedenceEnvvar,
+		},
+		EnvPolicy: envPolicyConfig{
+			NVIDIA:      EnvPolicyOverride,
+			SearchPaths: EnvPolicyAppend,
+			Other:       EnvPolicyOverride,
+		},
+		DriverRootMount: driverRootMountConfig{
+			StagingDir:    "/run/nvidia-container-toolkit/driver-root",
+			ContainerPath: "/usr/local/nvidia",
+		},
+		DriverCopy: driverCopyConfig{
+			Dir:           "/run/nvidia-container-toolkit/driver-copy",
+			ContainerPath: "/usr/local/nvidia",
+		},
+		StagedDriverRoot: "/run/nvidia/driver-stage",
 		Modes: modesConfig{
 			CSV: csvModeConfig{
 				MountSpecPath: "/etc/nvidia-container-runtime/host-files-for-container.d",
 			},
 			CDI: cdiModeConfig{
-				DefaultKind: "nvidia.com/gpu",
+				DefaultKind: cdiKinds{"nvidia.com/gpu"},
+				SpecDirPermissions: specDirPermissionsConfig{
+					MaxMode: "0755",
+				},
+				DeviceWait: deviceWaitConfig{
+					Interval: "250ms",
+				},
 			},
 		},
 	}
@@ -0,0 +1,78 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/pelletier/go-toml"
+)
+
+// loadTOML parses the specified TOML contents. The TOML parser panics on some malformed
+// inputs instead of returning an error. Such panics are recovered and returned as errors.
+func loadTOML(contents []byte) (tree *toml.Tree, rerr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tree = nil
+			rerr = fmt.Errorf("failed to parse TOML: %v", r)
+		}
+	}()
+	return toml.LoadBytes(contents)
+}
+
+// getString returns the string value for the specified key or the default value if the key is not set.
+// An error is returned if the value is not a string.
+func getString(toml *toml.Tree, key string, defaultValue string) (string, error) {
+	value := toml.GetDefault(key, defaultValue)
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid value for %v: expected string, got %T", key, value)
+	}
+	return s, nil
+}
+
+// getBool returns the bool value for the specified key or the default value if the key is not set.
+// An error is returned if the value is not a bool.
+func getBool(toml *toml.Tree, key string, defaultValue bool) (bool, error) {
+	value := toml.GetDefault(key, defaultValue)
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid value for %v: expected bool, got %T", key, value)
+	}
+	return b, nil
+}
+
+// getStringSlice returns the string slice value for the specified key or the default value if the key is not set.
+// An error is returned if the value is not a list of strings.
+func getStringSlice(toml *toml.Tree, key string, defaultValue []string) ([]string, error) {
+	if !toml.Has(key) {
+		return defaultValue, nil
+	}
+	values, ok := toml.Get(key).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %v: expected list, got %T", key, toml.Get(key))
+	}
+	s := []string{}
+	for _, value := range values {
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %v: expected string, got %T", key, value)
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
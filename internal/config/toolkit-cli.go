@@ -16,30 +16,74 @@
 
 package config
 
-import "github.com/pelletier/go-toml"
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/pelletier/go-toml"
+)
 
 // CTKConfig stores the config options for the NVIDIA Container Toolkit CLI (nvidia-ctk)
 type CTKConfig struct {
 	Path string `toml:"path"`
+	// Hooks defines the privileges, timeout, and failure policy with which the nvidia-ctk hooks injected into containers are run.
+	Hooks hookConfig `toml:"hooks"`
+}
+
+// hookConfig defines the privileges, timeout, and failure policy of the nvidia-ctk hooks
+type hookConfig struct {
+	// User is the user (uid[:gid]) as which the hooks are run. If this is empty, the hooks are run
+	// with the privileges of the low-level runtime (typically root).
+	User string `toml:"user"`
+	// Capabilities is the list of capabilities that are retained when the hooks are run as User.
+	Capabilities []string `toml:"capabilities"`
+	// Timeout is the maximum duration (e.g. 30s) of each hook. If this is empty, no timeout is applied.
+	Timeout string `toml:"timeout"`
+	// FailurePolicy defines whether a failure or timeout of a hook prevents the container from being
+	// created (fail-closed) or is logged and ignored (fail-open).
+	FailurePolicy string `toml:"failure-policy"`
 }
 
 // getCTKConfigFrom reads the nvidia container runtime config from the specified toml Tree.
-func getCTKConfigFrom(toml *toml.Tree) *CTKConfig {
+func getCTKConfigFrom(toml *toml.Tree) (*CTKConfig, error) {
 	cfg := getDefaultCTKConfig()
 
 	if toml == nil {
-		return cfg
+		return cfg, nil
 	}
 
-	cfg.Path = toml.GetDefault("nvidia-ctk.path", cfg.Path).(string)
+	options := map[string]*string{
+		"nvidia-ctk.path":                 &cfg.Path,
+		"nvidia-ctk.hooks.user":           &cfg.Hooks.User,
+		"nvidia-ctk.hooks.timeout":        &cfg.Hooks.Timeout,
+		"nvidia-ctk.hooks.failure-policy": &cfg.Hooks.FailurePolicy,
+	}
+	for key, value := range options {
+		var err error
+		*value, err = getString(toml, key, *value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if capabilities, ok := toml.Get("nvidia-ctk.hooks.capabilities").([]interface{}); ok {
+		cfg.Hooks.Capabilities = nil
+		for _, c := range capabilities {
+			cfg.Hooks.Capabilities = append(cfg.Hooks.Capabilities, fmt.Sprintf("%v", c))
+		}
+	}
 
-	return cfg
+	return cfg, nil
 }
 
 // getDefaultCTKConfig defines the default values for the config
 func getDefaultCTKConfig() *CTKConfig {
 	c := CTKConfig{
 		Path: "nvidia-ctk",
+		Hooks: hookConfig{
+			Capabilities:  append([]string{}, privileges.DefaultCapabilities...),
+			FailurePolicy: string(hookpolicy.FailClosed),
+		},
 	}
 
 	return &c
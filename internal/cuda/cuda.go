@@ -1,3 +1,5 @@
+//go:build cgo
+
 /**
 # Copyright (c) 2022, NVIDIA CORPORATION.  All rights reserved.
 #
@@ -0,0 +1,35 @@
+//go:build !cgo
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package cuda
+
+import "fmt"
+
+// errNoCgo is returned if the toolkit is built without cgo. The CUDA driver library is loaded
+// using cgo, meaning that the CUDA version and compute capability cannot be queried.
+var errNoCgo = fmt.Errorf("querying the CUDA driver requires cgo")
+
+// Version returns an error since the CUDA driver cannot be queried without cgo.
+func Version() (string, error) {
+	return "", errNoCgo
+}
+
+// ComputeCapability returns an error since the CUDA driver cannot be queried without cgo.
+func ComputeCapability(index int) (string, error) {
+	return "", errNoCgo
+}
@@ -0,0 +1,155 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Bundle collects files for inclusion in a debug bundle tarball.
+type Bundle struct {
+	logger *logrus.Logger
+	dir    string
+	files  []file
+}
+
+type file struct {
+	name     string
+	contents []byte
+}
+
+// New creates a debug bundle that is written to the specified directory.
+func New(logger *logrus.Logger, dir string) *Bundle {
+	b := Bundle{
+		logger: logger,
+		dir:    dir,
+	}
+	return &b
+}
+
+// AddFile adds a file with the specified contents to the bundle.
+func (b *Bundle) AddFile(name string, contents []byte) {
+	b.files = append(b.files, file{name: name, contents: contents})
+}
+
+// AddJSON adds a file containing the JSON representation of the specified value to the bundle.
+// If the value cannot be represented as JSON, the error is recorded instead.
+func (b *Bundle) AddJSON(name string, v interface{}) {
+	contents, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.AddError(name, fmt.Errorf("failed to marshal JSON: %v", err))
+		return
+	}
+	b.AddFile(name, append(contents, '\n'))
+}
+
+// AddError records an error encountered while collecting the specified file.
+func (b *Bundle) AddError(name string, err error) {
+	b.AddFile(name+".error", []byte(err.Error()+"\n"))
+}
+
+// Write writes the bundle as a gzipped tarball to a timestamped file in a
+// subdirectory of the bundle directory for the specified container. The path
+// of the created tarball is returned. Since the bundle may contain sensitive
+// information, the directories are only accessible to the current user.
+func (b *Bundle) Write(containerID string) (string, error) {
+	dir := filepath.Join(b.dir, filepath.Base(containerID))
+	for _, d := range []string{b.dir, dir} {
+		if err := createPrivateDir(d); err != nil {
+			return "", fmt.Errorf("failed to create bundle directory: %v", err)
+		}
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("%s.tar.gz", now.UTC().Format("20060102T150405.000000000Z")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle file: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.Chmod(0600); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to set permissions of bundle file: %v", err)
+	}
+	if err := b.writeTo(f, now); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	b.logger.Infof("Wrote debug bundle to %v", path)
+	return path, nil
+}
+
+// createPrivateDir creates the specified directory with mode 0700 if it does not exist. An existing
+// directory is required to be owned by the current user and its mode is restricted to 0700.
+func createPrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%v is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("%v is owned by uid %d", dir, stat.Uid)
+	}
+	if info.Mode().Perm() == 0700 {
+		return nil
+	}
+	return os.Chmod(dir, 0700)
+}
+
+func (b *Bundle) writeTo(f *os.File, modTime time.Time) error {
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, file := range b.files {
+		header := tar.Header{
+			Name:    file.name,
+			Mode:    0600,
+			Size:    int64(len(file.contents)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			return fmt.Errorf("failed to write header for %v: %v", file.name, err)
+		}
+		if _, err := tw.Write(file.contents); err != nil {
+			return fmt.Errorf("failed to write %v: %v", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip writer: %v", err)
+	}
+	return nil
+}
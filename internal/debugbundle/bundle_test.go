@@ -0,0 +1,104 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleWrite(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	dir := t.TempDir()
+
+	b := New(logger, dir)
+	b.AddFile("argv.txt", []byte("create ctr\n"))
+	b.AddJSON("config.json", map[string]string{"key": "value"})
+	b.AddJSON("invalid.json", make(chan int))
+	b.AddError("spec.json", fmt.Errorf("not found"))
+
+	path, err := b.Write("../ctr")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "ctr"), filepath.Dir(path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		c, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(c)
+	}
+
+	require.Equal(t,
+		map[string]string{
+			"argv.txt":           "create ctr\n",
+			"config.json":        "{\n  \"key\": \"value\"\n}\n",
+			"invalid.json.error": "failed to marshal JSON: json: unsupported type: chan int\n",
+			"spec.json.error":    "not found\n",
+		},
+		contents,
+	)
+}
+
+func TestBundleWritePermissions(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	dir := filepath.Join(t.TempDir(), "bundles")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ctr"), 0755))
+	require.NoError(t, os.Chmod(dir, 0755))
+
+	path, err := New(logger, dir).Write("ctr")
+	require.NoError(t, err)
+
+	for _, d := range []string{dir, filepath.Dir(path)} {
+		info, err := os.Stat(d)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0700), info.Mode().Perm(), d)
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestBundleWriteRejectsSymlink(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(dir, "ctr")))
+
+	_, err := New(logger, dir).Write("ctr")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not a directory")
+}
@@ -0,0 +1,188 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package deprecation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/statefile"
+	"github.com/sirupsen/logrus"
+)
+
+// ID is a stable identifier for a deprecated feature.
+type ID string
+
+// The following deprecated features are defined.
+const (
+	RuntimeExperimentalConfig = ID("runtime-experimental-config")
+	LegacyImageDefaults       = ID("legacy-image-defaults")
+	SwarmResourceEnvvars      = ID("swarm-resource-envvars")
+)
+
+// DefaultStateFile is the file in which the uses of deprecated features are recorded.
+// Since /run is cleared on boot, warnings are logged once per boot.
+const DefaultStateFile = "/run/nvidia-container-toolkit/deprecations.json"
+
+// Deprecation describes a deprecated feature.
+type Deprecation struct {
+	ID ID
+	// Summary describes the deprecated feature.
+	Summary string
+	// RemovedIn is the release in which the feature is removed.
+	RemovedIn string
+	// Replacement describes what should be used instead.
+	Replacement string
+}
+
+// removalRelease is the release in which the deprecated features are removed. This is the minor
+// release following LIB_VERSION in versions.mk and must be updated when the features are removed.
+const removalRelease = "v1.14.0"
+
+var registry = map[ID]Deprecation{
+	RuntimeExperimentalConfig: {
+		Summary:     "The nvidia-container-runtime.experimental config option",
+		RemovedIn:   removalRelease,
+		Replacement: "Remove the option and set nvidia-container-runtime.mode (e.g. to \"cdi\" or \"csv\") instead.",
+	},
+	LegacyImageDefaults: {
+		Summary: "Injecting all devices and driver capabilities into legacy CUDA images (images that set " +
+			"CUDA_VERSION but not NVIDIA_VISIBLE_DEVICES)",
+		RemovedIn:   removalRelease,
+		Replacement: "Set NVIDIA_VISIBLE_DEVICES and NVIDIA_DRIVER_CAPABILITIES explicitly.",
+	},
+	SwarmResourceEnvvars: {
+		Summary:     "Requesting devices using Docker Swarm resource environment variables (e.g. DOCKER_RESOURCE_GPU)",
+		RemovedIn:   removalRelease,
+		Replacement: "Request devices using NVIDIA_VISIBLE_DEVICES or CDI device names instead.",
+	},
+}
+
+// Lookup returns the deprecated feature with the specified ID.
+func Lookup(id ID) (Deprecation, bool) {
+	d, ok := registry[id]
+	if !ok {
+		return Deprecation{}, false
+	}
+	d.ID = id
+	return d, true
+}
+
+// All returns all deprecated features ordered by ID.
+func All() []Deprecation {
+	var all []Deprecation
+	for id := range registry {
+		d, _ := Lookup(id)
+		all = append(all, d)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
+
+// String returns the warning message for the deprecated feature.
+func (d Deprecation) String() string {
+	return fmt.Sprintf("%v is deprecated and will be removed in %v. %v", d.Summary, d.RemovedIn, d.Replacement)
+}
+
+// Tracker records the number of uses of deprecated features in a state file.
+type Tracker struct {
+	path string
+}
+
+// NewTracker creates a tracker that records the uses of deprecated features in the specified file.
+func NewTracker(path string) *Tracker {
+	t := Tracker{
+		path: path,
+	}
+	return &t
+}
+
+// Record increments the number of uses of the specified deprecated feature and returns whether this
+// is the first use recorded in the state file. Since the NVIDIA Container Runtime and Hook are invoked
+// once per container, a lock file is used to serialize updates.
+func (t *Tracker) Record(id ID) (bool, error) {
+	var first bool
+	err := statefile.Update(t.path, func() error {
+		counts, err := t.Counts()
+		if err != nil {
+			return err
+		}
+		counts[id]++
+		first = counts[id] == 1
+		return t.write(counts)
+	})
+	return first, err
+}
+
+// Counts returns the number of uses of each deprecated feature recorded in the state file.
+func (t *Tracker) Counts() (map[ID]int, error) {
+	counts := make(map[ID]int)
+
+	contents, err := os.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+	if err := json.Unmarshal(contents, &counts); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %v", err)
+	}
+	return counts, nil
+}
+
+// write atomically replaces the state file with the specified counts.
+func (t *Tracker) write(counts map[ID]int) error {
+	contents, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal counts: %v", err)
+	}
+	return statefile.WriteFile(t.path, contents)
+}
+
+// Report records the use of the specified deprecated feature and returns the warning that is to be
+// logged. An empty warning is returned if the feature has already been used since boot. If the use
+// cannot be recorded, the warning is always returned.
+func (t *Tracker) Report(id ID) (string, error) {
+	d, ok := Lookup(id)
+	if !ok {
+		return "", fmt.Errorf("unknown deprecated feature %q", id)
+	}
+	first, err := t.Record(id)
+	if err != nil || first {
+		return d.String(), err
+	}
+	return "", nil
+}
+
+// Warn records the use of the specified deprecated feature and logs a structured warning if this is
+// the first use since boot.
+func (t *Tracker) Warn(logger logrus.FieldLogger, id ID) {
+	warning, err := t.Report(id)
+	if err != nil {
+		logger.Debugf("Failed to record use of deprecated feature %v: %v", id, err)
+	}
+	if warning == "" {
+		return
+	}
+	logger.WithField(events.Field, events.DeprecatedFeatureUsed).WithField("deprecation", id).Warningf("%v", warning)
+}
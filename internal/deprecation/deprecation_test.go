@@ -0,0 +1,69 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package deprecation
+
+import (
+	"path/filepath"
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, d := range All() {
+		require.NotEmpty(t, d.Summary, "deprecation %v", d.ID)
+		require.NotEmpty(t, d.RemovedIn, "deprecation %v", d.ID)
+		require.NotEmpty(t, d.Replacement, "deprecation %v", d.ID)
+	}
+}
+
+func TestTrackerRecord(t *testing.T) {
+	tracker := NewTracker(filepath.Join(t.TempDir(), "run", "deprecations.json"))
+
+	first, err := tracker.Record(SwarmResourceEnvvars)
+	require.NoError(t, err)
+	require.True(t, first)
+
+	first, err = tracker.Record(SwarmResourceEnvvars)
+	require.NoError(t, err)
+	require.False(t, first)
+
+	first, err = tracker.Record(LegacyImageDefaults)
+	require.NoError(t, err)
+	require.True(t, first)
+
+	counts, err := tracker.Counts()
+	require.NoError(t, err)
+	require.Equal(t, map[ID]int{SwarmResourceEnvvars: 2, LegacyImageDefaults: 1}, counts)
+}
+
+func TestTrackerWarn(t *testing.T) {
+	logger, hook := testlog.NewNullLogger()
+	tracker := NewTracker(filepath.Join(t.TempDir(), "deprecations.json"))
+
+	tracker.Warn(logger, RuntimeExperimentalConfig)
+	tracker.Warn(logger, RuntimeExperimentalConfig)
+
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	require.Equal(t, RuntimeExperimentalConfig, entry.Data["deprecation"])
+	require.Contains(t, entry.Message, "will be removed in")
+
+	_, err := tracker.Report(ID("unknown"))
+	require.Error(t, err)
+}
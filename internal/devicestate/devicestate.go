@@ -0,0 +1,159 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package devicestate records the GPUs that were removed from the node (e.g. due to a hot-unplug or a
+// GPU falling off the bus) together with the CDI devices and containers that these impact. The NVIDIA
+// Container Runtime refuses to inject devices that are marked as unavailable.
+package devicestate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/statefile"
+)
+
+// DefaultStateFile is the file in which the device state is stored. Since /run is cleared on boot,
+// devices are considered available after a reboot.
+const DefaultStateFile = "/run/nvidia-container-toolkit/device-state.json"
+
+// Device is a GPU that was removed from the node.
+type Device struct {
+	UUID  string `json:"uuid,omitempty"`
+	BusID string `json:"busId"`
+	// DeviceNode is the device node of the GPU (e.g. /dev/nvidia0).
+	DeviceNode string `json:"deviceNode,omitempty"`
+	// CDIDevices are the fully-qualified names of the CDI devices that include the device node.
+	CDIDevices []string `json:"cdiDevices,omitempty"`
+	// Containers are the IDs of the containers that were using the GPU when it was removed.
+	Containers []string  `json:"containers,omitempty"`
+	RemovedAt  time.Time `json:"removedAt"`
+}
+
+// State is the state of the devices on the node.
+type State struct {
+	Unavailable []Device `json:"unavailable,omitempty"`
+}
+
+// MarkUnavailable records the specified device as unavailable. An existing entry for the same bus ID
+// is replaced.
+func (s *State) MarkUnavailable(d Device) {
+	s.MarkAvailable(d.BusID)
+	s.Unavailable = append(s.Unavailable, d)
+	sort.Slice(s.Unavailable, func(i, j int) bool {
+		return s.Unavailable[i].BusID < s.Unavailable[j].BusID
+	})
+}
+
+// MarkAvailable removes the device with the specified bus ID from the unavailable devices. It returns
+// whether the device was marked as unavailable.
+func (s *State) MarkAvailable(busID string) bool {
+	var found bool
+	var unavailable []Device
+	for _, d := range s.Unavailable {
+		if d.BusID == busID {
+			found = true
+			continue
+		}
+		unavailable = append(unavailable, d)
+	}
+	s.Unavailable = unavailable
+	return found
+}
+
+// Get returns the unavailable device with the specified UUID or bus ID. If no such device is marked as
+// unavailable, nil is returned.
+func (s *State) Get(id string) *Device {
+	for i, d := range s.Unavailable {
+		if d.UUID == id || d.BusID == id {
+			return &s.Unavailable[i]
+		}
+	}
+	return nil
+}
+
+// ForDeviceNode returns the unavailable device with the specified device node. If no such device is
+// marked as unavailable, nil is returned.
+func (s *State) ForDeviceNode(path string) *Device {
+	for i, d := range s.Unavailable {
+		if d.DeviceNode != "" && d.DeviceNode == path {
+			return &s.Unavailable[i]
+		}
+	}
+	return nil
+}
+
+// Store persists the device state in a state file.
+type Store struct {
+	path string
+}
+
+// NewStore creates a store for the specified state file. If the path is empty, DefaultStateFile is used.
+func NewStore(path string) *Store {
+	if path == "" {
+		path = DefaultStateFile
+	}
+	s := Store{
+		path: path,
+	}
+	return &s
+}
+
+// Load reads the device state from the state file. If the state file does not exist, all devices
+// are available.
+func (s *Store) Load() (*State, error) {
+	contents, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(contents, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %v", err)
+	}
+	return &state, nil
+}
+
+// Update applies the specified function to the device state and saves the result. A lock file is
+// used to serialize updates.
+func (s *Store) Update(update func(*State) error) error {
+	return statefile.Update(s.path, func() error {
+		state, err := s.Load()
+		if err != nil {
+			return err
+		}
+		if err := update(state); err != nil {
+			return err
+		}
+		return s.write(state)
+	})
+}
+
+// write atomically replaces the state file with the specified state.
+func (s *Store) write(state *State) error {
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+	return statefile.WriteFile(s.path, append(contents, '\n'))
+}
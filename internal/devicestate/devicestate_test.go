@@ -0,0 +1,127 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package devicestate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state", "device-state.json"))
+
+	state, err := store.Load()
+	require.NoError(t, err)
+	require.Empty(t, state.Unavailable)
+
+	removedAt := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	err = store.Update(func(s *State) error {
+		s.MarkUnavailable(Device{UUID: "GPU-1", BusID: "0000:3b:00.0", DeviceNode: "/dev/nvidia1", RemovedAt: removedAt})
+		s.MarkUnavailable(Device{UUID: "GPU-0", BusID: "0000:1a:00.0", DeviceNode: "/dev/nvidia0", RemovedAt: removedAt})
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Marking a device as unavailable again replaces the existing entry.
+	err = store.Update(func(s *State) error {
+		s.MarkUnavailable(Device{UUID: "GPU-1", BusID: "0000:3b:00.0", DeviceNode: "/dev/nvidia1", Containers: []string{"abc"}, RemovedAt: removedAt})
+		return nil
+	})
+	require.NoError(t, err)
+
+	state, err = store.Load()
+	require.NoError(t, err)
+	require.Equal(t,
+		[]Device{
+			{UUID: "GPU-0", BusID: "0000:1a:00.0", DeviceNode: "/dev/nvidia0", RemovedAt: removedAt},
+			{UUID: "GPU-1", BusID: "0000:3b:00.0", DeviceNode: "/dev/nvidia1", Containers: []string{"abc"}, RemovedAt: removedAt},
+		},
+		state.Unavailable,
+	)
+
+	require.Equal(t, "0000:3b:00.0", state.Get("GPU-1").BusID)
+	require.Equal(t, "GPU-0", state.Get("0000:1a:00.0").UUID)
+	require.Nil(t, state.Get("GPU-2"))
+	require.Equal(t, "GPU-1", state.ForDeviceNode("/dev/nvidia1").UUID)
+	require.Nil(t, state.ForDeviceNode("/dev/nvidiactl"))
+
+	err = store.Update(func(s *State) error {
+		require.True(t, s.MarkAvailable("0000:1a:00.0"))
+		require.False(t, s.MarkAvailable("0000:1a:00.0"))
+		return nil
+	})
+	require.NoError(t, err)
+
+	state, err = store.Load()
+	require.NoError(t, err)
+	require.Len(t, state.Unavailable, 1)
+	require.Equal(t, "GPU-1", state.Unavailable[0].UUID)
+}
+
+func TestGetGPUs(t *testing.T) {
+	root := t.TempDir()
+	for _, gpu := range []struct {
+		busID       string
+		information string
+	}{
+		{"0000:3b:00.0", "Model:           Tesla V100\nGPU UUID:        GPU-edfee158\nBus Location:    0000:3B:00.0\nDevice Minor:    1\n"},
+		{"0000:5e:00.0", "Model:           Tesla V100\nGPU UUID:        GPU-7ab1e2c4\nBus Location:    0000:5e:00.0\n"},
+	} {
+		dir := filepath.Join(root, "proc/driver/nvidia/gpus", gpu.busID)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "information"), []byte(gpu.information), 0644))
+	}
+
+	gpus, err := GetGPUs(root)
+	require.NoError(t, err)
+	require.Equal(t,
+		map[string]GPU{
+			"0000:3b:00.0": {UUID: "GPU-edfee158", BusID: "0000:3b:00.0", Minor: 1},
+			"0000:5e:00.0": {UUID: "GPU-7ab1e2c4", BusID: "0000:5e:00.0", Minor: -1},
+		},
+		gpus,
+	)
+	require.Equal(t, "/dev/nvidia1", gpus["0000:3b:00.0"].DeviceNode())
+	require.Equal(t, "", gpus["0000:5e:00.0"].DeviceNode())
+}
+
+func TestResolveBusID(t *testing.T) {
+	root := t.TempDir()
+	for _, gpu := range []struct {
+		busID       string
+		information string
+	}{
+		{"0000:5e:00.0", "GPU UUID:        GPU-7ab1e2c4\nBus Location:    0000:5e:00.0\nDevice Minor:    0\n"},
+		{"0000:3b:00.0", "GPU UUID:        GPU-edfee158\nBus Location:    0000:3B:00.0\nDevice Minor:    1\n"},
+	} {
+		dir := filepath.Join(root, "proc/driver/nvidia/gpus", gpu.busID)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "information"), []byte(gpu.information), 0644))
+	}
+
+	gpu, index, err := ResolveBusID(root, "0000:5E:00.0")
+	require.NoError(t, err)
+	require.Equal(t, 1, index)
+	require.Equal(t, "GPU-7ab1e2c4", gpu.UUID)
+
+	_, _, err = ResolveBusID(root, "0000:65:00.0")
+	require.Error(t, err)
+}
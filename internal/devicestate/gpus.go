@@ -0,0 +1,119 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package devicestate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/proc"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/procfs"
+)
+
+// GPU is an NVIDIA GPU as described by its information file in /proc/driver/nvidia/gpus.
+type GPU struct {
+	UUID  string
+	BusID string
+	Minor int
+}
+
+// DeviceNode returns the device node of the GPU. If the device minor is not known, an empty string
+// is returned.
+func (g GPU) DeviceNode() string {
+	if g.Minor < 0 {
+		return ""
+	}
+	return fmt.Sprintf("/dev/nvidia%d", g.Minor)
+}
+
+// GetGPUs returns the GPUs described by the information files under the specified root indexed by
+// their (lowercase) bus ID. Information files that cannot be parsed are skipped.
+func GetGPUs(root string) (map[string]GPU, error) {
+	paths, err := proc.GetInformationFilePaths(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GPU information files: %v", err)
+	}
+
+	gpus := make(map[string]GPU)
+	for _, path := range paths {
+		gpuInfo, err := proc.ParseGPUInformationFile(path)
+		if err != nil {
+			continue
+		}
+		busID := info.NormalizeBusID(gpuInfo[proc.GPUInfoBusLocation])
+		if busID == "" {
+			continue
+		}
+		gpu := GPU{
+			UUID:  gpuInfo[proc.GPUInfoGPUUUID],
+			BusID: busID,
+			Minor: -1,
+		}
+		if minor, err := strconv.Atoi(gpuInfo[proc.GPUInfoDeviceMinor]); err == nil {
+			gpu.Minor = minor
+		}
+		gpus[busID] = gpu
+	}
+	return gpus, nil
+}
+
+// ResolveBusID returns the GPU with the specified PCI bus ID under the specified root together with
+// its device index. Device indices are assigned in PCI bus ID order as for NVML.
+func ResolveBusID(root string, busID string) (*GPU, int, error) {
+	gpus, err := GetGPUs(root)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	var busIDs []string
+	for id := range gpus {
+		busIDs = append(busIDs, id)
+	}
+	sort.Strings(busIDs)
+
+	busID = info.NormalizeBusID(busID)
+	for index, id := range busIDs {
+		if id == busID {
+			gpu := gpus[id]
+			return &gpu, index, nil
+		}
+	}
+	return nil, -1, fmt.Errorf("no GPU with PCI bus ID %v found", busID)
+}
+
+// FindContainers returns the sorted IDs of the containers with processes that have the specified
+// device node open.
+func FindContainers(procRoot string, deviceNode string) ([]string, error) {
+	processes, err := procfs.FindDeviceProcesses(procRoot, deviceNode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine processes using %v: %v", deviceNode, err)
+	}
+
+	seen := make(map[string]bool)
+	var containers []string
+	for _, p := range processes {
+		if p.ContainerID == "" || seen[p.ContainerID] {
+			continue
+		}
+		seen[p.ContainerID] = true
+		containers = append(containers, p.ContainerID)
+	}
+	sort.Strings(containers)
+	return containers, nil
+}
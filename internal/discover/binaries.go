@@ -0,0 +1,27 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+// DriverBinaries lists the binaries that are included in a driver installation and may be
+// injected into containers.
+var DriverBinaries = []string{
+	"nvidia-smi",              /* System management interface */
+	"nvidia-debugdump",        /* GPU coredump utility */
+	"nvidia-persistenced",     /* Persistence mode utility */
+	"nvidia-cuda-mps-control", /* Multi process service CLI */
+	"nvidia-cuda-mps-server",  /* Multi process service server */
+}
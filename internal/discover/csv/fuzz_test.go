@@ -0,0 +1,47 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzNewMountSpecFromLine checks that parsing arbitrary CSV lines either fails or returns a
+// mount spec with a valid type and a trimmed, non-empty path.
+func FuzzNewMountSpecFromLine(f *testing.F) {
+	f.Add("dev, /dev/nvhost-ctrl")
+	f.Add("lib, /usr/lib/aarch64-linux-gnu/tegra/libcuda.so.1.1")
+	f.Add("sym, /usr/lib/aarch64-linux-gnu/tegra/libcuda.so")
+	f.Add("dir, /usr/local/cuda-10.2")
+	f.Add("\t")
+	f.Add("lib,")
+	f.Add("unknown, /some/path")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		spec, err := NewMountSpecFromLine(line)
+		if err != nil {
+			require.Nil(t, spec)
+			return
+		}
+		require.Contains(t, []MountSpecType{MountSpecDev, MountSpecLib, MountSpecSym, MountSpecDir}, spec.Type)
+		require.NotEmpty(t, spec.Path)
+		require.Equal(t, strings.TrimSpace(spec.Path), spec.Path)
+	})
+}
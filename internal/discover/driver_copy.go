@@ -0,0 +1,121 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// driverVersionPattern matches the versioned names of the driver libraries from which the driver
+// version is determined (e.g. libcuda.so.535.104.05).
+var driverVersionPattern = regexp.MustCompile(`^lib(?:cuda|nvidia-ml)\.so\.([0-9]+\.[0-9]+(?:\.[0-9]+)?)$`)
+
+// NewDriverCopyDiscoverer creates a discoverer that injects the mounts from the specified
+// discoverer as a single bind mount of a driver root assembled in a subdirectory of copyDir for
+// the driver version. In contrast to NewDriverRootDiscoverer, the files are copied (using a reflink
+// if supported) instead of being hard linked. A tmpfs is mounted at copyDir if it is not already on
+// a tmpfs. Since containers do not reference the files on the host, these are not affected if the
+// host files are replaced or updated in place during a driver upgrade.
+func NewDriverCopyDiscoverer(logger *logrus.Logger, d Discover, copyDir string, containerPath string) Discover {
+	return &driverRoot{
+		Discover:      d,
+		logger:        logger,
+		stagingDir:    copyDir,
+		containerPath: filepath.Join("/", containerPath),
+		copyFiles:     true,
+		mountTmpfs:    mountTmpfs,
+		mountedRoots:  getMountedRoots,
+	}
+}
+
+// prepareCopyDir ensures that the copy directory is a tmpfs and returns the directory for the
+// driver version of the specified mounts. If a tmpfs cannot be mounted, the copies are stored on
+// the underlying filesystem.
+func (d *driverRoot) prepareCopyDir(mounts []Mount) (string, error) {
+	if err := os.MkdirAll(d.stagingDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create copy directory: %v", err)
+	}
+	if err := d.mountTmpfs(d.stagingDir); err != nil {
+		d.logger.Warningf("Failed to mount tmpfs at %v; storing driver copies on the underlying filesystem: %v", d.stagingDir, err)
+	}
+	return filepath.Join(d.stagingDir, driverVersion(mounts)), nil
+}
+
+// driverVersion returns the driver version determined from the names of the driver libraries
+// in the specified mounts. If this cannot be determined, "unknown" is returned.
+func driverVersion(mounts []Mount) string {
+	for _, m := range mounts {
+		if match := driverVersionPattern.FindStringSubmatch(filepath.Base(m.HostPath)); match != nil {
+			return match[1]
+		}
+	}
+	return "unknown"
+}
+
+// mountTmpfs mounts a tmpfs at the specified directory unless it is already on a tmpfs.
+func mountTmpfs(dir string) error {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return err
+	}
+	if stat.Type == unix.TMPFS_MAGIC {
+		return nil
+	}
+	return unix.Mount("tmpfs", dir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755")
+}
+
+// reflinkOrCopy copies the source file to the target path. A reflink is used if supported by the
+// filesystem (e.g. btrfs or XFS); otherwise the contents are copied.
+func reflinkOrCopy(source string, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err == nil {
+		return dst.Close()
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
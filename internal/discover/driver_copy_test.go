@@ -0,0 +1,144 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverCopyDiscoverer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostRoot := t.TempDir()
+	copyDir := filepath.Join(t.TempDir(), "driver-copy")
+
+	libcuda := filepath.Join(hostRoot, "lib", "libcuda.so.520.61.05")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0755))
+
+	mock := &DiscoverMock{
+		MountsFunc: func() ([]Mount, error) {
+			mounts := []Mount{
+				{
+					HostPath: libcuda,
+					Path:     "/usr/lib64/libcuda.so.520.61.05",
+				},
+			}
+			return mounts, nil
+		},
+		HooksFunc: func() ([]Hook, error) {
+			return nil, nil
+		},
+	}
+
+	// The copies for an earlier driver version that are no longer mounted are removed.
+	previousVersion := filepath.Join(copyDir, "470.82.01", "previous")
+	require.NoError(t, os.MkdirAll(previousVersion, 0755))
+	old := time.Now().Add(-2 * unusedRootGracePeriod)
+	require.NoError(t, os.Chtimes(previousVersion, old, old))
+
+	newDiscoverer := func(mountErr error) (Discover, *[]string) {
+		var mounted []string
+		d := NewDriverCopyDiscoverer(logger, mock, copyDir, "/usr/local/nvidia")
+		d.(*driverRoot).mountTmpfs = func(dir string) error {
+			mounted = append(mounted, dir)
+			return mountErr
+		}
+		d.(*driverRoot).mountedRoots = func() (map[string]bool, error) {
+			return nil, nil
+		}
+		return d, &mounted
+	}
+
+	d, mounted := newDiscoverer(nil)
+	mounts, err := d.Mounts()
+	require.NoError(t, err)
+	require.Len(t, mounts, 1)
+	require.Equal(t, []string{copyDir}, *mounted)
+
+	require.Equal(t, "/usr/local/nvidia", mounts[0].Path)
+	require.Equal(t, filepath.Join(copyDir, "520.61.05"), filepath.Dir(mounts[0].HostPath))
+	_, err = os.Stat(filepath.Dir(previousVersion))
+	require.True(t, os.IsNotExist(err))
+
+	copied := filepath.Join(mounts[0].HostPath, "/usr/lib64/libcuda.so.520.61.05")
+	contents, err := os.ReadFile(copied)
+	require.NoError(t, err)
+	require.Equal(t, "libcuda", string(contents))
+
+	// The copy must not share an inode with the host file so that in-place updates of the host
+	// file do not affect running containers.
+	hostInfo, err := os.Stat(libcuda)
+	require.NoError(t, err)
+	copyInfo, err := os.Stat(copied)
+	require.NoError(t, err)
+	require.False(t, os.SameFile(hostInfo, copyInfo))
+	require.Equal(t, hostInfo.Mode(), copyInfo.Mode())
+
+	require.NoError(t, os.WriteFile(libcuda, []byte("updated"), 0755))
+	contents, err = os.ReadFile(copied)
+	require.NoError(t, err)
+	require.Equal(t, "libcuda", string(contents))
+
+	// A failure to mount the tmpfs is not fatal.
+	other, _ := newDiscoverer(fmt.Errorf("permission denied"))
+	otherMounts, err := other.Mounts()
+	require.NoError(t, err)
+	require.Len(t, otherMounts, 1)
+	require.Equal(t, filepath.Join(copyDir, "520.61.05"), filepath.Dir(otherMounts[0].HostPath))
+}
+
+func TestDriverVersion(t *testing.T) {
+	testCases := []struct {
+		description string
+		hostPaths   []string
+		expected    string
+	}{
+		{
+			description: "libcuda",
+			hostPaths:   []string{"/usr/bin/nvidia-smi", "/usr/lib64/libcuda.so.535.104.05"},
+			expected:    "535.104.05",
+		},
+		{
+			description: "libnvidia-ml with two-part version",
+			hostPaths:   []string{"/usr/lib64/libnvidia-ml.so.470.82"},
+			expected:    "470.82",
+		},
+		{
+			description: "soname is ignored",
+			hostPaths:   []string{"/usr/lib64/libcuda.so.1"},
+			expected:    "unknown",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var mounts []Mount
+			for _, p := range tc.hostPaths {
+				mounts = append(mounts, Mount{HostPath: p})
+			}
+			require.Equal(t, tc.expected, driverVersion(mounts))
+		})
+	}
+}
@@ -0,0 +1,578 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover/csv"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/sirupsen/logrus"
+)
+
+// driverRoot is a discoverer that stages the regular files discovered as mounts
+// by a wrapped discoverer in a single directory. This directory is then injected
+// into a container as a single read-only bind mount instead of a mount per file.
+// Since the staged directory is named according to the discovered files, it is
+// shared by all containers that require the same set of files.
+type driverRoot struct {
+	Discover
+	logger        *logrus.Logger
+	stagingDir    string
+	containerPath string
+	// copyFiles indicates that the files are copied into a per driver version subdirectory of the
+	// staging directory instead of being hard linked (see NewDriverCopyDiscoverer).
+	copyFiles  bool
+	mountTmpfs func(string) error
+	// mountedRoots returns the paths of the mount sources of all mounts in all mount namespaces.
+	// This is used to determine the driver roots that are no longer in use.
+	mountedRoots func() (map[string]bool, error)
+	sync.Mutex
+	// staged and stagedDirs are the container paths of the staged files and their directories.
+	staged     map[string]bool
+	stagedDirs map[string]bool
+	cache      []Mount
+}
+
+var _ Discover = (*driverRoot)(nil)
+
+// NewDriverRootDiscoverer creates a discoverer that injects the mounts from the specified
+// discoverer as a single bind mount of a driver root assembled in stagingDir. The driver
+// root is mounted at containerPath in the container and the paths referenced by hooks are
+// updated accordingly.
+func NewDriverRootDiscoverer(logger *logrus.Logger, d Discover, stagingDir string, containerPath string) Discover {
+	return &driverRoot{
+		Discover:      d,
+		logger:        logger,
+		stagingDir:    stagingDir,
+		containerPath: filepath.Join("/", containerPath),
+		mountedRoots:  getMountedRoots,
+	}
+}
+
+// unusedRootGracePeriod is the time after its last use for which an unmounted driver root is kept.
+// This ensures that a driver root is not removed between it being staged and the container being
+// started.
+const unusedRootGracePeriod = 10 * time.Minute
+
+// fixedPathDirs are the directories containing files that are looked up at fixed paths in a container
+// (e.g. the Vulkan ICD and EGL vendor files). These are not relocated to the driver root.
+var fixedPathDirs = []string{
+	"/etc/glvnd",
+	"/etc/vulkan",
+	"/usr/share/egl",
+	"/usr/share/glvnd",
+	"/usr/share/nvidia",
+	"/usr/share/vulkan",
+}
+
+// requiresFixedPath checks whether the file at the specified container path must be injected at this
+// path instead of being relocated to the driver root. This is the case for the ICD and vendor files
+// of the graphics libraries, Xorg modules, which are loaded from the configured module path, and
+// binaries, which are looked up in the PATH.
+func requiresFixedPath(path string) bool {
+	for _, dir := range fixedPathDirs {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	if strings.Contains(path, "/xorg/modules/") {
+		return true
+	}
+	switch filepath.Base(filepath.Dir(path)) {
+	case "bin", "sbin":
+		return true
+	}
+	return false
+}
+
+// Mounts returns a single mount for the staged driver root as well as any mounts
+// that cannot be staged such as directories or sockets.
+func (d *driverRoot) Mounts() ([]Mount, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.cache != nil {
+		return d.cache, nil
+	}
+
+	mounts, err := d.Discover.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var toStage []Mount
+	var unstaged []Mount
+	// The lib and sym entries of CSV files may resolve to the same file, which is only staged once.
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+		if requiresFixedPath(m.Path) {
+			unstaged = append(unstaged, m)
+			continue
+		}
+		info, err := os.Stat(m.HostPath)
+		if err != nil || !info.Mode().IsRegular() {
+			unstaged = append(unstaged, m)
+			continue
+		}
+		toStage = append(toStage, m)
+	}
+
+	if len(toStage) == 0 {
+		d.cache = unstaged
+		return d.cache, nil
+	}
+
+	root, err := d.stage(toStage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage driver root: %v", err)
+	}
+
+	d.staged = make(map[string]bool)
+	d.stagedDirs = make(map[string]bool)
+	for _, m := range toStage {
+		d.staged[m.Path] = true
+		d.stagedDirs[filepath.Dir(m.Path)] = true
+	}
+
+	d.logger.WithField(events.Field, events.StagedDriverRootSelected).Infof("Selecting staged driver root %v as %v", root, d.containerPath)
+	driverRootMount := Mount{
+		HostPath: root,
+		Path:     d.containerPath,
+		Options: []string{
+			"ro",
+			"nosuid",
+			"nodev",
+			"bind",
+		},
+	}
+
+	d.cache = append([]Mount{driverRootMount}, unstaged...)
+	return d.cache, nil
+}
+
+// Hooks returns the hooks from the wrapped discoverer with any paths referring
+// to staged files updated to refer to the files in the mounted driver root.
+func (d *driverRoot) Hooks() ([]Hook, error) {
+	// We ensure that the mounts are staged so that the set of staged paths is known.
+	if _, err := d.Mounts(); err != nil {
+		return nil, err
+	}
+
+	hooks, err := d.Discover.Hooks()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []Hook
+	for _, h := range hooks {
+		hook := h
+		hook.Args = d.updateArgs(h.Args)
+		updated = append(updated, hook)
+	}
+	return updated, nil
+}
+
+// updateArgs updates the paths in the specified hook arguments to refer to the files in the mounted
+// driver root. Since the driver root is mounted read-only, the links created by the create-symlinks
+// hook remain at their original paths and only link targets that are staged files are updated. The
+// symlinks from the CSV files passed to the hook are resolved on the host and passed as links
+// instead, so that their targets are also updated. Since these links are created in the original
+// directories of the staged files, these directories are retained as folders for the update-ldcache
+// hook in addition to the directories in the driver root.
+func (d *driverRoot) updateArgs(args []string) []string {
+	hostRoot := getFlagValue(args, "--host-root")
+
+	var updated []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if i+1 == len(args) {
+			updated = append(updated, d.updatePath(arg))
+			continue
+		}
+		value := args[i+1]
+		switch arg {
+		case "--link":
+			updated = append(updated, arg, d.updateLink(hostRoot, value))
+		case "--csv-filename":
+			for _, link := range d.getCSVLinks(hostRoot, value) {
+				updated = append(updated, "--link", d.updateLink(hostRoot, link))
+			}
+		case "--folder":
+			updated = append(updated, arg, d.updatePath(value))
+			if d.stagedDirs[value] {
+				updated = append(updated, arg, value)
+			}
+		default:
+			updated = append(updated, d.updatePath(arg))
+			continue
+		}
+		i++
+	}
+	return updated
+}
+
+// updatePath updates the specified path to refer to the mounted driver root if it is a staged file
+// or directory or is in a staged directory.
+func (d *driverRoot) updatePath(path string) string {
+	if d.staged[path] || d.stagedDirs[path] {
+		return filepath.Join(d.containerPath, path)
+	}
+	if filepath.IsAbs(path) && d.stagedDirs[filepath.Dir(path)] {
+		return filepath.Join(d.containerPath, path)
+	}
+	return path
+}
+
+// updateLink updates the target of a link of the form <target>::<link> as used by the create-symlinks
+// hook to refer to the mounted driver root if the target is a staged file. A relative target is
+// resolved relative to the directory of the link. The link itself is not updated.
+func (d *driverRoot) updateLink(hostRoot string, link string) string {
+	parts := strings.Split(link, "::")
+	if len(parts) != 2 {
+		return link
+	}
+	target := parts[0]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(parts[1]), target)
+	}
+	target = filepath.Join("/", strings.TrimPrefix(target, hostRoot))
+	if !d.staged[target] {
+		return link
+	}
+	return fmt.Sprintf("%v::%v", filepath.Join(d.containerPath, target), parts[1])
+}
+
+// getCSVLinks returns the links of the form <target>::<link> for the symlinks in the chains of the sym
+// entries of the specified CSV file. This matches the links created by the create-symlinks hook for
+// the CSV file.
+func (d *driverRoot) getCSVLinks(hostRoot string, filename string) []string {
+	mountSpecs, err := csv.NewCSVFileParser(d.logger, filename).Parse()
+	if err != nil {
+		d.logger.Debugf("Skipping CSV file %v: %v", filename, err)
+		return nil
+	}
+
+	chainLocator := lookup.NewSymlinkChainLocator(d.logger, hostRoot)
+	var links []string
+	for _, ms := range mountSpecs {
+		if ms.Type != csv.MountSpecSym {
+			continue
+		}
+		candidates, err := chainLocator.Locate(ms.Path)
+		if err != nil {
+			d.logger.Warnf("Failed to locate symlink %v", ms.Path)
+			continue
+		}
+		for _, candidate := range candidates {
+			target, err := os.Readlink(candidate)
+			if err != nil {
+				// The final target of the chain is not a symlink.
+				continue
+			}
+			links = append(links, fmt.Sprintf("%v::%v", target, candidate))
+		}
+	}
+	return links
+}
+
+// getFlagValue returns the value of the specified flag in the specified arguments.
+func getFlagValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return ""
+}
+
+// stage assembles a driver root containing the specified mounts in the staging
+// directory and returns its path. If an identical driver root already exists,
+// it is reused. If all mounts are from a driver root staged by
+// `nvidia-ctk system stage-driver`, that driver root is used directly.
+func (d *driverRoot) stage(mounts []Mount) (string, error) {
+	stagingDir := d.stagingDir
+	stageFile := linkOrCopy
+	if d.copyFiles {
+		var err error
+		stagingDir, err = d.prepareCopyDir(mounts)
+		if err != nil {
+			return "", err
+		}
+		stageFile = reflinkOrCopy
+	} else if root := stagedRootFor(mounts); root != "" {
+		d.logger.Debugf("Using staged driver root %v", root)
+		return root, nil
+	}
+
+	id, err := driverRootID(mounts)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate driver root ID: %v", err)
+	}
+
+	root := filepath.Join(stagingDir, id)
+	if _, err := os.Stat(root); err == nil {
+		d.logger.Debugf("Using existing driver root %v", root)
+		// The modification time is updated to mark the driver root as recently used.
+		now := time.Now()
+		_ = os.Chtimes(root, now, now)
+		return root, nil
+	}
+
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %v", err)
+	}
+
+	tmp, err := os.MkdirTemp(stagingDir, ".staging-"+id+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary driver root: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return "", fmt.Errorf("failed to set permissions for temporary driver root: %v", err)
+	}
+
+	for _, m := range mounts {
+		target := filepath.Join(tmp, m.Path)
+		d.logger.Debugf("Staging %v as %v", m.HostPath, target)
+		if err := stageFile(m.HostPath, target); err != nil {
+			return "", fmt.Errorf("failed to stage %v: %v", m.HostPath, err)
+		}
+	}
+
+	// The rename ensures that a partially-assembled driver root is never used.
+	// If another process has assembled the same driver root in the meantime, we
+	// use that one instead.
+	if err := os.Rename(tmp, root); err != nil {
+		if _, serr := os.Stat(root); serr == nil {
+			return root, nil
+		}
+		return "", fmt.Errorf("failed to move driver root into place: %v", err)
+	}
+
+	// Since a new driver root is only assembled if the discovered files change (e.g. after a driver
+	// upgrade), this is when the driver roots that are no longer used are removed.
+	d.removeUnusedRoots(root)
+
+	return root, nil
+}
+
+// removeUnusedRoots removes the driver roots in the staging directory (and for copied files, the
+// directories of other driver versions) that are not mounted in any mount namespace and have not
+// been used within the grace period. Driver roots
+// that are mounted must not be removed, since removing a mount point detaches the mounts in other
+// mount namespaces. Errors are logged and otherwise ignored.
+func (d *driverRoot) removeUnusedRoots(keep string) {
+	mounted, err := d.mountedRoots()
+	if err != nil {
+		d.logger.Warningf("Not removing unused driver roots: failed to determine mounted driver roots: %v", err)
+		return
+	}
+
+	dirs := []string{filepath.Dir(keep)}
+	if d.copyFiles {
+		versions, _ := filepath.Glob(filepath.Join(d.stagingDir, "*"))
+		for _, version := range versions {
+			if version != dirs[0] {
+				dirs = append(dirs, version)
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		var remaining int
+		for _, entry := range entries {
+			root := filepath.Join(dir, entry.Name())
+			// Driver roots that are being assembled are skipped.
+			if root == keep || !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || mounted[root] {
+				remaining++
+				continue
+			}
+			if info, err := entry.Info(); err != nil || time.Since(info.ModTime()) < unusedRootGracePeriod {
+				remaining++
+				continue
+			}
+			d.logger.Infof("Removing unused driver root %v", root)
+			if err := os.RemoveAll(root); err != nil {
+				d.logger.Warningf("Failed to remove unused driver root %v: %v", root, err)
+				remaining++
+			}
+		}
+		if remaining == 0 && dir != filepath.Dir(keep) {
+			os.Remove(dir)
+		}
+	}
+}
+
+// getMountedRoots returns the host paths of the sources of the bind mounts in the mount namespaces
+// of all processes. Since the mount info only includes the path relative to the root of the
+// mounted filesystem, the mount point of the filesystem on the host is prepended to this.
+func getMountedRoots() (map[string]bool, error) {
+	hostMounts, err := readMountInfo("/proc/1/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	// The mount points of the filesystems on the host are determined by their device IDs.
+	mountPoints := make(map[string]string)
+	for _, m := range hostMounts {
+		if m.root == "/" {
+			mountPoints[m.device] = m.mountPoint
+		}
+	}
+
+	files, err := filepath.Glob("/proc/[0-9]*/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	roots := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		mounts, err := readMountInfo(file)
+		if err != nil {
+			// The process may have exited.
+			continue
+		}
+		for _, m := range mounts {
+			key := m.device + ":" + m.root
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if mountPoint, ok := mountPoints[m.device]; ok {
+				roots[filepath.Join(mountPoint, m.root)] = true
+			}
+		}
+	}
+	return roots, nil
+}
+
+// mountInfo represents the fields of an entry in a mountinfo file that are required to determine the
+// source of a bind mount.
+type mountInfo struct {
+	device     string
+	root       string
+	mountPoint string
+}
+
+// readMountInfo reads the entries of the specified mountinfo file.
+func readMountInfo(path string) ([]mountInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			device:     fields[2],
+			root:       fields[3],
+			mountPoint: fields[4],
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// driverRootID generates a stable identifier for the specified set of mounts.
+// This includes the size and modification time of each host path so that a
+// driver upgrade results in a new driver root being staged.
+func driverRootID(mounts []Mount) (string, error) {
+	var entries []string
+	for _, m := range mounts {
+		info, err := os.Stat(m.HostPath)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, fmt.Sprintf("%v:%v:%v:%v", m.HostPath, m.Path, info.Size(), info.ModTime().UnixNano()))
+	}
+	sort.Strings(entries)
+
+	h := sha256.New()
+	for _, e := range entries {
+		h.Write([]byte(e))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16], nil
+}
+
+// linkOrCopy creates a hard link to the source file at the target path. If a
+// hard link cannot be created, for example if the paths are on different
+// filesystems, the file is copied instead.
+func linkOrCopy(source string, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Link(source, target); err == nil {
+		return nil
+	}
+
+	return copyFile(source, target)
+}
+
+// copyFile copies the contents and mode of the source file to the target path.
+func copyFile(source string, target string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
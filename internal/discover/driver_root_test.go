@@ -0,0 +1,179 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverRootDiscoverer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostRoot := t.TempDir()
+	stagingDir := filepath.Join(t.TempDir(), "staging")
+
+	libcuda := filepath.Join(hostRoot, "lib", "libcuda.so.520.61.05")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	socketDir := filepath.Join(hostRoot, "run", "nvidia-persistenced")
+	require.NoError(t, os.MkdirAll(socketDir, 0755))
+
+	mock := &DiscoverMock{
+		MountsFunc: func() ([]Mount, error) {
+			mounts := []Mount{
+				{
+					HostPath: libcuda,
+					Path:     "/usr/lib64/libcuda.so.520.61.05",
+				},
+				{
+					HostPath: socketDir,
+					Path:     "/run/nvidia-persistenced",
+				},
+			}
+			return mounts, nil
+		},
+		HooksFunc: func() ([]Hook, error) {
+			hooks := []Hook{
+				{
+					Lifecycle: "createContainer",
+					Path:      "/usr/bin/nvidia-ctk",
+					Args: []string{
+						"nvidia-ctk", "hook", "update-ldcache",
+						"--folder", "/usr/lib64",
+						"--link", "libcuda.so.520.61.05::/usr/lib64/libcuda.so.1",
+						"--link", "libcuda.so.1::/usr/lib64/libcuda.so",
+						"--other", "/not/staged",
+					},
+				},
+			}
+			return hooks, nil
+		},
+	}
+
+	d := NewDriverRootDiscoverer(logger, mock, stagingDir, "/usr/local/nvidia")
+
+	mounts, err := d.Mounts()
+	require.NoError(t, err)
+	require.Len(t, mounts, 2)
+
+	require.Equal(t, "/usr/local/nvidia", mounts[0].Path)
+	require.Equal(t, stagingDir, filepath.Dir(mounts[0].HostPath))
+	contents, err := os.ReadFile(filepath.Join(mounts[0].HostPath, "/usr/lib64/libcuda.so.520.61.05"))
+	require.NoError(t, err)
+	require.Equal(t, "libcuda", string(contents))
+
+	require.Equal(t, socketDir, mounts[1].HostPath)
+
+	hooks, err := d.Hooks()
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	require.EqualValues(t,
+		[]string{
+			"nvidia-ctk", "hook", "update-ldcache",
+			"--folder", "/usr/local/nvidia/usr/lib64",
+			"--folder", "/usr/lib64",
+			"--link", "/usr/local/nvidia/usr/lib64/libcuda.so.520.61.05::/usr/lib64/libcuda.so.1",
+			"--link", "libcuda.so.1::/usr/lib64/libcuda.so",
+			"--other", "/not/staged",
+		},
+		hooks[0].Args,
+	)
+
+	// A second discoverer for the same files reuses the staged driver root.
+	other, err := NewDriverRootDiscoverer(logger, mock, stagingDir, "/usr/local/nvidia").Mounts()
+	require.NoError(t, err)
+	require.Equal(t, mounts[0].HostPath, other[0].HostPath)
+
+	entries, err := os.ReadDir(stagingDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestDriverRootRemovesUnusedRoots(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostRoot := t.TempDir()
+	stagingDir := filepath.Join(t.TempDir(), "staging")
+
+	libcuda := filepath.Join(hostRoot, "libcuda.so.520.61.05")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	old := time.Now().Add(-2 * unusedRootGracePeriod)
+	for _, name := range []string{"unused", "mounted", "recent", ".staging-other"} {
+		root := filepath.Join(stagingDir, name)
+		require.NoError(t, os.MkdirAll(root, 0755))
+		if name != "recent" {
+			require.NoError(t, os.Chtimes(root, old, old))
+		}
+	}
+
+	d := NewDriverRootDiscoverer(logger,
+		&DiscoverMock{
+			MountsFunc: func() ([]Mount, error) {
+				return []Mount{{HostPath: libcuda, Path: "/usr/lib64/libcuda.so.520.61.05"}}, nil
+			},
+		},
+		stagingDir, "/usr/local/nvidia",
+	).(*driverRoot)
+	d.mountedRoots = func() (map[string]bool, error) {
+		return map[string]bool{filepath.Join(stagingDir, "mounted"): true}, nil
+	}
+
+	mounts, err := d.Mounts()
+	require.NoError(t, err)
+	require.Len(t, mounts, 1)
+
+	entries, err := os.ReadDir(stagingDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.ElementsMatch(t,
+		[]string{".staging-other", "mounted", "recent", filepath.Base(mounts[0].HostPath)},
+		names,
+	)
+}
+
+func TestRequiresFixedPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "/usr/lib64/libcuda.so.520.61.05"},
+		{path: "/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.1"},
+		{path: "/etc/vulkan/icd.d/nvidia_icd.json", expected: true},
+		{path: "/usr/share/glvnd/egl_vendor.d/10_nvidia.json", expected: true},
+		{path: "/usr/share/egl/egl_external_platform.d/15_nvidia_gbm.json", expected: true},
+		{path: "/usr/lib/xorg/modules/drivers/nvidia_drv.so", expected: true},
+		{path: "/usr/bin/nvidia-smi", expected: true},
+		{path: "/usr/sbin/nvidia-persistenced", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			require.Equal(t, tc.expected, requiresFixedPath(tc.path))
+		})
+	}
+}
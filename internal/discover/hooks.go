@@ -17,6 +17,7 @@
 package discover
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
@@ -29,6 +30,9 @@ const (
 	nvidiaCTKDefaultFilePath = "/usr/bin/nvidia-ctk"
 )
 
+// NvidiaCTKHooks are the names of the nvidia-ctk hooks that are added to containers.
+var NvidiaCTKHooks = []string{"chmod", "create-symlinks", "update-ldcache"}
+
 var _ Discover = (*Hook)(nil)
 
 // Devices returns an empty list of devices for a Hook discoverer.
@@ -73,6 +77,20 @@ func CreateNvidiaCTKHook(nvidiaCTKPath string, hookName string, additionalArgs .
 	}
 }
 
+// ValidateNvidiaCTKHooks checks that the specified names are names of nvidia-ctk hooks.
+func ValidateNvidiaCTKHooks(names []string) error {
+	known := make(map[string]bool)
+	for _, h := range NvidiaCTKHooks {
+		known[h] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("invalid hook %q; supported hooks are %v", name, NvidiaCTKHooks)
+		}
+	}
+	return nil
+}
+
 // FindNvidiaCTK locates the nvidia-ctk executable to be used in hooks.
 // If an nvidia-ctk path is specified as an absolute path, it is used directly
 // without checking for existence of an executable at that path.
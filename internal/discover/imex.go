@@ -0,0 +1,62 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IMEXChannelsPath is the path at which the IMEX channel device nodes are created.
+	IMEXChannelsPath = "/dev/nvidia-caps-imex-channels"
+)
+
+// IMEXConfigFiles lists the IMEX configuration files that are injected alongside IMEX channels.
+var IMEXConfigFiles = []string{
+	"config.cfg",
+	"nodes_config.cfg",
+}
+
+// NewIMEXDiscoverer creates a discoverer for the specified IMEX channel device nodes and the IMEX
+// configuration files in the specified config directory.
+func NewIMEXDiscoverer(logger *logrus.Logger, root string, channelPaths []string, configDir string) Discover {
+	channels := NewCharDeviceDiscoverer(
+		logger,
+		channelPaths,
+		root,
+	)
+
+	var configFiles []string
+	for _, f := range IMEXConfigFiles {
+		configFiles = append(configFiles, filepath.Join(configDir, f))
+	}
+
+	configs := NewMounts(
+		logger,
+		lookup.NewFileLocator(
+			lookup.WithLogger(logger),
+			lookup.WithRoot(root),
+		),
+		root,
+		configFiles,
+	)
+
+	return Merge(channels, configs)
+}
@@ -0,0 +1,162 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Root defines a driver root and its priority.
+type Root struct {
+	Path     string
+	Priority int
+}
+
+// multiRoot is a discoverer that merges the devices, mounts, and hooks discovered in multiple
+// driver roots. If the same entity is discovered in more than one root, the entity from the root
+// with the highest priority is selected.
+type multiRoot struct {
+	logger      *logrus.Logger
+	roots       []Root
+	discoverers []Discover
+}
+
+var _ Discover = (*multiRoot)(nil)
+
+// NewMultiRootDiscoverer creates a discoverer for the specified driver roots. The discoverer for
+// each of the roots is constructed using the specified function. Roots with the same priority are
+// considered in the order specified.
+func NewMultiRootDiscoverer(logger *logrus.Logger, roots []Root, newDiscoverer func(root string) (Discover, error)) (Discover, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no driver roots specified")
+	}
+	if len(roots) == 1 {
+		return newDiscoverer(roots[0].Path)
+	}
+
+	sorted := append([]Root{}, roots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	d := multiRoot{
+		logger: logger,
+		roots:  sorted,
+	}
+	for _, root := range sorted {
+		rd, err := newDiscoverer(root.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct discoverer for driver root %v: %v", root.Path, err)
+		}
+		d.discoverers = append(d.discoverers, rd)
+	}
+
+	return &d, nil
+}
+
+// Devices returns the devices discovered in all roots. If a device with the same container path
+// is discovered in more than one root, only the device from the root with the highest priority is returned.
+func (d *multiRoot) Devices() ([]Device, error) {
+	selected := make(map[string]int)
+	var devices []Device
+	for i, rd := range d.discoverers {
+		rootDevices, err := rd.Devices()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering devices for driver root %v: %v", d.roots[i].Path, err)
+		}
+		for _, device := range rootDevices {
+			if !d.selectFrom(selected, i, device.Path, device.HostPath) {
+				continue
+			}
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+// Mounts returns the mounts discovered in all roots. If the same file is discovered in more than
+// one root, only the mount from the root with the highest priority is returned. Libraries with the
+// same name are considered the same file, regardless of their version or location, to ensure that
+// libraries from different driver versions are not mixed.
+func (d *multiRoot) Mounts() ([]Mount, error) {
+	selected := make(map[string]int)
+	var mounts []Mount
+	for i, rd := range d.discoverers {
+		rootMounts, err := rd.Mounts()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering mounts for driver root %v: %v", d.roots[i].Path, err)
+		}
+		for _, mount := range rootMounts {
+			if !d.selectFrom(selected, i, mountConflictKey(mount.Path), mount.HostPath) {
+				continue
+			}
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts, nil
+}
+
+// Hooks returns the hooks discovered in all roots. Identical hooks are only returned once.
+func (d *multiRoot) Hooks() ([]Hook, error) {
+	seen := make(map[string]bool)
+	var hooks []Hook
+	for i, rd := range d.discoverers {
+		rootHooks, err := rd.Hooks()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering hooks for driver root %v: %v", d.roots[i].Path, err)
+		}
+		for _, hook := range rootHooks {
+			key := strings.Join(append([]string{hook.Lifecycle, hook.Path}, hook.Args...), "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// selectFrom checks whether the entity with the specified key is selected from the root with the
+// specified index. An entity is selected from the first root in which it is discovered.
+func (d *multiRoot) selectFrom(selected map[string]int, root int, key string, hostPath string) bool {
+	owner, ok := selected[key]
+	if !ok {
+		selected[key] = root
+		return true
+	}
+	if owner == root {
+		return true
+	}
+	d.logger.Infof("Ignoring %v from driver root %v; selected from driver root %v with a higher priority", hostPath, d.roots[root].Path, d.roots[owner].Path)
+	return false
+}
+
+// mountConflictKey returns the key used to detect conflicts between mounts from different roots.
+// For libraries this is the unversioned name of the library (e.g. libcuda.so).
+func mountConflictKey(path string) string {
+	if !isLibName(path) {
+		return path
+	}
+	base := filepath.Base(path)
+	return strings.SplitN(base, ".so", 2)[0] + ".so"
+}
@@ -0,0 +1,157 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiRootDiscoverer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	discoverers := map[string]Discover{
+		"/": &DiscoverMock{
+			DevicesFunc: func() ([]Device, error) {
+				return []Device{
+					{HostPath: "/dev/nvidia0", Path: "/dev/nvidia0"},
+					{HostPath: "/dev/nvidiactl", Path: "/dev/nvidiactl"},
+				}, nil
+			},
+			MountsFunc: func() ([]Mount, error) {
+				return []Mount{
+					{HostPath: "/usr/lib/x86_64-linux-gnu/libcuda.so.525.85.12", Path: "/usr/lib/x86_64-linux-gnu/libcuda.so.525.85.12"},
+					{HostPath: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin", Path: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin"},
+				}, nil
+			},
+			HooksFunc: func() ([]Hook, error) {
+				return []Hook{
+					{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				}, nil
+			},
+		},
+		"/run/nvidia/driver": &DiscoverMock{
+			DevicesFunc: func() ([]Device, error) {
+				return []Device{
+					{HostPath: "/run/nvidia/driver/dev/nvidiactl", Path: "/dev/nvidiactl"},
+				}, nil
+			},
+			MountsFunc: func() ([]Mount, error) {
+				return []Mount{
+					{HostPath: "/run/nvidia/driver/usr/lib64/libcuda.so.535.54.03", Path: "/usr/lib64/libcuda.so.535.54.03"},
+					{HostPath: "/run/nvidia/driver/usr/lib64/libcuda.so.1", Path: "/usr/lib64/libcuda.so.1"},
+					{HostPath: "/run/nvidia/driver/usr/lib64/libnvidia-ml.so.535.54.03", Path: "/usr/lib64/libnvidia-ml.so.535.54.03"},
+				}, nil
+			},
+			HooksFunc: func() ([]Hook, error) {
+				return []Hook{
+					{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+					{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "create-symlinks"}},
+				}, nil
+			},
+		},
+	}
+	newDiscoverer := func(root string) (Discover, error) {
+		return discoverers[root], nil
+	}
+
+	testCases := []struct {
+		description     string
+		roots           []Root
+		expectedDevices []Device
+		expectedMounts  []Mount
+		expectedHooks   []Hook
+	}{
+		{
+			description: "higher priority root is selected",
+			roots: []Root{
+				{Path: "/", Priority: 0},
+				{Path: "/run/nvidia/driver", Priority: 10},
+			},
+			expectedDevices: []Device{
+				{HostPath: "/run/nvidia/driver/dev/nvidiactl", Path: "/dev/nvidiactl"},
+				{HostPath: "/dev/nvidia0", Path: "/dev/nvidia0"},
+			},
+			expectedMounts: []Mount{
+				{HostPath: "/run/nvidia/driver/usr/lib64/libcuda.so.535.54.03", Path: "/usr/lib64/libcuda.so.535.54.03"},
+				{HostPath: "/run/nvidia/driver/usr/lib64/libcuda.so.1", Path: "/usr/lib64/libcuda.so.1"},
+				{HostPath: "/run/nvidia/driver/usr/lib64/libnvidia-ml.so.535.54.03", Path: "/usr/lib64/libnvidia-ml.so.535.54.03"},
+				{HostPath: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin", Path: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin"},
+			},
+			expectedHooks: []Hook{
+				{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "create-symlinks"}},
+			},
+		},
+		{
+			description: "roots with equal priority are considered in order",
+			roots: []Root{
+				{Path: "/"},
+				{Path: "/run/nvidia/driver"},
+			},
+			expectedDevices: []Device{
+				{HostPath: "/dev/nvidia0", Path: "/dev/nvidia0"},
+				{HostPath: "/dev/nvidiactl", Path: "/dev/nvidiactl"},
+			},
+			expectedMounts: []Mount{
+				{HostPath: "/usr/lib/x86_64-linux-gnu/libcuda.so.525.85.12", Path: "/usr/lib/x86_64-linux-gnu/libcuda.so.525.85.12"},
+				{HostPath: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin", Path: "/usr/lib/firmware/nvidia/525.85.12/gsp.bin"},
+				{HostPath: "/run/nvidia/driver/usr/lib64/libnvidia-ml.so.535.54.03", Path: "/usr/lib64/libnvidia-ml.so.535.54.03"},
+			},
+			expectedHooks: []Hook{
+				{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				{Lifecycle: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "create-symlinks"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			d, err := NewMultiRootDiscoverer(logger, tc.roots, newDiscoverer)
+			require.NoError(t, err)
+
+			devices, err := d.Devices()
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedDevices, devices)
+
+			mounts, err := d.Mounts()
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedMounts, mounts)
+
+			hooks, err := d.Hooks()
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedHooks, hooks)
+		})
+	}
+}
+
+func TestMultiRootDiscovererSingleRoot(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	expected := &DiscoverMock{}
+	d, err := NewMultiRootDiscoverer(logger, []Root{{Path: "/"}}, func(root string) (Discover, error) {
+		require.Equal(t, "/", root)
+		return expected, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, expected, d)
+
+	_, err = NewMultiRootDiscoverer(logger, nil, nil)
+	require.Error(t, err)
+}
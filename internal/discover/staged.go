@@ -0,0 +1,264 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// StagedManifestFile is the name of the manifest file in a staged driver root.
+	StagedManifestFile = "manifest.json"
+	// stagedFilesDir is the name of the directory containing the files in a staged driver root.
+	// The files are stored separately from the manifest so that this directory can be mounted
+	// into a container as is.
+	stagedFilesDir = "root"
+
+	kernelModuleVersionPath = "/sys/module/nvidia/version"
+)
+
+// StagedManifest describes the contents of a staged driver root.
+type StagedManifest struct {
+	DriverVersion string       `json:"driverVersion"`
+	Files         []StagedFile `json:"files"`
+}
+
+// StagedFile represents a single file in a staged driver root.
+// The Path is the path of the file in a container as well as relative to the files directory of the staged root.
+type StagedFile struct {
+	Path     string `json:"path"`
+	HostPath string `json:"hostPath"`
+}
+
+// LoadStagedManifest loads the manifest for the staged driver root at the specified path.
+func LoadStagedManifest(root string) (*StagedManifest, error) {
+	contents, err := os.ReadFile(filepath.Join(root, StagedManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest StagedManifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// Stage creates a staged driver root containing the regular files from the
+// specified mounts at the output path. Files are hard-linked where possible so
+// that the staged root is not affected by an in-place driver upgrade.
+//
+// The staged root is assembled in a sibling directory of the output path and
+// the output path is a symlink to this directory. If a staged root already
+// exists, the symlink is replaced atomically so that the output path always
+// refers to a complete staged root.
+func Stage(logger *logrus.Logger, mounts []Mount, driverVersion string, output string) (*StagedManifest, error) {
+	output = filepath.Clean(output)
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directory: %v", err)
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(output), "."+filepath.Base(output)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	// The directory is only removed if it is not moved into place.
+	staged := false
+	defer func() {
+		if !staged {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return nil, fmt.Errorf("failed to set permissions for temporary directory: %v", err)
+	}
+
+	manifest := StagedManifest{
+		DriverVersion: driverVersion,
+	}
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if seen[m.Path] {
+			continue
+		}
+		info, err := os.Stat(m.HostPath)
+		if err != nil || !info.Mode().IsRegular() {
+			logger.Debugf("Skipping %v: not a regular file", m.HostPath)
+			continue
+		}
+		seen[m.Path] = true
+
+		logger.Infof("Staging %v as %v", m.HostPath, m.Path)
+		if err := linkOrCopy(m.HostPath, filepath.Join(tmp, stagedFilesDir, m.Path)); err != nil {
+			return nil, fmt.Errorf("failed to stage %v: %v", m.HostPath, err)
+		}
+		manifest.Files = append(manifest.Files, StagedFile{Path: m.Path, HostPath: m.HostPath})
+	}
+
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, StagedManifestFile), contents, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmp, stagedFilesDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create files directory: %v", err)
+	}
+
+	previous, err := os.Readlink(output)
+	if err != nil && !os.IsNotExist(err) {
+		// A staged root that is not a symlink was created by an earlier version and is replaced.
+		if err := os.RemoveAll(output); err != nil {
+			return nil, fmt.Errorf("failed to remove existing staged driver root: %v", err)
+		}
+	}
+
+	link := tmp + ".link"
+	if err := os.Symlink(filepath.Base(tmp), link); err != nil {
+		return nil, fmt.Errorf("failed to create symlink to staged driver root: %v", err)
+	}
+	if err := os.Rename(link, output); err != nil {
+		os.Remove(link)
+		return nil, fmt.Errorf("failed to move staged driver root into place: %v", err)
+	}
+	staged = true
+
+	if previous != "" {
+		if !filepath.IsAbs(previous) {
+			previous = filepath.Join(filepath.Dir(output), previous)
+		}
+		if err := os.RemoveAll(previous); err != nil {
+			logger.Warningf("Failed to remove previous staged driver root %v: %v", previous, err)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// staged is a discoverer that prefers the files from a staged driver root over
+// the files on the host.
+type staged struct {
+	Discover
+	logger *logrus.Logger
+	root   string
+	files  map[string]bool
+}
+
+// NewStagedDiscoverer creates a discoverer that replaces the host paths of the mounts
+// from the specified discoverer with the corresponding files in the staged driver root.
+// If no valid staged driver root exists at the specified path, the discoverer is returned unmodified.
+func NewStagedDiscoverer(logger *logrus.Logger, d Discover, root string) Discover {
+	if root == "" {
+		return d
+	}
+
+	// The symlink is resolved so that a staged root that is replaced while the
+	// container is being created is not mixed with its replacement.
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		logger.Debugf("Not using staged driver root %v: %v", root, err)
+		return d
+	}
+	root = resolved
+
+	manifest, err := LoadStagedManifest(root)
+	if err != nil {
+		logger.Debugf("Not using staged driver root %v: %v", root, err)
+		return d
+	}
+
+	if version := KernelModuleVersion(); version != "" && manifest.DriverVersion != "" && version != manifest.DriverVersion {
+		logger.WithField(events.Field, events.StagedDriverRootIgnored).Warnf("Ignoring staged driver root %v: driver version %v does not match kernel module version %v", root, manifest.DriverVersion, version)
+		return d
+	}
+
+	files := make(map[string]bool)
+	for _, f := range manifest.Files {
+		files[f.Path] = true
+	}
+
+	return &staged{
+		Discover: d,
+		logger:   logger,
+		root:     root,
+		files:    files,
+	}
+}
+
+// Mounts returns the mounts from the wrapped discoverer with the host paths
+// updated to refer to the staged driver root where possible.
+func (d *staged) Mounts() ([]Mount, error) {
+	mounts, err := d.Discover.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []Mount
+	for _, m := range mounts {
+		mount := m
+		if d.files[m.Path] {
+			mount.HostPath = filepath.Join(d.root, stagedFilesDir, m.Path)
+			d.logger.Debugf("Using staged %v for %v", mount.HostPath, m.Path)
+		}
+		updated = append(updated, mount)
+	}
+	return updated, nil
+}
+
+// stagedRootFor returns the files directory of the staged driver root containing all the specified mounts.
+// If the mounts are not all from a single staged driver root, the empty string is returned.
+// Since the manifest is not included in the files directory, it is not injected into the container.
+func stagedRootFor(mounts []Mount) string {
+	var root string
+	for _, m := range mounts {
+		if !strings.HasSuffix(m.HostPath, m.Path) {
+			return ""
+		}
+		r := strings.TrimSuffix(m.HostPath, m.Path)
+		if root != "" && r != root {
+			return ""
+		}
+		root = r
+	}
+	if root == "" || filepath.Base(root) != stagedFilesDir {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), StagedManifestFile)); err != nil {
+		return ""
+	}
+	return root
+}
+
+// KernelModuleVersion returns the version of the loaded NVIDIA kernel module.
+// If this cannot be determined, the empty string is returned.
+func KernelModuleVersion() string {
+	contents, err := os.ReadFile(kernelModuleVersionPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
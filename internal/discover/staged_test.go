@@ -0,0 +1,94 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagedDiscoverer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostRoot := t.TempDir()
+	output := filepath.Join(t.TempDir(), "driver-stage")
+
+	libcuda := filepath.Join(hostRoot, "usr", "lib64", "libcuda.so.520.61.05")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	mounts := []Mount{
+		{
+			HostPath: libcuda,
+			Path:     "/usr/lib64/libcuda.so.520.61.05",
+		},
+		{
+			HostPath: filepath.Join(hostRoot, "usr", "lib64"),
+			Path:     "/usr/lib64",
+		},
+	}
+
+	manifest, err := Stage(logger, mounts, "520.61.05", output)
+	require.NoError(t, err)
+	require.Equal(t, "520.61.05", manifest.DriverVersion)
+	require.EqualValues(t, []StagedFile{{Path: "/usr/lib64/libcuda.so.520.61.05", HostPath: libcuda}}, manifest.Files)
+
+	loaded, err := LoadStagedManifest(output)
+	require.NoError(t, err)
+	require.EqualValues(t, manifest, loaded)
+
+	previous, err := filepath.EvalSymlinks(output)
+	require.NoError(t, err)
+
+	// Staging again replaces the existing staged root.
+	_, err = Stage(logger, mounts[:1], "520.61.05", output)
+	require.NoError(t, err)
+
+	root, err := filepath.EvalSymlinks(output)
+	require.NoError(t, err)
+	require.NotEqual(t, previous, root)
+	_, err = os.Stat(previous)
+	require.True(t, os.IsNotExist(err))
+
+	mock := &DiscoverMock{
+		MountsFunc: func() ([]Mount, error) {
+			return mounts, nil
+		},
+	}
+
+	d := NewStagedDiscoverer(logger, mock, output)
+	discovered, err := d.Mounts()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "root", "/usr/lib64/libcuda.so.520.61.05"), discovered[0].HostPath)
+	require.Equal(t, mounts[1], discovered[1])
+
+	// A missing staged root leaves the discoverer unmodified.
+	require.Equal(t, mock, NewStagedDiscoverer(logger, mock, filepath.Join(hostRoot, "missing")))
+
+	// The driver-root strategy uses the staged root directly.
+	driverRootMounts, err := NewDriverRootDiscoverer(logger, d, t.TempDir(), "/usr/local/nvidia").Mounts()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "root"), driverRootMounts[0].HostPath)
+	// The manifest is not injected into the container.
+	_, err = os.Stat(filepath.Join(driverRootMounts[0].HostPath, StagedManifestFile))
+	require.True(t, os.IsNotExist(err))
+
+}
@@ -0,0 +1,280 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package events
+
+import "sort"
+
+// ID is a stable identifier for a log event.
+// IDs are attached to log entries as the "event" field and are not changed
+// across releases, allowing log-based alerting to be independent of the
+// wording of the log message.
+type ID string
+
+// Field is the name of the log field used to record the event ID.
+const Field = "event"
+
+// The following event IDs are defined. The ranges are:
+//
+//	NVCT1xxx: container lifecycle and OCI specification modification
+//	NVCT2xxx: CDI device injection
+//	NVCT3xxx: driver discovery
+//	NVCT4xxx: reporting and diagnostics
+const (
+	InjectionStart           = ID("NVCT1001")
+	InjectionComplete        = ID("NVCT1002")
+	ModificationSkipped      = ID("NVCT1003")
+	ModificationTimeout      = ID("NVCT1004")
+	NVMLThrottleBypassed     = ID("NVCT1005")
+	CDIInject                = ID("NVCT2001")
+	CDIDevicesIgnored        = ID("NVCT2002")
+	CDIRefreshFailed         = ID("NVCT2003")
+	CDISpecDirRejected       = ID("NVCT2004")
+	CDIDeviceUnavailable     = ID("NVCT2005")
+	StagedDriverRootIgnored  = ID("NVCT3001")
+	StagedDriverRootSelected = ID("NVCT3002")
+	UnsupportedMountStrategy = ID("NVCT3003")
+	ChecksumMismatch         = ID("NVCT3004")
+	ForeignArchitecture      = ID("NVCT3005")
+	KernelConstraint         = ID("NVCT3006")
+	GPURemoved               = ID("NVCT3007")
+	RequestMetricsFailed     = ID("NVCT4001")
+	DebugBundleCaptureFailed = ID("NVCT4002")
+	DeprecatedFeatureUsed    = ID("NVCT4003")
+	LegacyRequestKept        = ID("NVCT4004")
+)
+
+// Event describes a log event.
+type Event struct {
+	ID          ID
+	Name        string
+	Summary     string
+	Detail      string
+	Remediation string
+}
+
+var registry = map[ID]Event{
+	InjectionStart: {
+		Name:    "injection-start",
+		Summary: "The OCI specification of a container is being modified",
+		Detail: "The NVIDIA Container Runtime has determined that the container requests " +
+			"NVIDIA devices and is modifying its OCI specification before forwarding the " +
+			"command to the low-level runtime.",
+	},
+	InjectionComplete: {
+		Name:    "injection-complete",
+		Summary: "The OCI specification of a container was modified",
+		Detail: "The required modifications were applied to the OCI specification and the " +
+			"updated specification was written to the container bundle.",
+	},
+	ModificationSkipped: {
+		Name:    "modification-skipped-non-linux",
+		Summary: "Modification was skipped for a non-Linux container",
+		Detail: "The OCI specification targets a platform other than Linux (for example " +
+			"Windows or a VM) and is forwarded to the low-level runtime unmodified.",
+		Remediation: "Use a Linux container image if NVIDIA devices are required.",
+	},
+	ModificationTimeout: {
+		Name:    "modification-timeout",
+		Summary: "The modification of an OCI specification timed out",
+		Detail: "Loading the config, discovering the required modifications (including NVML " +
+			"calls and refreshing the CDI registry), and applying these to the OCI specification " +
+			"did not complete within the configured modification-timeout. The container is not " +
+			"started. This is typically caused by a hung driver or an unresponsive (e.g. NFS-backed) " +
+			"driver root.",
+		Remediation: "Check that nvidia-smi responds and that the driver root is accessible, or " +
+			"increase nvidia-container-runtime.modification-timeout in config.toml.",
+	},
+	NVMLThrottleBypassed: {
+		Name:    "nvml-throttle-bypassed",
+		Summary: "A container was created without waiting for an NVML slot",
+		Detail: "The number of concurrent container creates that initialize NVML is limited " +
+			"(nvidia-container-runtime.nvml-throttle.max-concurrent) but no slot was available " +
+			"before the timeout expired or the lock dir could not be accessed. The container is " +
+			"created regardless.",
+		Remediation: "Increase nvml-throttle.timeout or max-concurrent if this occurs frequently, " +
+			"and ensure that nvml-throttle.lock-dir is writable.",
+	},
+	CDIInject: {
+		Name:    "cdi-inject",
+		Summary: "Devices are being injected using CDI",
+		Detail:  "The requested devices are being resolved against the CDI registry and the edits for these devices applied to the OCI specification.",
+	},
+	CDIDevicesIgnored: {
+		Name:    "cdi-devices-ignored",
+		Summary: "Devices in NVIDIA_VISIBLE_DEVICES were ignored",
+		Detail: "Devices requested through the NVIDIA_VISIBLE_DEVICES environment variable are " +
+			"ignored in CDI mode when the accept-nvidia-visible-devices-envvar-when-unprivileged " +
+			"option is disabled and the container is not privileged.",
+		Remediation: "Request devices using CDI annotations, or enable " +
+			"accept-nvidia-visible-devices-envvar-when-unprivileged in config.toml.",
+	},
+	CDIRefreshFailed: {
+		Name:    "cdi-refresh-failed",
+		Summary: "The CDI registry could not be refreshed",
+		Detail: "One or more errors were encountered while loading the CDI specifications from " +
+			"the configured spec-dirs. Devices defined in invalid specifications cannot be " +
+			"injected, although devices from other specifications remain available. If " +
+			"nvidia-container-runtime.modes.cdi.strict is enabled, the container is not started and " +
+			"the invalid specifications are listed in the error.",
+		Remediation: "Validate the CDI specifications in the configured spec-dirs, for example " +
+			"by regenerating them using 'nvidia-ctk cdi generate', and remove conflicting or " +
+			"malformed files.",
+	},
+	CDISpecDirRejected: {
+		Name:    "cdi-spec-dir-rejected",
+		Summary: "A CDI spec dir was rejected",
+		Detail: "A configured CDI spec dir is not included in the allowed-spec-dirs, or the spec dir, " +
+			"a specification in it, or one of their parent directories has an " +
+			"owner or permissions that allow users other than root to add or modify CDI " +
+			"specifications. No CDI devices are injected.",
+		Remediation: "Ensure that the CDI spec dirs, the specifications in these, and their parent " +
+			"directories are owned by root and are not writable by other users (e.g. 'chmod 0755'), " +
+			"or update the allowed-spec-dirs in config.toml.",
+	},
+	CDIDeviceUnavailable: {
+		Name:    "cdi-device-unavailable",
+		Summary: "A requested device was removed from the node",
+		Detail: "A device injected into the container includes the device node of a GPU that " +
+			"was removed from the node (e.g. due to a hot-unplug or the GPU falling off the bus) " +
+			"as recorded by 'nvidia-ctk system watch-devices'. The container is not started.",
+		Remediation: "Run 'nvidia-ctk state impacted --device <uuid>' to list the affected containers. " +
+			"Request another device or restore the GPU (e.g. by resetting it or rebooting the node) " +
+			"and regenerate the CDI specification.",
+	},
+	StagedDriverRootIgnored: {
+		Name:    "staged-driver-root-ignored",
+		Summary: "A staged driver root was ignored",
+		Detail: "The driver version recorded in the manifest of the staged driver root does " +
+			"not match the version of the loaded kernel module, or the runtime mode is not csv. " +
+			"The driver root is not used.",
+		Remediation: "Restage the driver files for the running driver version or remove the stale staging directory. " +
+			"Staged driver roots are only used in csv mode.",
+	},
+	StagedDriverRootSelected: {
+		Name:    "staged-driver-root-selected",
+		Summary: "A staged driver root was selected",
+		Detail:  "The driver-root mount strategy selected a staged driver root to mount into the container.",
+	},
+	UnsupportedMountStrategy: {
+		Name:        "unsupported-mount-strategy",
+		Summary:     "An unsupported mount strategy was configured",
+		Detail:      "The mount-strategy configured in config.toml is not supported and the default strategy is used instead.",
+		Remediation: "Set nvidia-container-runtime.mount-strategy to a supported value.",
+	},
+	ChecksumMismatch: {
+		Name:    "checksum-mismatch",
+		Summary: "An injected file does not match the checksum manifest",
+		Detail: "The SHA256 checksum of a file injected into a container does not match the " +
+			"checksum recorded in the configured checksum manifest, or the manifest could not be " +
+			"loaded. This indicates that the driver files were modified, for example by tampering " +
+			"or a partially-applied driver upgrade.",
+		Remediation: "Run 'nvidia-ctk doctor' to list the affected files. If the driver was " +
+			"upgraded, regenerate the CDI specification and checksum manifest using " +
+			"'nvidia-ctk cdi generate --checksum-manifest'.",
+	},
+	ForeignArchitecture: {
+		Name:    "foreign-architecture",
+		Summary: "The NVIDIA driver was not injected into a container of another architecture",
+		Detail: "The executables in the root filesystem of the container are built for an " +
+			"architecture other than that of the host, for example because the image is run " +
+			"using binfmt_misc and qemu-user emulation. The libraries of the host driver cannot " +
+			"be loaded in such a container and no driver for the architecture of the container " +
+			"is configured for the selected mode.",
+		Remediation: "Run an image that matches the architecture of the host or configure a driver " +
+			"for the architecture in the nvidia-container-runtime.foreign-architecture section of " +
+			"the config (driver-roots for csv mode or cdi-spec-dirs for cdi mode).",
+	},
+	KernelConstraint: {
+		Name:    "kernel-constraint",
+		Summary: "The NVIDIA kernel module is not loaded and cannot be loaded on demand",
+		Detail: "The NVIDIA kernel module (and hence its device nodes) is not available and a " +
+			"constraint of the running kernel prevents it from being loaded when a container is " +
+			"started. Kernel lockdown, secure boot, and module.sig_enforce only allow signed modules " +
+			"to be loaded and the NVIDIA driver does not load on PREEMPT_RT kernels unless it was " +
+			"built with IGNORE_PREEMPT_RT_PRESENCE=1.",
+		Remediation: "Run 'nvidia-ctk doctor' to show the detected constraints. Sign the NVIDIA " +
+			"kernel modules with a key enrolled in the MOK list or build these for the real-time " +
+			"kernel, and load the modules before starting containers.",
+	},
+	GPURemoved: {
+		Name:    "gpu-removed",
+		Summary: "A GPU was removed from the node",
+		Detail: "A remove event was received for an NVIDIA GPU (e.g. due to a hot-unplug or the " +
+			"GPU falling off the bus). The GPU is recorded as unavailable in the device state " +
+			"file together with the CDI devices and containers that it impacts.",
+		Remediation: "Run 'nvidia-ctk state impacted --device <uuid>' to list the affected containers " +
+			"and check the kernel log for XID errors.",
+	},
+	RequestMetricsFailed: {
+		Name:    "request-metrics-failed",
+		Summary: "Device request metrics could not be updated",
+		Detail: "The device request report is enabled but the configured metrics file could " +
+			"not be updated. The container is started regardless.",
+		Remediation: "Ensure that the directory containing request-report.metrics-file exists and is writable.",
+	},
+	DebugBundleCaptureFailed: {
+		Name:    "debug-bundle-capture-failed",
+		Summary: "A debug bundle could not be captured",
+		Detail:  "Capturing a debug bundle for a failed runtime invocation was requested but the bundle could not be written.",
+		Remediation: "Ensure that debug.bundle-dir exists and is writable, or disable " +
+			"debug.capture-bundle.",
+	},
+	DeprecatedFeatureUsed: {
+		Name:    "deprecated-feature-used",
+		Summary: "A deprecated feature was used",
+		Detail: "A config option or request mechanism that is scheduled for removal was used. " +
+			"The warning is logged once per boot and the number of uses is recorded in " +
+			"/run/nvidia-container-toolkit/deprecations.json.",
+		Remediation: "Run 'nvidia-ctk doctor' to list the deprecated features in use and their " +
+			"replacements, and update the config or containers before upgrading.",
+	},
+	LegacyRequestKept: {
+		Name:    "legacy-request-kept",
+		Summary: "A legacy device request could not be translated into CDI device requests",
+		Detail: "The translation of legacy NVIDIA_VISIBLE_DEVICES requests is enabled but not all " +
+			"requested devices are defined in the CDI spec dirs, or the container specifies " +
+			"NVIDIA_REQUIRE_* or NVIDIA_DRIVER_CAPABILITIES constraints that are only enforced in " +
+			"legacy mode. The devices are injected by the NVIDIA Container Runtime Hook instead. " +
+			"The container would fail to start or would not be checked against its constraints in cdi mode.",
+		Remediation: "Generate a CDI specification that includes the listed devices using " +
+			"'nvidia-ctk cdi generate', or update the container to request existing CDI devices. " +
+			"For the listed constraints, verify that the container runs on the supported drivers.",
+	},
+}
+
+// Lookup returns the event with the specified ID.
+func Lookup(id ID) (Event, bool) {
+	e, ok := registry[id]
+	if !ok {
+		return Event{}, false
+	}
+	e.ID = id
+	return e, true
+}
+
+// All returns all registered events ordered by ID.
+func All() []Event {
+	var all []Event
+	for id := range registry {
+		e, _ := Lookup(id)
+		all = append(all, e)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
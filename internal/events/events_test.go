@@ -0,0 +1,77 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package events
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	idPattern := regexp.MustCompile(`^NVCT[1-4][0-9]{3}$`)
+
+	names := make(map[string]ID)
+	for _, e := range All() {
+		require.Regexp(t, idPattern, string(e.ID))
+		require.NotEmpty(t, e.Name, "event %v", e.ID)
+		require.NotEmpty(t, e.Summary, "event %v", e.ID)
+		require.NotEmpty(t, e.Detail, "event %v", e.ID)
+
+		other, exists := names[e.Name]
+		require.False(t, exists, "event name %q used by %v and %v", e.Name, e.ID, other)
+		names[e.Name] = e.ID
+	}
+}
+
+func TestLookup(t *testing.T) {
+	testCases := []struct {
+		description  string
+		id           ID
+		expectedOk   bool
+		expectedName string
+	}{
+		{
+			description:  "known event",
+			id:           CDIRefreshFailed,
+			expectedOk:   true,
+			expectedName: "cdi-refresh-failed",
+		},
+		{
+			description:  "injection start",
+			id:           ID("NVCT1001"),
+			expectedOk:   true,
+			expectedName: "injection-start",
+		},
+		{
+			description: "unknown event",
+			id:          ID("NVCT9999"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			e, ok := Lookup(tc.id)
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expectedName, e.Name)
+			if ok {
+				require.Equal(t, tc.id, e.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Feature is the name of a feature that can be enabled or disabled per node.
+type Feature string
+
+// Stage defines the maturity of a feature.
+type Stage string
+
+// The following stages are defined for features.
+const (
+	// StageAlpha indicates that a feature is experimental and is disabled by default.
+	StageAlpha = Stage("alpha")
+	// StageBeta indicates that a feature is well tested but may still change.
+	StageBeta = Stage("beta")
+	// StageGA indicates that a feature is generally available.
+	StageGA = Stage("ga")
+)
+
+// The following features are defined.
+const (
+	// HooklessCDI injects the edits of CDI devices without relying on nvidia-ctk hooks.
+	HooklessCDI = Feature("hookless-cdi")
+	// GoLegacyInjection injects devices in legacy mode without invoking nvidia-container-cli.
+	GoLegacyInjection = Feature("go-legacy-injection")
+	// NRIPlugin allows devices to be injected by an NRI plugin instead of the runtime.
+	NRIPlugin = Feature("nri-plugin")
+)
+
+// Spec describes a feature.
+type Spec struct {
+	Default     bool
+	Stage       Stage
+	Description string
+}
+
+// registry defines the known features.
+var registry = map[Feature]Spec{
+	HooklessCDI: {
+		Stage:       StageAlpha,
+		Description: "Inject the edits of CDI devices without relying on nvidia-ctk hooks",
+	},
+	GoLegacyInjection: {
+		Stage:       StageAlpha,
+		Description: "Inject devices in legacy mode without invoking nvidia-container-cli",
+	},
+	NRIPlugin: {
+		Stage:       StageAlpha,
+		Description: "Allow devices to be injected by an NRI plugin instead of the runtime",
+	},
+}
+
+// Status describes the state of a feature.
+type Status struct {
+	Feature     Feature `json:"feature"`
+	Stage       Stage   `json:"stage"`
+	Default     bool    `json:"default"`
+	Enabled     bool    `json:"enabled"`
+	Description string  `json:"description"`
+}
+
+// Gates holds the enabled state of the known features. Features that are not explicitly
+// set use their default.
+type Gates struct {
+	values map[Feature]bool
+}
+
+// New creates feature gates with the specified values keyed by feature name. An error is
+// returned for unknown features.
+func New(values map[string]bool) (*Gates, error) {
+	g := &Gates{
+		values: make(map[Feature]bool),
+	}
+	if err := g.Set(values); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Set sets the specified values keyed by feature name. These take precedence over the values
+// that are already set. An error is returned for unknown features.
+func (g *Gates) Set(values map[string]bool) error {
+	for name, enabled := range values {
+		feature := Feature(name)
+		if _, ok := registry[feature]; !ok {
+			return fmt.Errorf("unknown feature %q", name)
+		}
+		g.values[feature] = enabled
+	}
+	return nil
+}
+
+// SetFromString sets the values specified as a comma-separated list of feature=bool pairs
+// (e.g. hookless-cdi=true,nri-plugin=false). An empty string is ignored.
+func (g *Gates) SetFromString(value string) error {
+	values, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	return g.Set(values)
+}
+
+// Enabled returns whether the specified feature is enabled.
+func (g *Gates) Enabled(feature Feature) bool {
+	if g != nil {
+		if enabled, ok := g.values[feature]; ok {
+			return enabled
+		}
+	}
+	return registry[feature].Default
+}
+
+// Status returns the state of all known features ordered by name.
+func (g *Gates) Status() []Status {
+	var statuses []Status
+	for feature, spec := range registry {
+		statuses = append(statuses, Status{
+			Feature:     feature,
+			Stage:       spec.Stage,
+			Default:     spec.Default,
+			Enabled:     g.Enabled(feature),
+			Description: spec.Description,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Feature < statuses[j].Feature
+	})
+	return statuses
+}
+
+// Parse parses a comma-separated list of feature=bool pairs. Feature names are not validated.
+func Parse(value string) (map[string]bool, error) {
+	values := make(map[string]bool)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid feature gate %q: expected feature=bool", pair)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for feature %v: %v", parts[0], err)
+		}
+		values[strings.TrimSpace(parts[0])] = enabled
+	}
+	return values, nil
+}
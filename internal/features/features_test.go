@@ -0,0 +1,109 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package features
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGates(t *testing.T) {
+	testCases := []struct {
+		description   string
+		values        map[string]bool
+		flag          string
+		expected      map[Feature]bool
+		expectedError bool
+	}{
+		{
+			description: "defaults",
+			expected: map[Feature]bool{
+				HooklessCDI:       false,
+				GoLegacyInjection: false,
+				NRIPlugin:         false,
+			},
+		},
+		{
+			description: "config values",
+			values:      map[string]bool{"hookless-cdi": true},
+			expected: map[Feature]bool{
+				HooklessCDI: true,
+				NRIPlugin:   false,
+			},
+		},
+		{
+			description: "flag takes precedence",
+			values:      map[string]bool{"hookless-cdi": true},
+			flag:        "hookless-cdi=false, nri-plugin=true",
+			expected: map[Feature]bool{
+				HooklessCDI: false,
+				NRIPlugin:   true,
+			},
+		},
+		{
+			description:   "unknown feature in config",
+			values:        map[string]bool{"unknown": true},
+			expectedError: true,
+		},
+		{
+			description:   "unknown feature in flag",
+			flag:          "unknown=true",
+			expectedError: true,
+		},
+		{
+			description:   "invalid flag",
+			flag:          "hookless-cdi",
+			expectedError: true,
+		},
+		{
+			description:   "invalid value",
+			flag:          "hookless-cdi=maybe",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			g, err := New(tc.values)
+			if err == nil {
+				err = g.SetFromString(tc.flag)
+			}
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for feature, enabled := range tc.expected {
+				require.Equal(t, enabled, g.Enabled(feature), feature)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	g, err := New(map[string]bool{"nri-plugin": true})
+	require.NoError(t, err)
+
+	statuses := g.Status()
+	require.Len(t, statuses, len(registry))
+	require.Equal(t, GoLegacyInjection, statuses[0].Feature)
+	require.Equal(t, NRIPlugin, statuses[2].Feature)
+	require.True(t, statuses[2].Enabled)
+	require.False(t, statuses[2].Default)
+	require.Equal(t, StageAlpha, statuses[2].Stage)
+}
@@ -0,0 +1,212 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package hookinject applies the CDI modifications of the NVIDIA Container Runtime to a container from
+// an OCI hook. This allows devices to be injected in environments where the runtime cannot be replaced.
+package hookinject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/modifier"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Edits are the modifications that the CDI modifier of the NVIDIA Container Runtime makes to the OCI
+// specification of a container. These are determined from the specification of a running container so
+// that these can be applied to the container from an OCI hook.
+type Edits struct {
+	Devices     []specs.LinuxDevice
+	DeviceRules []specs.LinuxDeviceCgroup
+	Mounts      []specs.Mount
+	// Hooks are the hooks that run before the container is started.
+	Hooks []specs.Hook
+	// LateHooks are the hooks that run once the container is started or stopped.
+	LateHooks []specs.Hook
+	Env       []string
+}
+
+// IsEmpty checks whether no edits are required.
+func (e *Edits) IsEmpty() bool {
+	return e == nil || reflect.DeepEqual(*e, Edits{})
+}
+
+// GetEdits determines the edits for the specified OCI specification by applying the CDI modifier to a
+// copy of the specification. As is the case for the NVIDIA Container Runtime, device requests in image
+// labels are considered if enabled in the config. Nil is returned if no devices are requested.
+func GetEdits(logger *logrus.Logger, cfg *config.Config, spec *specs.Spec) (*Edits, error) {
+	modified, err := copySpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ociSpec, err := modifier.NewImageLabelsSpec(logger, cfg, oci.NewMemorySpec(modified))
+	if err != nil {
+		return nil, err
+	}
+	m, err := modifier.NewCDIModifier(context.Background(), logger, cfg, ociSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct CDI modifier: %v", err)
+	}
+	if m == nil {
+		return nil, nil
+	}
+	disabledHooks, err := modifier.NewDisabledHooksModifier(logger, cfg, "cdi")
+	if err != nil {
+		return nil, err
+	}
+	if err := ociSpec.Modify(modifier.Merge(m, disabledHooks)); err != nil {
+		return nil, fmt.Errorf("failed to apply CDI modifier: %v", err)
+	}
+
+	modified, err = ociSpec.Load()
+	if err != nil {
+		return nil, err
+	}
+	return getEdits(spec, modified), nil
+}
+
+// getEdits returns the difference between the original and modified specifications.
+func getEdits(original *specs.Spec, modified *specs.Spec) *Edits {
+	e := Edits{}
+
+	existingDevices := make(map[string]bool)
+	for _, d := range getDevices(original) {
+		existingDevices[d.Path] = true
+	}
+	for _, d := range getDevices(modified) {
+		if !existingDevices[d.Path] {
+			e.Devices = append(e.Devices, d)
+		}
+	}
+
+	originalRules := getDeviceRules(original)
+	for _, r := range getDeviceRules(modified) {
+		if !containsDeviceRule(originalRules, r) {
+			e.DeviceRules = append(e.DeviceRules, r)
+		}
+	}
+
+	for _, m := range modified.Mounts {
+		if !containsMount(original.Mounts, m) {
+			e.Mounts = append(e.Mounts, m)
+		}
+	}
+
+	originalHooks := getHooks(original)
+	modifiedHooks := getHooks(modified)
+	e.Hooks = append(e.Hooks, newHooks(originalHooks.Prestart, modifiedHooks.Prestart)...)
+	e.Hooks = append(e.Hooks, newHooks(originalHooks.CreateRuntime, modifiedHooks.CreateRuntime)...)
+	e.Hooks = append(e.Hooks, newHooks(originalHooks.CreateContainer, modifiedHooks.CreateContainer)...)
+	e.LateHooks = append(e.LateHooks, newHooks(originalHooks.StartContainer, modifiedHooks.StartContainer)...)
+	e.LateHooks = append(e.LateHooks, newHooks(originalHooks.Poststart, modifiedHooks.Poststart)...)
+	e.LateHooks = append(e.LateHooks, newHooks(originalHooks.Poststop, modifiedHooks.Poststop)...)
+
+	originalEnv := make(map[string]bool)
+	for _, env := range getEnv(original) {
+		originalEnv[env] = true
+	}
+	for _, env := range getEnv(modified) {
+		if !originalEnv[env] {
+			e.Env = append(e.Env, env)
+		}
+	}
+
+	return &e
+}
+
+// copySpec returns a deep copy of the specified OCI specification.
+func copySpec(spec *specs.Spec) (*specs.Spec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OCI spec: %v", err)
+	}
+	var copied specs.Spec
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy OCI spec: %v", err)
+	}
+	return &copied, nil
+}
+
+func getDevices(spec *specs.Spec) []specs.LinuxDevice {
+	if spec.Linux == nil {
+		return nil
+	}
+	return spec.Linux.Devices
+}
+
+func getDeviceRules(spec *specs.Spec) []specs.LinuxDeviceCgroup {
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil
+	}
+	return spec.Linux.Resources.Devices
+}
+
+func containsDeviceRule(rules []specs.LinuxDeviceCgroup, rule specs.LinuxDeviceCgroup) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMount(mounts []specs.Mount, mount specs.Mount) bool {
+	for _, m := range mounts {
+		if reflect.DeepEqual(m, mount) {
+			return true
+		}
+	}
+	return false
+}
+
+func getHooks(spec *specs.Spec) specs.Hooks {
+	if spec.Hooks == nil {
+		return specs.Hooks{}
+	}
+	return *spec.Hooks
+}
+
+// newHooks returns the hooks in modified that are not in original.
+func newHooks(original []specs.Hook, modified []specs.Hook) []specs.Hook {
+	var hooks []specs.Hook
+	for _, h := range modified {
+		var found bool
+		for _, o := range original {
+			if reflect.DeepEqual(o, h) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+func getEnv(spec *specs.Spec) []string {
+	if spec.Process == nil {
+		return nil
+	}
+	return spec.Process.Env
+}
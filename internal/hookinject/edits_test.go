@@ -0,0 +1,178 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hookinject
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEdits(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	cdiSpec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - DEVICE=gpu0
+    mounts:
+    - hostPath: /usr/lib/libcuda.so.1
+      containerPath: /usr/lib/libcuda.so.1
+      options: [ro, nosuid, nodev, bind]
+containerEdits:
+  hooks:
+  - hookName: createContainer
+    path: /usr/bin/nvidia-ctk
+    args: [nvidia-ctk, hook, update-ldcache]
+  - hookName: startContainer
+    path: /usr/bin/late-hook
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(cdiSpec), 0644))
+
+	cfg := &config.Config{
+		AcceptEnvvarUnprivileged:     true,
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{specDir}
+	cfg.NVIDIAContainerRuntimeConfig.DeviceState.StateFile = filepath.Join(t.TempDir(), "device-state.json")
+
+	testCases := []struct {
+		description   string
+		imageLabels   bool
+		spec          *specs.Spec
+		expectedEdits *Edits
+	}{
+		{
+			description: "no devices requested",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"PATH=/usr/bin"}},
+			},
+		},
+		{
+			description: "requested device is injected",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=gpu0"}},
+			},
+			expectedEdits: &Edits{
+				Mounts: []specs.Mount{
+					{Source: "/usr/lib/libcuda.so.1", Destination: "/usr/lib/libcuda.so.1", Options: []string{"ro", "nosuid", "nodev", "bind"}},
+				},
+				Hooks: []specs.Hook{
+					{Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				},
+				LateHooks: []specs.Hook{
+					{Path: "/usr/bin/late-hook"},
+				},
+				Env: []string{"DEVICE=gpu0"},
+			},
+		},
+		{
+			description: "device requested using image labels is injected",
+			imageLabels: true,
+			spec: &specs.Spec{
+				Annotations: map[string]string{"com.nvidia.devices": "nvidia.com/gpu=gpu0"},
+				Process:     &specs.Process{Env: []string{"PATH=/usr/bin"}},
+			},
+			expectedEdits: &Edits{
+				Mounts: []specs.Mount{
+					{Source: "/usr/lib/libcuda.so.1", Destination: "/usr/lib/libcuda.so.1", Options: []string{"ro", "nosuid", "nodev", "bind"}},
+				},
+				Hooks: []specs.Hook{
+					{Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				},
+				LateHooks: []specs.Hook{
+					{Path: "/usr/bin/late-hook"},
+				},
+				Env: []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=gpu0", "DEVICE=gpu0"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := *cfg
+			cfg.NVIDIAContainerRuntimeConfig.ImageLabels.Enabled = tc.imageLabels
+
+			edits, err := GetEdits(logger, &cfg, tc.spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEdits, edits)
+		})
+	}
+}
+
+func TestGetEditsIgnoresExistingEdits(t *testing.T) {
+	major, minor := int64(195), int64(0)
+	device := specs.LinuxDevice{Path: "/dev/nvidia0", Type: "c", Major: major, Minor: minor}
+	rule := specs.LinuxDeviceCgroup{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "rwm"}
+	mount := specs.Mount{Source: "/usr/bin/nvidia-smi", Destination: "/usr/bin/nvidia-smi"}
+
+	original := &specs.Spec{
+		Process: &specs.Process{Env: []string{"A=1"}},
+		Mounts:  []specs.Mount{mount},
+		Linux: &specs.Linux{
+			Devices:   []specs.LinuxDevice{device},
+			Resources: &specs.LinuxResources{Devices: []specs.LinuxDeviceCgroup{rule}},
+		},
+	}
+	modified := &specs.Spec{
+		Process: &specs.Process{Env: []string{"A=1", "B=2"}},
+		Mounts:  []specs.Mount{mount},
+		Linux: &specs.Linux{
+			Devices:   []specs.LinuxDevice{device, {Path: "/dev/nvidiactl", Type: "c", Major: 195, Minor: 255}},
+			Resources: &specs.LinuxResources{Devices: []specs.LinuxDeviceCgroup{rule}},
+		},
+	}
+
+	edits := getEdits(original, modified)
+	require.Equal(t, &Edits{
+		Devices: []specs.LinuxDevice{{Path: "/dev/nvidiactl", Type: "c", Major: 195, Minor: 255}},
+		Env:     []string{"B=2"},
+	}, edits)
+	require.True(t, getEdits(original, original).IsEmpty())
+}
+
+func TestGetEditsRejectsInsecureSpec(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	specFile := filepath.Join(specDir, "nvidia.yaml")
+	require.NoError(t, os.WriteFile(specFile, []byte("cdiVersion: 0.5.0\nkind: nvidia.com/gpu\ndevices:\n- name: gpu0\n"), 0644))
+	require.NoError(t, os.Chmod(specFile, 0666))
+
+	cfg := &config.Config{
+		AcceptEnvvarUnprivileged:     true,
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{specDir}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirPermissions.Enforce = true
+
+	spec := &specs.Spec{
+		Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=gpu0"}},
+	}
+	_, err := GetEdits(logger, cfg, spec)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "nvidia.yaml")
+}
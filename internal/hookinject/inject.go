@@ -0,0 +1,454 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hookinject
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	defaultProcRoot   = "/proc"
+	defaultCgroupRoot = "/sys/fs/cgroup"
+
+	// maxSymlinks is the maximum number of symlinks that are followed when resolving a path in the
+	// container root.
+	maxSymlinks = 255
+)
+
+// Injector applies edits to a container from an OCI createRuntime hook. Such a hook is run in the
+// namespaces of the runtime once the namespaces of the container have been created, but before the
+// container root is changed. The device nodes and mounts are created in the mount namespace of the
+// container process so that these are visible in the container. Since the container root has not
+// been changed yet, the container root is accessible at the same path as in the namespace of the
+// runtime.
+type Injector struct {
+	logger     *logrus.Logger
+	state      *oci.State
+	root       string
+	pid        int
+	procRoot   string
+	cgroupRoot string
+	// inContainer runs the specified function in the mount namespace of the container.
+	inContainer func(func() error) error
+}
+
+// NewInjector creates an injector for the container with the specified state.
+func NewInjector(logger *logrus.Logger, state *oci.State) (*Injector, error) {
+	root, err := state.GetContainerRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine container root: %v", err)
+	}
+	if root == "" {
+		return nil, fmt.Errorf("empty container root detected")
+	}
+	if state.Pid <= 0 {
+		return nil, fmt.Errorf("container process not found in state")
+	}
+
+	i := Injector{
+		logger:     logger,
+		state:      state,
+		root:       root,
+		pid:        state.Pid,
+		procRoot:   defaultProcRoot,
+		cgroupRoot: defaultCgroupRoot,
+	}
+	i.inContainer = i.inMountNamespace
+	return &i, nil
+}
+
+// Apply applies the specified edits to the container. The device cgroup of the container is updated
+// before device nodes are created, mounts are made, and the hooks are run in the mount namespace of the
+// container. Since the process of the container has already been configured, environment variables
+// cannot be set from a hook and an error is returned if these are required. Hooks for later stages
+// cannot be run from a hook and are logged.
+func (i *Injector) Apply(e *Edits) error {
+	if e.IsEmpty() {
+		i.logger.Debugf("No edits required")
+		return nil
+	}
+
+	if len(e.Env) > 0 {
+		var names []string
+		for _, env := range e.Env {
+			names = append(names, strings.SplitN(env, "=", 2)[0])
+		}
+		return fmt.Errorf("environment variables %v cannot be set from a hook; use the NVIDIA Container Runtime to inject the requested devices", names)
+	}
+	for _, h := range e.LateHooks {
+		i.logger.Warningf("Ignoring hook %v that cannot be run from a createRuntime hook", h.Path)
+	}
+
+	if err := i.allowDevices(e.DeviceRules); err != nil {
+		return fmt.Errorf("failed to update device cgroup: %v", err)
+	}
+	return i.inContainer(func() error {
+		for _, d := range e.Devices {
+			if err := i.createDevice(d); err != nil {
+				return fmt.Errorf("failed to create device node %v: %v", d.Path, err)
+			}
+		}
+		for _, m := range e.Mounts {
+			if err := i.mount(m); err != nil {
+				return fmt.Errorf("failed to mount %v: %v", m.Destination, err)
+			}
+		}
+		for _, h := range e.Hooks {
+			if err := i.runHook(h); err != nil {
+				return fmt.Errorf("failed to run hook %v: %v", h.Path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// inMountNamespace runs the specified function on an OS thread that has joined the mount namespace of
+// the container process. Processes started by the function (e.g. hooks) are also run in this namespace.
+func (i *Injector) inMountNamespace(f func() error) error {
+	errs := make(chan error, 1)
+	go func() {
+		// The thread is not unlocked so that it is terminated once the goroutine exits instead of being
+		// reused by other goroutines in the mount namespace of the container.
+		runtime.LockOSThread()
+		if err := i.joinMountNamespace(); err != nil {
+			errs <- fmt.Errorf("failed to join mount namespace of container: %v", err)
+			return
+		}
+		errs <- f()
+	}()
+	return <-errs
+}
+
+// joinMountNamespace joins the mount namespace of the container process with the current thread. The
+// filesystem attributes of the thread are unshared first since a thread that shares these with other
+// threads cannot join a mount namespace.
+func (i *Injector) joinMountNamespace() error {
+	ns, err := os.Open(filepath.Join(i.procRoot, strconv.Itoa(i.pid), "ns", "mnt"))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+
+	if err := unix.Unshare(unix.CLONE_FS); err != nil {
+		return fmt.Errorf("failed to unshare filesystem attributes: %v", err)
+	}
+	return unix.Setns(int(ns.Fd()), unix.CLONE_NEWNS)
+}
+
+// createDevice creates the specified device node in the container root. If the device node cannot be
+// created (e.g. in a user namespace), the device node on the host is bind mounted instead.
+func (i *Injector) createDevice(d specs.LinuxDevice) error {
+	path, err := i.resolve(d.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		i.logger.Debugf("Device node %v already exists", d.Path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var deviceType uint32
+	switch d.Type {
+	case "c", "u":
+		deviceType = unix.S_IFCHR
+	case "b":
+		deviceType = unix.S_IFBLK
+	case "p":
+		deviceType = unix.S_IFIFO
+	default:
+		return fmt.Errorf("unsupported device type %q", d.Type)
+	}
+	mode := os.FileMode(0666)
+	if d.FileMode != nil {
+		mode = *d.FileMode
+	}
+
+	i.logger.Debugf("Creating device node %v", d.Path)
+	err = unix.Mknod(path, deviceType|uint32(mode.Perm()), int(unix.Mkdev(uint32(d.Major), uint32(d.Minor))))
+	if errors.Is(err, unix.EPERM) {
+		i.logger.Debugf("Bind mounting device node %v: %v", d.Path, err)
+		return i.bindMount(d.Path, path, unix.MS_NOEXEC|unix.MS_NOSUID)
+	}
+	if err != nil {
+		return err
+	}
+	// Mknod is subject to the umask.
+	if err := os.Chmod(path, mode.Perm()); err != nil {
+		return err
+	}
+	if d.UID != nil || d.GID != nil {
+		uid, gid := -1, -1
+		if d.UID != nil {
+			uid = int(*d.UID)
+		}
+		if d.GID != nil {
+			gid = int(*d.GID)
+		}
+		return os.Lchown(path, uid, gid)
+	}
+	return nil
+}
+
+// mount makes the specified mount in the container root.
+func (i *Injector) mount(m specs.Mount) error {
+	target, err := i.resolve(m.Destination)
+	if err != nil {
+		return err
+	}
+	flags, bind, data := parseMountOptions(m.Options)
+	if m.Type == "bind" {
+		bind = true
+	}
+
+	i.logger.Debugf("Mounting %v at %v", m.Source, m.Destination)
+	if !bind {
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+		return unix.Mount(m.Source, target, m.Type, flags, data)
+	}
+	return i.bindMount(m.Source, target, flags)
+}
+
+// bindMount bind mounts the specified source at the specified target, creating the target if required.
+// The bind mount is remounted with the specified flags.
+func (i *Injector) bindMount(source string, target string, flags uintptr) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if err := createMountTarget(target, info.IsDir()); err != nil {
+		return err
+	}
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return err
+	}
+	if flags == 0 {
+		return nil
+	}
+	return unix.Mount("", target, "", unix.MS_BIND|unix.MS_REMOUNT|flags, "")
+}
+
+// createMountTarget creates the directory or (empty) file at which a mount is made.
+func createMountTarget(target string, isDir bool) error {
+	if isDir {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// parseMountOptions returns the mount flags for the specified mount options, whether a bind mount is
+// requested, and the remaining options that are passed to the filesystem.
+func parseMountOptions(options []string) (uintptr, bool, string) {
+	var flags uintptr
+	var bind bool
+	var data []string
+	for _, o := range options {
+		switch o {
+		case "bind", "rbind":
+			bind = true
+		case "ro":
+			flags |= unix.MS_RDONLY
+		case "nosuid":
+			flags |= unix.MS_NOSUID
+		case "nodev":
+			flags |= unix.MS_NODEV
+		case "noexec":
+			flags |= unix.MS_NOEXEC
+		case "rw", "suid", "dev", "exec", "private", "rprivate", "slave", "rslave":
+		default:
+			data = append(data, o)
+		}
+	}
+	return flags, bind, strings.Join(data, ",")
+}
+
+// resolve returns the path in the container root for the specified path in the container. Symlinks are
+// resolved relative to the container root so that the returned path does not escape the root.
+func (i *Injector) resolve(path string) (string, error) {
+	return resolveInRoot(i.root, path)
+}
+
+func resolveInRoot(root string, path string) (string, error) {
+	resolved := "/"
+	remaining := strings.Split(filepath.Clean("/"+path), "/")
+	for links := 0; len(remaining) > 0; {
+		component := remaining[0]
+		remaining = remaining[1:]
+		if component == "" || component == "." {
+			continue
+		}
+		if component == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, component)
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			// The path is not a symlink or does not exist.
+			resolved = next
+			continue
+		}
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many symlinks in %v", path)
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		remaining = append(strings.Split(target, "/"), remaining...)
+	}
+	return filepath.Join(root, resolved), nil
+}
+
+// allowDevices adds the specified rules to the device cgroup of the container. This is only supported
+// for cgroup v1 since device access is controlled by an eBPF program that is attached by the runtime
+// for cgroup v2.
+func (i *Injector) allowDevices(rules []specs.LinuxDeviceCgroup) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(i.cgroupRoot, "cgroup.controllers")); err == nil {
+		i.logger.Warningf("Cannot update the device cgroup of the container for cgroup v2; access to the device nodes must be allowed by the container engine")
+		return nil
+	}
+
+	cgroup, err := getDevicesCgroup(filepath.Join(i.procRoot, strconv.Itoa(i.pid), "cgroup"))
+	if err != nil {
+		return err
+	}
+	if cgroup == "/" {
+		i.logger.Warningf("Cannot determine the device cgroup of the container; access to the device nodes must be allowed by the container engine")
+		return nil
+	}
+
+	allowFile := filepath.Join(i.cgroupRoot, "devices", cgroup, "devices.allow")
+	for _, r := range rules {
+		if !r.Allow {
+			continue
+		}
+		rule := formatDeviceRule(r)
+		i.logger.Debugf("Allowing device access %q", rule)
+		if err := os.WriteFile(allowFile, []byte(rule), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDevicesCgroup returns the path of the devices cgroup in the specified cgroup file.
+func getDevicesCgroup(cgroupFile string) (string, error) {
+	f, err := os.Open(cgroupFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			if controller == "devices" {
+				return parts[2], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("devices cgroup not found in %v", cgroupFile)
+}
+
+// formatDeviceRule returns the specified rule in the format of the devices.allow file.
+func formatDeviceRule(r specs.LinuxDeviceCgroup) string {
+	deviceType := r.Type
+	if deviceType == "" {
+		deviceType = "a"
+	}
+	major, minor := "*", "*"
+	if r.Major != nil {
+		major = fmt.Sprintf("%d", *r.Major)
+	}
+	if r.Minor != nil {
+		minor = fmt.Sprintf("%d", *r.Minor)
+	}
+	access := r.Access
+	if access == "" {
+		access = "rwm"
+	}
+	return fmt.Sprintf("%s %s:%s %s", deviceType, major, minor, access)
+}
+
+// runHook runs the specified hook with the state of the container on STDIN.
+func (i *Injector) runHook(h specs.Hook) error {
+	state, err := json.Marshal(i.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal container state: %v", err)
+	}
+
+	ctx := context.Background()
+	if h.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*h.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, h.Path)
+	if len(h.Args) > 0 {
+		cmd.Args = h.Args
+	}
+	cmd.Env = h.Env
+	cmd.Stdin = bytes.NewReader(state)
+
+	i.logger.Debugf("Running hook %v", h.Args)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
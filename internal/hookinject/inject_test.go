@@ -0,0 +1,217 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hookinject
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestResolveInRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "usr", "lib64"), 0755))
+	require.NoError(t, os.Symlink("usr/lib64", filepath.Join(root, "lib64")))
+	require.NoError(t, os.Symlink("/etc", filepath.Join(root, "usr", "escape")))
+	require.NoError(t, os.Symlink("../../..", filepath.Join(root, "usr", "lib64", "up")))
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{
+			path:     "/dev/nvidia0",
+			expected: "/dev/nvidia0",
+		},
+		{
+			path:     "/lib64/libcuda.so.1",
+			expected: "/usr/lib64/libcuda.so.1",
+		},
+		{
+			path:     "/usr/escape/passwd",
+			expected: "/etc/passwd",
+		},
+		{
+			path:     "/usr/lib64/up/etc",
+			expected: "/etc",
+		},
+		{
+			path:     "/../../etc",
+			expected: "/etc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			resolved, err := resolveInRoot(root, tc.path)
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(root, tc.expected), resolved)
+		})
+	}
+}
+
+func TestParseMountOptions(t *testing.T) {
+	flags, bind, data := parseMountOptions([]string{"ro", "nosuid", "nodev", "rbind", "rprivate", "size=64k"})
+	require.Equal(t, uintptr(unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV), flags)
+	require.True(t, bind)
+	require.Equal(t, "size=64k", data)
+}
+
+func TestAllowDevices(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	major, minor := int64(195), int64(0)
+	rules := []specs.LinuxDeviceCgroup{
+		{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "rwm"},
+		{Allow: true, Type: "c", Major: &major},
+		{Allow: false, Type: "c", Major: &major, Minor: &minor, Access: "rwm"},
+	}
+
+	procRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "1234"), 0755))
+	cgroups := "12:memory:/kubepods/pod1/ctr\n5:devices:/kubepods/pod1/ctr\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "1234", "cgroup"), []byte(cgroups), 0644))
+
+	cgroupRoot := t.TempDir()
+	cgroupDir := filepath.Join(cgroupRoot, "devices", "kubepods", "pod1", "ctr")
+	require.NoError(t, os.MkdirAll(cgroupDir, 0755))
+	// The devices.allow file of the cgroupfs accepts one rule per write. Since a regular file is used
+	// here, only the last rule that was written is checked.
+	allowFile := filepath.Join(cgroupDir, "devices.allow")
+	require.NoError(t, os.WriteFile(allowFile, nil, 0644))
+
+	i := Injector{
+		logger:     logger,
+		pid:        1234,
+		procRoot:   procRoot,
+		cgroupRoot: cgroupRoot,
+	}
+	require.NoError(t, i.allowDevices(rules))
+
+	contents, err := os.ReadFile(allowFile)
+	require.NoError(t, err)
+	require.Equal(t, "c 195:* rwm", string(contents))
+
+	require.Equal(t, "c 195:0 rwm", formatDeviceRule(rules[0]))
+}
+
+func TestAllowDevicesCgroupV2(t *testing.T) {
+	logger, hook := testlog.NewNullLogger()
+
+	cgroupRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupRoot, "cgroup.controllers"), []byte("cpu memory"), 0644))
+
+	i := Injector{
+		logger:     logger,
+		procRoot:   t.TempDir(),
+		cgroupRoot: cgroupRoot,
+	}
+	require.NoError(t, i.allowDevices([]specs.LinuxDeviceCgroup{{Allow: true, Type: "c", Access: "rwm"}}))
+	require.Len(t, hook.AllEntries(), 1)
+}
+
+func TestRunHook(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$1 $HOOK_ENV\" > \""+output+"\"\ncat >> \""+output+"\"\n"), 0755))
+
+	i := Injector{
+		logger: logger,
+		state:  &oci.State{ID: "container", Bundle: "/bundle"},
+	}
+
+	err := i.runHook(specs.Hook{
+		Path: script,
+		Args: []string{"hook-name", "arg"},
+		Env:  []string{"HOOK_ENV=value"},
+	})
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "arg value\n")
+	require.Contains(t, string(contents), `"id":"container"`)
+	require.Contains(t, string(contents), `"bundle":"/bundle"`)
+
+	err = i.runHook(specs.Hook{Path: filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
+
+func TestApplyEnv(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	var applied bool
+	i := Injector{
+		logger: logger,
+		inContainer: func(f func() error) error {
+			applied = true
+			return f()
+		},
+	}
+
+	err := i.Apply(&Edits{Env: []string{"NVIDIA_VISIBLE_DEVICES=void", "DEVICE_ENV=value"}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "[NVIDIA_VISIBLE_DEVICES DEVICE_ENV]")
+	require.False(t, applied)
+}
+
+func TestApplyInContainer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ntouch \""+output+"\"\n"), 0755))
+
+	var applied bool
+	i := Injector{
+		logger: logger,
+		state:  &oci.State{ID: "container"},
+		inContainer: func(f func() error) error {
+			applied = true
+			return f()
+		},
+	}
+
+	require.NoError(t, i.Apply(&Edits{Hooks: []specs.Hook{{Path: script}}}))
+	require.True(t, applied)
+	require.FileExists(t, output)
+}
+
+func TestNewInjectorRequiresPid(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	bundle := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bundle, "config.json"), []byte(`{"root": {"path": "rootfs"}}`), 0644))
+
+	_, err := NewInjector(logger, &oci.State{Bundle: bundle})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "container process not found")
+
+	i, err := NewInjector(logger, &oci.State{Bundle: bundle, Pid: 1234})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(bundle, "rootfs"), i.root)
+}
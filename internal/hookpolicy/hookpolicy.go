@@ -0,0 +1,190 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package hookpolicy supports enforcing timeouts and failure policies for the hooks of the NVIDIA Container Toolkit.
+package hookpolicy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+)
+
+// FailurePolicy defines how the failure of a hook is handled.
+type FailurePolicy string
+
+const (
+	// FailClosed causes container creation to fail if a hook fails or times out.
+	FailClosed FailurePolicy = "fail-closed"
+	// FailOpen ignores hook failures and timeouts so that the container is still created.
+	FailOpen FailurePolicy = "fail-open"
+)
+
+const (
+	// TimeoutFlag is the flag of the nvidia-ctk hook command used to specify the timeout.
+	TimeoutFlag = "timeout"
+	// FailurePolicyFlag is the flag of the nvidia-ctk hook command used to specify the failure policy.
+	FailurePolicyFlag = "failure-policy"
+
+	// TimeoutExitCode is the exit code of a hook that did not complete within its timeout.
+	TimeoutExitCode = 124
+
+	// supervisedEnvvar is set for a hook that is run under the supervision of a policy.
+	supervisedEnvvar = "NVIDIA_CTK_HOOK_SUPERVISED"
+)
+
+// Policy defines the timeout and failure policy with which a hook is run.
+type Policy struct {
+	Timeout       time.Duration
+	FailurePolicy FailurePolicy
+}
+
+// TimeoutError is returned if a hook does not complete within its timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error returns the message for the timeout.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("hook timed out after %v", e.Timeout)
+}
+
+// New creates the policy for the specified timeout and failure policy. A timeout of 0 means that
+// no timeout is applied and the failure policy defaults to fail-closed. If neither a timeout nor a
+// fail-open policy is specified, nil is returned and hooks are run without supervision.
+func New(timeout time.Duration, failurePolicy string) (*Policy, error) {
+	if timeout < 0 {
+		return nil, fmt.Errorf("invalid timeout %v", timeout)
+	}
+
+	p := Policy{
+		Timeout:       timeout,
+		FailurePolicy: FailClosed,
+	}
+	switch FailurePolicy(failurePolicy) {
+	case "", FailClosed:
+	case FailOpen:
+		p.FailurePolicy = FailOpen
+	default:
+		return nil, fmt.Errorf("invalid failure policy %q", failurePolicy)
+	}
+
+	if p.Timeout == 0 && p.FailurePolicy == FailClosed {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// Parse creates the policy for the specified timeout (e.g. 30s) and failure policy.
+func Parse(timeout string, failurePolicy string) (*Policy, error) {
+	var d time.Duration
+	if timeout != "" {
+		var err error
+		d, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %v", timeout, err)
+		}
+	}
+	return New(d, failurePolicy)
+}
+
+// Args returns the flags of the nvidia-ctk hook command that select this policy.
+func (p *Policy) Args() []string {
+	var args []string
+	if p.Timeout > 0 {
+		args = append(args, fmt.Sprintf("--%v=%v", TimeoutFlag, p.Timeout))
+	}
+	args = append(args, fmt.Sprintf("--%v=%v", FailurePolicyFlag, p.FailurePolicy))
+	return args
+}
+
+// HookArgs returns the arguments of the specified hook with the flags selecting this policy
+// inserted. Hooks that do not invoke the nvidia-ctk hook command or that already specify a
+// timeout or failure policy are returned unchanged.
+func (p *Policy) HookArgs(path string, args []string) []string {
+	if p == nil || !privileges.IsNVIDIACTKHook(path, args) {
+		return args
+	}
+	for _, arg := range args[2:] {
+		for _, flag := range []string{TimeoutFlag, FailurePolicyFlag} {
+			if arg == "--"+flag || strings.HasPrefix(arg, "--"+flag+"=") {
+				return args
+			}
+		}
+	}
+
+	updated := append([]string{}, args[:2]...)
+	updated = append(updated, p.Args()...)
+	return append(updated, args[2:]...)
+}
+
+// Supervised checks whether the current process is already run under the supervision of a policy.
+func Supervised() bool {
+	return os.Getenv(supervisedEnvvar) != ""
+}
+
+// Command returns a command that re-executes the specified executable under the supervision of
+// this policy. The command is started in its own process group so that any processes it starts
+// (e.g. ldconfig) are also stopped if the timeout is exceeded.
+func (p *Policy) Command(executable string, args []string) *exec.Cmd {
+	cmd := exec.Command(executable, args...)
+	cmd.Env = append(os.Environ(), supervisedEnvvar+"=true")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	return cmd
+}
+
+// Run runs the specified command. If a timeout is set and the command does not complete within
+// this timeout, its process group is killed and a *TimeoutError is returned.
+func (p *Policy) Run(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	if p.Timeout == 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(p.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		pid := cmd.Process.Pid
+		if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+			pid = -pid
+		}
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		<-done
+		return &TimeoutError{Timeout: p.Timeout}
+	}
+}
@@ -0,0 +1,135 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hookpolicy
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		description   string
+		timeout       string
+		failurePolicy string
+		expected      *Policy
+		expectedError bool
+	}{
+		{
+			description: "empty is nil",
+		},
+		{
+			description:   "fail-closed without timeout is nil",
+			failurePolicy: "fail-closed",
+		},
+		{
+			description: "timeout defaults to fail-closed",
+			timeout:     "30s",
+			expected:    &Policy{Timeout: 30 * time.Second, FailurePolicy: FailClosed},
+		},
+		{
+			description:   "fail-open without timeout",
+			failurePolicy: "fail-open",
+			expected:      &Policy{FailurePolicy: FailOpen},
+		},
+		{
+			description:   "invalid timeout",
+			timeout:       "thirty",
+			expectedError: true,
+		},
+		{
+			description:   "negative timeout",
+			timeout:       "-1s",
+			expectedError: true,
+		},
+		{
+			description:   "invalid failure policy",
+			failurePolicy: "ignore",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			p, err := Parse(tc.timeout, tc.failurePolicy)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expected, p)
+		})
+	}
+}
+
+func TestHookArgs(t *testing.T) {
+	p := &Policy{Timeout: 30 * time.Second, FailurePolicy: FailOpen}
+
+	testCases := []struct {
+		description string
+		path        string
+		args        []string
+		expected    []string
+	}{
+		{
+			description: "nvidia-ctk hook is updated",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+			expected:    []string{"nvidia-ctk", "hook", "--timeout=30s", "--failure-policy=fail-open", "update-ldcache", "--folder", "/usr/lib64"},
+		},
+		{
+			description: "existing timeout is not overridden",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "--timeout=5s", "chmod"},
+			expected:    []string{"nvidia-ctk", "hook", "--timeout=5s", "chmod"},
+		},
+		{
+			description: "other hooks are unchanged",
+			path:        "/usr/bin/nvidia-container-runtime-hook",
+			args:        []string{"nvidia-container-runtime-hook", "prestart"},
+			expected:    []string{"nvidia-container-runtime-hook", "prestart"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.EqualValues(t, tc.expected, p.HookArgs(tc.path, tc.args))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	p := &Policy{Timeout: 100 * time.Millisecond, FailurePolicy: FailClosed}
+
+	err := p.Run(p.Command("/bin/sh", []string{"-c", "exit 0"}))
+	require.NoError(t, err)
+
+	err = p.Run(p.Command("/bin/sh", []string{"-c", "exit 3"}))
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, 3, exitErr.ExitCode())
+
+	start := time.Now()
+	err = p.Run(p.Command("/bin/sh", []string{"-c", "sleep 10 & wait"}))
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,48 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"strings"
+
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// GetBusID returns the PCI bus ID of a device from its NVML PCI info in the form used in sysfs and
+// by the NVIDIA kernel module (e.g. 0000:01:00.0).
+func GetBusID(p nvml.PciInfo) string {
+	var bytes []byte
+	for _, b := range p.BusId {
+		if byte(b) == '\x00' {
+			break
+		}
+		bytes = append(bytes, byte(b))
+	}
+	return NormalizeBusID(string(bytes))
+}
+
+// NormalizeBusID returns the specified PCI bus ID in the form used in sysfs and by the NVIDIA kernel
+// module. The bus ID is converted to lowercase and the eight-digit domain reported by NVML (e.g.
+// 00000000:01:00.0) is shortened to four digits.
+func NormalizeBusID(busID string) string {
+	id := strings.ToLower(strings.TrimSpace(busID))
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) == 2 && len(parts[0]) == 8 {
+		return parts[0][4:] + ":" + parts[1]
+	}
+	return id
+}
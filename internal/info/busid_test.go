@@ -0,0 +1,64 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+func TestGetBusID(t *testing.T) {
+	testCases := []struct {
+		description string
+		busID       string
+		expected    string
+	}{
+		{
+			description: "eight-digit domain",
+			busID:       "00000000:3B:00.0",
+			expected:    "0000:3b:00.0",
+		},
+		{
+			description: "non-zero domain",
+			busID:       "00000009:01:00.0",
+			expected:    "0009:01:00.0",
+		},
+		{
+			description: "whitespace",
+			busID:       " 0000:01:00.0\n",
+			expected:    "0000:01:00.0",
+		},
+		{
+			description: "four-digit domain",
+			busID:       "0000:01:00.0",
+			expected:    "0000:01:00.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var p nvml.PciInfo
+			for i, c := range tc.busID {
+				p.BusId[i] = int8(c)
+			}
+			require.Equal(t, tc.expected, GetBusID(p))
+			require.Equal(t, tc.expected, NormalizeBusID(tc.busID))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Component is an installed component of the NVIDIA Container Toolkit.
+type Component struct {
+	Name    string
+	Path    string
+	Version string
+}
+
+// versionQuery defines how the version of a component is queried.
+type versionQuery struct {
+	args []string
+	// prefix is the text in the output that directly precedes the version.
+	prefix string
+}
+
+var versionQueries = map[string]versionQuery{
+	"nvidia-container-runtime": {
+		args:   []string{"--version"},
+		prefix: "NVIDIA Container Runtime version ",
+	},
+	"nvidia-container-runtime-hook": {
+		args:   []string{"-version"},
+		prefix: "NVIDIA Container Runtime Hook version ",
+	},
+	"nvidia-ctk": {
+		args:   []string{"--version"},
+		prefix: "NVIDIA Container Toolkit CLI version ",
+	},
+	"nvidia-container-cli": {
+		args:   []string{"--version"},
+		prefix: "lib-version: ",
+	},
+}
+
+// Components returns the names of the components for which versions can be queried.
+func Components() []string {
+	return []string{
+		"nvidia-container-runtime",
+		"nvidia-container-runtime-hook",
+		"nvidia-ctk",
+		"nvidia-container-cli",
+	}
+}
+
+// GetComponentVersion runs the executable at the specified path to determine the version of
+// the named component. For nvidia-container-cli the version of libnvidia-container is returned.
+func GetComponentVersion(name string, path string) (string, error) {
+	q, ok := versionQueries[name]
+	if !ok {
+		return "", fmt.Errorf("unsupported component %v", name)
+	}
+
+	output, err := exec.Command(path, q.args...).CombinedOutput()
+	v := parseVersion(string(output), q.prefix)
+	if v != "" {
+		return v, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run %v: %v", path, err)
+	}
+	return "", fmt.Errorf("no version found in output of %v", path)
+}
+
+// parseVersion returns the first field following the specified prefix in the output.
+func parseVersion(output string, prefix string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, prefix))
+		if len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// VersionsMatch checks whether two component versions refer to the same release.
+// A leading 'v' and build metadata (following a '+') are ignored.
+func VersionsMatch(a string, b string) bool {
+	return normalizeVersion(a) == normalizeVersion(b)
+}
+
+func normalizeVersion(v string) string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		description string
+		name        string
+		output      string
+		expected    string
+	}{
+		{
+			description: "runtime",
+			name:        "nvidia-container-runtime",
+			output:      "NVIDIA Container Runtime version 1.13.0-rc.2\ncommit: abcdef\nspec: 1.0.2\n\nrunc version 1.1.4\n",
+			expected:    "1.13.0-rc.2",
+		},
+		{
+			description: "hook",
+			name:        "nvidia-container-runtime-hook",
+			output:      "NVIDIA Container Runtime Hook version 1.12.1\ncommit: abcdef\n",
+			expected:    "1.12.1",
+		},
+		{
+			description: "libnvidia-container",
+			name:        "nvidia-container-cli",
+			output:      "cli-version: 1.13.0\nlib-version: 1.13.0\nbuild date: 2023-03-31T13:12+00:00\n",
+			expected:    "1.13.0",
+		},
+		{
+			description: "no version",
+			name:        "nvidia-ctk",
+			output:      "unknown flag --version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			v := parseVersion(tc.output, versionQueries[tc.name].prefix)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestVersionsMatch(t *testing.T) {
+	require.True(t, VersionsMatch("1.13.0", "v1.13.0"))
+	require.True(t, VersionsMatch("1.13.0-rc.2", "1.13.0-rc.2+jetpack"))
+	require.False(t, VersionsMatch("1.13.0-rc.2", "1.13.0"))
+	require.False(t, VersionsMatch("1.12.1", "1.13.0"))
+}
@@ -0,0 +1,159 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package numa supports the discovery of the NUMA topology of GPUs, including the coherent GPU memory
+// of systems where the GPU is connected to the CPU over NVLink-C2C (e.g. GH200 Grace Hopper).
+package numa
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// StatusOnline indicates that the coherent GPU memory has been onlined as a NUMA node.
+	StatusOnline = "online"
+
+	// graceSocID is the SoC ID reported by NVIDIA Grace CPUs (JEP106 vendor 0x036b, device 0x0241).
+	graceSocID = "jep106:036b:0241"
+)
+
+// GPU describes the NUMA topology of a GPU.
+type GPU struct {
+	BusID string
+	// CPUNode is the NUMA node of the CPUs closest to the GPU, or -1 if this is not known.
+	CPUNode int
+	// MemoryNode is the NUMA node of the coherent GPU memory, or -1 if the GPU memory is not
+	// coherent with the CPU.
+	MemoryNode int
+	// MemoryStatus is the status of the coherent GPU memory as reported by the driver (e.g. online).
+	MemoryStatus string
+}
+
+// IsCoherent checks whether the memory of the GPU is exposed as a NUMA node.
+func (g GPU) IsCoherent() bool {
+	return g.MemoryNode >= 0
+}
+
+// Env returns the environment variables that provide the NUMA node hints for the GPU with the
+// specified minor number to applications in a container.
+func (g GPU) Env(minor int) []string {
+	var env []string
+	if g.CPUNode >= 0 {
+		env = append(env, fmt.Sprintf("NVIDIA_GPU%d_CPU_NUMA_NODE=%d", minor, g.CPUNode))
+	}
+	if g.IsCoherent() && g.MemoryStatus == StatusOnline {
+		env = append(env, fmt.Sprintf("NVIDIA_GPU%d_MEMORY_NUMA_NODE=%d", minor, g.MemoryNode))
+	}
+	return env
+}
+
+// GetGPU returns the NUMA topology of the GPU with the specified PCI bus ID. The sysfs and procfs
+// entries are read relative to the specified root.
+func GetGPU(root string, busID string) (GPU, error) {
+	gpu := GPU{
+		BusID:      busID,
+		CPUNode:    -1,
+		MemoryNode: -1,
+	}
+
+	node, err := readNode(filepath.Join(root, "/sys/bus/pci/devices", busID, "numa_node"))
+	if err != nil && !os.IsNotExist(err) {
+		return gpu, fmt.Errorf("failed to read NUMA node for %v: %v", busID, err)
+	}
+	if err == nil {
+		gpu.CPUNode = node
+	}
+
+	// The driver only creates the numa_status file for GPUs with coherent memory.
+	node, status, err := readStatus(filepath.Join(root, "/proc/driver/nvidia/gpus", busID, "numa_status"))
+	if err != nil && !os.IsNotExist(err) {
+		return gpu, fmt.Errorf("failed to read NUMA status for %v: %v", busID, err)
+	}
+	if err == nil {
+		gpu.MemoryNode = node
+		gpu.MemoryStatus = status
+	}
+
+	return gpu, nil
+}
+
+// IsGrace checks whether the system has an NVIDIA Grace CPU. The sysfs entries are read relative to
+// the specified root.
+func IsGrace(root string) bool {
+	contents, err := os.ReadFile(filepath.Join(root, "/sys/devices/soc0/soc_id"))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(contents)) == graceSocID
+}
+
+// readNode reads a NUMA node ID from the specified file. A negative ID indicates that the node is
+// not known.
+func readNode(path string) (int, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return -1, err
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return -1, fmt.Errorf("invalid NUMA node: %v", err)
+	}
+	if node < 0 {
+		return -1, nil
+	}
+	return node, nil
+}
+
+// readStatus reads the NUMA node and status of the coherent GPU memory from the specified numa_status file.
+func readStatus(path string) (int, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return -1, "", err
+	}
+	defer file.Close()
+
+	node := -1
+	var status string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "Node":
+			node, err = strconv.Atoi(value)
+			if err != nil {
+				return -1, "", fmt.Errorf("invalid NUMA node %q: %v", value, err)
+			}
+		case "Status":
+			status = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, "", err
+	}
+	if node < 0 {
+		return -1, "", nil
+	}
+	return node, status, nil
+}
@@ -0,0 +1,108 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package numa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGPU(t *testing.T) {
+	testCases := []struct {
+		description   string
+		files         map[string]string
+		expected      GPU
+		expectedEnv   []string
+		expectedError bool
+	}{
+		{
+			description: "no sysfs entries",
+			expected:    GPU{BusID: "0009:01:00.0", CPUNode: -1, MemoryNode: -1},
+		},
+		{
+			description: "pcie gpu",
+			files: map[string]string{
+				"sys/bus/pci/devices/0009:01:00.0/numa_node": "1\n",
+			},
+			expected:    GPU{BusID: "0009:01:00.0", CPUNode: 1, MemoryNode: -1},
+			expectedEnv: []string{"NVIDIA_GPU0_CPU_NUMA_NODE=1"},
+		},
+		{
+			description: "no numa affinity",
+			files: map[string]string{
+				"sys/bus/pci/devices/0009:01:00.0/numa_node": "-1\n",
+			},
+			expected: GPU{BusID: "0009:01:00.0", CPUNode: -1, MemoryNode: -1},
+		},
+		{
+			description: "coherent gpu memory",
+			files: map[string]string{
+				"sys/bus/pci/devices/0009:01:00.0/numa_node":       "0\n",
+				"proc/driver/nvidia/gpus/0009:01:00.0/numa_status": "Node: 1\nStatus: online\nMemory block size: 0x20000000\n",
+			},
+			expected:    GPU{BusID: "0009:01:00.0", CPUNode: 0, MemoryNode: 1, MemoryStatus: "online"},
+			expectedEnv: []string{"NVIDIA_GPU0_CPU_NUMA_NODE=0", "NVIDIA_GPU0_MEMORY_NUMA_NODE=1"},
+		},
+		{
+			description: "offline gpu memory",
+			files: map[string]string{
+				"sys/bus/pci/devices/0009:01:00.0/numa_node":       "0\n",
+				"proc/driver/nvidia/gpus/0009:01:00.0/numa_status": "Node: 1\nStatus: offline\n",
+			},
+			expected:    GPU{BusID: "0009:01:00.0", CPUNode: 0, MemoryNode: 1, MemoryStatus: "offline"},
+			expectedEnv: []string{"NVIDIA_GPU0_CPU_NUMA_NODE=0"},
+		},
+		{
+			description: "invalid numa node",
+			files: map[string]string{
+				"sys/bus/pci/devices/0009:01:00.0/numa_node": "invalid\n",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			for path, contents := range tc.files {
+				require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(contents), 0644))
+			}
+
+			gpu, err := GetGPU(root, "0009:01:00.0")
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expected, gpu)
+			require.EqualValues(t, tc.expectedEnv, gpu.Env(0))
+		})
+	}
+}
+
+func TestIsGrace(t *testing.T) {
+	root := t.TempDir()
+	require.False(t, IsGrace(root))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys/devices/soc0"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sys/devices/soc0/soc_id"), []byte("jep106:036b:0241\n"), 0644))
+	require.True(t, IsGrace(root))
+}
@@ -0,0 +1,43 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+// platformInfo defines the checks of the host platform used to resolve the auto mode.
+// Checking for the NVML library requires cgo. If the toolkit is built without cgo (e.g. when
+// cross-compiling for architectures without a cross-compiler), NVML is reported as unavailable.
+type platformInfo interface {
+	HasDXCore() (bool, string)
+	HasNvml() (bool, string)
+	IsTegraSystem() (bool, string)
+}
+
+// HasDXCore checks whether the DXCore library used on WSL2 systems can be loaded. The reason for
+// the result is also returned.
+func HasDXCore() (bool, string) {
+	return newPlatformInfo().HasDXCore()
+}
+
+// HasNvml checks whether the NVML library can be loaded. The reason for the result is also returned.
+func HasNvml() (bool, string) {
+	return newPlatformInfo().HasNvml()
+}
+
+// IsTegraSystem checks whether the host is a Tegra-based system. The reason for the result
+// is also returned.
+func IsTegraSystem() (bool, string) {
+	return newPlatformInfo().IsTegraSystem()
+}
@@ -0,0 +1,65 @@
+//go:build !cgo
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package info
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	tegraReleaseFile = "/etc/nv_tegra_release"
+	tegraFamilyFile  = "/sys/devices/soc0/family"
+)
+
+// nocgo implements the platform checks for builds without cgo. The Tegra checks match those
+// of go-nvlib, but NVML cannot be loaded.
+type nocgo struct{}
+
+// newPlatformInfo returns the platform checks for builds without cgo.
+func newPlatformInfo() platformInfo {
+	return nocgo{}
+}
+
+// HasDXCore returns false since DXCore cannot be loaded without cgo.
+func (nocgo) HasDXCore() (bool, string) {
+	return false, "DXCore cannot be loaded in builds without cgo"
+}
+
+// HasNvml returns false since NVML cannot be loaded without cgo.
+func (nocgo) HasNvml() (bool, string) {
+	return false, "NVML cannot be loaded in builds without cgo"
+}
+
+// IsTegraSystem returns true if the system is detected as a Tegra-based system.
+func (nocgo) IsTegraSystem() (bool, string) {
+	if info, err := os.Stat(tegraReleaseFile); err == nil && !info.IsDir() {
+		return true, fmt.Sprintf("%v found", tegraReleaseFile)
+	}
+
+	contents, err := os.ReadFile(tegraFamilyFile)
+	if err != nil {
+		return false, fmt.Sprintf("could not read %v", tegraFamilyFile)
+	}
+	if strings.HasPrefix(strings.ToLower(string(contents)), "tegra") {
+		return true, fmt.Sprintf("%v has 'tegra' prefix", tegraFamilyFile)
+	}
+	return false, fmt.Sprintf("%v has no 'tegra' prefix", tegraFamilyFile)
+}
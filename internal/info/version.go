@@ -16,7 +16,10 @@
 
 package info
 
-import "strings"
+import (
+	"runtime/debug"
+	"strings"
+)
 
 // version must be set by go build's -X main.version= option in the Makefile.
 var version = "unknown"
@@ -25,9 +28,22 @@ var version = "unknown"
 // and will be populated by the Makefile
 var gitCommit = ""
 
+// GetVersion returns the version of the NVIDIA Container Toolkit. If the version was not
+// set when building the binary, the version of the main module recorded in the build info
+// is used instead.
+func GetVersion() string {
+	if version != "unknown" {
+		return version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return strings.TrimPrefix(bi.Main.Version, "v")
+	}
+	return version
+}
+
 // GetVersionParts returns the different version components
 func GetVersionParts() []string {
-	v := []string{version}
+	v := []string{GetVersion()}
 
 	if gitCommit != "" {
 		v = append(v, "commit: "+gitCommit)
@@ -0,0 +1,175 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package kernel detects the properties of the running kernel that prevent the NVIDIA kernel modules
+// from being loaded, such as kernel lockdown, secure boot, and PREEMPT_RT.
+package kernel
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	lockdownPath      = "/sys/kernel/security/lockdown"
+	sigEnforcePath    = "/sys/module/module/parameters/sig_enforce"
+	realtimePath      = "/sys/kernel/realtime"
+	procVersionPath   = "/proc/version"
+	moduleSysfsPath   = "/sys/module"
+	efiSecureBootPath = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+	// NVIDIAModule is the name of the NVIDIA kernel module.
+	NVIDIAModule = "nvidia"
+)
+
+// Lockdown modes as reported by the kernel.
+const (
+	LockdownNone            = "none"
+	LockdownIntegrity       = "integrity"
+	LockdownConfidentiality = "confidentiality"
+)
+
+// Constraints describes the properties of the running kernel that restrict the loading of kernel modules.
+type Constraints struct {
+	// Lockdown is the active lockdown mode. This is empty if the kernel does not support lockdown.
+	Lockdown string
+	// SecureBoot indicates whether the system was booted with UEFI secure boot enabled.
+	SecureBoot bool
+	// SignaturesEnforced indicates whether the kernel only loads signed modules. This is the case if
+	// module.sig_enforce is set or a lockdown mode is active.
+	SignaturesEnforced bool
+	// PreemptRT indicates whether the kernel is a PREEMPT_RT (real-time) kernel.
+	PreemptRT bool
+}
+
+// Detect returns the constraints of the running kernel as reported by sysfs and procfs under the
+// specified root. Properties that cannot be read are assumed not to apply.
+func Detect(root string) *Constraints {
+	c := Constraints{
+		Lockdown:   getLockdown(root),
+		SecureBoot: isSecureBoot(root),
+		PreemptRT:  isPreemptRT(root),
+	}
+	c.SignaturesEnforced = readString(root, sigEnforcePath) == "Y" ||
+		c.Lockdown == LockdownIntegrity || c.Lockdown == LockdownConfidentiality
+	return &c
+}
+
+// ModuleLoaded checks whether the specified kernel module is loaded.
+func ModuleLoaded(root string, module string) bool {
+	_, err := os.Stat(filepath.Join(root, moduleSysfsPath, module))
+	return err == nil
+}
+
+// Error is raised if the NVIDIA kernel module is not loaded and cannot be loaded on demand due to a
+// constraint of the running kernel.
+type Error struct {
+	Constraint  string
+	Remediation string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("the NVIDIA kernel module is not loaded and cannot be loaded on demand: %v; %v", e.Constraint, e.Remediation)
+}
+
+// CheckModuleLoading returns an error describing the constraint and its remediation if the NVIDIA
+// kernel module is not loaded and the constraints of the kernel prevent it (and hence the creation of
+// its device nodes) from being loaded on demand. No error is returned if the module is loaded or if no
+// constraint applies.
+func CheckModuleLoading(root string) error {
+	if ModuleLoaded(root, NVIDIAModule) {
+		return nil
+	}
+	return Detect(root).ModuleLoadingError()
+}
+
+// ModuleLoadingError returns the error that is raised if the NVIDIA kernel module is not loaded for
+// the constraints. If no constraint applies, nil is returned.
+func (c *Constraints) ModuleLoadingError() error {
+	if c.PreemptRT {
+		return &Error{
+			Constraint:  "the kernel is a PREEMPT_RT kernel",
+			Remediation: "build the NVIDIA kernel modules with IGNORE_PREEMPT_RT_PRESENCE=1 and load these before starting containers",
+		}
+	}
+	if c.SignaturesEnforced {
+		return &Error{
+			Constraint:  fmt.Sprintf("the kernel only loads signed modules (%v)", c.signatureReason()),
+			Remediation: "sign the NVIDIA kernel modules with a key enrolled in the MOK list (e.g. using mokutil) or install a signed driver, and load these before starting containers",
+		}
+	}
+	return nil
+}
+
+// signatureReason returns the reason that module signatures are enforced.
+func (c *Constraints) signatureReason() string {
+	var reasons []string
+	if c.Lockdown == LockdownIntegrity || c.Lockdown == LockdownConfidentiality {
+		reasons = append(reasons, "lockdown="+c.Lockdown)
+	}
+	if c.SecureBoot {
+		reasons = append(reasons, "secure boot enabled")
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "module.sig_enforce=1")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// getLockdown returns the active lockdown mode. The modes are listed with the active mode in
+// brackets (e.g. "none [integrity] confidentiality").
+func getLockdown(root string) string {
+	for _, mode := range strings.Fields(readString(root, lockdownPath)) {
+		if strings.HasPrefix(mode, "[") && strings.HasSuffix(mode, "]") {
+			return strings.Trim(mode, "[]")
+		}
+	}
+	return ""
+}
+
+// isSecureBoot checks the SecureBoot EFI variable. The contents of the variable consist of four bytes
+// of attributes followed by the value.
+func isSecureBoot(root string) bool {
+	contents, err := os.ReadFile(filepath.Join(root, efiSecureBootPath))
+	if err != nil || len(contents) < 5 {
+		return false
+	}
+	return contents[4] == 1
+}
+
+// isPreemptRT checks whether the running kernel is a PREEMPT_RT kernel.
+func isPreemptRT(root string) bool {
+	if readString(root, realtimePath) == "1" {
+		return true
+	}
+	version, err := os.ReadFile(filepath.Join(root, procVersionPath))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(version, []byte("PREEMPT_RT"))
+}
+
+// readString returns the trimmed contents of the specified file or an empty string if it cannot be read.
+func readString(root string, path string) string {
+	contents, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
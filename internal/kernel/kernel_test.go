@@ -0,0 +1,148 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package kernel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		description         string
+		files               map[string]string
+		expectedConstraints Constraints
+	}{
+		{
+			description: "no constraints",
+			files: map[string]string{
+				lockdownPath:    "[none] integrity confidentiality\n",
+				sigEnforcePath:  "N\n",
+				procVersionPath: "Linux version 5.15.0-generic (gcc) #1 SMP\n",
+			},
+			expectedConstraints: Constraints{Lockdown: LockdownNone},
+		},
+		{
+			description: "lockdown enforces signatures",
+			files: map[string]string{
+				lockdownPath:      "none [integrity] confidentiality\n",
+				efiSecureBootPath: "\x06\x00\x00\x00\x01",
+			},
+			expectedConstraints: Constraints{Lockdown: LockdownIntegrity, SecureBoot: true, SignaturesEnforced: true},
+		},
+		{
+			description: "sig_enforce without lockdown",
+			files: map[string]string{
+				sigEnforcePath: "Y\n",
+			},
+			expectedConstraints: Constraints{SignaturesEnforced: true},
+		},
+		{
+			description: "secure boot disabled",
+			files: map[string]string{
+				efiSecureBootPath: "\x06\x00\x00\x00\x00",
+			},
+			expectedConstraints: Constraints{},
+		},
+		{
+			description: "PREEMPT_RT from sysfs",
+			files: map[string]string{
+				realtimePath: "1\n",
+			},
+			expectedConstraints: Constraints{PreemptRT: true},
+		},
+		{
+			description: "PREEMPT_RT from proc version",
+			files: map[string]string{
+				procVersionPath: "Linux version 6.1.0-rt (gcc) #1 SMP PREEMPT_RT\n",
+			},
+			expectedConstraints: Constraints{PreemptRT: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			for path, contents := range tc.files {
+				writeFile(t, filepath.Join(root, path), contents)
+			}
+			require.Equal(t, &tc.expectedConstraints, Detect(root))
+		})
+	}
+}
+
+func TestCheckModuleLoading(t *testing.T) {
+	testCases := []struct {
+		description   string
+		moduleLoaded  bool
+		files         map[string]string
+		expectedError *Error
+	}{
+		{
+			description:  "module loaded",
+			moduleLoaded: true,
+			files:        map[string]string{lockdownPath: "none [integrity] confidentiality"},
+		},
+		{
+			description: "module not loaded without constraints",
+			files:       map[string]string{lockdownPath: "[none] integrity confidentiality"},
+		},
+		{
+			description: "module not loaded with lockdown",
+			files:       map[string]string{lockdownPath: "none [integrity] confidentiality"},
+			expectedError: &Error{
+				Constraint:  "the kernel only loads signed modules (lockdown=integrity)",
+				Remediation: "sign the NVIDIA kernel modules with a key enrolled in the MOK list (e.g. using mokutil) or install a signed driver, and load these before starting containers",
+			},
+		},
+		{
+			description: "module not loaded on PREEMPT_RT kernel",
+			files:       map[string]string{realtimePath: "1"},
+			expectedError: &Error{
+				Constraint:  "the kernel is a PREEMPT_RT kernel",
+				Remediation: "build the NVIDIA kernel modules with IGNORE_PREEMPT_RT_PRESENCE=1 and load these before starting containers",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			for path, contents := range tc.files {
+				writeFile(t, filepath.Join(root, path), contents)
+			}
+			if tc.moduleLoaded {
+				require.NoError(t, os.MkdirAll(filepath.Join(root, moduleSysfsPath, NVIDIAModule), 0755))
+			}
+
+			err := CheckModuleLoading(root)
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+}
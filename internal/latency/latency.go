@@ -0,0 +1,171 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package latency records the time spent by the NVIDIA Container Runtime in each phase of the
+// creation of a container.
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Phase is a phase of the creation of a container.
+type Phase string
+
+// The following phases are recorded.
+const (
+	// PhaseConfigLoad is the loading of the toolkit config.
+	PhaseConfigLoad = Phase("config-load")
+	// PhaseDiscovery is the discovery and application of the modifications to the OCI specification.
+	PhaseDiscovery = Phase("discovery")
+	// PhaseCDIRefresh is the loading of the CDI specifications. This is included in the discovery phase.
+	PhaseCDIRefresh = Phase("cdi-refresh")
+	// PhaseSpecWrite is the writing of the modified OCI specification.
+	PhaseSpecWrite = Phase("spec-write")
+	// PhaseExec is the lookup of the low-level runtime and the preparation of its invocation.
+	PhaseExec = Phase("exec")
+)
+
+// Phases lists the recorded phases in the order in which they occur.
+var Phases = []Phase{PhaseConfigLoad, PhaseDiscovery, PhaseCDIRefresh, PhaseSpecWrite, PhaseExec}
+
+// Annotation is the annotation that is added to the OCI specification of a container with the time
+// spent in the phases that complete before the specification is written.
+const Annotation = "nvidia.com/container-toolkit.startup-latency"
+
+// Recorder records the time spent in each phase. A nil recorder records nothing so that callers do
+// not need to check whether recording is enabled.
+type Recorder struct {
+	mu          sync.Mutex
+	start       time.Time
+	containerID string
+	store       *Store
+	phases      map[Phase]time.Duration
+}
+
+// NewRecorder creates a recorder for the specified container whose creation started at the
+// specified time. The recorded times are saved to the specified store.
+func NewRecorder(start time.Time, containerID string, store *Store) *Recorder {
+	r := Recorder{
+		start:       start,
+		containerID: containerID,
+		store:       store,
+		phases:      make(map[Phase]time.Duration),
+	}
+	return &r
+}
+
+// Track starts timing the specified phase and returns a function that stops the timer. If a
+// phase is tracked multiple times, the durations are added.
+func (r *Recorder) Track(phase Phase) func() {
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		r.Record(phase, time.Since(start))
+	}
+}
+
+// Record adds the specified duration to the specified phase.
+func (r *Recorder) Record(phase Phase, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases[phase] += d
+}
+
+// Entry returns the times recorded so far. The total is the time elapsed since the start of the
+// creation.
+func (r *Recorder) Entry() Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := Entry{
+		Timestamp:   r.start,
+		ContainerID: r.containerID,
+		Phases:      make(map[Phase]Milliseconds),
+		Total:       Milliseconds(time.Since(r.start)),
+	}
+	for phase, d := range r.phases {
+		e.Phases[phase] = Milliseconds(d)
+	}
+	return e
+}
+
+// Modify adds the times recorded so far as an annotation to the specified OCI specification.
+func (r *Recorder) Modify(spec *specs.Spec) error {
+	if r == nil || spec == nil {
+		return nil
+	}
+	contents, err := json.Marshal(r.Entry().Phases)
+	if err != nil {
+		return err
+	}
+	if spec.Annotations == nil {
+		spec.Annotations = make(map[string]string)
+	}
+	spec.Annotations[Annotation] = string(contents)
+	return nil
+}
+
+// Save appends the times recorded so far to the store. This is called immediately before the
+// low-level runtime is invoked.
+func (r *Recorder) Save() error {
+	if r == nil || r.store == nil {
+		return nil
+	}
+	return r.store.Append(r.Entry())
+}
+
+// Milliseconds is a duration that is represented as a (fractional) number of milliseconds in JSON.
+type Milliseconds time.Duration
+
+// MarshalJSON returns the number of milliseconds rounded to microseconds.
+func (m Milliseconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(time.Duration(m).Microseconds()) / 1000)
+}
+
+// UnmarshalJSON parses a number of milliseconds.
+func (m *Milliseconds) UnmarshalJSON(data []byte) error {
+	var ms float64
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return err
+	}
+	*m = Milliseconds(ms * float64(time.Millisecond))
+	return nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries the specified recorder.
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the recorder carried by the specified context. If the context does not carry
+// a recorder, nil is returned.
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
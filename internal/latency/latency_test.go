@@ -0,0 +1,144 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "startup-latency.jsonl")
+	r := NewRecorder(time.Now().Add(-time.Second), "abc", NewStore(stateFile))
+	r.Record(PhaseConfigLoad, 2*time.Millisecond)
+	r.Record(PhaseDiscovery, 10*time.Millisecond)
+	r.Record(PhaseDiscovery, 5*time.Millisecond)
+
+	e := r.Entry()
+	require.Equal(t, "abc", e.ContainerID)
+	require.Equal(t,
+		map[Phase]Milliseconds{
+			PhaseConfigLoad: Milliseconds(2 * time.Millisecond),
+			PhaseDiscovery:  Milliseconds(15 * time.Millisecond),
+		},
+		e.Phases,
+	)
+	require.GreaterOrEqual(t, time.Duration(e.Total), time.Second)
+
+	spec := &specs.Spec{}
+	require.NoError(t, r.Modify(spec))
+	require.Equal(t, `{"config-load":2,"discovery":15}`, spec.Annotations[Annotation])
+
+	require.NoError(t, r.Save())
+	entries, err := NewStore(stateFile).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "abc", entries[0].ContainerID)
+	require.Equal(t, e.Phases, entries[0].Phases)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Track(PhaseDiscovery)()
+	r.Record(PhaseExec, time.Millisecond)
+
+	spec := &specs.Spec{}
+	require.NoError(t, r.Modify(spec))
+	require.Empty(t, spec.Annotations)
+	require.NoError(t, r.Save())
+
+	require.Nil(t, FromContext(context.Background()))
+	require.Nil(t, FromContext(NewContext(context.Background(), r)))
+}
+
+func TestMilliseconds(t *testing.T) {
+	contents, err := json.Marshal(Milliseconds(1234567 * time.Nanosecond))
+	require.NoError(t, err)
+	require.Equal(t, "1.234", string(contents))
+
+	var m Milliseconds
+	require.NoError(t, json.Unmarshal([]byte("1.5"), &m))
+	require.Equal(t, 1500*time.Microsecond, time.Duration(m))
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "state", "startup-latency.jsonl"))
+	s.maxEntries = 3
+
+	entries, err := s.Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	for i := 0; i < 5; i++ {
+		e := Entry{
+			ContainerID: fmt.Sprintf("container-%d", i),
+			Phases:      map[Phase]Milliseconds{PhaseConfigLoad: Milliseconds(time.Millisecond)},
+			Total:       Milliseconds(time.Duration(i) * time.Millisecond),
+		}
+		require.NoError(t, s.Append(e))
+	}
+
+	entries, err = s.Entries()
+	require.NoError(t, err)
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ContainerID)
+	}
+	require.Equal(t, []string{"container-2", "container-3", "container-4"}, ids)
+}
+
+func TestSummarize(t *testing.T) {
+	var entries []Entry
+	for i := 1; i <= 100; i++ {
+		entries = append(entries, Entry{
+			Phases: map[Phase]Milliseconds{
+				PhaseDiscovery: Milliseconds(time.Duration(i) * time.Millisecond),
+			},
+			Total: Milliseconds(time.Duration(2*i) * time.Millisecond),
+		})
+	}
+
+	summaries := Summarize(entries)
+	require.Equal(t,
+		[]Summary{
+			{
+				Phase: PhaseDiscovery,
+				Count: 100,
+				P50:   Milliseconds(50 * time.Millisecond),
+				P90:   Milliseconds(90 * time.Millisecond),
+				P99:   Milliseconds(99 * time.Millisecond),
+				Max:   Milliseconds(100 * time.Millisecond),
+			},
+			{
+				Phase: PhaseTotal,
+				Count: 100,
+				P50:   Milliseconds(100 * time.Millisecond),
+				P90:   Milliseconds(180 * time.Millisecond),
+				P99:   Milliseconds(198 * time.Millisecond),
+				Max:   Milliseconds(200 * time.Millisecond),
+			},
+		},
+		summaries,
+	)
+}
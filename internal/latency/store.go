@@ -0,0 +1,145 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package latency
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/statefile"
+)
+
+// DefaultStateFile is the file in which the times recorded for recently created containers are stored.
+// Since /run is cleared on boot, only the containers created since boot are included.
+const DefaultStateFile = "/run/nvidia-container-toolkit/startup-latency.jsonl"
+
+// DefaultMaxEntries is the number of entries retained in the state file.
+const DefaultMaxEntries = 1000
+
+// Entry is the time spent in each phase of the creation of a container.
+type Entry struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	ContainerID string                 `json:"containerId,omitempty"`
+	Phases      map[Phase]Milliseconds `json:"phases"`
+	// Total is the time from the invocation of the runtime until the low-level runtime is invoked.
+	Total Milliseconds `json:"total"`
+}
+
+// Store records entries in a state file with one JSON entry per line. Only the most recent entries
+// are retained.
+type Store struct {
+	path       string
+	maxEntries int
+}
+
+// NewStore creates a store for the specified state file.
+func NewStore(path string) *Store {
+	s := Store{
+		path:       path,
+		maxEntries: DefaultMaxEntries,
+	}
+	return &s
+}
+
+// Append adds the specified entry to the state file. Since the NVIDIA Container Runtime is invoked
+// once per container, a lock file is used to serialize updates. If the number of entries exceeds
+// the maximum, the oldest entries are removed.
+func (s *Store) Append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %v", err)
+	}
+
+	return statefile.Update(s.path, func() error {
+		lines, err := s.readLines()
+		if err != nil {
+			return err
+		}
+		if len(lines) < s.maxEntries {
+			return appendLine(s.path, line)
+		}
+
+		lines = append(lines[len(lines)-s.maxEntries+1:], line)
+		return s.write(lines)
+	})
+}
+
+// Entries returns the entries in the state file, oldest first. Lines that cannot be parsed are skipped.
+func (s *Store) Entries() ([]Entry, error) {
+	lines, err := s.readLines()
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, line := range lines {
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (s *Store) readLines() ([][]byte, error) {
+	contents, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+	return lines, nil
+}
+
+// write atomically replaces the state file with the specified lines.
+func (s *Store) write(lines [][]byte) error {
+	var contents bytes.Buffer
+	for _, line := range lines {
+		contents.Write(line)
+		contents.WriteByte('\n')
+	}
+	return statefile.WriteFile(s.path, contents.Bytes())
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,75 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package latency
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PhaseTotal identifies the total time in a summary.
+const PhaseTotal = Phase("total")
+
+// Summary is the distribution of the time spent in a phase.
+type Summary struct {
+	Phase Phase        `json:"phase"`
+	Count int          `json:"count"`
+	P50   Milliseconds `json:"p50"`
+	P90   Milliseconds `json:"p90"`
+	P99   Milliseconds `json:"p99"`
+	Max   Milliseconds `json:"max"`
+}
+
+// Summarize returns the distribution of the time spent in each phase and in total for the
+// specified entries. Phases that were not recorded for any entry are omitted.
+func Summarize(entries []Entry) []Summary {
+	durations := make(map[Phase][]time.Duration)
+	for _, e := range entries {
+		for phase, d := range e.Phases {
+			durations[phase] = append(durations[phase], time.Duration(d))
+		}
+		durations[PhaseTotal] = append(durations[PhaseTotal], time.Duration(e.Total))
+	}
+
+	var summaries []Summary
+	for _, phase := range append(Phases, PhaseTotal) {
+		d := durations[phase]
+		if len(d) == 0 {
+			continue
+		}
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		summaries = append(summaries, Summary{
+			Phase: phase,
+			Count: len(d),
+			P50:   Milliseconds(percentile(d, 50)),
+			P90:   Milliseconds(percentile(d, 90)),
+			P99:   Milliseconds(percentile(d, 99)),
+			Max:   Milliseconds(d[len(d)-1]),
+		})
+	}
+	return summaries
+}
+
+// percentile returns the specified percentile of the sorted durations using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -44,13 +44,32 @@ const (
 	flagTypeMask = 0x00ff
 	flagTypeELF  = 0x0001
 
-	flagArchMask    = 0xff00
-	flagArchI386    = 0x0000
-	flagArchX8664   = 0x0300
-	flagArchX32     = 0x0800
-	flagArchPpc64le = 0x0500
+	flagArchMask             = 0xff00
+	flagArchI386             = 0x0000
+	flagArchX8664            = 0x0300
+	flagArchX32              = 0x0800
+	flagArchPpc64le          = 0x0500
+	flagArchAArch64          = 0x0a00
+	flagArchRISCVFloatSoft   = 0x0f00
+	flagArchRISCVFloatDouble = 0x1000
+	flagArchLArchFloatSoft   = 0x1100
+	flagArchLArchFloatDouble = 0x1200
 )
 
+// flagArchBits maps the architecture flags of ldcache entries to the word size of the libraries.
+// Entries with other flags (e.g. for architectures that are not supported) are ignored.
+var flagArchBits = map[int32]int{
+	flagArchI386:             32,
+	flagArchX32:              32,
+	flagArchX8664:            64,
+	flagArchPpc64le:          64,
+	flagArchAArch64:          64,
+	flagArchRISCVFloatSoft:   64,
+	flagArchRISCVFloatDouble: 64,
+	flagArchLArchFloatSoft:   64,
+	flagArchLArchFloatDouble: 64,
+}
+
 var errInvalidCache = errors.New("invalid ld.so.cache file")
 
 type header1 struct {
@@ -189,20 +208,11 @@ type entry struct {
 func (c *ldcache) getEntries(selected func(string) bool) []entry {
 	var entries []entry
 	for _, e := range c.entries {
-		bits := 0
 		if ((e.Flags & flagTypeMask) & flagTypeELF) == 0 {
 			continue
 		}
-		switch e.Flags & flagArchMask {
-		case flagArchX8664:
-			fallthrough
-		case flagArchPpc64le:
-			bits = 64
-		case flagArchX32:
-			fallthrough
-		case flagArchI386:
-			bits = 32
-		default:
+		bits, ok := flagArchBits[e.Flags&flagArchMask]
+		if !ok {
 			continue
 		}
 		if e.Key > uint32(len(c.libs)) || e.Value > uint32(len(c.libs)) {
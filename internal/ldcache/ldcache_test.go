@@ -0,0 +1,104 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package ldcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCache(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	root := t.TempDir()
+
+	libraries := map[string]string{
+		"libcuda.so.1":       "/usr/lib64/libcuda.so.1",
+		"libnvidia-ml.so.1":  "/usr/lib64/libnvidia-ml.so.1",
+		"libnvidia-cfg.so.1": "/usr/lib64/libnvidia-cfg.so.1",
+	}
+	for _, path := range libraries {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), nil, 0644))
+	}
+
+	require.NoError(t, WriteCache(root, libraries))
+
+	cache, err := New(logger, root)
+	require.NoError(t, err)
+
+	libs32, libs64 := cache.List()
+	require.Empty(t, libs32)
+	require.ElementsMatch(t,
+		[]string{
+			filepath.Join(root, "/usr/lib64/libcuda.so.1"),
+			filepath.Join(root, "/usr/lib64/libnvidia-ml.so.1"),
+			filepath.Join(root, "/usr/lib64/libnvidia-cfg.so.1"),
+		},
+		libs64,
+	)
+
+	_, libs64 = cache.Lookup("libnvidia-ml.so")
+	require.Equal(t, []string{filepath.Join(root, "/usr/lib64/libnvidia-ml.so.1")}, libs64)
+}
+
+func TestArchitectureFlags(t *testing.T) {
+	testCases := []struct {
+		description  string
+		flagArch     int32
+		expected32   bool
+		expectedNone bool
+	}{
+		{description: "x86_64", flagArch: flagArchX8664},
+		{description: "aarch64", flagArch: flagArchAArch64},
+		{description: "ppc64le", flagArch: flagArchPpc64le},
+		{description: "riscv64", flagArch: flagArchRISCVFloatDouble},
+		{description: "loongarch64", flagArch: flagArchLArchFloatDouble},
+		{description: "i386", flagArch: flagArchI386, expected32: true},
+		{description: "unsupported", flagArch: 0x0700, expectedNone: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			logger, _ := testlog.NewNullLogger()
+			root := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(root, "/usr/lib64"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(root, "/usr/lib64/libcuda.so.1"), nil, 0644))
+
+			require.NoError(t, writeCache(root, map[string]string{"libcuda.so.1": "/usr/lib64/libcuda.so.1"}, tc.flagArch))
+
+			cache, err := New(logger, root)
+			require.NoError(t, err)
+
+			libs32, libs64 := cache.Lookup("libcuda.so")
+			switch {
+			case tc.expectedNone:
+				require.Empty(t, libs32)
+				require.Empty(t, libs64)
+			case tc.expected32:
+				require.Len(t, libs32, 1)
+				require.Empty(t, libs64)
+			default:
+				require.Empty(t, libs32)
+				require.Len(t, libs64, 1)
+			}
+		})
+	}
+}
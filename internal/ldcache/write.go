@@ -0,0 +1,88 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package ldcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"unsafe"
+)
+
+// WriteCache writes an ld.so.cache file at the specified root for the specified libraries.
+// The libraries are specified as a map of library names (e.g. libcuda.so.1) to the paths of
+// the libraries relative to the root. All libraries are recorded as 64-bit ELF libraries and
+// the cache is written in the glibc-ld.so.cache1.1 format.
+// This is intended to generate ld.so.cache files for test fixtures.
+func WriteCache(root string, libraries map[string]string) error {
+	return writeCache(root, libraries, flagArchX8664)
+}
+
+// writeCache writes an ld.so.cache file with the specified architecture flags for all libraries.
+func writeCache(root string, libraries map[string]string, flagArch int32) error {
+	var names []string
+	for name := range libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// The offsets of the keys and values are relative to the start of the header.
+	tableOffset := uint32(unsafe.Sizeof(header2{})) + uint32(len(names))*uint32(unsafe.Sizeof(entry2{}))
+
+	var table bytes.Buffer
+	addString := func(s string) uint32 {
+		offset := tableOffset + uint32(table.Len())
+		table.WriteString(s)
+		table.WriteByte(0)
+		return offset
+	}
+
+	var entries []entry2
+	for _, name := range names {
+		e := entry2{
+			Flags: flagTypeELF | flagArch,
+			Key:   addString(name),
+			Value: addString(libraries[name]),
+		}
+		entries = append(entries, e)
+	}
+
+	header := header2{
+		NLibs:     uint32(len(entries)),
+		TableSize: uint32(table.Len()),
+	}
+	copy(header.Magic[:], magicString2)
+	copy(header.Version[:], magicVersion)
+
+	var contents bytes.Buffer
+	if err := binary.Write(&contents, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("failed to write header: %v", err)
+	}
+	if err := binary.Write(&contents, binary.LittleEndian, entries); err != nil {
+		return fmt.Errorf("failed to write entries: %v", err)
+	}
+	contents.Write(table.Bytes())
+
+	path := filepath.Join(root, ldcachePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %v: %v", path, err)
+	}
+	return os.WriteFile(path, contents.Bytes(), 0644)
+}
@@ -0,0 +1,54 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package lookup
+
+import "path/filepath"
+
+// architecture defines the directories in which the 64-bit libraries for an architecture are installed.
+type architecture struct {
+	// name is the name of the architecture as used by GOARCH.
+	name string
+	// triplet is the multiarch tuple used for the library directories on Debian-based distributions.
+	triplet string
+	// libraryDirs are additional library directories (relative to /usr and /) used by other distributions.
+	libraryDirs []string
+}
+
+// architectures lists the architectures for which library directories are considered.
+var architectures = []architecture{
+	{name: "amd64", triplet: "x86_64-linux-gnu"},
+	{name: "arm64", triplet: "aarch64-linux-gnu"},
+	{name: "ppc64le", triplet: "powerpc64le-linux-gnu"},
+	{name: "riscv64", triplet: "riscv64-linux-gnu", libraryDirs: []string{"lib64/lp64d"}},
+	{name: "loong64", triplet: "loongarch64-linux-gnu"},
+}
+
+// LibraryDirs returns the standard 64-bit library directories for all supported architectures.
+// The directories in /usr are listed before those in /.
+func LibraryDirs() []string {
+	var dirs []string
+	for _, prefix := range []string{"/usr", "/"} {
+		dirs = append(dirs, filepath.Join(prefix, "lib64"))
+		for _, arch := range architectures {
+			dirs = append(dirs, filepath.Join(prefix, "lib", arch.triplet))
+			for _, dir := range arch.libraryDirs {
+				dirs = append(dirs, filepath.Join(prefix, dir))
+			}
+		}
+	}
+	return dirs
+}
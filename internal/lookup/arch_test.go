@@ -0,0 +1,41 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package lookup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryDirs(t *testing.T) {
+	dirs := LibraryDirs()
+
+	require.Equal(t, "/usr/lib64", dirs[0])
+	require.Subset(t, dirs, []string{
+		"/usr/lib/x86_64-linux-gnu",
+		"/usr/lib/aarch64-linux-gnu",
+		"/usr/lib/powerpc64le-linux-gnu",
+		"/usr/lib/riscv64-linux-gnu",
+		"/usr/lib64/lp64d",
+		"/usr/lib/loongarch64-linux-gnu",
+		"/lib64",
+		"/lib/loongarch64-linux-gnu",
+		"/lib64/lp64d",
+	})
+	require.NotContains(t, dirs, "/usr/lib")
+}
@@ -0,0 +1,122 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/state"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// allocationRecorder records the GPUs injected into a container so that these can be queried using
+// the pkg/state package.
+type allocationRecorder struct {
+	logger      *logrus.Logger
+	root        string
+	containerID string
+	store       *state.Store
+	now         func() time.Time
+}
+
+var _ oci.SpecModifier = (*allocationRecorder)(nil)
+
+// NewAllocationRecorder creates a modifier that adds the GPUs injected into the container with the
+// specified ID as an annotation to the spec and records these in the state dir. If the recording of
+// allocations is not enabled, no modifier is returned.
+func NewAllocationRecorder(logger *logrus.Logger, cfg *config.Config, containerID string) oci.SpecModifier {
+	allocationsConfig := cfg.NVIDIAContainerRuntimeConfig.Allocations
+	if !allocationsConfig.Enabled {
+		return nil
+	}
+
+	m := allocationRecorder{
+		logger:      logger,
+		root:        "/",
+		containerID: containerID,
+		store:       state.NewStore(allocationsConfig.StateDir),
+		now:         time.Now,
+	}
+	return m
+}
+
+// Modify determines the GPUs injected into the container from its device nodes and
+// NVIDIA_VISIBLE_DEVICES and records these. Since the allocation is informational, failures to
+// record it are logged and do not prevent the container from being created.
+func (m allocationRecorder) Modify(spec *specs.Spec) error {
+	gpus, err := getProcGPUs(m.root)
+	if err != nil {
+		m.logger.Warningf("Not recording allocation: failed to get GPUs: %v", err)
+		return nil
+	}
+	injected, err := getInjectedDeviceUUIDs(spec, gpus)
+	if err != nil {
+		m.logger.Warningf("Not recording allocation: %v", err)
+		return nil
+	}
+	if len(injected) == 0 {
+		return nil
+	}
+
+	byUUID := make(map[string]procGPU)
+	for _, gpu := range gpus {
+		byUUID[gpu.uuid] = gpu
+	}
+
+	allocation := state.Allocation{
+		ContainerID: m.containerID,
+		CreatedAt:   m.now().UTC(),
+	}
+	for uuid := range injected {
+		d := state.Device{UUID: uuid}
+		if gpu, ok := byUUID[uuid]; ok {
+			d.BusID = gpu.busID
+			if gpu.minor >= 0 {
+				d.DeviceNode = fmt.Sprintf("/dev/nvidia%d", gpu.minor)
+			}
+		}
+		allocation.Devices = append(allocation.Devices, d)
+	}
+	sort.Slice(allocation.Devices, func(i, j int) bool {
+		return allocation.Devices[i].UUID < allocation.Devices[j].UUID
+	})
+
+	value, err := json.Marshal(allocation)
+	if err != nil {
+		m.logger.Warningf("Not recording allocation: %v", err)
+		return nil
+	}
+	if spec.Annotations == nil {
+		spec.Annotations = make(map[string]string)
+	}
+	spec.Annotations[state.AllocationAnnotation] = string(value)
+
+	if m.containerID == "" {
+		m.logger.Warningf("Not recording allocation in state dir: unknown container ID")
+		return nil
+	}
+	if err := m.store.Save(allocation); err != nil {
+		m.logger.Warningf("Failed to record allocation: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/state"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocationRecorder(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	for minor, busID := range []string{"0000:3b:00.0", "0000:86:00.0"} {
+		dir := filepath.Join(root, procDriverGPUsPath, busID)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		information := fmt.Sprintf("Model: \t\t NVIDIA A100\nGPU UUID: \t GPU-%d\nDevice Minor: \t %d\n", minor, minor)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "information"), []byte(information), 0644))
+	}
+
+	createdAt := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		description        string
+		containerID        string
+		spec               *specs.Spec
+		expectedAllocation *state.Allocation
+		expectRecorded     bool
+	}{
+		{
+			description: "no devices",
+			containerID: "abc",
+			spec:        &specs.Spec{},
+		},
+		{
+			description: "device nodes",
+			containerID: "abc",
+			spec: &specs.Spec{
+				Linux: &specs.Linux{
+					Devices: []specs.LinuxDevice{{Path: "/dev/nvidiactl"}, {Path: "/dev/nvidia1"}},
+				},
+			},
+			expectedAllocation: &state.Allocation{
+				ContainerID: "abc",
+				CreatedAt:   createdAt,
+				Devices:     []state.Device{{UUID: "GPU-1", BusID: "0000:86:00.0", DeviceNode: "/dev/nvidia1"}},
+			},
+			expectRecorded: true,
+		},
+		{
+			description: "visible devices",
+			containerID: "abc",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=all"}},
+			},
+			expectedAllocation: &state.Allocation{
+				ContainerID: "abc",
+				CreatedAt:   createdAt,
+				Devices: []state.Device{
+					{UUID: "GPU-0", BusID: "0000:3b:00.0", DeviceNode: "/dev/nvidia0"},
+					{UUID: "GPU-1", BusID: "0000:86:00.0", DeviceNode: "/dev/nvidia1"},
+				},
+			},
+			expectRecorded: true,
+		},
+		{
+			description: "unknown container ID",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=MIG-7ab1e2c4"}},
+			},
+			expectedAllocation: &state.Allocation{
+				CreatedAt: createdAt,
+				Devices:   []state.Device{{UUID: "MIG-7ab1e2c4"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			store := state.NewStore(t.TempDir())
+			m := allocationRecorder{
+				logger:      logger,
+				root:        root,
+				containerID: tc.containerID,
+				store:       store,
+				now:         func() time.Time { return createdAt },
+			}
+
+			require.NoError(t, m.Modify(tc.spec))
+
+			if tc.expectedAllocation == nil {
+				require.NotContains(t, tc.spec.Annotations, state.AllocationAnnotation)
+				return
+			}
+			annotated, err := state.FromAnnotations(tc.spec.Annotations)
+			require.NoError(t, err)
+			require.Equal(t, *tc.expectedAllocation, annotated)
+
+			recorded, err := store.Lookup("abc")
+			if !tc.expectRecorded {
+				require.ErrorIs(t, err, state.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, *tc.expectedAllocation, recorded)
+
+			value, err := json.Marshal(recorded)
+			require.NoError(t, err)
+			require.Equal(t, string(value), tc.spec.Annotations[state.AllocationAnnotation])
+		})
+	}
+}
@@ -17,26 +17,66 @@
 package modifier
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdispecdirs"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/policy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/redact"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	cdispecs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
 )
 
 type cdiModifier struct {
-	logger   *logrus.Logger
-	specDirs []string
-	devices  []string
+	logger       *logrus.Logger
+	specDirs     []string
+	overrideDirs []string
+	indexFile    string
+	strict       bool
+	// registrySocket is the socket of the CDI registry daemon. This is empty if no daemon is used.
+	registrySocket string
+	devices        []string
+	deviceWait     deviceWait
+	deviceState    *devicestate.Store
+	redactor       *redact.Redactor
+	recorder       *latency.Recorder
+	// ctx bounds the loading of the CDI specs. A nil context is never done.
+	ctx context.Context
 }
 
 // NewCDIModifier creates an OCI spec modifier that determines the modifications to make based on the
 // CDI specifications available on the system. The NVIDIA_VISIBLE_DEVICES enviroment variable is
-// used to select the devices to include.
-func NewCDIModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+// used to select the devices to include. The NVML calls and the loading of the CDI specifications
+// stop once the specified context is done and the time spent loading the CDI specifications is
+// recorded using the recorder of the context, if any.
+func NewCDIModifier(ctx context.Context, logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	validator, err := cdispecdirs.NewValidator(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := validator.Validate(getCDISpecDirs(cfg)...); err != nil {
+		return nil, err
+	}
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+	overrideDirs := getCDIOverrideDirs(cfg, rawSpec.Annotations)
+	if err := validator.Validate(overrideDirs...); err != nil {
+		return nil, err
+	}
+
 	devices, err := getDevicesFromSpec(logger, ociSpec, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get required devices from OCI specification: %v", err)
@@ -45,17 +85,34 @@ func NewCDIModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec)
 		logger.Debugf("No devices requested; no modification required.")
 		return nil, nil
 	}
+	if err := checkDeviceLimit(ctx, cfg, ociSpec, devices); err != nil {
+		return nil, err
+	}
 	logger.Debugf("Creating CDI modifier for devices: %v", devices)
 
-	specDirs := cdi.DefaultSpecDirs
-	if len(cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs) > 0 {
-		specDirs = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	deviceWait, err := getDeviceWait(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	m := cdiModifier{
-		logger:   logger,
-		specDirs: specDirs,
-		devices:  devices,
+		logger:         logger,
+		specDirs:       getCDISpecDirs(cfg),
+		overrideDirs:   overrideDirs,
+		indexFile:      cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.IndexFile,
+		strict:         cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.Strict,
+		registrySocket: cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.RegistrySocket,
+		devices:        devices,
+		deviceWait:     deviceWait,
+		deviceState:    devicestate.NewStore(cfg.NVIDIAContainerRuntimeConfig.DeviceState.StateFile),
+		redactor:       redactor,
+		recorder:       latency.FromContext(ctx),
+		ctx:            ctx,
 	}
 
 	return m, nil
@@ -67,11 +124,18 @@ func getDevicesFromSpec(logger *logrus.Logger, ociSpec oci.Spec, cfg *config.Con
 		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
 	}
 
-	_, annotationDevices, err := cdi.ParseAnnotations(rawSpec.Annotations)
+	prefixes, err := getAnnotationPrefixes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	annotationDevices, ignoredPrefixes, err := parseCDIAnnotations(rawSpec.Annotations, prefixes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse container annotations: %v", err)
 	}
 	if len(annotationDevices) > 0 {
+		if len(ignoredPrefixes) > 0 {
+			logger.Warningf("Ignoring devices requested using annotations with lower-precedence prefixes %v", ignoredPrefixes)
+		}
 		return annotationDevices, nil
 	}
 
@@ -81,16 +145,24 @@ func getDevicesFromSpec(logger *logrus.Logger, ociSpec oci.Spec, cfg *config.Con
 	}
 	envDevices := container.DevicesFromEnvvars(visibleDevicesEnvvar)
 
+	resolver := newKindResolver(logger, cfg)
+
 	var devices []string
 	seen := make(map[string]bool)
 	for _, name := range envDevices.List() {
-		if !cdi.IsQualifiedName(name) {
-			name = fmt.Sprintf("%s=%s", cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DefaultKind, name)
+		if busID, ok := image.ParseBusID(name); ok {
+			name, err = resolver.qualifyBusID(busID)
+			if err != nil {
+				return nil, err
+			}
+		} else if !isQualifiedCDIName(name) {
+			name = resolver.qualify(name)
 		}
 		if seen[name] {
 			logger.Debugf("Ignoring duplicate device %q", name)
 			continue
 		}
+		seen[name] = true
 		devices = append(devices, name)
 	}
 
@@ -102,26 +174,252 @@ func getDevicesFromSpec(logger *logrus.Logger, ociSpec oci.Spec, cfg *config.Con
 		return devices, nil
 	}
 
-	logger.Warningf("Ignoring devices specified in NVIDIA_VISIBLE_DEVICES: %v", devices)
+	logger.WithField(events.Field, events.CDIDevicesIgnored).Warningf("Ignoring devices specified in NVIDIA_VISIBLE_DEVICES: %v", devices)
 
 	return nil, nil
 }
 
-// Modify loads the CDI registry and injects the specified CDI devices into the OCI runtime specification.
+// checkDeviceLimit checks the requested devices against the device limit that applies to the container.
+// A request for all devices of a kind counts as the number of GPUs on the node. NVML is not
+// initialized if the specified context is already done.
+func checkDeviceLimit(ctx context.Context, cfg *config.Config, ociSpec oci.Spec, devices []string) error {
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	limit := policy.DeviceLimit(&cfg.NVIDIAContainerRuntimeConfig, rawSpec.Annotations)
+	countAll := func() (int, error) {
+		if err := oci.CheckContext(ctx); err != nil {
+			return 0, err
+		}
+		return policy.CountGPUs(nvml.New(), "/", cfg.NVIDIAContainerCLIConfig.Root)
+	}
+	err = policy.CheckDeviceLimit(limit, devices, countAll)
+	if ctxErr := oci.CheckContext(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return oci.NewError(oci.ErrorKindUnsupportedRequest, err)
+	}
+	return nil
+}
+
+// Modify injects the specified CDI devices into the OCI runtime specification and applies the edits
+// from the override dirs. Injecting devices that include the device node of a removed GPU fails. If the injected edits embed the properties of the
+// node, the NVIDIA_REQUIRE_* requirements of the container are checked against these.
 func (m cdiModifier) Modify(spec *specs.Spec) error {
+	if err := m.inject(spec); err != nil {
+		return err
+	}
+	if err := applyCDIOverrides(m.logger, m.overrideDirs, m.devices, spec); err != nil {
+		return err
+	}
+	if err := checkUnavailableDevices(m.logger, m.deviceState, spec); err != nil {
+		return err
+	}
+	return checkNodeRequirements(m.logger, spec)
+}
+
+// inject injects the specified CDI devices into the OCI runtime specification. If a CDI registry
+// daemon is configured and available, the edits for the devices are queried from the daemon. Otherwise,
+// if a CDI spec index is configured and up to date, only the specs that define the devices are loaded.
+// If neither can be used, the CDI registry is loaded. In strict mode, an error is returned if any of
+// the specs in the spec dirs cannot be loaded.
+func (m cdiModifier) inject(spec *specs.Spec) error {
+	if m.registrySocket != "" {
+		injected, err := m.injectFromDaemon(spec)
+		if err != nil {
+			return err
+		}
+		if injected {
+			return nil
+		}
+	}
+
+	if m.indexFile != "" {
+		injected, err := m.injectFromIndex(spec)
+		if err != nil {
+			return err
+		}
+		if injected {
+			return nil
+		}
+	}
+
+	refreshed := m.recorder.Track(latency.PhaseCDIRefresh)
 	registry := cdi.GetRegistry(
 		cdi.WithSpecDirs(m.specDirs...),
 		cdi.WithAutoRefresh(false),
 	)
-	if err := registry.Refresh(); err != nil {
-		m.logger.Debugf("The following error was triggered when refreshing the CDI registry: %v", err)
+	// Errors are reported per spec below.
+	_ = registry.Refresh()
+	refreshed()
+	if err := oci.CheckContext(m.ctx); err != nil {
+		return err
+	}
+	if err := cdiSpecErrors(registry.GetErrors()).check(m.logger, m.strict); err != nil {
+		return err
+	}
+
+	if m.deviceWait.timeout > 0 {
+		err := m.deviceWait.wait(m.logger, getCDIDeviceNodePaths(registry.DeviceDB(), m.devices))
+		if err != nil {
+			return oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("failed to inject CDI devices: %v", err))
+		}
 	}
 
-	m.logger.Debugf("Injecting devices using CDI: %v", m.devices)
-	_, err := registry.InjectDevices(spec, m.devices...)
+	m.logger.WithField(events.Field, events.CDIInject).Debugf("Injecting devices using CDI: %v", m.devices)
+	m.logDeviceEdits(registry.DeviceDB())
+	unresolved, err := registry.InjectDevices(spec, m.devices...)
+	if len(unresolved) > 0 {
+		return oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("failed to inject CDI devices: %v", err))
+	}
 	if err != nil {
 		return fmt.Errorf("failed to inject CDI devices: %v", err)
 	}
 
 	return nil
 }
+
+// logDeviceEdits logs the container edits of the requested devices and their specs at debug level.
+// The environment of the edits and hooks is redacted.
+func (m cdiModifier) logDeviceEdits(db cdiDeviceDB) {
+	if !m.logger.IsLevelEnabled(logrus.DebugLevel) || m.redactor == nil {
+		return
+	}
+	for _, name := range m.devices {
+		device := db.GetDevice(name)
+		if device == nil {
+			continue
+		}
+		m.logEdits("spec", name, &device.GetSpec().ContainerEdits)
+		m.logEdits("device", name, &device.ContainerEdits)
+	}
+}
+
+// logEdits logs the specified container edits at debug level with the environment of the edits
+// and hooks redacted.
+func (m cdiModifier) logEdits(description string, name string, edits *cdispecs.ContainerEdits) {
+	if !m.logger.IsLevelEnabled(logrus.DebugLevel) || m.redactor == nil {
+		return
+	}
+	redacted, err := m.redactor.ContainerEdits(edits)
+	if err != nil {
+		return
+	}
+	contents, err := json.Marshal(redacted)
+	if err != nil {
+		return
+	}
+	m.logger.Debugf("CDI %v edits for %v: %s", description, name, contents)
+}
+
+// getCDISpecDirs returns the directories from which CDI specifications are loaded.
+func getCDISpecDirs(cfg *config.Config) []string {
+	if len(cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs) > 0 {
+		return cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	}
+	return cdi.DefaultSpecDirs
+}
+
+// kindResolver constructs fully-qualified CDI device names for unqualified device names.
+type kindResolver struct {
+	logger   *logrus.Logger
+	kinds    []string
+	specDirs []string
+	registry cdi.Registry
+	// root is the root under which the GPUs that are requested by PCI bus ID are looked up.
+	root string
+}
+
+func newKindResolver(logger *logrus.Logger, cfg *config.Config) *kindResolver {
+	r := kindResolver{
+		logger:   logger,
+		kinds:    cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DefaultKind,
+		specDirs: getCDISpecDirs(cfg),
+		root:     "/",
+	}
+	return &r
+}
+
+// qualify returns the fully-qualified CDI device name for the specified device name.
+// If multiple default kinds are configured, these are checked in order and the first
+// kind for which the device exists in the CDI registry is used. If the device does
+// not exist for any kind, the first kind is used.
+func (r *kindResolver) qualify(name string) string {
+	if len(r.kinds) == 0 {
+		return name
+	}
+	if len(r.kinds) > 1 {
+		registry := r.getRegistry()
+		for _, kind := range r.kinds {
+			qualified := fmt.Sprintf("%s=%s", kind, name)
+			if registry.DeviceDB().GetDevice(qualified) != nil {
+				r.logger.Debugf("Resolved device %q as %q", name, qualified)
+				return qualified
+			}
+		}
+		r.logger.Debugf("Device %q not found for kinds %v", name, r.kinds)
+	}
+	return fmt.Sprintf("%s=%s", r.kinds[0], name)
+}
+
+// qualifyBusID returns the fully-qualified CDI device name for the GPU with the specified PCI bus ID.
+// The GPU is looked up in the information files of the NVIDIA kernel module. The device name based
+// on the UUID of the GPU is used if it exists in the CDI registry, since this is stable across
+// reboots and changes to the set of GPUs. Otherwise, the device name based on its index is used.
+func (r *kindResolver) qualifyBusID(busID string) (string, error) {
+	gpu, index, err := devicestate.ResolveBusID(r.root, busID)
+	if err != nil {
+		return "", err
+	}
+	name, ok := r.qualifyExisting(gpu.UUID)
+	if !ok {
+		name = r.qualify(strconv.Itoa(index))
+	}
+	r.logger.Debugf("Resolved PCI bus ID %v as %q", busID, name)
+	return name, nil
+}
+
+// qualifyExisting returns the fully-qualified CDI device name for the specified device name if this
+// exists in the CDI registry for any of the default kinds.
+func (r *kindResolver) qualifyExisting(name string) (string, bool) {
+	if name == "" || len(r.kinds) == 0 {
+		return "", false
+	}
+	registry := r.getRegistry()
+	for _, kind := range r.kinds {
+		qualified := fmt.Sprintf("%s=%s", kind, name)
+		if registry.DeviceDB().GetDevice(qualified) != nil {
+			return qualified, true
+		}
+	}
+	return "", false
+}
+
+// getRegistry returns the CDI registry, refreshing it on first use.
+func (r *kindResolver) getRegistry() cdi.Registry {
+	if r.registry != nil {
+		return r.registry
+	}
+	r.registry = cdi.GetRegistry(
+		cdi.WithSpecDirs(r.specDirs...),
+		cdi.WithAutoRefresh(false),
+	)
+	if err := r.registry.Refresh(); err != nil {
+		r.logger.WithField(events.Field, events.CDIRefreshFailed).Debugf("The following error was triggered when refreshing the CDI registry: %v", err)
+	}
+	return r.registry
+}
+
+// isQualifiedCDIName checks whether the specified device is a fully-qualified CDI device name.
+// The vendored CDI package panics when validating single-character vendor or class names, so
+// these are rejected before the name is validated.
+func isQualifiedCDIName(device string) bool {
+	vendor, class, _ := cdi.ParseDevice(device)
+	if len(vendor) < 2 || len(class) < 2 {
+		return false
+	}
+	return cdi.IsQualifiedName(device)
+}
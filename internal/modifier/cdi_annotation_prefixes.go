@@ -0,0 +1,101 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+)
+
+// annotationPrefix matches valid annotation prefixes. These are a DNS subdomain followed by a slash.
+var annotationPrefix = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/$`)
+
+// getAnnotationPrefixes returns the annotation prefixes that are parsed for CDI device requests
+// in order of precedence. The cdi.k8s.io/ prefix is always included and takes precedence over
+// the additional prefixes specified in the config.
+func getAnnotationPrefixes(cfg *config.Config) ([]string, error) {
+	prefixes := []string{cdi.AnnotationPrefix}
+	seen := map[string]bool{cdi.AnnotationPrefix: true}
+	for _, prefix := range cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.AnnotationPrefixes {
+		if !annotationPrefix.MatchString(prefix) {
+			return nil, fmt.Errorf("invalid annotation prefix %q: expected a domain followed by '/'", prefix)
+		}
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// parseCDIAnnotations returns the CDI devices requested using annotations with the specified
+// prefixes. The prefixes are in order of precedence and an annotation is associated with the
+// first prefix it matches. If devices are requested using more than one prefix, only the devices
+// for the prefix with the highest precedence are returned; the prefixes that were ignored are
+// returned as the second value. All requested devices must be fully-qualified CDI device names.
+func parseCDIAnnotations(annotations map[string]string, prefixes []string) ([]string, []string, error) {
+	var keys []string
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	requests := make(map[string][]string)
+	for _, key := range keys {
+		prefix := getMatchingPrefix(key, prefixes)
+		if prefix == "" {
+			continue
+		}
+		for _, device := range strings.Split(annotations[key], ",") {
+			if !isQualifiedCDIName(device) {
+				return nil, nil, fmt.Errorf("invalid CDI device name %q in annotation %v", device, key)
+			}
+			requests[prefix] = append(requests[prefix], device)
+		}
+	}
+
+	var devices []string
+	var ignored []string
+	for _, prefix := range prefixes {
+		if len(requests[prefix]) == 0 {
+			continue
+		}
+		if devices == nil {
+			devices = requests[prefix]
+			continue
+		}
+		ignored = append(ignored, prefix)
+	}
+	return devices, ignored, nil
+}
+
+// getMatchingPrefix returns the first of the specified prefixes that the key matches. An empty
+// string is returned if there is no match.
+func getMatchingPrefix(key string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
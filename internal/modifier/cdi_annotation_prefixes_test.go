@@ -0,0 +1,192 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAnnotationPrefixes(t *testing.T) {
+	testCases := []struct {
+		description      string
+		prefixes         []string
+		expectedPrefixes []string
+		expectedError    bool
+	}{
+		{
+			description:      "default prefix only",
+			expectedPrefixes: []string{"cdi.k8s.io/"},
+		},
+		{
+			description:      "additional prefixes follow the default prefix",
+			prefixes:         []string{"devices.nvidia.com/", "example.com/"},
+			expectedPrefixes: []string{"cdi.k8s.io/", "devices.nvidia.com/", "example.com/"},
+		},
+		{
+			description:      "duplicate prefixes are ignored",
+			prefixes:         []string{"cdi.k8s.io/", "devices.nvidia.com/", "devices.nvidia.com/"},
+			expectedPrefixes: []string{"cdi.k8s.io/", "devices.nvidia.com/"},
+		},
+		{
+			description:   "prefix must end with a slash",
+			prefixes:      []string{"devices.nvidia.com"},
+			expectedError: true,
+		},
+		{
+			description:   "prefix must be a domain",
+			prefixes:      []string{"Devices_NVIDIA/"},
+			expectedError: true,
+		},
+		{
+			description:   "empty prefix is invalid",
+			prefixes:      []string{""},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.AnnotationPrefixes = tc.prefixes
+
+			prefixes, err := getAnnotationPrefixes(cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedPrefixes, prefixes)
+		})
+	}
+}
+
+func TestParseCDIAnnotations(t *testing.T) {
+	prefixes := []string{"cdi.k8s.io/", "devices.nvidia.com/", "example.com/"}
+
+	testCases := []struct {
+		description     string
+		annotations     map[string]string
+		expectedDevices []string
+		expectedIgnored []string
+		expectedError   bool
+	}{
+		{
+			description: "no matching annotations",
+			annotations: map[string]string{
+				"other.io/devices": "nvidia.com/gpu=0",
+			},
+		},
+		{
+			description: "default prefix",
+			annotations: map[string]string{
+				"cdi.k8s.io/nvidia": "nvidia.com/gpu=0,nvidia.com/gpu=1",
+			},
+			expectedDevices: []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1"},
+		},
+		{
+			description: "additional prefix",
+			annotations: map[string]string{
+				"devices.nvidia.com/request": "nvidia.com/gpu=0",
+			},
+			expectedDevices: []string{"nvidia.com/gpu=0"},
+		},
+		{
+			description: "devices from multiple annotations with the same prefix are combined",
+			annotations: map[string]string{
+				"devices.nvidia.com/b": "nvidia.com/gpu=1",
+				"devices.nvidia.com/a": "nvidia.com/gpu=0",
+			},
+			expectedDevices: []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1"},
+		},
+		{
+			description: "default prefix takes precedence",
+			annotations: map[string]string{
+				"cdi.k8s.io/nvidia":          "nvidia.com/gpu=0",
+				"devices.nvidia.com/request": "nvidia.com/gpu=1",
+			},
+			expectedDevices: []string{"nvidia.com/gpu=0"},
+			expectedIgnored: []string{"devices.nvidia.com/"},
+		},
+		{
+			description: "additional prefixes take precedence in order",
+			annotations: map[string]string{
+				"example.com/request":        "nvidia.com/gpu=2",
+				"devices.nvidia.com/request": "nvidia.com/gpu=1",
+			},
+			expectedDevices: []string{"nvidia.com/gpu=1"},
+			expectedIgnored: []string{"example.com/"},
+		},
+		{
+			description: "unqualified device is an error",
+			annotations: map[string]string{
+				"devices.nvidia.com/request": "0",
+			},
+			expectedError: true,
+		},
+		{
+			description: "unqualified device with ignored prefix is an error",
+			annotations: map[string]string{
+				"cdi.k8s.io/nvidia":          "nvidia.com/gpu=0",
+				"devices.nvidia.com/request": "0",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			devices, ignored, err := parseCDIAnnotations(tc.annotations, prefixes)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedDevices, devices)
+			require.EqualValues(t, tc.expectedIgnored, ignored)
+		})
+	}
+}
+
+func TestGetDevicesFromSpecWithAnnotationPrefixes(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.AnnotationPrefixes = []string{"devices.nvidia.com/"}
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+		},
+		Annotations: map[string]string{
+			"devices.nvidia.com/request": "nvidia.com/gpu=1",
+		},
+	}
+
+	devices, err := getDevicesFromSpec(logger, oci.NewMemorySpec(spec), cfg)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"nvidia.com/gpu=1"}, devices)
+}
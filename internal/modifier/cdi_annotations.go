@@ -0,0 +1,107 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// cdiAnnotationPluginName and cdiAnnotationDeviceID define the key of the
+	// annotation used to request CDI devices (cdi.k8s.io/nvidia-container-runtime_requested).
+	cdiAnnotationPluginName = "nvidia-container-runtime"
+	cdiAnnotationDeviceID   = "requested"
+
+	gdsCDIDevice   = "nvidia.com/gds=all"
+	mofedCDIDevice = "nvidia.com/mofed=all"
+)
+
+type cdiAnnotationsModifier struct {
+	logger  *logrus.Logger
+	devices []string
+}
+
+// NewCDIAnnotationsModifier creates an OCI spec modifier that translates the devices requested
+// using the NVIDIA_VISIBLE_DEVICES environment variable into cdi.k8s.io/ annotations instead of
+// injecting the devices. A downstream CDI-aware component is responsible for resolving these
+// annotations and performing the injection. Requests for GDS and MOFED devices are also
+// translated to the corresponding CDI devices.
+func NewCDIAnnotationsModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	prefixes, err := getAnnotationPrefixes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	annotationDevices, _, err := parseCDIAnnotations(rawSpec.Annotations, prefixes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse container annotations: %v", err)
+	}
+	if len(annotationDevices) > 0 {
+		logger.Debugf("Devices already requested using CDI annotations: %v", annotationDevices)
+		return nil, nil
+	}
+
+	devices, err := getDevicesFromSpec(logger, ociSpec, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get required devices from OCI specification: %v", err)
+	}
+	if len(devices) == 0 {
+		logger.Debugf("No devices requested; no modification required.")
+		return nil, nil
+	}
+
+	cudaImage, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	if cudaImage[nvidiaGDSEnvvar] == "enabled" {
+		devices = append(devices, gdsCDIDevice)
+	}
+	if cudaImage[nvidiaMOFEDEnvvar] == "enabled" {
+		devices = append(devices, mofedCDIDevice)
+	}
+
+	m := cdiAnnotationsModifier{
+		logger:  logger,
+		devices: devices,
+	}
+
+	return m, nil
+}
+
+// Modify adds the CDI annotations for the requested devices to the OCI runtime specification.
+func (m cdiAnnotationsModifier) Modify(spec *specs.Spec) error {
+	m.logger.Debugf("Requesting devices using CDI annotations: %v", m.devices)
+	annotations, err := cdi.UpdateAnnotations(spec.Annotations, cdiAnnotationPluginName, cdiAnnotationDeviceID, m.devices)
+	if err != nil {
+		return fmt.Errorf("failed to add CDI annotations: %v", err)
+	}
+	spec.Annotations = annotations
+
+	return nil
+}
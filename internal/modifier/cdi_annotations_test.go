@@ -0,0 +1,105 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCDIAnnotationsModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{
+		AcceptEnvvarUnprivileged:     true,
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+
+	testCases := []struct {
+		description         string
+		spec                *specs.Spec
+		expectedModifier    bool
+		expectedAnnotations map[string]string
+	}{
+		{
+			description: "no devices requested returns nil",
+			spec: &specs.Spec{
+				Process: &specs.Process{},
+			},
+		},
+		{
+			description: "existing CDI annotations returns nil",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+				Annotations: map[string]string{
+					"cdi.k8s.io/engine": "nvidia.com/gpu=0",
+				},
+			},
+		},
+		{
+			description: "visible devices are translated to annotations",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=0,nvidia.com/gpu=1"},
+				},
+			},
+			expectedModifier: true,
+			expectedAnnotations: map[string]string{
+				"cdi.k8s.io/nvidia-container-runtime_requested": "nvidia.com/gpu=0,nvidia.com/gpu=1",
+			},
+		},
+		{
+			description: "GDS and MOFED requests are translated to annotations",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_GDS=enabled", "NVIDIA_MOFED=enabled"},
+				},
+				Annotations: map[string]string{
+					"other": "annotation",
+				},
+			},
+			expectedModifier: true,
+			expectedAnnotations: map[string]string{
+				"other": "annotation",
+				"cdi.k8s.io/nvidia-container-runtime_requested": "nvidia.com/gpu=all,nvidia.com/gds=all,nvidia.com/mofed=all",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m, err := NewCDIAnnotationsModifier(logger, cfg, oci.NewMemorySpec(tc.spec))
+			require.NoError(t, err)
+			if !tc.expectedModifier {
+				require.Nil(t, m)
+				return
+			}
+			require.NotNil(t, m)
+
+			err = m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedAnnotations, tc.spec.Annotations)
+		})
+	}
+}
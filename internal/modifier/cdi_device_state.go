@@ -0,0 +1,53 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// checkUnavailableDevices checks the device nodes in the OCI runtime specification against the GPUs
+// that are recorded as removed in the device state. If the device state cannot be loaded, a warning
+// is logged and the devices are assumed to be available.
+func checkUnavailableDevices(logger *logrus.Logger, store *devicestate.Store, spec *specs.Spec) error {
+	if store == nil || spec == nil || spec.Linux == nil {
+		return nil
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		logger.Warningf("Ignoring device state: %v", err)
+		return nil
+	}
+
+	for _, d := range spec.Linux.Devices {
+		removed := state.ForDeviceNode(d.Path)
+		if removed == nil {
+			continue
+		}
+		logger.WithField(events.Field, events.CDIDeviceUnavailable).Warningf("Refusing to inject device node %v of removed GPU %v", d.Path, removed.BusID)
+		return oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("GPU %v (%v) was removed at %v", removed.UUID, removed.BusID, removed.RemovedAt.Format(time.RFC3339)))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/devicestate"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnavailableDevices(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	store := devicestate.NewStore(filepath.Join(t.TempDir(), "device-state.json"))
+	err := store.Update(func(s *devicestate.State) error {
+		s.MarkUnavailable(devicestate.Device{
+			UUID:       "GPU-1",
+			BusID:      "0000:3b:00.0",
+			DeviceNode: "/dev/nvidia1",
+			RemovedAt:  time.Now(),
+		})
+		return nil
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		description   string
+		devices       []string
+		expectedError bool
+	}{
+		{
+			description: "available devices",
+			devices:     []string{"/dev/nvidiactl", "/dev/nvidia0"},
+		},
+		{
+			description:   "removed device",
+			devices:       []string{"/dev/nvidiactl", "/dev/nvidia0", "/dev/nvidia1"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spec := &specs.Spec{
+				Linux: &specs.Linux{},
+			}
+			for _, path := range tc.devices {
+				spec.Linux.Devices = append(spec.Linux.Devices, specs.LinuxDevice{Path: path})
+			}
+
+			err := checkUnavailableDevices(logger, store, spec)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindUnsupportedRequest, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	// A missing state file means that all devices are available.
+	missing := devicestate.NewStore(filepath.Join(t.TempDir(), "missing.json"))
+	spec := &specs.Spec{Linux: &specs.Linux{Devices: []specs.LinuxDevice{{Path: "/dev/nvidia1"}}}}
+	require.NoError(t, checkUnavailableDevices(logger, missing, spec))
+}
@@ -0,0 +1,138 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	cdispecs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// deviceWait defines how long to wait for the device nodes of requested devices to be created.
+// This allows containers that are started during node bring-up (e.g. before the driver has
+// created all device nodes) to rely only on static CDI specifications and the presence of the
+// device nodes on the host instead of failing.
+type deviceWait struct {
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// getDeviceWait returns the device wait options from the config.
+func getDeviceWait(cfg *config.Config) (deviceWait, error) {
+	c := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DeviceWait
+
+	var w deviceWait
+	if c.Timeout == "" {
+		return w, nil
+	}
+
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return w, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid device-wait.timeout %q: %v", c.Timeout, err))
+	}
+	interval := 250 * time.Millisecond
+	if c.Interval != "" {
+		interval, err = time.ParseDuration(c.Interval)
+		if err != nil {
+			return w, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid device-wait.interval %q: %v", c.Interval, err))
+		}
+	}
+	if interval <= 0 {
+		return w, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid device-wait.interval %q: must be positive", c.Interval))
+	}
+
+	w.timeout = timeout
+	w.interval = interval
+	return w, nil
+}
+
+// cdiDeviceDB is used to look up CDI devices by their fully-qualified names.
+type cdiDeviceDB interface {
+	GetDevice(string) *cdi.Device
+}
+
+// getCDIDeviceNodePaths returns the host paths of the device nodes that are required by the
+// specified devices. This includes the device nodes defined for the specs of the devices.
+// Devices that are not found in the device DB are ignored.
+func getCDIDeviceNodePaths(db cdiDeviceDB, devices []string) []string {
+	var nodes []*cdispecs.DeviceNode
+	seenSpecs := make(map[*cdi.Spec]bool)
+	for _, name := range devices {
+		d := db.GetDevice(name)
+		if d == nil {
+			continue
+		}
+		nodes = append(nodes, d.ContainerEdits.DeviceNodes...)
+		if spec := d.GetSpec(); spec != nil && !seenSpecs[spec] {
+			seenSpecs[spec] = true
+			nodes = append(nodes, spec.ContainerEdits.DeviceNodes...)
+		}
+	}
+
+	return getDeviceNodePaths(nodes)
+}
+
+// getDeviceNodePaths returns the unique host paths of the specified device nodes.
+func getDeviceNodePaths(nodes []*cdispecs.DeviceNode) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		path := n.HostPath
+		if path == "" {
+			path = n.Path
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// wait waits until all the specified paths exist or the timeout expires.
+func (w deviceWait) wait(logger *logrus.Logger, paths []string) error {
+	deadline := time.Now().Add(w.timeout)
+	for {
+		missing := getMissingPaths(paths)
+		if len(missing) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("device nodes %v do not exist after %v", missing, w.timeout)
+		}
+		logger.Debugf("Waiting for device nodes %v", missing)
+		time.Sleep(w.interval)
+	}
+}
+
+// getMissingPaths returns the paths that do not exist.
+func getMissingPaths(paths []string) []string {
+	var missing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,128 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeviceWait(t *testing.T) {
+	testCases := []struct {
+		description   string
+		timeout       string
+		interval      string
+		expected      deviceWait
+		expectedError bool
+	}{
+		{
+			description: "disabled by default",
+			interval:    "250ms",
+		},
+		{
+			description: "timeout and interval",
+			timeout:     "30s",
+			interval:    "1s",
+			expected:    deviceWait{timeout: 30 * time.Second, interval: time.Second},
+		},
+		{
+			description: "default interval",
+			timeout:     "5s",
+			expected:    deviceWait{timeout: 5 * time.Second, interval: 250 * time.Millisecond},
+		},
+		{
+			description:   "invalid timeout",
+			timeout:       "30",
+			expectedError: true,
+		},
+		{
+			description:   "invalid interval",
+			timeout:       "30s",
+			interval:      "0s",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DeviceWait.Timeout = tc.timeout
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DeviceWait.Interval = tc.interval
+
+			w, err := getDeviceWait(cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, w)
+		})
+	}
+}
+
+func TestDeviceWait(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	devDir := t.TempDir()
+	nvidiactl := filepath.Join(devDir, "nvidiactl")
+	nvidia0 := filepath.Join(devDir, "nvidia0")
+
+	specDir := t.TempDir()
+	cdiSpec := fmt.Sprintf(`
+cdiVersion: "0.5.0"
+kind: nvidia.com/gpu
+devices:
+- name: "0"
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+      hostPath: %v
+containerEdits:
+  deviceNodes:
+  - path: %v
+`, nvidia0, nvidiactl)
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "gpu.yaml"), []byte(cdiSpec), 0644))
+
+	registry := cdi.GetRegistry(
+		cdi.WithSpecDirs(specDir),
+		cdi.WithAutoRefresh(false),
+	)
+	require.NoError(t, registry.Refresh())
+
+	paths := getCDIDeviceNodePaths(registry.DeviceDB(), []string{"nvidia.com/gpu=0", "nvidia.com/gpu=missing"})
+	require.ElementsMatch(t, []string{nvidia0, nvidiactl}, paths)
+
+	w := deviceWait{timeout: 50 * time.Millisecond, interval: 10 * time.Millisecond}
+	require.Error(t, w.wait(logger, paths))
+
+	require.NoError(t, os.WriteFile(nvidiactl, nil, 0644))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = os.WriteFile(nvidia0, nil, 0644)
+	}()
+
+	w = deviceWait{timeout: 5 * time.Second, interval: 10 * time.Millisecond}
+	require.NoError(t, w.wait(logger, paths))
+}
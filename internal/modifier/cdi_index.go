@@ -0,0 +1,165 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// indexedDevices maps fully-qualified device names to the devices loaded using the CDI spec index.
+type indexedDevices map[string]*cdi.Device
+
+// GetDevice returns the device with the specified fully-qualified name.
+func (d indexedDevices) GetDevice(name string) *cdi.Device {
+	return d[name]
+}
+
+// injectFromIndex injects the requested devices by loading only the CDI specs that define these
+// according to the CDI spec index. If the index cannot be used (e.g. because it is out of date or
+// a device is not included), false is returned and the devices should be injected using the CDI
+// registry instead.
+func (m cdiModifier) injectFromIndex(spec *specs.Spec) (bool, error) {
+	refreshed := m.recorder.Track(latency.PhaseCDIRefresh)
+	devices, specErrors, ok := m.loadIndexedDevices()
+	refreshed()
+	if err := oci.CheckContext(m.ctx); err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+	if err := specErrors.check(m.logger, m.strict); err != nil {
+		return false, err
+	}
+
+	if m.deviceWait.timeout > 0 {
+		err := m.deviceWait.wait(m.logger, getCDIDeviceNodePaths(devices, m.devices))
+		if err != nil {
+			return false, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("failed to inject CDI devices: %v", err))
+		}
+	}
+
+	m.logger.WithField(events.Field, events.CDIInject).Debugf("Injecting devices using CDI spec index %v: %v", m.indexFile, m.devices)
+	m.logDeviceEdits(devices)
+	applied := make(map[*cdi.Spec]bool)
+	for _, name := range m.devices {
+		device := devices[name]
+		if deviceSpec := device.GetSpec(); !applied[deviceSpec] {
+			applied[deviceSpec] = true
+			if err := deviceSpec.ApplyEdits(spec); err != nil {
+				return false, fmt.Errorf("failed to inject CDI devices: %v", err)
+			}
+		}
+		if err := device.ApplyEdits(spec); err != nil {
+			return false, fmt.Errorf("failed to inject CDI devices: %v", err)
+		}
+	}
+
+	return true, nil
+}
+
+// loadIndexedDevices loads the requested devices from the CDI specs that define these according to
+// the CDI spec index. The errors recorded in the index for specs that could not be loaded are also
+// returned. In strict mode, all specs in the index are validated and unresolved device conflicts are
+// included in the errors, as is the case when the CDI registry is used. If the index cannot be used,
+// false is returned.
+func (m cdiModifier) loadIndexedDevices() (indexedDevices, cdiSpecErrors, bool) {
+	index, err := cdiindex.Load(m.indexFile)
+	if err != nil {
+		m.logger.Debugf("Not using CDI spec index: %v", err)
+		return nil, nil, false
+	}
+	current, err := index.IsCurrent(m.specDirs)
+	if err != nil || !current {
+		m.logger.Debugf("Not using CDI spec index %v: index is out of date (%v)", m.indexFile, err)
+		return nil, nil, false
+	}
+
+	loaded := make(map[string]*cdi.Spec)
+	devices := make(indexedDevices)
+	for _, name := range m.devices {
+		path, ok := index.Lookup(name)
+		if !ok {
+			m.logger.Debugf("Not using CDI spec index %v: device %q is not indexed", m.indexFile, name)
+			return nil, nil, false
+		}
+		cdiSpec, ok := loaded[path]
+		if !ok {
+			cdiSpec, err = cdi.ReadSpec(path, 0)
+			if err != nil {
+				m.logger.Debugf("Not using CDI spec index %v: %v", m.indexFile, err)
+				return nil, nil, false
+			}
+			loaded[path] = cdiSpec
+		}
+		_, _, deviceName, err := cdi.ParseQualifiedName(name)
+		if err != nil {
+			return nil, nil, false
+		}
+		device := cdiSpec.GetDevice(deviceName)
+		if device == nil {
+			m.logger.Debugf("Not using CDI spec index %v: device %q is not defined in %v", m.indexFile, name, path)
+			return nil, nil, false
+		}
+		devices[name] = device
+	}
+
+	specErrors := make(cdiSpecErrors)
+	for _, s := range index.Specs {
+		if s.Error != "" {
+			specErrors[s.Path] = append(specErrors[s.Path], errors.New(s.Error))
+		}
+	}
+	if m.strict {
+		for path, err := range validateIndexedSpecs(index, loaded) {
+			specErrors[path] = append(specErrors[path], err...)
+		}
+	}
+
+	return devices, specErrors, true
+}
+
+// validateIndexedSpecs validates the specs in the specified index that were not already loaded and
+// returns the unresolved device conflicts between specs as errors.
+func validateIndexedSpecs(index *cdiindex.Index, loaded map[string]*cdi.Spec) cdiSpecErrors {
+	specErrors := make(cdiSpecErrors)
+	for _, s := range index.Specs {
+		if s.Error != "" || loaded[s.Path] != nil {
+			continue
+		}
+		if _, err := cdi.ReadSpec(s.Path, s.Priority); err != nil {
+			specErrors[s.Path] = append(specErrors[s.Path], err)
+		}
+	}
+	for _, c := range index.Conflicts {
+		if c.Type != cdiindex.ConflictDevice || c.Resolved {
+			continue
+		}
+		for _, path := range c.Specs {
+			specErrors[path] = append(specErrors[path], fmt.Errorf("conflicting device %q", c.Name))
+		}
+	}
+	return specErrors
+}
@@ -0,0 +1,144 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectFromIndex(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	spec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - DEVICE=gpu0
+containerEdits:
+  env:
+  - COMMON=1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(spec), 0644))
+
+	indexFile := filepath.Join(t.TempDir(), "cdi-index.json")
+	index, err := cdiindex.Build([]string{specDir})
+	require.NoError(t, err)
+	require.NoError(t, index.Save(indexFile))
+
+	testCases := []struct {
+		description      string
+		specDirs         []string
+		devices          []string
+		expectedInjected bool
+		expectedEnv      []string
+	}{
+		{
+			description:      "indexed device is injected",
+			specDirs:         []string{specDir},
+			devices:          []string{"nvidia.com/gpu=gpu0"},
+			expectedInjected: true,
+			expectedEnv:      []string{"COMMON=1", "DEVICE=gpu0"},
+		},
+		{
+			description: "device not in index falls back",
+			specDirs:    []string{specDir},
+			devices:     []string{"nvidia.com/gpu=gpu1"},
+		},
+		{
+			description: "index for other spec dirs falls back",
+			specDirs:    []string{specDir, t.TempDir()},
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := cdiModifier{
+				logger:    logger,
+				specDirs:  tc.specDirs,
+				indexFile: indexFile,
+				devices:   tc.devices,
+			}
+			spec := &specs.Spec{Process: &specs.Process{}}
+
+			injected, err := m.injectFromIndex(spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedInjected, injected)
+			require.ElementsMatch(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
+
+func TestInjectFromIndexStrict(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	spec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - DEVICE=gpu0
+`
+	// The specs of another vendor define the same device, which is an unresolved conflict.
+	otherSpec := `cdiVersion: 0.5.0
+kind: example.com/device
+devices:
+- name: dev0
+  containerEdits:
+    env:
+    - DEVICE=dev0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(spec), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "example-a.yaml"), []byte(otherSpec), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "example-b.yaml"), []byte(otherSpec), 0644))
+
+	indexFile := filepath.Join(t.TempDir(), "cdi-index.json")
+	index, err := cdiindex.Build([]string{specDir})
+	require.NoError(t, err)
+	require.NoError(t, index.Save(indexFile))
+
+	for _, strict := range []bool{false, true} {
+		m := cdiModifier{
+			logger:    logger,
+			specDirs:  []string{specDir},
+			indexFile: indexFile,
+			devices:   []string{"nvidia.com/gpu=gpu0"},
+			strict:    strict,
+		}
+
+		injected, err := m.injectFromIndex(&specs.Spec{Process: &specs.Process{}})
+		if strict {
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "example-a.yaml")
+			continue
+		}
+		require.NoError(t, err)
+		require.True(t, injected)
+	}
+}
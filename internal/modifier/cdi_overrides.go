@@ -0,0 +1,147 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/policy"
+	cdi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// getCDIOverrideDirs returns the override dirs that apply to a container with the specified
+// annotations. The tenant override dirs for the Kubernetes namespace of the container are applied
+// after the common override dirs.
+func getCDIOverrideDirs(cfg *config.Config, annotations map[string]string) []string {
+	cdiConfig := cfg.NVIDIAContainerRuntimeConfig.Modes.CDI
+
+	dirs := append([]string{}, cdiConfig.OverrideDirs...)
+	if namespace, ok := annotations[policy.NamespaceAnnotation]; ok {
+		dirs = append(dirs, cdiConfig.TenantOverrideDirs[namespace]...)
+	}
+	return dirs
+}
+
+// applyCDIOverrides applies the edits of the CDI specifications in the specified override dirs for
+// the requested devices. For each override spec of the same kind (vendor and class) as a requested
+// device, the spec edits and the edits of the device with the same name are applied. The override dirs
+// (and the specs in each dir) are applied in order so that environment variables and mounts from later
+// overrides replace those with the same name or container path. Override specs cannot add devices.
+func applyCDIOverrides(logger *logrus.Logger, dirs []string, devices []string, spec *specs.Spec) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	for _, dir := range dirs {
+		for _, overrideSpec := range readCDIOverrideSpecs(logger, dir) {
+			var applySpecEdits bool
+			for _, device := range devices {
+				vendor, class, name, err := cdi.ParseQualifiedName(device)
+				if err != nil {
+					continue
+				}
+				if vendor != overrideSpec.GetVendor() || class != overrideSpec.GetClass() {
+					continue
+				}
+				applySpecEdits = true
+
+				overrideDevice := overrideSpec.GetDevice(name)
+				if overrideDevice == nil {
+					continue
+				}
+				logger.Debugf("Applying CDI override edits for %v from %v", device, overrideSpec.GetPath())
+				if err := overrideDevice.ApplyEdits(spec); err != nil {
+					return fmt.Errorf("failed to apply CDI override edits for %v from %v: %v", device, overrideSpec.GetPath(), err)
+				}
+			}
+			if !applySpecEdits {
+				continue
+			}
+			logger.Debugf("Applying CDI override spec edits from %v", overrideSpec.GetPath())
+			if err := overrideSpec.ApplyEdits(spec); err != nil {
+				return fmt.Errorf("failed to apply CDI override spec edits from %v: %v", overrideSpec.GetPath(), err)
+			}
+		}
+	}
+	if spec.Process != nil {
+		spec.Process.Env = dedupeEnv(spec.Process.Env)
+	}
+	return nil
+}
+
+// dedupeEnv removes duplicate environment variables. The last value for each name is kept at the
+// position of the first occurrence of the name.
+func dedupeEnv(env []string) []string {
+	var names []string
+	values := make(map[string]string)
+	for _, e := range env {
+		name := strings.SplitN(e, "=", 2)[0]
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = e
+	}
+
+	var deduped []string
+	for _, name := range names {
+		deduped = append(deduped, values[name])
+	}
+	return deduped
+}
+
+// readCDIOverrideSpecs reads the CDI specifications in the specified override dir in lexical order.
+// Since an override dir may not exist for all tenants, a missing dir is ignored. Invalid specs are
+// logged and skipped.
+func readCDIOverrideSpecs(logger *logrus.Logger, dir string) []*cdi.Spec {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		logger.Warningf("Ignoring CDI override dir %v: %v", dir, err)
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	var overrideSpecs []*cdi.Spec
+	for _, path := range paths {
+		overrideSpec, err := cdi.ReadSpec(path, 0)
+		if err != nil {
+			logger.Warningf("Ignoring CDI override spec %v: %v", path, err)
+			continue
+		}
+		overrideSpecs = append(overrideSpecs, overrideSpec)
+	}
+	return overrideSpecs
+}
@@ -0,0 +1,173 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCDIOverrideDirs(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.OverrideDirs = []string{"/etc/cdi-overrides/site"}
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.TenantOverrideDirs = map[string][]string{
+		"training": {"/etc/cdi-overrides/training"},
+	}
+
+	testCases := []struct {
+		description  string
+		annotations  map[string]string
+		expectedDirs []string
+	}{
+		{
+			description:  "no namespace uses common dirs",
+			expectedDirs: []string{"/etc/cdi-overrides/site"},
+		},
+		{
+			description:  "tenant dirs are applied after common dirs",
+			annotations:  map[string]string{"io.kubernetes.pod.namespace": "training"},
+			expectedDirs: []string{"/etc/cdi-overrides/site", "/etc/cdi-overrides/training"},
+		},
+		{
+			description:  "unknown namespace uses common dirs",
+			annotations:  map[string]string{"io.kubernetes.pod.namespace": "inference"},
+			expectedDirs: []string{"/etc/cdi-overrides/site"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedDirs, getCDIOverrideDirs(cfg, tc.annotations))
+		})
+	}
+}
+
+func TestApplyCDIOverrides(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	siteDir := t.TempDir()
+	site := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - SITE=gpu0
+    mounts:
+    - hostPath: /site/gpu0
+      containerPath: /opt/site
+- name: gpu1
+  containerEdits:
+    env:
+    - SITE=gpu1
+containerEdits:
+  env:
+  - LAYER=site
+`
+	require.NoError(t, os.WriteFile(filepath.Join(siteDir, "site.yaml"), []byte(site), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(siteDir, "README"), []byte("not a spec"), 0644))
+
+	tenantDir := t.TempDir()
+	tenant := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    mounts:
+    - hostPath: /tenant/gpu0
+      containerPath: /opt/site
+containerEdits:
+  env:
+  - LAYER=tenant
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tenantDir, "tenant.yaml"), []byte(tenant), 0644))
+
+	otherDir := t.TempDir()
+	other := `cdiVersion: 0.5.0
+kind: example.com/nic
+devices:
+- name: nic0
+  containerEdits:
+    env:
+    - NIC=nic0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "other.yaml"), []byte(other), 0644))
+
+	testCases := []struct {
+		description    string
+		dirs           []string
+		devices        []string
+		expectedEnv    []string
+		expectedMounts []specs.Mount
+	}{
+		{
+			description: "no override dirs",
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+		},
+		{
+			description: "missing override dir is ignored",
+			dirs:        []string{filepath.Join(siteDir, "missing")},
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+		},
+		{
+			description: "site override is applied",
+			dirs:        []string{siteDir},
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+			expectedEnv: []string{"SITE=gpu0", "LAYER=site"},
+			expectedMounts: []specs.Mount{
+				{Source: "/site/gpu0", Destination: "/opt/site"},
+			},
+		},
+		{
+			description: "later override replaces env and mounts",
+			dirs:        []string{siteDir, tenantDir},
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+			expectedEnv: []string{"SITE=gpu0", "LAYER=tenant"},
+			expectedMounts: []specs.Mount{
+				{Source: "/tenant/gpu0", Destination: "/opt/site"},
+			},
+		},
+		{
+			description: "spec edits apply to other devices of the same kind",
+			dirs:        []string{tenantDir},
+			devices:     []string{"nvidia.com/gpu=gpu1"},
+			expectedEnv: []string{"LAYER=tenant"},
+		},
+		{
+			description: "overrides for other kinds are not applied",
+			dirs:        []string{otherDir},
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spec := &specs.Spec{Process: &specs.Process{}}
+
+			err := applyCDIOverrides(logger, tc.dirs, tc.devices, spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEnv, spec.Process.Env)
+			require.Equal(t, tc.expectedMounts, spec.Mounts)
+		})
+	}
+}
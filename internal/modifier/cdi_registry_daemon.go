@@ -0,0 +1,83 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiregistry"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// injectFromDaemon injects the requested devices using the edits returned by the CDI registry daemon
+// listening on the registry socket. If the daemon is not available, false is returned and the devices
+// should be injected by loading the CDI specs instead. Requests that are rejected by the daemon (e.g.
+// due to invalid specs in strict mode) fail.
+func (m cdiModifier) injectFromDaemon(spec *specs.Spec) (bool, error) {
+	refreshed := m.recorder.Track(latency.PhaseCDIRefresh)
+	response, err := cdiregistry.Query(m.registrySocket, m.devices, cdiregistry.DefaultTimeout)
+	refreshed()
+	if err := oci.CheckContext(m.ctx); err != nil {
+		return false, err
+	}
+	if err != nil {
+		m.logger.Debugf("Not using CDI registry daemon at %v: %v", m.registrySocket, err)
+		return false, nil
+	}
+	if response.Error != "" {
+		return false, oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("CDI registry daemon rejected request: %v", response.Error))
+	}
+
+	specErrors := make(cdiSpecErrors)
+	for path, messages := range response.SpecErrors {
+		for _, message := range messages {
+			specErrors[path] = append(specErrors[path], errors.New(message))
+		}
+	}
+	if err := specErrors.check(m.logger, m.strict); err != nil {
+		return false, err
+	}
+
+	if len(response.Unresolved) > 0 {
+		return false, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("failed to inject CDI devices: unresolvable CDI devices %v", strings.Join(response.Unresolved, ", ")))
+	}
+	if response.Edits == nil {
+		return true, nil
+	}
+
+	if m.deviceWait.timeout > 0 {
+		err := m.deviceWait.wait(m.logger, getDeviceNodePaths(response.Edits.DeviceNodes))
+		if err != nil {
+			return false, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("failed to inject CDI devices: %v", err))
+		}
+	}
+
+	m.logger.WithField(events.Field, events.CDIInject).Debugf("Injecting devices using CDI registry daemon at %v: %v", m.registrySocket, m.devices)
+	m.logEdits("combined", strings.Join(m.devices, ", "), response.Edits)
+	edits := cdi.ContainerEdits{ContainerEdits: response.Edits}
+	if err := edits.Apply(spec); err != nil {
+		return false, fmt.Errorf("failed to inject CDI devices: %v", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,119 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiregistry"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdispecdirs"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectFromDaemon(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	daemonSpecDir := t.TempDir()
+	daemonSpec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - SOURCE=daemon
+`
+	require.NoError(t, os.WriteFile(filepath.Join(daemonSpecDir, "nvidia.yaml"), []byte(daemonSpec), 0644))
+
+	// The spec dir of the modifier differs from that of the daemon so that the source of the edits
+	// can be determined.
+	localSpecDir := t.TempDir()
+	localSpec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - SOURCE=local
+`
+	require.NoError(t, os.WriteFile(filepath.Join(localSpecDir, "nvidia.yaml"), []byte(localSpec), 0644))
+
+	socket := filepath.Join(t.TempDir(), "cdi-registry.sock")
+	listener, err := cdiregistry.Listen(socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	validator, err := cdispecdirs.NewValidator(logger, &config.Config{})
+	require.NoError(t, err)
+	server, err := cdiregistry.NewServer(logger, validator, []string{daemonSpecDir}, false)
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	testCases := []struct {
+		description   string
+		socket        string
+		devices       []string
+		expectedEnv   []string
+		expectedError bool
+	}{
+		{
+			description: "edits are queried from the daemon",
+			socket:      socket,
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+			expectedEnv: []string{"SOURCE=daemon"},
+		},
+		{
+			description: "specs are loaded if the daemon is not available",
+			socket:      filepath.Join(t.TempDir(), "missing.sock"),
+			devices:     []string{"nvidia.com/gpu=gpu0"},
+			expectedEnv: []string{"SOURCE=local"},
+		},
+		{
+			description:   "unresolved device fails",
+			socket:        socket,
+			devices:       []string{"nvidia.com/gpu=gpu1"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := cdiModifier{
+				logger:         logger,
+				specDirs:       []string{localSpecDir},
+				registrySocket: tc.socket,
+				devices:        tc.devices,
+			}
+			spec := &specs.Spec{Process: &specs.Process{}}
+
+			err := m.inject(spec)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindUnsupportedRequest, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+)
+
+// cdiSpecErrors maps the paths of CDI specifications to the errors encountered while loading these.
+type cdiSpecErrors map[string][]error
+
+// check logs the errors for each CDI specification. In strict mode, these are logged as errors and an
+// error enumerating the invalid specifications is returned. Otherwise the errors are only logged at
+// debug level, since the devices defined in other specifications remain available.
+func (e cdiSpecErrors) check(logger *logrus.Logger, strict bool) error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var invalid []string
+	for _, path := range paths {
+		var messages []string
+		for _, err := range e[path] {
+			messages = append(messages, err.Error())
+		}
+		entry := logger.WithField(events.Field, events.CDIRefreshFailed).WithField("spec", path)
+		if strict {
+			entry.Errorf("Invalid CDI specification: %v", strings.Join(messages, "; "))
+		} else {
+			entry.Debugf("Ignoring invalid CDI specification: %v", strings.Join(messages, "; "))
+		}
+		invalid = append(invalid, fmt.Sprintf("%v (%v)", path, strings.Join(messages, "; ")))
+	}
+
+	if !strict {
+		return nil
+	}
+	return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("invalid CDI specifications in strict mode: %v", strings.Join(invalid, ", ")))
+}
@@ -0,0 +1,101 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/cdiindex"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectStrict(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	spec := `cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: gpu0
+  containerEdits:
+    env:
+    - DEVICE=gpu0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "nvidia.yaml"), []byte(spec), 0644))
+	brokenSpec := filepath.Join(specDir, "broken.yaml")
+	require.NoError(t, os.WriteFile(brokenSpec, []byte("cdiVersion: 0.5.0\nkind: [\n"), 0644))
+
+	indexFile := filepath.Join(t.TempDir(), "cdi-index.json")
+	index, err := cdiindex.Build([]string{specDir})
+	require.NoError(t, err)
+	require.NoError(t, index.Save(indexFile))
+
+	testCases := []struct {
+		description string
+		indexFile   string
+		strict      bool
+		expectedEnv []string
+	}{
+		{
+			description: "invalid spec is ignored",
+			expectedEnv: []string{"DEVICE=gpu0"},
+		},
+		{
+			description: "invalid spec fails in strict mode",
+			strict:      true,
+		},
+		{
+			description: "invalid indexed spec is ignored",
+			indexFile:   indexFile,
+			expectedEnv: []string{"DEVICE=gpu0"},
+		},
+		{
+			description: "invalid indexed spec fails in strict mode",
+			indexFile:   indexFile,
+			strict:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := cdiModifier{
+				logger:    logger,
+				specDirs:  []string{specDir},
+				indexFile: tc.indexFile,
+				strict:    tc.strict,
+				devices:   []string{"nvidia.com/gpu=gpu0"},
+			}
+			spec := &specs.Spec{Process: &specs.Process{}}
+
+			err := m.inject(spec)
+			if tc.strict {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindDiscovery, oci.GetErrorKind(err))
+				require.Contains(t, err.Error(), brokenSpec)
+				require.Empty(t, spec.Process.Env)
+				return
+			}
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
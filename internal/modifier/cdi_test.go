@@ -0,0 +1,229 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/driverroot"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDevicesFromSpecWithDefaultKinds(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	cdiSpec := `
+cdiVersion: "0.5.0"
+kind: nvidia.com/mig
+devices:
+- name: "1"
+  containerEdits:
+    env:
+    - MIG=1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "mig.yaml"), []byte(cdiSpec), 0644))
+
+	testCases := []struct {
+		description     string
+		defaultKinds    []string
+		visibleDevices  string
+		expectedDevices []string
+	}{
+		{
+			description:     "single kind is used for all devices",
+			defaultKinds:    []string{"nvidia.com/gpu"},
+			visibleDevices:  "0,1",
+			expectedDevices: []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1"},
+		},
+		{
+			description:     "existing device is resolved against later kind",
+			defaultKinds:    []string{"nvidia.com/gpu", "nvidia.com/mig"},
+			visibleDevices:  "0,1",
+			expectedDevices: []string{"nvidia.com/gpu=0", "nvidia.com/mig=1"},
+		},
+		{
+			description:     "qualified names are not modified",
+			defaultKinds:    []string{"nvidia.com/gpu", "nvidia.com/mig"},
+			visibleDevices:  "nvidia.com/gpu=1",
+			expectedDevices: []string{"nvidia.com/gpu=1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				AcceptEnvvarUnprivileged:     true,
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{specDir}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.DefaultKind = tc.defaultKinds
+
+			spec := &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=" + tc.visibleDevices},
+				},
+			}
+
+			devices, err := getDevicesFromSpec(logger, oci.NewMemorySpec(spec), cfg)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedDevices, devices)
+		})
+	}
+}
+
+func TestQualifyBusID(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	require.NoError(t, driverroot.Create(root, driverroot.WithGPUs(2)))
+
+	specDir := t.TempDir()
+	cdiSpec := `
+cdiVersion: "0.5.0"
+kind: nvidia.com/gpu
+devices:
+- name: "1"
+  containerEdits:
+    env:
+    - GPU=1
+- name: "GPU-00000001-0000-0000-0000-000000000000"
+  containerEdits:
+    env:
+    - GPU=1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "gpu.yaml"), []byte(cdiSpec), 0644))
+
+	testCases := []struct {
+		description    string
+		busID          string
+		expectedDevice string
+		expectedError  bool
+	}{
+		{
+			description:    "index is used if no uuid device exists",
+			busID:          "0000:01:00.0",
+			expectedDevice: "nvidia.com/gpu=0",
+		},
+		{
+			description:    "uuid is preferred over index",
+			busID:          "0000:02:00.0",
+			expectedDevice: "nvidia.com/gpu=GPU-00000001-0000-0000-0000-000000000000",
+		},
+		{
+			description:   "unknown bus id is an error",
+			busID:         "0000:65:00.0",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := kindResolver{
+				logger:   logger,
+				kinds:    []string{"nvidia.com/gpu"},
+				specDirs: []string{specDir},
+				root:     root,
+			}
+
+			device, err := r.qualifyBusID(tc.busID)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDevice, device)
+		})
+	}
+}
+
+func TestCheckDeviceLimit(t *testing.T) {
+	driverRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(driverRoot, "dev"), 0755))
+	for _, name := range []string{"nvidia0", "nvidia1", "nvidia2", "nvidia3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(driverRoot, "dev", name), nil, 0644))
+	}
+
+	testCases := []struct {
+		description   string
+		annotations   map[string]string
+		devices       []string
+		expired       bool
+		expectedError bool
+		expectedKind  oci.ErrorKind
+	}{
+		{
+			description: "devices within limit",
+			devices:     []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1"},
+		},
+		{
+			description:   "all devices exceed limit",
+			devices:       []string{"nvidia.com/gpu=all"},
+			expectedError: true,
+			expectedKind:  oci.ErrorKindUnsupportedRequest,
+		},
+		{
+			description:   "expired context",
+			annotations:   map[string]string{"io.kubernetes.pod.namespace": "training"},
+			devices:       []string{"nvidia.com/gpu=all"},
+			expired:       true,
+			expectedError: true,
+			expectedKind:  oci.ErrorKindTimeout,
+		},
+		{
+			description: "namespace override allows all devices",
+			annotations: map[string]string{"io.kubernetes.pod.namespace": "training"},
+			devices:     []string{"nvidia.com/gpu=all"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerCLIConfig.Root = driverRoot
+			cfg.NVIDIAContainerRuntimeConfig.DeviceLimits.MaxDevicesPerContainer = 2
+			cfg.NVIDIAContainerRuntimeConfig.DeviceLimits.Namespaces = map[string]int{"training": 4}
+
+			ociSpec := oci.NewMemorySpec(&specs.Spec{Annotations: tc.annotations})
+
+			ctx := context.Background()
+			if tc.expired {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(-time.Second))
+				defer cancel()
+			}
+
+			err := checkDeviceLimit(ctx, cfg, ociSpec, tc.devices)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedKind, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
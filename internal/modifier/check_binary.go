@@ -0,0 +1,105 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checkBinaryName = "nvidia-ctk-check"
+	// checkBinaryContainerPath is the path at which the check binary is injected into containers.
+	checkBinaryContainerPath = "/usr/bin/" + checkBinaryName
+)
+
+// checkBinary injects the nvidia-ctk-check binary into a container.
+type checkBinary struct {
+	logger   *logrus.Logger
+	hostPath string
+}
+
+var _ oci.SpecModifier = (*checkBinary)(nil)
+
+// NewCheckBinaryModifier creates a modifier that injects the nvidia-ctk-check binary into containers
+// that request devices and the utility capability. The binary is located in the same directory as
+// the nvidia-ctk binary or, if this is not an absolute path, in the PATH. If the binary is not
+// installed, no modifier is returned.
+func NewCheckBinaryModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	cudaImage, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if devices := cudaImage.DevicesFromEnvvars(visibleDevicesEnvvar); len(devices.List()) == 0 {
+		return nil, nil
+	}
+	if !cudaImage.GetDriverCapabilities().Has(image.DriverCapabilityUtility) {
+		return nil, nil
+	}
+
+	candidate := checkBinaryName
+	if filepath.IsAbs(cfg.NVIDIACTKConfig.Path) {
+		candidate = filepath.Join(filepath.Dir(cfg.NVIDIACTKConfig.Path), checkBinaryName)
+	}
+	paths, err := lookup.NewExecutableLocator(logger, "").Locate(candidate)
+	if err != nil || len(paths) == 0 {
+		logger.Debugf("Not injecting %v: %v", checkBinaryName, err)
+		return nil, nil
+	}
+
+	m := checkBinary{
+		logger:   logger,
+		hostPath: paths[0],
+	}
+	return m, nil
+}
+
+// Modify adds a read-only bind mount for the check binary to the spec. Existing mounts at the
+// container path are not replaced.
+func (m checkBinary) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+	for _, mount := range spec.Mounts {
+		if mount.Destination == checkBinaryContainerPath {
+			m.logger.Debugf("Skipping injection of %v; a mount at %v already exists", m.hostPath, checkBinaryContainerPath)
+			return nil
+		}
+	}
+
+	m.logger.Infof("Injecting %v as %v", m.hostPath, checkBinaryContainerPath)
+	spec.Mounts = append(spec.Mounts, specs.Mount{
+		Source:      m.hostPath,
+		Destination: checkBinaryContainerPath,
+		Type:        "bind",
+		Options:     []string{"ro", "nosuid", "nodev", "bind"},
+	})
+	return nil
+}
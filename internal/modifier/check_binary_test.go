@@ -0,0 +1,107 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBinaryModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	installed := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(installed, checkBinaryName), nil, 0755))
+	notInstalled := t.TempDir()
+
+	testCases := []struct {
+		description    string
+		ctkDir         string
+		env            []string
+		mounts         []specs.Mount
+		expectedMounts []specs.Mount
+	}{
+		{
+			description: "binary is injected for utility capability",
+			ctkDir:      installed,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=compute,utility"},
+			expectedMounts: []specs.Mount{
+				{
+					Source:      filepath.Join(installed, checkBinaryName),
+					Destination: checkBinaryContainerPath,
+					Type:        "bind",
+					Options:     []string{"ro", "nosuid", "nodev", "bind"},
+				},
+			},
+		},
+		{
+			description: "binary is not injected without utility capability",
+			ctkDir:      installed,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=compute"},
+		},
+		{
+			description: "binary is not injected without devices",
+			ctkDir:      installed,
+			env:         []string{"NVIDIA_DRIVER_CAPABILITIES=utility"},
+		},
+		{
+			description: "binary is not injected if not installed",
+			ctkDir:      notInstalled,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=utility"},
+		},
+		{
+			description: "existing mount is not replaced",
+			ctkDir:      installed,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=utility"},
+			mounts: []specs.Mount{
+				{Source: "/other", Destination: checkBinaryContainerPath},
+			},
+			expectedMounts: []specs.Mount{
+				{Source: "/other", Destination: checkBinaryContainerPath},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIACTKConfig: config.CTKConfig{
+					Path: filepath.Join(tc.ctkDir, "nvidia-ctk"),
+				},
+			}
+
+			spec := &specs.Spec{
+				Process: &specs.Process{Env: tc.env},
+				Mounts:  tc.mounts,
+			}
+
+			m, err := NewCheckBinaryModifier(logger, cfg, oci.NewMemorySpec(spec))
+			require.NoError(t, err)
+			if m != nil {
+				require.NoError(t, m.Modify(spec))
+			}
+			require.Equal(t, tc.expectedMounts, spec.Mounts)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// checksumVerifier is a spec modifier that verifies the checksums of the sources of the mounts
+// in an OCI specification against a checksum manifest.
+type checksumVerifier struct {
+	logger         *logrus.Logger
+	manifest       *checksum.Manifest
+	failOnMismatch bool
+	// requireVerified indicates whether at least one of the mounts is required to be included in the
+	// manifest for the fail policy.
+	requireVerified bool
+}
+
+var _ oci.SpecModifier = (*checksumVerifier)(nil)
+
+// NewChecksumVerifier creates a modifier that verifies the injected files against the configured
+// checksum manifest. If no manifest is configured, no modifier is returned. With the fail policy,
+// the modifier fails closed if devices are requested but none of the injected files could be
+// verified (e.g. in legacy mode where the files are injected by the NVIDIA Container Runtime Hook).
+// Files that are relocated by a mount strategy are verified before these are relocated instead (see
+// withChecksumVerification).
+func NewChecksumVerifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	m, err := newChecksumVerifier(logger, cfg)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if relocatesMounts(cfg) {
+		return m, nil
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+	container, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	m.requireVerified = len(container.DevicesFromEnvvars(visibleDevicesEnvvar).List()) > 0
+	return m, nil
+}
+
+// newChecksumVerifier creates a verifier for the configured checksum manifest. If no manifest is
+// configured, or the manifest cannot be loaded with the warn policy, nil is returned.
+func newChecksumVerifier(logger *logrus.Logger, cfg *config.Config) (*checksumVerifier, error) {
+	verification := cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification
+	if verification.Manifest == "" {
+		return nil, nil
+	}
+
+	var failOnMismatch bool
+	switch verification.Policy {
+	case config.ChecksumPolicyWarn, "":
+	case config.ChecksumPolicyFail:
+		failOnMismatch = true
+	default:
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid checksum verification policy: %q", verification.Policy))
+	}
+
+	manifest, err := checksum.Load(verification.Manifest)
+	if err != nil {
+		err = fmt.Errorf("failed to load checksum manifest: %v", err)
+		if failOnMismatch {
+			return nil, oci.NewError(oci.ErrorKindConfig, err)
+		}
+		logger.WithField(events.Field, events.ChecksumMismatch).Warnf("Skipping checksum verification: %v", err)
+		return nil, nil
+	}
+
+	m := checksumVerifier{
+		logger:         logger,
+		manifest:       manifest,
+		failOnMismatch: failOnMismatch,
+	}
+	return &m, nil
+}
+
+// Modify verifies the sources of the mounts in the specified OCI specification. The specification is not modified.
+func (m *checksumVerifier) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	var sources []string
+	for _, mount := range spec.Mounts {
+		sources = append(sources, mount.Source)
+	}
+	return m.verify(sources, m.requireVerified)
+}
+
+// verify checks the specified files against the manifest. Files that are not included in the
+// manifest are skipped. With the fail policy, an error is returned for mismatches and, if
+// requireVerified is set, if none of the files are included in the manifest.
+func (m *checksumVerifier) verify(paths []string, requireVerified bool) error {
+	var included []string
+	for _, path := range paths {
+		if _, ok := m.manifest.Lookup(path); ok {
+			included = append(included, path)
+		}
+	}
+	if len(included) == 0 {
+		if !requireVerified {
+			return nil
+		}
+		m.logger.WithField(events.Field, events.ChecksumMismatch).Warnf("None of the injected files are included in the checksum manifest")
+		if m.failOnMismatch {
+			return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("checksum verification failed: none of the injected files could be verified"))
+		}
+		return nil
+	}
+
+	mismatches := m.manifest.Verify(included...)
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	for _, mismatch := range mismatches {
+		m.logger.WithField(events.Field, events.ChecksumMismatch).Warnf("Checksum verification failed for %v", mismatch)
+	}
+	if m.failOnMismatch {
+		return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("checksum verification failed for %d injected files", len(mismatches)))
+	}
+	return nil
+}
+
+// checksumDiscoverer verifies the mounts of the wrapped discoverer against a checksum manifest.
+type checksumDiscoverer struct {
+	discover.Discover
+	verifier *checksumVerifier
+}
+
+// withChecksumVerification wraps the specified discoverer so that the host paths of the discovered
+// mounts are verified against the configured checksum manifest. This is required if the mounts are
+// relocated by a mount strategy since the relocated files are not included in the manifest. If no
+// manifest is configured, or the mounts are not relocated, the discoverer is returned as is.
+func withChecksumVerification(logger *logrus.Logger, cfg *config.Config, d discover.Discover) (discover.Discover, error) {
+	if !relocatesMounts(cfg) {
+		return d, nil
+	}
+	verifier, err := newChecksumVerifier(logger, cfg)
+	if err != nil || verifier == nil {
+		return d, err
+	}
+	return checksumDiscoverer{Discover: d, verifier: verifier}, nil
+}
+
+// Mounts returns the mounts of the wrapped discoverer once their host paths have been verified. With
+// the fail policy, at least one of the mounts is required to be included in the manifest.
+func (d checksumDiscoverer) Mounts() ([]discover.Mount, error) {
+	mounts, err := d.Discover.Mounts()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, m := range mounts {
+		paths = append(paths, m.HostPath)
+	}
+	if err := d.verifier.verify(paths, len(paths) > 0); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// relocatesMounts checks whether the discovered driver files are relocated (i.e. injected from a
+// staged driver root or from a staging or copy directory) instead of being mounted from the host.
+func relocatesMounts(cfg *config.Config) bool {
+	switch cfg.NVIDIAContainerRuntimeConfig.MountStrategy {
+	case config.MountStrategyDriverRoot, config.MountStrategyCopy:
+		return true
+	}
+	stagedDriverRoot := cfg.NVIDIAContainerRuntimeConfig.StagedDriverRoot
+	if stagedDriverRoot == "" {
+		return false
+	}
+	_, err := discover.LoadStagedManifest(stagedDriverRoot)
+	return err == nil
+}
@@ -0,0 +1,252 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/checksum"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumVerifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	libcuda := filepath.Join(dir, "libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	manifest, err := checksum.New([]string{libcuda})
+	require.NoError(t, err)
+	manifestPath := filepath.Join(dir, "checksums.json")
+	require.NoError(t, manifest.Save(manifestPath))
+
+	spec := &specs.Spec{
+		Mounts: []specs.Mount{
+			{Source: libcuda, Destination: "/usr/lib64/libcuda.so.999.99"},
+			{Source: "/not/in/manifest", Destination: "/not/in/manifest"},
+		},
+	}
+
+	testCases := []struct {
+		description       string
+		manifest          string
+		policy            string
+		contents          string
+		expectNilModifier bool
+		expectedError     bool
+	}{
+		{
+			description:       "no manifest returns nil modifier",
+			expectNilModifier: true,
+		},
+		{
+			description:   "invalid policy is an error",
+			manifest:      manifestPath,
+			policy:        "ignore",
+			expectedError: true,
+		},
+		{
+			description:       "missing manifest with warn policy returns nil modifier",
+			manifest:          filepath.Join(dir, "missing.json"),
+			policy:            config.ChecksumPolicyWarn,
+			expectNilModifier: true,
+		},
+		{
+			description:   "missing manifest with fail policy is an error",
+			manifest:      filepath.Join(dir, "missing.json"),
+			policy:        config.ChecksumPolicyFail,
+			expectedError: true,
+		},
+		{
+			description: "matching checksums",
+			manifest:    manifestPath,
+			policy:      config.ChecksumPolicyFail,
+			contents:    "libcuda",
+		},
+		{
+			description: "mismatch with warn policy",
+			manifest:    manifestPath,
+			policy:      config.ChecksumPolicyWarn,
+			contents:    "tampered",
+		},
+		{
+			description:   "mismatch with fail policy",
+			manifest:      manifestPath,
+			policy:        config.ChecksumPolicyFail,
+			contents:      "tampered",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest = tc.manifest
+			if tc.policy != "" {
+				cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Policy = tc.policy
+			}
+
+			ociSpec := oci.NewMemorySpec(&specs.Spec{Process: &specs.Process{}})
+			m, err := NewChecksumVerifier(logger, cfg, ociSpec)
+			if tc.expectNilModifier {
+				require.NoError(t, err)
+				require.Nil(t, m)
+				return
+			}
+			if m == nil {
+				require.Error(t, err)
+				require.True(t, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NoError(t, os.WriteFile(libcuda, []byte(tc.contents), 0644))
+
+			err = m.Modify(spec)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChecksumVerifierRequiresVerifiedFiles(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	libcuda := filepath.Join(dir, "libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	manifest, err := checksum.New([]string{libcuda})
+	require.NoError(t, err)
+	manifestPath := filepath.Join(dir, "checksums.json")
+	require.NoError(t, manifest.Save(manifestPath))
+
+	testCases := []struct {
+		description   string
+		policy        string
+		env           []string
+		mounts        []specs.Mount
+		expectedError bool
+	}{
+		{
+			description: "no devices requested",
+			policy:      config.ChecksumPolicyFail,
+			mounts:      []specs.Mount{{Source: "/proc", Destination: "/proc"}},
+		},
+		{
+			description:   "devices requested and no files verified with fail policy",
+			policy:        config.ChecksumPolicyFail,
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=all"},
+			mounts:        []specs.Mount{{Source: "/proc", Destination: "/proc"}},
+			expectedError: true,
+		},
+		{
+			description: "devices requested and no files verified with warn policy",
+			policy:      config.ChecksumPolicyWarn,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all"},
+			mounts:      []specs.Mount{{Source: "/proc", Destination: "/proc"}},
+		},
+		{
+			description: "devices requested and files verified with fail policy",
+			policy:      config.ChecksumPolicyFail,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all"},
+			mounts:      []specs.Mount{{Source: libcuda, Destination: libcuda}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest = manifestPath
+			cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Policy = tc.policy
+
+			spec := &specs.Spec{
+				Process: &specs.Process{Env: tc.env},
+				Mounts:  tc.mounts,
+			}
+			m, err := NewChecksumVerifier(logger, cfg, oci.NewMemorySpec(spec))
+			require.NoError(t, err)
+
+			err = m.Modify(spec)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWithChecksumVerification(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	dir := t.TempDir()
+	libcuda := filepath.Join(dir, "libcuda.so.999.99")
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+
+	manifest, err := checksum.New([]string{libcuda})
+	require.NoError(t, err)
+	manifestPath := filepath.Join(dir, "checksums.json")
+	require.NoError(t, manifest.Save(manifestPath))
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Manifest = manifestPath
+	cfg.NVIDIAContainerRuntimeConfig.ChecksumVerification.Policy = config.ChecksumPolicyFail
+
+	mounts := &discover.DiscoverMock{
+		MountsFunc: func() ([]discover.Mount, error) {
+			return []discover.Mount{{HostPath: libcuda, Path: "/usr/lib64/libcuda.so.999.99"}}, nil
+		},
+	}
+
+	// The mounts are not verified by the discoverer if these are not relocated.
+	d, err := withChecksumVerification(logger, cfg, mounts)
+	require.NoError(t, err)
+	require.Equal(t, mounts, d)
+
+	cfg.NVIDIAContainerRuntimeConfig.MountStrategy = config.MountStrategyCopy
+	d, err = withChecksumVerification(logger, cfg, mounts)
+	require.NoError(t, err)
+	_, err = d.Mounts()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(libcuda, []byte("tampered"), 0644))
+	_, err = d.Mounts()
+	require.Error(t, err)
+
+	// The verification of the relocated mounts in the spec is not required.
+	m, err := NewChecksumVerifier(logger, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Modify(&specs.Spec{Mounts: []specs.Mount{{Source: filepath.Join(dir, "copy"), Destination: "/driver"}}}))
+}
@@ -24,8 +24,10 @@ import (
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/cuda"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover/csv"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/requirements"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/nvmlthrottle"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,8 +45,11 @@ const (
 )
 
 // NewCSVModifier creates a modifier that applies modications to an OCI spec if required by the runtime wrapper.
-// The modifications are defined by CSV MountSpecs.
-func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+// The modifications are defined by CSV MountSpecs. The files discovered by the specified graphics discoverer
+// (if not nil) are injected together with the files from the CSV MountSpecs so that the mount strategy is
+// applied to all files at once. For the driver-root and copy strategies, this ensures that a single driver
+// root is staged and mounted.
+func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec, graphics discover.Discover) (oci.SpecModifier, error) {
 	rawSpec, err := ociSpec.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
@@ -66,8 +71,8 @@ func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec)
 		NvidiaCTKPath: cfg.NVIDIACTKConfig.Path,
 	}
 
-	if err := checkRequirements(logger, image); err != nil {
-		return nil, fmt.Errorf("requirements not met: %v", err)
+	if err := checkRequirements(logger, cfg, image); err != nil {
+		return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("requirements not met: %v", err))
 	}
 
 	csvFiles, err := csv.GetFileList(cfg.NVIDIAContainerRuntimeConfig.Modes.CSV.MountSpecPath)
@@ -79,7 +84,9 @@ func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec)
 		csvFiles = csv.BaseFilesOnly(csvFiles)
 	}
 
-	csvDiscoverer, err := discover.NewFromCSVFiles(logger, csvFiles, config.DriverRoot)
+	csvDiscoverer, err := newDriverRootsDiscoverer(logger, cfg, func(root string) (discover.Discover, error) {
+		return discover.NewFromCSVFiles(logger, csvFiles, root)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CSV discoverer: %v", err)
 	}
@@ -100,8 +107,15 @@ func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec)
 		// The ldcacheUpdateHook is added last to ensure that the created symlinks are included
 		ldcacheUpdateHook,
 	)
+	if graphics != nil {
+		d = discover.Merge(d, graphics)
+	}
 
-	discoverModifier, err := NewModifierFromDiscoverer(logger, d)
+	mounts, err := withMountStrategy(logger, cfg, d)
+	if err != nil {
+		return nil, err
+	}
+	discoverModifier, err := NewModifierFromDiscoverer(logger, mounts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct modifier: %v", err)
 	}
@@ -114,34 +128,44 @@ func NewCSVModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec)
 	return modifiers, nil
 }
 
-func checkRequirements(logger *logrus.Logger, image image.CUDA) error {
-	if image.HasDisableRequire() {
-		// TODO: We could print the real value here instead
-		logger.Debugf("NVIDIA_DISABLE_REQUIRE=%v; skipping requirement checks", true)
-		return nil
-	}
-
-	imageRequirements, err := image.GetRequirements()
-	if err != nil {
-		//  TODO: Should we treat this as a failure, or just issue a warning?
-		return fmt.Errorf("failed to get image requirements: %v", err)
-	}
-
-	r := requirements.New(logger, imageRequirements)
+func checkRequirements(logger *logrus.Logger, cfg *config.Config, image image.CUDA) error {
+	return assertRequirements(logger, image, func() requirements.Properties {
+		var properties requirements.Properties
+
+		// Querying the CUDA version initializes the CUDA driver API and is subject to the NVML throttle.
+		slot := acquireNVMLSlot(logger, cfg)
+		defer slot.Release()
+
+		cudaVersion, err := cuda.Version()
+		if err != nil {
+			logger.Warnf("Failed to get CUDA version: %v", err)
+		} else {
+			properties.CUDA = cudaVersion
+		}
+
+		compteCapability, err := cuda.ComputeCapability(0)
+		if err != nil {
+			logger.Warnf("Failed to get CUDA Compute Capability: %v", err)
+		} else {
+			properties.Arch = compteCapability
+		}
+
+		return properties
+	})
+}
 
-	cudaVersion, err := cuda.Version()
+// acquireNVMLSlot waits for a slot of the NVML throttle if one is configured. If no slot can be
+// acquired, a warning is logged and nil is returned.
+func acquireNVMLSlot(logger *logrus.Logger, cfg *config.Config) *nvmlthrottle.Slot {
+	throttle, err := nvmlthrottle.NewFromConfig(&cfg.NVIDIAContainerRuntimeConfig)
 	if err != nil {
-		logger.Warnf("Failed to get CUDA version: %v", err)
-	} else {
-		r.AddVersionProperty(requirements.CUDA, cudaVersion)
+		logger.WithField(events.Field, events.NVMLThrottleBypassed).Warnf("Invalid nvml-throttle config: %v", err)
+		return nil
 	}
-
-	compteCapability, err := cuda.ComputeCapability(0)
+	slot, err := throttle.Acquire()
 	if err != nil {
-		logger.Warnf("Failed to get CUDA Compute Capability: %v", err)
-	} else {
-		r.AddVersionProperty(requirements.ARCH, compteCapability)
+		logger.WithField(events.Field, events.NVMLThrottleBypassed).Warnf("Failed to acquire NVML slot: %v", err)
+		return nil
 	}
-
-	return r.Assert()
+	return slot
 }
@@ -18,10 +18,13 @@ package modifier
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	testlog "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
@@ -78,7 +81,7 @@ func TestNewCSVModifier(t *testing.T) {
 				}
 			}
 
-			m, err := NewCSVModifier(logger, tc.cfg, spec)
+			m, err := NewCSVModifier(logger, tc.cfg, spec, nil)
 			if tc.expectedError != nil {
 				require.Error(t, err)
 			} else {
@@ -156,3 +159,68 @@ func TestCSVModifierRemovesHook(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVModifierWithGraphicsUsesSingleDriverRoot(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	hostRoot := t.TempDir()
+	libcuda := filepath.Join(hostRoot, "usr/lib/libcuda.so.1")
+	libglsi := filepath.Join(hostRoot, "usr/lib/libnvidia-glsi.so.520.61.05")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+	require.NoError(t, os.WriteFile(libglsi, []byte("libglsi"), 0644))
+
+	mountSpecPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(mountSpecPath, "drivers.csv"), []byte("lib, /usr/lib/libcuda.so.1\n"), 0644))
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerCLIConfig.Root = hostRoot
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CSV.MountSpecPath = mountSpecPath
+	cfg.NVIDIAContainerRuntimeConfig.MountStrategy = config.MountStrategyDriverRoot
+	cfg.NVIDIAContainerRuntimeConfig.DriverRootMount.StagingDir = filepath.Join(t.TempDir(), "staging")
+
+	graphics := &discover.DiscoverMock{
+		DevicesFunc: func() ([]discover.Device, error) {
+			return nil, nil
+		},
+		MountsFunc: func() ([]discover.Mount, error) {
+			mounts := []discover.Mount{
+				{
+					HostPath: libglsi,
+					Path:     "/usr/lib/libnvidia-glsi.so.520.61.05",
+				},
+			}
+			return mounts, nil
+		},
+		HooksFunc: func() ([]discover.Hook, error) {
+			return nil, nil
+		},
+	}
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{
+				"NVIDIA_VISIBLE_DEVICES=all",
+				"NVIDIA_DRIVER_CAPABILITIES=compute,graphics",
+			},
+		},
+	}
+
+	m, err := NewCSVModifier(logger, cfg, oci.NewMemorySpec(spec), graphics)
+	require.NoError(t, err)
+	require.NoError(t, m.Modify(spec))
+
+	var driverRootMounts []specs.Mount
+	for _, mount := range spec.Mounts {
+		if mount.Destination == "/usr/local/nvidia" {
+			driverRootMounts = append(driverRootMounts, mount)
+		}
+	}
+	require.Len(t, driverRootMounts, 1)
+
+	root := driverRootMounts[0].Source
+	require.FileExists(t, filepath.Join(root, "usr/lib/libcuda.so.1"))
+	require.FileExists(t, filepath.Join(root, "usr/lib/libnvidia-glsi.so.520.61.05"))
+}
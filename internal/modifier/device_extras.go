@@ -0,0 +1,270 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/proc"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	gpuDeviceNodePattern = regexp.MustCompile(`^/dev/nvidia([0-9]+)$`)
+	envvarNamePattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// deviceExtras applies the site-specific mounts and environment variables that are configured
+// for a device whenever that device is injected into a container.
+type deviceExtras struct {
+	logger  *logrus.Logger
+	root    string
+	devices map[string]config.DeviceConfig
+}
+
+var _ oci.SpecModifier = (*deviceExtras)(nil)
+
+// procGPU represents the information for a GPU as reported by the NVIDIA kernel module.
+type procGPU struct {
+	busID string
+	uuid  string
+	minor int
+}
+
+// NewDeviceExtrasModifier creates a modifier that adds the extra mounts and environment variables
+// configured for injected devices. If no devices are configured, no modifier is returned.
+func NewDeviceExtrasModifier(logger *logrus.Logger, cfg *config.Config) (oci.SpecModifier, error) {
+	if len(cfg.Devices) == 0 {
+		return nil, nil
+	}
+
+	if err := validateDeviceConfigs(cfg.Devices); err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, err)
+	}
+
+	m := deviceExtras{
+		logger:  logger,
+		root:    "/",
+		devices: cfg.Devices,
+	}
+	return m, nil
+}
+
+// validateDeviceConfigs checks that the device configs are keyed by device UUIDs and define valid
+// mounts and environment variables.
+func validateDeviceConfigs(devices map[string]config.DeviceConfig) error {
+	for uuid, d := range devices {
+		if !strings.HasPrefix(uuid, "GPU-") && !strings.HasPrefix(uuid, "MIG-") {
+			return fmt.Errorf("invalid device %q: devices must be specified by UUID", uuid)
+		}
+		for _, m := range d.ExtraMounts {
+			if !filepath.IsAbs(m.HostPath) {
+				return fmt.Errorf("invalid extra mount for device %v: host-path %q is not absolute", uuid, m.HostPath)
+			}
+			if m.ContainerPath != "" && !filepath.IsAbs(m.ContainerPath) {
+				return fmt.Errorf("invalid extra mount for device %v: container-path %q is not absolute", uuid, m.ContainerPath)
+			}
+			if filepath.Clean(m.GetContainerPath()) == "/" {
+				return fmt.Errorf("invalid extra mount for device %v: cannot mount over /", uuid)
+			}
+		}
+		for _, e := range d.Env {
+			parts := strings.SplitN(e, "=", 2)
+			if len(parts) != 2 || !envvarNamePattern.MatchString(parts[0]) {
+				return fmt.Errorf("invalid environment variable for device %v: %q is not of the form NAME=VALUE", uuid, e)
+			}
+		}
+	}
+	return nil
+}
+
+// Modify adds the extra mounts and environment variables for each configured device that has been
+// injected into the container. Devices are considered injected if their device nodes are included in
+// the spec or if they are requested using the NVIDIA_VISIBLE_DEVICES environment variable.
+func (m deviceExtras) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	gpus, err := getProcGPUs(m.root)
+	if err != nil {
+		return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("failed to get GPUs: %v", err))
+	}
+
+	injected, err := getInjectedDeviceUUIDs(spec, gpus)
+	if err != nil {
+		return err
+	}
+
+	var uuids []string
+	for uuid := range m.devices {
+		if injected[uuid] {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+
+	env := make(map[string]string)
+	for _, uuid := range uuids {
+		d := m.devices[uuid]
+		for _, e := range d.Env {
+			parts := strings.SplitN(e, "=", 2)
+			if existing, ok := env[parts[0]]; ok && existing != parts[1] {
+				return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("conflicting values for %v configured for injected devices: %q and %q", parts[0], existing, parts[1]))
+			}
+			env[parts[0]] = parts[1]
+		}
+		for _, mount := range d.ExtraMounts {
+			if _, err := os.Stat(filepath.Join(m.root, mount.HostPath)); err != nil {
+				return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("extra mount for device %v: %v", uuid, err))
+			}
+			m.logger.Debugf("Mounting %v at %v for device %v", mount.HostPath, mount.GetContainerPath(), uuid)
+			spec.Mounts = append(spec.Mounts, specs.Mount{
+				Source:      mount.HostPath,
+				Destination: mount.GetContainerPath(),
+				Type:        "bind",
+				Options:     mount.GetOptions(),
+			})
+		}
+	}
+
+	if len(env) == 0 {
+		return nil
+	}
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if indexOfEnv(spec.Process.Env, name) >= 0 {
+			m.logger.Debugf("Ignoring configured device environment variable %v; environment variable is set", name)
+			continue
+		}
+		spec.Process.Env = append(spec.Process.Env, name+"="+env[name])
+	}
+
+	return nil
+}
+
+// getInjectedDeviceUUIDs returns the UUIDs of the GPUs whose device nodes are included in the spec or
+// that are requested using NVIDIA_VISIBLE_DEVICES. Device indices are resolved in PCI bus ID order.
+func getInjectedDeviceUUIDs(spec *specs.Spec, gpus []procGPU) (map[string]bool, error) {
+	injected := make(map[string]bool)
+
+	byMinor := make(map[int]string)
+	for _, gpu := range gpus {
+		byMinor[gpu.minor] = gpu.uuid
+	}
+	if spec.Linux != nil {
+		for _, d := range spec.Linux.Devices {
+			match := gpuDeviceNodePattern.FindStringSubmatch(d.Path)
+			if match == nil {
+				continue
+			}
+			minor, _ := strconv.Atoi(match[1])
+			if uuid, ok := byMinor[minor]; ok {
+				injected[uuid] = true
+			}
+		}
+	}
+
+	container, err := image.NewCUDAImageFromSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range container.DevicesFromEnvvars(visibleDevicesEnvvar).List() {
+		if id == "all" {
+			for _, gpu := range gpus {
+				injected[gpu.uuid] = true
+			}
+			continue
+		}
+		// For MIG devices specified as GPU:GI, the parent GPU is considered injected.
+		index, err := strconv.Atoi(strings.SplitN(id, ":", 2)[0])
+		if err != nil {
+			injected[id] = true
+			continue
+		}
+		if index >= 0 && index < len(gpus) {
+			injected[gpus[index].uuid] = true
+		}
+	}
+
+	return injected, nil
+}
+
+// getProcGPUs returns the GPUs reported by the NVIDIA kernel module under the specified root
+// ordered by PCI bus ID. If the kernel module is not loaded, no GPUs are returned.
+func getProcGPUs(root string) ([]procGPU, error) {
+	paths, err := proc.GetInformationFilePaths(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var gpus []procGPU
+	for _, path := range paths {
+		busID := filepath.Base(filepath.Dir(path))
+		gpu, err := readProcGPU(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read information for GPU %v: %v", busID, err)
+		}
+		gpu.busID = busID
+		gpus = append(gpus, *gpu)
+	}
+	sort.Slice(gpus, func(i, j int) bool {
+		return gpus[i].busID < gpus[j].busID
+	})
+	return gpus, nil
+}
+
+// readProcGPU reads the UUID and device minor from the specified information file.
+func readProcGPU(path string) (*procGPU, error) {
+	info, err := proc.ParseGPUInformationFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	gpu := procGPU{
+		uuid:  info[proc.GPUInfoGPUUUID],
+		minor: -1,
+	}
+	if value, ok := info[proc.GPUInfoDeviceMinor]; ok {
+		minor, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device minor %q", value)
+		}
+		gpu.minor = minor
+	}
+	if gpu.uuid == "" {
+		return nil, fmt.Errorf("no GPU UUID in %v", path)
+	}
+	return &gpu, nil
+}
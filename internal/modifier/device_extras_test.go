@@ -0,0 +1,197 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// procDriverGPUsPath is the directory containing the information files of the GPUs.
+const procDriverGPUsPath = "/proc/driver/nvidia/gpus"
+
+func TestValidateDeviceConfigs(t *testing.T) {
+	testCases := []struct {
+		description   string
+		devices       map[string]config.DeviceConfig
+		expectedError bool
+	}{
+		{
+			description: "valid config",
+			devices: map[string]config.DeviceConfig{
+				"GPU-0": {
+					ExtraMounts: []config.DeviceMount{{HostPath: "/licenses/gpu0", ContainerPath: "/licenses"}},
+					Env:         []string{"LICENSE=/licenses/gpu0.lic"},
+				},
+			},
+		},
+		{
+			description: "device not specified by UUID",
+			devices: map[string]config.DeviceConfig{
+				"0": {Env: []string{"FOO=bar"}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "relative host path",
+			devices: map[string]config.DeviceConfig{
+				"GPU-0": {ExtraMounts: []config.DeviceMount{{HostPath: "licenses"}}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "mount over root",
+			devices: map[string]config.DeviceConfig{
+				"GPU-0": {ExtraMounts: []config.DeviceMount{{HostPath: "/licenses", ContainerPath: "/"}}},
+			},
+			expectedError: true,
+		},
+		{
+			description: "invalid envvar",
+			devices: map[string]config.DeviceConfig{
+				"GPU-0": {Env: []string{"FOO"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateDeviceConfigs(tc.devices)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDeviceExtrasModify(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	for minor, busID := range []string{"0000:3b:00.0", "0000:86:00.0"} {
+		dir := filepath.Join(root, procDriverGPUsPath, busID)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		information := fmt.Sprintf("Model: \t\t NVIDIA A100\nGPU UUID: \t GPU-%d\nDevice Minor: \t %d\n", minor, minor)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "information"), []byte(information), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "licenses/gpu1"), 0755))
+
+	m := deviceExtras{
+		logger: logger,
+		root:   root,
+		devices: map[string]config.DeviceConfig{
+			"GPU-1": {
+				ExtraMounts: []config.DeviceMount{{HostPath: "/licenses/gpu1", ContainerPath: "/licenses"}},
+				Env:         []string{"LICENSE=/licenses/gpu1.lic"},
+			},
+		},
+	}
+
+	expectedMount := specs.Mount{
+		Source:      "/licenses/gpu1",
+		Destination: "/licenses",
+		Type:        "bind",
+		Options:     []string{"ro", "nosuid", "nodev", "bind"},
+	}
+
+	testCases := []struct {
+		description    string
+		spec           *specs.Spec
+		expectedMounts []specs.Mount
+		expectedEnv    []string
+	}{
+		{
+			description: "device not injected",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=0"}},
+			},
+			expectedEnv: []string{"NVIDIA_VISIBLE_DEVICES=0"},
+		},
+		{
+			description: "device node injected",
+			spec: &specs.Spec{
+				Linux: &specs.Linux{Devices: []specs.LinuxDevice{{Path: "/dev/nvidia1"}}},
+			},
+			expectedMounts: []specs.Mount{expectedMount},
+			expectedEnv:    []string{"LICENSE=/licenses/gpu1.lic"},
+		},
+		{
+			description: "device requested by index",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=1"}},
+			},
+			expectedMounts: []specs.Mount{expectedMount},
+			expectedEnv:    []string{"NVIDIA_VISIBLE_DEVICES=1", "LICENSE=/licenses/gpu1.lic"},
+		},
+		{
+			description: "device requested by UUID with existing envvar",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=GPU-1", "LICENSE=/custom.lic"}},
+			},
+			expectedMounts: []specs.Mount{expectedMount},
+			expectedEnv:    []string{"NVIDIA_VISIBLE_DEVICES=GPU-1", "LICENSE=/custom.lic"},
+		},
+		{
+			description: "all devices requested",
+			spec: &specs.Spec{
+				Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=all"}},
+			},
+			expectedMounts: []specs.Mount{expectedMount},
+			expectedEnv:    []string{"NVIDIA_VISIBLE_DEVICES=all", "LICENSE=/licenses/gpu1.lic"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.NoError(t, m.Modify(tc.spec))
+			require.Equal(t, tc.expectedMounts, tc.spec.Mounts)
+			var env []string
+			if tc.spec.Process != nil {
+				env = tc.spec.Process.Env
+			}
+			require.Equal(t, tc.expectedEnv, env)
+		})
+	}
+}
+
+func TestDeviceExtrasConflictingEnv(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	m := deviceExtras{
+		logger: logger,
+		root:   t.TempDir(),
+		devices: map[string]config.DeviceConfig{
+			"GPU-0": {Env: []string{"LICENSE=/licenses/gpu0.lic"}},
+			"GPU-1": {Env: []string{"LICENSE=/licenses/gpu1.lic"}},
+		},
+	}
+
+	spec := &specs.Spec{
+		Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=GPU-0,GPU-1"}},
+	}
+	require.Error(t, m.Modify(spec))
+}
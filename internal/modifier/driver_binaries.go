@@ -0,0 +1,99 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// driverBinariesFilter is a spec modifier that removes the mounts for driver binaries that
+// are not permitted by the config.
+type driverBinariesFilter struct {
+	logger  *logrus.Logger
+	allowed map[string]bool
+}
+
+var _ oci.SpecModifier = (*driverBinariesFilter)(nil)
+
+// NewDriverBinariesFilter creates a modifier that removes mounts of driver binaries such as
+// nvidia-smi from the OCI specification based on the driver-binaries allowlist and denylist.
+// If neither list is set, no modifier is returned.
+func NewDriverBinariesFilter(logger *logrus.Logger, cfg *config.Config) (oci.SpecModifier, error) {
+	allow := cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Allow
+	deny := cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Deny
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	for _, b := range discover.DriverBinaries {
+		known[b] = true
+	}
+	// The lists are copied since appending to allow could modify the backing array of the config.
+	var configured []string
+	configured = append(configured, allow...)
+	configured = append(configured, deny...)
+	for _, b := range configured {
+		if !known[b] {
+			return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid driver binary %q; supported binaries are %v", b, discover.DriverBinaries))
+		}
+	}
+
+	allowed := make(map[string]bool)
+	for _, b := range discover.DriverBinaries {
+		allowed[b] = len(allow) == 0
+	}
+	for _, b := range allow {
+		allowed[b] = true
+	}
+	for _, b := range deny {
+		allowed[b] = false
+	}
+
+	m := driverBinariesFilter{
+		logger:  logger,
+		allowed: allowed,
+	}
+	return m, nil
+}
+
+// Modify removes the mounts for driver binaries that are not allowed from the specified OCI specification.
+func (m driverBinariesFilter) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	var mounts []specs.Mount
+	for _, mount := range spec.Mounts {
+		allowed, isDriverBinary := m.allowed[filepath.Base(mount.Destination)]
+		if isDriverBinary && !allowed {
+			m.logger.Infof("Removing mount for driver binary %v", mount.Destination)
+			continue
+		}
+		mounts = append(mounts, mount)
+	}
+	spec.Mounts = mounts
+
+	return nil
+}
@@ -0,0 +1,130 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverBinariesFilter(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	mounts := []specs.Mount{
+		{Source: "/usr/bin/nvidia-smi", Destination: "/usr/bin/nvidia-smi"},
+		{Source: "/usr/bin/nvidia-debugdump", Destination: "/usr/bin/nvidia-debugdump"},
+		{Source: "/usr/bin/nvidia-persistenced", Destination: "/usr/bin/nvidia-persistenced"},
+		{Source: "/usr/lib64/libcuda.so.1", Destination: "/usr/lib64/libcuda.so.1"},
+	}
+
+	testCases := []struct {
+		description          string
+		allow                []string
+		deny                 []string
+		expectedError        bool
+		expectNilModifier    bool
+		expectedDestinations []string
+	}{
+		{
+			description:       "no lists returns nil modifier",
+			expectNilModifier: true,
+		},
+		{
+			description: "deny removes binary",
+			deny:        []string{"nvidia-smi"},
+			expectedDestinations: []string{
+				"/usr/bin/nvidia-debugdump",
+				"/usr/bin/nvidia-persistenced",
+				"/usr/lib64/libcuda.so.1",
+			},
+		},
+		{
+			description: "allow keeps only listed binaries",
+			allow:       []string{"nvidia-smi"},
+			expectedDestinations: []string{
+				"/usr/bin/nvidia-smi",
+				"/usr/lib64/libcuda.so.1",
+			},
+		},
+		{
+			description: "deny takes precedence over allow",
+			allow:       []string{"nvidia-smi", "nvidia-debugdump"},
+			deny:        []string{"nvidia-smi"},
+			expectedDestinations: []string{
+				"/usr/bin/nvidia-debugdump",
+				"/usr/lib64/libcuda.so.1",
+			},
+		},
+		{
+			description:   "unknown binary is an error",
+			deny:          []string{"nvidia-foo"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Allow = tc.allow
+			cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Deny = tc.deny
+
+			m, err := NewDriverBinariesFilter(logger, cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectNilModifier {
+				require.Nil(t, m)
+				return
+			}
+
+			spec := &specs.Spec{
+				Mounts: append([]specs.Mount{}, mounts...),
+			}
+			require.NoError(t, m.Modify(spec))
+
+			var destinations []string
+			for _, mount := range spec.Mounts {
+				destinations = append(destinations, mount.Destination)
+			}
+			require.EqualValues(t, tc.expectedDestinations, destinations)
+		})
+	}
+}
+
+func TestDriverBinariesFilterDoesNotModifyConfig(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	allow := make([]string, 1, 2)
+	allow[0] = "nvidia-smi"
+	backing := allow[:2]
+
+	cfg := config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Allow = allow
+	cfg.NVIDIAContainerRuntimeConfig.DriverBinaries.Deny = []string{"nvidia-debugdump"}
+
+	_, err := NewDriverBinariesFilter(logger, &cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"nvidia-smi", ""}, backing)
+}
@@ -0,0 +1,47 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/sirupsen/logrus"
+)
+
+// newDriverRootsDiscoverer creates a discoverer for the configured driver roots. The discoverer for
+// each of the roots is constructed using the specified function. If no driver roots are configured,
+// the discoverer for the nvidia-container-cli root is returned.
+func newDriverRootsDiscoverer(logger *logrus.Logger, cfg *config.Config, newDiscoverer func(root string) (discover.Discover, error)) (discover.Discover, error) {
+	driverRoots := cfg.NVIDIAContainerRuntimeConfig.DriverRoots
+	if len(driverRoots) == 0 {
+		return newDiscoverer(cfg.NVIDIAContainerCLIConfig.Root)
+	}
+
+	var roots []discover.Root
+	for _, r := range driverRoots {
+		if !filepath.IsAbs(r.Path) {
+			return nil, fmt.Errorf("invalid driver root %q: must be an absolute path", r.Path)
+		}
+		roots = append(roots, discover.Root{Path: r.Path, Priority: r.Priority})
+	}
+	logger.Debugf("Using driver roots %v", roots)
+
+	return discover.NewMultiRootDiscoverer(logger, roots, newDiscoverer)
+}
@@ -0,0 +1,81 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverRootsDiscoverer(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		cliRoot       string
+		driverRoots   []config.DriverRoot
+		expectedError bool
+		expectedRoots []string
+	}{
+		{
+			description:   "nvidia-container-cli root is used by default",
+			cliRoot:       "/run/nvidia/driver",
+			expectedRoots: []string{"/run/nvidia/driver"},
+		},
+		{
+			description: "driver roots are used if configured",
+			cliRoot:     "/run/nvidia/driver",
+			driverRoots: []config.DriverRoot{
+				{Path: "/"},
+				{Path: "/run/nvidia/driver", Priority: 10},
+			},
+			expectedRoots: []string{"/run/nvidia/driver", "/"},
+		},
+		{
+			description: "relative driver root is invalid",
+			driverRoots: []config.DriverRoot{
+				{Path: "/"},
+				{Path: "run/nvidia/driver"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerCLIConfig.Root = tc.cliRoot
+			cfg.NVIDIAContainerRuntimeConfig.DriverRoots = tc.driverRoots
+
+			var roots []string
+			_, err := newDriverRootsDiscoverer(logger, cfg, func(root string) (discover.Discover, error) {
+				roots = append(roots, root)
+				return discover.None{}, nil
+			})
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedRoots, roots)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// searchPathEnvvars are the environment variables that are lists of paths.
+var searchPathEnvvars = map[string]bool{
+	"PATH":            true,
+	"LD_LIBRARY_PATH": true,
+	"LD_PRELOAD":      true,
+}
+
+// envPolicy is a spec modifier that applies a wrapped modifier and resolves the conflicts between the
+// environment variables set by it and those set in the container according to the configured policies.
+type envPolicy struct {
+	logger      *logrus.Logger
+	nvidia      string
+	searchPaths string
+	other       string
+	variables   map[string]string
+	modifier    oci.SpecModifier
+}
+
+var _ oci.SpecModifier = (*envPolicy)(nil)
+
+// NewEnvPolicyModifier wraps the specified modifier so that the environment variables that it sets
+// are merged with those already set in the container (e.g. by the image or the user) according to
+// the policy configured for each class of environment variables. If the modifier is nil, nil is returned.
+func NewEnvPolicyModifier(logger *logrus.Logger, cfg *config.Config, modifier oci.SpecModifier) (oci.SpecModifier, error) {
+	if modifier == nil {
+		return nil, nil
+	}
+
+	policyConfig := cfg.NVIDIAContainerRuntimeConfig.EnvPolicy
+	m := envPolicy{
+		logger:      logger,
+		nvidia:      envPolicyOrDefault(policyConfig.NVIDIA, config.EnvPolicyOverride),
+		searchPaths: envPolicyOrDefault(policyConfig.SearchPaths, config.EnvPolicyAppend),
+		other:       envPolicyOrDefault(policyConfig.Other, config.EnvPolicyOverride),
+		variables:   policyConfig.Variables,
+		modifier:    modifier,
+	}
+
+	policies := map[string]string{
+		"env-policy.nvidia":       m.nvidia,
+		"env-policy.search-paths": m.searchPaths,
+		"env-policy.other":        m.other,
+	}
+	for name, policy := range policyConfig.Variables {
+		policies["env-policy.variables."+name] = policy
+	}
+	for option, policy := range policies {
+		if !isValidEnvPolicy(policy) {
+			return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid %v %q: must be one of [override | keep | append | prepend]", option, policy))
+		}
+	}
+	return m, nil
+}
+
+// envPolicyOrDefault returns the specified policy or the default policy if no policy is specified.
+func envPolicyOrDefault(policy string, defaultPolicy string) string {
+	if policy == "" {
+		return defaultPolicy
+	}
+	return policy
+}
+
+func isValidEnvPolicy(policy string) bool {
+	switch policy {
+	case config.EnvPolicyOverride, config.EnvPolicyKeep, config.EnvPolicyAppend, config.EnvPolicyPrepend:
+		return true
+	}
+	return false
+}
+
+// Modify applies the wrapped modifier and then applies the configured policy to each environment
+// variable that was set in the container and whose value was changed by the wrapped modifier.
+func (m envPolicy) Modify(spec *specs.Spec) error {
+	var original []string
+	if spec.Process != nil {
+		original = append(original, spec.Process.Env...)
+	}
+
+	if err := m.modifier.Modify(spec); err != nil {
+		return err
+	}
+
+	if spec.Process == nil {
+		return nil
+	}
+	spec.Process.Env = m.merge(original, spec.Process.Env)
+	return nil
+}
+
+// merge returns the modified environment with the policies applied to the injected values. Entries of
+// the modified environment that are not included in the original environment are considered injected.
+// If an environment variable is injected more than once, the last value is used.
+func (m envPolicy) merge(original []string, modified []string) []string {
+	existing := make(map[string]string)
+	remaining := make(map[string]int)
+	for _, e := range original {
+		name, value, _ := strings.Cut(e, "=")
+		if _, ok := existing[name]; !ok {
+			existing[name] = value
+		}
+		remaining[e]++
+	}
+
+	injected := make(map[string]string)
+	for _, e := range modified {
+		if remaining[e] > 0 {
+			remaining[e]--
+			continue
+		}
+		name, value, _ := strings.Cut(e, "=")
+		injected[name] = value
+	}
+
+	var env []string
+	seen := make(map[string]bool)
+	for _, e := range modified {
+		name, _, _ := strings.Cut(e, "=")
+		value, ok := injected[name]
+		if !ok {
+			env = append(env, e)
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if current, ok := existing[name]; ok {
+			policy := m.policyFor(name)
+			value = mergeEnvValue(policy, current, value)
+			m.logger.Debugf("Applying env policy %v to %v", policy, name)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// policyFor returns the policy for the specified environment variable.
+func (m envPolicy) policyFor(name string) string {
+	if policy, ok := m.variables[name]; ok {
+		return policy
+	}
+	switch {
+	case strings.HasPrefix(name, "NVIDIA_") || strings.HasPrefix(name, "CUDA_"):
+		return m.nvidia
+	case searchPathEnvvars[name]:
+		return m.searchPaths
+	default:
+		return m.other
+	}
+}
+
+// mergeEnvValue merges the value set in the container with the injected value according to the
+// specified policy. When appending or prepending, the elements of the injected value that are
+// already included in the value set in the container are not repeated.
+func mergeEnvValue(policy string, current string, injected string) string {
+	switch policy {
+	case config.EnvPolicyKeep:
+		return current
+	case config.EnvPolicyOverride:
+		return injected
+	}
+
+	var elements []string
+	if current != "" {
+		elements = strings.Split(current, ":")
+	}
+	included := make(map[string]bool)
+	for _, e := range elements {
+		included[e] = true
+	}
+
+	var added []string
+	for _, e := range strings.Split(injected, ":") {
+		if e == "" || included[e] {
+			continue
+		}
+		included[e] = true
+		added = append(added, e)
+	}
+
+	if policy == config.EnvPolicyAppend {
+		return strings.Join(append(elements, added...), ":")
+	}
+	return strings.Join(append(added, elements...), ":")
+}
@@ -0,0 +1,143 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvPolicyModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description    string
+		policy         func(*config.Config)
+		env            []string
+		injected       []string
+		appendInjected bool
+		expectedError  bool
+		expectedEnv    []string
+	}{
+		{
+			description: "default policies",
+			env:         []string{"PATH=/usr/bin", "LD_PRELOAD=/opt/libfoo.so", "NVIDIA_DRIVER_CAPABILITIES=compute", "FOO=bar"},
+			injected:    []string{"PATH=/usr/local/nvidia/bin", "LD_PRELOAD=/usr/lib/libnvidia-foo.so", "NVIDIA_DRIVER_CAPABILITIES=all", "FOO=baz", "NEW=value"},
+			expectedEnv: []string{
+				"PATH=/usr/bin:/usr/local/nvidia/bin",
+				"LD_PRELOAD=/opt/libfoo.so:/usr/lib/libnvidia-foo.so",
+				"NVIDIA_DRIVER_CAPABILITIES=all",
+				"FOO=baz",
+				"NEW=value",
+			},
+		},
+		{
+			description: "keep user-set NVIDIA variables",
+			policy: func(c *config.Config) {
+				c.NVIDIAContainerRuntimeConfig.EnvPolicy.NVIDIA = config.EnvPolicyKeep
+			},
+			env:         []string{"NVIDIA_DRIVER_CAPABILITIES=compute", "CUDA_MODULE_LOADING=EAGER"},
+			injected:    []string{"NVIDIA_DRIVER_CAPABILITIES=all", "CUDA_MODULE_LOADING=LAZY"},
+			expectedEnv: []string{"NVIDIA_DRIVER_CAPABILITIES=compute", "CUDA_MODULE_LOADING=EAGER"},
+		},
+		{
+			description: "prepend search paths without duplicates",
+			policy: func(c *config.Config) {
+				c.NVIDIAContainerRuntimeConfig.EnvPolicy.SearchPaths = config.EnvPolicyPrepend
+			},
+			env:         []string{"LD_LIBRARY_PATH=/opt/lib:/usr/local/nvidia/lib64"},
+			injected:    []string{"LD_LIBRARY_PATH=/usr/local/nvidia/lib64:/usr/local/nvidia/lib"},
+			expectedEnv: []string{"LD_LIBRARY_PATH=/usr/local/nvidia/lib:/opt/lib:/usr/local/nvidia/lib64"},
+		},
+		{
+			description: "per-variable policy takes precedence over class",
+			policy: func(c *config.Config) {
+				c.NVIDIAContainerRuntimeConfig.EnvPolicy.Variables = map[string]string{"LD_PRELOAD": config.EnvPolicyKeep}
+			},
+			env:         []string{"PATH=/usr/bin", "LD_PRELOAD=/opt/libfoo.so"},
+			injected:    []string{"PATH=/usr/local/nvidia/bin", "LD_PRELOAD=/usr/lib/libnvidia-foo.so"},
+			expectedEnv: []string{"PATH=/usr/bin:/usr/local/nvidia/bin", "LD_PRELOAD=/opt/libfoo.so"},
+		},
+		{
+			description:    "duplicate injected variables are collapsed",
+			env:            []string{"FOO=bar", "PATH=/usr/bin"},
+			injected:       []string{"FOO=baz", "PATH=/usr/local/nvidia/bin", "FOO=qux"},
+			appendInjected: true,
+			expectedEnv:    []string{"FOO=qux", "PATH=/usr/bin:/usr/local/nvidia/bin"},
+		},
+		{
+			description: "unchanged variables are not modified",
+			env:         []string{"PATH=/usr/bin", "PATH=/bin"},
+			expectedEnv: []string{"PATH=/usr/bin", "PATH=/bin"},
+		},
+		{
+			description: "unset policies use defaults",
+			policy: func(c *config.Config) {
+				c.NVIDIAContainerRuntimeConfig.EnvPolicy = config.RuntimeConfig{}.EnvPolicy
+			},
+			env:         []string{"PATH=/usr/bin", "FOO=bar"},
+			injected:    []string{"PATH=/usr/local/nvidia/bin", "FOO=baz"},
+			expectedEnv: []string{"PATH=/usr/bin:/usr/local/nvidia/bin", "FOO=baz"},
+		},
+		{
+			description: "invalid policy",
+			policy: func(c *config.Config) {
+				c.NVIDIAContainerRuntimeConfig.EnvPolicy.Variables = map[string]string{"PATH": "merge"}
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			if tc.policy != nil {
+				tc.policy(cfg)
+			}
+
+			inject := modifierFunc(func(spec *specs.Spec) error {
+				for _, e := range tc.injected {
+					if tc.appendInjected {
+						spec.Process.Env = append(spec.Process.Env, e)
+						continue
+					}
+					name, value, _ := strings.Cut(e, "=")
+					spec.Process.Env = setEnv(spec.Process.Env, name, value)
+				}
+				return nil
+			})
+
+			m, err := NewEnvPolicyModifier(logger, cfg, inject)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			spec := &specs.Spec{Process: &specs.Process{Env: append([]string{}, tc.env...)}}
+			require.NoError(t, m.Modify(spec))
+			require.Equal(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzGetDevicesFromSpec checks that the CDI devices requested through container annotations
+// or NVIDIA_VISIBLE_DEVICES are returned as non-empty names and that devices requested through
+// the environment are not duplicated.
+func FuzzGetDevicesFromSpec(f *testing.F) {
+	logger, _ := testlog.NewNullLogger()
+	specDir := f.TempDir()
+
+	f.Add("cdi.k8s.io/nvidia", "nvidia.com/gpu=0", "")
+	f.Add("cdi.k8s.io/nvidia", "nvidia.com/gpu=0,nvidia.com/gpu=all", "1")
+	f.Add("cdi.k8s.io/", "nvidia.com/gpu", "")
+	f.Add("example.com/annotation", "value", "0,1")
+	f.Add("", "", "all")
+	f.Add("", "", "nvidia.com/gpu=0,GPU-8dbc5d32-9ad4-4a4d-9d6c-4b5c0e1f2a3b")
+
+	f.Fuzz(func(t *testing.T, annotationKey string, annotationValue string, visibleDevices string) {
+		cfg := &config.Config{
+			AcceptEnvvarUnprivileged:     true,
+			NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+		}
+		cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{specDir}
+
+		spec := &specs.Spec{
+			Process: &specs.Process{
+				Env: []string{"NVIDIA_VISIBLE_DEVICES=" + visibleDevices},
+			},
+			Annotations: map[string]string{
+				annotationKey: annotationValue,
+			},
+		}
+
+		devices, err := getDevicesFromSpec(logger, oci.NewMemorySpec(spec), cfg)
+		if err != nil {
+			require.Nil(t, devices)
+			return
+		}
+
+		seen := make(map[string]bool)
+		for _, device := range devices {
+			require.NotEmpty(t, device)
+			if strings.HasPrefix(annotationKey, "cdi.k8s.io/") {
+				continue
+			}
+			require.False(t, seen[device], "duplicate device %q", device)
+			seen[device] = true
+		}
+	})
+}
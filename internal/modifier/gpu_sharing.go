@@ -0,0 +1,219 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gpuSharingAnnotationPrefix = "nvidia.com/gpu-sharing."
+	// gpuSharingReplicasAnnotation marks an allocation as shared. Its value is the number of
+	// replicas that each of the allocated GPUs is shared between.
+	gpuSharingReplicasAnnotation = gpuSharingAnnotationPrefix + "replicas"
+	// gpuSharingMemoryLimitAnnotation is the device memory (e.g. 8G) available to the container on each GPU.
+	gpuSharingMemoryLimitAnnotation = gpuSharingAnnotationPrefix + "memory-limit"
+	// gpuSharingActiveThreadPercentageAnnotation is the percentage of the SMs available to the container.
+	gpuSharingActiveThreadPercentageAnnotation = gpuSharingAnnotationPrefix + "active-thread-percentage"
+
+	cudaVisibleDevicesEnvvar            = "CUDA_VISIBLE_DEVICES"
+	cudaMPSPinnedDeviceMemLimitEnvvar   = "CUDA_MPS_PINNED_DEVICE_MEM_LIMIT"
+	cudaMPSActiveThreadPercentageEnvvar = "CUDA_MPS_ACTIVE_THREAD_PERCENTAGE"
+	// tfForceGPUAllowGrowthEnvvar prevents TensorFlow from reserving all the memory of a GPU.
+	tfForceGPUAllowGrowthEnvvar = "TF_FORCE_GPU_ALLOW_GROWTH"
+)
+
+// memoryLimitPattern matches the memory limits accepted by CUDA_MPS_PINNED_DEVICE_MEM_LIMIT.
+var memoryLimitPattern = regexp.MustCompile(`^[0-9]+([KMGT]B?)?$`)
+
+// gpuSharing sets the environment variables that allow frameworks to behave correctly if the GPUs
+// injected into a container are shared with other containers.
+type gpuSharing struct {
+	logger                 *logrus.Logger
+	root                   string
+	devices                []string
+	memoryLimit            string
+	activeThreadPercentage string
+}
+
+var _ oci.SpecModifier = (*gpuSharing)(nil)
+
+// NewGPUSharingModifier creates a modifier that sets CUDA_VISIBLE_DEVICES and the memory and
+// compute hints for containers whose allocation is marked as shared using the nvidia.com/gpu-sharing.*
+// annotations. If GPU sharing is not enabled or the allocation of the container is not shared, no
+// modifier is returned.
+func NewGPUSharingModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	if !cfg.NVIDIAContainerRuntimeConfig.GPUSharing.Enabled {
+		return nil, nil
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	replicas, ok := rawSpec.Annotations[gpuSharingReplicasAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if n, err := strconv.Atoi(replicas); err != nil || n < 1 {
+		return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("invalid %v annotation %q: must be a positive integer", gpuSharingReplicasAnnotation, replicas))
+	}
+
+	memoryLimit := rawSpec.Annotations[gpuSharingMemoryLimitAnnotation]
+	if memoryLimit != "" && !memoryLimitPattern.MatchString(memoryLimit) {
+		return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("invalid %v annotation %q: must be a size such as 8G or 512M", gpuSharingMemoryLimitAnnotation, memoryLimit))
+	}
+
+	activeThreadPercentage := rawSpec.Annotations[gpuSharingActiveThreadPercentageAnnotation]
+	if activeThreadPercentage != "" {
+		if p, err := strconv.Atoi(activeThreadPercentage); err != nil || p < 1 || p > 100 {
+			return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("invalid %v annotation %q: must be a percentage between 1 and 100", gpuSharingActiveThreadPercentageAnnotation, activeThreadPercentage))
+		}
+	}
+
+	devices, err := getSharedDevices(cfg, rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+
+	m := gpuSharing{
+		logger:                 logger,
+		root:                   "/",
+		devices:                devices,
+		memoryLimit:            memoryLimit,
+		activeThreadPercentage: activeThreadPercentage,
+	}
+	return m, nil
+}
+
+// getSharedDevices returns the devices requested using CDI annotations or, if no devices are
+// requested using annotations, the NVIDIA_VISIBLE_DEVICES environment variable.
+func getSharedDevices(cfg *config.Config, rawSpec *specs.Spec) ([]string, error) {
+	prefixes, err := getAnnotationPrefixes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	devices, _, err := parseCDIAnnotations(rawSpec.Annotations, prefixes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse container annotations: %v", err)
+	}
+	if len(devices) > 0 {
+		return devices, nil
+	}
+
+	container, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	return container.DevicesFromEnvvars(visibleDevicesEnvvar).List(), nil
+}
+
+// Modify sets CUDA_VISIBLE_DEVICES to the UUIDs of the requested devices and sets the memory limit of
+// each of these devices, the active thread percentage, and TF_FORCE_GPU_ALLOW_GROWTH. Environment
+// variables that are already set in the container are not overridden.
+func (m gpuSharing) Modify(spec *specs.Spec) error {
+	gpus, err := getProcGPUs(m.root)
+	if err != nil {
+		return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("failed to get GPUs: %v", err))
+	}
+
+	var env [][2]string
+	uuids, err := resolveDeviceUUIDs(m.devices, gpus)
+	if err != nil {
+		m.logger.Warningf("Not setting %v for shared devices: %v", cudaVisibleDevicesEnvvar, err)
+	} else {
+		env = append(env, [2]string{cudaVisibleDevicesEnvvar, strings.Join(uuids, ",")})
+		if m.memoryLimit != "" {
+			var limits []string
+			for i := range uuids {
+				limits = append(limits, fmt.Sprintf("%d=%v", i, m.memoryLimit))
+			}
+			env = append(env, [2]string{cudaMPSPinnedDeviceMemLimitEnvvar, strings.Join(limits, ",")})
+		}
+	}
+	if m.activeThreadPercentage != "" {
+		env = append(env, [2]string{cudaMPSActiveThreadPercentageEnvvar, m.activeThreadPercentage})
+	}
+	env = append(env, [2]string{tfForceGPUAllowGrowthEnvvar, "true"})
+
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	for _, e := range env {
+		if indexOfEnv(spec.Process.Env, e[0]) >= 0 {
+			m.logger.Debugf("Ignoring GPU sharing environment variable %v; environment variable is set", e[0])
+			continue
+		}
+		m.logger.Debugf("Setting %v=%v for shared devices", e[0], e[1])
+		spec.Process.Env = append(spec.Process.Env, e[0]+"="+e[1])
+	}
+	return nil
+}
+
+// resolveDeviceUUIDs returns the unique UUIDs of the specified devices in the order in which these
+// are requested. Devices may be specified by UUID, by index, or as all, optionally qualified by a CDI
+// kind (e.g. nvidia.com/gpu=0). Device indices are resolved in PCI bus ID order. Since replicas of
+// the same GPU are allocated as the same device, duplicates are removed.
+func resolveDeviceUUIDs(devices []string, gpus []procGPU) ([]string, error) {
+	var uuids []string
+	seen := make(map[string]bool)
+	add := func(uuid string) {
+		if seen[uuid] {
+			return
+		}
+		seen[uuid] = true
+		uuids = append(uuids, uuid)
+	}
+
+	for _, device := range devices {
+		name := device
+		if isQualifiedCDIName(device) {
+			name = device[strings.LastIndex(device, "=")+1:]
+		}
+		switch {
+		case strings.HasPrefix(name, "GPU-") || strings.HasPrefix(name, "MIG-"):
+			add(name)
+		case name == "all":
+			if len(gpus) == 0 {
+				return nil, fmt.Errorf("no GPUs found to resolve %q", device)
+			}
+			for _, gpu := range gpus {
+				add(gpu.uuid)
+			}
+		default:
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 || index >= len(gpus) {
+				return nil, fmt.Errorf("cannot resolve device %q to a UUID", device)
+			}
+			add(gpus[index].uuid)
+		}
+	}
+	return uuids, nil
+}
@@ -0,0 +1,192 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGPUSharingModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description      string
+		enabled          bool
+		env              []string
+		annotations      map[string]string
+		expectedModifier bool
+		expectedError    bool
+	}{
+		{
+			description: "disabled",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			annotations: map[string]string{gpuSharingReplicasAnnotation: "4"},
+		},
+		{
+			description: "allocation not shared",
+			enabled:     true,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=0"},
+		},
+		{
+			description: "no devices requested",
+			enabled:     true,
+			annotations: map[string]string{gpuSharingReplicasAnnotation: "4"},
+		},
+		{
+			description:      "shared allocation",
+			enabled:          true,
+			env:              []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			annotations:      map[string]string{gpuSharingReplicasAnnotation: "4", gpuSharingMemoryLimitAnnotation: "8G"},
+			expectedModifier: true,
+		},
+		{
+			description:      "shared allocation requested using CDI annotations",
+			enabled:          true,
+			annotations:      map[string]string{gpuSharingReplicasAnnotation: "4", "cdi.k8s.io/gpu": "nvidia.com/gpu=0"},
+			expectedModifier: true,
+		},
+		{
+			description:   "invalid replicas",
+			enabled:       true,
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			annotations:   map[string]string{gpuSharingReplicasAnnotation: "0"},
+			expectedError: true,
+		},
+		{
+			description:   "invalid memory limit",
+			enabled:       true,
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			annotations:   map[string]string{gpuSharingReplicasAnnotation: "2", gpuSharingMemoryLimitAnnotation: "lots"},
+			expectedError: true,
+		},
+		{
+			description:   "invalid active thread percentage",
+			enabled:       true,
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			annotations:   map[string]string{gpuSharingReplicasAnnotation: "2", gpuSharingActiveThreadPercentageAnnotation: "150"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.GPUSharing.Enabled = tc.enabled
+
+			ociSpec := oci.NewMemorySpec(&specs.Spec{
+				Process:     &specs.Process{Env: tc.env},
+				Annotations: tc.annotations,
+			})
+
+			m, err := NewGPUSharingModifier(logger, cfg, ociSpec)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindUnsupportedRequest, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedModifier {
+				require.NotNil(t, m)
+			} else {
+				require.Nil(t, m)
+			}
+		})
+	}
+}
+
+func TestGPUSharingModify(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	root := t.TempDir()
+	for minor, busID := range []string{"0000:3b:00.0", "0000:86:00.0"} {
+		dir := filepath.Join(root, procDriverGPUsPath, busID)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		information := fmt.Sprintf("Model: \t\t NVIDIA A100\nGPU UUID: \t GPU-%d\nDevice Minor: \t %d\n", minor, minor)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "information"), []byte(information), 0644))
+	}
+
+	testCases := []struct {
+		description            string
+		devices                []string
+		memoryLimit            string
+		activeThreadPercentage string
+		env                    []string
+		expectedEnv            []string
+	}{
+		{
+			description: "index is resolved to UUID",
+			devices:     []string{"1"},
+			expectedEnv: []string{"CUDA_VISIBLE_DEVICES=GPU-1", "TF_FORCE_GPU_ALLOW_GROWTH=true"},
+		},
+		{
+			description:            "replicas of the same device are deduplicated",
+			devices:                []string{"nvidia.com/gpu=GPU-1", "nvidia.com/gpu=GPU-1", "nvidia.com/gpu=0"},
+			memoryLimit:            "8G",
+			activeThreadPercentage: "50",
+			expectedEnv: []string{
+				"CUDA_VISIBLE_DEVICES=GPU-1,GPU-0",
+				"CUDA_MPS_PINNED_DEVICE_MEM_LIMIT=0=8G,1=8G",
+				"CUDA_MPS_ACTIVE_THREAD_PERCENTAGE=50",
+				"TF_FORCE_GPU_ALLOW_GROWTH=true",
+			},
+		},
+		{
+			description: "all is resolved in PCI bus ID order",
+			devices:     []string{"all"},
+			expectedEnv: []string{"CUDA_VISIBLE_DEVICES=GPU-0,GPU-1", "TF_FORCE_GPU_ALLOW_GROWTH=true"},
+		},
+		{
+			description: "unresolvable device does not set CUDA_VISIBLE_DEVICES",
+			devices:     []string{"0:1"},
+			memoryLimit: "8G",
+			expectedEnv: []string{"TF_FORCE_GPU_ALLOW_GROWTH=true"},
+		},
+		{
+			description: "existing envvars are not overridden",
+			devices:     []string{"0"},
+			env:         []string{"CUDA_VISIBLE_DEVICES=0"},
+			expectedEnv: []string{"CUDA_VISIBLE_DEVICES=0", "TF_FORCE_GPU_ALLOW_GROWTH=true"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := gpuSharing{
+				logger:                 logger,
+				root:                   root,
+				devices:                tc.devices,
+				memoryLimit:            tc.memoryLimit,
+				activeThreadPercentage: tc.activeThreadPercentage,
+			}
+
+			spec := &specs.Spec{Process: &specs.Process{Env: tc.env}}
+			require.NoError(t, m.Modify(spec))
+			require.Equal(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
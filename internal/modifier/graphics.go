@@ -22,13 +22,28 @@ import (
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/sirupsen/logrus"
 )
 
-// NewGraphicsModifier constructs a modifier that injects graphics-related modifications into an OCI runtime specification.
-// The value of the NVIDIA_DRIVER_CAPABILITIES environment variable is checked to determine if this modification should be made.
-func NewGraphicsModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+// NewGraphicsModifier constructs a modifier that injects the graphics-related modifications from the specified
+// discoverer (as returned by NewGraphicsDiscoverer) into an OCI runtime specification. If the discoverer is nil,
+// no modifier is returned.
+func NewGraphicsModifier(logger *logrus.Logger, cfg *config.Config, graphics discover.Discover) (oci.SpecModifier, error) {
+	if graphics == nil {
+		return nil, nil
+	}
+	d, err := withMountStrategy(logger, cfg, graphics)
+	if err != nil {
+		return nil, err
+	}
+	return NewModifierFromDiscoverer(logger, d)
+}
+
+// NewGraphicsDiscoverer constructs a discoverer for the graphics-related files and devices required by a container.
+// The value of the NVIDIA_DRIVER_CAPABILITIES environment variable is checked to determine if these are required.
+// If not, nil is returned.
+func NewGraphicsDiscoverer(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (discover.Discover, error) {
 	rawSpec, err := ociSpec.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
@@ -44,20 +59,21 @@ func NewGraphicsModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.
 		return nil, nil
 	}
 
-	config := &discover.Config{
-		DriverRoot:    cfg.NVIDIAContainerCLIConfig.Root,
-		NvidiaCTKPath: cfg.NVIDIACTKConfig.Path,
-	}
-	d, err := discover.NewGraphicsDiscoverer(
-		logger,
-		image.DevicesFromEnvvars(visibleDevicesEnvvar),
-		config,
-	)
+	d, err := newDriverRootsDiscoverer(logger, cfg, func(root string) (discover.Discover, error) {
+		config := &discover.Config{
+			DriverRoot:    root,
+			NvidiaCTKPath: cfg.NVIDIACTKConfig.Path,
+		}
+		return discover.NewGraphicsDiscoverer(
+			logger,
+			image.DevicesFromEnvvars(visibleDevicesEnvvar),
+			config,
+		)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct discoverer: %v", err)
 	}
-
-	return NewModifierFromDiscoverer(logger, d)
+	return d, nil
 }
 
 // requiresGraphicsModifier determines whether a graphics modifier is required.
@@ -0,0 +1,89 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// disabledHooks is a spec modifier that removes the disabled nvidia-ctk hooks from an OCI specification.
+type disabledHooks struct {
+	logger   *logrus.Logger
+	disabled map[string]bool
+}
+
+var _ oci.SpecModifier = (*disabledHooks)(nil)
+
+// NewDisabledHooksModifier creates a modifier that removes the nvidia-ctk hooks that are disabled for
+// the specified mode (including those injected from CDI specifications) from the OCI spec. If no
+// hooks are disabled, nil is returned.
+func NewDisabledHooksModifier(logger *logrus.Logger, cfg *config.Config, mode string) (oci.SpecModifier, error) {
+	names := cfg.NVIDIAContainerRuntimeConfig.DisabledHooks(mode)
+	if err := discover.ValidateNvidiaCTKHooks(names); err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid hooks config: %v", err))
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range names {
+		disabled[name] = true
+	}
+
+	m := disabledHooks{
+		logger:   logger,
+		disabled: disabled,
+	}
+	return m, nil
+}
+
+// Modify removes the disabled nvidia-ctk hooks from each of the hook types in the spec.
+func (m disabledHooks) Modify(spec *specs.Spec) error {
+	if spec == nil || spec.Hooks == nil {
+		return nil
+	}
+
+	spec.Hooks.Prestart = m.filter(spec.Hooks.Prestart)
+	spec.Hooks.CreateRuntime = m.filter(spec.Hooks.CreateRuntime)
+	spec.Hooks.CreateContainer = m.filter(spec.Hooks.CreateContainer)
+	spec.Hooks.StartContainer = m.filter(spec.Hooks.StartContainer)
+	spec.Hooks.Poststart = m.filter(spec.Hooks.Poststart)
+	spec.Hooks.Poststop = m.filter(spec.Hooks.Poststop)
+
+	return nil
+}
+
+// filter returns the specified hooks without the disabled nvidia-ctk hooks.
+func (m disabledHooks) filter(hooks []specs.Hook) []specs.Hook {
+	var filtered []specs.Hook
+	for _, hook := range hooks {
+		if name := privileges.NVIDIACTKHookName(hook.Path, hook.Args); m.disabled[name] {
+			m.logger.Debugf("Removing disabled %v hook %v", name, hook.Args)
+			continue
+		}
+		filtered = append(filtered, hook)
+	}
+	return filtered
+}
@@ -0,0 +1,128 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisabledHooksModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	other := specs.Hook{
+		Path: "/usr/local/bin/other-hook",
+		Args: []string{"other-hook", "create-symlinks"},
+	}
+	symlinks := specs.Hook{
+		Path: "/usr/bin/nvidia-ctk",
+		Args: []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "libcuda.so.1::/lib64/libcuda.so"},
+	}
+	ldcache := specs.Hook{
+		Path: "/usr/bin/nvidia-ctk",
+		Args: []string{"nvidia-ctk", "hook", "--user=1000:1000", "update-ldcache", "--folder", "/lib64"},
+	}
+
+	testCases := []struct {
+		description    string
+		disable        []string
+		disableForMode map[string][]string
+		spec           *specs.Spec
+		expectedError  bool
+		expectedSpec   *specs.Spec
+	}{
+		{
+			description:   "unknown hook returns error",
+			disable:       []string{"update-symlinks"},
+			expectedError: true,
+		},
+		{
+			description: "disabled hooks are removed",
+			disable:     []string{"create-symlinks"},
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{symlinks, other, ldcache},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{other, ldcache},
+				},
+			},
+		},
+		{
+			description:    "hooks disabled for the mode are removed",
+			disable:        []string{"create-symlinks"},
+			disableForMode: map[string][]string{"cdi": {"update-ldcache"}},
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{symlinks, other, ldcache},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{other},
+				},
+			},
+		},
+		{
+			description:    "hooks disabled for other modes are retained",
+			disableForMode: map[string][]string{"csv": {"update-ldcache"}, "cdi": {"chmod"}},
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{symlinks, ldcache},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{symlinks, ldcache},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.Hooks.Disable = tc.disable
+			cfg.NVIDIAContainerRuntimeConfig.Hooks.DisableForMode = tc.disableForMode
+
+			m, err := NewDisabledHooksModifier(logger, cfg, "cdi")
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			err = m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
+
+func TestDisabledHooksModifierNotConfigured(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	m, err := NewDisabledHooksModifier(logger, &config.Config{}, "cdi")
+	require.NoError(t, err)
+	require.Nil(t, m)
+}
@@ -0,0 +1,139 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// hookOrdering is a spec modifier that reorders and deduplicates the NVIDIA hooks in an OCI specification.
+type hookOrdering struct {
+	logger      *logrus.Logger
+	position    string
+	deduplicate bool
+}
+
+var _ oci.SpecModifier = (*hookOrdering)(nil)
+
+// NewHookOrderingModifier creates a modifier that moves the NVIDIA hooks (i.e. the nvidia-ctk hooks
+// and the NVIDIA Container Runtime Hook) before or after the other hooks of the same type and
+// optionally removes repeated NVIDIA hooks. If neither is configured, nil is returned.
+func NewHookOrderingModifier(logger *logrus.Logger, cfg *config.Config) (oci.SpecModifier, error) {
+	ordering := cfg.NVIDIAContainerRuntimeConfig.HookOrdering
+	switch ordering.Position {
+	case "", config.HookPositionFirst, config.HookPositionLast:
+	default:
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid hook position: %q", ordering.Position))
+	}
+	if ordering.Position == "" && !ordering.Deduplicate {
+		return nil, nil
+	}
+
+	m := hookOrdering{
+		logger:      logger,
+		position:    ordering.Position,
+		deduplicate: ordering.Deduplicate,
+	}
+	return m, nil
+}
+
+// Modify reorders and deduplicates the NVIDIA hooks for each of the hook types in the spec.
+// The relative order of the NVIDIA hooks and of the other hooks is retained.
+func (m hookOrdering) Modify(spec *specs.Spec) error {
+	if spec == nil || spec.Hooks == nil {
+		return nil
+	}
+
+	spec.Hooks.Prestart = m.order("prestart", spec.Hooks.Prestart)
+	spec.Hooks.CreateRuntime = m.order("createRuntime", spec.Hooks.CreateRuntime)
+	spec.Hooks.CreateContainer = m.order("createContainer", spec.Hooks.CreateContainer)
+	spec.Hooks.StartContainer = m.order("startContainer", spec.Hooks.StartContainer)
+	spec.Hooks.Poststart = m.order("poststart", spec.Hooks.Poststart)
+	spec.Hooks.Poststop = m.order("poststop", spec.Hooks.Poststop)
+
+	return nil
+}
+
+// order returns the specified hooks with the NVIDIA hooks at the configured position.
+func (m hookOrdering) order(hookType string, hooks []specs.Hook) []specs.Hook {
+	if len(hooks) == 0 {
+		return hooks
+	}
+
+	var deduplicated []specs.Hook
+	var nvidiaHooks []specs.Hook
+	var otherHooks []specs.Hook
+	seen := make(map[string]bool)
+	for _, hook := range hooks {
+		if !isNVIDIAHook(&hook) {
+			deduplicated = append(deduplicated, hook)
+			otherHooks = append(otherHooks, hook)
+			continue
+		}
+		if m.deduplicate {
+			key := hookKey(&hook)
+			if seen[key] {
+				m.logger.Debugf("Removing duplicate %v hook %v", hookType, hook.Args)
+				continue
+			}
+			seen[key] = true
+		}
+		deduplicated = append(deduplicated, hook)
+		nvidiaHooks = append(nvidiaHooks, hook)
+	}
+
+	var ordered []specs.Hook
+	switch m.position {
+	case config.HookPositionFirst:
+		ordered = append(nvidiaHooks, otherHooks...)
+	case config.HookPositionLast:
+		ordered = append(otherHooks, nvidiaHooks...)
+	default:
+		ordered = deduplicated
+	}
+
+	if len(ordered) != len(hooks) || m.position != "" {
+		m.logger.Debugf("Updating %v hooks to %v", hookType, ordered)
+	}
+	return ordered
+}
+
+// isNVIDIAHook checks whether the specified hook is an nvidia-ctk hook or an NVIDIA Container Runtime Hook.
+func isNVIDIAHook(hook *specs.Hook) bool {
+	return privileges.IsNVIDIACTKHook(hook.Path, hook.Args) || isNVIDIAContainerRuntimeHook(hook)
+}
+
+// hookKey returns a key that identifies hooks with the same path, arguments, environment, and timeout.
+func hookKey(hook *specs.Hook) string {
+	var timeout string
+	if hook.Timeout != nil {
+		timeout = fmt.Sprintf("%d", *hook.Timeout)
+	}
+	return strings.Join([]string{
+		hook.Path,
+		strings.Join(hook.Args, "\x00"),
+		strings.Join(hook.Env, "\x00"),
+		timeout,
+	}, "\x01")
+}
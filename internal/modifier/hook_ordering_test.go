@@ -0,0 +1,180 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookOrderingModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	ldconfig := specs.Hook{
+		Path: "/usr/local/bin/update-ldconfig",
+		Args: []string{"update-ldconfig"},
+	}
+	other := specs.Hook{
+		Path: "/usr/local/bin/other-hook",
+		Args: []string{"other-hook"},
+	}
+	symlinks := specs.Hook{
+		Path: "/usr/bin/nvidia-ctk",
+		Args: []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "libcuda.so.1::/lib64/libcuda.so"},
+	}
+	ldcache := specs.Hook{
+		Path: "/usr/bin/nvidia-ctk",
+		Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/lib64"},
+	}
+	runtimeHook := specs.Hook{
+		Path: "/usr/bin/nvidia-container-runtime-hook",
+		Args: []string{"nvidia-container-runtime-hook", "prestart"},
+	}
+
+	testCases := []struct {
+		description   string
+		position      string
+		deduplicate   bool
+		spec          *specs.Spec
+		expectedError bool
+		expectedSpec  *specs.Spec
+	}{
+		{
+			description:   "invalid position returns error",
+			position:      "middle",
+			expectedError: true,
+		},
+		{
+			description: "nvidia hooks are moved first",
+			position:    "first",
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					Prestart:        []specs.Hook{other, runtimeHook},
+					CreateContainer: []specs.Hook{ldconfig, symlinks, other, ldcache},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					Prestart:        []specs.Hook{runtimeHook, other},
+					CreateContainer: []specs.Hook{symlinks, ldcache, ldconfig, other},
+				},
+			},
+		},
+		{
+			description: "nvidia hooks are moved last",
+			position:    "last",
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{symlinks, ldconfig, ldcache, other},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{ldconfig, other, symlinks, ldcache},
+				},
+			},
+		},
+		{
+			description: "duplicate nvidia hooks are removed in place",
+			deduplicate: true,
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					Prestart:        []specs.Hook{runtimeHook, other, runtimeHook},
+					CreateContainer: []specs.Hook{ldcache, ldconfig, ldconfig, ldcache, symlinks},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					Prestart:        []specs.Hook{runtimeHook, other},
+					CreateContainer: []specs.Hook{ldcache, ldconfig, ldconfig, symlinks},
+				},
+			},
+		},
+		{
+			description: "duplicate nvidia hooks are removed and moved",
+			position:    "last",
+			deduplicate: true,
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{ldcache, ldconfig, ldcache},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{ldconfig, ldcache},
+				},
+			},
+		},
+		{
+			description: "hooks with different arguments are not duplicates",
+			deduplicate: true,
+			spec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{
+						ldcache,
+						{
+							Path: "/usr/bin/nvidia-ctk",
+							Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+						},
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{
+						ldcache,
+						{
+							Path: "/usr/bin/nvidia-ctk",
+							Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.HookOrdering.Position = tc.position
+			cfg.NVIDIAContainerRuntimeConfig.HookOrdering.Deduplicate = tc.deduplicate
+
+			m, err := NewHookOrderingModifier(logger, cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			err = m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
+
+func TestHookOrderingModifierNotConfigured(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	m, err := NewHookOrderingModifier(logger, &config.Config{})
+	require.NoError(t, err)
+	require.Nil(t, m)
+}
@@ -0,0 +1,145 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// idMappedMounts is a spec modifier that applies a wrapped modifier and updates the mounts and
+// device nodes added by it for containers that use a user namespace.
+type idMappedMounts struct {
+	logger   *logrus.Logger
+	modifier oci.SpecModifier
+}
+
+var _ oci.SpecModifier = (*idMappedMounts)(nil)
+
+// NewIDMappedMountsModifier wraps the specified modifier so that the bind mounts injected by it are
+// ID-mapped using the user namespace mappings of the container and the ownership of the device nodes
+// injected by it is translated to the container's ID space.
+// If ID-mapped mounts are not enabled in the config, the input modifier is returned.
+func NewIDMappedMountsModifier(logger *logrus.Logger, cfg *config.Config, modifier oci.SpecModifier) oci.SpecModifier {
+	if !cfg.NVIDIAContainerRuntimeConfig.IDMappedMounts || modifier == nil {
+		return modifier
+	}
+
+	m := idMappedMounts{
+		logger:   logger,
+		modifier: modifier,
+	}
+	return m
+}
+
+// Modify applies the wrapped modifier and, if the container uses a user namespace, updates the
+// injected mounts and device nodes.
+func (m idMappedMounts) Modify(spec *specs.Spec) error {
+	if !hasUserNamespace(spec) {
+		return m.modifier.Modify(spec)
+	}
+
+	existingMounts := make(map[string]bool)
+	for _, mount := range spec.Mounts {
+		existingMounts[mountKey(mount)] = true
+	}
+	existingDevices := make(map[string]bool)
+	for _, d := range spec.Linux.Devices {
+		existingDevices[d.Path] = true
+	}
+
+	if err := m.modifier.Modify(spec); err != nil {
+		return err
+	}
+
+	uidMappings := spec.Linux.UIDMappings
+	gidMappings := spec.Linux.GIDMappings
+
+	for i, mount := range spec.Mounts {
+		if existingMounts[mountKey(mount)] || !isBindMount(mount.Options) {
+			continue
+		}
+		if len(mount.UIDMappings) > 0 || len(mount.GIDMappings) > 0 {
+			continue
+		}
+		m.logger.Debugf("Using ID-mapped mount for %v", mount.Destination)
+		spec.Mounts[i].Options = toRecursiveBind(mount.Options)
+		spec.Mounts[i].UIDMappings = append([]specs.LinuxIDMapping{}, uidMappings...)
+		spec.Mounts[i].GIDMappings = append([]specs.LinuxIDMapping{}, gidMappings...)
+	}
+
+	for i, d := range spec.Linux.Devices {
+		if existingDevices[d.Path] {
+			continue
+		}
+		spec.Linux.Devices[i].UID = toContainerID(d.UID, uidMappings)
+		spec.Linux.Devices[i].GID = toContainerID(d.GID, gidMappings)
+	}
+
+	return nil
+}
+
+// isBindMount checks whether the specified mount options request a bind mount.
+func isBindMount(options []string) bool {
+	return contains(options, "bind") || contains(options, "rbind")
+}
+
+// toRecursiveBind returns a copy of the specified mount options with the bind option replaced by
+// rbind so that the ID mappings are also applied to the mounts below the source.
+func toRecursiveBind(options []string) []string {
+	var recursive []string
+	for _, o := range options {
+		if o == "bind" {
+			o = "rbind"
+		}
+		recursive = append(recursive, o)
+	}
+	return recursive
+}
+
+// hasUserNamespace checks whether the specified OCI specification defines a user namespace with ID mappings.
+func hasUserNamespace(spec *specs.Spec) bool {
+	if spec == nil || spec.Linux == nil {
+		return false
+	}
+	if len(spec.Linux.UIDMappings) == 0 && len(spec.Linux.GIDMappings) == 0 {
+		return false
+	}
+	for _, ns := range spec.Linux.Namespaces {
+		if ns.Type == specs.UserNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+// toContainerID translates a host ID to the corresponding ID in the container using the specified mappings.
+// If the ID is not mapped, nil is returned so that the default ownership is used.
+func toContainerID(hostID *uint32, mappings []specs.LinuxIDMapping) *uint32 {
+	if hostID == nil {
+		return nil
+	}
+	for _, m := range mappings {
+		if *hostID >= m.HostID && *hostID-m.HostID < m.Size {
+			containerID := m.ContainerID + (*hostID - m.HostID)
+			return &containerID
+		}
+	}
+	return nil
+}
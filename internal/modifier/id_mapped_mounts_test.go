@@ -0,0 +1,161 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDMappedMounts(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	mappings := []specs.LinuxIDMapping{
+		{ContainerID: 0, HostID: 100000, Size: 65536},
+	}
+	hostRoot := uint32(0)
+	hostMapped := uint32(100044)
+	containerMapped := uint32(44)
+
+	inject := modifierFunc(func(spec *specs.Spec) error {
+		spec.Mounts = append(spec.Mounts,
+			specs.Mount{
+				Source:      "/usr/bin/nvidia-smi",
+				Destination: "/usr/bin/nvidia-smi",
+				Options:     []string{"ro", "nosuid", "nodev", "bind"},
+			},
+		)
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		spec.Linux.Devices = append(spec.Linux.Devices,
+			specs.LinuxDevice{
+				Path: "/dev/nvidiactl",
+				UID:  &hostRoot,
+				GID:  &hostMapped,
+			},
+		)
+		return nil
+	})
+
+	testCases := []struct {
+		description  string
+		enabled      bool
+		spec         *specs.Spec
+		expectedSpec *specs.Spec
+	}{
+		{
+			description: "no user namespace",
+			enabled:     true,
+			spec:        &specs.Spec{},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/usr/bin/nvidia-smi",
+						Destination: "/usr/bin/nvidia-smi",
+						Options:     []string{"ro", "nosuid", "nodev", "bind"},
+					},
+				},
+				Linux: &specs.Linux{
+					Devices: []specs.LinuxDevice{
+						{Path: "/dev/nvidiactl", UID: &hostRoot, GID: &hostMapped},
+					},
+				},
+			},
+		},
+		{
+			description: "user namespace maps injected mounts and devices",
+			enabled:     true,
+			spec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{Source: "/data", Destination: "/data", Options: []string{"bind"}},
+				},
+				Linux: &specs.Linux{
+					Namespaces:  []specs.LinuxNamespace{{Type: specs.UserNamespace}},
+					UIDMappings: mappings,
+					GIDMappings: mappings,
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{Source: "/data", Destination: "/data", Options: []string{"bind"}},
+					{
+						Source:      "/usr/bin/nvidia-smi",
+						Destination: "/usr/bin/nvidia-smi",
+						Options:     []string{"ro", "nosuid", "nodev", "rbind"},
+						UIDMappings: mappings,
+						GIDMappings: mappings,
+					},
+				},
+				Linux: &specs.Linux{
+					Namespaces:  []specs.LinuxNamespace{{Type: specs.UserNamespace}},
+					UIDMappings: mappings,
+					GIDMappings: mappings,
+					Devices: []specs.LinuxDevice{
+						{Path: "/dev/nvidiactl", UID: nil, GID: &containerMapped},
+					},
+				},
+			},
+		},
+		{
+			description: "disabled",
+			spec: &specs.Spec{
+				Linux: &specs.Linux{
+					Namespaces:  []specs.LinuxNamespace{{Type: specs.UserNamespace}},
+					UIDMappings: mappings,
+					GIDMappings: mappings,
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/usr/bin/nvidia-smi",
+						Destination: "/usr/bin/nvidia-smi",
+						Options:     []string{"ro", "nosuid", "nodev", "bind"},
+					},
+				},
+				Linux: &specs.Linux{
+					Namespaces:  []specs.LinuxNamespace{{Type: specs.UserNamespace}},
+					UIDMappings: mappings,
+					GIDMappings: mappings,
+					Devices: []specs.LinuxDevice{
+						{Path: "/dev/nvidiactl", UID: &hostRoot, GID: &hostMapped},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.IDMappedMounts = tc.enabled
+
+			m := NewIDMappedMountsModifier(logger, cfg, inject)
+
+			err := m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
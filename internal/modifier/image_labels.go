@@ -0,0 +1,121 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// imageLabelsSpec wraps an OCI specification and translates the device and capability requests in
+// image labels to the equivalent environment variables each time the specification is loaded.
+// Since the translated environment variables are also written to the OCI specification, the requests
+// are honoured by all modifiers as well as the NVIDIA Container Runtime Hook.
+type imageLabelsSpec struct {
+	oci.Spec
+	logger       *logrus.Logger
+	preferLabels bool
+}
+
+// NewImageLabelsSpec returns an OCI specification for which image labels are considered as a
+// source of device requests. Container engines expose image labels to the low-level runtime as
+// annotations in the OCI specification. If image labels are not enabled in the config, the
+// specified OCI specification is returned unchanged.
+func NewImageLabelsSpec(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.Spec, error) {
+	c := cfg.NVIDIAContainerRuntimeConfig.ImageLabels
+	if !c.Enabled {
+		return ociSpec, nil
+	}
+
+	var preferLabels bool
+	switch c.Precedence {
+	case "", config.ImageLabelPrecedenceEnvvar:
+	case config.ImageLabelPrecedenceLabel:
+		preferLabels = true
+	default:
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid image-labels.precedence %q", c.Precedence))
+	}
+
+	s := imageLabelsSpec{
+		Spec:         ociSpec,
+		logger:       logger,
+		preferLabels: preferLabels,
+	}
+	return &s, nil
+}
+
+// Load loads the OCI specification and applies the requests from the image labels.
+func (s *imageLabelsSpec) Load() (*specs.Spec, error) {
+	spec, err := s.Spec.Load()
+	if err != nil {
+		return nil, err
+	}
+	s.apply(spec)
+	return spec, nil
+}
+
+// apply sets the environment variables for the requests in the image labels of the specified spec.
+func (s *imageLabelsSpec) apply(spec *specs.Spec) {
+	if spec == nil {
+		return
+	}
+	labelEnv := image.EnvFromLabels(spec.Annotations)
+	if len(labelEnv) == 0 {
+		return
+	}
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+
+	var names []string
+	for name := range labelEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := labelEnv[name]
+		i := indexOfEnv(spec.Process.Env, name)
+		switch {
+		case i < 0:
+			s.logger.Debugf("Setting %v=%v from image labels", name, value)
+			spec.Process.Env = append(spec.Process.Env, name+"="+value)
+		case s.preferLabels:
+			s.logger.Debugf("Overriding %v with %q from image labels", spec.Process.Env[i], value)
+			spec.Process.Env[i] = name + "=" + value
+		default:
+			s.logger.Debugf("Ignoring image label request %v=%v; environment variable is set", name, value)
+		}
+	}
+}
+
+// indexOfEnv returns the index of the specified environment variable in env, or -1 if it is not set.
+func indexOfEnv(env []string, name string) int {
+	for i, e := range env {
+		if strings.SplitN(e, "=", 2)[0] == name {
+			return i
+		}
+	}
+	return -1
+}
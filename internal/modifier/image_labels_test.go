@@ -0,0 +1,117 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageLabelsSpec(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		precedence    string
+		env           []string
+		annotations   map[string]string
+		expectedEnv   []string
+		expectedError bool
+	}{
+		{
+			description: "no labels",
+			env:         []string{"PATH=/bin"},
+			expectedEnv: []string{"PATH=/bin"},
+		},
+		{
+			description: "labels set envvars",
+			env:         []string{"PATH=/bin"},
+			annotations: map[string]string{
+				"com.nvidia.devices":      "0",
+				"com.nvidia.capabilities": "compute",
+			},
+			expectedEnv: []string{"PATH=/bin", "NVIDIA_DRIVER_CAPABILITIES=compute", "NVIDIA_VISIBLE_DEVICES=0"},
+		},
+		{
+			description: "envvars take precedence",
+			precedence:  "envvar",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=1"},
+			annotations: map[string]string{
+				"com.nvidia.devices": "0",
+			},
+			expectedEnv: []string{"NVIDIA_VISIBLE_DEVICES=1"},
+		},
+		{
+			description: "labels take precedence",
+			precedence:  "label",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=1"},
+			annotations: map[string]string{
+				"com.nvidia.devices": "0",
+			},
+			expectedEnv: []string{"NVIDIA_VISIBLE_DEVICES=0"},
+		},
+		{
+			description:   "invalid precedence",
+			precedence:    "image",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.ImageLabels.Enabled = true
+			cfg.NVIDIAContainerRuntimeConfig.ImageLabels.Precedence = tc.precedence
+
+			raw := &specs.Spec{
+				Process:     &specs.Process{Env: tc.env},
+				Annotations: tc.annotations,
+			}
+
+			ociSpec, err := NewImageLabelsSpec(logger, cfg, oci.NewMemorySpec(raw))
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindConfig, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+
+			spec, err := ociSpec.Load()
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEnv, spec.Process.Env)
+
+			// Loading the spec again does not duplicate the envvars.
+			spec, err = ociSpec.Load()
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEnv, spec.Process.Env)
+		})
+	}
+}
+
+func TestImageLabelsSpecDisabled(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	ociSpec := oci.NewMemorySpec(&specs.Spec{})
+	s, err := NewImageLabelsSpec(logger, &config.Config{}, ociSpec)
+	require.NoError(t, err)
+	require.Equal(t, ociSpec, s)
+}
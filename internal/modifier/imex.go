@@ -0,0 +1,140 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nvidiaIMEXChannelsEnvvar = "NVIDIA_IMEX_CHANNELS"
+	nvidiaIMEXDomainEnvvar   = "NVIDIA_IMEX_DOMAIN"
+)
+
+// imexModifier injects IMEX channels and configuration files and sets the IMEX domain for a container.
+type imexModifier struct {
+	oci.SpecModifier
+	domain string
+}
+
+// NewIMEXModifier creates the modifiers for IMEX channels.
+// If the spec does not contain the NVIDIA_IMEX_CHANNELS environment variable no changes are made.
+func NewIMEXModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	container, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// We check for the envvar explicitly since legacy images default to all devices if it is unset.
+	if _, ok := container[nvidiaIMEXChannelsEnvvar]; !ok {
+		return nil, nil
+	}
+	channels := container.DevicesFromEnvvars(nvidiaIMEXChannelsEnvvar).List()
+	if len(channels) == 0 || channels[0] == "" {
+		return nil, nil
+	}
+
+	domain := cfg.NVIDIAContainerRuntimeConfig.IMEX.Domain
+	if requested, ok := container[nvidiaIMEXDomainEnvvar]; ok && requested != domain {
+		return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("requested IMEX domain %q does not match the domain of the node %q", requested, domain))
+	}
+
+	root := cfg.NVIDIAContainerCLIConfig.Root
+	locator := lookup.NewCharDeviceLocator(
+		lookup.WithLogger(logger),
+		lookup.WithRoot(root),
+	)
+	channelPaths, err := getIMEXChannelPaths(locator, channels)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindUnsupportedRequest, err)
+	}
+	logger.Debugf("Injecting IMEX channels %v", channelPaths)
+
+	d := discover.NewIMEXDiscoverer(logger, root, channelPaths, cfg.NVIDIAContainerRuntimeConfig.IMEX.ConfigDir)
+	discoverModifier, err := NewModifierFromDiscoverer(logger, d)
+	if err != nil {
+		return nil, err
+	}
+
+	m := imexModifier{
+		SpecModifier: discoverModifier,
+		domain:       domain,
+	}
+	return m, nil
+}
+
+// Modify injects the IMEX channels and configuration files and sets the NVIDIA_IMEX_DOMAIN envvar if a domain is configured.
+func (m imexModifier) Modify(spec *specs.Spec) error {
+	if err := m.SpecModifier.Modify(spec); err != nil {
+		return err
+	}
+
+	if m.domain == "" {
+		return nil
+	}
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	envvar := nvidiaIMEXDomainEnvvar + "=" + m.domain
+	for _, e := range spec.Process.Env {
+		if e == envvar {
+			return nil
+		}
+	}
+	spec.Process.Env = append(spec.Process.Env, envvar)
+	return nil
+}
+
+// getIMEXChannelPaths returns the paths of the requested IMEX channels.
+// An error is returned if a requested channel does not exist.
+func getIMEXChannelPaths(locator lookup.Locator, channels []string) ([]string, error) {
+	if len(channels) == 1 && channels[0] == "all" {
+		pattern := filepath.Join(discover.IMEXChannelsPath, "channel*")
+		if found, err := locator.Locate(pattern); err != nil || len(found) == 0 {
+			return nil, fmt.Errorf("no IMEX channels found at %v", discover.IMEXChannelsPath)
+		}
+		return []string{pattern}, nil
+	}
+
+	var paths []string
+	for _, channel := range channels {
+		if _, err := strconv.ParseUint(channel, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid IMEX channel %q", channel)
+		}
+		path := filepath.Join(discover.IMEXChannelsPath, "channel"+channel)
+		if _, err := locator.Locate(path); err != nil {
+			return nil, fmt.Errorf("requested IMEX channel %v does not exist: %v", channel, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,157 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIMEXChannelPaths(t *testing.T) {
+	existing := map[string]bool{
+		"/dev/nvidia-caps-imex-channels/channel0": true,
+		"/dev/nvidia-caps-imex-channels/channel*": true,
+	}
+	locator := &lookup.LocatorMock{
+		LocateFunc: func(s string) ([]string, error) {
+			if existing[s] {
+				return []string{s}, nil
+			}
+			return nil, fmt.Errorf("not found")
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		channels      []string
+		expectedPaths []string
+		expectedError bool
+	}{
+		{
+			description:   "all channels",
+			channels:      []string{"all"},
+			expectedPaths: []string{"/dev/nvidia-caps-imex-channels/channel*"},
+		},
+		{
+			description:   "existing channel",
+			channels:      []string{"0"},
+			expectedPaths: []string{"/dev/nvidia-caps-imex-channels/channel0"},
+		},
+		{
+			description:   "missing channel",
+			channels:      []string{"0", "1"},
+			expectedError: true,
+		},
+		{
+			description:   "invalid channel",
+			channels:      []string{"../nvidiactl"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			paths, err := getIMEXChannelPaths(locator, tc.channels)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedPaths, paths)
+		})
+	}
+}
+
+func TestNewIMEXModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description       string
+		env               []string
+		domain            string
+		expectNilModifier bool
+		expectedErrorKind oci.ErrorKind
+	}{
+		{
+			description:       "no channels requested",
+			env:               []string{"CUDA_VERSION=12.0"},
+			expectNilModifier: true,
+		},
+		{
+			description:       "none requested",
+			env:               []string{"NVIDIA_IMEX_CHANNELS=none"},
+			expectNilModifier: true,
+		},
+		{
+			description:       "mismatched domain",
+			env:               []string{"NVIDIA_IMEX_CHANNELS=0", "NVIDIA_IMEX_DOMAIN=other"},
+			domain:            "nvl72-a",
+			expectedErrorKind: oci.ErrorKindUnsupportedRequest,
+		},
+		{
+			description:       "missing channel",
+			env:               []string{"NVIDIA_IMEX_CHANNELS=999"},
+			expectedErrorKind: oci.ErrorKindUnsupportedRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerCLIConfig: config.ContainerCLIConfig{
+					Root: t.TempDir(),
+				},
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.IMEX.Domain = tc.domain
+
+			ociSpec := &oci.SpecMock{
+				LoadFunc: func() (*specs.Spec, error) {
+					return &specs.Spec{Process: &specs.Process{Env: tc.env}}, nil
+				},
+			}
+
+			m, err := NewIMEXModifier(logger, cfg, ociSpec)
+			if tc.expectNilModifier {
+				require.NoError(t, err)
+				require.Nil(t, m)
+				return
+			}
+			require.Error(t, err)
+			require.Equal(t, tc.expectedErrorKind, oci.GetErrorKind(err))
+		})
+	}
+}
+
+func TestIMEXModifierSetsDomain(t *testing.T) {
+	m := imexModifier{
+		SpecModifier: modifierFunc(func(*specs.Spec) error { return nil }),
+		domain:       "nvl72-a",
+	}
+
+	spec := &specs.Spec{}
+	require.NoError(t, m.Modify(spec))
+	require.NoError(t, m.Modify(spec))
+	require.Equal(t, []string{"NVIDIA_IMEX_DOMAIN=nvl72-a"}, spec.Process.Env)
+}
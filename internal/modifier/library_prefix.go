@@ -0,0 +1,192 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// prefixedLibraryDirs are the 64-bit library directories in containers. Injected libraries in these
+// directories are mounted under the library prefix instead.
+var prefixedLibraryDirs = func() map[string]bool {
+	dirs := make(map[string]bool)
+	for _, dir := range lookup.LibraryDirs() {
+		dirs[dir] = true
+	}
+	return dirs
+}()
+
+// libraryPrefix is a spec modifier that applies a wrapped modifier and moves the libraries injected
+// by it from the standard library directories to a dedicated directory.
+type libraryPrefix struct {
+	logger        *logrus.Logger
+	prefix        string
+	nvidiaCTKPath string
+	modifier      oci.SpecModifier
+}
+
+var _ oci.SpecModifier = (*libraryPrefix)(nil)
+
+// NewLibraryPrefixModifier wraps the specified modifier so that the libraries injected by it into
+// the standard library directories are mounted under the configured library prefix instead. This
+// prevents the injected libraries from shadowing (or being shadowed by) libraries in the image.
+// If no library prefix is configured, the input modifier is returned.
+func NewLibraryPrefixModifier(logger *logrus.Logger, cfg *config.Config, modifier oci.SpecModifier) (oci.SpecModifier, error) {
+	prefix := cfg.NVIDIAContainerRuntimeConfig.LibraryPrefix
+	if prefix == "" || modifier == nil {
+		return modifier, nil
+	}
+
+	prefix = filepath.Clean(prefix)
+	if !filepath.IsAbs(prefix) || prefix == "/" || prefixedLibraryDirs[prefix] {
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid library prefix %q: must be an absolute path that is not a standard library directory", cfg.NVIDIAContainerRuntimeConfig.LibraryPrefix))
+	}
+
+	m := libraryPrefix{
+		logger:        logger,
+		prefix:        prefix,
+		nvidiaCTKPath: discover.FindNvidiaCTK(logger, cfg.NVIDIACTKConfig.Path),
+		modifier:      modifier,
+	}
+	return m, nil
+}
+
+// Modify applies the wrapped modifier and moves the injected libraries under the library prefix.
+// The hooks that update the ldcache and create symlinks for the injected libraries are updated
+// accordingly. If no ldcache update hook was injected, one is added for the library prefix.
+func (m libraryPrefix) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return m.modifier.Modify(spec)
+	}
+
+	existingMounts := make(map[string]bool)
+	destinations := make(map[string]bool)
+	for _, mount := range spec.Mounts {
+		existingMounts[mountKey(mount)] = true
+		destinations[mount.Destination] = true
+	}
+	var existingHooks int
+	if spec.Hooks != nil {
+		existingHooks = len(spec.Hooks.CreateContainer)
+	}
+
+	if err := m.modifier.Modify(spec); err != nil {
+		return err
+	}
+
+	remapped := make(map[string]string)
+	var libraries []string
+	for i, mount := range spec.Mounts {
+		if existingMounts[mountKey(mount)] || !m.isPrefixed(mount.Destination) {
+			continue
+		}
+		destination := filepath.Join(m.prefix, filepath.Base(mount.Destination))
+		if destinations[destination] {
+			m.logger.Warnf("Not moving %v to %v: a mount at this path already exists", mount.Destination, destination)
+			continue
+		}
+		m.logger.Debugf("Moving injected library %v to %v", mount.Destination, destination)
+		remapped[mount.Destination] = destination
+		destinations[destination] = true
+		libraries = append(libraries, destination)
+		spec.Mounts[i].Destination = destination
+	}
+	if len(remapped) == 0 {
+		return nil
+	}
+
+	var hasLDCacheHook bool
+	if spec.Hooks != nil {
+		for i := existingHooks; i < len(spec.Hooks.CreateContainer); i++ {
+			hook := &spec.Hooks.CreateContainer[i]
+			switch {
+			case isNVIDIACTKHook(hook, "update-ldcache"):
+				hook.Args = m.updateLDCacheArgs(hook.Args)
+				hasLDCacheHook = true
+			case isNVIDIACTKHook(hook, "create-symlinks"):
+				hook.Args = m.updateSymlinkArgs(hook.Args, remapped)
+			}
+		}
+	}
+	if hasLDCacheHook {
+		return nil
+	}
+
+	ldcacheUpdate, err := NewModifierFromDiscoverer(m.logger, discover.CreateLDCacheUpdateHook(m.nvidiaCTKPath, libraries))
+	if err != nil {
+		return err
+	}
+	return ldcacheUpdate.Modify(spec)
+}
+
+// isPrefixed checks whether the specified path is a library in one of the standard library directories.
+func (m libraryPrefix) isPrefixed(path string) bool {
+	if !prefixedLibraryDirs[filepath.Dir(path)] {
+		return false
+	}
+	isLibrary, _ := filepath.Match("lib?*.so*", filepath.Base(path))
+	return isLibrary
+}
+
+// updateLDCacheArgs ensures that the library prefix is included in the folders of an ldcache update hook.
+func (m libraryPrefix) updateLDCacheArgs(args []string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--folder" && args[i+1] == m.prefix {
+			return args
+		}
+	}
+	return append(args, "--folder", m.prefix)
+}
+
+// updateSymlinkArgs moves the links in the standard library directories that are created by a
+// create-symlinks hook to the library prefix. Absolute targets that were moved are also updated.
+func (m libraryPrefix) updateSymlinkArgs(args []string, remapped map[string]string) []string {
+	updated := append([]string{}, args...)
+	for i := 0; i < len(updated)-1; i++ {
+		if updated[i] != "--link" {
+			continue
+		}
+		parts := strings.Split(updated[i+1], "::")
+		if len(parts) != 2 {
+			continue
+		}
+		target, link := parts[0], parts[1]
+		if destination, ok := remapped[target]; ok {
+			target = destination
+		}
+		if m.isPrefixed(link) {
+			link = filepath.Join(m.prefix, filepath.Base(link))
+		}
+		updated[i+1] = target + "::" + link
+	}
+	return updated
+}
+
+// isNVIDIACTKHook checks whether the specified hook invokes the specified nvidia-ctk hook subcommand.
+func isNVIDIACTKHook(hook *specs.Hook, name string) bool {
+	return privileges.IsNVIDIACTKHook(hook.Path, hook.Args) && len(hook.Args) > 2 && hook.Args[2] == name
+}
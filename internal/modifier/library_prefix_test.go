@@ -0,0 +1,187 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPrefixModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	libraryMount := specs.Mount{
+		Source:      "/usr/lib/x86_64-linux-gnu/libcuda.so.999.99",
+		Destination: "/usr/lib/x86_64-linux-gnu/libcuda.so.999.99",
+		Options:     []string{"ro", "nosuid", "nodev", "bind"},
+	}
+	binaryMount := specs.Mount{
+		Source:      "/usr/bin/nvidia-smi",
+		Destination: "/usr/bin/nvidia-smi",
+		Options:     []string{"ro", "nosuid", "nodev", "bind"},
+	}
+	subdirMount := specs.Mount{
+		Source:      "/usr/lib/x86_64-linux-gnu/vdpau/libvdpau_nvidia.so.999.99",
+		Destination: "/usr/lib/x86_64-linux-gnu/vdpau/libvdpau_nvidia.so.999.99",
+		Options:     []string{"ro", "nosuid", "nodev", "bind"},
+	}
+
+	testCases := []struct {
+		description   string
+		prefix        string
+		spec          *specs.Spec
+		mounts        []specs.Mount
+		hooks         []specs.Hook
+		expectedError bool
+		expectedSpec  *specs.Spec
+	}{
+		{
+			description: "no prefix leaves mounts unchanged",
+			spec:        &specs.Spec{},
+			mounts:      []specs.Mount{libraryMount},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{libraryMount},
+			},
+		},
+		{
+			description:   "relative prefix is invalid",
+			prefix:        "nvidia-host",
+			expectedError: true,
+		},
+		{
+			description:   "standard library directory is invalid",
+			prefix:        "/usr/lib64/",
+			expectedError: true,
+		},
+		{
+			description: "libraries are moved and ldcache hook is updated",
+			prefix:      "/usr/lib/nvidia-host",
+			spec:        &specs.Spec{},
+			mounts:      []specs.Mount{libraryMount, binaryMount, subdirMount},
+			hooks: []specs.Hook{
+				{
+					Path: "/usr/bin/nvidia-ctk",
+					Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib/x86_64-linux-gnu"},
+				},
+				{
+					Path: "/usr/bin/nvidia-ctk",
+					Args: []string{"nvidia-ctk", "hook", "create-symlinks",
+						"--link", "libcuda.so.999.99::/usr/lib/x86_64-linux-gnu/libcuda.so.1",
+						"--link", "/usr/lib/x86_64-linux-gnu/libcuda.so.999.99::/usr/local/cuda/lib64/libcuda.so",
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/usr/lib/x86_64-linux-gnu/libcuda.so.999.99",
+						Destination: "/usr/lib/nvidia-host/libcuda.so.999.99",
+						Options:     []string{"ro", "nosuid", "nodev", "bind"},
+					},
+					binaryMount,
+					subdirMount,
+				},
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{
+						{
+							Path: "/usr/bin/nvidia-ctk",
+							Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib/x86_64-linux-gnu", "--folder", "/usr/lib/nvidia-host"},
+						},
+						{
+							Path: "/usr/bin/nvidia-ctk",
+							Args: []string{"nvidia-ctk", "hook", "create-symlinks",
+								"--link", "libcuda.so.999.99::/usr/lib/nvidia-host/libcuda.so.1",
+								"--link", "/usr/lib/nvidia-host/libcuda.so.999.99::/usr/local/cuda/lib64/libcuda.so",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "ldcache hook is added if required",
+			prefix:      "/usr/lib/nvidia-host",
+			spec:        &specs.Spec{},
+			mounts:      []specs.Mount{libraryMount},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/usr/lib/x86_64-linux-gnu/libcuda.so.999.99",
+						Destination: "/usr/lib/nvidia-host/libcuda.so.999.99",
+						Options:     []string{"ro", "nosuid", "nodev", "bind"},
+					},
+				},
+				Hooks: &specs.Hooks{
+					CreateContainer: []specs.Hook{
+						{
+							Path: "/usr/bin/nvidia-ctk",
+							Args: []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib/nvidia-host"},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "existing mounts are not moved",
+			prefix:      "/usr/lib/nvidia-host",
+			spec: &specs.Spec{
+				Mounts: []specs.Mount{libraryMount},
+			},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{libraryMount},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: config.RuntimeConfig{
+					LibraryPrefix: tc.prefix,
+				},
+				NVIDIACTKConfig: config.CTKConfig{
+					Path: "/usr/bin/nvidia-ctk",
+				},
+			}
+			inject := modifierFunc(func(spec *specs.Spec) error {
+				spec.Mounts = append(spec.Mounts, tc.mounts...)
+				if len(tc.hooks) > 0 {
+					if spec.Hooks == nil {
+						spec.Hooks = &specs.Hooks{}
+					}
+					spec.Hooks.CreateContainer = append(spec.Hooks.CreateContainer, tc.hooks...)
+				}
+				return nil
+			})
+
+			m, err := NewLibraryPrefixModifier(logger, cfg, inject)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			err = m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
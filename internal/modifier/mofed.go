@@ -22,7 +22,7 @@ import (
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/sirupsen/logrus"
 )
 
@@ -52,7 +52,9 @@ func NewMOFEDModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spe
 		return nil, nil
 	}
 
-	d, err := discover.NewMOFEDDiscoverer(logger, cfg.NVIDIAContainerCLIConfig.Root)
+	d, err := newDriverRootsDiscoverer(logger, cfg, func(root string) (discover.Discover, error) {
+		return discover.NewMOFEDDiscoverer(logger, root)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct discoverer for MOFED devices: %v", err)
 	}
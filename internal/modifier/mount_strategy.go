@@ -0,0 +1,67 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/sirupsen/logrus"
+)
+
+// withMountStrategy applies the mount strategy from the specified config to a discoverer.
+// Files from a staged driver root are preferred over the files on the host if available.
+// For the driver-root and copy strategies, the discovered files are staged (or copied) and injected
+// using a single mount. The discovered files are verified against the checksum manifest before
+// these are relocated.
+func withMountStrategy(logger *logrus.Logger, cfg *config.Config, d discover.Discover) (discover.Discover, error) {
+	d, err := withChecksumVerification(logger, cfg, d)
+	if err != nil {
+		return nil, err
+	}
+	d = discover.NewStagedDiscoverer(logger, d, cfg.NVIDIAContainerRuntimeConfig.StagedDriverRoot)
+
+	switch cfg.NVIDIAContainerRuntimeConfig.MountStrategy {
+	case config.MountStrategyDriverRoot:
+		driverRootMount := cfg.NVIDIAContainerRuntimeConfig.DriverRootMount
+		logger.Debugf("Using driver-root mount strategy with staging directory %v", driverRootMount.StagingDir)
+		return discover.NewDriverRootDiscoverer(logger, d, driverRootMount.StagingDir, driverRootMount.ContainerPath), nil
+	case config.MountStrategyCopy:
+		driverCopy := cfg.NVIDIAContainerRuntimeConfig.DriverCopy
+		logger.Debugf("Using copy mount strategy with copy directory %v", driverCopy.Dir)
+		return discover.NewDriverCopyDiscoverer(logger, d, driverCopy.Dir, driverCopy.ContainerPath), nil
+	case "", config.MountStrategyIndividual:
+	default:
+		logger.WithField(events.Field, events.UnsupportedMountStrategy).Warnf("Ignoring unsupported mount strategy %q", cfg.NVIDIAContainerRuntimeConfig.MountStrategy)
+	}
+	return d, nil
+}
+
+// ValidateMountStrategy checks whether the configured mount strategy is supported in the specified mode.
+// The files injected in legacy mode (by the NVIDIA Container Runtime Hook) and in cdi mode (as defined in
+// the CDI specs) are not discovered by the NVIDIA Container Runtime, meaning that these cannot be staged.
+func ValidateMountStrategy(mode string, cfg *config.Config) error {
+	switch strategy := cfg.NVIDIAContainerRuntimeConfig.MountStrategy; strategy {
+	case config.MountStrategyDriverRoot, config.MountStrategyCopy:
+		if mode != "csv" {
+			return fmt.Errorf("mount strategy %q is not supported in %v mode", strategy, mode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMountStrategy(t *testing.T) {
+	testCases := []struct {
+		description   string
+		mode          string
+		mountStrategy string
+		expectedError bool
+	}{
+		{
+			description:   "individual mounts are supported in legacy mode",
+			mode:          "legacy",
+			mountStrategy: config.MountStrategyIndividual,
+		},
+		{
+			description:   "driver-root is supported in csv mode",
+			mode:          "csv",
+			mountStrategy: config.MountStrategyDriverRoot,
+		},
+		{
+			description:   "driver-root is not supported in legacy mode",
+			mode:          "legacy",
+			mountStrategy: config.MountStrategyDriverRoot,
+			expectedError: true,
+		},
+		{
+			description:   "driver-root is not supported in cdi mode",
+			mode:          "cdi",
+			mountStrategy: config.MountStrategyDriverRoot,
+			expectedError: true,
+		},
+		{
+			description:   "copy is supported in csv mode",
+			mode:          "csv",
+			mountStrategy: config.MountStrategyCopy,
+		},
+		{
+			description:   "copy is not supported in legacy mode",
+			mode:          "legacy",
+			mountStrategy: config.MountStrategyCopy,
+			expectedError: true,
+		},
+		{
+			description:   "copy is not supported in cdi mode",
+			mode:          "cdi",
+			mountStrategy: config.MountStrategyCopy,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: config.RuntimeConfig{
+					MountStrategy: tc.mountStrategy,
+				},
+			}
+			err := ValidateMountStrategy(tc.mode, cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// nvidiaCTKHooks is a spec modifier that updates the nvidia-ctk hooks in the spec to run with the
+// configured privileges, timeout, and failure policy.
+type nvidiaCTKHooks struct {
+	logger     *logrus.Logger
+	privileges *privileges.Privileges
+	policy     *hookpolicy.Policy
+}
+
+var _ oci.SpecModifier = (*nvidiaCTKHooks)(nil)
+
+// NewNVIDIACTKHooksModifier creates a modifier that updates the nvidia-ctk hooks in the OCI spec
+// (including those injected from CDI specifications) to run as the configured user with only the
+// configured capabilities, and with the configured timeout and failure policy. If none of these
+// are configured, nil is returned.
+func NewNVIDIACTKHooksModifier(logger *logrus.Logger, cfg *config.Config) (oci.SpecModifier, error) {
+	p, err := privileges.New(cfg.NVIDIACTKConfig.Hooks.User, cfg.NVIDIACTKConfig.Hooks.Capabilities)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, err)
+	}
+	policy, err := hookpolicy.Parse(cfg.NVIDIACTKConfig.Hooks.Timeout, cfg.NVIDIACTKConfig.Hooks.FailurePolicy)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, err)
+	}
+	if p == nil && policy == nil {
+		return nil, nil
+	}
+
+	m := nvidiaCTKHooks{
+		logger:     logger,
+		privileges: p,
+		policy:     policy,
+	}
+	return &m, nil
+}
+
+// Modify updates the arguments of the nvidia-ctk hooks in the spec.
+func (m nvidiaCTKHooks) Modify(spec *specs.Spec) error {
+	if spec == nil || spec.Hooks == nil {
+		return nil
+	}
+
+	for _, hooks := range [][]specs.Hook{
+		spec.Hooks.Prestart,
+		spec.Hooks.CreateRuntime,
+		spec.Hooks.CreateContainer,
+		spec.Hooks.StartContainer,
+		spec.Hooks.Poststart,
+		spec.Hooks.Poststop,
+	} {
+		for i, hook := range hooks {
+			if !privileges.IsNVIDIACTKHook(hook.Path, hook.Args) {
+				continue
+			}
+			args := m.privileges.HookArgs(hook.Path, hook.Args)
+			args = m.policy.HookArgs(hook.Path, args)
+			hooks[i].Args = args
+			m.logger.Debugf("Updated arguments of hook %v: %v", hook.Path, args)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNVIDIACTKHooksModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{}
+	m, err := NewNVIDIACTKHooksModifier(logger, cfg)
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	cfg.NVIDIACTKConfig.Hooks.User = "invalid"
+	_, err = NewNVIDIACTKHooksModifier(logger, cfg)
+	require.Error(t, err)
+
+	cfg.NVIDIACTKConfig.Hooks.User = ""
+	cfg.NVIDIACTKConfig.Hooks.Timeout = "invalid"
+	_, err = NewNVIDIACTKHooksModifier(logger, cfg)
+	require.Error(t, err)
+
+	cfg.NVIDIACTKConfig.Hooks.User = "65534"
+	cfg.NVIDIACTKConfig.Hooks.Capabilities = []string{"CAP_DAC_OVERRIDE"}
+	cfg.NVIDIACTKConfig.Hooks.Timeout = "30s"
+	cfg.NVIDIACTKConfig.Hooks.FailurePolicy = "fail-open"
+	m, err = NewNVIDIACTKHooksModifier(logger, cfg)
+	require.NoError(t, err)
+
+	spec := &specs.Spec{
+		Hooks: &specs.Hooks{
+			Prestart: []specs.Hook{
+				{Path: "/usr/bin/nvidia-container-runtime-hook", Args: []string{"nvidia-container-runtime-hook", "prestart"}},
+			},
+			CreateContainer: []specs.Hook{
+				{Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "a::b"}},
+				{Path: "/usr/bin/other", Args: []string{"other", "hook"}},
+			},
+		},
+	}
+	require.NoError(t, m.Modify(spec))
+
+	require.Equal(t, &specs.Hooks{
+		Prestart: []specs.Hook{
+			{Path: "/usr/bin/nvidia-container-runtime-hook", Args: []string{"nvidia-container-runtime-hook", "prestart"}},
+		},
+		CreateContainer: []specs.Hook{
+			{Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "--timeout=30s", "--failure-policy=fail-open", "--user=65534:65534", "--capabilities=CAP_DAC_OVERRIDE", "create-symlinks", "--link", "a::b"}},
+			{Path: "/usr/bin/other", Args: []string{"other", "hook"}},
+		},
+	}, spec.Hooks)
+}
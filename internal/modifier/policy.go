@@ -0,0 +1,52 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/policy"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckPolicy evaluates the policies configured in the nvidia-container-runtime.policy section of the
+// config for the container (see policy.Evaluate) and returns an error if the container violates these.
+// Containers that do not request any devices are always allowed.
+func CheckPolicy(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) error {
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	cudaImage, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return err
+	}
+
+	result, err := policy.Evaluate(logger, cfg, cudaImage)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policies: %v", err)
+	}
+	if !result.Allowed {
+		return oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("container violates the configured policies: %v", strings.Join(result.Reasons, "; ")))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.Policy.ForbiddenCapabilities = []string{"display"}
+	cfg.NVIDIAContainerRuntimeConfig.Policy.AllowedDevices = []string{"all", "GPU-*"}
+
+	testCases := []struct {
+		description   string
+		env           []string
+		expectedError bool
+	}{
+		{
+			description: "no devices requested",
+			env:         []string{"NVIDIA_DRIVER_CAPABILITIES=display"},
+		},
+		{
+			description: "none requested",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=none", "NVIDIA_DRIVER_CAPABILITIES=display"},
+		},
+		{
+			description: "allowed device",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=GPU-1234", "NVIDIA_DRIVER_CAPABILITIES=compute"},
+		},
+		{
+			description:   "device not allowed",
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			expectedError: true,
+		},
+		{
+			description:   "forbidden capability",
+			env:           []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=display"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ociSpec := oci.NewMemorySpec(&specs.Spec{Process: &specs.Process{Env: tc.env}})
+
+			err := CheckPolicy(logger, cfg, ociSpec)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindUnsupportedRequest, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
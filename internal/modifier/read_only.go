@@ -0,0 +1,130 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// readOnlyInjection is a spec modifier that applies a wrapped modifier and hardens the
+// mounts and device cgroup rules that were added by it.
+type readOnlyInjection struct {
+	logger   *logrus.Logger
+	modifier oci.SpecModifier
+}
+
+var _ oci.SpecModifier = (*readOnlyInjection)(nil)
+
+// NewReadOnlyInjectionModifier wraps the specified modifier so that all mounts injected by it are
+// read-only, nosuid, and nodev and all device cgroup rules injected by it do not allow mknod.
+// If read-only injection is not enabled in the config, the input modifier is returned.
+func NewReadOnlyInjectionModifier(logger *logrus.Logger, cfg *config.Config, modifier oci.SpecModifier) oci.SpecModifier {
+	if !cfg.NVIDIAContainerRuntimeConfig.ReadOnlyInjection || modifier == nil {
+		return modifier
+	}
+
+	m := readOnlyInjection{
+		logger:   logger,
+		modifier: modifier,
+	}
+	return m
+}
+
+// Modify applies the wrapped modifier and hardens the injected mounts and device cgroup rules.
+func (m readOnlyInjection) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return m.modifier.Modify(spec)
+	}
+
+	existingMounts := make(map[string]bool)
+	for _, mount := range spec.Mounts {
+		existingMounts[mountKey(mount)] = true
+	}
+	var existingDeviceRules int
+	if spec.Linux != nil && spec.Linux.Resources != nil {
+		existingDeviceRules = len(spec.Linux.Resources.Devices)
+	}
+
+	if err := m.modifier.Modify(spec); err != nil {
+		return err
+	}
+
+	for i, mount := range spec.Mounts {
+		if existingMounts[mountKey(mount)] {
+			continue
+		}
+		spec.Mounts[i].Options = hardenMountOptions(mount.Options)
+		m.logger.Debugf("Using options %v for injected mount %v", spec.Mounts[i].Options, mount.Destination)
+	}
+
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil
+	}
+	for i := existingDeviceRules; i < len(spec.Linux.Resources.Devices); i++ {
+		rule := &spec.Linux.Resources.Devices[i]
+		if !rule.Allow {
+			continue
+		}
+		rule.Access = strings.ReplaceAll(rule.Access, "m", "")
+	}
+
+	return nil
+}
+
+// mountKey returns a key that identifies a mount, ignoring its options.
+func mountKey(m specs.Mount) string {
+	return m.Type + ":" + m.Source + ":" + m.Destination
+}
+
+// hardenMountOptions ensures that the ro, nosuid, and nodev options are set, replacing any
+// conflicting options.
+func hardenMountOptions(options []string) []string {
+	conflicting := map[string]bool{
+		"rw":   true,
+		"suid": true,
+		"dev":  true,
+	}
+
+	var hardened []string
+	for _, o := range options {
+		if conflicting[o] {
+			continue
+		}
+		hardened = append(hardened, o)
+	}
+
+	for _, required := range []string{"ro", "nosuid", "nodev"} {
+		if !contains(hardened, required) {
+			hardened = append(hardened, required)
+		}
+	}
+	return hardened
+}
+
+func contains(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
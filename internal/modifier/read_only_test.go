@@ -0,0 +1,150 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+type modifierFunc func(*specs.Spec) error
+
+func (f modifierFunc) Modify(spec *specs.Spec) error {
+	return f(spec)
+}
+
+func TestReadOnlyInjection(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	major := int64(195)
+	minor := int64(0)
+
+	inject := modifierFunc(func(spec *specs.Spec) error {
+		spec.Mounts = append(spec.Mounts,
+			specs.Mount{
+				Source:      "/usr/lib64/libcuda.so.1",
+				Destination: "/usr/lib64/libcuda.so.1",
+				Options:     []string{"rw", "bind", "suid"},
+			},
+		)
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		if spec.Linux.Resources == nil {
+			spec.Linux.Resources = &specs.LinuxResources{}
+		}
+		spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices,
+			specs.LinuxDeviceCgroup{
+				Allow:  true,
+				Type:   "c",
+				Major:  &major,
+				Minor:  &minor,
+				Access: "rwm",
+			},
+		)
+		return nil
+	})
+
+	testCases := []struct {
+		description  string
+		enabled      bool
+		spec         *specs.Spec
+		expectedSpec *specs.Spec
+	}{
+		{
+			description: "disabled does not harden mounts",
+			spec:        &specs.Spec{},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/usr/lib64/libcuda.so.1",
+						Destination: "/usr/lib64/libcuda.so.1",
+						Options:     []string{"rw", "bind", "suid"},
+					},
+				},
+				Linux: &specs.Linux{
+					Resources: &specs.LinuxResources{
+						Devices: []specs.LinuxDeviceCgroup{
+							{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "rwm"},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "enabled hardens injected mounts and devices only",
+			enabled:     true,
+			spec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/data",
+						Destination: "/data",
+						Options:     []string{"rw", "bind"},
+					},
+				},
+				Linux: &specs.Linux{
+					Resources: &specs.LinuxResources{
+						Devices: []specs.LinuxDeviceCgroup{
+							{Allow: false, Access: "rwm"},
+						},
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Mounts: []specs.Mount{
+					{
+						Source:      "/data",
+						Destination: "/data",
+						Options:     []string{"rw", "bind"},
+					},
+					{
+						Source:      "/usr/lib64/libcuda.so.1",
+						Destination: "/usr/lib64/libcuda.so.1",
+						Options:     []string{"bind", "ro", "nosuid", "nodev"},
+					},
+				},
+				Linux: &specs.Linux{
+					Resources: &specs.LinuxResources{
+						Devices: []specs.LinuxDeviceCgroup{
+							{Allow: false, Access: "rwm"},
+							{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "rw"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+			}
+			cfg.NVIDIAContainerRuntimeConfig.ReadOnlyInjection = tc.enabled
+
+			m := NewReadOnlyInjectionModifier(logger, cfg, inject)
+
+			err := m.Modify(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
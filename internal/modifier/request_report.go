@@ -0,0 +1,309 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/statefile"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// The mechanisms that containers use to request devices.
+const (
+	requestMechanismCDIAnnotation = "cdi-annotation"
+	requestMechanismCDIEnvvar     = "cdi-envvar"
+	requestMechanismLegacyEnvvar  = "legacy-envvar"
+)
+
+const (
+	deviceRequestsMetric = "nvidia_container_runtime_device_requests_total"
+	unknownImageName     = "unknown"
+)
+
+// imageNameAnnotations are the annotations set by container engines that contain the image name.
+var imageNameAnnotations = []string{
+	"io.kubernetes.cri.image-name",
+	"io.kubernetes.cri-o.ImageName",
+	"org.opencontainers.image.ref.name",
+}
+
+type requestReporter struct {
+	logger      *logrus.Logger
+	metricsFile string
+	mechanism   string
+	image       string
+	devices     []string
+}
+
+// NewRequestReporter creates a modifier that reports the mechanism used by a container to request
+// devices. This distinguishes between containers using CDI annotations, fully-qualified CDI device
+// names in the NVIDIA_VISIBLE_DEVICES environment variable, and legacy NVIDIA_VISIBLE_DEVICES
+// values. The OCI specification is not modified.
+func NewRequestReporter(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	reportConfig := cfg.NVIDIAContainerRuntimeConfig.RequestReport
+	if !reportConfig.Enabled {
+		return nil, nil
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	prefixes, err := getAnnotationPrefixes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, devices, err := getRequestMechanism(rawSpec, prefixes)
+	if err != nil {
+		return nil, err
+	}
+	if mechanism == "" {
+		return nil, nil
+	}
+
+	r := requestReporter{
+		logger:      logger,
+		metricsFile: reportConfig.MetricsFile,
+		mechanism:   mechanism,
+		image:       getImageName(rawSpec),
+		devices:     devices,
+	}
+	return r, nil
+}
+
+// Modify reports the device request mechanism for the container.
+func (r requestReporter) Modify(*specs.Spec) error {
+	r.logger.Infof("Container for image %q requests devices %v using %v", r.image, r.devices, r.mechanism)
+
+	if r.metricsFile == "" {
+		return nil
+	}
+	if err := incrementCounter(r.metricsFile, r.mechanism); err != nil {
+		r.logger.WithField(events.Field, events.RequestMetricsFailed).Warningf("Failed to update device request metrics: %v", err)
+	}
+	return nil
+}
+
+// getRequestMechanism returns the mechanism used to request devices in the specified OCI spec.
+// Annotations with the specified prefixes are considered CDI annotations. An empty mechanism is
+// returned if no devices are requested.
+func getRequestMechanism(spec *specs.Spec, annotationPrefixes []string) (string, []string, error) {
+	annotationDevices, _, err := parseCDIAnnotations(spec.Annotations, annotationPrefixes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse container annotations: %v", err)
+	}
+	if len(annotationDevices) > 0 {
+		return requestMechanismCDIAnnotation, annotationDevices, nil
+	}
+
+	cudaImage, err := image.NewCUDAImageFromSpec(spec)
+	if err != nil {
+		return "", nil, err
+	}
+	devices := cudaImage.DevicesFromEnvvars(visibleDevicesEnvvar).List()
+	if len(devices) == 0 {
+		return "", nil, nil
+	}
+
+	for _, device := range devices {
+		if !isQualifiedCDIName(device) {
+			return requestMechanismLegacyEnvvar, devices, nil
+		}
+	}
+	return requestMechanismCDIEnvvar, devices, nil
+}
+
+// ResolveLegacyTranslation returns the mode used to inject the devices requested by the container
+// described by the specified OCI spec. If the translation of legacy requests is enabled, a container
+// that requests devices using legacy NVIDIA_VISIBLE_DEVICES values in legacy mode is served in cdi
+// mode, where the requested devices are qualified using the default kinds. The translation is only
+// applied if all translated devices are defined in the CDI spec dirs so that the container is not
+// prevented from starting. Since the NVIDIA_REQUIRE_* and NVIDIA_DRIVER_CAPABILITIES constraints are
+// enforced by the NVIDIA Container Runtime Hook in legacy mode, containers that specify these are
+// not translated. The outcome is logged, including the image name, in either case.
+func ResolveLegacyTranslation(logger *logrus.Logger, cfg *config.Config, mode string, ociSpec oci.Spec) (string, error) {
+	if mode != "legacy" || !cfg.NVIDIAContainerRuntimeConfig.RequestReport.TranslateLegacy {
+		return mode, nil
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	prefixes, err := getAnnotationPrefixes(cfg)
+	if err != nil {
+		return "", err
+	}
+	mechanism, requested, err := getRequestMechanism(rawSpec, prefixes)
+	if err != nil {
+		return "", err
+	}
+	if mechanism != requestMechanismLegacyEnvvar {
+		return mode, nil
+	}
+
+	imageName := getImageName(rawSpec)
+	cudaImage, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return "", err
+	}
+	constraints, err := getLegacyConstraints(cudaImage)
+	if err != nil {
+		return "", err
+	}
+	if len(constraints) > 0 {
+		logger.WithField(events.Field, events.LegacyRequestKept).Warningf("Not translating devices %v requested by container for image %q: constraints %v are enforced in legacy mode", requested, imageName, constraints)
+		return mode, nil
+	}
+
+	devices, err := getDevicesFromSpec(logger, ociSpec, cfg)
+	if err != nil {
+		return "", err
+	}
+	if len(devices) == 0 {
+		return mode, nil
+	}
+
+	registry := newKindResolver(logger, cfg).getRegistry()
+	var undefined []string
+	for _, device := range devices {
+		if registry.DeviceDB().GetDevice(device) == nil {
+			undefined = append(undefined, device)
+		}
+	}
+
+	if len(undefined) > 0 {
+		logger.WithField(events.Field, events.LegacyRequestKept).Warningf("Not translating devices %v requested by container for image %q: CDI devices %v are not defined", requested, imageName, undefined)
+		return mode, nil
+	}
+	logger.Infof("Translated devices %v requested by container for image %q to CDI devices %v", requested, imageName, devices)
+	return "cdi", nil
+}
+
+// getLegacyConstraints returns the constraints of the specified image that are enforced by the
+// NVIDIA Container Runtime Hook in legacy mode. These are the requirements specified using the
+// NVIDIA_REQUIRE_* envvars (unless NVIDIA_DISABLE_REQUIRE is set) and the driver capabilities if
+// NVIDIA_DRIVER_CAPABILITIES does not select all capabilities.
+func getLegacyConstraints(cudaImage image.CUDA) ([]string, error) {
+	var constraints []string
+	if !cudaImage.HasDisableRequire() {
+		requirements, err := cudaImage.GetRequirements()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get requirements: %v", err)
+		}
+		sort.Strings(requirements)
+		constraints = append(constraints, requirements...)
+	}
+
+	if capabilities, ok := cudaImage["NVIDIA_DRIVER_CAPABILITIES"]; ok && !cudaImage.GetDriverCapabilities().Has(image.DriverCapabilityAll) {
+		constraints = append(constraints, "NVIDIA_DRIVER_CAPABILITIES="+capabilities)
+	}
+	return constraints, nil
+}
+
+// getImageName returns the name of the container image from the annotations set by the container engine.
+func getImageName(spec *specs.Spec) string {
+	for _, annotation := range imageNameAnnotations {
+		if name := spec.Annotations[annotation]; name != "" {
+			return name
+		}
+	}
+	return unknownImageName
+}
+
+// incrementCounter increments the device request counter for the specified mechanism in a metrics
+// file in the Prometheus text format. Since the file is replaced atomically, collectors (e.g. the
+// node-exporter textfile collector) never observe a partial file.
+func incrementCounter(metricsFile string, mechanism string) error {
+	return statefile.Update(metricsFile, func() error {
+		counters, err := readCounters(metricsFile)
+		if err != nil {
+			return err
+		}
+		counters[mechanism]++
+
+		return writeCounters(metricsFile, counters)
+	})
+}
+
+// readCounters reads the device request counters from the specified metrics file.
+func readCounters(metricsFile string) (map[string]int64, error) {
+	counters := make(map[string]int64)
+
+	f, err := os.Open(metricsFile)
+	if os.IsNotExist(err) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %v", err)
+	}
+	defer f.Close()
+
+	prefix := deviceRequestsMetric + `{mechanism="`
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, prefix), `"} `, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[parts[0]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics file: %v", err)
+	}
+
+	return counters, nil
+}
+
+// writeCounters atomically replaces the specified metrics file with the specified counters.
+func writeCounters(metricsFile string, counters map[string]int64) error {
+	var mechanisms []string
+	for mechanism := range counters {
+		mechanisms = append(mechanisms, mechanism)
+	}
+	sort.Strings(mechanisms)
+
+	var contents strings.Builder
+	fmt.Fprintf(&contents, "# HELP %s The number of containers requesting devices by request mechanism.\n", deviceRequestsMetric)
+	fmt.Fprintf(&contents, "# TYPE %s counter\n", deviceRequestsMetric)
+	for _, mechanism := range mechanisms {
+		fmt.Fprintf(&contents, "%s{mechanism=%q} %d\n", deviceRequestsMetric, mechanism, counters[mechanism])
+	}
+
+	return statefile.WriteFile(metricsFile, []byte(contents.String()))
+}
@@ -0,0 +1,282 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRequestMechanism(t *testing.T) {
+	testCases := []struct {
+		description       string
+		spec              *specs.Spec
+		expectedMechanism string
+		expectedDevices   []string
+	}{
+		{
+			description: "no devices requested",
+			spec: &specs.Spec{
+				Process: &specs.Process{},
+			},
+		},
+		{
+			description: "void devices",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=void"},
+				},
+			},
+		},
+		{
+			description: "CDI annotation takes precedence",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+				Annotations: map[string]string{
+					"cdi.k8s.io/engine": "nvidia.com/gpu=0",
+				},
+			},
+			expectedMechanism: requestMechanismCDIAnnotation,
+			expectedDevices:   []string{"nvidia.com/gpu=0"},
+		},
+		{
+			description: "fully-qualified names in envvar",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=0"},
+				},
+			},
+			expectedMechanism: requestMechanismCDIEnvvar,
+			expectedDevices:   []string{"nvidia.com/gpu=0"},
+		},
+		{
+			description: "legacy envvar",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+			},
+			expectedMechanism: requestMechanismLegacyEnvvar,
+			expectedDevices:   []string{"all"},
+		},
+		{
+			description: "mixed envvar is legacy",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=0,1"},
+				},
+			},
+			expectedMechanism: requestMechanismLegacyEnvvar,
+			expectedDevices:   []string{"nvidia.com/gpu=0", "1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mechanism, devices, err := getRequestMechanism(tc.spec, []string{"cdi.k8s.io/"})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMechanism, mechanism)
+			require.EqualValues(t, tc.expectedDevices, devices)
+		})
+	}
+}
+
+func TestRequestReporterMetrics(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	metricsFile := filepath.Join(t.TempDir(), "metrics.prom")
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.RequestReport.Enabled = true
+	cfg.NVIDIAContainerRuntimeConfig.RequestReport.MetricsFile = metricsFile
+
+	for _, env := range []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=all"} {
+		spec := &specs.Spec{
+			Process: &specs.Process{
+				Env: []string{env},
+			},
+		}
+		m, err := NewRequestReporter(logger, cfg, oci.NewMemorySpec(spec))
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		require.NoError(t, m.Modify(spec))
+	}
+
+	contents, err := os.ReadFile(metricsFile)
+	require.NoError(t, err)
+	require.Equal(t,
+		"# HELP nvidia_container_runtime_device_requests_total The number of containers requesting devices by request mechanism.\n"+
+			"# TYPE nvidia_container_runtime_device_requests_total counter\n"+
+			"nvidia_container_runtime_device_requests_total{mechanism=\"cdi-envvar\"} 1\n"+
+			"nvidia_container_runtime_device_requests_total{mechanism=\"legacy-envvar\"} 2\n",
+		string(contents),
+	)
+}
+
+func TestResolveLegacyTranslation(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	specDir := t.TempDir()
+	cdiSpec := `
+cdiVersion: "0.5.0"
+kind: nvidia.com/gpu
+devices:
+- name: "0"
+  containerEdits:
+    env:
+    - GPU=0
+- name: all
+  containerEdits:
+    env:
+    - GPU=all
+`
+	require.NoError(t, os.WriteFile(filepath.Join(specDir, "gpu.yaml"), []byte(cdiSpec), 0644))
+
+	testCases := []struct {
+		description  string
+		mode         string
+		translate    bool
+		env          []string
+		annotations  map[string]string
+		expectedMode string
+	}{
+		{
+			description:  "translation disabled",
+			mode:         "legacy",
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "other modes are not changed",
+			mode:         "csv",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			expectedMode: "csv",
+		},
+		{
+			description:  "legacy request is translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0"},
+			expectedMode: "cdi",
+		},
+		{
+			description:  "all devices are translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=all"},
+			expectedMode: "cdi",
+		},
+		{
+			description:  "mixed request is translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=nvidia.com/gpu=all,0"},
+			expectedMode: "cdi",
+		},
+		{
+			description:  "undefined device is not translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0,1"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "no devices requested",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=void"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "request with requirements is not translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_REQUIRE_CUDA=cuda>=99.0"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "request with disabled requirements is translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_REQUIRE_CUDA=cuda>=99.0", "NVIDIA_DISABLE_REQUIRE=true"},
+			expectedMode: "cdi",
+		},
+		{
+			description:  "request with legacy CUDA version is not translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0", "CUDA_VERSION=11.0"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "request with driver capabilities is not translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_DRIVER_CAPABILITIES=compute,utility"},
+			expectedMode: "legacy",
+		},
+		{
+			description:  "request with all driver capabilities is translated",
+			mode:         "legacy",
+			translate:    true,
+			env:          []string{"NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_DRIVER_CAPABILITIES=all"},
+			expectedMode: "cdi",
+		},
+		{
+			description: "CDI annotations are not translated",
+			mode:        "legacy",
+			translate:   true,
+			annotations: map[string]string{
+				"cdi.k8s.io/engine": "nvidia.com/gpu=0",
+			},
+			expectedMode: "legacy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+				AcceptEnvvarUnprivileged:     true,
+			}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{specDir}
+			cfg.NVIDIAContainerRuntimeConfig.RequestReport.TranslateLegacy = tc.translate
+
+			spec := &specs.Spec{
+				Process: &specs.Process{
+					Env: tc.env,
+				},
+				Annotations: tc.annotations,
+			}
+
+			mode, err := ResolveLegacyTranslation(logger, cfg, tc.mode, oci.NewMemorySpec(spec))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMode, mode)
+		})
+	}
+}
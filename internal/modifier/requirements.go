@@ -0,0 +1,77 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// assertRequirements checks the NVIDIA_REQUIRE_* requirements of the specified image against the
+// node properties returned by getProperties. The properties are only queried if the requirement
+// checks are not disabled for the image.
+func assertRequirements(logger *logrus.Logger, image image.CUDA, getProperties func() requirements.Properties) error {
+	if image.HasDisableRequire() {
+		// TODO: We could print the real value here instead
+		logger.Debugf("NVIDIA_DISABLE_REQUIRE=%v; skipping requirement checks", true)
+		return nil
+	}
+
+	imageRequirements, err := image.GetRequirements()
+	if err != nil {
+		//  TODO: Should we treat this as a failure, or just issue a warning?
+		return fmt.Errorf("failed to get image requirements: %v", err)
+	}
+
+	r := requirements.New(logger, imageRequirements)
+	r.AddProperties(getProperties())
+
+	return r.Assert()
+}
+
+// checkNodeRequirements checks the NVIDIA_REQUIRE_* requirements of the container against the node
+// properties embedded in the injected CDI edits. If no node properties were embedded, the checks
+// are skipped.
+func checkNodeRequirements(logger *logrus.Logger, spec *specs.Spec) error {
+	if spec == nil || spec.Process == nil {
+		return nil
+	}
+
+	properties, err := requirements.GetPropertiesFromEnv(spec.Process.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get node properties: %v", err)
+	}
+	if properties == nil {
+		return nil
+	}
+	logger.Debugf("Checking requirements against node properties: %v", properties)
+
+	container, err := image.NewCUDAImageFromSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	if err := assertRequirements(logger, container, func() requirements.Properties { return *properties }); err != nil {
+		return oci.NewError(oci.ErrorKindUnsupportedRequest, fmt.Errorf("requirements not met: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckNodeRequirements(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		env           []string
+		expectedError bool
+	}{
+		{
+			description: "no node properties skips checks",
+			env:         []string{"NVIDIA_REQUIRE_CUDA=cuda>=99.0"},
+		},
+		{
+			description: "requirements met",
+			env: []string{
+				"NVIDIA_REQUIRE_CUDA=cuda>=11.8 brand=tesla,driver>=470",
+				"NVIDIA_NODE_PROPERTIES=cuda=12.0,driver=525.60.13,brand=tesla",
+			},
+		},
+		{
+			description: "requirements not met",
+			env: []string{
+				"NVIDIA_REQUIRE_CUDA=cuda>=12.1",
+				"NVIDIA_NODE_PROPERTIES=cuda=12.0,driver=525.60.13",
+			},
+			expectedError: true,
+		},
+		{
+			description: "disabled requirements are not checked",
+			env: []string{
+				"NVIDIA_REQUIRE_CUDA=cuda>=12.1",
+				"NVIDIA_DISABLE_REQUIRE=true",
+				"NVIDIA_NODE_PROPERTIES=cuda=12.0,driver=525.60.13",
+			},
+		},
+		{
+			description: "invalid node properties",
+			env: []string{
+				"NVIDIA_NODE_PROPERTIES=driver",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spec := &specs.Spec{
+				Process: &specs.Process{Env: tc.env},
+			}
+
+			err := checkNodeRequirements(logger, spec)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckNodeRequirementsErrorKind(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	spec := &specs.Spec{
+		Process: &specs.Process{Env: []string{
+			"NVIDIA_REQUIRE_CUDA=cuda>=12.1",
+			"NVIDIA_NODE_PROPERTIES=cuda=12.0",
+		}},
+	}
+
+	err := checkNodeRequirements(logger, spec)
+	require.Error(t, err)
+	require.Equal(t, oci.ErrorKindUnsupportedRequest, oci.GetErrorKind(err))
+}
@@ -0,0 +1,245 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/redact"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// specLogger is a spec modifier that logs the changes made to the OCI specification by a wrapped
+// modifier. The full input and modified specifications can optionally be dumped to files. The
+// environment of the process and hooks is redacted in these files.
+type specLogger struct {
+	logger      *logrus.Logger
+	redactor    *redact.Redactor
+	dumpDir     string
+	containerID string
+	modifier    oci.SpecModifier
+}
+
+var _ oci.SpecModifier = (*specLogger)(nil)
+
+// NewSpecLoggerModifier wraps the specified modifier so that the devices, mounts, hooks, and
+// environment variables added to the OCI specification are logged at debug level. If
+// debug.spec-dump-dir is set, the input and modified specifications for the specified container are
+// also written to this directory with the values of environment variables matching the
+// debug.redact-env patterns masked. If neither applies, the input modifier is returned.
+func NewSpecLoggerModifier(logger *logrus.Logger, cfg *config.Config, containerID string, modifier oci.SpecModifier) (oci.SpecModifier, error) {
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dumpDir := cfg.DebugConfig.SpecDumpDir
+	if modifier == nil || (!logger.IsLevelEnabled(logrus.DebugLevel) && dumpDir == "") {
+		return modifier, nil
+	}
+
+	m := specLogger{
+		logger:      logger,
+		redactor:    redactor,
+		dumpDir:     dumpDir,
+		containerID: containerID,
+		modifier:    modifier,
+	}
+	return m, nil
+}
+
+// Modify applies the wrapped modifier and logs the resultant changes to the OCI specification.
+func (m specLogger) Modify(spec *specs.Spec) error {
+	if spec == nil {
+		return m.modifier.Modify(spec)
+	}
+
+	timestamp := time.Now().UTC().Format("20060102T150405.000000000Z")
+	input := newSpecSummary(spec)
+	m.dump(timestamp, "input", spec)
+
+	if err := m.modifier.Modify(spec); err != nil {
+		return err
+	}
+
+	m.dump(timestamp, "modified", spec)
+	m.logDelta(newSpecSummary(spec).added(input))
+	return nil
+}
+
+// logDelta logs the counts and names of the items added to the OCI specification. Only the names of
+// the added environment variables are logged so that their values need not be redacted.
+func (m specLogger) logDelta(added *specSummary) {
+	if !m.logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	m.logger.WithFields(logrus.Fields{
+		"devices": added.devices.names(),
+		"mounts":  added.mounts.names(),
+		"hooks":   added.hooks.names(),
+		"env":     added.env.names(),
+	}).Debugf("Modified OCI specification: added %d devices, %d mounts, %d hooks, and %d environment variables",
+		len(added.devices), len(added.mounts), len(added.hooks), len(added.env))
+}
+
+// dump writes the redacted OCI specification to a file in a per-container subdirectory of the
+// configured dump directory. Since the specification is only dumped for debugging, failures are
+// logged and otherwise ignored.
+func (m specLogger) dump(timestamp string, description string, spec *specs.Spec) {
+	if m.dumpDir == "" {
+		return
+	}
+	path, err := m.writeSpec(timestamp, description, spec)
+	if err != nil {
+		m.logger.Warningf("Failed to dump %v OCI specification: %v", description, err)
+		return
+	}
+	m.logger.Debugf("Wrote %v OCI specification to %v", description, path)
+}
+
+func (m specLogger) writeSpec(timestamp string, description string, spec *specs.Spec) (string, error) {
+	redacted, err := m.redactor.Spec(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to redact specification: %v", err)
+	}
+	contents, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal specification: %v", err)
+	}
+
+	dir := filepath.Join(m.dumpDir, filepath.Base(m.containerID))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create dump directory: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", timestamp, description))
+	if err := os.WriteFile(path, append(contents, '\n'), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %v: %v", path, err)
+	}
+	return path, nil
+}
+
+// specSummary records the devices, mounts, hooks, and environment variables in an OCI specification.
+type specSummary struct {
+	devices specItems
+	mounts  specItems
+	hooks   specItems
+	env     specItems
+}
+
+// specItem is an entry in an OCI specification. The key identifies the entry as a whole, whereas the
+// name is used when logging the entry.
+type specItem struct {
+	key  string
+	name string
+}
+
+type specItems []specItem
+
+func newSpecSummary(spec *specs.Spec) *specSummary {
+	s := specSummary{}
+	if spec.Linux != nil {
+		for _, d := range spec.Linux.Devices {
+			s.devices = append(s.devices, specItem{key: d.Path, name: d.Path})
+		}
+	}
+	for _, mount := range spec.Mounts {
+		s.mounts = append(s.mounts, specItem{key: mount.Source + ":" + mount.Destination, name: mount.Destination})
+	}
+	if spec.Hooks != nil {
+		s.hooks = append(s.hooks, hookItems("prestart", spec.Hooks.Prestart)...)
+		s.hooks = append(s.hooks, hookItems("createRuntime", spec.Hooks.CreateRuntime)...)
+		s.hooks = append(s.hooks, hookItems("createContainer", spec.Hooks.CreateContainer)...)
+		s.hooks = append(s.hooks, hookItems("startContainer", spec.Hooks.StartContainer)...)
+		s.hooks = append(s.hooks, hookItems("poststart", spec.Hooks.Poststart)...)
+		s.hooks = append(s.hooks, hookItems("poststop", spec.Hooks.Poststop)...)
+	}
+	if spec.Process != nil {
+		for _, e := range spec.Process.Env {
+			name := strings.SplitN(e, "=", 2)[0]
+			s.env = append(s.env, specItem{key: e, name: name})
+		}
+	}
+	return &s
+}
+
+// hookItems returns the items for the specified hooks. An nvidia-ctk hook is named for the invoked
+// subcommand (e.g. createContainer:update-ldcache) and other hooks for their path.
+func hookItems(stage string, hooks []specs.Hook) specItems {
+	var items specItems
+	for _, hook := range hooks {
+		name := privileges.NVIDIACTKHookName(hook.Path, hook.Args)
+		if name == "" {
+			name = hook.Path
+		}
+		items = append(items, specItem{
+			key:  stage + ":" + hook.Path + " " + strings.Join(hook.Args, " "),
+			name: stage + ":" + name,
+		})
+	}
+	return items
+}
+
+// added returns the items in the summary that are not present in the specified original summary.
+func (s *specSummary) added(original *specSummary) *specSummary {
+	a := specSummary{
+		devices: s.devices.without(original.devices),
+		mounts:  s.mounts.without(original.mounts),
+		hooks:   s.hooks.without(original.hooks),
+		env:     s.env.without(original.env),
+	}
+	return &a
+}
+
+func (s specItems) without(other specItems) specItems {
+	existing := make(map[string]bool)
+	for _, item := range other {
+		existing[item.key] = true
+	}
+	var filtered specItems
+	for _, item := range s {
+		if existing[item.key] {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+func (s specItems) names() []string {
+	names := []string{}
+	for _, item := range s {
+		names = append(names, item.name)
+	}
+	return names
+}
+
+// newRedactor creates a redactor for the debug.redact-env patterns in the config.
+func newRedactor(cfg *config.Config) (*redact.Redactor, error) {
+	redactor, err := redact.New(cfg.DebugConfig.RedactEnv)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid debug.redact-env config: %v", err))
+	}
+	return redactor, nil
+}
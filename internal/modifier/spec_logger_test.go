@@ -0,0 +1,126 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecLoggerModifier(t *testing.T) {
+	logger, hook := testlog.NewNullLogger()
+
+	inject := modifierFunc(func(spec *specs.Spec) error {
+		spec.Process.Env = append(spec.Process.Env, "INJECTED_TOKEN=def")
+		spec.Mounts = append(spec.Mounts, specs.Mount{Source: "/usr/lib/libcuda.so.1", Destination: "/usr/lib/libcuda.so.1"})
+		spec.Linux.Devices = append(spec.Linux.Devices, specs.LinuxDevice{Path: "/dev/nvidia0"})
+		spec.Hooks = &specs.Hooks{
+			CreateContainer: []specs.Hook{
+				{Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+			},
+		}
+		return nil
+	})
+
+	cfg := &config.Config{}
+	cfg.DebugConfig.RedactEnv = []string{"*TOKEN*"}
+
+	m, err := NewSpecLoggerModifier(logger, cfg, "ctr", inject)
+	require.NoError(t, err)
+	require.IsType(t, inject, m, "the modifier is not wrapped if debug logging is not enabled")
+
+	logger.SetLevel(logrus.DebugLevel)
+	m, err = NewSpecLoggerModifier(logger, cfg, "ctr", inject)
+	require.NoError(t, err)
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"API_TOKEN=abc", "PATH=/usr/bin"},
+		},
+		Linux: &specs.Linux{},
+	}
+	require.NoError(t, m.Modify(spec))
+	require.Equal(t, []string{"API_TOKEN=abc", "PATH=/usr/bin", "INJECTED_TOKEN=def"}, spec.Process.Env)
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "Modified OCI specification: added 1 devices, 1 mounts, 1 hooks, and 1 environment variables", entries[0].Message)
+	require.Equal(t, []string{"/dev/nvidia0"}, entries[0].Data["devices"])
+	require.Equal(t, []string{"/usr/lib/libcuda.so.1"}, entries[0].Data["mounts"])
+	require.Equal(t, []string{"createContainer:update-ldcache"}, entries[0].Data["hooks"])
+	require.Equal(t, []string{"INJECTED_TOKEN"}, entries[0].Data["env"])
+}
+
+func TestSpecLoggerModifierDumpDir(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	inject := modifierFunc(func(spec *specs.Spec) error {
+		spec.Process.Env = append(spec.Process.Env, "INJECTED_TOKEN=def")
+		return nil
+	})
+
+	cfg := &config.Config{}
+	cfg.DebugConfig.RedactEnv = []string{"*TOKEN*"}
+	cfg.DebugConfig.SpecDumpDir = t.TempDir()
+
+	m, err := NewSpecLoggerModifier(logger, cfg, "ctr", inject)
+	require.NoError(t, err)
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"API_TOKEN=abc", "PATH=/usr/bin"},
+		},
+	}
+	require.NoError(t, m.Modify(spec))
+
+	input, err := filepath.Glob(filepath.Join(cfg.DebugConfig.SpecDumpDir, "ctr", "*-input.json"))
+	require.NoError(t, err)
+	require.Len(t, input, 1)
+	modified, err := filepath.Glob(filepath.Join(cfg.DebugConfig.SpecDumpDir, "ctr", "*-modified.json"))
+	require.NoError(t, err)
+	require.Len(t, modified, 1)
+
+	contents, err := os.ReadFile(input[0])
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "API_TOKEN=REDACTED")
+	require.NotContains(t, string(contents), "INJECTED_TOKEN")
+
+	contents, err = os.ReadFile(modified[0])
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "API_TOKEN=REDACTED")
+	require.Contains(t, string(contents), "INJECTED_TOKEN=REDACTED")
+	require.Contains(t, string(contents), "PATH=/usr/bin")
+	require.NotContains(t, string(contents), "abc")
+	require.NotContains(t, string(contents), "def")
+}
+
+func TestSpecLoggerModifierInvalidPattern(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{}
+	cfg.DebugConfig.RedactEnv = []string{"[TOKEN"}
+
+	_, err := NewSpecLoggerModifier(logger, cfg, "", nil)
+	require.Error(t, err)
+}
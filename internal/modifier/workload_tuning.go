@@ -0,0 +1,248 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rlimitMemlock = "RLIMIT_MEMLOCK"
+
+	// nvidiaHostNUMABalancingEnvvar exposes the value of the kernel.numa_balancing sysctl of the
+	// host. This sysctl is not namespaced and can therefore not be set for a container.
+	nvidiaHostNUMABalancingEnvvar = "NVIDIA_HOST_NUMA_BALANCING"
+	numaBalancingSysctlPath       = "/proc/sys/kernel/numa_balancing"
+)
+
+// namespacedSysctls are the sysctls (or prefixes of sysctls) that can be set for a container.
+// This matches the list of sysctls that are accepted by runc.
+var namespacedSysctls = []string{
+	"kernel.msgmax",
+	"kernel.msgmnb",
+	"kernel.msgmni",
+	"kernel.sem",
+	"kernel.shmall",
+	"kernel.shmmax",
+	"kernel.shmmni",
+	"kernel.shm_rmid_forced",
+	"fs.mqueue.",
+	"net.",
+}
+
+// sysctlNamespaces maps the sysctls (or prefixes of sysctls) in namespacedSysctls to the namespace
+// that these apply to. The runtime only accepts these if the container has the namespace since these
+// would otherwise modify the namespace of the host.
+var sysctlNamespaces = map[string]specs.LinuxNamespaceType{
+	"kernel.msg": specs.IPCNamespace,
+	"kernel.sem": specs.IPCNamespace,
+	"kernel.shm": specs.IPCNamespace,
+	"fs.mqueue.": specs.IPCNamespace,
+	"net.":       specs.NetworkNamespace,
+}
+
+// workloadTuning sets the resource limits and sysctls required by containers that use
+// GPUDirect RDMA or GDS.
+type workloadTuning struct {
+	logger   *logrus.Logger
+	memlock  uint64
+	sysctls  map[string]string
+	procRoot string
+}
+
+var _ oci.SpecModifier = (*workloadTuning)(nil)
+
+// NewWorkloadTuningModifier creates a modifier that raises RLIMIT_MEMLOCK and sets the configured
+// sysctls for containers that request MOFED or GDS devices. Without a sufficient memlock limit,
+// the registration of memory for RDMA (e.g. ibv_reg_mr) fails. If workload tuning is not enabled
+// or the container does not request these devices, no changes are made.
+func NewWorkloadTuningModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec) (oci.SpecModifier, error) {
+	tuningConfig := cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning
+	if !tuningConfig.Enabled {
+		return nil, nil
+	}
+
+	memlock, err := parseRlimit(tuningConfig.Memlock)
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid workload-tuning.memlock: %v", err))
+	}
+	for name := range tuningConfig.Sysctls {
+		if !isNamespacedSysctl(name) {
+			return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid workload-tuning.sysctls: %v is not namespaced and cannot be set for a container", name))
+		}
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI spec: %v", err)
+	}
+
+	container, err := image.NewCUDAImageFromSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if devices := container.DevicesFromEnvvars(visibleDevicesEnvvar); len(devices.List()) == 0 {
+		return nil, nil
+	}
+	if container[nvidiaMOFEDEnvvar] != "enabled" && container[nvidiaGDSEnvvar] != "enabled" {
+		return nil, nil
+	}
+
+	m := workloadTuning{
+		logger:   logger,
+		memlock:  memlock,
+		sysctls:  tuningConfig.Sysctls,
+		procRoot: "/",
+	}
+	return m, nil
+}
+
+// Modify raises the memlock limit, sets the sysctls that are not already set, and exposes the
+// kernel.numa_balancing setting of the host. Sysctls that apply to a namespace that the container
+// does not have are skipped.
+func (m workloadTuning) Modify(spec *specs.Spec) error {
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	m.raiseMemlock(spec.Process)
+
+	for name, value := range m.sysctls {
+		if spec.Linux != nil {
+			if _, ok := spec.Linux.Sysctl[name]; ok {
+				m.logger.Debugf("Not setting sysctl %v; already set in OCI specification", name)
+				continue
+			}
+		}
+		if namespace := sysctlNamespace(name); namespace != "" && !hasNamespace(spec, namespace) {
+			m.logger.Warnf("Not setting sysctl %v; the container does not have a %v namespace", name, namespace)
+			continue
+		}
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		if spec.Linux.Sysctl == nil {
+			spec.Linux.Sysctl = make(map[string]string)
+		}
+		m.logger.Debugf("Setting sysctl %v=%v", name, value)
+		spec.Linux.Sysctl[name] = value
+	}
+
+	if value, err := os.ReadFile(filepath.Join(m.procRoot, numaBalancingSysctlPath)); err == nil {
+		spec.Process.Env = setEnv(spec.Process.Env, nvidiaHostNUMABalancingEnvvar, strings.TrimSpace(string(value)))
+	}
+
+	return nil
+}
+
+// raiseMemlock sets the soft and hard memlock limits of the process to the configured limit
+// unless these are already at least as high.
+func (m workloadTuning) raiseMemlock(process *specs.Process) {
+	for i, rlimit := range process.Rlimits {
+		if rlimit.Type != rlimitMemlock {
+			continue
+		}
+		if rlimit.Soft >= m.memlock && rlimit.Hard >= m.memlock {
+			return
+		}
+		m.logger.Debugf("Raising %v from %v/%v to %v", rlimitMemlock, rlimit.Soft, rlimit.Hard, m.memlock)
+		if process.Rlimits[i].Soft < m.memlock {
+			process.Rlimits[i].Soft = m.memlock
+		}
+		if process.Rlimits[i].Hard < m.memlock {
+			process.Rlimits[i].Hard = m.memlock
+		}
+		return
+	}
+
+	m.logger.Debugf("Setting %v to %v", rlimitMemlock, m.memlock)
+	process.Rlimits = append(process.Rlimits, specs.POSIXRlimit{
+		Type: rlimitMemlock,
+		Soft: m.memlock,
+		Hard: m.memlock,
+	})
+}
+
+// parseRlimit parses a resource limit that is either a number or unlimited.
+func parseRlimit(value string) (uint64, error) {
+	if value == "unlimited" {
+		return math.MaxUint64, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number or unlimited", value)
+	}
+	return limit, nil
+}
+
+// isNamespacedSysctl checks whether the specified sysctl can be set for a container.
+func isNamespacedSysctl(name string) bool {
+	for _, s := range namespacedSysctls {
+		if name == s || (strings.HasSuffix(s, ".") && strings.HasPrefix(name, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sysctlNamespace returns the namespace that the specified sysctl applies to.
+func sysctlNamespace(name string) specs.LinuxNamespaceType {
+	for prefix, namespace := range sysctlNamespaces {
+		if strings.HasPrefix(name, prefix) {
+			return namespace
+		}
+	}
+	return ""
+}
+
+// hasNamespace checks whether the container has a namespace of the specified type. This is the case
+// if the namespace is created for the container or the container joins an existing namespace (e.g.
+// that of a Kubernetes pod).
+func hasNamespace(spec *specs.Spec, namespace specs.LinuxNamespaceType) bool {
+	if spec.Linux == nil {
+		return false
+	}
+	for _, ns := range spec.Linux.Namespaces {
+		if ns.Type == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnv sets the specified environment variable, replacing an existing value.
+func setEnv(env []string, key string, value string) []string {
+	envvar := key + "=" + value
+	for i, e := range env {
+		if strings.HasPrefix(e, key+"=") {
+			env[i] = envvar
+			return env
+		}
+	}
+	return append(env, envvar)
+}
@@ -0,0 +1,260 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkloadTuningModifier(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description      string
+		enabled          bool
+		env              []string
+		expectedModifier bool
+	}{
+		{
+			description: "disabled returns nil",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_MOFED=enabled"},
+		},
+		{
+			description:      "mofed container is tuned",
+			enabled:          true,
+			env:              []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_MOFED=enabled"},
+			expectedModifier: true,
+		},
+		{
+			description:      "gds container is tuned",
+			enabled:          true,
+			env:              []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_GDS=enabled"},
+			expectedModifier: true,
+		},
+		{
+			description: "container without rdma is not tuned",
+			enabled:     true,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all"},
+		},
+		{
+			description: "container without devices is not tuned",
+			enabled:     true,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=void", "NVIDIA_MOFED=enabled"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning.Enabled = tc.enabled
+			cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning.Memlock = "unlimited"
+
+			spec := oci.NewMemorySpec(&specs.Spec{
+				Process: &specs.Process{Env: tc.env},
+			})
+
+			m, err := NewWorkloadTuningModifier(logger, cfg, spec)
+			require.NoError(t, err)
+			if tc.expectedModifier {
+				require.NotNil(t, m)
+			} else {
+				require.Nil(t, m)
+			}
+		})
+	}
+}
+
+func TestNewWorkloadTuningModifierInvalidConfig(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description string
+		memlock     string
+		sysctls     map[string]string
+	}{
+		{
+			description: "invalid memlock",
+			memlock:     "lots",
+		},
+		{
+			description: "non-namespaced sysctl",
+			memlock:     "unlimited",
+			sysctls:     map[string]string{"kernel.numa_balancing": "0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning.Enabled = true
+			cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning.Memlock = tc.memlock
+			cfg.NVIDIAContainerRuntimeConfig.WorkloadTuning.Sysctls = tc.sysctls
+
+			_, err := NewWorkloadTuningModifier(logger, cfg, oci.NewMemorySpec(&specs.Spec{}))
+			require.Error(t, err)
+			require.Equal(t, oci.ErrorKindConfig, oci.GetErrorKind(err))
+		})
+	}
+}
+
+func TestWorkloadTuningModify(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	procRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "proc/sys/kernel"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, numaBalancingSysctlPath), []byte("1\n"), 0644))
+
+	testCases := []struct {
+		description  string
+		memlock      uint64
+		sysctls      map[string]string
+		spec         *specs.Spec
+		expectedSpec *specs.Spec
+	}{
+		{
+			description: "memlock is added",
+			memlock:     math.MaxUint64,
+			spec:        &specs.Spec{},
+			expectedSpec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_HOST_NUMA_BALANCING=1"},
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_MEMLOCK", Soft: math.MaxUint64, Hard: math.MaxUint64},
+					},
+				},
+			},
+		},
+		{
+			description: "lower memlock is raised",
+			memlock:     1024,
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_NOFILE", Soft: 1024, Hard: 1024},
+						{Type: "RLIMIT_MEMLOCK", Soft: 64, Hard: 2048},
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_HOST_NUMA_BALANCING=1"},
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_NOFILE", Soft: 1024, Hard: 1024},
+						{Type: "RLIMIT_MEMLOCK", Soft: 1024, Hard: 2048},
+					},
+				},
+			},
+		},
+		{
+			description: "sysctls set in spec take precedence",
+			memlock:     1024,
+			sysctls: map[string]string{
+				"net.core.rmem_max": "16777216",
+				"kernel.shmmax":     "68719476736",
+			},
+			spec: &specs.Spec{
+				Linux: &specs.Linux{
+					Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace}, {Type: specs.IPCNamespace}},
+					Sysctl:     map[string]string{"kernel.shmmax": "1024"},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_HOST_NUMA_BALANCING=1"},
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_MEMLOCK", Soft: 1024, Hard: 1024},
+					},
+				},
+				Linux: &specs.Linux{
+					Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace}, {Type: specs.IPCNamespace}},
+					Sysctl: map[string]string{
+						"kernel.shmmax":     "1024",
+						"net.core.rmem_max": "16777216",
+					},
+				},
+			},
+		},
+		{
+			description: "sysctls are skipped without the namespace",
+			memlock:     1024,
+			sysctls: map[string]string{
+				"net.core.rmem_max": "16777216",
+				"kernel.shmmax":     "68719476736",
+				"kernel.sem":        "250 32000 32 128",
+			},
+			spec: &specs.Spec{
+				Linux: &specs.Linux{
+					Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace, Path: "/proc/1234/ns/net"}},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_HOST_NUMA_BALANCING=1"},
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_MEMLOCK", Soft: 1024, Hard: 1024},
+					},
+				},
+				Linux: &specs.Linux{
+					Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace, Path: "/proc/1234/ns/net"}},
+					Sysctl: map[string]string{
+						"net.core.rmem_max": "16777216",
+					},
+				},
+			},
+		},
+		{
+			description: "no sysctls are set in the host namespaces",
+			memlock:     1024,
+			sysctls: map[string]string{
+				"net.core.rmem_max": "16777216",
+				"kernel.shmmax":     "68719476736",
+			},
+			spec: &specs.Spec{},
+			expectedSpec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_HOST_NUMA_BALANCING=1"},
+					Rlimits: []specs.POSIXRlimit{
+						{Type: "RLIMIT_MEMLOCK", Soft: 1024, Hard: 1024},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := workloadTuning{
+				logger:   logger,
+				memlock:  tc.memlock,
+				sysctls:  tc.sysctls,
+				procRoot: procRoot,
+			}
+
+			require.NoError(t, m.Modify(tc.spec))
+			require.Equal(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nomad
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClientConfig is the Nomad client config that allows docker tasks to use the NVIDIA Container Runtime.
+type ClientConfig struct {
+	// RuntimeName is the name of the NVIDIA Container Runtime in the docker config.
+	RuntimeName string
+	// AllowRuntimes are the other runtimes that docker tasks are allowed to use.
+	AllowRuntimes []string
+}
+
+// Render returns the HCL representation of the client config. Since Nomad merges the config files in
+// the config dir, the config can be written to a dedicated file (e.g. /etc/nomad.d/nvidia.hcl).
+func (c ClientConfig) Render() string {
+	var runtimes []string
+	for _, r := range append(c.AllowRuntimes, c.RuntimeName) {
+		runtimes = append(runtimes, fmt.Sprintf("%q", r))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by nvidia-ctk nomad configure\n")
+	fmt.Fprintf(&b, "plugin \"docker\" {\n")
+	fmt.Fprintf(&b, "  config {\n")
+	fmt.Fprintf(&b, "    allow_runtimes = [%s]\n", strings.Join(runtimes, ", "))
+	fmt.Fprintf(&b, "  }\n")
+	fmt.Fprintf(&b, "}\n")
+	return b.String()
+}
@@ -0,0 +1,116 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package nomad provides the integration of the NVIDIA Container Toolkit with HashiCorp Nomad.
+package nomad
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// DefaultKind is the CDI device kind of the devices generated by nvidia-ctk cdi generate.
+const DefaultKind = "nvidia.com/gpu"
+
+// DeviceMapper maps the device IDs assigned by the Nomad NVIDIA device plugin (i.e. the UUIDs of
+// GPUs and MIG devices) to fully-qualified CDI device names.
+type DeviceMapper struct {
+	kind string
+	// names maps the UUID of each device to the CDI device name generated by the device namer.
+	names map[string]string
+}
+
+// NewDeviceMapper creates a mapper for the GPUs and MIG devices of the specified device library. The
+// CDI device names are generated using the specified namer, which must match the naming strategy used
+// to generate the CDI specification.
+func NewDeviceMapper(devicelib device.Interface, namer nvcdi.DeviceNamer, kind string) (*DeviceMapper, error) {
+	m := DeviceMapper{
+		kind:  kind,
+		names: make(map[string]string),
+	}
+
+	err := devicelib.VisitDevices(func(i int, d device.Device) error {
+		uuid, r := d.GetUUID()
+		if r != nvml.SUCCESS {
+			return fmt.Errorf("failed to get UUID of device %d: %v", i, r)
+		}
+		name, err := namer.GetDeviceName(i, d)
+		if err != nil {
+			return err
+		}
+		m.names[uuid] = name
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to visit devices: %v", err)
+	}
+
+	err = devicelib.VisitMigDevices(func(i int, d device.Device, j int, mig device.MigDevice) error {
+		uuid, r := mig.GetUUID()
+		if r != nvml.SUCCESS {
+			return fmt.Errorf("failed to get UUID of MIG device %d:%d: %v", i, j, r)
+		}
+		name, err := namer.GetMigDeviceName(i, d, j, mig)
+		if err != nil {
+			return err
+		}
+		m.names[uuid] = name
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to visit MIG devices: %v", err)
+	}
+
+	return &m, nil
+}
+
+// Map returns the fully-qualified CDI device names for the specified Nomad device IDs. The IDs may
+// also be specified as a comma-separated list as is the case for the NVIDIA_VISIBLE_DEVICES
+// environment variable set by the device plugin. The special ID all maps to the all device.
+func (m *DeviceMapper) Map(ids ...string) ([]string, error) {
+	var names []string
+	for _, id := range splitIDs(ids) {
+		if id == "all" {
+			names = append(names, m.kind+"=all")
+			continue
+		}
+		name, ok := m.names[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown device ID %v", id)
+		}
+		names = append(names, m.kind+"="+name)
+	}
+	return names, nil
+}
+
+// splitIDs splits the specified IDs at commas and removes empty IDs.
+func splitIDs(ids []string) []string {
+	var split []string
+	for _, id := range ids {
+		for _, part := range strings.Split(id, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			split = append(split, part)
+		}
+	}
+	return split
+}
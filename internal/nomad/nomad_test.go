@@ -0,0 +1,98 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nomad
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceMapperMap(t *testing.T) {
+	m := DeviceMapper{
+		kind: DefaultKind,
+		names: map[string]string{
+			"GPU-0": "0",
+			"GPU-1": "1",
+			"MIG-0": "1:0",
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		ids           []string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			description: "no IDs",
+		},
+		{
+			description: "single ID",
+			ids:         []string{"GPU-1"},
+			expected:    []string{"nvidia.com/gpu=1"},
+		},
+		{
+			description: "multiple IDs",
+			ids:         []string{"GPU-0", "MIG-0"},
+			expected:    []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1:0"},
+		},
+		{
+			description: "comma-separated IDs",
+			ids:         []string{"GPU-0, MIG-0,"},
+			expected:    []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1:0"},
+		},
+		{
+			description: "all",
+			ids:         []string{"all"},
+			expected:    []string{"nvidia.com/gpu=all"},
+		},
+		{
+			description:   "unknown ID",
+			ids:           []string{"GPU-0", "GPU-2"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			names, err := m.Map(tc.ids...)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestClientConfigRender(t *testing.T) {
+	c := ClientConfig{
+		RuntimeName:   "nvidia",
+		AllowRuntimes: []string{"runc"},
+	}
+
+	expected := `# Generated by nvidia-ctk nomad configure
+plugin "docker" {
+  config {
+    allow_runtimes = ["runc", "nvidia"]
+  }
+}
+`
+	require.Equal(t, expected, c.Render())
+}
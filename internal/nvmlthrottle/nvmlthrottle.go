@@ -0,0 +1,175 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package nvmlthrottle limits the number of concurrent operations that initialize NVML (or the CUDA
+// driver API) on a node. When hundreds of containers are started simultaneously (e.g. after a node
+// reboot), the parallel initialization of NVML by the hooks of each container leads to contention in
+// the driver. The throttle is a node-local semaphore implemented as a set of slot files in a lock dir
+// that are locked using flock(2). Since the locks are released by the kernel when the holding process
+// exits, a crashed process does not leak a slot.
+package nvmlthrottle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// DefaultLockDir is the directory in which the slot files are created.
+	DefaultLockDir = "/run/nvidia-container-toolkit/nvml-throttle"
+	// DefaultTimeout is the maximum time that is waited for a slot.
+	DefaultTimeout = 60 * time.Second
+
+	minPollInterval = 10 * time.Millisecond
+	maxPollInterval = 500 * time.Millisecond
+)
+
+// ErrTimeout is returned if no slot could be acquired before the timeout expired.
+var ErrTimeout = errors.New("timed out waiting for an NVML slot")
+
+// Throttle limits the number of concurrent holders of a slot.
+type Throttle struct {
+	lockDir       string
+	maxConcurrent int
+	timeout       time.Duration
+}
+
+// Slot is an acquired slot of a Throttle. The slot is held until it is released or the process
+// exits.
+type Slot struct {
+	file *os.File
+}
+
+// New creates a throttle that allows at most maxConcurrent holders of a slot. If maxConcurrent is
+// not positive, the throttle is disabled and Acquire returns immediately. If lockDir is empty,
+// DefaultLockDir is used and if timeout is not positive, DefaultTimeout is used.
+func New(lockDir string, maxConcurrent int, timeout time.Duration) *Throttle {
+	if lockDir == "" {
+		lockDir = DefaultLockDir
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Throttle{
+		lockDir:       lockDir,
+		maxConcurrent: maxConcurrent,
+		timeout:       timeout,
+	}
+}
+
+// Enabled returns whether the throttle limits the number of concurrent operations.
+func (t *Throttle) Enabled() bool {
+	return t != nil && t.maxConcurrent > 0
+}
+
+// Acquire waits until a slot is available and acquires it. If the throttle is disabled, a nil slot
+// is returned. If no slot is available before the timeout expires, ErrTimeout is returned.
+func (t *Throttle) Acquire() (*Slot, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	if err := os.MkdirAll(t.lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock dir: %v", err)
+	}
+
+	deadline := time.Now().Add(t.timeout)
+	interval := minPollInterval
+	for {
+		slot, err := t.tryAcquire()
+		if err != nil || slot != nil {
+			return slot, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// tryAcquire attempts to lock each of the slot files without blocking. If all slots are held, nil
+// is returned.
+func (t *Throttle) tryAcquire() (*Slot, error) {
+	for i := 0; i < t.maxConcurrent; i++ {
+		path := filepath.Join(t.lockDir, fmt.Sprintf("slot-%d.lock", i))
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open slot file: %v", err)
+		}
+
+		err = unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			return &Slot{file: file}, nil
+		}
+		file.Close()
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, fmt.Errorf("failed to lock slot file %v: %v", path, err)
+		}
+	}
+	return nil, nil
+}
+
+// KeepOnExec ensures that the slot is inherited by a process that replaces the current process
+// using exec(2). The slot is then held until the new process exits.
+func (s *Slot) KeepOnExec() error {
+	if s == nil {
+		return nil
+	}
+	flags, err := unix.FcntlInt(s.file.Fd(), unix.F_GETFD, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get descriptor flags: %v", err)
+	}
+	if _, err := unix.FcntlInt(s.file.Fd(), unix.F_SETFD, flags&^unix.FD_CLOEXEC); err != nil {
+		return fmt.Errorf("failed to set descriptor flags: %v", err)
+	}
+	return nil
+}
+
+// Release releases the slot.
+func (s *Slot) Release() error {
+	if s == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// NewFromConfig creates a throttle from the nvml-throttle section of the runtime config.
+func NewFromConfig(cfg *config.RuntimeConfig) (*Throttle, error) {
+	throttleConfig := cfg.NVMLThrottle
+
+	var timeout time.Duration
+	if throttleConfig.Timeout != "" {
+		d, err := time.ParseDuration(throttleConfig.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %v", throttleConfig.Timeout, err)
+		}
+		timeout = d
+	}
+	return New(throttleConfig.LockDir, throttleConfig.MaxConcurrent, timeout), nil
+}
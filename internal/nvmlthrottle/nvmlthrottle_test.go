@@ -0,0 +1,117 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvmlthrottle
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestAcquire(t *testing.T) {
+	lockDir := t.TempDir()
+	throttle := New(lockDir, 2, 50*time.Millisecond)
+
+	first, err := throttle.Acquire()
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	second, err := throttle.Acquire()
+	require.NoError(t, err)
+	require.NotNil(t, second)
+
+	_, err = throttle.Acquire()
+	require.ErrorIs(t, err, ErrTimeout)
+
+	require.NoError(t, first.Release())
+
+	third, err := throttle.Acquire()
+	require.NoError(t, err)
+	require.NotNil(t, third)
+
+	require.NoError(t, second.Release())
+	require.NoError(t, third.Release())
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	throttle := New(t.TempDir(), 1, 5*time.Second)
+
+	held, err := throttle.Acquire()
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Release()
+	}()
+
+	slot, err := throttle.Acquire()
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	require.NoError(t, slot.Release())
+}
+
+func TestDisabled(t *testing.T) {
+	lockDir := t.TempDir() + "/locks"
+	throttle := New(lockDir, 0, 0)
+	require.False(t, throttle.Enabled())
+
+	slot, err := throttle.Acquire()
+	require.NoError(t, err)
+	require.Nil(t, slot)
+	require.NoError(t, slot.KeepOnExec())
+	require.NoError(t, slot.Release())
+
+	_, err = os.Stat(lockDir)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestKeepOnExec(t *testing.T) {
+	slot, err := New(t.TempDir(), 1, 0).Acquire()
+	require.NoError(t, err)
+	defer slot.Release()
+
+	require.NoError(t, slot.KeepOnExec())
+
+	flags, err := unix.FcntlInt(slot.file.Fd(), unix.F_GETFD, 0)
+	require.NoError(t, err)
+	require.Zero(t, flags&unix.FD_CLOEXEC)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	cfg := &config.RuntimeConfig{}
+
+	throttle, err := NewFromConfig(cfg)
+	require.NoError(t, err)
+	require.False(t, throttle.Enabled())
+	require.Equal(t, DefaultLockDir, throttle.lockDir)
+	require.Equal(t, DefaultTimeout, throttle.timeout)
+
+	cfg.NVMLThrottle.MaxConcurrent = 4
+	cfg.NVMLThrottle.Timeout = "5s"
+	throttle, err = NewFromConfig(cfg)
+	require.NoError(t, err)
+	require.True(t, throttle.Enabled())
+	require.Equal(t, 5*time.Second, throttle.timeout)
+
+	cfg.NVMLThrottle.Timeout = "invalid"
+	_, err = NewFromConfig(cfg)
+	require.Error(t, err)
+}
@@ -0,0 +1,140 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package platform determines the architecture of container images so that a container that is
+// run using emulation (e.g. binfmt_misc and qemu-user) can be detected.
+package platform
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxSymlinks is the maximum number of symlinks that are followed when resolving a path in a root.
+const maxSymlinks = 40
+
+// probes are the files in a container root filesystem whose architecture is checked. The first
+// file that exists and is an ELF file determines the architecture.
+var probes = []string{
+	"/bin/sh",
+	"/usr/bin/env",
+	"/bin/busybox",
+	"/usr/bin/bash",
+}
+
+// machines maps ELF machine types to architectures as named by GOARCH.
+var machines = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_PPC64:   "ppc64le",
+	elf.EM_S390:    "s390x",
+	elf.EM_386:     "386",
+	elf.EM_ARM:     "arm",
+	elf.EM_RISCV:   "riscv64",
+	// EM_LOONGARCH is only defined in debug/elf as of go1.19.
+	elf.Machine(258): "loong64",
+}
+
+// HostArchitecture returns the architecture of the host as named by GOARCH.
+func HostArchitecture() string {
+	return runtime.GOARCH
+}
+
+// GetArchitecture returns the architecture (as named by GOARCH) of the executables in the
+// specified container root filesystem.
+func GetArchitecture(rootfs string) (string, error) {
+	for _, probe := range probes {
+		path, err := resolveInRoot(rootfs, probe)
+		if err != nil {
+			continue
+		}
+		arch, err := getELFArchitecture(path)
+		if err != nil {
+			continue
+		}
+		return arch, nil
+	}
+	return "", fmt.Errorf("no executable found in %v to determine the architecture", rootfs)
+}
+
+// getELFArchitecture returns the architecture of the specified ELF file.
+func getELFArchitecture(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	arch, ok := machines[f.Machine]
+	if !ok {
+		return "", fmt.Errorf("unsupported machine %v", f.Machine)
+	}
+	if arch == "ppc64le" && f.Data != elf.ELFDATA2LSB {
+		arch = "ppc64"
+	}
+	return arch, nil
+}
+
+// resolveInRoot resolves the specified path in the root, following symlinks (including those of
+// intermediate directories such as /bin -> usr/bin) as if the root were the root filesystem.
+func resolveInRoot(root string, path string) (string, error) {
+	var resolved string
+	remaining := strings.Split(strings.TrimPrefix(filepath.Clean("/"+path), "/"), "/")
+	var followed int
+	for len(remaining) > 0 {
+		component := remaining[0]
+		remaining = remaining[1:]
+
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			if resolved == "." {
+				resolved = ""
+			}
+			continue
+		}
+
+		candidate := filepath.Join(resolved, component)
+		info, err := os.Lstat(filepath.Join(root, candidate))
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+
+		followed++
+		if followed > maxSymlinks {
+			return "", fmt.Errorf("too many symlinks resolving %v", path)
+		}
+		target, err := os.Readlink(filepath.Join(root, candidate))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = ""
+		}
+		remaining = append(strings.Split(target, "/"), remaining...)
+	}
+	return filepath.Join(root, resolved), nil
+}
@@ -0,0 +1,120 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package platform
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeELFHeader writes a minimal 64-bit little-endian ELF header for the specified machine.
+func writeELFHeader(t *testing.T, path string, machine elf.Machine) {
+	header := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Ehsize:    64,
+		Phentsize: 56,
+		Shentsize: 64,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, header))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0755))
+}
+
+func TestGetArchitecture(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setup         func(t *testing.T, rootfs string)
+		expectedArch  string
+		expectedError bool
+	}{
+		{
+			description: "arm64 shell",
+			setup: func(t *testing.T, rootfs string) {
+				writeELFHeader(t, filepath.Join(rootfs, "bin/sh"), elf.EM_AARCH64)
+			},
+			expectedArch: "arm64",
+		},
+		{
+			description: "merged usr with absolute symlink",
+			setup: func(t *testing.T, rootfs string) {
+				writeELFHeader(t, filepath.Join(rootfs, "usr/bin/dash"), elf.EM_X86_64)
+				require.NoError(t, os.Symlink("usr/bin", filepath.Join(rootfs, "bin")))
+				require.NoError(t, os.Symlink("/usr/bin/dash", filepath.Join(rootfs, "usr/bin/sh")))
+			},
+			expectedArch: "amd64",
+		},
+		{
+			description: "symlink does not escape root",
+			setup: func(t *testing.T, rootfs string) {
+				require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "bin"), 0755))
+				require.NoError(t, os.Symlink("../../../../../../../usr/bin/busybox", filepath.Join(rootfs, "bin/sh")))
+				writeELFHeader(t, filepath.Join(rootfs, "usr/bin/busybox"), elf.EM_S390)
+			},
+			expectedArch: "s390x",
+		},
+		{
+			description: "busybox is used if there is no shell",
+			setup: func(t *testing.T, rootfs string) {
+				writeELFHeader(t, filepath.Join(rootfs, "bin/busybox"), elf.EM_PPC64)
+			},
+			expectedArch: "ppc64le",
+		},
+		{
+			description: "script is skipped",
+			setup: func(t *testing.T, rootfs string) {
+				require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "bin"), 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(rootfs, "bin/sh"), []byte("#!/bin/busybox\n"), 0755))
+				writeELFHeader(t, filepath.Join(rootfs, "bin/busybox"), elf.EM_AARCH64)
+			},
+			expectedArch: "arm64",
+		},
+		{
+			description:   "empty rootfs",
+			setup:         func(t *testing.T, rootfs string) {},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rootfs := t.TempDir()
+			tc.setup(t, rootfs)
+
+			arch, err := GetArchitecture(rootfs)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedArch, arch)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// NamespaceAnnotation is the annotation set by the CRI runtimes to the Kubernetes namespace of a container.
+const NamespaceAnnotation = "io.kubernetes.pod.namespace"
+
+// DeviceLimit returns the maximum number of devices that a container with the specified annotations
+// may request. An override matching one of the annotations takes precedence over an override for
+// the namespace of the container, which takes precedence over max-devices-per-container. If more
+// than one annotation override matches, the highest limit applies. A limit of 0 indicates that the
+// number of devices is not limited.
+//
+// Since annotations can be set by the workload, annotation overrides are only applied for the keys
+// listed in trusted-annotation-keys.
+func DeviceLimit(cfg *config.RuntimeConfig, annotations map[string]string) int {
+	limits := cfg.DeviceLimits
+
+	trusted := make(map[string]bool)
+	for _, key := range limits.TrustedAnnotationKeys {
+		trusted[key] = true
+	}
+
+	limit := -1
+	for selector, l := range limits.Annotations {
+		key, _, _ := strings.Cut(selector, "=")
+		if !trusted[key] || !matchesAnnotation(selector, annotations) {
+			continue
+		}
+		limit = higherLimit(limit, l)
+	}
+	if limit >= 0 {
+		return limit
+	}
+
+	if namespace := annotations[NamespaceAnnotation]; namespace != "" {
+		if l, ok := limits.Namespaces[namespace]; ok {
+			return l
+		}
+	}
+
+	return limits.MaxDevicesPerContainer
+}
+
+// matchesAnnotation checks whether the specified annotations match a selector of the form key=value
+// or key.
+func matchesAnnotation(selector string, annotations map[string]string) bool {
+	key, value, hasValue := strings.Cut(selector, "=")
+	v, ok := annotations[key]
+	if !ok {
+		return false
+	}
+	return !hasValue || v == value
+}
+
+// higherLimit returns the less restrictive of the two limits. A negative limit is unset and a limit
+// of 0 is unlimited.
+func higherLimit(a int, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// CheckDeviceLimit returns an error if the number of requested devices exceeds the specified limit.
+// A request for all devices (e.g. all or nvidia.com/gpu=all) counts as the number of GPUs returned
+// by countAll, which is only called for such requests. Since such a request includes all other
+// requested devices, the devices are only counted once if all devices are requested more than
+// once or in combination with individual devices.
+func CheckDeviceLimit(limit int, devices []string, countAll func() (int, error)) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	var all string
+	seen := make(map[string]bool)
+	for _, device := range devices {
+		if device == "" || device == "none" || device == "void" {
+			continue
+		}
+		if device == "all" || strings.HasSuffix(device, "=all") {
+			all = device
+			continue
+		}
+		seen[device] = true
+	}
+
+	count := len(seen)
+	if all != "" {
+		var err error
+		count, err = countAll()
+		if err != nil {
+			return fmt.Errorf("failed to determine the number of devices requested as %q: %v", all, err)
+		}
+	}
+
+	if count > limit {
+		return fmt.Errorf("%d devices requested but at most %d devices may be requested per container", count, limit)
+	}
+	return nil
+}
+
+// CountGPUs returns the number of GPUs on the node. The number of GPUs is queried using NVML if
+// possible. Otherwise, the GPU device nodes (e.g. /dev/nvidia0) are counted in the dev directories
+// of the specified roots (e.g. / and the driver root, since a containerized driver may create the
+// device nodes in its root), with the highest count being used. Since a request for all devices
+// must not bypass the device limit, an error is returned if no GPUs are found.
+func CountGPUs(nvmllib nvml.Interface, roots ...string) (int, error) {
+	if count, err := countNVMLDevices(nvmllib); err == nil && count > 0 {
+		return count, nil
+	}
+
+	var count int
+	for _, root := range roots {
+		nodes, err := filepath.Glob(filepath.Join(root, "/dev/nvidia[0-9]*"))
+		if err != nil {
+			return 0, err
+		}
+		if len(nodes) > count {
+			count = len(nodes)
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no GPUs found")
+	}
+	return count, nil
+}
+
+// countNVMLDevices returns the number of GPUs reported by NVML.
+func countNVMLDevices(nvmllib nvml.Interface) (int, error) {
+	if nvmllib == nil {
+		return 0, fmt.Errorf("NVML is not available")
+	}
+	if r := nvmllib.Init(); r != nvml.SUCCESS {
+		return 0, fmt.Errorf("failed to initialize NVML: %v", r)
+	}
+	defer nvmllib.Shutdown()
+
+	count, r := nvmllib.DeviceGetCount()
+	if r != nvml.SUCCESS {
+		return 0, fmt.Errorf("failed to get device count: %v", r)
+	}
+	return count, nil
+}
@@ -0,0 +1,230 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+func TestDeviceLimit(t *testing.T) {
+	cfg := &config.RuntimeConfig{}
+	cfg.DeviceLimits.MaxDevicesPerContainer = 2
+	cfg.DeviceLimits.Namespaces = map[string]int{"training": 8, "interactive": 1}
+	cfg.DeviceLimits.Annotations = map[string]int{
+		"example.com/tier=large": 4,
+		"example.com/tier=xl":    6,
+		"example.com/unlimited":  0,
+		"example.com/untrusted":  0,
+	}
+	cfg.DeviceLimits.TrustedAnnotationKeys = []string{"example.com/tier", "example.com/unlimited"}
+
+	testCases := []struct {
+		description   string
+		annotations   map[string]string
+		expectedLimit int
+	}{
+		{
+			description:   "no annotations uses default",
+			expectedLimit: 2,
+		},
+		{
+			description:   "namespace override",
+			annotations:   map[string]string{NamespaceAnnotation: "training"},
+			expectedLimit: 8,
+		},
+		{
+			description:   "unknown namespace uses default",
+			annotations:   map[string]string{NamespaceAnnotation: "other"},
+			expectedLimit: 2,
+		},
+		{
+			description:   "annotation override takes precedence over namespace",
+			annotations:   map[string]string{NamespaceAnnotation: "training", "example.com/tier": "large"},
+			expectedLimit: 4,
+		},
+		{
+			description:   "annotation with other value does not match",
+			annotations:   map[string]string{"example.com/tier": "small"},
+			expectedLimit: 2,
+		},
+		{
+			description:   "key-only selector matches any value",
+			annotations:   map[string]string{"example.com/unlimited": "yes", "example.com/tier": "xl"},
+			expectedLimit: 0,
+		},
+		{
+			description:   "untrusted annotation is ignored",
+			annotations:   map[string]string{"example.com/untrusted": "yes"},
+			expectedLimit: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedLimit, DeviceLimit(cfg, tc.annotations))
+		})
+	}
+}
+
+func TestCheckDeviceLimit(t *testing.T) {
+	countAll := func() (int, error) { return 4, nil }
+
+	testCases := []struct {
+		description   string
+		limit         int
+		devices       []string
+		countAllError error
+		expectedError bool
+	}{
+		{
+			description: "no limit",
+			limit:       0,
+			devices:     []string{"all"},
+		},
+		{
+			description: "within limit",
+			limit:       2,
+			devices:     []string{"0", "1"},
+		},
+		{
+			description:   "exceeds limit",
+			limit:         2,
+			devices:       []string{"0", "1", "2"},
+			expectedError: true,
+		},
+		{
+			description: "duplicates are counted once",
+			limit:       2,
+			devices:     []string{"0", "1", "1", "0"},
+		},
+		{
+			description:   "all counts as all GPUs",
+			limit:         3,
+			devices:       []string{"all"},
+			expectedError: true,
+		},
+		{
+			description:   "CDI all counts as all GPUs",
+			limit:         3,
+			devices:       []string{"nvidia.com/gpu=all"},
+			expectedError: true,
+		},
+		{
+			description: "all within limit",
+			limit:       4,
+			devices:     []string{"nvidia.com/gpu=all"},
+		},
+		{
+			description: "all requested more than once is counted once",
+			limit:       4,
+			devices:     []string{"all", "nvidia.com/gpu=all", "0"},
+		},
+		{
+			description: "none and void are not counted",
+			limit:       1,
+			devices:     []string{"none", "void", ""},
+		},
+		{
+			description:   "error counting all is returned",
+			limit:         8,
+			devices:       []string{"all"},
+			countAllError: fmt.Errorf("count failed"),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			count := countAll
+			if tc.countAllError != nil {
+				count = func() (int, error) { return 0, tc.countAllError }
+			}
+			err := CheckDeviceLimit(tc.limit, tc.devices, count)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCountGPUs(t *testing.T) {
+	hostRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(hostRoot, "dev"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hostRoot, "dev", "nvidiactl"), nil, 0644))
+
+	driverRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(driverRoot, "dev"), 0755))
+	for _, name := range []string{"nvidia0", "nvidia1", "nvidia10", "nvidiactl", "nvidia-uvm"} {
+		require.NoError(t, os.WriteFile(filepath.Join(driverRoot, "dev", name), nil, 0644))
+	}
+
+	newNVML := func(r nvml.Return) nvml.Interface {
+		return &nvml.InterfaceMock{
+			InitFunc:           func() nvml.Return { return r },
+			ShutdownFunc:       func() nvml.Return { return nvml.SUCCESS },
+			DeviceGetCountFunc: func() (int, nvml.Return) { return 8, nvml.SUCCESS },
+		}
+	}
+
+	testCases := []struct {
+		description   string
+		nvmllib       nvml.Interface
+		roots         []string
+		expectedCount int
+		expectedError bool
+	}{
+		{
+			description:   "NVML is used if available",
+			nvmllib:       newNVML(nvml.SUCCESS),
+			roots:         []string{hostRoot},
+			expectedCount: 8,
+		},
+		{
+			description:   "device nodes are counted if NVML is unavailable",
+			nvmllib:       newNVML(nvml.ERROR_LIBRARY_NOT_FOUND),
+			roots:         []string{hostRoot, driverRoot},
+			expectedCount: 3,
+		},
+		{
+			description:   "no GPUs is an error",
+			nvmllib:       newNVML(nvml.ERROR_LIBRARY_NOT_FOUND),
+			roots:         []string{hostRoot},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			count, err := CountGPUs(tc.nvmllib, tc.roots...)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedCount, count)
+		})
+	}
+}
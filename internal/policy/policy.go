@@ -0,0 +1,161 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package policy evaluates the configured policies for container images requesting devices.
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+	"github.com/sirupsen/logrus"
+)
+
+const visibleDevicesEnvvar = "NVIDIA_VISIBLE_DEVICES"
+
+// Result is the result of evaluating the policies for an image.
+type Result struct {
+	Allowed bool     `json:"allowed"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Evaluate applies the configured policies to an image with the specified environment. These are:
+//   - the requested driver capabilities may not include any of the forbidden capabilities;
+//   - each of the requested devices must match one of the allowed device selectors;
+//   - the requirements of the image (e.g. NVIDIA_REQUIRE_CUDA) must be satisfied by the
+//     configured driver and CUDA versions.
+//
+// Images that do not request any devices (including images that set NVIDIA_VISIBLE_DEVICES to none,
+// void, or an empty value) are always allowed.
+func Evaluate(logger *logrus.Logger, cfg *config.Config, image image.CUDA) (*Result, error) {
+	properties := requirements.Properties{
+		Driver: cfg.NVIDIAContainerRuntimeConfig.Policy.DriverVersion,
+		CUDA:   cfg.NVIDIAContainerRuntimeConfig.Policy.CUDAVersion,
+	}
+	return EvaluateWithProperties(logger, cfg, image, properties)
+}
+
+// EvaluateWithProperties applies the configured policies to an image, evaluating the requirements of
+// the image against the specified node properties instead of the configured driver and CUDA versions.
+func EvaluateWithProperties(logger *logrus.Logger, cfg *config.Config, image image.CUDA, properties requirements.Properties) (*Result, error) {
+	policy := cfg.NVIDIAContainerRuntimeConfig.Policy
+
+	devices := requestedDevices(image)
+	if len(devices) == 0 {
+		return &Result{Allowed: true}, nil
+	}
+
+	var reasons []string
+	reasons = append(reasons, checkCapabilities(policy.ForbiddenCapabilities, image)...)
+	reasons = append(reasons, checkDevices(policy.AllowedDevices, devices)...)
+
+	requirementReasons, err := checkRequirements(logger, properties, image)
+	if err != nil {
+		return nil, err
+	}
+	reasons = append(reasons, requirementReasons...)
+
+	r := Result{
+		Allowed: len(reasons) == 0,
+		Reasons: reasons,
+	}
+	return &r, nil
+}
+
+// requestedDevices returns the devices requested by the image. The none and void selectors and empty
+// values do not request any devices.
+func requestedDevices(i image.CUDA) []string {
+	var devices []string
+	for _, device := range i.DevicesFromEnvvars(visibleDevicesEnvvar).List() {
+		if device == "" || device == "none" || device == "void" {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// checkCapabilities returns a reason for each of the forbidden capabilities requested by the image.
+func checkCapabilities(forbidden []string, i image.CUDA) []string {
+	capabilities := i.GetDriverCapabilities()
+
+	var reasons []string
+	for _, c := range forbidden {
+		if !capabilities.Has(image.DriverCapability(c)) {
+			continue
+		}
+		if capabilities[image.DriverCapabilityAll] {
+			reasons = append(reasons, fmt.Sprintf("driver capability %q is forbidden (requested using %q)", c, image.DriverCapabilityAll))
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("driver capability %q is forbidden", c))
+	}
+	return reasons
+}
+
+// checkDevices returns a reason for each requested device that does not match any of the allowed selectors.
+func checkDevices(allowed []string, devices []string) []string {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var reasons []string
+	for _, device := range devices {
+		if !matchesAny(allowed, device) {
+			reasons = append(reasons, fmt.Sprintf("device %q is not allowed", device))
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if match, _ := filepath.Match(pattern, value); match {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequirements evaluates the requirements of the image against the specified node properties.
+// No requirements are evaluated if the driver version is not specified or if the image sets
+// NVIDIA_DISABLE_REQUIRE.
+func checkRequirements(logger *logrus.Logger, properties requirements.Properties, i image.CUDA) ([]string, error) {
+	if properties.Driver == "" || i.HasDisableRequire() {
+		return nil, nil
+	}
+
+	imageRequirements, err := i.GetRequirements()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image requirements: %v", err)
+	}
+	sort.Strings(imageRequirements)
+
+	var reasons []string
+	for _, requirement := range imageRequirements {
+		r := requirements.New(logger, []string{requirement})
+		r.AddProperties(properties)
+		if err := r.Assert(); err != nil {
+			reasons = append(reasons, fmt.Sprintf("requirement %q is not satisfied: %v", requirement, err))
+		}
+	}
+	return reasons, nil
+}
@@ -0,0 +1,171 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/requirements"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	policy := &config.Config{}
+	policy.NVIDIAContainerRuntimeConfig.Policy.ForbiddenCapabilities = []string{"display"}
+	policy.NVIDIAContainerRuntimeConfig.Policy.AllowedDevices = []string{"all", "GPU-*"}
+	policy.NVIDIAContainerRuntimeConfig.Policy.DriverVersion = "470.57.02"
+	policy.NVIDIAContainerRuntimeConfig.Policy.CUDAVersion = "11.4"
+
+	testCases := []struct {
+		description    string
+		cfg            *config.Config
+		env            []string
+		expectedResult *Result
+	}{
+		{
+			description:    "empty policy allows all",
+			cfg:            &config.Config{},
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=0", "NVIDIA_DRIVER_CAPABILITIES=all", "NVIDIA_REQUIRE_CUDA=cuda>=12.0"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description:    "no devices requested is allowed",
+			cfg:            policy,
+			env:            []string{"NVIDIA_DRIVER_CAPABILITIES=display"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description:    "none is not a device request",
+			cfg:            policy,
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=none", "NVIDIA_DRIVER_CAPABILITIES=display"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description:    "void is not a device request",
+			cfg:            policy,
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=void", "NVIDIA_DRIVER_CAPABILITIES=display"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description:    "empty value is not a device request",
+			cfg:            policy,
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=", "NVIDIA_DRIVER_CAPABILITIES=display"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description:    "allowed request",
+			cfg:            policy,
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=GPU-1234", "NVIDIA_DRIVER_CAPABILITIES=compute,utility", "NVIDIA_REQUIRE_CUDA=cuda>=11.0"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description: "forbidden capability",
+			cfg:         policy,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=compute,display"},
+			expectedResult: &Result{
+				Reasons: []string{`driver capability "display" is forbidden`},
+			},
+		},
+		{
+			description: "forbidden capability requested using all",
+			cfg:         policy,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_DRIVER_CAPABILITIES=all"},
+			expectedResult: &Result{
+				Reasons: []string{`driver capability "display" is forbidden (requested using "all")`},
+			},
+		},
+		{
+			description: "device not allowed",
+			cfg:         policy,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=1,0,GPU-1234"},
+			expectedResult: &Result{
+				Reasons: []string{`device "0" is not allowed`, `device "1" is not allowed`},
+			},
+		},
+		{
+			description: "unsatisfied requirement",
+			cfg:         policy,
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_REQUIRE_CUDA=cuda>=12.0"},
+			expectedResult: &Result{
+				Reasons: []string{`requirement "cuda>=12.0" is not satisfied: unsatisfied condition: cuda>=12.0 (cuda=11.4)`},
+			},
+		},
+		{
+			description:    "requirements are not checked if disabled",
+			cfg:            policy,
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_REQUIRE_CUDA=cuda>=12.0", "NVIDIA_DISABLE_REQUIRE=true"},
+			expectedResult: &Result{Allowed: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			i, err := image.NewCUDAImageFromEnv(tc.env)
+			require.NoError(t, err)
+
+			result, err := Evaluate(logger, tc.cfg, i)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
+
+func TestEvaluateWithProperties(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	properties := requirements.Properties{
+		Driver: "525.60.13",
+		CUDA:   "12.0",
+		Arch:   "8.0",
+		Brand:  "tesla",
+	}
+
+	testCases := []struct {
+		description    string
+		env            []string
+		expectedResult *Result
+	}{
+		{
+			description:    "satisfied requirements",
+			env:            []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_REQUIRE_CUDA=cuda>=12.0 brand=geforce,driver>=470", "NVIDIA_REQUIRE_ARCH=arch>=7.0"},
+			expectedResult: &Result{Allowed: true},
+		},
+		{
+			description: "unsatisfied brand requirement",
+			env:         []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_REQUIRE_BRAND=brand=geforce"},
+			expectedResult: &Result{
+				Reasons: []string{`requirement "brand=geforce" is not satisfied: unsatisfied condition: brand=geforce (brand=tesla)`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			i, err := image.NewCUDAImageFromEnv(tc.env)
+			require.NoError(t, err)
+
+			result, err := EvaluateWithProperties(logger, &config.Config{}, i, properties)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package privileges supports running the hooks of the NVIDIA Container Toolkit with reduced privileges.
+package privileges
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/syndtr/gocapability/capability"
+)
+
+const (
+	// UserFlag is the flag of the nvidia-ctk hook command used to specify the user (and group).
+	UserFlag = "user"
+	// CapabilitiesFlag is the flag of the nvidia-ctk hook command used to specify the retained capabilities.
+	CapabilitiesFlag = "capabilities"
+
+	// droppedEnvvar is set for a hook that has been re-executed with reduced privileges.
+	droppedEnvvar = "NVIDIA_CTK_HOOK_PRIVILEGES_DROPPED"
+)
+
+// DefaultCapabilities are the capabilities that are retained by default when a hook is run
+// with reduced privileges. These are required to create files (e.g. symlinks) in the container
+// root filesystem and to run ldconfig in the container.
+var DefaultCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_FOWNER",
+	"CAP_SYS_CHROOT",
+}
+
+// Privileges defines the user, group, and capabilities with which a hook is run.
+type Privileges struct {
+	UID          uint32
+	GID          uint32
+	Capabilities []capability.Cap
+}
+
+// New creates the privileges for the specified user and capabilities. The user is specified
+// as uid[:gid] and the gid defaults to the uid if omitted. Capabilities are specified by name
+// (e.g. CAP_DAC_OVERRIDE). If the user is empty, nil is returned and hooks are run with the
+// privileges of the runtime.
+func New(user string, capabilities []string) (*Privileges, error) {
+	if user == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(user, ":", 2)
+	uid, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user %q: %v", user, err)
+	}
+	gid := uid
+	if len(parts) == 2 {
+		gid, err = strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group in %q: %v", user, err)
+		}
+	}
+
+	p := Privileges{
+		UID: uint32(uid),
+		GID: uint32(gid),
+	}
+	for _, name := range capabilities {
+		c, err := parseCapability(name)
+		if err != nil {
+			return nil, err
+		}
+		p.Capabilities = append(p.Capabilities, c)
+	}
+	return &p, nil
+}
+
+// parseCapability returns the capability with the specified name. The CAP_ prefix is optional.
+func parseCapability(name string) (capability.Cap, error) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "CAP_"))
+	for _, c := range capability.List() {
+		if c.String() == normalized {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown capability %q", name)
+}
+
+// Args returns the flags of the nvidia-ctk hook command that select these privileges.
+func (p *Privileges) Args() []string {
+	args := []string{fmt.Sprintf("--%v=%d:%d", UserFlag, p.UID, p.GID)}
+	if len(p.Capabilities) > 0 {
+		var names []string
+		for _, c := range p.Capabilities {
+			names = append(names, "CAP_"+strings.ToUpper(c.String()))
+		}
+		args = append(args, fmt.Sprintf("--%v=%v", CapabilitiesFlag, strings.Join(names, ",")))
+	}
+	return args
+}
+
+// HookArgs returns the arguments of the specified hook with the flags selecting these privileges
+// inserted. Hooks that do not invoke the nvidia-ctk hook command or that already specify a user
+// are returned unchanged.
+func (p *Privileges) HookArgs(path string, args []string) []string {
+	if p == nil || !IsNVIDIACTKHook(path, args) {
+		return args
+	}
+	for _, arg := range args[2:] {
+		if arg == "--"+UserFlag || strings.HasPrefix(arg, "--"+UserFlag+"=") {
+			return args
+		}
+	}
+
+	updated := append([]string{}, args[:2]...)
+	updated = append(updated, p.Args()...)
+	return append(updated, args[2:]...)
+}
+
+// IsNVIDIACTKHook checks whether the specified hook invokes the nvidia-ctk hook command.
+func IsNVIDIACTKHook(path string, args []string) bool {
+	return filepath.Base(path) == "nvidia-ctk" && len(args) > 1 && args[1] == "hook"
+}
+
+// NVIDIACTKHookName returns the name of the nvidia-ctk hook subcommand (e.g. create-symlinks) that
+// is invoked by the specified hook. The flags of the hook command (e.g. --user) that precede the
+// subcommand are skipped. If the hook does not invoke the nvidia-ctk hook command, "" is returned.
+func NVIDIACTKHookName(path string, args []string) string {
+	if !IsNVIDIACTKHook(path, args) {
+		return ""
+	}
+	for i := 2; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			return args[i]
+		}
+		// All flags of the hook command take a value.
+		if !strings.Contains(args[i], "=") {
+			i++
+		}
+	}
+	return ""
+}
+
+// Dropped checks whether the current process has already been re-executed with reduced privileges.
+func Dropped() bool {
+	return os.Getenv(droppedEnvvar) != ""
+}
+
+// Command returns a command that re-executes the specified executable with these privileges. The
+// specified capabilities are raised in the ambient set so that these are also retained by
+// executables invoked by the hook (e.g. ldconfig). All other capabilities are dropped.
+func (p *Privileges) Command(executable string, args []string) *exec.Cmd {
+	cmd := exec.Command(executable, args...)
+	cmd.Env = append(os.Environ(), droppedEnvvar+"=true")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	var ambient []uintptr
+	for _, c := range p.Capabilities {
+		ambient = append(ambient, uintptr(c))
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{
+			Uid:    p.UID,
+			Gid:    p.GID,
+			Groups: []uint32{},
+		},
+		AmbientCaps: ambient,
+	}
+	return cmd
+}
@@ -0,0 +1,162 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/gocapability/capability"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		user          string
+		capabilities  []string
+		expected      *Privileges
+		expectedError bool
+	}{
+		{
+			user: "",
+		},
+		{
+			user:     "65534",
+			expected: &Privileges{UID: 65534, GID: 65534},
+		},
+		{
+			user:         "1000:2000",
+			capabilities: []string{"CAP_DAC_OVERRIDE", "fowner", "Cap_Sys_Chroot"},
+			expected: &Privileges{
+				UID:          1000,
+				GID:          2000,
+				Capabilities: []capability.Cap{capability.CAP_DAC_OVERRIDE, capability.CAP_FOWNER, capability.CAP_SYS_CHROOT},
+			},
+		},
+		{
+			user:          "nobody",
+			expectedError: true,
+		},
+		{
+			user:          "1000:nogroup",
+			expectedError: true,
+		},
+		{
+			user:          "1000",
+			capabilities:  []string{"CAP_UNKNOWN"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.user, func(t *testing.T) {
+			p, err := New(tc.user, tc.capabilities)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestHookArgs(t *testing.T) {
+	p, err := New("1000", []string{"CAP_DAC_OVERRIDE", "CAP_FOWNER"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		description string
+		privileges  *Privileges
+		path        string
+		args        []string
+		expected    []string
+	}{
+		{
+			description: "nvidia-ctk hook is updated",
+			privileges:  p,
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "a::b"},
+			expected:    []string{"nvidia-ctk", "hook", "--user=1000:1000", "--capabilities=CAP_DAC_OVERRIDE,CAP_FOWNER", "create-symlinks", "--link", "a::b"},
+		},
+		{
+			description: "nil privileges leave hook unchanged",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "chmod"},
+			expected:    []string{"nvidia-ctk", "hook", "chmod"},
+		},
+		{
+			description: "hook with user is unchanged",
+			privileges:  p,
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "--user=0", "chmod"},
+			expected:    []string{"nvidia-ctk", "hook", "--user=0", "chmod"},
+		},
+		{
+			description: "other hooks are unchanged",
+			privileges:  p,
+			path:        "/usr/bin/nvidia-container-runtime-hook",
+			args:        []string{"nvidia-container-runtime-hook", "prestart"},
+			expected:    []string{"nvidia-container-runtime-hook", "prestart"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.privileges.HookArgs(tc.path, tc.args))
+		})
+	}
+}
+
+func TestNVIDIACTKHookName(t *testing.T) {
+	testCases := []struct {
+		description string
+		path        string
+		args        []string
+		expected    string
+	}{
+		{
+			description: "hook without flags",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "a::b"},
+			expected:    "create-symlinks",
+		},
+		{
+			description: "flags are skipped",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "--user=1000:1000", "--timeout", "30s", "update-ldcache", "--folder", "/usr/lib64"},
+			expected:    "update-ldcache",
+		},
+		{
+			description: "hook command without subcommand",
+			path:        "/usr/bin/nvidia-ctk",
+			args:        []string{"nvidia-ctk", "hook", "--user=0"},
+			expected:    "",
+		},
+		{
+			description: "other hooks have no name",
+			path:        "/usr/bin/nvidia-container-runtime-hook",
+			args:        []string{"nvidia-container-runtime-hook", "prestart"},
+			expected:    "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, NVIDIACTKHookName(tc.path, tc.args))
+		})
+	}
+}
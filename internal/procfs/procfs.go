@@ -0,0 +1,132 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package procfs provides functions to determine which (containerized) processes are using devices.
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// containerIDPattern matches the (64 character) container IDs used by the supported container engines
+// in cgroup paths (e.g. docker-<id>.scope, cri-containerd-<id>.scope, or crio-<id>.scope).
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// Process represents a process that has a device node open.
+type Process struct {
+	PID int
+	// Name is the command name of the process as reported in /proc/<pid>/comm.
+	Name string
+	// ContainerID is the ID of the container the process belongs to. This is empty for
+	// processes that are not running in a container.
+	ContainerID string
+}
+
+// ContainerOrHost returns the container ID of the process, or <host> for processes that are
+// not running in a container.
+func (p Process) ContainerOrHost() string {
+	if p.ContainerID == "" {
+		return "<host>"
+	}
+	return p.ContainerID
+}
+
+// FindDeviceProcesses returns the processes that have the specified device node open by inspecting
+// the file descriptors of each process under the specified proc root.
+func FindDeviceProcesses(procRoot string, devicePath string) ([]Process, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if !hasOpenFile(filepath.Join(procRoot, entry.Name(), "fd"), devicePath) {
+			continue
+		}
+		processes = append(processes, Process{
+			PID:         pid,
+			Name:        getName(filepath.Join(procRoot, entry.Name(), "comm")),
+			ContainerID: getContainerID(filepath.Join(procRoot, entry.Name(), "cgroup")),
+		})
+	}
+	return processes, nil
+}
+
+// hasOpenFile checks whether any of the file descriptors in the specified directory refer to the
+// specified path. Processes that exit or cannot be inspected are ignored.
+func hasOpenFile(fdDir string, path string) bool {
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		if target == path {
+			return true
+		}
+	}
+	return false
+}
+
+// getName reads the command name of a process from the specified comm file. An empty string is
+// returned if the name cannot be read.
+func getName(commFile string) string {
+	contents, err := os.ReadFile(commFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+// getContainerID extracts the container ID from the specified cgroup file. An empty string is
+// returned for processes that are not running in a container.
+func getContainerID(cgroupFile string) string {
+	contents, err := os.ReadFile(cgroupFile)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Running returns the processes that are still running.
+func Running(processes []Process) []Process {
+	var running []Process
+	for _, p := range processes {
+		if err := syscall.Kill(p.PID, 0); err == syscall.ESRCH {
+			continue
+		}
+		running = append(running, p)
+	}
+	return running
+}
@@ -0,0 +1,55 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDeviceProcesses(t *testing.T) {
+	containerID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	procRoot := t.TempDir()
+	createProcess(t, procRoot, "100", []string{"/dev/nvidia0", "/dev/nvidiactl"}, "0::/system.slice/docker-"+containerID+".scope\n")
+	createProcess(t, procRoot, "200", []string{"/dev/nvidia1", "/dev/null"}, "0::/user.slice\n")
+	createProcess(t, procRoot, "300", []string{"/dev/nvidia0"}, "0::/user.slice\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "self"), 0755))
+
+	processes, err := FindDeviceProcesses(procRoot, "/dev/nvidia0")
+	require.NoError(t, err)
+	require.Equal(t, []Process{
+		{PID: 100, Name: "process-100", ContainerID: containerID},
+		{PID: 300, Name: "process-300"},
+	}, processes)
+
+	require.Equal(t, containerID, processes[0].ContainerOrHost())
+	require.Equal(t, "<host>", processes[1].ContainerOrHost())
+}
+
+func createProcess(t *testing.T, procRoot string, pid string, files []string, cgroup string) {
+	fdDir := filepath.Join(procRoot, pid, "fd")
+	require.NoError(t, os.MkdirAll(fdDir, 0755))
+	for i, file := range files {
+		require.NoError(t, os.Symlink(file, filepath.Join(fdDir, string(rune('0'+i)))))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, pid, "cgroup"), []byte(cgroup), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, pid, "comm"), []byte("process-"+pid+"\n"), 0644))
+}
@@ -0,0 +1,134 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	cdispecs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Mask replaces the values of redacted environment variables.
+const Mask = "REDACTED"
+
+// DefaultPatterns are the patterns for the names of environment variables whose values are
+// redacted by default.
+var DefaultPatterns = []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*KEY*", "*CREDENTIAL*"}
+
+// Redactor masks the values of environment variables whose names match one of a set of glob
+// patterns. Names are matched case-insensitively.
+type Redactor struct {
+	patterns []string
+}
+
+// New creates a Redactor for the specified patterns. An error is returned for invalid patterns.
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range patterns {
+		pattern = strings.ToUpper(pattern)
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", pattern, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+// Env returns a copy of the specified environment (in the form NAME=VALUE) with the values of
+// matching variables replaced by Mask.
+func (r *Redactor) Env(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	redacted := make([]string, 0, len(env))
+	for _, e := range env {
+		name := strings.SplitN(e, "=", 2)[0]
+		if r.matches(name) {
+			e = name + "=" + Mask
+		}
+		redacted = append(redacted, e)
+	}
+	return redacted
+}
+
+// Spec returns a copy of the specified OCI specification with the environment of the process
+// and of the hooks redacted.
+func (r *Redactor) Spec(spec *specs.Spec) (*specs.Spec, error) {
+	redacted := &specs.Spec{}
+	if err := deepCopy(spec, redacted); err != nil {
+		return nil, err
+	}
+	if redacted.Process != nil {
+		redacted.Process.Env = r.Env(redacted.Process.Env)
+	}
+	if redacted.Hooks != nil {
+		for _, hooks := range [][]specs.Hook{
+			redacted.Hooks.Prestart,
+			redacted.Hooks.CreateRuntime,
+			redacted.Hooks.CreateContainer,
+			redacted.Hooks.StartContainer,
+			redacted.Hooks.Poststart,
+			redacted.Hooks.Poststop,
+		} {
+			for i := range hooks {
+				hooks[i].Env = r.Env(hooks[i].Env)
+			}
+		}
+	}
+	return redacted, nil
+}
+
+// ContainerEdits returns a copy of the specified CDI container edits with the environment of the
+// edits and of the hooks redacted.
+func (r *Redactor) ContainerEdits(edits *cdispecs.ContainerEdits) (*cdispecs.ContainerEdits, error) {
+	redacted := &cdispecs.ContainerEdits{}
+	if err := deepCopy(edits, redacted); err != nil {
+		return nil, err
+	}
+	redacted.Env = r.Env(redacted.Env)
+	for _, hook := range redacted.Hooks {
+		hook.Env = r.Env(hook.Env)
+	}
+	return redacted, nil
+}
+
+// matches checks whether the specified name matches any of the patterns.
+func (r *Redactor) matches(name string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range r.patterns {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
+
+// deepCopy copies the specified value to the destination using its JSON representation.
+func deepCopy(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("failed to copy %T: %v", from, err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return fmt.Errorf("failed to copy %T: %v", from, err)
+	}
+	return nil
+}
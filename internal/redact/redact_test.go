@@ -0,0 +1,113 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package redact
+
+import (
+	"testing"
+
+	cdispecs "github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	testCases := []struct {
+		description string
+		patterns    []string
+		env         []string
+		expected    []string
+	}{
+		{
+			description: "nil env",
+			patterns:    DefaultPatterns,
+		},
+		{
+			description: "no patterns",
+			env:         []string{"API_TOKEN=abc"},
+			expected:    []string{"API_TOKEN=abc"},
+		},
+		{
+			description: "default patterns",
+			patterns:    DefaultPatterns,
+			env:         []string{"API_TOKEN=abc", "NVIDIA_VISIBLE_DEVICES=all", "license_key=123", "PATH=/usr/bin"},
+			expected:    []string{"API_TOKEN=" + Mask, "NVIDIA_VISIBLE_DEVICES=all", "license_key=" + Mask, "PATH=/usr/bin"},
+		},
+		{
+			description: "custom pattern",
+			patterns:    []string{"NGC_*"},
+			env:         []string{"NGC_API_KEY=abc", "API_TOKEN=abc", "NGC_EMPTY"},
+			expected:    []string{"NGC_API_KEY=" + Mask, "API_TOKEN=abc", "NGC_EMPTY=" + Mask},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r, err := New(tc.patterns)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, r.Env(tc.env))
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	_, err := New([]string{"[TOKEN"})
+	require.Error(t, err)
+}
+
+func TestSpec(t *testing.T) {
+	r, err := New(DefaultPatterns)
+	require.NoError(t, err)
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"API_TOKEN=abc", "PATH=/usr/bin"},
+		},
+		Hooks: &specs.Hooks{
+			Prestart: []specs.Hook{
+				{Path: "/usr/bin/hook", Env: []string{"DB_PASSWORD=abc"}},
+			},
+		},
+	}
+
+	redacted, err := r.Spec(spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_TOKEN=" + Mask, "PATH=/usr/bin"}, redacted.Process.Env)
+	require.Equal(t, []string{"DB_PASSWORD=" + Mask}, redacted.Hooks.Prestart[0].Env)
+	require.Equal(t, "/usr/bin/hook", redacted.Hooks.Prestart[0].Path)
+
+	// The input spec is not modified.
+	require.Equal(t, []string{"API_TOKEN=abc", "PATH=/usr/bin"}, spec.Process.Env)
+	require.Equal(t, []string{"DB_PASSWORD=abc"}, spec.Hooks.Prestart[0].Env)
+}
+
+func TestContainerEdits(t *testing.T) {
+	r, err := New(DefaultPatterns)
+	require.NoError(t, err)
+
+	edits := &cdispecs.ContainerEdits{
+		Env: []string{"LICENSE_KEY=abc", "NVIDIA_DRIVER_CAPABILITIES=all"},
+		Hooks: []*cdispecs.Hook{
+			{HookName: "createContainer", Path: "/usr/bin/nvidia-ctk", Env: []string{"SECRET=abc"}},
+		},
+	}
+
+	redacted, err := r.ContainerEdits(edits)
+	require.NoError(t, err)
+	require.Equal(t, []string{"LICENSE_KEY=" + Mask, "NVIDIA_DRIVER_CAPABILITIES=all"}, redacted.Env)
+	require.Equal(t, []string{"SECRET=" + Mask}, redacted.Hooks[0].Env)
+	require.Equal(t, []string{"SECRET=abc"}, edits.Hooks[0].Env)
+}
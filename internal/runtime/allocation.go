@@ -0,0 +1,49 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/state"
+	"github.com/sirupsen/logrus"
+)
+
+// getAllocationContainerID returns the ID of the container for which the allocation is recorded. If
+// the container ID cannot be determined from the command line arguments, an empty string is returned.
+func getAllocationContainerID(argv []string) string {
+	id := getContainerID(argv)
+	if id == unknownContainerID {
+		return ""
+	}
+	return id
+}
+
+// removeAllocation removes the allocation recorded for the container that is being deleted or that
+// failed to be created. Since the low-level runtime is invoked regardless, failures are only logged.
+func removeAllocation(logger *logrus.Logger, cfg *config.Config, argv []string) {
+	allocationsConfig := cfg.NVIDIAContainerRuntimeConfig.Allocations
+	if !allocationsConfig.Enabled {
+		return
+	}
+	id := getAllocationContainerID(argv)
+	if id == "" {
+		return
+	}
+	if err := state.NewStore(allocationsConfig.StateDir).Remove(id); err != nil {
+		logger.Warningf("Failed to remove allocation of container %v: %v", id, err)
+	}
+}
@@ -0,0 +1,53 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/state"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveAllocation(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	stateDir := t.TempDir()
+	store := state.NewStore(stateDir)
+	require.NoError(t, store.Save(state.Allocation{ContainerID: "abc"}))
+
+	cfg := &config.Config{}
+	cfg.NVIDIAContainerRuntimeConfig.Allocations.StateDir = stateDir
+
+	// The allocation is retained if recording allocations is not enabled.
+	removeAllocation(logger, cfg, []string{"runc", "delete", "abc"})
+	_, err := store.Lookup("abc")
+	require.NoError(t, err)
+
+	cfg.NVIDIAContainerRuntimeConfig.Allocations.Enabled = true
+	removeAllocation(logger, cfg, []string{"runc", "delete", "--force", "abc"})
+	_, err = store.Lookup("abc")
+	require.ErrorIs(t, err, state.ErrNotFound)
+
+	// The allocation of a container that failed to be created is removed.
+	require.NoError(t, store.Save(state.Allocation{ContainerID: "def"}))
+	removeAllocation(logger, cfg, []string{"runc", "create", "--bundle", "/bundle", "def"})
+	_, err = store.Lookup("def")
+	require.ErrorIs(t, err, state.ErrNotFound)
+}
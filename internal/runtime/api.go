@@ -1,8 +1,13 @@
 package runtime
 
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+)
+
 type rt struct {
 	logger       *Logger
 	modeOverride string
+	specSource   oci.SpecSource
 }
 
 // Interface is the interface for the runtime library.
@@ -31,3 +36,12 @@ func WithModeOverride(mode string) Option {
 		r.modeOverride = mode
 	}
 }
+
+// WithSpecSource allows for overriding the source of the OCI specification. This allows the
+// runtime to be embedded in shims that provide the OCI specification using a file descriptor
+// or an alternative path.
+func WithSpecSource(source oci.SpecSource) Option {
+	return func(r *rt) {
+		r.specSource = source
+	}
+}
@@ -0,0 +1,239 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/debugbundle"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/modifier"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+const unknownContainerID = "unknown"
+
+// debugBundleDiscoveryTimeout bounds the discovery repeated for a debug bundle if no
+// modification-timeout is configured.
+const debugBundleDiscoveryTimeout = 30 * time.Second
+
+var errModificationTimedOut = errors.New("not captured since the modification of the OCI specification timed out")
+
+// cdiRegistryState represents the state of the CDI registry captured in a debug bundle.
+type cdiRegistryState struct {
+	SpecDirs      []string            `json:"specDirs"`
+	SpecDirErrors map[string]string   `json:"specDirErrors,omitempty"`
+	SpecErrors    map[string][]string `json:"specErrors,omitempty"`
+	Devices       []string            `json:"devices"`
+}
+
+// captureDebugBundle captures the state relevant to a failed invocation of the runtime
+// in a debug bundle. This includes the input OCI specification, the resolved config,
+// the output of the discovery (as the modified OCI specification), and the state of the
+// CDI registry. The environment variables matching the debug.redact-env patterns are masked in
+// the captured OCI specifications and config.
+func captureDebugBundle(logger *logrus.Logger, cfg *config.Config, argv []string, specSource oci.SpecSource, runErr error) (string, error) {
+	b := debugbundle.New(logger, cfg.DebugConfig.BundleDir)
+
+	b.AddFile("error.txt", []byte(runErr.Error()+"\n"))
+	b.AddFile("argv.txt", []byte(strings.Join(argv, " ")+"\n"))
+	redactor := getRedactor(cfg)
+	b.AddJSON("config.json", redactConfig(cfg))
+
+	spec, err := loadInputSpec(logger, argv, specSource)
+	if err == nil {
+		spec, err = redactor.Spec(spec)
+	}
+	if err != nil {
+		b.AddError("spec.json", err)
+	} else {
+		b.AddJSON("spec.json", spec)
+	}
+
+	// The discovery is not repeated if the modification timed out since this is expected to
+	// block again.
+	if oci.GetErrorKind(runErr) == oci.ErrorKindTimeout {
+		b.AddError("modified-spec.json", errModificationTimedOut)
+		b.AddError("cdi-registry.json", errModificationTimedOut)
+		return b.Write(getContainerID(argv))
+	}
+
+	modified, err := discoverModifiedSpec(logger, cfg, argv, specSource)
+	if err == nil {
+		modified, err = redactor.Spec(modified)
+	}
+	if err != nil {
+		b.AddError("modified-spec.json", err)
+	} else {
+		b.AddJSON("modified-spec.json", modified)
+	}
+
+	b.AddJSON("cdi-registry.json", getCDIRegistryState(cfg))
+
+	return b.Write(getContainerID(argv))
+}
+
+// loadInputSpec returns the OCI specification for the command line arguments. If no spec source
+// is specified, the OCI specification file is read directly.
+func loadInputSpec(logger *logrus.Logger, argv []string, specSource oci.SpecSource) (*specs.Spec, error) {
+	if specSource == nil {
+		specFilePath, err := oci.GetSpecFilePathFromArgs(argv)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := os.ReadFile(specFilePath)
+		if err != nil {
+			return nil, err
+		}
+		spec := &specs.Spec{}
+		if err := json.Unmarshal(contents, spec); err != nil {
+			return nil, fmt.Errorf("failed to parse OCI specification: %v", err)
+		}
+		return spec, nil
+	}
+
+	ociSpec, err := specSource(logger, argv)
+	if err != nil {
+		return nil, err
+	}
+	return ociSpec.Load()
+}
+
+// discoverModifiedSpec applies the modifications required for the container to an in-memory copy
+// of the input OCI specification. Since the container is not created, the driver files are not
+// staged and the device nodes of CDI devices are not waited for. The discovery is bounded by the
+// modification-timeout or, if this is not configured, by debugBundleDiscoveryTimeout.
+func discoverModifiedSpec(logger *logrus.Logger, cfg *config.Config, argv []string, specSource oci.SpecSource) (*specs.Spec, error) {
+	if !oci.HasCreateSubcommand(argv) {
+		return nil, fmt.Errorf("no modification for non-create subcommand")
+	}
+
+	ctx, cancel, err := newModificationContext(time.Now(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		var cancelDefault context.CancelFunc
+		ctx, cancelDefault = context.WithTimeout(ctx, debugBundleDiscoveryTimeout)
+		defer cancelDefault()
+	}
+
+	discoveryConfig := *cfg
+	discoveryConfig.NVIDIAContainerRuntimeConfig.MountStrategy = config.MountStrategyIndividual
+	discoveryConfig.NVIDIAContainerRuntimeConfig.Modes.CDI.DeviceWait.Timeout = ""
+
+	ociSpec, err := newSpec(logger, argv, specSource)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing OCI specification: %v", err)
+	}
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading OCI specification: %v", err)
+	}
+	if err := modifySpec(ctx, logger, &discoveryConfig, rawSpec, argv); err != nil {
+		return nil, err
+	}
+	return rawSpec, nil
+}
+
+// modifySpec applies the modifications required for the container to the specified in-memory
+// OCI specification. Since no container is created from the specification, metrics reporting, the
+// recording of the startup latency, and the recording of device allocations are disabled. The
+// discovery and modification are bounded by the specified context.
+func modifySpec(ctx context.Context, logger *logrus.Logger, cfg *config.Config, rawSpec *specs.Spec, argv []string) error {
+	discoveryConfig := *cfg
+	discoveryConfig.NVIDIAContainerRuntimeConfig.RequestReport.Enabled = false
+	discoveryConfig.NVIDIAContainerRuntimeConfig.StartupLatency.Enabled = false
+	discoveryConfig.NVIDIAContainerRuntimeConfig.Allocations.Enabled = false
+
+	return oci.RunWithContext(ctx, func() error {
+		memorySpec, err := modifier.NewImageLabelsSpec(logger, &discoveryConfig, oci.NewMemorySpec(rawSpec))
+		if err != nil {
+			return err
+		}
+
+		specModifier, err := newSpecModifier(ctx, logger, &discoveryConfig, memorySpec, argv)
+		if err != nil {
+			return fmt.Errorf("failed to construct OCI spec modifier: %v", err)
+		}
+		if specModifier == nil {
+			return nil
+		}
+		if err := memorySpec.Modify(specModifier); err != nil {
+			return fmt.Errorf("error modifying OCI spec: %v", err)
+		}
+		return nil
+	})
+}
+
+// getCDIRegistryState returns the devices and errors of the CDI registry for the configured spec dirs.
+func getCDIRegistryState(cfg *config.Config) cdiRegistryState {
+	specDirs := cdi.DefaultSpecDirs
+	if len(cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs) > 0 {
+		specDirs = cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs
+	}
+
+	registry := cdi.GetRegistry(
+		cdi.WithSpecDirs(specDirs...),
+		cdi.WithAutoRefresh(false),
+	)
+	// Errors are captured per spec and spec dir below.
+	_ = registry.Refresh()
+
+	state := cdiRegistryState{
+		SpecDirs: registry.GetSpecDirectories(),
+		Devices:  registry.DeviceDB().ListDevices(),
+	}
+	for dir, err := range registry.GetSpecDirErrors() {
+		if state.SpecDirErrors == nil {
+			state.SpecDirErrors = make(map[string]string)
+		}
+		state.SpecDirErrors[dir] = err.Error()
+	}
+	for path, errs := range registry.GetErrors() {
+		if state.SpecErrors == nil {
+			state.SpecErrors = make(map[string][]string)
+		}
+		for _, err := range errs {
+			state.SpecErrors[path] = append(state.SpecErrors[path], err.Error())
+		}
+	}
+	return state
+}
+
+// getContainerID returns the container ID from the command line arguments of a runtime
+// invocation. The container ID is the last argument of OCI runtime commands.
+func getContainerID(argv []string) string {
+	if len(argv) < 2 {
+		return unknownContainerID
+	}
+	id := argv[len(argv)-1]
+	if strings.HasPrefix(id, "-") || strings.ContainsAny(id, "/\\") || id == "." || id == ".." {
+		return unknownContainerID
+	}
+	return id
+}
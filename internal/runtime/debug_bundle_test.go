@@ -0,0 +1,186 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/state"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetContainerID(t *testing.T) {
+	testCases := []struct {
+		argv     []string
+		expected string
+	}{
+		{
+			argv:     []string{"nvidia-container-runtime"},
+			expected: "unknown",
+		},
+		{
+			argv:     []string{"nvidia-container-runtime", "create", "--bundle", "/bundle", "ctr"},
+			expected: "ctr",
+		},
+		{
+			argv:     []string{"nvidia-container-runtime", "create", "--bundle", "/bundle"},
+			expected: "unknown",
+		},
+		{
+			argv:     []string{"nvidia-container-runtime", "--version"},
+			expected: "unknown",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d", i), func(t *testing.T) {
+			require.Equal(t, tc.expected, getContainerID(tc.argv))
+		})
+	}
+}
+
+func TestCaptureDebugBundle(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	bundleDir := t.TempDir()
+	spec := `{"ociVersion": "1.0.0", "process": {"env": ["NVIDIA_VISIBLE_DEVICES=void", "API_TOKEN=secret"]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.json"), []byte(spec), 0600))
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+		DebugConfig: config.DebugConfig{
+			CaptureBundle: true,
+			BundleDir:     t.TempDir(),
+			RedactEnv:     []string{"*TOKEN*"},
+		},
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Mode = "cdi"
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{t.TempDir()}
+
+	argv := []string{"nvidia-container-runtime", "create", "--bundle", bundleDir, "ctr"}
+	path, err := captureDebugBundle(logger, cfg, argv, nil, fmt.Errorf("failed"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(cfg.DebugConfig.BundleDir, "ctr"), filepath.Dir(path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		c, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(c)
+	}
+
+	require.Equal(t, "failed\n", contents["error.txt"])
+	require.Contains(t, contents["spec.json"], "NVIDIA_VISIBLE_DEVICES=void")
+	require.Contains(t, contents["spec.json"], "API_TOKEN=REDACTED")
+	require.NotContains(t, contents["spec.json"], "secret")
+	require.Contains(t, contents, "config.json")
+	require.Contains(t, contents, "modified-spec.json")
+	require.Contains(t, contents, "cdi-registry.json")
+}
+
+func TestCaptureDebugBundleDoesNotStageDriverFiles(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	driverRoot := t.TempDir()
+	libcuda := filepath.Join(driverRoot, "usr/lib/libcuda.so.1")
+	require.NoError(t, os.MkdirAll(filepath.Dir(libcuda), 0755))
+	require.NoError(t, os.WriteFile(libcuda, []byte("libcuda"), 0644))
+	mountSpecPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(mountSpecPath, "drivers.csv"), []byte("lib, /usr/lib/libcuda.so.1\n"), 0644))
+
+	bundleDir := t.TempDir()
+	spec := `{"ociVersion": "1.0.0", "process": {"env": ["NVIDIA_VISIBLE_DEVICES=all"]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.json"), []byte(spec), 0600))
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+		DebugConfig: config.DebugConfig{
+			CaptureBundle: true,
+			BundleDir:     t.TempDir(),
+		},
+	}
+	cfg.NVIDIAContainerCLIConfig.Root = driverRoot
+	cfg.NVIDIAContainerRuntimeConfig.Mode = "csv"
+	cfg.NVIDIAContainerRuntimeConfig.Modes.CSV.MountSpecPath = mountSpecPath
+	cfg.NVIDIAContainerRuntimeConfig.MountStrategy = config.MountStrategyDriverRoot
+	cfg.NVIDIAContainerRuntimeConfig.DriverRootMount.StagingDir = filepath.Join(t.TempDir(), "staging")
+
+	argv := []string{"nvidia-container-runtime", "create", "--bundle", bundleDir, "ctr"}
+	_, err := captureDebugBundle(logger, cfg, argv, nil, fmt.Errorf("failed"))
+	require.NoError(t, err)
+
+	require.NoDirExists(t, cfg.NVIDIAContainerRuntimeConfig.DriverRootMount.StagingDir)
+}
+
+func TestCaptureDebugBundleDoesNotRecordAllocation(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	bundleDir := t.TempDir()
+	spec := `{"ociVersion": "1.0.0", "process": {"env": ["NVIDIA_VISIBLE_DEVICES=GPU-1c3d5e7f"]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.json"), []byte(spec), 0600))
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+		DebugConfig: config.DebugConfig{
+			CaptureBundle: true,
+			BundleDir:     t.TempDir(),
+		},
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Mode = "legacy"
+	cfg.NVIDIAContainerRuntimeConfig.Allocations.Enabled = true
+	cfg.NVIDIAContainerRuntimeConfig.Allocations.StateDir = t.TempDir()
+
+	argv := []string{"nvidia-container-runtime", "create", "--bundle", bundleDir, "ctr"}
+	_, err := captureDebugBundle(logger, cfg, argv, nil, fmt.Errorf("failed"))
+	require.NoError(t, err)
+
+	_, err = state.NewStore(cfg.NVIDIAContainerRuntimeConfig.Allocations.StateDir).Lookup("ctr")
+	require.ErrorIs(t, err, state.ErrNotFound)
+
+	// The allocation is recorded if the same specification is modified for a container that is created.
+	rawSpec := &specs.Spec{Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=GPU-1c3d5e7f"}}}
+	ociSpec := oci.NewMemorySpec(rawSpec)
+	specModifier, err := newSpecModifier(context.Background(), logger, cfg, ociSpec, argv)
+	require.NoError(t, err)
+	require.NoError(t, ociSpec.Modify(specModifier))
+
+	_, err = state.NewStore(cfg.NVIDIAContainerRuntimeConfig.Allocations.StateDir).Lookup("ctr")
+	require.NoError(t, err)
+}
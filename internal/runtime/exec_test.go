@@ -0,0 +1,189 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/fakeruntime"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+const (
+	execTestCDISpec = `{
+  "cdiVersion": "0.5.0",
+  "kind": "example.com/device",
+  "devices": [
+    {
+      "name": "dev0",
+      "containerEdits": {
+        "env": ["EXAMPLE_DEVICE=dev0"],
+        "mounts": [{"hostPath": "/dev/null", "containerPath": "/dev/example0"}]
+      }
+    }
+  ]
+}`
+
+	execTestSpec = `{
+  "ociVersion": "1.0.2",
+  "process": {
+    "args": ["sh"],
+    "env": ["PATH=/usr/bin:/bin", "NVIDIA_VISIBLE_DEVICES=example.com/device=dev0"],
+    "cwd": "/"
+  },
+  "root": {"path": "rootfs"},
+  "linux": {}
+}`
+)
+
+// TestExecGolden runs the lifecycle of a container through the NVIDIA Container Runtime and
+// compares the invocations of the low-level runtime against the golden files in testdata/exec.
+// Run the test with -update to regenerate the golden files after an intended change in behavior.
+func TestExecGolden(t *testing.T) {
+	type step struct {
+		args          []string
+		expectedError bool
+	}
+
+	testCases := []struct {
+		description string
+		specFile    string
+		env         map[string]string
+		options     []fakeruntime.Option
+		steps       []step
+	}{
+		{
+			description: "runc",
+			steps: []step{
+				{args: []string{"runc", "--root", "/run/containerd/runc/k8s.io", "--log", "{{bundle}}/log.json", "--log-format", "json", "create", "--bundle", "{{bundle}}", "--pid-file", "{{bundle}}/init.pid", "c1"}},
+				{args: []string{"runc", "--root", "/run/containerd/runc/k8s.io", "--log", "{{bundle}}/log.json", "--log-format", "json", "start", "c1"}},
+				{args: []string{"runc", "--root", "/run/containerd/runc/k8s.io", "--log", "{{bundle}}/log.json", "--log-format", "json", "delete", "--force", "c1"}},
+			},
+		},
+		{
+			description: "runc-short-bundle-flag",
+			steps: []step{
+				{args: []string{"runc", "create", "-b", "{{bundle}}", "c1"}},
+				{args: []string{"runc", "start", "c1"}},
+				{args: []string{"runc", "delete", "c1"}},
+			},
+		},
+		{
+			description: "runc-bundle-equals",
+			steps: []step{
+				{args: []string{"runc", "create", "--bundle={{bundle}}", "--console-socket", "/tmp/console.sock", "c1"}},
+				{args: []string{"runc", "start", "c1"}},
+				{args: []string{"runc", "delete", "c1"}},
+			},
+		},
+		{
+			description: "runc-rootless",
+			env: map[string]string{
+				"XDG_RUNTIME_DIR": "/run/user/1000",
+			},
+			steps: []step{
+				{args: []string{"runc", "--rootless", "true", "--root", "/run/user/1000/runc", "create", "--bundle", "{{bundle}}", "c1"}},
+				{args: []string{"runc", "--rootless", "true", "--root", "/run/user/1000/runc", "start", "c1"}},
+				{args: []string{"runc", "--rootless", "true", "--root", "/run/user/1000/runc", "delete", "c1"}},
+			},
+		},
+		{
+			description: "crun-config-flag",
+			specFile:    "alt.json",
+			steps: []step{
+				{args: []string{"crun", "--systemd-cgroup", "create", "--bundle", "{{bundle}}", "--config", "alt.json", "c1"}},
+				{args: []string{"crun", "--systemd-cgroup", "start", "c1"}},
+				{args: []string{"crun", "--systemd-cgroup", "delete", "--force", "c1"}},
+			},
+		},
+		{
+			description: "runtime-error-is-returned",
+			options: []fakeruntime.Option{
+				fakeruntime.WithResponse("delete", errors.New("container c1 does not exist")),
+			},
+			steps: []step{
+				{args: []string{"runc", "create", "--bundle", "{{bundle}}", "c1"}},
+				{args: []string{"runc", "delete", "c1"}, expectedError: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			logger, _ := testlog.NewNullLogger()
+			// The recorded environment must not depend on the environment of the test process.
+			t.Setenv("XDG_RUNTIME_DIR", "")
+			require.NoError(t, os.Unsetenv("XDG_RUNTIME_DIR"))
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			cdiDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(cdiDir, "example.json"), []byte(execTestCDISpec), 0644))
+
+			bundleDir := t.TempDir()
+			specFile := tc.specFile
+			if specFile == "" {
+				specFile = "config.json"
+			}
+			require.NoError(t, os.WriteFile(filepath.Join(bundleDir, specFile), []byte(execTestSpec), 0644))
+
+			cfg := &config.Config{
+				AcceptEnvvarUnprivileged: true,
+				NVIDIAContainerRuntimeConfig: config.RuntimeConfig{
+					Mode: "cdi",
+				},
+			}
+			cfg.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{cdiDir}
+
+			lowLevelRuntime := fakeruntime.New(append(tc.options, fakeruntime.WithEnv("XDG_RUNTIME_DIR"))...)
+			for _, s := range tc.steps {
+				var argv []string
+				for _, arg := range s.args {
+					argv = append(argv, strings.ReplaceAll(arg, "{{bundle}}", bundleDir))
+				}
+
+				r, err := newModifyingRuntime(context.Background(), logger, cfg, argv, nil, lowLevelRuntime)
+				require.NoError(t, err)
+
+				err = r.Exec(argv)
+				if s.expectedError {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+
+			fakeruntime.RequireGolden(t,
+				filepath.Join("testdata", "exec", tc.description+".json"),
+				*updateGolden,
+				lowLevelRuntime.Invocations(),
+				map[string]string{bundleDir: "{{bundle}}"},
+			)
+		})
+	}
+}
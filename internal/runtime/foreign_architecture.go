@@ -0,0 +1,101 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"path/filepath"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/platform"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+)
+
+// resolveForeignArchitecture returns the config used to construct the modifiers for the container.
+// If the architecture of the container image differs from that of the host (e.g. because the image is
+// run using binfmt_misc and qemu-user emulation), the driver stack configured for the architecture is
+// used. If no driver stack is configured for the architecture, false is returned and the driver must
+// not be injected since the libraries of the host cannot be loaded in the container.
+func resolveForeignArchitecture(logger *logrus.Logger, cfg *config.Config, mode string, ociSpec oci.Spec, argv []string) (*config.Config, bool, error) {
+	// In cdi-annotations mode, the devices are injected by a downstream CDI-aware component.
+	if mode == "cdi-annotations" {
+		return cfg, true, nil
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, false, err
+	}
+	if rawSpec == nil || rawSpec.Root == nil {
+		return cfg, true, nil
+	}
+
+	rootfs := rawSpec.Root.Path
+	if !filepath.IsAbs(rootfs) {
+		bundleDir, _ := oci.GetBundleDir(argv)
+		rootfs = filepath.Join(bundleDir, rootfs)
+	}
+	arch, err := platform.GetArchitecture(rootfs)
+	if err != nil {
+		logger.Debugf("Assuming that the container architecture matches the host: %v", err)
+		return cfg, true, nil
+	}
+
+	resolved, inject := configForArchitecture(logger, cfg, mode, arch)
+	return resolved, inject, nil
+}
+
+// configForArchitecture returns the config with the driver roots and CDI spec dirs configured for
+// the specified container architecture. If the selected mode cannot inject a driver stack for the
+// architecture, false is returned.
+func configForArchitecture(logger *logrus.Logger, cfg *config.Config, mode string, arch string) (*config.Config, bool) {
+	host := platform.HostArchitecture()
+	if arch == host {
+		return cfg, true
+	}
+
+	foreign := cfg.NVIDIAContainerRuntimeConfig.ForeignArchitecture
+	driverRoot, hasDriverRoot := foreign.DriverRoots[arch]
+	cdiSpecDir, hasCDISpecDir := foreign.CDISpecDirs[arch]
+
+	var supported bool
+	switch mode {
+	case "csv":
+		supported = hasDriverRoot
+	case "cdi":
+		supported = hasCDISpecDir
+	}
+	if !supported {
+		logger.WithField(events.Field, events.ForeignArchitecture).Warningf("Skipping injection of the NVIDIA driver: the container architecture %v differs from the host architecture %v (e.g. due to emulation) and no driver for %v is configured for %v mode", arch, host, arch, mode)
+		return nil, false
+	}
+
+	resolved := *cfg
+	if hasDriverRoot {
+		logger.Infof("Using driver root %v for container architecture %v", driverRoot, arch)
+		resolved.NVIDIAContainerCLIConfig.Root = driverRoot
+		resolved.NVIDIAContainerRuntimeConfig.DriverRoots = nil
+		resolved.NVIDIAContainerRuntimeConfig.StagedDriverRoot = ""
+	}
+	if hasCDISpecDir {
+		logger.Infof("Using CDI spec dir %v for container architecture %v", cdiSpecDir, arch)
+		resolved.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs = []string{cdiSpecDir}
+		resolved.NVIDIAContainerRuntimeConfig.Modes.CDI.IndexFile = ""
+	}
+	return &resolved, true
+}
@@ -0,0 +1,112 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/platform"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigForArchitecture(t *testing.T) {
+	logger, hook := testlog.NewNullLogger()
+
+	// The foreign architecture must differ from the architecture of the test host.
+	foreignArch := "arm64"
+	if platform.HostArchitecture() == foreignArch {
+		foreignArch = "amd64"
+	}
+
+	foreignArchitecture := config.RuntimeConfig{}
+	foreignArchitecture.ForeignArchitecture.DriverRoots = map[string]string{foreignArch: "/opt/nvidia/foreign"}
+	foreignArchitecture.ForeignArchitecture.CDISpecDirs = map[string]string{foreignArch: "/etc/cdi/foreign"}
+
+	testCases := []struct {
+		description    string
+		runtimeConfig  config.RuntimeConfig
+		mode           string
+		arch           string
+		expectedInject bool
+		expectedRoot   string
+		expectedDirs   []string
+	}{
+		{
+			description:    "host architecture uses config",
+			runtimeConfig:  foreignArchitecture,
+			mode:           "csv",
+			arch:           platform.HostArchitecture(),
+			expectedInject: true,
+			expectedRoot:   "/",
+		},
+		{
+			description: "foreign architecture without driver is skipped",
+			mode:        "cdi",
+			arch:        foreignArch,
+		},
+		{
+			description:   "foreign architecture in legacy mode is skipped",
+			runtimeConfig: foreignArchitecture,
+			mode:          "legacy",
+			arch:          foreignArch,
+		},
+		{
+			description:    "foreign architecture uses driver root in csv mode",
+			runtimeConfig:  foreignArchitecture,
+			mode:           "csv",
+			arch:           foreignArch,
+			expectedInject: true,
+			expectedRoot:   "/opt/nvidia/foreign",
+			expectedDirs:   []string{"/etc/cdi/foreign"},
+		},
+		{
+			description:    "foreign architecture uses cdi spec dir in cdi mode",
+			runtimeConfig:  foreignArchitecture,
+			mode:           "cdi",
+			arch:           foreignArch,
+			expectedInject: true,
+			expectedRoot:   "/opt/nvidia/foreign",
+			expectedDirs:   []string{"/etc/cdi/foreign"},
+		},
+	}
+
+	for _, tc := range testCases {
+		hook.Reset()
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{
+				NVIDIAContainerCLIConfig: config.ContainerCLIConfig{
+					Root: "/",
+				},
+				NVIDIAContainerRuntimeConfig: tc.runtimeConfig,
+			}
+
+			resolved, inject := configForArchitecture(logger, cfg, tc.mode, tc.arch)
+			require.Equal(t, tc.expectedInject, inject)
+			if !tc.expectedInject {
+				require.Equal(t, events.ForeignArchitecture, hook.LastEntry().Data[events.Field])
+				return
+			}
+			require.Equal(t, tc.expectedRoot, resolved.NVIDIAContainerCLIConfig.Root)
+			require.Equal(t, tc.expectedDirs, resolved.NVIDIAContainerRuntimeConfig.Modes.CDI.SpecDirs)
+			// The input config is not modified.
+			require.Equal(t, "/", cfg.NVIDIAContainerCLIConfig.Root)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// ComputePatch returns the RFC6902 JSON Patch of the modifications (e.g. devices, mounts, hooks,
+// and environment variables) that the NVIDIA Container Runtime would apply to the specified OCI
+// specification for the specified config. The input specification is not modified.
+func ComputePatch(logger *logrus.Logger, cfg *config.Config, spec *specs.Spec) ([]oci.PatchOperation, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OCI specification: %v", err)
+	}
+	var modified specs.Spec
+	if err := json.Unmarshal(data, &modified); err != nil {
+		return nil, fmt.Errorf("failed to copy OCI specification: %v", err)
+	}
+
+	if err := modifySpec(context.Background(), logger, cfg, &modified, nil); err != nil {
+		return nil, err
+	}
+
+	return oci.CreatePatch(spec, &modified)
+}
@@ -0,0 +1,68 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePatch(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: *config.GetDefaultRuntimeConfig(),
+	}
+	cfg.NVIDIAContainerRuntimeConfig.Mode = "legacy"
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+		},
+	}
+
+	moduleRoot, err := test.GetModuleRoot()
+	require.NoError(t, err)
+	hookPath := filepath.Join(moduleRoot, "test", "bin", "nvidia-container-runtime-hook")
+
+	patch, err := ComputePatch(logger, cfg, spec)
+	require.NoError(t, err)
+	require.Equal(t, []oci.PatchOperation{
+		{
+			Op:   "add",
+			Path: "/hooks",
+			Value: map[string]interface{}{
+				"prestart": []interface{}{
+					map[string]interface{}{
+						"path": hookPath,
+						"args": []interface{}{hookPath, "prestart"},
+					},
+				},
+			},
+		},
+	}, patch)
+
+	// The input specification is not modified.
+	require.Nil(t, spec.Hooks)
+}
@@ -17,22 +17,44 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/deprecation"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/redact"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// configLoadTimeout bounds the loading of the config, which cannot be bounded by the
+// modification-timeout since this is read from the config.
+const configLoadTimeout = 30 * time.Second
+
 // Run is an entry point that allows for idiomatic handling of errors
 // when calling from the main function.
 func (r rt) Run(argv []string) (rerr error) {
+	// The modification timeout includes the time taken to load the config.
+	start := time.Now()
+
+	var errorFormat string
 	defer func() {
-		if rerr != nil {
-			r.logger.Errorf("%v", rerr)
+		if rerr == nil {
+			return
 		}
+		if errorFormat == config.ErrorFormatJSON {
+			writeJSONError(os.Stderr, rerr)
+			return
+		}
+		r.logger.Errorf("%v", rerr)
 	}()
 
 	printVersion := hasVersionFlag(argv)
@@ -40,13 +62,21 @@ func (r rt) Run(argv []string) (rerr error) {
 		fmt.Printf("%v version %v\n", "NVIDIA Container Runtime", info.GetVersionString(fmt.Sprintf("spec: %v", specs.Version)))
 	}
 
-	cfg, err := config.GetConfig()
+	cfg, err := loadConfig(start)
 	if err != nil {
-		return fmt.Errorf("error loading config: %v", err)
+		return err
 	}
+	recorder := newLatencyRecorder(start, cfg, argv)
+	recorder.Record(latency.PhaseConfigLoad, time.Since(start))
 	if r.modeOverride != "" {
 		cfg.NVIDIAContainerRuntimeConfig.Mode = r.modeOverride
 	}
+	errorFormat = cfg.NVIDIAContainerRuntimeConfig.ErrorFormat
+
+	specSource, err := r.getSpecSource()
+	if err != nil {
+		return oci.NewError(oci.ErrorKindConfig, err)
+	}
 
 	err = r.logger.Update(
 		cfg.NVIDIAContainerRuntimeConfig.DebugFilePath,
@@ -54,33 +84,128 @@ func (r rt) Run(argv []string) (rerr error) {
 		argv,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to set up logger: %v", err)
+		return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("failed to set up logger: %v", err))
 	}
 	defer func() {
-		if rerr != nil {
+		if rerr != nil && oci.GetErrorKind(rerr) == oci.ErrorKindTimeout {
+			r.logger.WithField(events.Field, events.ModificationTimeout).Errorf("%v", rerr)
+		} else if rerr != nil {
 			r.logger.Errorf("%v", rerr)
 		}
+		// Since no delete follows a failed create, the allocation recorded for the container is
+		// removed here.
+		if rerr != nil && oci.HasCreateSubcommand(argv) {
+			removeAllocation(r.logger.Logger, cfg, argv)
+		}
+		if rerr != nil && cfg.DebugConfig.CaptureBundle {
+			if _, err := captureDebugBundle(r.logger.Logger, cfg, argv, specSource, rerr); err != nil {
+				r.logger.WithField(events.Field, events.DebugBundleCaptureFailed).Warningf("Failed to capture debug bundle: %v", err)
+			}
+		}
 		r.logger.Reset()
 	}()
 
-	// Print the config to the output.
-	configJSON, err := json.MarshalIndent(cfg, "", "  ")
+	tracker := deprecation.NewTracker(deprecation.DefaultStateFile)
+	for _, id := range cfg.Deprecations() {
+		tracker.Warn(r.logger.Logger, id)
+	}
+
+	// Print the config to the output. The environment variables configured for devices are redacted.
+	loggedConfig := redactConfig(cfg)
+	configJSON, err := json.MarshalIndent(loggedConfig, "", "  ")
 	if err == nil {
 		r.logger.Infof("Running with config:\n%v", string(configJSON))
 	} else {
-		r.logger.Infof("Running with config:\n%+v", cfg)
+		r.logger.Infof("Running with config:\n%+v", loggedConfig)
 	}
 
+	ctx, cancel, err := newModificationContext(start, cfg)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	ctx = latency.NewContext(ctx, recorder)
+
 	r.logger.Debugf("Command line arguments: %v", argv)
-	runtime, err := newNVIDIAContainerRuntime(r.logger.Logger, cfg, argv)
+	runtime, err := newNVIDIAContainerRuntime(ctx, r.logger.Logger, cfg, argv, specSource)
 	if err != nil {
-		return fmt.Errorf("failed to create NVIDIA Container Runtime: %v", err)
+		return oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("failed to create NVIDIA Container Runtime: %w", err))
 	}
 
 	if printVersion {
 		fmt.Print("\n")
 	}
-	return runtime.Exec(argv)
+	return oci.NewError(oci.ErrorKindLowLevelRuntime, runtime.Exec(argv))
+}
+
+// loadConfig loads the config. Since the modification-timeout is read from the config, loading the
+// config is bounded by configLoadTimeout instead. The time taken to load the config still counts
+// towards the modification-timeout.
+func loadConfig(start time.Time) (*config.Config, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(configLoadTimeout))
+	defer cancel()
+
+	var cfg *config.Config
+	err := oci.RunWithContext(ctx, func() error {
+		loaded, err := config.GetConfig()
+		if err != nil {
+			return oci.NewError(oci.ErrorKindConfig, fmt.Errorf("error loading config: %v", err))
+		}
+		cfg = loaded
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newModificationContext returns the context that bounds the discovery and application of the
+// modifications to the OCI specification. The deadline is relative to the specified start time.
+// If no modification-timeout is configured, the context has no deadline.
+func newModificationContext(start time.Time, cfg *config.Config) (context.Context, context.CancelFunc, error) {
+	timeout := cfg.NVIDIAContainerRuntimeConfig.ModificationTimeout
+	if timeout == "" {
+		return context.Background(), func() {}, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid modification-timeout %q: %v", timeout, err))
+	}
+	if d <= 0 {
+		return nil, nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid modification-timeout %q: must be positive", timeout))
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(d))
+	return ctx, cancel, nil
+}
+
+// newLatencyRecorder returns the recorder for the startup latency of the container. If the
+// recording of the startup latency is not enabled, nil is returned.
+func newLatencyRecorder(start time.Time, cfg *config.Config, argv []string) *latency.Recorder {
+	latencyConfig := cfg.NVIDIAContainerRuntimeConfig.StartupLatency
+	if !latencyConfig.Enabled || !oci.HasCreateSubcommand(argv) {
+		return nil
+	}
+	stateFile := latencyConfig.StateFile
+	if stateFile == "" {
+		stateFile = latency.DefaultStateFile
+	}
+	return latency.NewRecorder(start, getContainerID(argv), latency.NewStore(stateFile))
+}
+
+// ExitCode returns the exit code of the runtime for the specified error.
+func ExitCode(err error) int {
+	return oci.ExitCode(err)
+}
+
+// writeJSONError writes the machine-readable representation of the specified error to the writer.
+func writeJSONError(w io.Writer, err error) {
+	e := &oci.Error{Kind: oci.GetErrorKind(err), Err: err}
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		fmt.Fprintf(w, "%v\n", e)
+	}
 }
 
 func (r rt) Errorf(format string, args ...interface{}) {
@@ -107,3 +232,31 @@ func hasVersionFlag(args []string) bool {
 
 	return false
 }
+
+// redactConfig returns a copy of the specified config in which the values of the environment
+// variables configured for devices that match the debug.redact-env patterns are masked. If the
+// patterns are invalid, all values are masked.
+func redactConfig(cfg *config.Config) *config.Config {
+	if len(cfg.Devices) == 0 {
+		return cfg
+	}
+	redactor := getRedactor(cfg)
+
+	redacted := *cfg
+	redacted.Devices = make(map[string]config.DeviceConfig)
+	for uuid, device := range cfg.Devices {
+		device.Env = redactor.Env(device.Env)
+		redacted.Devices[uuid] = device
+	}
+	return &redacted
+}
+
+// getRedactor returns a redactor for the debug.redact-env patterns in the config. If the patterns
+// are invalid, a redactor that masks all values is returned.
+func getRedactor(cfg *config.Config) *redact.Redactor {
+	redactor, err := redact.New(cfg.DebugConfig.RedactEnv)
+	if err != nil {
+		redactor, _ = redact.New([]string{"*"})
+	}
+	return redactor
+}
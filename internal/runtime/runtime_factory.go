@@ -17,20 +17,41 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/modifier"
-	"github.com/NVIDIA/nvidia-container-toolkit/internal/oci"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
 	"github.com/sirupsen/logrus"
 )
 
-// newNVIDIAContainerRuntime is a factory method that constructs a runtime based on the selected configuration and specified logger
-func newNVIDIAContainerRuntime(logger *logrus.Logger, cfg *config.Config, argv []string) (oci.Runtime, error) {
+// newNVIDIAContainerRuntime is a factory method that constructs a runtime based on the selected configuration and specified logger.
+// The discovery of the required modifications and the modification of the OCI specification are bounded by the specified context.
+func newNVIDIAContainerRuntime(ctx context.Context, logger *logrus.Logger, cfg *config.Config, argv []string, specSource oci.SpecSource) (oci.Runtime, error) {
+	recorder := latency.FromContext(ctx)
+
+	located := recorder.Track(latency.PhaseExec)
 	lowLevelRuntime, err := oci.NewLowLevelRuntime(logger, cfg.NVIDIAContainerRuntimeConfig.Runtimes)
+	located()
 	if err != nil {
-		return nil, fmt.Errorf("error constructing low-level runtime: %v", err)
+		return nil, oci.NewError(oci.ErrorKindLowLevelRuntime, fmt.Errorf("error constructing low-level runtime: %v", err))
+	}
+
+	return newModifyingRuntime(ctx, logger, cfg, argv, specSource, lowLevelRuntime)
+}
+
+// newModifyingRuntime constructs a runtime that applies the modifications required by the selected
+// configuration to the OCI specification before invoking the specified low-level runtime.
+func newModifyingRuntime(ctx context.Context, logger *logrus.Logger, cfg *config.Config, argv []string, specSource oci.SpecSource, lowLevelRuntime oci.Runtime) (oci.Runtime, error) {
+	recorder := latency.FromContext(ctx)
+
+	if oci.HasDeleteSubcommand(argv) {
+		removeAllocation(logger, cfg, argv)
 	}
 
 	if !oci.HasCreateSubcommand(argv) {
@@ -38,18 +59,52 @@ func newNVIDIAContainerRuntime(logger *logrus.Logger, cfg *config.Config, argv [
 		return lowLevelRuntime, nil
 	}
 
-	ociSpec, err := oci.NewSpec(logger, argv)
+	ociSpec, err := newSpec(logger, argv, specSource)
 	if err != nil {
 		return nil, fmt.Errorf("error constructing OCI specification: %v", err)
 	}
 
-	specModifier, err := newSpecModifier(logger, cfg, ociSpec, argv)
+	// Non-Linux containers are passed to the low-level runtime before any modifier is constructed, since
+	// the discovery (e.g. the policy checks, NVML queries, or CDI validation) could otherwise fail the
+	// creation of a container that is not modified.
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return nil, oci.NewError(oci.ErrorKindDiscovery, fmt.Errorf("error loading OCI specification: %v", err))
+	}
+	if platform := oci.GetNonLinuxPlatform(rawSpec); platform != "" {
+		logger.WithField(events.Field, events.ModificationSkipped).Warningf("Skipping modification of OCI specification: the container targets a %v platform and only Linux containers are supported", platform)
+		return lowLevelRuntime, nil
+	}
+
+	// The discovery (including NVML calls and the refresh of the CDI registry) may block, for
+	// example if the driver is hung or the driver root is unresponsive.
+	// The results are only assigned if the discovery completes before the context is done.
+	var specModifier oci.SpecModifier
+	discovered := recorder.Track(latency.PhaseDiscovery)
+	err = oci.RunWithContext(ctx, func() error {
+		labelledSpec, err := modifier.NewImageLabelsSpec(logger, cfg, ociSpec)
+		if err != nil {
+			return err
+		}
+
+		m, err := newSpecModifier(ctx, logger, cfg, labelledSpec, argv)
+		if err != nil {
+			return fmt.Errorf("failed to construct OCI spec modifier: %w", err)
+		}
+		if err := oci.CheckContext(ctx); err != nil {
+			return err
+		}
+		ociSpec, specModifier = labelledSpec, m
+		return nil
+	})
+	discovered()
 	if err != nil {
-		return nil, fmt.Errorf("failed to construct OCI spec modifier: %v", err)
+		return nil, err
 	}
 
 	// Create the wrapping runtime with the specified modifier
 	r := oci.NewModifyingRuntimeWrapper(
+		ctx,
 		logger,
 		lowLevelRuntime,
 		ociSpec,
@@ -60,16 +115,61 @@ func newNVIDIAContainerRuntime(logger *logrus.Logger, cfg *config.Config, argv [
 }
 
 // newSpecModifier is a factory method that creates constructs an OCI spec modifer based on the provided config.
-func newSpecModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec, argv []string) (oci.SpecModifier, error) {
-	modeModifier, err := newModeModifier(logger, cfg, ociSpec, argv)
+// The discovery is bounded by the specified context and the startup latency is recorded using the
+// recorder of the context, if any.
+func newSpecModifier(ctx context.Context, logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec, argv []string) (oci.SpecModifier, error) {
+	requestReporter, err := modifier.NewRequestReporter(logger, cfg, ociSpec)
 	if err != nil {
 		return nil, err
 	}
 
-	graphicsModifier, err := modifier.NewGraphicsModifier(logger, cfg, ociSpec)
+	mode := info.ResolveAutoMode(logger, cfg.NVIDIAContainerRuntimeConfig.Mode)
+	mode, err = modifier.ResolveLegacyTranslation(logger, cfg, mode, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := modifier.ValidateMountStrategy(mode, cfg); err != nil {
+		return nil, oci.NewError(oci.ErrorKindConfig, err)
+	}
+	cfg = resolveStagedDriverRoot(logger, mode, cfg)
+	cfg, inject, err := resolveForeignArchitecture(logger, cfg, mode, ociSpec, argv)
 	if err != nil {
 		return nil, err
 	}
+	if !inject {
+		return requestReporter, nil
+	}
+	if err := modifier.CheckPolicy(logger, cfg, ociSpec); err != nil {
+		return nil, err
+	}
+
+	var graphicsDiscoverer discover.Discover
+	if mode != "cdi-annotations" {
+		graphicsDiscoverer, err = modifier.NewGraphicsDiscoverer(logger, cfg, ociSpec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	modeModifier, err := newModeModifier(ctx, logger, mode, cfg, ociSpec, graphicsDiscoverer)
+	if err != nil {
+		return nil, err
+	}
+	// In cdi-annotations mode, the injection of all devices (including GDS and MOFED devices)
+	// is performed by a downstream CDI-aware component and no other modifiers are applied.
+	if mode == "cdi-annotations" {
+		return modifier.Merge(requestReporter, modeModifier), nil
+	}
+
+	// In csv mode, the graphics files are injected by the mode modifier so that a single driver root is
+	// staged and mounted for the graphics files and the files from the CSV mount specs.
+	var graphicsModifier oci.SpecModifier
+	if mode != "csv" {
+		graphicsModifier, err = modifier.NewGraphicsModifier(logger, cfg, graphicsDiscoverer)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	gdsModifier, err := modifier.NewGDSModifier(logger, cfg, ociSpec)
 	if err != nil {
@@ -81,30 +181,150 @@ func newSpecModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec
 		return nil, err
 	}
 
+	imexModifier, err := modifier.NewIMEXModifier(logger, cfg, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	workloadTuning, err := modifier.NewWorkloadTuningModifier(logger, cfg, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	gpuSharing, err := modifier.NewGPUSharingModifier(logger, cfg, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
 	tegraModifier, err := modifier.NewTegraPlatformFiles(logger)
 	if err != nil {
 		return nil, err
 	}
 
-	modifiers := modifier.Merge(
+	checkBinary, err := modifier.NewCheckBinaryModifier(logger, cfg, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The device extras are applied after the mode modifier so that the device nodes of
+	// the injected devices are included in the spec.
+	deviceExtras, err := modifier.NewDeviceExtrasModifier(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The driver binaries filter and checksum verifier are applied after the other
+	// modifiers so that the mounts added by any of these are considered.
+	driverBinariesFilter, err := modifier.NewDriverBinariesFilter(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if driverBinariesFilter != nil && mode == "legacy" {
+		logger.Warnf("The driver-binaries config does not apply to binaries injected by the NVIDIA Container Runtime Hook in legacy mode")
+	}
+
+	checksumVerifier, err := modifier.NewChecksumVerifier(logger, cfg, ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The disabled hooks are removed after the library prefix is applied so that the hooks that are
+	// updated or added for the moved libraries are also considered.
+	disabledHooks, err := modifier.NewDisabledHooksModifier(logger, cfg, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nvidia-ctk hooks modifier is applied last so that the privileges, timeout, and
+	// failure policy are applied to the hooks added by any of the other modifiers.
+	nvidiaCTKHooks, err := modifier.NewNVIDIACTKHooksModifier(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The library prefix is applied before the nvidia-ctk hooks modifier so that the hooks that
+	// are updated or added for the moved libraries are also considered.
+	injectionModifiers, err := modifier.NewLibraryPrefixModifier(logger, cfg, modifier.Merge(
 		modeModifier,
 		graphicsModifier,
 		gdsModifier,
 		mofedModifier,
+		imexModifier,
+		workloadTuning,
+		gpuSharing,
 		tegraModifier,
+		checkBinary,
+		deviceExtras,
+		driverBinariesFilter,
+		checksumVerifier,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.NVIDIAContainerRuntimeConfig.LibraryPrefix != "" && mode == "legacy" {
+		logger.Warnf("The library-prefix config does not apply to libraries injected by the NVIDIA Container Runtime Hook in legacy mode")
+	}
+	injectionModifiers = modifier.Merge(injectionModifiers, disabledHooks, nvidiaCTKHooks)
+	injectionModifiers = modifier.NewIDMappedMountsModifier(logger, cfg, injectionModifiers)
+	injectionModifiers = modifier.NewReadOnlyInjectionModifier(logger, cfg, injectionModifiers)
+	// The env policy wraps all injection modifiers so that the environment variables set by any of
+	// these are merged with those set in the container.
+	injectionModifiers, err = modifier.NewEnvPolicyModifier(logger, cfg, injectionModifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	// The hook ordering is applied after all other modifiers so that the hooks that are already
+	// present in the spec and those injected by the other modifiers are considered.
+	hookOrdering, err := modifier.NewHookOrderingModifier(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The allocation is recorded after all injection modifiers are applied so that all injected
+	// device nodes are considered.
+	allocationRecorder := modifier.NewAllocationRecorder(logger, cfg, getAllocationContainerID(argv))
+
+	modifiers := modifier.Merge(
+		requestReporter,
+		injectionModifiers,
+		hookOrdering,
+		allocationRecorder,
 	)
-	return modifiers, nil
+	// The changes made by all modifications to the spec are logged.
+	return modifier.NewSpecLoggerModifier(logger, cfg, getContainerID(argv), modifiers)
+}
+
+// resolveStagedDriverRoot returns the config to use for the specified mode with respect to the staged driver root.
+// A staged driver root is only used in csv mode since the files injected in the other modes are not discovered
+// by the NVIDIA Container Runtime. Using the staged root for the graphics files only would mix driver files
+// from different locations.
+func resolveStagedDriverRoot(logger *logrus.Logger, mode string, cfg *config.Config) *config.Config {
+	root := cfg.NVIDIAContainerRuntimeConfig.StagedDriverRoot
+	if mode == "csv" || root == "" {
+		return cfg
+	}
+	if _, err := discover.LoadStagedManifest(root); err == nil {
+		logger.WithField(events.Field, events.StagedDriverRootIgnored).Warnf("Ignoring staged driver root %v: staged driver roots are not supported in %v mode", root, mode)
+	}
+	resolved := *cfg
+	resolved.NVIDIAContainerRuntimeConfig.StagedDriverRoot = ""
+	return &resolved
 }
 
-func newModeModifier(logger *logrus.Logger, cfg *config.Config, ociSpec oci.Spec, argv []string) (oci.SpecModifier, error) {
-	switch info.ResolveAutoMode(logger, cfg.NVIDIAContainerRuntimeConfig.Mode) {
+// newModeModifier creates the modifier for the specified mode. In csv mode, the files discovered by the
+// specified graphics discoverer are injected by the mode modifier.
+func newModeModifier(ctx context.Context, logger *logrus.Logger, mode string, cfg *config.Config, ociSpec oci.Spec, graphics discover.Discover) (oci.SpecModifier, error) {
+	switch mode {
 	case "legacy":
 		return modifier.NewStableRuntimeModifier(logger), nil
 	case "csv":
-		return modifier.NewCSVModifier(logger, cfg, ociSpec)
+		return modifier.NewCSVModifier(logger, cfg, ociSpec, graphics)
 	case "cdi":
-		return modifier.NewCDIModifier(logger, cfg, ociSpec)
+		return modifier.NewCDIModifier(ctx, logger, cfg, ociSpec)
+	case "cdi-annotations":
+		return modifier.NewCDIAnnotationsModifier(logger, cfg, ociSpec)
 	}
 
-	return nil, fmt.Errorf("invalid runtime mode: %v", cfg.NVIDIAContainerRuntimeConfig.Mode)
+	return nil, oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid runtime mode: %v", cfg.NVIDIAContainerRuntimeConfig.Mode))
 }
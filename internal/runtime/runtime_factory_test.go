@@ -17,6 +17,7 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"os/exec"
@@ -150,7 +151,7 @@ func TestFactoryMethod(t *testing.T) {
 
 			argv := []string{"--bundle", bundleDir, "create"}
 
-			_, err = newNVIDIAContainerRuntime(logger, tc.cfg, argv)
+			_, err = newNVIDIAContainerRuntime(context.Background(), logger, tc.cfg, argv, nil)
 			if tc.expectedError {
 				require.Error(t, err)
 			} else {
@@ -159,3 +160,86 @@ func TestFactoryMethod(t *testing.T) {
 		})
 	}
 }
+
+func TestNonLinuxSpecSkipsDiscovery(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	// The invalid mode causes the construction of the modifiers to fail.
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: config.RuntimeConfig{
+			Runtimes: []string{"runc"},
+			Mode:     "non-legacy",
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		spec          *specs.Spec
+		expectedError bool
+	}{
+		{
+			description: "linux spec raises error",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			description: "windows spec is passed through",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+				Windows: &specs.Windows{},
+			},
+		},
+		{
+			description: "vm spec is passed through",
+			spec: &specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"NVIDIA_VISIBLE_DEVICES=all"},
+				},
+				VM: &specs.VM{},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			bundleDir := t.TempDir()
+
+			specFile, err := os.Create(filepath.Join(bundleDir, "config.json"))
+			require.NoError(t, err)
+			require.NoError(t, json.NewEncoder(specFile).Encode(tc.spec))
+
+			argv := []string{"--bundle", bundleDir, "create"}
+
+			_, err = newNVIDIAContainerRuntime(context.Background(), logger, cfg, argv, nil)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResolveStagedDriverRoot(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	cfg := &config.Config{
+		NVIDIAContainerRuntimeConfig: config.RuntimeConfig{
+			StagedDriverRoot: "/run/nvidia/driver-stage",
+		},
+	}
+
+	require.Equal(t, cfg, resolveStagedDriverRoot(logger, "csv", cfg))
+
+	for _, mode := range []string{"legacy", "cdi"} {
+		resolved := resolveStagedDriverRoot(logger, mode, cfg)
+		require.Empty(t, resolved.NVIDIAContainerRuntimeConfig.StagedDriverRoot)
+		require.Equal(t, "/run/nvidia/driver-stage", cfg.NVIDIAContainerRuntimeConfig.StagedDriverRoot)
+	}
+}
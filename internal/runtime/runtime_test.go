@@ -0,0 +1,124 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONError(t *testing.T) {
+	testCases := []struct {
+		description string
+		err         error
+		expected    string
+	}{
+		{
+			description: "typed error",
+			err:         fmt.Errorf("failed to create NVIDIA Container Runtime: %w", oci.NewError(oci.ErrorKindConfig, fmt.Errorf("invalid runtime mode: foo"))),
+			expected:    `{"kind": "config", "exitCode": 2, "message": "failed to create NVIDIA Container Runtime: invalid runtime mode: foo"}`,
+		},
+		{
+			description: "untyped error",
+			err:         fmt.Errorf("failed"),
+			expected:    `{"kind": "unknown", "exitCode": 1, "message": "failed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			writeJSONError(&buffer, tc.err)
+			require.JSONEq(t, tc.expected, buffer.String())
+		})
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := &config.Config{
+		DebugConfig: config.DebugConfig{
+			RedactEnv: []string{"*KEY*"},
+		},
+		Devices: map[string]config.DeviceConfig{
+			"GPU-0": {
+				Env: []string{"LICENSE_KEY=abc", "LICENSE_PATH=/licenses/gpu0"},
+			},
+		},
+	}
+
+	redacted := redactConfig(cfg)
+	require.Equal(t, []string{"LICENSE_KEY=REDACTED", "LICENSE_PATH=/licenses/gpu0"}, redacted.Devices["GPU-0"].Env)
+	require.Equal(t, []string{"LICENSE_KEY=abc", "LICENSE_PATH=/licenses/gpu0"}, cfg.Devices["GPU-0"].Env)
+}
+
+func TestNewModificationContext(t *testing.T) {
+	start := time.Now()
+
+	testCases := []struct {
+		description      string
+		timeout          string
+		expectedDeadline bool
+		expectedError    bool
+	}{
+		{
+			description: "no timeout",
+		},
+		{
+			description:      "timeout",
+			timeout:          "30s",
+			expectedDeadline: true,
+		},
+		{
+			description:   "invalid timeout",
+			timeout:       "30",
+			expectedError: true,
+		},
+		{
+			description:   "negative timeout",
+			timeout:       "-1s",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.NVIDIAContainerRuntimeConfig.ModificationTimeout = tc.timeout
+
+			ctx, cancel, err := newModificationContext(start, cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Equal(t, oci.ErrorKindConfig, oci.GetErrorKind(err))
+				return
+			}
+			require.NoError(t, err)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			require.Equal(t, tc.expectedDeadline, ok)
+			if ok {
+				require.Equal(t, start.Add(30*time.Second), deadline)
+			}
+		})
+	}
+}
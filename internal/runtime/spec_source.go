@@ -0,0 +1,59 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/sirupsen/logrus"
+)
+
+// specFDEnvvar is the environment variable used by shims to provide the OCI specification
+// using a file descriptor that is inherited by the runtime.
+const specFDEnvvar = "NVIDIA_CONTAINER_RUNTIME_SPEC_FD"
+
+// getSpecSource returns the source of the OCI specification. A source specified when constructing
+// the runtime takes precedence over a file descriptor specified using the NVIDIA_CONTAINER_RUNTIME_SPEC_FD
+// environment variable. If neither is specified, nil is returned and the OCI specification is read from
+// the file referenced by the command line arguments.
+func (r rt) getSpecSource() (oci.SpecSource, error) {
+	if r.specSource != nil {
+		return r.specSource, nil
+	}
+
+	value := os.Getenv(specFDEnvvar)
+	if value == "" {
+		return nil, nil
+	}
+	fd, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v %q: %v", specFDEnvvar, value, err)
+	}
+	return oci.FDSpecSource(uintptr(fd)), nil
+}
+
+// newSpec constructs the OCI specification using the specified source. If the source is nil,
+// the OCI specification file referenced by the command line arguments is used.
+func newSpec(logger *logrus.Logger, argv []string, specSource oci.SpecSource) (oci.Spec, error) {
+	if specSource == nil {
+		specSource = oci.NewSpec
+	}
+	return specSource(logger, argv)
+}
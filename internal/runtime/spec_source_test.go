@@ -0,0 +1,97 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSpecSource(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	memorySpec := &specs.Spec{Version: "memory"}
+
+	testCases := []struct {
+		description   string
+		specSource    oci.SpecSource
+		fd            string
+		expectedNil   bool
+		expectedSpec  *specs.Spec
+		expectedError bool
+	}{
+		{
+			description: "default source",
+			expectedNil: true,
+		},
+		{
+			description:   "invalid file descriptor",
+			fd:            "stdin",
+			expectedError: true,
+		},
+		{
+			description: "file descriptor",
+			fd:          "3",
+		},
+		{
+			description:  "source option takes precedence",
+			specSource:   oci.MemorySpecSource(memorySpec),
+			fd:           "3",
+			expectedSpec: memorySpec,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv(specFDEnvvar, tc.fd)
+
+			r := rt{specSource: tc.specSource}
+			specSource, err := r.getSpecSource()
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedNil {
+				require.Nil(t, specSource)
+				return
+			}
+			require.NotNil(t, specSource)
+			if tc.expectedSpec == nil {
+				return
+			}
+
+			ociSpec, err := newSpec(logger, nil, specSource)
+			require.NoError(t, err)
+			spec, err := ociSpec.Load()
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSpec, spec)
+		})
+	}
+}
+
+func TestLoadInputSpecFromSource(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	spec := &specs.Spec{Version: "1.0.0"}
+	loaded, err := loadInputSpec(logger, []string{"create", "--bundle", "/does/not/exist"}, oci.MemorySpecSource(spec))
+	require.NoError(t, err)
+	require.Equal(t, spec, loaded)
+}
@@ -0,0 +1,266 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package driverroot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/ldcache"
+)
+
+const (
+	// DefaultDriverVersion is the driver version used if no version is specified.
+	DefaultDriverVersion = "999.99"
+
+	libraryDir = "/usr/lib64"
+	binaryDir  = "/usr/bin"
+
+	// The number of GPU instances and compute instances per GPU instance for which MIG
+	// capabilities are created. These match the values for an A100 GPU.
+	migGPUInstances     = 15
+	migComputeInstances = 8
+)
+
+// driverLibraries maps the libraries included in a fake driver root to their SONAMEs. An empty
+// SONAME indicates that the SONAME of the library includes the full driver version.
+var driverLibraries = map[string]string{
+	"libcuda.so":                  "libcuda.so.1",
+	"libnvidia-ml.so":             "libnvidia-ml.so.1",
+	"libnvidia-cfg.so":            "libnvidia-cfg.so.1",
+	"libnvidia-ptxjitcompiler.so": "libnvidia-ptxjitcompiler.so.1",
+	"libnvidia-nvvm.so":           "libnvidia-nvvm.so.4",
+	"libnvidia-opencl.so":         "libnvidia-opencl.so.1",
+	"libnvidia-allocator.so":      "libnvidia-allocator.so.1",
+	"libnvidia-encode.so":         "libnvidia-encode.so.1",
+	"libnvcuvid.so":               "libnvcuvid.so.1",
+	"libnvidia-eglcore.so":        "",
+	"libnvidia-glcore.so":         "",
+	"libnvidia-tls.so":            "",
+	"libnvidia-glsi.so":           "",
+	"libEGL_nvidia.so":            "libEGL_nvidia.so.0",
+	"libGLX_nvidia.so":            "libGLX_nvidia.so.0",
+	"libGLESv2_nvidia.so":         "libGLESv2_nvidia.so.2",
+	"libGLESv1_CM_nvidia.so":      "libGLESv1_CM_nvidia.so.1",
+}
+
+// gspFirmwares lists the GSP firmware files included in a fake driver root.
+var gspFirmwares = []string{
+	"gsp_ga10x.bin",
+	"gsp_tu10x.bin",
+}
+
+// controlDevices lists the device nodes that are created independent of the number of GPUs.
+var controlDevices = []string{
+	"/dev/nvidiactl",
+	"/dev/nvidia-uvm",
+	"/dev/nvidia-uvm-tools",
+	"/dev/nvidia-modeset",
+}
+
+type driverRoot struct {
+	root          string
+	driverVersion string
+	gpus          int
+	mig           bool
+}
+
+// Create creates a fake driver root at the specified path. This includes the device nodes in
+// /dev, the files in /proc/driver/nvidia, and the driver libraries, binaries, firmware, and
+// ld.so.cache that are used to locate driver files. Since creating character devices requires
+// privileges, device nodes are created as empty regular files. The libraries, binaries, and
+// firmware files are stubs and cannot be loaded or executed.
+func Create(root string, opts ...Option) error {
+	d := &driverRoot{
+		root: root,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.driverVersion == "" {
+		d.driverVersion = DefaultDriverVersion
+	}
+	if d.gpus < 0 {
+		return fmt.Errorf("invalid number of GPUs: %d", d.gpus)
+	}
+
+	for _, create := range []func() error{
+		d.createDeviceNodes,
+		d.createProcFiles,
+		d.createLibraries,
+		d.createBinaries,
+		d.createFirmware,
+	} {
+		if err := create(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createDeviceNodes creates the control device nodes, a device node for each GPU, and the
+// MIG capability device nodes if MIG is enabled.
+func (d *driverRoot) createDeviceNodes() error {
+	devices := append([]string{}, controlDevices...)
+	for i := 0; i < d.gpus; i++ {
+		devices = append(devices, fmt.Sprintf("/dev/nvidia%d", i))
+	}
+	if d.mig {
+		devices = append(devices,
+			"/dev/nvidia-caps/nvidia-cap1",
+			"/dev/nvidia-caps/nvidia-cap2",
+		)
+	}
+
+	for _, device := range devices {
+		if err := d.writeFile(device, "", 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createProcFiles creates the driver version file and an information file for each GPU. If
+// MIG is enabled, the MIG capability files and the mig-minors file are also created.
+func (d *driverRoot) createProcFiles() error {
+	version := fmt.Sprintf("NVRM version: NVIDIA UNIX x86_64 Kernel Module  %v  Thu Jan  1 00:00:00 UTC 1970\n", d.driverVersion)
+	if err := d.writeFile("/proc/driver/nvidia/version", version, 0444); err != nil {
+		return err
+	}
+
+	for i := 0; i < d.gpus; i++ {
+		busID := fmt.Sprintf("0000:%02x:00.0", i+1)
+		information := strings.Join([]string{
+			"Model:           NVIDIA A100-SXM4-40GB",
+			"IRQ:             0",
+			fmt.Sprintf("GPU UUID:        GPU-%08x-0000-0000-0000-000000000000", i),
+			"Video BIOS:      00.00.00.00.00",
+			"Bus Type:        PCIe",
+			"DMA Size:        47 bits",
+			"DMA Mask:        0x7fffffffffff",
+			"Bus Location:    " + busID,
+			fmt.Sprintf("Device Minor:    %d", i),
+			"GPU Excluded:    No",
+		}, "\n") + "\n"
+		if err := d.writeFile(filepath.Join("/proc/driver/nvidia/gpus", busID, "information"), information, 0444); err != nil {
+			return err
+		}
+	}
+
+	if !d.mig {
+		return nil
+	}
+
+	for minor, name := range []string{"config", "monitor"} {
+		capability := fmt.Sprintf("DeviceFileMinor: %d\nDeviceFileMode: 256\nDeviceFileModify: 1\n", minor+1)
+		if err := d.writeFile(filepath.Join("/proc/driver/nvidia/capabilities/mig", name), capability, 0444); err != nil {
+			return err
+		}
+	}
+
+	minors := []string{"config 1", "monitor 2"}
+	minor := 3
+	for gpu := 0; gpu < d.gpus; gpu++ {
+		for gi := 0; gi < migGPUInstances; gi++ {
+			minors = append(minors, fmt.Sprintf("gpu%d/gi%d/access %d", gpu, gi, minor))
+			minor++
+			for ci := 0; ci < migComputeInstances; ci++ {
+				minors = append(minors, fmt.Sprintf("gpu%d/gi%d/ci%d/access %d", gpu, gi, ci, minor))
+				minor++
+			}
+		}
+	}
+	return d.writeFile("/proc/driver/nvidia-caps/mig-minors", strings.Join(minors, "\n")+"\n", 0444)
+}
+
+// createLibraries creates the versioned driver libraries, the symlinks for their SONAMEs, and
+// an ld.so.cache that maps the SONAMEs to these symlinks.
+func (d *driverRoot) createLibraries() error {
+	cache := make(map[string]string)
+	for name, soname := range driverLibraries {
+		library := name + "." + d.driverVersion
+		if err := d.writeFile(filepath.Join(libraryDir, library), "fake "+library+"\n", 0755); err != nil {
+			return err
+		}
+		if soname == "" {
+			cache[library] = filepath.Join(libraryDir, library)
+			continue
+		}
+		if err := d.symlink(library, filepath.Join(libraryDir, soname)); err != nil {
+			return err
+		}
+		cache[soname] = filepath.Join(libraryDir, soname)
+	}
+
+	// As is the case for driver installations, a libcuda.so symlink is also created.
+	if err := d.symlink(driverLibraries["libcuda.so"], filepath.Join(libraryDir, "libcuda.so")); err != nil {
+		return err
+	}
+
+	if err := ldcache.WriteCache(d.root, cache); err != nil {
+		return fmt.Errorf("failed to create ld.so.cache: %v", err)
+	}
+	return nil
+}
+
+// createBinaries creates a stub executable for each of the driver binaries.
+func (d *driverRoot) createBinaries() error {
+	for _, binary := range discover.DriverBinaries {
+		contents := fmt.Sprintf("#!/bin/sh\necho \"fake %v (driver version %v)\"\n", binary, d.driverVersion)
+		if err := d.writeFile(filepath.Join(binaryDir, binary), contents, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createFirmware creates the GSP firmware files for the driver version.
+func (d *driverRoot) createFirmware() error {
+	for _, firmware := range gspFirmwares {
+		if err := d.writeFile(filepath.Join("/lib/firmware/nvidia", d.driverVersion, firmware), "", 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes the specified contents to the specified path relative to the root.
+func (d *driverRoot) writeFile(path string, contents string, mode os.FileMode) error {
+	path = filepath.Join(d.root, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %v: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(contents), mode); err != nil {
+		return fmt.Errorf("failed to write %v: %v", path, err)
+	}
+	return nil
+}
+
+// symlink creates a symlink at the specified path relative to the root. Existing files are replaced.
+func (d *driverRoot) symlink(target string, link string) error {
+	link = filepath.Join(d.root, link)
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove existing file %v: %v", link, err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		return fmt.Errorf("failed to create symlink %v: %v", link, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package driverroot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/proc"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description     string
+		options         []Option
+		expectedVersion string
+		expectedGPUs    int
+		expectedFiles   []string
+		expectedMissing []string
+		expectedError   bool
+	}{
+		{
+			description:     "default driver root has no GPUs",
+			expectedVersion: DefaultDriverVersion,
+			expectedFiles: []string{
+				"/dev/nvidiactl",
+				"/dev/nvidia-uvm",
+				"/proc/driver/nvidia/version",
+				"/lib/firmware/nvidia/999.99/gsp_ga10x.bin",
+			},
+			expectedMissing: []string{
+				"/dev/nvidia0",
+				"/dev/nvidia-caps",
+			},
+		},
+		{
+			description: "GPUs are created",
+			options: []Option{
+				WithDriverVersion("550.54.14"),
+				WithGPUs(4),
+			},
+			expectedVersion: "550.54.14",
+			expectedGPUs:    4,
+			expectedFiles: []string{
+				"/dev/nvidia0",
+				"/dev/nvidia3",
+			},
+			expectedMissing: []string{
+				"/dev/nvidia4",
+				"/dev/nvidia-caps",
+				"/proc/driver/nvidia-caps/mig-minors",
+			},
+		},
+		{
+			description: "MIG capabilities are created",
+			options: []Option{
+				WithGPUs(2),
+				WithMIG(true),
+			},
+			expectedVersion: DefaultDriverVersion,
+			expectedGPUs:    2,
+			expectedFiles: []string{
+				"/dev/nvidia-caps/nvidia-cap1",
+				"/dev/nvidia-caps/nvidia-cap2",
+				"/proc/driver/nvidia/capabilities/mig/config",
+				"/proc/driver/nvidia/capabilities/mig/monitor",
+				"/proc/driver/nvidia-caps/mig-minors",
+			},
+		},
+		{
+			description: "negative number of GPUs is an error",
+			options: []Option{
+				WithGPUs(-1),
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+
+			err := Create(root, tc.options...)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, file := range tc.expectedFiles {
+				require.FileExists(t, filepath.Join(root, file))
+			}
+			for _, file := range tc.expectedMissing {
+				_, err := os.Stat(filepath.Join(root, file))
+				require.ErrorIs(t, err, os.ErrNotExist)
+			}
+
+			informationFiles, err := proc.GetInformationFilePaths(root)
+			require.NoError(t, err)
+			require.Len(t, informationFiles, tc.expectedGPUs)
+			for _, informationFile := range informationFiles {
+				info, err := proc.ParseGPUInformationFile(informationFile)
+				require.NoError(t, err)
+				require.Contains(t, informationFile, info[proc.GPUInfoBusLocation])
+			}
+
+			libraries, err := lookup.NewLibraryLocator(logger, root)
+			require.NoError(t, err)
+			libcuda, err := libraries.Locate("libcuda.so.1")
+			require.NoError(t, err)
+			require.Equal(t, []string{filepath.Join(root, "/usr/lib64/libcuda.so."+tc.expectedVersion)}, libcuda)
+
+			smi, err := lookup.NewExecutableLocator(logger, root).Locate("nvidia-smi")
+			require.NoError(t, err)
+			require.Equal(t, []string{filepath.Join(root, "/usr/bin/nvidia-smi")}, smi)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package driverroot
+
+// Option is a function that configures a fake driver root
+type Option func(*driverRoot)
+
+// WithDriverVersion sets the driver version for the driver root
+func WithDriverVersion(version string) Option {
+	return func(d *driverRoot) {
+		d.driverVersion = version
+	}
+}
+
+// WithGPUs sets the number of GPUs for the driver root
+func WithGPUs(gpus int) Option {
+	return func(d *driverRoot) {
+		d.gpus = gpus
+	}
+}
+
+// WithMIG sets whether MIG is enabled for the driver root
+func WithMIG(mig bool) Option {
+	return func(d *driverRoot) {
+		d.mig = mig
+	}
+}
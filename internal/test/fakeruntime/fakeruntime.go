@@ -0,0 +1,163 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package fakeruntime provides a test double for the low-level runtime (e.g. runc or crun) that
+// records the invocations made by the NVIDIA Container Runtime and replays canned responses.
+package fakeruntime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Invocation is a recorded invocation of the low-level runtime.
+type Invocation struct {
+	// Args are the command line arguments passed to the runtime.
+	Args []string `json:"args"`
+	// Env are the environment variables that the runtime would be invoked with. Only the
+	// variables selected using WithEnv are recorded.
+	Env []string `json:"env,omitempty"`
+	// Spec is the OCI specification in the bundle at the time of a create invocation.
+	Spec *specs.Spec `json:"spec,omitempty"`
+	// Error is the error replayed for the invocation.
+	Error string `json:"error,omitempty"`
+}
+
+// Runtime is a fake low-level runtime that records each invocation instead of exec-ing into a
+// runtime binary.
+type Runtime struct {
+	mu          sync.Mutex
+	envKeys     []string
+	responses   map[string]error
+	invocations []Invocation
+}
+
+var _ oci.Runtime = (*Runtime)(nil)
+
+// Option is a functional option for the fake runtime.
+type Option func(*Runtime)
+
+// WithEnv sets the environment variables that are recorded for each invocation.
+func WithEnv(keys ...string) Option {
+	return func(r *Runtime) {
+		r.envKeys = append(r.envKeys, keys...)
+	}
+}
+
+// WithResponse sets the error that is returned when the runtime is invoked with the specified
+// subcommand (e.g. delete). If no response is set for a subcommand, nil is returned.
+func WithResponse(subcommand string, err error) Option {
+	return func(r *Runtime) {
+		r.responses[subcommand] = err
+	}
+}
+
+// New creates a fake runtime with the specified options.
+func New(opts ...Option) *Runtime {
+	r := &Runtime{
+		responses: make(map[string]error),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Exec records the invocation and returns the canned response for its subcommand.
+func (r *Runtime) Exec(args []string) error {
+	invocation := Invocation{
+		Args: append([]string{}, args...),
+		Env:  r.environ(),
+	}
+
+	if oci.HasCreateSubcommand(args) {
+		spec, err := loadSpec(args)
+		if err != nil {
+			return err
+		}
+		invocation.Spec = spec
+	}
+
+	err := r.response(args)
+	if err != nil {
+		invocation.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invocations = append(r.invocations, invocation)
+
+	return err
+}
+
+// Invocations returns the invocations recorded so far.
+func (r *Runtime) Invocations() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Invocation{}, r.invocations...)
+}
+
+// environ returns the recorded environment variables in the order in which these were selected.
+func (r *Runtime) environ() []string {
+	var env []string
+	for _, key := range r.envKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// response returns the canned response for the first argument that matches a subcommand.
+func (r *Runtime) response(args []string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if err, ok := r.responses[arg]; ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSpec reads the OCI specification that the runtime would use for the specified arguments.
+func loadSpec(args []string) (*specs.Spec, error) {
+	path, err := oci.GetSpecFilePathFromArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI specification: %v", err)
+	}
+
+	var spec specs.Spec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI specification: %v", err)
+	}
+	return &spec, nil
+}
@@ -0,0 +1,79 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package fakeruntime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntime(t *testing.T) {
+	bundleDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.json"), []byte(`{"ociVersion": "1.0.2"}`), 0644))
+	t.Setenv("FAKE_RUNTIME_TEST", "value")
+
+	r := New(
+		WithEnv("FAKE_RUNTIME_TEST", "FAKE_RUNTIME_UNSET"),
+		WithResponse("delete", errors.New("delete failed")),
+	)
+
+	require.NoError(t, r.Exec([]string{"runc", "create", "--bundle", bundleDir, "c1"}))
+	require.NoError(t, r.Exec([]string{"runc", "--root", "/run/runc", "start", "c1"}))
+	require.EqualError(t, r.Exec([]string{"runc", "delete", "--force", "c1"}), "delete failed")
+
+	require.Equal(t,
+		[]Invocation{
+			{
+				Args: []string{"runc", "create", "--bundle", bundleDir, "c1"},
+				Env:  []string{"FAKE_RUNTIME_TEST=value"},
+				Spec: &specs.Spec{Version: "1.0.2"},
+			},
+			{
+				Args: []string{"runc", "--root", "/run/runc", "start", "c1"},
+				Env:  []string{"FAKE_RUNTIME_TEST=value"},
+			},
+			{
+				Args:  []string{"runc", "delete", "--force", "c1"},
+				Env:   []string{"FAKE_RUNTIME_TEST=value"},
+				Error: "delete failed",
+			},
+		},
+		r.Invocations(),
+	)
+}
+
+func TestRequireGolden(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.json")
+	invocations := []Invocation{
+		{Args: []string{"runc", "create", "--bundle", "/tmp/bundle-123", "c1"}},
+	}
+	placeholders := map[string]string{"/tmp/bundle-123": "{{bundle}}"}
+
+	RequireGolden(t, golden, true, invocations, placeholders)
+
+	contents, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), `"{{bundle}}"`)
+	require.NotContains(t, string(contents), "/tmp/bundle-123")
+
+	RequireGolden(t, golden, false, invocations, placeholders)
+}
@@ -0,0 +1,60 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package fakeruntime
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RequireGolden asserts that the specified invocations match those recorded in the golden file
+// at the specified path. The placeholders are replaced in the arguments of the invocations before
+// these are compared so that temporary paths do not appear in the golden file. If update is set,
+// the golden file is (re)written instead.
+func RequireGolden(t *testing.T, path string, update bool, invocations []Invocation, placeholders map[string]string) {
+	t.Helper()
+
+	var normalized []Invocation
+	for _, i := range invocations {
+		var args []string
+		for _, arg := range i.Args {
+			for value, placeholder := range placeholders {
+				arg = strings.ReplaceAll(arg, value, placeholder)
+			}
+			args = append(args, arg)
+		}
+		i.Args = args
+		normalized = append(normalized, i)
+	}
+
+	actual, err := json.MarshalIndent(normalized, "", "  ")
+	require.NoError(t, err)
+	actual = append(actual, '\n')
+
+	if update {
+		require.NoError(t, os.WriteFile(path, actual, 0644))
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "run the test with -update to create the golden file")
+	require.Equal(t, string(expected), string(actual))
+}
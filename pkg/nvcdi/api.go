@@ -36,6 +36,9 @@ const (
 	ModeGds = "gds"
 	// ModeMofed configures the CDI spec generator to generate a MOFED spec.
 	ModeMofed = "mofed"
+	// ModeUtility configures the CDI spec generator to generate a spec for monitoring containers
+	// that includes only NVML, nvidia-smi, and the required device nodes.
+	ModeUtility = "utility"
 )
 
 // Interface defines the API for the nvcdi package
@@ -27,15 +27,16 @@ import (
 )
 
 // newCommonNVMLDiscoverer returns a discoverer for entities that are not associated with a specific CDI device.
-// This includes driver libraries and meta devices, for example.
-func newCommonNVMLDiscoverer(logger *logrus.Logger, driverRoot string, nvidiaCTKPath string, nvmllib nvml.Interface) (discover.Discover, error) {
+// This includes driver libraries and meta devices, for example. The meta devices are located relative to the
+// specified dev root.
+func newCommonNVMLDiscoverer(logger *logrus.Logger, driverRoot string, devRoot string, nvidiaCTKPath string, nvmllib nvml.Interface) (discover.Discover, error) {
 	metaDevices := discover.NewDeviceDiscoverer(
 		logger,
 		lookup.NewCharDeviceLocator(
 			lookup.WithLogger(logger),
-			lookup.WithRoot(driverRoot),
+			lookup.WithRoot(devRoot),
 		),
-		driverRoot,
+		devRoot,
 		[]string{
 			"/dev/nvidia-modeset",
 			"/dev/nvidia-uvm-tools",
@@ -0,0 +1,132 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// DeviceEnvValues holds the values that can be referenced by the per-device environment variable
+// templates (e.g. NVIDIA_DEVICE_UUID={{.UUID}}).
+type DeviceEnvValues struct {
+	// Name is the name of the CDI device (e.g. 0 or GPU-edfee158-11c1-52b8-0517-92f30e7fac88).
+	Name string
+	// Index is the index of the device. For MIG devices this is <gpu>:<mig>.
+	Index string
+	// UUID is the UUID of the device. For MIG devices this is the UUID of the MIG device.
+	UUID string
+	// ParentUUID is the UUID of the full GPU. For full GPUs this is the same as UUID.
+	ParentUUID string
+	// PCIBusID is the PCI bus ID of the full GPU.
+	PCIBusID string
+	// Minor is the minor number of the device node of the full GPU.
+	Minor int
+}
+
+// deviceEnvTemplates are the parsed per-device environment variable templates.
+type deviceEnvTemplates []*template.Template
+
+// ValidateDeviceEnvTemplates checks whether the specified per-device environment variable templates
+// can be parsed and have the form KEY=VALUE. Both the KEY and VALUE may reference device values.
+func ValidateDeviceEnvTemplates(templates ...string) error {
+	parsed, err := parseDeviceEnvTemplates(templates)
+	if err != nil {
+		return err
+	}
+	// Rendering the templates with empty values detects references to unknown values.
+	_, err = parsed.render(DeviceEnvValues{})
+	return err
+}
+
+// parseDeviceEnvTemplates parses the specified per-device environment variable templates.
+func parseDeviceEnvTemplates(templates []string) (deviceEnvTemplates, error) {
+	var parsed deviceEnvTemplates
+	for _, t := range templates {
+		if key, _, ok := strings.Cut(t, "="); !ok || key == "" {
+			return nil, fmt.Errorf("invalid device environment variable %q: expected KEY=VALUE", t)
+		}
+		tmpl, err := template.New(t).Option("missingkey=error").Parse(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device environment variable %q: %v", t, err)
+		}
+		parsed = append(parsed, tmpl)
+	}
+	return parsed, nil
+}
+
+// render returns the environment variables for a device with the specified values.
+func (t deviceEnvTemplates) render(values DeviceEnvValues) ([]string, error) {
+	var env []string
+	for _, tmpl := range t {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return nil, fmt.Errorf("failed to render device environment variable %q: %v", tmpl.Name(), err)
+		}
+		if key, _, _ := strings.Cut(buf.String(), "="); key == "" {
+			return nil, fmt.Errorf("device environment variable %q rendered with an empty key", tmpl.Name())
+		}
+		env = append(env, buf.String())
+	}
+	return env, nil
+}
+
+// getDeviceEnv returns the templated environment variables for a device. If no templates are
+// configured, the device is not queried.
+func (l *nvmllib) getDeviceEnv(name string, index string, parent nvml.Device, device nvml.Device) ([]string, error) {
+	if len(l.deviceEnvTemplates) == 0 {
+		return nil, nil
+	}
+	templates, err := parseDeviceEnvTemplates(l.deviceEnvTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	values := DeviceEnvValues{
+		Name:  name,
+		Index: index,
+	}
+
+	var ret nvml.Return
+	values.ParentUUID, ret = parent.GetUUID()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting UUID of device: %v", ret)
+	}
+	values.UUID = values.ParentUUID
+	if device != nil {
+		values.UUID, ret = device.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("error getting UUID of MIG device: %v", ret)
+		}
+	}
+	values.Minor, ret = parent.GetMinorNumber()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting GPU device minor number: %v", ret)
+	}
+	pciInfo, ret := parent.GetPciInfo()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting PCI info for device: %v", ret)
+	}
+	values.PCIBusID = info.GetBusID(pciInfo)
+
+	return templates.render(values)
+}
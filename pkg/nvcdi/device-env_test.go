@@ -0,0 +1,164 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+func TestValidateDeviceEnvTemplates(t *testing.T) {
+	testCases := []struct {
+		description   string
+		templates     []string
+		expectedError bool
+	}{
+		{
+			description: "no templates",
+		},
+		{
+			description: "valid templates",
+			templates:   []string{"NVIDIA_DEVICE_INDEX={{.Index}}", "NVIDIA_DEVICE_UUID={{.UUID}}"},
+		},
+		{
+			description:   "missing value",
+			templates:     []string{"NVIDIA_DEVICE_INDEX"},
+			expectedError: true,
+		},
+		{
+			description:   "empty key",
+			templates:     []string{"={{.Index}}"},
+			expectedError: true,
+		},
+		{
+			description: "templated key",
+			templates:   []string{"NVIDIA_GPU{{.Minor}}_UUID={{.UUID}}"},
+		},
+		{
+			description:   "unknown value",
+			templates:     []string{"NVIDIA_DEVICE_SERIAL={{.Serial}}"},
+			expectedError: true,
+		},
+		{
+			description:   "invalid template",
+			templates:     []string{"NVIDIA_DEVICE_INDEX={{.Index"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := ValidateDeviceEnvTemplates(tc.templates...)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetDeviceEnv(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	newDevice := func(uuid string) *nvml.DeviceMock {
+		return &nvml.DeviceMock{
+			GetUUIDFunc:        func() (string, nvml.Return) { return uuid, nvml.SUCCESS },
+			GetMinorNumberFunc: func() (int, nvml.Return) { return 3, nvml.SUCCESS },
+			GetPciInfoFunc: func() (nvml.PciInfo, nvml.Return) {
+				var info nvml.PciInfo
+				for i, c := range "00000000:3B:00.0" {
+					info.BusId[i] = int8(c)
+				}
+				return info, nvml.SUCCESS
+			},
+		}
+	}
+	gpu := newDevice("GPU-edfee158")
+	mig := newDevice("MIG-7ab1e2c4")
+
+	templates := []string{
+		"NVIDIA_DEVICE_NAME={{.Name}}",
+		"NVIDIA_DEVICE_INDEX={{.Index}}",
+		"NVIDIA_DEVICE_UUID={{.UUID}}",
+		"NVIDIA_DEVICE_PARENT_UUID={{.ParentUUID}}",
+		"NVIDIA_DEVICE_BUS_ID={{.PCIBusID}}",
+		"NVIDIA_DEVICE_MINOR={{.Minor}}",
+		"NVIDIA_GPU{{.Minor}}_UUID={{.ParentUUID}}",
+	}
+
+	testCases := []struct {
+		description string
+		templates   []string
+		name        string
+		index       string
+		mig         nvml.Device
+		expectedEnv []string
+	}{
+		{
+			description: "no templates",
+			name:        "0",
+			index:       "0",
+		},
+		{
+			description: "full GPU",
+			templates:   templates,
+			name:        "0",
+			index:       "0",
+			expectedEnv: []string{
+				"NVIDIA_DEVICE_NAME=0",
+				"NVIDIA_DEVICE_INDEX=0",
+				"NVIDIA_DEVICE_UUID=GPU-edfee158",
+				"NVIDIA_DEVICE_PARENT_UUID=GPU-edfee158",
+				"NVIDIA_DEVICE_BUS_ID=0000:3b:00.0",
+				"NVIDIA_DEVICE_MINOR=3",
+				"NVIDIA_GPU3_UUID=GPU-edfee158",
+			},
+		},
+		{
+			description: "MIG device",
+			templates:   templates,
+			name:        "0:1",
+			index:       "0:1",
+			mig:         mig,
+			expectedEnv: []string{
+				"NVIDIA_DEVICE_NAME=0:1",
+				"NVIDIA_DEVICE_INDEX=0:1",
+				"NVIDIA_DEVICE_UUID=MIG-7ab1e2c4",
+				"NVIDIA_DEVICE_PARENT_UUID=GPU-edfee158",
+				"NVIDIA_DEVICE_BUS_ID=0000:3b:00.0",
+				"NVIDIA_DEVICE_MINOR=3",
+				"NVIDIA_GPU3_UUID=GPU-edfee158",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			l := nvmllib{
+				logger:             logger,
+				deviceEnvTemplates: tc.templates,
+			}
+			env, err := l.getDeviceEnv(tc.name, tc.index, gpu, tc.mig)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEnv, env)
+		})
+	}
+}
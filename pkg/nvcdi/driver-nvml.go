@@ -115,13 +115,7 @@ func NewDriverBinariesDiscoverer(logger *logrus.Logger, driverRoot string) disco
 		logger,
 		lookup.NewExecutableLocator(logger, driverRoot),
 		driverRoot,
-		[]string{
-			"nvidia-smi",              /* System management interface */
-			"nvidia-debugdump",        /* GPU coredump utility */
-			"nvidia-persistenced",     /* Persistence mode utility */
-			"nvidia-cuda-mps-control", /* Multi process service CLI */
-			"nvidia-cuda-mps-server",  /* Multi process service server */
-		},
+		discover.DriverBinaries,
 	)
 }
 
@@ -0,0 +1,105 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+const (
+	persistencedSocketPath  = "/var/run/nvidia-persistenced/socket"
+	fabricManagerSocketPath = "/var/run/nvidia-fabricmanager/socket"
+	firmwarePath            = "/lib/firmware/nvidia"
+)
+
+// extras defines which of the optional edits are included in the common edits.
+type extras struct {
+	persistencedSocket  bool
+	fabricManagerSocket bool
+	firmware            bool
+	// driverBinaries is the list of driver binaries that are included. If this is nil, all driver
+	// binaries are included.
+	driverBinaries []string
+}
+
+// defaultExtras includes all optional edits.
+func defaultExtras() extras {
+	return extras{
+		persistencedSocket:  true,
+		fabricManagerSocket: true,
+		firmware:            true,
+	}
+}
+
+// apply removes the mounts for the optional edits that are not included from the specified edits.
+func (e extras) apply(edits *specs.ContainerEdits) {
+	if edits == nil {
+		return
+	}
+
+	var mounts []*specs.Mount
+	for _, m := range edits.Mounts {
+		if !e.includes(m.ContainerPath) {
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	edits.Mounts = mounts
+}
+
+// includes checks whether a mount at the specified container path is included.
+func (e extras) includes(path string) bool {
+	switch {
+	case path == persistencedSocketPath:
+		return e.persistencedSocket
+	case path == fabricManagerSocketPath:
+		return e.fabricManagerSocket
+	case strings.HasPrefix(path, firmwarePath+"/"):
+		return e.firmware
+	case isDriverBinary(path):
+		return e.includesDriverBinary(filepath.Base(path))
+	}
+	return true
+}
+
+// includesDriverBinary checks whether the specified driver binary is included.
+func (e extras) includesDriverBinary(name string) bool {
+	if e.driverBinaries == nil {
+		return true
+	}
+	for _, b := range e.driverBinaries {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isDriverBinary checks whether the specified path refers to one of the driver binaries.
+func isDriverBinary(path string) bool {
+	name := filepath.Base(path)
+	for _, b := range discover.DriverBinaries {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
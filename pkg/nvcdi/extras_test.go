@@ -0,0 +1,87 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtras(t *testing.T) {
+	mounts := []*specs.Mount{
+		{HostPath: "/usr/lib64/libcuda.so.999.99", ContainerPath: "/usr/lib64/libcuda.so.999.99"},
+		{HostPath: "/var/run/nvidia-persistenced/socket", ContainerPath: "/var/run/nvidia-persistenced/socket"},
+		{HostPath: "/var/run/nvidia-fabricmanager/socket", ContainerPath: "/var/run/nvidia-fabricmanager/socket"},
+		{HostPath: "/lib/firmware/nvidia/999.99/gsp_tu10x.bin", ContainerPath: "/lib/firmware/nvidia/999.99/gsp_tu10x.bin"},
+		{HostPath: "/usr/bin/nvidia-smi", ContainerPath: "/usr/bin/nvidia-smi"},
+		{HostPath: "/usr/bin/nvidia-debugdump", ContainerPath: "/usr/bin/nvidia-debugdump"},
+	}
+
+	testCases := []struct {
+		description    string
+		options        []Option
+		expectedMounts []*specs.Mount
+	}{
+		{
+			description:    "all extras are included by default",
+			expectedMounts: mounts,
+		},
+		{
+			description: "sockets and firmware can be excluded",
+			options: []Option{
+				WithPersistencedSocket(false),
+				WithFabricManagerSocket(false),
+				WithFirmware(false),
+			},
+			expectedMounts: []*specs.Mount{mounts[0], mounts[4], mounts[5]},
+		},
+		{
+			description: "driver binaries can be selected",
+			options: []Option{
+				WithDriverBinaries("nvidia-smi"),
+			},
+			expectedMounts: []*specs.Mount{mounts[0], mounts[1], mounts[2], mounts[3], mounts[4]},
+		},
+		{
+			description: "no driver binaries",
+			options: []Option{
+				WithDriverBinaries(),
+			},
+			expectedMounts: mounts[:4],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			l := &nvcdilib{
+				extras: defaultExtras(),
+			}
+			for _, opt := range tc.options {
+				opt(l)
+			}
+
+			edits := &specs.ContainerEdits{
+				Mounts: append([]*specs.Mount{}, mounts...),
+			}
+			l.extras.apply(edits)
+
+			require.EqualValues(t, tc.expectedMounts, edits.Mounts)
+		})
+	}
+}
@@ -20,10 +20,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/edits"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
 	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/drm"
 	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
 	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
@@ -44,6 +44,12 @@ func (l *nvmllib) GetGPUDeviceSpecs(i int, d device.Device) (*specs.Device, erro
 		return nil, fmt.Errorf("failed to get device name: %v", err)
 	}
 
+	env, err := l.getDeviceEnv(name, fmt.Sprintf("%d", i), d, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment variables for device: %v", err)
+	}
+	edits.Env = append(edits.Env, env...)
+
 	spec := specs.Device{
 		Name:           name,
 		ContainerEdits: *edits.ContainerEdits,
@@ -54,7 +60,7 @@ func (l *nvmllib) GetGPUDeviceSpecs(i int, d device.Device) (*specs.Device, erro
 
 // GetGPUDeviceEdits returns the CDI edits for the full GPU represented by 'device'.
 func (l *nvmllib) GetGPUDeviceEdits(d device.Device) (*cdi.ContainerEdits, error) {
-	device, err := newFullGPUDiscoverer(l.logger, l.driverRoot, l.nvidiaCTKPath, d)
+	device, err := newFullGPUDiscoverer(l.logger, l.devRoot, l.nvidiaCTKPath, d)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create device discoverer: %v", err)
 	}
@@ -64,13 +70,19 @@ func (l *nvmllib) GetGPUDeviceEdits(d device.Device) (*cdi.ContainerEdits, error
 		return nil, fmt.Errorf("failed to create container edits for device: %v", err)
 	}
 
+	env, err := l.getNUMAEnv(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine NUMA topology for device: %v", err)
+	}
+	editsForDevice.Env = append(editsForDevice.Env, env...)
+
 	return editsForDevice, nil
 }
 
 // byPathHookDiscoverer discovers the entities required for injecting by-path DRM device links
 type byPathHookDiscoverer struct {
 	logger        *logrus.Logger
-	driverRoot    string
+	devRoot       string
 	nvidiaCTKPath string
 	pciBusID      string
 	deviceNodes   discover.Discover
@@ -78,8 +90,9 @@ type byPathHookDiscoverer struct {
 
 var _ discover.Discover = (*byPathHookDiscoverer)(nil)
 
-// newFullGPUDiscoverer creates a discoverer for the full GPU defined by the specified device.
-func newFullGPUDiscoverer(logger *logrus.Logger, driverRoot string, nvidiaCTKPath string, d device.Device) (discover.Discover, error) {
+// newFullGPUDiscoverer creates a discoverer for the full GPU defined by the specified device. The device
+// nodes of the GPU are located relative to the specified dev root.
+func newFullGPUDiscoverer(logger *logrus.Logger, devRoot string, nvidiaCTKPath string, d device.Device) (discover.Discover, error) {
 	// TODO: The functionality to get device paths should be integrated into the go-nvlib/pkg/device.Device interface.
 	// This will allow reuse here and in other code where the paths are queried such as the NVIDIA device plugin.
 	minor, ret := d.GetMinorNumber()
@@ -92,7 +105,7 @@ func newFullGPUDiscoverer(logger *logrus.Logger, driverRoot string, nvidiaCTKPat
 	if ret != nvml.SUCCESS {
 		return nil, fmt.Errorf("error getting PCI info for device: %v", ret)
 	}
-	pciBusID := getBusID(pciInfo)
+	pciBusID := info.GetBusID(pciInfo)
 
 	drmDeviceNodes, err := drm.GetDeviceNodesByBusID(pciBusID)
 	if err != nil {
@@ -104,12 +117,12 @@ func newFullGPUDiscoverer(logger *logrus.Logger, driverRoot string, nvidiaCTKPat
 	deviceNodes := discover.NewCharDeviceDiscoverer(
 		logger,
 		deviceNodePaths,
-		driverRoot,
+		devRoot,
 	)
 
 	byPathHooks := &byPathHookDiscoverer{
 		logger:        logger,
-		driverRoot:    driverRoot,
+		devRoot:       devRoot,
 		nvidiaCTKPath: nvidiaCTKPath,
 		pciBusID:      pciBusID,
 		deviceNodes:   deviceNodes,
@@ -117,7 +130,7 @@ func newFullGPUDiscoverer(logger *logrus.Logger, driverRoot string, nvidiaCTKPat
 
 	deviceFolderPermissionHooks := newDeviceFolderPermissionHookDiscoverer(
 		logger,
-		driverRoot,
+		devRoot,
 		nvidiaCTKPath,
 		deviceNodes,
 	)
@@ -189,7 +202,7 @@ func (d *byPathHookDiscoverer) deviceNodeLinks() ([]string, error) {
 
 	var links []string
 	for _, c := range candidates {
-		linkPath := filepath.Join(d.driverRoot, c)
+		linkPath := filepath.Join(d.devRoot, c)
 		device, err := os.Readlink(linkPath)
 		if err != nil {
 			d.logger.Warningf("Failed to evaluate symlink %v; ignoring", linkPath)
@@ -210,21 +223,3 @@ func (d *byPathHookDiscoverer) deviceNodeLinks() ([]string, error) {
 
 	return links, nil
 }
-
-// getBusID provides a utility function that returns the string representation of the bus ID.
-func getBusID(p nvml.PciInfo) string {
-	var bytes []byte
-	for _, b := range p.BusId {
-		if byte(b) == '\x00' {
-			break
-		}
-		bytes = append(bytes, byte(b))
-	}
-	id := strings.ToLower(string(bytes))
-
-	if id != "0000" {
-		id = strings.TrimPrefix(id, "0000")
-	}
-
-	return id
-}
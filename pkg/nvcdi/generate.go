@@ -0,0 +1,94 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/spec"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/transform"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// GenerateSpec generates a CDI specification for the current system using the specified options.
+// This allows the spec generation of nvidia-ctk cdi generate to be embedded in other applications.
+//
+// As is the case for New, invalid options are returned as an error. If the selected mode uses NVML,
+// NVML is initialized for the duration of the call. If the mode does not generate a device named
+// "all", a device that combines the edits of all generated devices is added. The devices and edits
+// of the returned spec are ordered.
+func GenerateSpec(opts ...Option) (spec.Interface, error) {
+	lib, err := newWrapper(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if lib.nvmllib != nil {
+		if r := lib.nvmllib.Init(); r != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to initialize NVML: %v", r)
+		}
+		defer lib.nvmllib.Shutdown()
+	}
+
+	deviceSpecs, err := lib.GetAllDeviceSpecs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device CDI specs: %v", err)
+	}
+	deviceSpecs, err = withAllDevice(deviceSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	commonEdits, err := lib.GetCommonEdits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create edits common for entities: %v", err)
+	}
+
+	s, err := spec.New(
+		spec.WithVendor(lib.vendor),
+		spec.WithClass(lib.class),
+		spec.WithDeviceSpecs(deviceSpecs),
+		spec.WithEdits(*commonEdits.ContainerEdits),
+		spec.WithFormat(lib.format),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transform.NewSortTransformer().Transform(s.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("failed to order CDI spec: %v", err)
+	}
+
+	return s, nil
+}
+
+// withAllDevice adds a device named "all" that combines the specified devices if no such device exists.
+func withAllDevice(deviceSpecs []specs.Device) ([]specs.Device, error) {
+	for _, deviceSpec := range deviceSpecs {
+		if deviceSpec.Name == allDeviceName {
+			return deviceSpecs, nil
+		}
+	}
+
+	allDevice, err := MergeDeviceSpecs(deviceSpecs, allDeviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDI specification for %q device: %v", allDeviceName, err)
+	}
+	return append(deviceSpecs, allDevice), nil
+}
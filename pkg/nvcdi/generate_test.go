@@ -0,0 +1,99 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSpecInvalidOptions(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		options       []Option
+		expectedError string
+	}{
+		{
+			description:   "unknown mode",
+			options:       []Option{WithMode("not-a-mode")},
+			expectedError: `unknown mode "not-a-mode"`,
+		},
+		{
+			description:   "invalid device name strategy",
+			options:       []Option{WithDeviceNameStrategy("not-a-strategy")},
+			expectedError: "failed to create device namer",
+		},
+		{
+			description:   "invalid spec format",
+			options:       []Option{WithSpecFormat("toml")},
+			expectedError: `invalid spec format "toml"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			opts := append([]Option{WithLogger(logger)}, tc.options...)
+			s, err := GenerateSpec(opts...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedError)
+			require.Nil(t, s)
+		})
+	}
+}
+
+func TestWithAllDevice(t *testing.T) {
+	testCases := []struct {
+		description   string
+		deviceSpecs   []specs.Device
+		expectedNames []string
+	}{
+		{
+			description: "all device is added",
+			deviceSpecs: []specs.Device{
+				{Name: "0", ContainerEdits: specs.ContainerEdits{Env: []string{"A=B"}}},
+				{Name: "1", ContainerEdits: specs.ContainerEdits{Env: []string{"C=D"}}},
+			},
+			expectedNames: []string{"0", "1", "all"},
+		},
+		{
+			description: "existing all device is kept",
+			deviceSpecs: []specs.Device{
+				{Name: "0", ContainerEdits: specs.ContainerEdits{Env: []string{"A=B"}}},
+				{Name: "all", ContainerEdits: specs.ContainerEdits{Env: []string{"A=B"}}},
+			},
+			expectedNames: []string{"0", "all"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			deviceSpecs, err := withAllDevice(tc.deviceSpecs)
+			require.NoError(t, err)
+
+			var names []string
+			for _, d := range deviceSpecs {
+				names = append(names, d.Name)
+			}
+			require.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
@@ -45,18 +45,31 @@ func (l *nvmllib) GetAllDeviceSpecs() ([]specs.Device, error) {
 	}
 	deviceSpecs = append(deviceSpecs, gpuDeviceSpecs...)
 
-	migDeviceSpecs, err := l.getMigDeviceSpecs()
+	migDeviceSpecs, migAdditionalSpecs, err := l.getMigDeviceSpecs()
 	if err != nil {
 		return nil, err
 	}
 	deviceSpecs = append(deviceSpecs, migDeviceSpecs...)
 
+	if len(migAdditionalSpecs) == 0 {
+		return deviceSpecs, nil
+	}
+
+	// The MIG device aliases and parent devices repeat the edits of other
+	// devices and are not included in the "all" device.
+	allDevice, err := MergeDeviceSpecs(deviceSpecs, allDeviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDI specification for %q device: %v", allDeviceName, err)
+	}
+	deviceSpecs = append(deviceSpecs, migAdditionalSpecs...)
+	deviceSpecs = append(deviceSpecs, allDevice)
+
 	return deviceSpecs, nil
 }
 
 // GetCommonEdits generates a CDI specification that can be used for ANY devices
 func (l *nvmllib) GetCommonEdits() (*cdi.ContainerEdits, error) {
-	common, err := newCommonNVMLDiscoverer(l.logger, l.driverRoot, l.nvidiaCTKPath, l.nvmllib)
+	common, err := newCommonNVMLDiscoverer(l.logger, l.driverRoot, l.devRoot, l.nvidiaCTKPath, l.nvmllib)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discoverer for common entities: %v", err)
 	}
@@ -81,8 +94,16 @@ func (l *nvmllib) getGPUDeviceSpecs() ([]specs.Device, error) {
 	return deviceSpecs, err
 }
 
-func (l *nvmllib) getMigDeviceSpecs() ([]specs.Device, error) {
+// getMigDeviceSpecs returns the device specs for all MIG devices as well as any
+// additional specs (aliases and parent devices) that are enabled.
+func (l *nvmllib) getMigDeviceSpecs() ([]specs.Device, []specs.Device, error) {
 	var deviceSpecs []specs.Device
+	var additionalSpecs []specs.Device
+
+	parentDeviceSpecs := make(map[int][]specs.Device)
+	var parents []int
+	parentDevices := make(map[int]device.Device)
+
 	err := l.devicelib.VisitMigDevices(func(i int, d device.Device, j int, mig device.MigDevice) error {
 		deviceSpec, err := l.GetMIGDeviceSpecs(i, d, j, mig)
 		if err != nil {
@@ -90,10 +111,41 @@ func (l *nvmllib) getMigDeviceSpecs() ([]specs.Device, error) {
 		}
 		deviceSpecs = append(deviceSpecs, *deviceSpec)
 
+		if l.migDeviceAliases {
+			aliases, err := l.getMIGDeviceAliases(i, mig, *deviceSpec)
+			if err != nil {
+				return err
+			}
+			additionalSpecs = append(additionalSpecs, aliases...)
+		}
+
+		if _, ok := parentDevices[i]; !ok {
+			parents = append(parents, i)
+			parentDevices[i] = d
+		}
+		parentDeviceSpecs[i] = append(parentDeviceSpecs[i], *deviceSpec)
+
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate CDI edits for GPU devices: %v", err)
+		return nil, nil, fmt.Errorf("failed to generate CDI edits for GPU devices: %v", err)
 	}
-	return deviceSpecs, err
+
+	if !l.migParentDevices {
+		return deviceSpecs, additionalSpecs, nil
+	}
+
+	for _, i := range parents {
+		name, err := l.deviceNamer.GetDeviceName(i, parentDevices[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get device name: %v", err)
+		}
+		parentDevice, err := newMIGParentDevice(name+migParentDeviceSuffix, parentDeviceSpecs[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate CDI edits for MIG devices of GPU %v: %v", i, err)
+		}
+		additionalSpecs = append(additionalSpecs, parentDevice)
+	}
+
+	return deviceSpecs, additionalSpecs, nil
 }
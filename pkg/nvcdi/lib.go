@@ -17,7 +17,10 @@
 package nvcdi
 
 import (
+	"fmt"
+
 	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/spec"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
 	"github.com/sirupsen/logrus"
 	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
 	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/info"
@@ -29,26 +32,55 @@ type wrapper struct {
 
 	vendor string
 	class  string
+	format string
+	extras extras
+
+	nvmllib nvml.Interface
 }
 
 type nvcdilib struct {
 	logger        *logrus.Logger
 	nvmllib       nvml.Interface
+	nvmlLibPath   string
 	mode          string
 	devicelib     device.Interface
 	deviceNamer   DeviceNamer
 	driverRoot    string
+	devRoot       string
 	nvidiaCTKPath string
+	extras        extras
+	// deviceEnvTemplates are the templates of the environment variables added to each device.
+	deviceEnvTemplates []string
+	// migDeviceAliases indicates whether each MIG device is also included under its UUID and (gpu, gi, ci) names.
+	migDeviceAliases bool
+	// migParentDevices indicates whether a device that includes all MIG devices of a GPU is generated.
+	migParentDevices bool
+	// deviceNameStrategy is the strategy used to create the device namer if no namer is set.
+	deviceNameStrategy string
 
 	vendor string
 	class  string
+	format string
 
 	infolib info.Interface
 }
 
-// New creates a new nvcdi library
-func New(opts ...Option) Interface {
-	l := &nvcdilib{}
+// New creates a new nvcdi library. An error is returned if the specified options are invalid (e.g.
+// an invalid spec format or device name strategy).
+func New(opts ...Option) (Interface, error) {
+	w, err := newWrapper(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// newWrapper creates a new nvcdi library for the specified options. An error is returned if the
+// options are invalid.
+func newWrapper(opts ...Option) (*wrapper, error) {
+	l := &nvcdilib{
+		extras: defaultExtras(),
+	}
 	for _, opt := range opts {
 		opt(l)
 	}
@@ -59,11 +91,26 @@ func New(opts ...Option) Interface {
 		l.logger = logrus.StandardLogger()
 	}
 	if l.deviceNamer == nil {
-		l.deviceNamer, _ = NewDeviceNamer(DeviceNameStrategyIndex)
+		if l.deviceNameStrategy == "" {
+			l.deviceNameStrategy = DeviceNameStrategyIndex
+		}
+		namer, err := NewDeviceNamer(l.deviceNameStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create device namer: %v", err)
+		}
+		l.deviceNamer = namer
+	}
+	switch l.format {
+	case "", spec.FormatJSON, spec.FormatYAML:
+	default:
+		return nil, fmt.Errorf("invalid spec format %q", l.format)
 	}
 	if l.driverRoot == "" {
 		l.driverRoot = "/"
 	}
+	if l.devRoot == "" {
+		l.devRoot = l.driverRoot
+	}
 	if l.nvidiaCTKPath == "" {
 		l.nvidiaCTKPath = "/usr/bin/nvidia-ctk"
 	}
@@ -80,7 +127,7 @@ func New(opts ...Option) Interface {
 		lib = (*managementlib)(l)
 	case ModeNvml:
 		if l.nvmllib == nil {
-			l.nvmllib = nvml.New()
+			l.nvmllib = l.newNvml()
 		}
 		if l.devicelib == nil {
 			l.devicelib = device.New(device.WithNvml(l.nvmllib))
@@ -99,17 +146,27 @@ func New(opts ...Option) Interface {
 			l.class = "mofed"
 		}
 		lib = (*mofedlib)(l)
+	case ModeUtility:
+		if l.class == "" {
+			l.class = "utility"
+		}
+		if l.nvmllib == nil {
+			l.nvmllib = l.newNvml()
+		}
+		lib = (*utilitylib)(l)
 	default:
-		// TODO: We would like to return an error here instead of panicking
-		panic("Unknown mode")
+		return nil, fmt.Errorf("unknown mode %q", l.mode)
 	}
 
 	w := wrapper{
 		Interface: lib,
 		vendor:    l.vendor,
 		class:     l.class,
+		format:    l.format,
+		extras:    l.extras,
+		nvmllib:   l.nvmllib,
 	}
-	return &w
+	return &w, nil
 }
 
 // GetSpec combines the device specs and common edits from the wrapped Interface to a single spec.Interface.
@@ -129,10 +186,31 @@ func (l *wrapper) GetSpec() (spec.Interface, error) {
 		spec.WithEdits(*edits.ContainerEdits),
 		spec.WithVendor(l.vendor),
 		spec.WithClass(l.class),
+		spec.WithFormat(l.format),
 	)
 
 }
 
+// GetCommonEdits returns the common edits from the wrapped Interface. The optional edits that are
+// not included (e.g. the nvidia-persistenced socket) are removed.
+func (l *wrapper) GetCommonEdits() (*cdi.ContainerEdits, error) {
+	edits, err := l.Interface.GetCommonEdits()
+	if err != nil || edits == nil {
+		return edits, err
+	}
+	l.extras.apply(edits.ContainerEdits)
+	return edits, nil
+}
+
+// newNvml creates the NVML interface used by the library. If an NVML library path is set, the
+// library at this path is used.
+func (l *nvcdilib) newNvml() nvml.Interface {
+	if l.nvmlLibPath != "" {
+		return newNvmlLibrary(l.nvmlLibPath)
+	}
+	return nvml.New()
+}
+
 // resolveMode resolves the mode for CDI spec generation based on the current system.
 func (l *nvcdilib) resolveMode() (rmode string) {
 	if l.mode != ModeAuto {
@@ -24,6 +24,42 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestNewInvalidOptions(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		options       []Option
+		expectedError string
+	}{
+		{
+			description:   "unknown mode",
+			options:       []Option{WithMode("not-a-mode")},
+			expectedError: `unknown mode "not-a-mode"`,
+		},
+		{
+			description:   "invalid device name strategy",
+			options:       []Option{WithDeviceNameStrategy("not-a-strategy")},
+			expectedError: "failed to create device namer",
+		},
+		{
+			description:   "invalid spec format",
+			options:       []Option{WithSpecFormat("toml")},
+			expectedError: `invalid spec format "toml"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			opts := append([]Option{WithLogger(logger)}, tc.options...)
+			l, err := New(opts...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedError)
+			require.Nil(t, l)
+		})
+	}
+}
+
 func TestResolveMode(t *testing.T) {
 	logger, _ := testlog.NewNullLogger()
 
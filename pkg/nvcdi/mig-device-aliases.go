@@ -0,0 +1,99 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+const (
+	// allDeviceName is the name of the device that combines the edits of all GPU and MIG devices.
+	allDeviceName = "all"
+	// migParentDeviceSuffix is appended to the name of a GPU to construct the name of the device
+	// that includes all MIG devices of that GPU.
+	migParentDeviceSuffix = ":mig"
+)
+
+// getMIGDeviceAliases returns copies of the specified MIG device spec that are named using the
+// MIG device UUID (e.g. MIG-<uuid>) and the (gpu, gi, ci) tuple (e.g. 0:1.0).
+func (l *nvmllib) getMIGDeviceAliases(i int, mig device.MigDevice, deviceSpec specs.Device) ([]specs.Device, error) {
+	uuid, ret := mig.GetUUID()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting MIG device UUID: %v", ret)
+	}
+
+	gi, ret := mig.GetGpuInstanceId()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting GPU Instance ID: %v", ret)
+	}
+
+	ci, ret := mig.GetComputeInstanceId()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting Compute Instance ID: %v", ret)
+	}
+
+	return newDeviceAliases(deviceSpec, uuid, fmt.Sprintf("%d:%d.%d", i, gi, ci))
+}
+
+// newDeviceAliases creates a copy of the specified device for each of the specified names.
+// Names that match the name of the device are skipped.
+func newDeviceAliases(deviceSpec specs.Device, names ...string) ([]specs.Device, error) {
+	var aliases []specs.Device
+	for _, name := range names {
+		if name == deviceSpec.Name {
+			continue
+		}
+		alias, err := copyDeviceSpec(deviceSpec, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create alias %q for device %q: %v", name, deviceSpec.Name, err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// newMIGParentDevice creates a device with the specified name that includes the edits of all the
+// specified MIG devices.
+func newMIGParentDevice(name string, migDeviceSpecs []specs.Device) (specs.Device, error) {
+	merged, err := MergeDeviceSpecs(migDeviceSpecs, name)
+	if err != nil {
+		return specs.Device{}, err
+	}
+	return copyDeviceSpec(merged, name)
+}
+
+// copyDeviceSpec returns a deep copy of the specified device with the specified name.
+// A deep copy is required since the hooks of a device are modified in-place when transforming a spec.
+func copyDeviceSpec(deviceSpec specs.Device, name string) (specs.Device, error) {
+	data, err := json.Marshal(deviceSpec)
+	if err != nil {
+		return specs.Device{}, fmt.Errorf("failed to marshal device: %v", err)
+	}
+
+	var copied specs.Device
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return specs.Device{}, fmt.Errorf("failed to unmarshal device: %v", err)
+	}
+	copied.Name = name
+
+	return copied, nil
+}
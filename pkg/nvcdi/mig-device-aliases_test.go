@@ -0,0 +1,119 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDeviceAliases(t *testing.T) {
+	deviceSpec := specs.Device{
+		Name: "0:0",
+		ContainerEdits: specs.ContainerEdits{
+			DeviceNodes: []*specs.DeviceNode{
+				{Path: "/dev/nvidia0"},
+			},
+			Hooks: []*specs.Hook{
+				{HookName: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook"}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		names         []string
+		expectedNames []string
+	}{
+		{
+			description: "no names",
+		},
+		{
+			description:   "aliases are created",
+			names:         []string{"MIG-f6ff1b5b-6e37-5d1e-a2a1-4f8d4c5e9a01", "0:1.0"},
+			expectedNames: []string{"MIG-f6ff1b5b-6e37-5d1e-a2a1-4f8d4c5e9a01", "0:1.0"},
+		},
+		{
+			description:   "name of device is skipped",
+			names:         []string{"0:0", "0:1.0"},
+			expectedNames: []string{"0:1.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			aliases, err := newDeviceAliases(deviceSpec, tc.names...)
+			require.NoError(t, err)
+
+			var names []string
+			for _, alias := range aliases {
+				names = append(names, alias.Name)
+				require.EqualValues(t, deviceSpec.ContainerEdits, alias.ContainerEdits)
+
+				// Modifying the alias must not modify the original device.
+				alias.ContainerEdits.Hooks[0].Args = append(alias.ContainerEdits.Hooks[0].Args, "--extra")
+				alias.ContainerEdits.DeviceNodes[0].Path = "/dev/modified"
+				require.Equal(t, []string{"nvidia-ctk", "hook"}, deviceSpec.ContainerEdits.Hooks[0].Args)
+				require.Equal(t, "/dev/nvidia0", deviceSpec.ContainerEdits.DeviceNodes[0].Path)
+			}
+			require.EqualValues(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestNewMIGParentDevice(t *testing.T) {
+	hook := &specs.Hook{HookName: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook"}}
+	migDeviceSpecs := []specs.Device{
+		{
+			Name: "0:0",
+			ContainerEdits: specs.ContainerEdits{
+				Env:   []string{"MIG=0"},
+				Hooks: []*specs.Hook{hook},
+			},
+		},
+		{
+			Name: "0:1",
+			ContainerEdits: specs.ContainerEdits{
+				Env: []string{"MIG=1"},
+			},
+		},
+	}
+
+	parent, err := newMIGParentDevice("0"+migParentDeviceSuffix, migDeviceSpecs)
+	require.NoError(t, err)
+	require.EqualValues(t,
+		specs.Device{
+			Name: "0:mig",
+			ContainerEdits: specs.ContainerEdits{
+				Env: []string{"MIG=0", "MIG=1"},
+				Hooks: []*specs.Hook{
+					{HookName: "createContainer", Path: "/usr/bin/nvidia-ctk", Args: []string{"nvidia-ctk", "hook"}},
+				},
+			},
+		},
+		parent,
+	)
+
+	// The hooks of the parent device must not be shared with the MIG devices.
+	parent.ContainerEdits.Hooks[0].Args = append(parent.ContainerEdits.Hooks[0].Args, "--extra")
+	require.Equal(t, []string{"nvidia-ctk", "hook"}, hook.Args)
+
+	_, err = newMIGParentDevice("0:0", migDeviceSpecs)
+	require.Error(t, err)
+}
@@ -41,6 +41,12 @@ func (l *nvmllib) GetMIGDeviceSpecs(i int, d device.Device, j int, mig device.Mi
 		return nil, fmt.Errorf("failed to get device name: %v", err)
 	}
 
+	env, err := l.getDeviceEnv(name, fmt.Sprintf("%d:%d", i, j), d, mig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment variables for device: %v", err)
+	}
+	edits.Env = append(edits.Env, env...)
+
 	spec := specs.Device{
 		Name:           name,
 		ContainerEdits: *edits.ContainerEdits,
@@ -66,17 +72,24 @@ func (l *nvmllib) GetMIGDeviceEdits(parent device.Device, mig device.MigDevice)
 		return nil, fmt.Errorf("error getting Compute Instance ID: %v", ret)
 	}
 
-	editsForDevice, err := GetEditsForComputeInstance(l.logger, l.driverRoot, gpu, gi, ci)
+	editsForDevice, err := GetEditsForComputeInstance(l.logger, l.devRoot, gpu, gi, ci)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create container edits for MIG device: %v", err)
 	}
 
+	env, err := l.getNUMAEnv(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine NUMA topology for MIG device: %v", err)
+	}
+	editsForDevice.Env = append(editsForDevice.Env, env...)
+
 	return editsForDevice, nil
 }
 
-// GetEditsForComputeInstance returns the CDI edits for a particular compute instance defined by the (gpu, gi, ci) tuple
-func GetEditsForComputeInstance(logger *logrus.Logger, driverRoot string, gpu int, gi int, ci int) (*cdi.ContainerEdits, error) {
-	computeInstance, err := newComputeInstanceDiscoverer(logger, driverRoot, gpu, gi, ci)
+// GetEditsForComputeInstance returns the CDI edits for a particular compute instance defined by the (gpu, gi, ci) tuple.
+// The device nodes are located relative to the specified dev root.
+func GetEditsForComputeInstance(logger *logrus.Logger, devRoot string, gpu int, gi int, ci int) (*cdi.ContainerEdits, error) {
+	computeInstance, err := newComputeInstanceDiscoverer(logger, devRoot, gpu, gi, ci)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discoverer for Compute Instance: %v", err)
 	}
@@ -90,7 +103,7 @@ func GetEditsForComputeInstance(logger *logrus.Logger, driverRoot string, gpu in
 }
 
 // newComputeInstanceDiscoverer returns a discoverer for the specified compute instance
-func newComputeInstanceDiscoverer(logger *logrus.Logger, driverRoot string, gpu int, gi int, ci int) (discover.Discover, error) {
+func newComputeInstanceDiscoverer(logger *logrus.Logger, devRoot string, gpu int, gi int, ci int) (discover.Discover, error) {
 	parentPath := fmt.Sprintf("/dev/nvidia%d", gpu)
 
 	migCaps, err := nvcaps.NewMigCaps()
@@ -117,7 +130,7 @@ func newComputeInstanceDiscoverer(logger *logrus.Logger, driverRoot string, gpu
 			giCapDevicePath,
 			ciCapDevicePath,
 		},
-		driverRoot,
+		devRoot,
 	)
 
 	return deviceNodes, nil
@@ -0,0 +1,51 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/info/numa"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+// getNUMAEnv returns the environment variables with the NUMA node hints for the specified GPU.
+// On systems with coherent GPU memory (e.g. GH200 Grace Hopper) this includes the NUMA node of the
+// GPU memory so that applications can allocate memory on, or bind to, the correct node.
+func (l *nvmllib) getNUMAEnv(d device.Device) ([]string, error) {
+	minor, ret := d.GetMinorNumber()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting GPU device minor number: %v", ret)
+	}
+
+	pciInfo, ret := d.GetPciInfo()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting PCI info for device: %v", ret)
+	}
+
+	gpu, err := numa.GetGPU("/", info.GetBusID(pciInfo))
+	if err != nil {
+		return nil, err
+	}
+	if gpu.IsCoherent() && gpu.MemoryStatus != numa.StatusOnline {
+		l.logger.Warningf("Coherent memory of GPU %v is not online (status: %v); omitting memory NUMA node hint", gpu.BusID, gpu.MemoryStatus)
+	}
+
+	return gpu.Env(minor), nil
+}
@@ -0,0 +1,83 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"sync"
+
+	"github.com/NVIDIA/go-nvml/pkg/dl"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+const (
+	nvmlLibraryLoadFlags = dl.RTLD_LAZY | dl.RTLD_GLOBAL
+)
+
+// nvmlLibrary is an NVML interface that uses the NVML library at a specific path. Since the NVML
+// bindings load libnvidia-ml.so.1 by name, the library at the path is loaded before NVML is
+// initialized so that the name resolves to the already-loaded library.
+type nvmlLibrary struct {
+	nvml.Interface
+	sync.Mutex
+	path string
+	libs []*dl.DynamicLibrary
+}
+
+var _ nvml.Interface = (*nvmlLibrary)(nil)
+
+// newNvmlLibrary creates an NVML interface that uses the NVML library at the specified path.
+func newNvmlLibrary(path string) nvml.Interface {
+	return &nvmlLibrary{
+		Interface: nvml.New(),
+		path:      path,
+	}
+}
+
+// Init loads the NVML library from the configured path and initializes NVML.
+func (n *nvmlLibrary) Init() nvml.Return {
+	n.Lock()
+	defer n.Unlock()
+
+	lib := dl.New(n.path, nvmlLibraryLoadFlags)
+	if err := lib.Open(); err != nil {
+		return nvml.ERROR_LIBRARY_NOT_FOUND
+	}
+
+	ret := n.Interface.Init()
+	if ret != nvml.SUCCESS {
+		_ = lib.Close()
+		return ret
+	}
+	n.libs = append(n.libs, lib)
+	return ret
+}
+
+// Shutdown shuts down NVML and releases the library that was loaded by the matching call to Init.
+func (n *nvmlLibrary) Shutdown() nvml.Return {
+	n.Lock()
+	defer n.Unlock()
+
+	ret := n.Interface.Shutdown()
+	if ret != nvml.SUCCESS {
+		return ret
+	}
+	if len(n.libs) > 0 {
+		_ = n.libs[len(n.libs)-1].Close()
+		n.libs = n.libs[:len(n.libs)-1]
+	}
+	return ret
+}
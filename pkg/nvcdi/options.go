@@ -39,6 +39,14 @@ func WithDeviceNamer(namer DeviceNamer) Option {
 	}
 }
 
+// WithDeviceNameStrategy sets the strategy used to name the generated devices for the library.
+// This is ignored if a device namer is set using WithDeviceNamer.
+func WithDeviceNameStrategy(strategy string) Option {
+	return func(l *nvcdilib) {
+		l.deviceNameStrategy = strategy
+	}
+}
+
 // WithDriverRoot sets the driver root for the library
 func WithDriverRoot(root string) Option {
 	return func(l *nvcdilib) {
@@ -46,6 +54,15 @@ func WithDriverRoot(root string) Option {
 	}
 }
 
+// WithDevRoot sets the root relative to which the device nodes are located for the library. This is
+// required if the device nodes are not located under the driver root (e.g. for a containerized driver).
+// If this is not set, the driver root is used.
+func WithDevRoot(root string) Option {
+	return func(l *nvcdilib) {
+		l.devRoot = root
+	}
+}
+
 // WithLogger sets the logger for the library
 func WithLogger(logger *logrus.Logger) Option {
 	return func(l *nvcdilib) {
@@ -67,6 +84,21 @@ func WithNvmlLib(nvmllib nvml.Interface) Option {
 	}
 }
 
+// WithNvmlLibraryPath sets the path of the NVML library (libnvidia-ml.so.1) that is used if no nvml
+// library is set using WithNvmlLib. If this is not set, the library is located by the dynamic linker.
+func WithNvmlLibraryPath(path string) Option {
+	return func(l *nvcdilib) {
+		l.nvmlLibPath = path
+	}
+}
+
+// WithSpecFormat sets the output format (json or yaml) of the generated spec
+func WithSpecFormat(format string) Option {
+	return func(l *nvcdilib) {
+		l.format = format
+	}
+}
+
 // WithMode sets the discovery mode for the library
 func WithMode(mode string) Option {
 	return func(l *nvcdilib) {
@@ -87,3 +119,60 @@ func WithClass(class string) Option {
 		o.class = class
 	}
 }
+
+// WithPersistencedSocket sets whether the nvidia-persistenced socket is included in the common edits.
+// The socket is included by default.
+func WithPersistencedSocket(include bool) Option {
+	return func(l *nvcdilib) {
+		l.extras.persistencedSocket = include
+	}
+}
+
+// WithFabricManagerSocket sets whether the nvidia-fabricmanager socket is included in the common edits.
+// The socket is included by default.
+func WithFabricManagerSocket(include bool) Option {
+	return func(l *nvcdilib) {
+		l.extras.fabricManagerSocket = include
+	}
+}
+
+// WithFirmware sets whether the GSP firmware files are included in the common edits.
+// The firmware files are included by default.
+func WithFirmware(include bool) Option {
+	return func(l *nvcdilib) {
+		l.extras.firmware = include
+	}
+}
+
+// WithDriverBinaries sets the driver binaries (e.g. nvidia-smi) that are included in the common edits.
+// If this option is not specified, all driver binaries are included.
+func WithDriverBinaries(binaries ...string) Option {
+	return func(l *nvcdilib) {
+		l.extras.driverBinaries = append([]string{}, binaries...)
+	}
+}
+
+// WithDeviceEnvTemplates sets the templates (e.g. NVIDIA_DEVICE_UUID={{.UUID}}) of the environment
+// variables added to the edits of each GPU and MIG device. See DeviceEnvValues for the values that
+// can be referenced.
+func WithDeviceEnvTemplates(templates ...string) Option {
+	return func(l *nvcdilib) {
+		l.deviceEnvTemplates = append([]string{}, templates...)
+	}
+}
+
+// WithMIGDeviceAliases sets whether each MIG device is also included in the spec under the names
+// MIG-<uuid> and <gpu>:<gi>.<ci> in addition to the name generated by the device namer.
+func WithMIGDeviceAliases(enabled bool) Option {
+	return func(l *nvcdilib) {
+		l.migDeviceAliases = enabled
+	}
+}
+
+// WithMIGParentDevices sets whether a device named <gpu>:mig that includes all MIG devices of
+// the GPU is included in the spec for each MIG-enabled GPU.
+func WithMIGParentDevices(enabled bool) Option {
+	return func(l *nvcdilib) {
+		l.migParentDevices = enabled
+	}
+}
@@ -0,0 +1,139 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// specLock is an advisory lock used to serialize writes to a spec. The lock file also records
+// the generation of the spec, which is incremented each time the spec is replaced.
+type specLock struct {
+	file *os.File
+}
+
+// lockSpec acquires an exclusive lock on the lock file for the spec at the specified path.
+// The call blocks until the lock is acquired.
+func lockSpec(path string) (*specLock, error) {
+	file, err := os.OpenFile(lockFilePath(path), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock spec: %w", err)
+	}
+	l := specLock{
+		file: file,
+	}
+	return &l, nil
+}
+
+// release releases the lock.
+func (l *specLock) release() {
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+}
+
+// incrementGeneration increments the generation recorded in the lock file.
+func (l *specLock) incrementGeneration() error {
+	generation, err := readGeneration(l.file)
+	if err != nil {
+		// An invalid generation is reset instead of failing the write of the spec.
+		generation = 0
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek lock file: %w", err)
+	}
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate lock file: %w", err)
+	}
+	if _, err := fmt.Fprintf(l.file, "%d\n", generation+1); err != nil {
+		return fmt.Errorf("failed to write generation: %w", err)
+	}
+	return nil
+}
+
+// GetGeneration returns the generation of the spec at the specified path. This is incremented
+// each time the spec is replaced by Save and is 0 if the spec has not been saved.
+func GetGeneration(path string) (uint64, error) {
+	file, err := os.Open(lockFilePath(path))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer file.Close()
+
+	return readGeneration(file)
+}
+
+// readGeneration reads the generation from the specified lock file.
+func readGeneration(file *os.File) (uint64, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek lock file: %w", err)
+	}
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	value := strings.TrimSpace(string(contents))
+	if value == "" {
+		return 0, nil
+	}
+	generation, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid generation %q: %w", value, err)
+	}
+	return generation, nil
+}
+
+// lockFilePath returns the path of the lock file for the spec at the specified path. Since the
+// file does not have a .json or .yaml extension, it is ignored by CDI registries.
+func lockFilePath(path string) string {
+	return path + ".lock"
+}
+
+// sameContents checks whether the specified files have the same contents.
+func sameContents(path string, other string) (bool, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	otherContents, err := os.ReadFile(other)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(contents, otherContents), nil
+}
+
+// syncPath flushes the specified file or directory to disk.
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -39,6 +39,10 @@ func New(opts ...Option) (Interface, error) {
 }
 
 // Save writes the spec to the specified path and overwrites the file if it exists.
+// Concurrent writers (e.g. a systemd unit and an operator) are serialized using an advisory
+// lock and the spec is written to a staging file that is synced and renamed into place so that
+// readers never observe a partially-written spec. If the contents of the spec are unchanged, the
+// existing file is not replaced. Otherwise the generation of the spec is incremented.
 func (s *spec) Save(path string) error {
 	path, err := s.normalizePath(path)
 	if err != nil {
@@ -46,12 +50,39 @@ func (s *spec) Save(path string) error {
 	}
 
 	specDir := filepath.Dir(path)
-	registry := cdi.GetRegistry(
-		cdi.WithAutoRefresh(false),
-		cdi.WithSpecDirs(specDir),
-	)
+	if err := os.MkdirAll(specDir, 0755); err != nil {
+		return fmt.Errorf("failed to create spec directory: %w", err)
+	}
+
+	lock, err := lockSpec(path)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	// The staging directory is created in the spec directory to ensure that the staged spec can
+	// be renamed into place. Subdirectories of spec directories are ignored by CDI registries.
+	staged, err := s.stage(specDir, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(filepath.Dir(staged))
+
+	if unchanged, err := sameContents(staged, path); err == nil && unchanged {
+		return nil
+	}
+
+	if err := syncPath(staged); err != nil {
+		return fmt.Errorf("failed to sync spec: %w", err)
+	}
+	if err := os.Rename(staged, path); err != nil {
+		return fmt.Errorf("failed to replace spec: %w", err)
+	}
+	if err := syncPath(specDir); err != nil {
+		return fmt.Errorf("failed to sync spec directory: %w", err)
+	}
 
-	return registry.SpecDB().WriteSpec(s.Raw(), filepath.Base(path))
+	return lock.incrementGeneration()
 }
 
 // WriteTo writes the spec to the specified writer.
@@ -62,28 +93,46 @@ func (s *spec) WriteTo(w io.Writer) (int64, error) {
 	}
 
 	path, _ := s.normalizePath(name)
-	tmpFile, err := os.CreateTemp("", "*"+filepath.Base(path))
+	staged, err := s.stage("", filepath.Base(path))
 	if err != nil {
 		return 0, err
 	}
-	defer os.Remove(tmpFile.Name())
+	defer os.RemoveAll(filepath.Dir(staged))
 
-	if err := s.Save(tmpFile.Name()); err != nil {
-		return 0, err
+	r, err := os.Open(staged)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open staged spec: %w", err)
 	}
+	defer r.Close()
 
-	err = tmpFile.Close()
+	return io.Copy(w, r)
+}
+
+// stage validates the spec and writes it to a file with the specified name in a new temporary
+// directory in dir. If dir is empty, the default directory for temporary files is used. The
+// path of the staged file is returned and the caller is responsible for removing its directory.
+func (s *spec) stage(dir string, name string) (string, error) {
+	stagingDir, err := os.MkdirTemp(dir, "."+name+".tmp-")
 	if err != nil {
-		return 0, fmt.Errorf("failed to close temporary file: %w", err)
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
 	}
 
-	r, err := os.Open(tmpFile.Name())
+	// A dedicated cache is used instead of the global registry so that concurrent
+	// writers in the same process do not reconfigure each other's spec directories.
+	cache, err := cdi.NewCache(
+		cdi.WithAutoRefresh(false),
+		cdi.WithSpecDirs(stagingDir),
+	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open temporary file: %w", err)
+		os.RemoveAll(stagingDir)
+		return "", fmt.Errorf("failed to create CDI cache: %w", err)
+	}
+	if err := cache.WriteSpec(s.Raw(), name); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", err
 	}
-	defer r.Close()
 
-	return io.Copy(w, r)
+	return filepath.Join(stagingDir, name), nil
 }
 
 // Raw returns a pointer to the raw spec.
@@ -0,0 +1,142 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSpec(t *testing.T, device string) Interface {
+	s, err := New(
+		WithDeviceSpecs([]specs.Device{
+			{
+				Name: device,
+				ContainerEdits: specs.ContainerEdits{
+					Env: []string{"DEVICE=" + device},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSave(t *testing.T) {
+	specDir := t.TempDir()
+	path := filepath.Join(specDir, "nvidia.yaml")
+
+	generation, err := GetGeneration(path)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, generation)
+
+	require.NoError(t, newTestSpec(t, "0").Save(path))
+	generation, err = GetGeneration(path)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, generation)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// Saving an unchanged spec does not replace the file.
+	require.NoError(t, newTestSpec(t, "0").Save(path))
+	generation, err = GetGeneration(path)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, generation)
+	unchanged, err := os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(info, unchanged))
+
+	require.NoError(t, newTestSpec(t, "1").Save(path))
+	generation, err = GetGeneration(path)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, generation)
+
+	saved, err := cdi.ReadSpec(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, "1", saved.Devices[0].Name)
+
+	entries, err := os.ReadDir(specDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.ElementsMatch(t, []string{"nvidia.yaml", "nvidia.yaml.lock"}, names)
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	specDir := t.TempDir()
+	path := filepath.Join(specDir, "nvidia.yaml")
+
+	writers := 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- newTestSpec(t, fmt.Sprintf("%d", i)).Save(path)
+		}(i)
+	}
+
+	// Readers must always observe a complete spec.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			_, err := cdi.ReadSpec(path, 0)
+			require.NoError(t, err)
+		}
+	}()
+
+	wg.Wait()
+	<-done
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	saved, err := cdi.ReadSpec(path, 0)
+	require.NoError(t, err)
+	require.Len(t, saved.Devices, 1)
+
+	generation, err := GetGeneration(path)
+	require.NoError(t, err)
+	require.EqualValues(t, writers, generation)
+}
+
+func TestWriteTo(t *testing.T) {
+	buffer := bytes.Buffer{}
+	_, err := newTestSpec(t, "0").WriteTo(&buffer)
+	require.NoError(t, err)
+
+	raw, err := cdi.ParseSpec(buffer.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "nvidia.com/gpu", raw.Kind)
+}
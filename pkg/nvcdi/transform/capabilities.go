@@ -0,0 +1,249 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+// capabilityFiles maps driver files to the capabilities that require them.
+// Libraries are matched as a prefix of the file name (e.g. libcuda.so matches
+// libcuda.so.1 and libcuda.so.525.60.13), other files are matched by name.
+// Files that are not listed here are always considered required.
+var capabilityFiles = map[string][]image.DriverCapability{
+	// Utility binaries and libraries
+	"nvidia-smi":          {image.DriverCapabilityUtility},
+	"nvidia-debugdump":    {image.DriverCapabilityUtility},
+	"nvidia-persistenced": {image.DriverCapabilityUtility},
+	"libnvidia-ml.so":     {image.DriverCapabilityUtility},
+	"libnvidia-cfg.so":    {image.DriverCapabilityUtility},
+	"libnvidia-nscq.so":   {image.DriverCapabilityUtility},
+
+	// Compute binaries and libraries
+	"nvidia-cuda-mps-control":      {image.DriverCapabilityCompute},
+	"nvidia-cuda-mps-server":       {image.DriverCapabilityCompute},
+	"libcuda.so":                   {image.DriverCapabilityCompute},
+	"libcudadebugger.so":           {image.DriverCapabilityCompute},
+	"libnvidia-opencl.so":          {image.DriverCapabilityCompute},
+	"libnvidia-gpucomp.so":         {image.DriverCapabilityCompute, image.DriverCapabilityGraphics},
+	"libnvidia-ptxjitcompiler.so":  {image.DriverCapabilityCompute},
+	"libnvidia-fatbinaryloader.so": {image.DriverCapabilityCompute},
+	"libnvidia-allocator.so":       {image.DriverCapabilityCompute},
+	"libnvidia-compiler.so":        {image.DriverCapabilityCompute},
+	"libnvidia-nvvm.so":            {image.DriverCapabilityCompute},
+	"libnvidia-pkcs11.so":          {image.DriverCapabilityCompute},
+	"libnvidia-pkcs11-openssl3.so": {image.DriverCapabilityCompute},
+
+	// Video libraries
+	"libvdpau_nvidia.so":       {image.DriverCapabilityVideo},
+	"libnvidia-encode.so":      {image.DriverCapabilityVideo},
+	"libnvidia-opticalflow.so": {image.DriverCapabilityVideo},
+	"libnvcuvid.so":            {image.DriverCapabilityVideo},
+
+	// Graphics libraries and config files
+	"libnvidia-eglcore.so":         {image.DriverCapabilityGraphics},
+	"libnvidia-glcore.so":          {image.DriverCapabilityGraphics},
+	"libnvidia-tls.so":             {image.DriverCapabilityGraphics},
+	"libnvidia-glsi.so":            {image.DriverCapabilityGraphics},
+	"libnvidia-fbc.so":             {image.DriverCapabilityGraphics},
+	"libnvidia-ifr.so":             {image.DriverCapabilityGraphics},
+	"libnvidia-rtcore.so":          {image.DriverCapabilityGraphics},
+	"libnvoptix.so":                {image.DriverCapabilityGraphics},
+	"libGLX_nvidia.so":             {image.DriverCapabilityGraphics},
+	"libEGL_nvidia.so":             {image.DriverCapabilityGraphics},
+	"libGLESv2_nvidia.so":          {image.DriverCapabilityGraphics},
+	"libGLESv1_CM_nvidia.so":       {image.DriverCapabilityGraphics},
+	"libnvidia-glvkspirv.so":       {image.DriverCapabilityGraphics},
+	"libnvidia-cbl.so":             {image.DriverCapabilityGraphics},
+	"libnvidia-egl-gbm.so":         {image.DriverCapabilityGraphics},
+	"libnvidia-egl-wayland.so":     {image.DriverCapabilityGraphics},
+	"libnvidia-vulkan-producer.so": {image.DriverCapabilityGraphics},
+	"10_nvidia.json":               {image.DriverCapabilityGraphics},
+	"nvidia_icd.json":              {image.DriverCapabilityGraphics},
+	"nvidia_layers.json":           {image.DriverCapabilityGraphics},
+	"15_nvidia_gbm.json":           {image.DriverCapabilityGraphics},
+	"10_nvidia_wayland.json":       {image.DriverCapabilityGraphics},
+	"nvoptix.bin":                  {image.DriverCapabilityGraphics},
+
+	// Display libraries
+	"nvidia_drv.so":          {image.DriverCapabilityDisplay},
+	"libglxserver_nvidia.so": {image.DriverCapabilityDisplay},
+
+	// NGX libraries
+	"libnvidia-ngx.so": {image.DriverCapabilityNgx},
+}
+
+// drmDevicePath is the path to DRM device nodes (and their by-path symlinks).
+// These are only required for the graphics and display capabilities.
+const drmDevicePath = "/dev/dri"
+
+type capabilitiesTransformer struct {
+	capabilities image.DriverCapabilities
+}
+
+var _ Transformer = (*capabilitiesTransformer)(nil)
+
+// NewCapabilitiesTransformer creates a transformer that removes the edits from
+// a CDI spec that are not required for the specified driver capabilities. If no
+// capabilities are specified, or the 'all' capability is included, this
+// transformer is a no-op.
+func NewCapabilitiesTransformer(capabilities image.DriverCapabilities) Transformer {
+	if len(capabilities) == 0 || capabilities.Has(image.DriverCapabilityAll) {
+		return NewNoopTransformer()
+	}
+
+	t := capabilitiesTransformer{
+		capabilities: capabilities,
+	}
+	return t
+}
+
+// Transform removes device nodes, mounts, and hook arguments that are not
+// required for the selected capabilities from the spec.
+func (t capabilitiesTransformer) Transform(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	for i := range spec.Devices {
+		t.applyToEdits(&spec.Devices[i].ContainerEdits)
+	}
+	t.applyToEdits(&spec.ContainerEdits)
+
+	return nil
+}
+
+func (t capabilitiesTransformer) applyToEdits(edits *specs.ContainerEdits) {
+	var deviceNodes []*specs.DeviceNode
+	for _, dn := range edits.DeviceNodes {
+		if !t.isRequired(dn.Path) {
+			continue
+		}
+		deviceNodes = append(deviceNodes, dn)
+	}
+	edits.DeviceNodes = deviceNodes
+
+	var mounts []*specs.Mount
+	for _, m := range edits.Mounts {
+		if !t.isRequired(m.ContainerPath) {
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	edits.Mounts = mounts
+
+	var hooks []*specs.Hook
+	for _, h := range edits.Hooks {
+		h, required := t.transformHook(h)
+		if !required {
+			continue
+		}
+		hooks = append(hooks, h)
+	}
+	edits.Hooks = hooks
+}
+
+// hookValueFlags lists the flags of the nvidia-ctk hooks that take a value as
+// the following argument. Other flags (e.g. --debug) are boolean flags and
+// values can only be specified as --flag=value.
+var hookValueFlags = map[string]bool{
+	"--container-spec": true,
+	"--csv-filename":   true,
+	"--folder":         true,
+	"--host-root":      true,
+	"--link":           true,
+	"--mode":           true,
+	"--path":           true,
+}
+
+// transformHook removes the flag arguments from a hook that refer to files
+// that are not required. The hook itself is not required if all its path
+// arguments were removed.
+func (t capabilitiesTransformer) transformHook(hook *specs.Hook) (*specs.Hook, bool) {
+	var args []string
+	var removed int
+	var remainingPaths int
+	for i := 0; i < len(hook.Args); i++ {
+		arg := hook.Args[i]
+
+		var flagArgs []string
+		var value string
+		switch {
+		case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+			flagArgs = []string{arg}
+			value = arg[strings.Index(arg, "=")+1:]
+		case hookValueFlags[arg] && i+1 < len(hook.Args):
+			flagArgs = []string{arg, hook.Args[i+1]}
+			value = hook.Args[i+1]
+			i++
+		default:
+			args = append(args, arg)
+			continue
+		}
+
+		if !t.isRequiredArg(value) {
+			removed++
+			continue
+		}
+		if strings.Contains(value, "/") {
+			remainingPaths++
+		}
+		args = append(args, flagArgs...)
+	}
+
+	if removed == 0 {
+		return hook, true
+	}
+	if remainingPaths == 0 {
+		return nil, false
+	}
+
+	transformed := *hook
+	transformed.Args = args
+	return &transformed, true
+}
+
+// isRequiredArg checks whether a hook argument is required. For arguments of
+// the form <target>::<link> both paths are checked.
+func (t capabilitiesTransformer) isRequiredArg(arg string) bool {
+	for _, path := range strings.Split(arg, "::") {
+		if !t.isRequired(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRequired checks whether the specified file is required for the selected capabilities.
+func (t capabilitiesTransformer) isRequired(path string) bool {
+	if path == drmDevicePath || strings.HasPrefix(path, drmDevicePath+"/") {
+		return t.capabilities.Any(image.DriverCapabilityGraphics, image.DriverCapabilityDisplay)
+	}
+
+	base := filepath.Base(path)
+	for name, capabilities := range capabilityFiles {
+		if base != name && !(strings.HasSuffix(name, ".so") && strings.HasPrefix(base, name+".")) {
+			continue
+		}
+		return t.capabilities.Any(capabilities...)
+	}
+	return true
+}
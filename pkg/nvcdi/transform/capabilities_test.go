@@ -0,0 +1,205 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/image"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesTransformer(t *testing.T) {
+	spec := func() *specs.Spec {
+		return &specs.Spec{
+			Devices: []specs.Device{
+				{
+					Name: "0",
+					ContainerEdits: specs.ContainerEdits{
+						DeviceNodes: []*specs.DeviceNode{
+							{Path: "/dev/nvidia0"},
+							{Path: "/dev/dri/card1"},
+							{Path: "/dev/dri/renderD128"},
+						},
+						Hooks: []*specs.Hook{
+							{
+								HookName: "createContainer",
+								Path:     "/usr/bin/nvidia-ctk",
+								Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "../card1::/dev/dri/by-path/pci-0000:00:00.0-card"},
+							},
+						},
+					},
+				},
+			},
+			ContainerEdits: specs.ContainerEdits{
+				Mounts: []*specs.Mount{
+					{HostPath: "/usr/lib64/libcuda.so.520.61.05", ContainerPath: "/usr/lib64/libcuda.so.520.61.05"},
+					{HostPath: "/usr/lib64/libnvidia-ml.so.520.61.05", ContainerPath: "/usr/lib64/libnvidia-ml.so.520.61.05"},
+					{HostPath: "/usr/lib64/libGLX_nvidia.so.520.61.05", ContainerPath: "/usr/lib64/libGLX_nvidia.so.520.61.05"},
+					{HostPath: "/usr/lib64/libnvidia-eglcore.so.520.61.05", ContainerPath: "/usr/lib64/libnvidia-eglcore.so.520.61.05"},
+					{HostPath: "/etc/vulkan/icd.d/nvidia_icd.json", ContainerPath: "/etc/vulkan/icd.d/nvidia_icd.json"},
+					{HostPath: "/lib/firmware/nvidia/520.61.05/gsp.bin", ContainerPath: "/lib/firmware/nvidia/520.61.05/gsp.bin"},
+				},
+				Hooks: []*specs.Hook{
+					{
+						HookName: "createContainer",
+						Path:     "/usr/bin/nvidia-ctk",
+						Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "libcuda.so.520.61.05::/usr/lib64/libcuda.so.1", "--link", "libGLX_nvidia.so.520.61.05::/usr/lib64/libGLX_indirect.so.0"},
+					},
+					{
+						HookName: "createContainer",
+						Path:     "/usr/bin/nvidia-ctk",
+						Args:     []string{"nvidia-ctk", "hook", "chmod", "--mode", "755", "--path", "/dev/dri"},
+					},
+					{
+						HookName: "createContainer",
+						Path:     "/usr/bin/nvidia-ctk",
+						Args:     []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		description  string
+		capabilities image.DriverCapabilities
+		spec         *specs.Spec
+		expectedSpec *specs.Spec
+	}{
+		{
+			description:  "nil spec",
+			capabilities: image.DriverCapabilities{"compute": true},
+			spec:         nil,
+			expectedSpec: nil,
+		},
+		{
+			description:  "no capabilities is no-op",
+			spec:         spec(),
+			expectedSpec: spec(),
+		},
+		{
+			description:  "all capabilities is no-op",
+			capabilities: image.DriverCapabilities{"all": true},
+			spec:         spec(),
+			expectedSpec: spec(),
+		},
+		{
+			description:  "compute and utility removes graphics edits",
+			capabilities: image.DriverCapabilities{"compute": true, "utility": true},
+			spec:         spec(),
+			expectedSpec: &specs.Spec{
+				Devices: []specs.Device{
+					{
+						Name: "0",
+						ContainerEdits: specs.ContainerEdits{
+							DeviceNodes: []*specs.DeviceNode{
+								{Path: "/dev/nvidia0"},
+							},
+						},
+					},
+				},
+				ContainerEdits: specs.ContainerEdits{
+					Mounts: []*specs.Mount{
+						{HostPath: "/usr/lib64/libcuda.so.520.61.05", ContainerPath: "/usr/lib64/libcuda.so.520.61.05"},
+						{HostPath: "/usr/lib64/libnvidia-ml.so.520.61.05", ContainerPath: "/usr/lib64/libnvidia-ml.so.520.61.05"},
+						{HostPath: "/lib/firmware/nvidia/520.61.05/gsp.bin", ContainerPath: "/lib/firmware/nvidia/520.61.05/gsp.bin"},
+					},
+					Hooks: []*specs.Hook{
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "libcuda.so.520.61.05::/usr/lib64/libcuda.so.1"},
+						},
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+						},
+					},
+				},
+			},
+		},
+		{
+			description:  "graphics removes compute and utility libraries",
+			capabilities: image.DriverCapabilities{"graphics": true},
+			spec:         spec(),
+			expectedSpec: func() *specs.Spec {
+				s := spec()
+				s.ContainerEdits.Mounts = []*specs.Mount{
+					{HostPath: "/usr/lib64/libGLX_nvidia.so.520.61.05", ContainerPath: "/usr/lib64/libGLX_nvidia.so.520.61.05"},
+					{HostPath: "/usr/lib64/libnvidia-eglcore.so.520.61.05", ContainerPath: "/usr/lib64/libnvidia-eglcore.so.520.61.05"},
+					{HostPath: "/etc/vulkan/icd.d/nvidia_icd.json", ContainerPath: "/etc/vulkan/icd.d/nvidia_icd.json"},
+					{HostPath: "/lib/firmware/nvidia/520.61.05/gsp.bin", ContainerPath: "/lib/firmware/nvidia/520.61.05/gsp.bin"},
+				}
+				s.ContainerEdits.Hooks[0].Args = []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "libGLX_nvidia.so.520.61.05::/usr/lib64/libGLX_indirect.so.0"}
+				return s
+			}(),
+		},
+		{
+			description:  "boolean flags do not consume the following argument",
+			capabilities: image.DriverCapabilities{"graphics": true},
+			spec: &specs.Spec{
+				ContainerEdits: specs.ContainerEdits{
+					Hooks: []*specs.Hook{
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "--debug", "hook", "create-symlinks", "--debug", "--link", "libcuda.so.520.61.05::/usr/lib64/libcuda.so.1", "--link=libGLX_nvidia.so.520.61.05::/usr/lib64/libGLX_indirect.so.0"},
+						},
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{
+				ContainerEdits: specs.ContainerEdits{
+					Hooks: []*specs.Hook{
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "--debug", "hook", "create-symlinks", "--debug", "--link=libGLX_nvidia.so.520.61.05::/usr/lib64/libGLX_indirect.so.0"},
+						},
+					},
+				},
+			},
+		},
+		{
+			description:  "flags with inline values are removed",
+			capabilities: image.DriverCapabilities{"compute": true},
+			spec: &specs.Spec{
+				ContainerEdits: specs.ContainerEdits{
+					Hooks: []*specs.Hook{
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link=libGLX_nvidia.so.520.61.05::/usr/lib64/libGLX_indirect.so.0"},
+						},
+					},
+				},
+			},
+			expectedSpec: &specs.Spec{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := NewCapabilitiesTransformer(tc.capabilities).Transform(tc.spec)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedSpec, tc.spec)
+		})
+	}
+}
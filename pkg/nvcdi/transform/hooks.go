@@ -0,0 +1,130 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+// hookArgsTransformer updates the arguments of the hooks in a CDI spec.
+type hookArgsTransformer struct {
+	update func(path string, args []string) []string
+}
+
+var _ Transformer = (*hookArgsTransformer)(nil)
+
+// NewHookPrivilegesTransformer creates a transformer that updates the nvidia-ctk hooks in a CDI
+// spec to run with the specified privileges. If no privileges are specified, this transformer is
+// a no-op.
+func NewHookPrivilegesTransformer(p *privileges.Privileges) Transformer {
+	if p == nil {
+		return NewNoopTransformer()
+	}
+
+	t := hookArgsTransformer{
+		update: p.HookArgs,
+	}
+	return t
+}
+
+// NewHookPolicyTransformer creates a transformer that updates the nvidia-ctk hooks in a CDI spec
+// to run with the specified timeout and failure policy. If no policy is specified, this
+// transformer is a no-op.
+func NewHookPolicyTransformer(p *hookpolicy.Policy) Transformer {
+	if p == nil {
+		return NewNoopTransformer()
+	}
+
+	t := hookArgsTransformer{
+		update: p.HookArgs,
+	}
+	return t
+}
+
+// Transform updates the arguments of the hooks in the spec.
+func (t hookArgsTransformer) Transform(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	for i := range spec.Devices {
+		t.applyToEdits(&spec.Devices[i].ContainerEdits)
+	}
+	t.applyToEdits(&spec.ContainerEdits)
+
+	return nil
+}
+
+func (t hookArgsTransformer) applyToEdits(edits *specs.ContainerEdits) {
+	for _, h := range edits.Hooks {
+		if h == nil {
+			continue
+		}
+		h.Args = t.update(h.Path, h.Args)
+	}
+}
+
+// disabledHooksTransformer removes the disabled nvidia-ctk hooks from a CDI spec.
+type disabledHooksTransformer struct {
+	disabled map[string]bool
+}
+
+var _ Transformer = (*disabledHooksTransformer)(nil)
+
+// NewDisabledHooksTransformer creates a transformer that removes the nvidia-ctk hooks with the
+// specified names (e.g. create-symlinks) from a CDI spec. If no hooks are specified, this transformer
+// is a no-op.
+func NewDisabledHooksTransformer(names ...string) Transformer {
+	if len(names) == 0 {
+		return NewNoopTransformer()
+	}
+
+	t := disabledHooksTransformer{
+		disabled: make(map[string]bool),
+	}
+	for _, name := range names {
+		t.disabled[name] = true
+	}
+	return t
+}
+
+// Transform removes the disabled hooks from the spec.
+func (t disabledHooksTransformer) Transform(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	for i := range spec.Devices {
+		t.applyToEdits(&spec.Devices[i].ContainerEdits)
+	}
+	t.applyToEdits(&spec.ContainerEdits)
+
+	return nil
+}
+
+func (t disabledHooksTransformer) applyToEdits(edits *specs.ContainerEdits) {
+	var hooks []*specs.Hook
+	for _, h := range edits.Hooks {
+		if h != nil && t.disabled[privileges.NVIDIACTKHookName(h.Path, h.Args)] {
+			continue
+		}
+		hooks = append(hooks, h)
+	}
+	edits.Hooks = hooks
+}
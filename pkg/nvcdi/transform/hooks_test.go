@@ -0,0 +1,141 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/hookpolicy"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/privileges"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func hooksTestSpec() *specs.Spec {
+	return &specs.Spec{
+		Devices: []specs.Device{
+			{
+				Name: "0",
+				ContainerEdits: specs.ContainerEdits{
+					Hooks: []*specs.Hook{
+						{
+							HookName: "createContainer",
+							Path:     "/usr/bin/nvidia-ctk",
+							Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "../card1::/dev/dri/by-path/pci-0000:00:00.0-card"},
+						},
+					},
+				},
+			},
+		},
+		ContainerEdits: specs.ContainerEdits{
+			Hooks: []*specs.Hook{
+				{
+					HookName: "createContainer",
+					Path:     "/usr/bin/nvidia-ctk",
+					Args:     []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64"},
+				},
+				{
+					HookName: "createContainer",
+					Path:     "/usr/local/bin/custom-hook",
+					Args:     []string{"custom-hook", "hook"},
+				},
+			},
+		},
+	}
+}
+
+func TestHookPrivilegesTransformer(t *testing.T) {
+	spec := hooksTestSpec
+
+	testCases := []struct {
+		description  string
+		user         string
+		capabilities []string
+		expected     func() *specs.Spec
+	}{
+		{
+			description: "no user is no-op",
+			expected:    spec,
+		},
+		{
+			description:  "nvidia-ctk hooks are updated",
+			user:         "1000:1001",
+			capabilities: []string{"CAP_DAC_OVERRIDE", "CAP_FOWNER"},
+			expected: func() *specs.Spec {
+				s := spec()
+				s.Devices[0].ContainerEdits.Hooks[0].Args = []string{"nvidia-ctk", "hook", "--user=1000:1001", "--capabilities=CAP_DAC_OVERRIDE,CAP_FOWNER", "create-symlinks", "--link", "../card1::/dev/dri/by-path/pci-0000:00:00.0-card"}
+				s.ContainerEdits.Hooks[0].Args = []string{"nvidia-ctk", "hook", "--user=1000:1001", "--capabilities=CAP_DAC_OVERRIDE,CAP_FOWNER", "update-ldcache", "--folder", "/usr/lib64"}
+				return s
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			p, err := privileges.New(tc.user, tc.capabilities)
+			require.NoError(t, err)
+
+			s := spec()
+			err = NewHookPrivilegesTransformer(p).Transform(s)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expected(), s)
+		})
+	}
+}
+
+func TestHookPolicyTransformer(t *testing.T) {
+	spec := hooksTestSpec
+
+	p, err := hookpolicy.New(0, "")
+	require.NoError(t, err)
+	s := spec()
+	require.NoError(t, NewHookPolicyTransformer(p).Transform(s))
+	require.EqualValues(t, spec(), s)
+
+	p, err = hookpolicy.New(10*time.Second, "fail-open")
+	require.NoError(t, err)
+	s = spec()
+	require.NoError(t, NewHookPolicyTransformer(p).Transform(s))
+
+	expected := spec()
+	expected.Devices[0].ContainerEdits.Hooks[0].Args = []string{"nvidia-ctk", "hook", "--timeout=10s", "--failure-policy=fail-open", "create-symlinks", "--link", "../card1::/dev/dri/by-path/pci-0000:00:00.0-card"}
+	expected.ContainerEdits.Hooks[0].Args = []string{"nvidia-ctk", "hook", "--timeout=10s", "--failure-policy=fail-open", "update-ldcache", "--folder", "/usr/lib64"}
+	require.EqualValues(t, expected, s)
+}
+
+func TestDisabledHooksTransformer(t *testing.T) {
+	spec := hooksTestSpec
+
+	s := spec()
+	require.NoError(t, NewDisabledHooksTransformer().Transform(s))
+	require.EqualValues(t, spec(), s)
+
+	s = spec()
+	require.NoError(t, NewDisabledHooksTransformer("create-symlinks", "chmod").Transform(s))
+
+	expected := spec()
+	expected.Devices[0].ContainerEdits.Hooks = nil
+	require.EqualValues(t, expected, s)
+
+	s = spec()
+	require.NoError(t, NewDisabledHooksTransformer("update-ldcache").Transform(s))
+
+	expected = spec()
+	expected.ContainerEdits.Hooks = expected.ContainerEdits.Hooks[1:]
+	require.EqualValues(t, expected, s)
+}
@@ -0,0 +1,120 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+// reproducible normalizes a CDI spec so that specs generated for identical systems are identical.
+type reproducible struct {
+	sorter Transformer
+}
+
+var _ Transformer = (*reproducible)(nil)
+
+// NewReproducibleTransformer creates a transformer that normalizes a CDI spec so that two runs on
+// identical hardware produce byte-identical specs. In addition to the ordering applied by the sort
+// transformer, paths are cleaned, mount options are sorted, and the values of repeated consecutive
+// hook flags (e.g. the --link flags of the create-symlinks hook) are sorted.
+func NewReproducibleTransformer() Transformer {
+	t := reproducible{
+		sorter: NewSortTransformer(),
+	}
+	return t
+}
+
+// Transform normalizes the spec.
+func (t reproducible) Transform(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	for i := range spec.Devices {
+		normalizeEdits(&spec.Devices[i].ContainerEdits)
+	}
+	normalizeEdits(&spec.ContainerEdits)
+
+	return t.sorter.Transform(spec)
+}
+
+func normalizeEdits(edits *specs.ContainerEdits) {
+	for _, d := range edits.DeviceNodes {
+		if d == nil {
+			continue
+		}
+		d.Path = cleanPath(d.Path)
+		d.HostPath = cleanPath(d.HostPath)
+	}
+	for _, m := range edits.Mounts {
+		if m == nil {
+			continue
+		}
+		m.HostPath = cleanPath(m.HostPath)
+		m.ContainerPath = cleanPath(m.ContainerPath)
+		sort.Strings(m.Options)
+	}
+	for _, h := range edits.Hooks {
+		if h == nil {
+			continue
+		}
+		h.Path = cleanPath(h.Path)
+		sortRepeatedFlags(h.Args)
+	}
+}
+
+// cleanPath returns the shortest equivalent of the specified path. Empty paths are retained.
+func cleanPath(path string) string {
+	if path == "" {
+		return path
+	}
+	return filepath.Clean(path)
+}
+
+// sortRepeatedFlags sorts the values of runs of the same flag (e.g. --link a --link b) in place.
+// The order of the values of such flags is determined by the order of discovery and is not
+// significant.
+func sortRepeatedFlags(args []string) {
+	for i := 0; i < len(args); {
+		flag := args[i]
+		if !strings.HasPrefix(flag, "--") || strings.Contains(flag, "=") {
+			i++
+			continue
+		}
+
+		var values []string
+		end := i
+		for end+1 < len(args) && args[end] == flag {
+			values = append(values, args[end+1])
+			end += 2
+		}
+		if len(values) < 2 {
+			i++
+			continue
+		}
+
+		sort.Strings(values)
+		for j, v := range values {
+			args[i+1+2*j] = v
+		}
+		i = end
+	}
+}
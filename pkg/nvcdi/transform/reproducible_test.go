@@ -0,0 +1,137 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReproducibleTransformer(t *testing.T) {
+	spec := &specs.Spec{
+		Devices: []specs.Device{
+			{
+				Name: "1",
+				ContainerEdits: specs.ContainerEdits{
+					DeviceNodes: []*specs.DeviceNode{
+						{Path: "/dev//nvidia1", HostPath: "/host/./dev/nvidia1"},
+					},
+				},
+			},
+			{Name: "0"},
+		},
+		ContainerEdits: specs.ContainerEdits{
+			Mounts: []*specs.Mount{
+				{
+					HostPath:      "/usr/lib/x86_64-linux-gnu//libcuda.so.1",
+					ContainerPath: "/usr/lib/x86_64-linux-gnu/../x86_64-linux-gnu/libcuda.so.1",
+					Options:       []string{"ro", "nosuid", "nodev", "bind"},
+				},
+			},
+			Hooks: []*specs.Hook{
+				{
+					HookName: "createContainer",
+					Path:     "/usr/bin//nvidia-ctk",
+					Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "b::/b", "--link", "a::/a"},
+				},
+				{
+					HookName: "createContainer",
+					Path:     "/usr/bin/nvidia-ctk",
+					Args:     []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib64", "--folder", "/usr/lib", "--ldconfig-path", "/sbin/ldconfig"},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, NewReproducibleTransformer().Transform(spec))
+
+	require.Equal(t,
+		&specs.Spec{
+			Devices: []specs.Device{
+				{Name: "0"},
+				{
+					Name: "1",
+					ContainerEdits: specs.ContainerEdits{
+						DeviceNodes: []*specs.DeviceNode{
+							{Path: "/dev/nvidia1", HostPath: "/host/dev/nvidia1"},
+						},
+					},
+				},
+			},
+			ContainerEdits: specs.ContainerEdits{
+				Mounts: []*specs.Mount{
+					{
+						HostPath:      "/usr/lib/x86_64-linux-gnu/libcuda.so.1",
+						ContainerPath: "/usr/lib/x86_64-linux-gnu/libcuda.so.1",
+						Options:       []string{"bind", "nodev", "nosuid", "ro"},
+					},
+				},
+				Hooks: []*specs.Hook{
+					{
+						HookName: "createContainer",
+						Path:     "/usr/bin/nvidia-ctk",
+						Args:     []string{"nvidia-ctk", "hook", "create-symlinks", "--link", "a::/a", "--link", "b::/b"},
+					},
+					{
+						HookName: "createContainer",
+						Path:     "/usr/bin/nvidia-ctk",
+						Args:     []string{"nvidia-ctk", "hook", "update-ldcache", "--folder", "/usr/lib", "--folder", "/usr/lib64", "--ldconfig-path", "/sbin/ldconfig"},
+					},
+				},
+			},
+		},
+		spec,
+	)
+}
+
+func TestSortRepeatedFlags(t *testing.T) {
+	testCases := []struct {
+		description string
+		args        []string
+		expected    []string
+	}{
+		{
+			description: "single flag is unchanged",
+			args:        []string{"hook", "--link", "b"},
+			expected:    []string{"hook", "--link", "b"},
+		},
+		{
+			description: "separate runs are sorted independently",
+			args:        []string{"--link", "d", "--link", "c", "--folder", "/b", "--link", "b", "--link", "a"},
+			expected:    []string{"--link", "c", "--link", "d", "--folder", "/b", "--link", "a", "--link", "b"},
+		},
+		{
+			description: "flags with inline values are unchanged",
+			args:        []string{"--link=b", "--link=a"},
+			expected:    []string{"--link=b", "--link=a"},
+		},
+		{
+			description: "trailing flag without value",
+			args:        []string{"--link", "b", "--link", "a", "--link"},
+			expected:    []string{"--link", "a", "--link", "b", "--link"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			sortRepeatedFlags(tc.args)
+			require.Equal(t, tc.expected, tc.args)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+)
+
+// sorter orders the devices and edits in a CDI spec.
+type sorter struct{}
+
+var _ Transformer = (*sorter)(nil)
+
+// NewSortTransformer creates a transformer that orders the devices and edits in a CDI spec so that
+// the spec generated for a system does not depend on the order in which entities were discovered.
+// Devices are ordered by name (with numeric parts compared as numbers), mounts by container path,
+// device nodes by path, and environment variables by name. Since the order of hooks is significant,
+// hooks are not reordered.
+func NewSortTransformer() Transformer {
+	return sorter{}
+}
+
+// Transform orders the devices and edits in the spec.
+func (t sorter) Transform(spec *specs.Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	sort.SliceStable(spec.Devices, func(i, j int) bool {
+		return naturalLess(spec.Devices[i].Name, spec.Devices[j].Name)
+	})
+	for i := range spec.Devices {
+		sortEdits(&spec.Devices[i].ContainerEdits)
+	}
+	sortEdits(&spec.ContainerEdits)
+
+	return nil
+}
+
+func sortEdits(edits *specs.ContainerEdits) {
+	sort.SliceStable(edits.Env, func(i, j int) bool {
+		return envName(edits.Env[i]) < envName(edits.Env[j])
+	})
+	sort.SliceStable(edits.DeviceNodes, func(i, j int) bool {
+		return edits.DeviceNodes[i].Path < edits.DeviceNodes[j].Path
+	})
+	// Since a parent directory sorts before its children, mounts that are nested in other
+	// mounts remain ordered after these.
+	sort.SliceStable(edits.Mounts, func(i, j int) bool {
+		return edits.Mounts[i].ContainerPath < edits.Mounts[j].ContainerPath
+	})
+}
+
+// envName returns the name of the specified environment variable.
+func envName(envvar string) string {
+	name, _, _ := strings.Cut(envvar, "=")
+	return name
+}
+
+// naturalLess compares the specified strings with sequences of digits compared as numbers so that,
+// for example, device 2 is ordered before device 10.
+func naturalLess(a string, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := isDigit(a[0]), isDigit(b[0])
+		if aDigits && bDigits {
+			var aNumber, bNumber string
+			aNumber, a = splitDigits(a)
+			bNumber, b = splitDigits(b)
+			aTrimmed := strings.TrimLeft(aNumber, "0")
+			bTrimmed := strings.TrimLeft(bNumber, "0")
+			if len(aTrimmed) != len(bTrimmed) {
+				return len(aTrimmed) < len(bTrimmed)
+			}
+			if aTrimmed != bTrimmed {
+				return aTrimmed < bTrimmed
+			}
+			if aNumber != bNumber {
+				return len(aNumber) < len(bNumber)
+			}
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// splitDigits splits the leading sequence of digits from the specified string.
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
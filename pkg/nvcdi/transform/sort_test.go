@@ -0,0 +1,103 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortTransformer(t *testing.T) {
+	spec := &specs.Spec{
+		Devices: []specs.Device{
+			{Name: "all"},
+			{Name: "10"},
+			{Name: "2"},
+			{Name: "1:1"},
+			{Name: "1:0"},
+			{Name: "1"},
+			{Name: "GPU-b"},
+			{Name: "GPU-a"},
+		},
+		ContainerEdits: specs.ContainerEdits{
+			Env: []string{"NVIDIA_VISIBLE_DEVICES=void", "FOO=2", "BAR=1", "FOO=1"},
+			DeviceNodes: []*specs.DeviceNode{
+				{Path: "/dev/nvidiactl"},
+				{Path: "/dev/nvidia-uvm"},
+			},
+			Mounts: []*specs.Mount{
+				{ContainerPath: "/usr/lib64/libcuda.so.1"},
+				{ContainerPath: "/lib/firmware/nvidia/525.60.13/gsp.bin"},
+				{ContainerPath: "/usr/lib64"},
+			},
+			Hooks: []*specs.Hook{
+				{HookName: "createContainer", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				{HookName: "createContainer", Args: []string{"nvidia-ctk", "hook", "create-symlinks"}},
+			},
+		},
+	}
+
+	require.NoError(t, NewSortTransformer().Transform(spec))
+
+	var names []string
+	for _, d := range spec.Devices {
+		names = append(names, d.Name)
+	}
+	require.Equal(t, []string{"1", "1:0", "1:1", "2", "10", "GPU-a", "GPU-b", "all"}, names)
+
+	require.Equal(t,
+		specs.ContainerEdits{
+			Env: []string{"BAR=1", "FOO=2", "FOO=1", "NVIDIA_VISIBLE_DEVICES=void"},
+			DeviceNodes: []*specs.DeviceNode{
+				{Path: "/dev/nvidia-uvm"},
+				{Path: "/dev/nvidiactl"},
+			},
+			Mounts: []*specs.Mount{
+				{ContainerPath: "/lib/firmware/nvidia/525.60.13/gsp.bin"},
+				{ContainerPath: "/usr/lib64"},
+				{ContainerPath: "/usr/lib64/libcuda.so.1"},
+			},
+			Hooks: []*specs.Hook{
+				{HookName: "createContainer", Args: []string{"nvidia-ctk", "hook", "update-ldcache"}},
+				{HookName: "createContainer", Args: []string{"nvidia-ctk", "hook", "create-symlinks"}},
+			},
+		},
+		spec.ContainerEdits,
+	)
+}
+
+func TestNaturalLess(t *testing.T) {
+	testCases := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{a: "2", b: "10", expected: true},
+		{a: "10", b: "2", expected: false},
+		{a: "0:9", b: "0:10", expected: true},
+		{a: "1", b: "01", expected: true},
+		{a: "a", b: "a", expected: false},
+		{a: "a", b: "ab", expected: true},
+		{a: "9", b: "a", expected: true},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, naturalLess(tc.a, tc.b), "%q < %q", tc.a, tc.b)
+	}
+}
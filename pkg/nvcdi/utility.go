@@ -0,0 +1,142 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/discover"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/edits"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/lookup"
+	"github.com/NVIDIA/nvidia-container-toolkit/pkg/nvcdi/spec"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvlib/device"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+type utilitylib nvcdilib
+
+var _ Interface = (*utilitylib)(nil)
+
+// GetAllDeviceSpecs returns the device specs for use in monitoring containers.
+// A single device with the name `all` is returned that includes NVML, nvidia-smi,
+// and the device nodes required to query all GPUs.
+func (l *utilitylib) GetAllDeviceSpecs() ([]specs.Device, error) {
+	version, r := l.nvmllib.SystemGetDriverVersion()
+	if r != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to determine driver version: %v", r)
+	}
+
+	discoverer, err := l.newUtilityDiscoverer(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create utility discoverer: %v", err)
+	}
+
+	edits, err := edits.FromDiscoverer(discoverer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container edits for utility device: %v", err)
+	}
+
+	if len(edits.DeviceNodes) == 0 {
+		return nil, fmt.Errorf("no NVIDIA device nodes found")
+	}
+
+	deviceSpec := specs.Device{
+		Name:           "all",
+		ContainerEdits: *edits.ContainerEdits,
+	}
+
+	return []specs.Device{deviceSpec}, nil
+}
+
+// newUtilityDiscoverer creates a discoverer for the NVML library, the nvidia-smi binary,
+// and the control and GPU device nodes.
+func (l *utilitylib) newUtilityDiscoverer(version string) (discover.Discover, error) {
+	libraryLocator, err := lookup.NewLibraryLocator(l.logger, l.driverRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create library locator: %v", err)
+	}
+
+	libraries := discover.NewMounts(
+		l.logger,
+		libraryLocator,
+		l.driverRoot,
+		[]string{"libnvidia-ml.so." + version},
+	)
+
+	cfg := &discover.Config{
+		DriverRoot:    l.driverRoot,
+		NvidiaCTKPath: l.nvidiaCTKPath,
+	}
+	ldcacheHook, _ := discover.NewLDCacheUpdateHook(l.logger, libraries, cfg)
+
+	binaries := discover.NewMounts(
+		l.logger,
+		lookup.NewExecutableLocator(l.logger, l.driverRoot),
+		l.driverRoot,
+		[]string{"nvidia-smi"},
+	)
+
+	deviceNodes := discover.NewCharDeviceDiscoverer(
+		l.logger,
+		[]string{
+			"/dev/nvidiactl",
+			"/dev/nvidia[0-9]*",
+		},
+		l.driverRoot,
+	)
+
+	d := discover.Merge(
+		libraries,
+		ldcacheHook,
+		binaries,
+		deviceNodes,
+	)
+	return d, nil
+}
+
+// GetCommonEdits returns an empty set of edits since all edits are associated with the `all` device.
+func (l *utilitylib) GetCommonEdits() (*cdi.ContainerEdits, error) {
+	return edits.FromDiscoverer(discover.None{})
+}
+
+// GetSpec is unsppported for the utilitylib specs.
+// utilitylib is typically wrapped by a spec that implements GetSpec.
+func (l *utilitylib) GetSpec() (spec.Interface, error) {
+	return nil, fmt.Errorf("GetSpec is not supported")
+}
+
+// GetGPUDeviceEdits is unsupported for the utilitylib specs
+func (l *utilitylib) GetGPUDeviceEdits(device.Device) (*cdi.ContainerEdits, error) {
+	return nil, fmt.Errorf("GetGPUDeviceEdits is not supported")
+}
+
+// GetGPUDeviceSpecs is unsupported for the utilitylib specs
+func (l *utilitylib) GetGPUDeviceSpecs(int, device.Device) (*specs.Device, error) {
+	return nil, fmt.Errorf("GetGPUDeviceSpecs is not supported")
+}
+
+// GetMIGDeviceEdits is unsupported for the utilitylib specs
+func (l *utilitylib) GetMIGDeviceEdits(device.Device, device.MigDevice) (*cdi.ContainerEdits, error) {
+	return nil, fmt.Errorf("GetMIGDeviceEdits is not supported")
+}
+
+// GetMIGDeviceSpecs is unsupported for the utilitylib specs
+func (l *utilitylib) GetMIGDeviceSpecs(int, device.Device, int, device.MigDevice) (*specs.Device, error) {
+	return nil, fmt.Errorf("GetMIGDeviceSpecs is not supported")
+}
@@ -0,0 +1,79 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package nvcdi
+
+import (
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/nvidia/cloud-native/go-nvlib/pkg/nvml"
+)
+
+func TestUtilityGetAllDeviceSpecs(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	testCases := []struct {
+		description   string
+		driverVersion string
+		nvmlReturn    nvml.Return
+		expectedError string
+	}{
+		{
+			description:   "driver version query fails",
+			nvmlReturn:    nvml.ERROR_UNINITIALIZED,
+			expectedError: "failed to determine driver version",
+		},
+		{
+			description:   "missing ldcache in driver root",
+			driverVersion: "999.99",
+			nvmlReturn:    nvml.SUCCESS,
+			expectedError: "failed to create utility discoverer",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			l := &nvcdilib{
+				logger:     logger,
+				driverRoot: t.TempDir(),
+				nvmllib: &nvml.InterfaceMock{
+					SystemGetDriverVersionFunc: func() (string, nvml.Return) {
+						return tc.driverVersion, tc.nvmlReturn
+					},
+				},
+			}
+
+			devices, err := (*utilitylib)(l).GetAllDeviceSpecs()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedError)
+			require.Nil(t, devices)
+		})
+	}
+}
+
+func TestUtilityClass(t *testing.T) {
+	l, err := New(
+		WithMode(ModeUtility),
+		WithNvmlLib(&nvml.InterfaceMock{}),
+	)
+	require.NoError(t, err)
+
+	w, ok := l.(*wrapper)
+	require.True(t, ok)
+	require.Equal(t, "utility", w.class)
+}
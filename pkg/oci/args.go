@@ -44,34 +44,68 @@ func GetBundleDir(args []string) (string, error) {
 // -b{{SEP}}BUNDLE_PATH
 // where {{SEP}} is either ' ' or '='
 func GetBundleDirFromArgs(args []string) (string, error) {
-	var bundleDir string
+	bundleDir, err := getFlagValue(args, IsBundleFlag)
+	if err != nil {
+		return "", fmt.Errorf("bundle option requires an argument")
+	}
+	return bundleDir, nil
+}
+
+// GetSpecFilePathFromArgs returns the path to the OCI specification file for the specified
+// command line arguments. This is the config.json file in the bundle directory unless an
+// alternative file is specified using the --config flag (as supported by crun). A relative
+// path specified using the --config flag is resolved relative to the bundle directory.
+func GetSpecFilePathFromArgs(args []string) (string, error) {
+	bundleDir, err := GetBundleDir(args)
+	if err != nil {
+		return "", err
+	}
+
+	configFile, err := getFlagValue(args, isConfigFlag)
+	if err != nil {
+		return "", fmt.Errorf("config option requires an argument")
+	}
+	if configFile == "" {
+		return GetSpecFilePath(bundleDir), nil
+	}
+	if filepath.IsAbs(configFile) {
+		return configFile, nil
+	}
+	return filepath.Join(bundleDir, configFile), nil
+}
+
+// getFlagValue returns the value of the last flag in the specified arguments matched by isFlag.
+// Values can be specified as the next argument or separated from the flag by '='. An error is
+// returned if a matching flag is the last argument and has no value.
+func getFlagValue(args []string, isFlag func(string) bool) (string, error) {
+	var value string
 
 	for i := 0; i < len(args); i++ {
 		param := args[i]
 
 		parts := strings.SplitN(param, "=", 2)
-		if !IsBundleFlag(parts[0]) {
+		if !isFlag(parts[0]) {
 			continue
 		}
 
-		// The flag has the format --bundle=/path
+		// The flag has the format --flag=value
 		if len(parts) == 2 {
-			bundleDir = parts[1]
+			value = parts[1]
 			continue
 		}
 
-		// The flag has the format --bundle /path
+		// The flag has the format --flag value
 		if i+1 < len(args) {
-			bundleDir = args[i+1]
+			value = args[i+1]
 			i++
 			continue
 		}
 
-		// --bundle / -b was the last element of args
-		return "", fmt.Errorf("bundle option requires an argument")
+		// The flag was the last element of args
+		return "", fmt.Errorf("missing value for %v", param)
 	}
 
-	return bundleDir, nil
+	return value, nil
 }
 
 // GetSpecFilePath returns the expected path to the OCI specification file for the given
@@ -92,19 +126,39 @@ func IsBundleFlag(arg string) bool {
 	return trimmed == "b" || trimmed == "bundle"
 }
 
+// isConfigFlag checks whether the specified argument is a flag (--config) specifying an
+// alternative OCI specification file.
+func isConfigFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+
+	return strings.TrimLeft(arg, "-") == "config"
+}
+
 // HasCreateSubcommand checks the supplied arguments for a 'create' subcommand
 func HasCreateSubcommand(args []string) bool {
+	return hasSubcommand(args, "create")
+}
+
+// HasDeleteSubcommand checks the supplied arguments for a 'delete' subcommand
+func HasDeleteSubcommand(args []string) bool {
+	return hasSubcommand(args, "delete")
+}
+
+// hasSubcommand checks the supplied arguments for the specified subcommand
+func hasSubcommand(args []string, subcommand string) bool {
 	var previousWasBundle bool
 	for _, a := range args {
-		// We check for '--bundle create' explicitly to ensure that we
-		// don't inadvertently trigger a modification if the bundle directory
-		// is specified as `create`
+		// We check for '--bundle <subcommand>' explicitly to ensure that we
+		// don't inadvertently match the subcommand if the bundle directory
+		// is specified as the name of the subcommand (e.g. `create`)
 		if !previousWasBundle && IsBundleFlag(a) {
 			previousWasBundle = true
 			continue
 		}
 
-		if !previousWasBundle && a == "create" {
+		if !previousWasBundle && a == subcommand {
 			return true
 		}
 
@@ -152,6 +152,49 @@ func TestGetSpecFilePathAppendsFilename(t *testing.T) {
 	}
 }
 
+func TestGetSpecFilePathFromArgs(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+		isError  bool
+	}{
+		{
+			args:     []string{"create", "--bundle", "/foo/bar", "id"},
+			expected: "/foo/bar/config.json",
+		},
+		{
+			args:     []string{"create", "--bundle", "/foo/bar", "--config", "spec.json", "id"},
+			expected: "/foo/bar/spec.json",
+		},
+		{
+			args:     []string{"create", "--bundle=/foo/bar", "--config=/run/shim/spec.json", "id"},
+			expected: "/run/shim/spec.json",
+		},
+		{
+			args:     []string{"create", "-config", "spec.json", "id"},
+			expected: "spec.json",
+		},
+		{
+			args:    []string{"create", "--bundle", "/foo/bar", "--config"},
+			isError: true,
+		},
+		{
+			args:    []string{"create", "--config", "spec.json", "--bundle"},
+			isError: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		specPath, err := GetSpecFilePathFromArgs(tc.args)
+		if tc.isError {
+			require.Errorf(t, err, "%d: %v", i, tc)
+			continue
+		}
+		require.NoErrorf(t, err, "%d: %v", i, tc)
+		require.Equalf(t, tc.expected, specPath, "%d: %v", i, tc)
+	}
+}
+
 func TestHasCreateSubcommand(t *testing.T) {
 	testCases := []struct {
 		args         []string
@@ -182,3 +225,30 @@ func TestHasCreateSubcommand(t *testing.T) {
 		require.Equal(t, tc.shouldModify, HasCreateSubcommand(tc.args), "%d: %v", i, tc)
 	}
 }
+
+func TestHasDeleteSubcommand(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{
+			expected: false,
+		},
+		{
+			args:     []string{"--root", "/run/runc", "delete", "--force", "abc"},
+			expected: true,
+		},
+		{
+			args:     []string{"--bundle", "delete", "create", "abc"},
+			expected: false,
+		},
+		{
+			args:     []string{"create", "abc"},
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, HasDeleteSubcommand(tc.args), "%d: %v", i, tc)
+	}
+}
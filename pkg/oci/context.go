@@ -0,0 +1,71 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RunWithContext runs the specified function and returns its error. If the context is done before
+// the function returns, an error is returned without waiting for the function to complete. If the
+// deadline of the context was exceeded, the error has kind ErrorKindTimeout.
+//
+// Since the function is not interrupted, this is intended for bounding calls that may block
+// indefinitely (e.g. NVML calls or file system access on an unresponsive driver root) in a
+// process that exits once an error is returned. Functions that perform several such calls should
+// use CheckContext between these so that they stop once the context is done instead of
+// continuing in the background. Results must only be read by the caller if nil is returned.
+func RunWithContext(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+	if err := ctx.Err(); err != nil {
+		return contextError(ctx)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- fn()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+// CheckContext returns an error if the specified context is done. A nil context is never done.
+func CheckContext(ctx context.Context) error {
+	if ctx == nil || ctx.Err() == nil {
+		return nil
+	}
+	return contextError(ctx)
+}
+
+// contextError returns the error for a context that is done.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		deadline, _ := ctx.Deadline()
+		return NewError(ErrorKindTimeout, fmt.Errorf("deadline of %v exceeded: %w", deadline.Format("15:04:05.000"), err))
+	}
+	return err
+}
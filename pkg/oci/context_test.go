@@ -0,0 +1,96 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	testCases := []struct {
+		description  string
+		timeout      time.Duration
+		fn           func() error
+		expectedKind ErrorKind
+		expectedErr  bool
+	}{
+		{
+			description: "no deadline",
+			fn:          func() error { return nil },
+		},
+		{
+			description: "completes before deadline",
+			timeout:     time.Minute,
+			fn:          func() error { return nil },
+		},
+		{
+			description:  "error is returned",
+			timeout:      time.Minute,
+			fn:           func() error { return NewError(ErrorKindConfig, errors.New("invalid")) },
+			expectedErr:  true,
+			expectedKind: ErrorKindConfig,
+		},
+		{
+			description: "deadline exceeded",
+			timeout:     10 * time.Millisecond,
+			fn: func() error {
+				<-block
+				return nil
+			},
+			expectedErr:  true,
+			expectedKind: ErrorKindTimeout,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			err := RunWithContext(ctx, tc.fn)
+			if !tc.expectedErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Equal(t, tc.expectedKind, GetErrorKind(err))
+		})
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	require.NoError(t, CheckContext(nil))
+	require.NoError(t, CheckContext(context.Background()))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	err := CheckContext(ctx)
+	require.Error(t, err)
+	require.Equal(t, ErrorKindTimeout, GetErrorKind(err))
+}
@@ -0,0 +1,26 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package oci provides the abstractions used by the NVIDIA Container Toolkit to load, modify, and
+// write OCI runtime specifications, as well as helpers to parse OCI container state and resolve the
+// bundle and spec paths from runtime command line arguments.
+//
+// A Spec can be backed by a file (NewFileSpec), a file descriptor (NewFDSpec), or memory
+// (NewMemorySpec). All implementations share the same semantics: Load reads the specification
+// (a no-op for in-memory specs), Modify applies a SpecModifier to the loaded specification in-place,
+// and Flush persists the modified specification (also a no-op for in-memory specs). This allows shims and test frameworks that embed the NVIDIA
+// Container Runtime to reuse the same spec handling by providing a SpecSource.
+package oci
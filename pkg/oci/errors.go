@@ -0,0 +1,115 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrorKind classifies the errors raised by the NVIDIA Container Runtime.
+type ErrorKind string
+
+// The kinds of errors raised by the NVIDIA Container Runtime.
+const (
+	// ErrorKindUnknown indicates an error that has not been classified.
+	ErrorKindUnknown ErrorKind = "unknown"
+	// ErrorKindConfig indicates an invalid or unreadable config.
+	ErrorKindConfig ErrorKind = "config"
+	// ErrorKindDiscovery indicates a failure to discover or apply the required modifications.
+	ErrorKindDiscovery ErrorKind = "discovery"
+	// ErrorKindUnsupportedRequest indicates that the requested devices or features cannot be provided.
+	ErrorKindUnsupportedRequest ErrorKind = "unsupported-request"
+	// ErrorKindLowLevelRuntime indicates a failure to invoke the low-level runtime.
+	ErrorKindLowLevelRuntime ErrorKind = "low-level-runtime"
+	// ErrorKindTimeout indicates that the modification of the OCI specification did not complete in time.
+	ErrorKindTimeout ErrorKind = "timeout"
+)
+
+// exitCodes maps each error kind to the exit code of the runtime.
+var exitCodes = map[ErrorKind]int{
+	ErrorKindUnknown:            1,
+	ErrorKindConfig:             2,
+	ErrorKindDiscovery:          3,
+	ErrorKindUnsupportedRequest: 4,
+	ErrorKindLowLevelRuntime:    5,
+	ErrorKindTimeout:            6,
+}
+
+// Error is an error with an associated kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// NewError associates the specified kind with an error. If the error (or an error
+// that it wraps) already has a kind, this more specific kind is retained.
+// If err is nil, nil is returned.
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		kind = e.Kind
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Error returns the message of the wrapped error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GetErrorKind returns the kind of the specified error.
+func GetErrorKind(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ErrorKindUnknown
+}
+
+// ExitCode returns the exit code associated with the specified error.
+// An exit code of 0 is returned for a nil error.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code, ok := exitCodes[GetErrorKind(err)]; ok {
+		return code
+	}
+	return exitCodes[ErrorKindUnknown]
+}
+
+// MarshalJSON returns the machine-readable representation of the error.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     ErrorKind `json:"kind"`
+		ExitCode int       `json:"exitCode"`
+		Message  string    `json:"message"`
+	}{
+		Kind:     e.Kind,
+		ExitCode: ExitCode(e),
+		Message:  e.Error(),
+	})
+}
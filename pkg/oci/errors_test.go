@@ -0,0 +1,90 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors(t *testing.T) {
+	testCases := []struct {
+		description      string
+		err              error
+		expectedKind     ErrorKind
+		expectedExitCode int
+	}{
+		{
+			description:      "nil error",
+			err:              nil,
+			expectedKind:     ErrorKindUnknown,
+			expectedExitCode: 0,
+		},
+		{
+			description:      "untyped error",
+			err:              fmt.Errorf("untyped"),
+			expectedKind:     ErrorKindUnknown,
+			expectedExitCode: 1,
+		},
+		{
+			description:      "config error",
+			err:              NewError(ErrorKindConfig, fmt.Errorf("config")),
+			expectedKind:     ErrorKindConfig,
+			expectedExitCode: 2,
+		},
+		{
+			description:      "wrapped discovery error",
+			err:              fmt.Errorf("wrapped: %w", NewError(ErrorKindDiscovery, fmt.Errorf("discovery"))),
+			expectedKind:     ErrorKindDiscovery,
+			expectedExitCode: 3,
+		},
+		{
+			description:      "inner kind is retained",
+			err:              NewError(ErrorKindLowLevelRuntime, fmt.Errorf("wrapped: %w", NewError(ErrorKindUnsupportedRequest, fmt.Errorf("unsupported")))),
+			expectedKind:     ErrorKindUnsupportedRequest,
+			expectedExitCode: 4,
+		},
+		{
+			description:      "low-level runtime error",
+			err:              NewError(ErrorKindLowLevelRuntime, fmt.Errorf("exec failed")),
+			expectedKind:     ErrorKindLowLevelRuntime,
+			expectedExitCode: 5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedKind, GetErrorKind(tc.err))
+			require.Equal(t, tc.expectedExitCode, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestNewErrorNil(t *testing.T) {
+	require.Nil(t, NewError(ErrorKindConfig, nil))
+}
+
+func TestErrorMarshalJSON(t *testing.T) {
+	err := NewError(ErrorKindUnsupportedRequest, fmt.Errorf("unresolvable CDI devices nvidia.com/gpu=99"))
+
+	b, jsonErr := json.Marshal(err)
+	require.NoError(t, jsonErr)
+	require.JSONEq(t, `{"kind": "unsupported-request", "exitCode": 4, "message": "unresolvable CDI devices nvidia.com/gpu=99"}`, string(b))
+}
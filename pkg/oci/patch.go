@@ -0,0 +1,149 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// PatchOperation represents a single RFC6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON returns the JSON representation of the operation. The value is omitted for the remove,
+// move, and copy operations only, since a null, false, zero, or empty value is valid for the others.
+func (p PatchOperation) MarshalJSON() ([]byte, error) {
+	switch p.Op {
+	case "remove", "move", "copy":
+		op := struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{
+			Op:   p.Op,
+			Path: p.Path,
+		}
+		return json.Marshal(op)
+	}
+	type operation PatchOperation
+	return json.Marshal(operation(p))
+}
+
+// CreatePatch returns the RFC6902 JSON Patch that transforms the original OCI specification
+// into the modified OCI specification. Elements appended to arrays (e.g. mounts, devices,
+// hooks, or environment variables) are represented as individual add operations. Arrays that
+// are otherwise modified are replaced.
+func CreatePatch(original *specs.Spec, modified *specs.Spec) ([]PatchOperation, error) {
+	o, err := toGeneric(original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert original spec: %v", err)
+	}
+	m, err := toGeneric(modified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert modified spec: %v", err)
+	}
+
+	patch := []PatchOperation{}
+	diff(&patch, "", o, m)
+	return patch, nil
+}
+
+// toGeneric converts the specified value to its generic JSON representation.
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+func diff(patch *[]PatchOperation, path string, original interface{}, modified interface{}) {
+	if reflect.DeepEqual(original, modified) {
+		return
+	}
+
+	switch m := modified.(type) {
+	case map[string]interface{}:
+		o, ok := original.(map[string]interface{})
+		if !ok {
+			break
+		}
+		diffObjects(patch, path, o, m)
+		return
+	case []interface{}:
+		o, ok := original.([]interface{})
+		if !ok || len(o) > len(m) || !reflect.DeepEqual(o, m[:len(o)]) {
+			break
+		}
+		for i := len(o); i < len(m); i++ {
+			*patch = append(*patch, PatchOperation{Op: "add", Path: fmt.Sprintf("%v/%d", path, i), Value: m[i]})
+		}
+		return
+	}
+
+	if path == "" || original == nil {
+		*patch = append(*patch, PatchOperation{Op: "add", Path: path, Value: modified})
+		return
+	}
+	*patch = append(*patch, PatchOperation{Op: "replace", Path: path, Value: modified})
+}
+
+func diffObjects(patch *[]PatchOperation, path string, original map[string]interface{}, modified map[string]interface{}) {
+	keys := make(map[string]bool)
+	for k := range original {
+		keys[k] = true
+	}
+	for k := range modified {
+		keys[k] = true
+	}
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		childPath := path + "/" + escapePointer(k)
+		o, inOriginal := original[k]
+		m, inModified := modified[k]
+		switch {
+		case !inModified:
+			*patch = append(*patch, PatchOperation{Op: "remove", Path: childPath})
+		case !inOriginal:
+			*patch = append(*patch, PatchOperation{Op: "add", Path: childPath, Value: m})
+		default:
+			diff(patch, childPath, o, m)
+		}
+	}
+}
+
+// escapePointer escapes the specified key for use in a JSON Pointer as defined in RFC6901.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
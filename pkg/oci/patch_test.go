@@ -0,0 +1,166 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePatch(t *testing.T) {
+	testCases := []struct {
+		description string
+		original    *specs.Spec
+		modify      func(*specs.Spec)
+		expected    []PatchOperation
+	}{
+		{
+			description: "no modifications",
+			original: &specs.Spec{
+				Process: &specs.Process{Env: []string{"PATH=/bin"}},
+			},
+			modify:   func(s *specs.Spec) {},
+			expected: []PatchOperation{},
+		},
+		{
+			description: "env and mounts are appended",
+			original: &specs.Spec{
+				Process: &specs.Process{Env: []string{"PATH=/bin"}},
+				Mounts: []specs.Mount{
+					{Destination: "/proc", Type: "proc"},
+				},
+			},
+			modify: func(s *specs.Spec) {
+				s.Process.Env = append(s.Process.Env, "NVIDIA_VISIBLE_DEVICES=void")
+				s.Mounts = append(s.Mounts, specs.Mount{Destination: "/usr/bin/nvidia-smi", Source: "/usr/bin/nvidia-smi"})
+			},
+			expected: []PatchOperation{
+				{Op: "add", Path: "/mounts/1", Value: map[string]interface{}{"destination": "/usr/bin/nvidia-smi", "source": "/usr/bin/nvidia-smi"}},
+				{Op: "add", Path: "/process/env/1", Value: "NVIDIA_VISIBLE_DEVICES=void"},
+			},
+		},
+		{
+			description: "missing hooks and devices are added",
+			original: &specs.Spec{
+				Linux: &specs.Linux{},
+			},
+			modify: func(s *specs.Spec) {
+				s.Hooks = &specs.Hooks{
+					CreateContainer: []specs.Hook{{Path: "/usr/bin/nvidia-ctk"}},
+				}
+				s.Linux.Devices = []specs.LinuxDevice{{Path: "/dev/nvidia0", Type: "c", Major: 195}}
+			},
+			expected: []PatchOperation{
+				{Op: "add", Path: "/hooks", Value: map[string]interface{}{
+					"createContainer": []interface{}{map[string]interface{}{"path": "/usr/bin/nvidia-ctk"}},
+				}},
+				{Op: "add", Path: "/linux/devices", Value: []interface{}{
+					map[string]interface{}{"path": "/dev/nvidia0", "type": "c", "major": float64(195), "minor": float64(0)},
+				}},
+			},
+		},
+		{
+			description: "modified arrays are replaced and removed keys are removed",
+			original: &specs.Spec{
+				Process: &specs.Process{Env: []string{"PATH=/bin", "NVIDIA_VISIBLE_DEVICES=all"}},
+				Annotations: map[string]string{
+					"cdi.k8s.io/a/b": "nvidia.com/gpu=0",
+				},
+			},
+			modify: func(s *specs.Spec) {
+				s.Process.Env = []string{"PATH=/bin"}
+				s.Annotations = map[string]string{}
+			},
+			expected: []PatchOperation{
+				{Op: "remove", Path: "/annotations"},
+				{Op: "replace", Path: "/process/env", Value: []interface{}{"PATH=/bin"}},
+			},
+		},
+		{
+			description: "keys are escaped",
+			original: &specs.Spec{
+				Annotations: map[string]string{"a": "b"},
+			},
+			modify: func(s *specs.Spec) {
+				s.Annotations["cdi.k8s.io/nvidia~gpu"] = "nvidia.com/gpu=0"
+			},
+			expected: []PatchOperation{
+				{Op: "add", Path: "/annotations/cdi.k8s.io~1nvidia~0gpu", Value: "nvidia.com/gpu=0"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			data, err := json.Marshal(tc.original)
+			require.NoError(t, err)
+			var spec specs.Spec
+			require.NoError(t, json.Unmarshal(data, &spec))
+
+			tc.modify(&spec)
+
+			patch, err := CreatePatch(tc.original, &spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, patch)
+		})
+	}
+}
+
+func TestPatchOperationMarshalJSON(t *testing.T) {
+	testCases := []struct {
+		description string
+		operation   PatchOperation
+		expected    string
+	}{
+		{
+			description: "remove omits value",
+			operation:   PatchOperation{Op: "remove", Path: "/process/env/0"},
+			expected:    `{"op":"remove","path":"/process/env/0"}`,
+		},
+		{
+			description: "add includes false value",
+			operation:   PatchOperation{Op: "add", Path: "/process/terminal", Value: false},
+			expected:    `{"op":"add","path":"/process/terminal","value":false}`,
+		},
+		{
+			description: "replace includes zero value",
+			operation:   PatchOperation{Op: "replace", Path: "/process/user/uid", Value: float64(0)},
+			expected:    `{"op":"replace","path":"/process/user/uid","value":0}`,
+		},
+		{
+			description: "add includes empty value",
+			operation:   PatchOperation{Op: "add", Path: "/annotations/example", Value: ""},
+			expected:    `{"op":"add","path":"/annotations/example","value":""}`,
+		},
+		{
+			description: "add includes null value",
+			operation:   PatchOperation{Op: "add", Path: "/linux/resources"},
+			expected:    `{"op":"add","path":"/linux/resources","value":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			data, err := json.Marshal(tc.operation)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(data))
+		})
+	}
+}
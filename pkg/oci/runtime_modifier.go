@@ -0,0 +1,126 @@
+/*
+# Copyright (c) 2021, NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/events"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/latency"
+	log "github.com/sirupsen/logrus"
+)
+
+type modifyingRuntimeWrapper struct {
+	ctx      context.Context
+	logger   *log.Logger
+	runtime  Runtime
+	ociSpec  Spec
+	modifier SpecModifier
+}
+
+var _ Runtime = (*modifyingRuntimeWrapper)(nil)
+
+// NewModifyingRuntimeWrapper creates a runtime wrapper that applies the specified modifier to the OCI specification
+// before invoking the wrapped runtime. The modification is abandoned if the context is done before it completes.
+// If the modifier is nil, the input runtime is returned.
+func NewModifyingRuntimeWrapper(ctx context.Context, logger *log.Logger, runtime Runtime, spec Spec, modifier SpecModifier) Runtime {
+	if modifier == nil {
+		logger.Infof("Using low-level runtime with no modification")
+		return runtime
+	}
+
+	rt := modifyingRuntimeWrapper{
+		ctx:      ctx,
+		logger:   logger,
+		runtime:  runtime,
+		ociSpec:  spec,
+		modifier: modifier,
+	}
+	return &rt
+}
+
+// Exec checks whether a modification of the OCI specification is required and modifies it accordingly before exec-ing
+// into the wrapped runtime.
+func (r *modifyingRuntimeWrapper) Exec(args []string) error {
+	if HasCreateSubcommand(args) {
+		err := r.modify()
+		if err != nil {
+			return fmt.Errorf("could not apply required modification to OCI specification: %w", err)
+		}
+	} else {
+		r.logger.Infof("No modification of OCI specification required")
+	}
+
+	if err := latency.FromContext(r.ctx).Save(); err != nil {
+		r.logger.Debugf("Failed to record startup latency: %v", err)
+	}
+
+	r.logger.Infof("Forwarding command to runtime")
+	return r.runtime.Exec(args)
+}
+
+// modify loads, modifies, and flushes the OCI specification using the defined Modifier
+func (r *modifyingRuntimeWrapper) modify() error {
+	recorder := latency.FromContext(r.ctx)
+
+	var skipped bool
+	discovered := recorder.Track(latency.PhaseDiscovery)
+	err := RunWithContext(r.ctx, func() error {
+		spec, err := r.ociSpec.Load()
+		if err != nil {
+			return NewError(ErrorKindDiscovery, fmt.Errorf("error loading OCI specification for modification: %v", err))
+		}
+
+		if platform := GetNonLinuxPlatform(spec); platform != "" {
+			r.logger.WithField(events.Field, events.ModificationSkipped).Warningf("Skipping modification of OCI specification: the container targets a %v platform and only Linux containers are supported", platform)
+			skipped = true
+			return nil
+		}
+
+		r.logger.WithField(events.Field, events.InjectionStart).Infof("Modifying OCI specification")
+		err = r.ociSpec.Modify(r.modifier)
+		if err != nil {
+			return NewError(ErrorKindDiscovery, fmt.Errorf("error modifying OCI spec: %w", err))
+		}
+		return nil
+	})
+	discovered()
+	if err != nil {
+		return err
+	}
+	if skipped {
+		return nil
+	}
+
+	// The annotation includes the times for the phases that complete before the spec is written.
+	if recorder != nil {
+		if err := r.ociSpec.Modify(recorder); err != nil {
+			r.logger.Debugf("Failed to annotate OCI specification with startup latency: %v", err)
+		}
+	}
+
+	written := recorder.Track(latency.PhaseSpecWrite)
+	err = r.ociSpec.Flush()
+	written()
+	if err != nil {
+		return NewError(ErrorKindDiscovery, fmt.Errorf("error writing modified OCI specification: %v", err))
+	}
+
+	r.logger.WithField(events.Field, events.InjectionComplete).Infof("Applied required modification to OCI specification")
+	return nil
+}
@@ -17,8 +17,10 @@
 package oci
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/runtime-spec/specs-go"
 	testlog "github.com/sirupsen/logrus/hooks/test"
@@ -38,6 +40,7 @@ func TestExec(t *testing.T) {
 		modifyError   error
 		writeError    error
 		modifer       SpecModifier
+		spec          *specs.Spec
 	}{
 		{
 			description:   "no args forwards",
@@ -76,6 +79,43 @@ func TestExec(t *testing.T) {
 			shouldForward: false,
 			modifer:       &modiferMock{},
 		},
+		{
+			description:   "windows spec is not modified and forwards",
+			args:          []string{"create"},
+			shouldLoad:    true,
+			shouldModify:  false,
+			shouldFlush:   false,
+			shouldForward: true,
+			modifer:       &modiferMock{},
+			spec: &specs.Spec{
+				Windows: &specs.Windows{},
+			},
+		},
+		{
+			description:   "vm spec is not modified and forwards",
+			args:          []string{"create"},
+			shouldLoad:    true,
+			shouldModify:  false,
+			shouldFlush:   false,
+			shouldForward: true,
+			modifer:       &modiferMock{},
+			spec: &specs.Spec{
+				Linux: &specs.Linux{},
+				VM:    &specs.VM{},
+			},
+		},
+		{
+			description:   "linux spec is modified",
+			args:          []string{"create"},
+			shouldLoad:    true,
+			shouldModify:  true,
+			shouldFlush:   true,
+			shouldForward: true,
+			modifer:       &modiferMock{},
+			spec: &specs.Spec{
+				Linux: &specs.Linux{},
+			},
+		},
 		{
 			description:   "nil modifier forwards on create",
 			args:          []string{"create"},
@@ -93,6 +133,9 @@ func TestExec(t *testing.T) {
 		t.Run(tc.description, func(t *testing.T) {
 			runtimeMock := &RuntimeMock{}
 			specMock := &SpecMock{
+				LoadFunc: func() (*specs.Spec, error) {
+					return tc.spec, nil
+				},
 				ModifyFunc: func(specModifier SpecModifier) error {
 					return tc.modifyError
 				},
@@ -102,6 +145,7 @@ func TestExec(t *testing.T) {
 			}
 
 			shim := NewModifyingRuntimeWrapper(
+				context.Background(),
 				logger,
 				runtimeMock,
 				specMock,
@@ -140,6 +184,38 @@ func TestExec(t *testing.T) {
 	}
 }
 
+func TestExecModificationTimeout(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	runtimeMock := &RuntimeMock{}
+	specMock := &SpecMock{
+		LoadFunc: func() (*specs.Spec, error) {
+			return &specs.Spec{Linux: &specs.Linux{}}, nil
+		},
+		ModifyFunc: func(specModifier SpecModifier) error {
+			<-block
+			return nil
+		},
+		FlushFunc: func() error {
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	shim := NewModifyingRuntimeWrapper(ctx, logger, runtimeMock, specMock, &modiferMock{})
+
+	err := shim.Exec([]string{"create"})
+	require.Error(t, err)
+	require.Equal(t, ErrorKindTimeout, GetErrorKind(err))
+	require.Equal(t, 0, len(specMock.FlushCalls()))
+	require.Equal(t, 0, len(runtimeMock.ExecCalls()))
+}
+
 func TestNilModiferReturnsRuntime(t *testing.T) {
 	logger, _ := testlog.NewNullLogger()
 
@@ -147,6 +223,7 @@ func TestNilModiferReturnsRuntime(t *testing.T) {
 	specMock := &SpecMock{}
 
 	shim := NewModifyingRuntimeWrapper(
+		context.Background(),
 		logger,
 		runtimeMock,
 		specMock,
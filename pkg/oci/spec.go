@@ -0,0 +1,117 @@
+/*
+# Copyright (c) 2021, NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+*/
+
+package oci
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// SpecModifier defines an interace for modifying a (raw) OCI spec
+type SpecModifier interface {
+	// Modify is a method that accepts a pointer to an OCI Srec and returns an
+	// error. The intention is that the function would modify the spec in-place.
+	Modify(*specs.Spec) error
+}
+
+//go:generate moq -stub -out spec_mock.go . Spec
+// Spec defines the operations to be performed on an OCI specification
+type Spec interface {
+	// Load reads the OCI specification from its source and returns it. The returned
+	// specification is modified in-place by subsequent calls to Modify.
+	Load() (*specs.Spec, error)
+	// Flush writes the (modified) OCI specification back to its source.
+	Flush() error
+	// Modify applies the specified modifier to the loaded OCI specification. An error is
+	// returned if the specification has not been loaded.
+	Modify(SpecModifier) error
+	// LookupEnv returns the value of the specified environment variable of the process in
+	// the loaded OCI specification and whether it is set.
+	LookupEnv(string) (string, bool)
+}
+
+// SpecSource constructs the OCI specification for the specified command line arguments.
+// This allows the location from which the specification is loaded (and to which it is flushed)
+// to be overridden when embedding the runtime, for example in a shim.
+type SpecSource func(*logrus.Logger, []string) (Spec, error)
+
+var _ SpecSource = NewSpec
+
+// NewSpec creates fileSpec based on the command line arguments passed to the
+// application using the specified logger. The path of the file is determined
+// by the bundle directory and the (optional) --config flag.
+func NewSpec(logger *logrus.Logger, args []string) (Spec, error) {
+	ociSpecPath, err := GetSpecFilePathFromArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("error getting OCI specification file path: %v", err)
+	}
+	logger.Infof("Using OCI specification file path: %v", ociSpecPath)
+
+	ociSpec := NewFileSpec(ociSpecPath)
+
+	return ociSpec, nil
+}
+
+// FileSpecSource returns a SpecSource for the OCI specification at the specified path.
+// The command line arguments are ignored.
+func FileSpecSource(path string) SpecSource {
+	return func(logger *logrus.Logger, _ []string) (Spec, error) {
+		logger.Infof("Using OCI specification file path: %v", path)
+		return NewFileSpec(path), nil
+	}
+}
+
+// FDSpecSource returns a SpecSource for the OCI specification provided using the specified
+// file descriptor. The command line arguments are ignored.
+func FDSpecSource(fd uintptr) SpecSource {
+	return func(logger *logrus.Logger, _ []string) (Spec, error) {
+		logger.Infof("Using OCI specification from file descriptor %d", fd)
+		return NewFDSpec(fd), nil
+	}
+}
+
+// MemorySpecSource returns a SpecSource for the specified in-memory OCI specification.
+// The command line arguments are ignored.
+func MemorySpecSource(spec *specs.Spec) SpecSource {
+	return func(*logrus.Logger, []string) (Spec, error) {
+		return NewMemorySpec(spec), nil
+	}
+}
+
+// GetNonLinuxPlatform returns the name of the non-Linux platform that the specified OCI specification
+// targets. Specifications with a windows section (e.g. WCOW or LCOW containers) or a vm section
+// (e.g. for VM-based runtimes) are not Linux containers on the host, meaning that Linux-specific
+// edits such as host device nodes and mounts do not apply. An empty string is returned for a
+// Linux (or empty) specification.
+func GetNonLinuxPlatform(spec *specs.Spec) string {
+	if spec == nil {
+		return ""
+	}
+	switch {
+	case spec.Windows != nil:
+		return "windows"
+	case spec.VM != nil:
+		return "vm"
+	case spec.Solaris != nil:
+		return "solaris"
+	case spec.ZOS != nil:
+		return "zos"
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+type fdSpec struct {
+	memorySpec
+	file *os.File
+}
+
+var _ Spec = (*fdSpec)(nil)
+
+// NewFDSpec creates an object that encapsulates an OCI spec provided using a file descriptor.
+// If the file descriptor is seekable (e.g. a regular file or a memfd), the spec can be
+// reloaded and is written back to the same file descriptor when flushed. If the file
+// descriptor is not seekable (e.g. a pipe such as stdin), the spec can only be read once
+// and cannot be flushed.
+func NewFDSpec(fd uintptr) Spec {
+	oci := fdSpec{
+		file: os.NewFile(fd, fmt.Sprintf("fd %d", fd)),
+	}
+
+	return &oci
+}
+
+// Load reads the contents of an OCI spec from the file descriptor. If the file descriptor
+// is not seekable and the spec has already been loaded, the previously loaded spec is returned.
+func (s *fdSpec) Load() (*specs.Spec, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil && s.Spec != nil {
+		return s.Spec, nil
+	}
+
+	spec, err := LoadFrom(s.file)
+	if err != nil {
+		return nil, fmt.Errorf("error loading OCI specification from %v: %v", s.file.Name(), err)
+	}
+	s.Spec = spec
+	return s.Spec, nil
+}
+
+// Modify applies the specified SpecModifier to the stored OCI specification.
+func (s *fdSpec) Modify(m SpecModifier) error {
+	return s.memorySpec.Modify(m)
+}
+
+// Flush replaces the contents of the file descriptor with the stored OCI specification.
+func (s fdSpec) Flush() error {
+	if s.Spec == nil {
+		return fmt.Errorf("no OCI specification loaded")
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("cannot write OCI specification to %v: %v", s.file.Name(), err)
+	}
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("error truncating %v: %v", s.file.Name(), err)
+	}
+
+	return flushTo(s.Spec, s.file)
+}
@@ -0,0 +1,83 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFDSpec(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "config.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(`{"process": {"env": ["NVIDIA_VISIBLE_DEVICES=all"]}}`)
+	require.NoError(t, err)
+
+	spec := NewFDSpec(f.Fd())
+
+	loaded, err := spec.Load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"NVIDIA_VISIBLE_DEVICES=all"}, loaded.Process.Env)
+
+	err = spec.Modify(modifierFunc(func(s *specs.Spec) error {
+		s.Process.Env = []string{"FOO=bar"}
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NoError(t, spec.Flush())
+
+	reloaded, err := NewFDSpec(f.Fd()).Load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"FOO=bar"}, reloaded.Process.Env)
+
+	contents, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ociVersion": "", "process": {"cwd": "", "env": ["FOO=bar"], "user": {"gid": 0, "uid": 0}}}`, string(contents))
+}
+
+func TestFDSpecPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	_, err = w.WriteString(`{"process": {"env": ["NVIDIA_VISIBLE_DEVICES=all"]}}`)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	spec := NewFDSpec(r.Fd())
+
+	loaded, err := spec.Load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"NVIDIA_VISIBLE_DEVICES=all"}, loaded.Process.Env)
+
+	reloaded, err := spec.Load()
+	require.NoError(t, err)
+	require.Equal(t, loaded, reloaded)
+
+	require.Error(t, spec.Flush())
+}
+
+type modifierFunc func(*specs.Spec) error
+
+func (m modifierFunc) Modify(s *specs.Spec) error {
+	return m(s)
+}
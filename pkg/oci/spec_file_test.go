@@ -40,6 +40,27 @@ func TestLoadFrom(t *testing.T) {
 			isError:  false,
 			spec:     &specs.Spec{},
 		},
+		{
+			contents: []byte(`{"windows": {"layerFolders": ["C:\\layers\\1"], "hyperv": {}}}`),
+			isError:  false,
+			spec: &specs.Spec{
+				Windows: &specs.Windows{
+					LayerFolders: []string{`C:\layers\1`},
+					HyperV:       &specs.WindowsHyperV{},
+				},
+			},
+		},
+		{
+			contents: []byte(`{"linux": {}, "vm": {"hypervisor": {"path": "/usr/bin/qemu"}, "kernel": {"path": "/boot/vmlinuz"}}}`),
+			isError:  false,
+			spec: &specs.Spec{
+				Linux: &specs.Linux{},
+				VM: &specs.VM{
+					Hypervisor: specs.VMHypervisor{Path: "/usr/bin/qemu"},
+					Kernel:     specs.VMKernel{Path: "/boot/vmlinuz"},
+				},
+			},
+		},
 	}
 
 	for i, tc := range testCases {
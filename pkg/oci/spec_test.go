@@ -0,0 +1,113 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainSpec(t *testing.T) {
+	moduleRoot, err := test.GetModuleRoot()
+	require.NoError(t, err)
+
+	files := []string{
+		"config.clone3.json",
+		"config.windows.json",
+		"config.vm.json",
+	}
+
+	for _, f := range files {
+		inputSpecPath := filepath.Join(moduleRoot, "test/input", f)
+
+		spec := NewFileSpec(inputSpecPath).(*fileSpec)
+
+		spec.Load()
+
+		outputSpecPath := filepath.Join(t.TempDir(), f)
+		spec.path = outputSpecPath
+		spec.Flush()
+
+		inputContents, err := os.ReadFile(inputSpecPath)
+		require.NoError(t, err)
+
+		outputContents, err := os.ReadFile(outputSpecPath)
+		require.NoError(t, err)
+
+		require.JSONEq(t, string(inputContents), string(outputContents))
+	}
+}
+
+func TestGetNonLinuxPlatform(t *testing.T) {
+	testCases := []struct {
+		description      string
+		spec             *specs.Spec
+		expectedPlatform string
+	}{
+		{
+			description: "nil spec",
+		},
+		{
+			description: "empty spec",
+			spec:        &specs.Spec{},
+		},
+		{
+			description: "linux spec",
+			spec: &specs.Spec{
+				Linux: &specs.Linux{},
+			},
+		},
+		{
+			description: "windows spec",
+			spec: &specs.Spec{
+				Windows: &specs.Windows{},
+			},
+			expectedPlatform: "windows",
+		},
+		{
+			description: "windows spec with linux section",
+			spec: &specs.Spec{
+				Linux:   &specs.Linux{},
+				Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+			},
+			expectedPlatform: "windows",
+		},
+		{
+			description: "vm spec",
+			spec: &specs.Spec{
+				Linux: &specs.Linux{},
+				VM:    &specs.VM{},
+			},
+			expectedPlatform: "vm",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedPlatform, GetNonLinuxPlatform(tc.spec))
+		})
+	}
+}
+
+func TestLoadNonLinuxSpecs(t *testing.T) {
+	moduleRoot, err := test.GetModuleRoot()
+	require.NoError(t, err)
+
+	testCases := map[string]string{
+		"config.windows.json": "windows",
+		"config.vm.json":      "vm",
+	}
+
+	for f, platform := range testCases {
+		spec, err := NewFileSpec(filepath.Join(moduleRoot, "test/input", f)).Load()
+		require.NoError(t, err)
+		require.Equal(t, platform, GetNonLinuxPlatform(spec))
+
+		value, exists := NewMemorySpec(spec).LookupEnv("NVIDIA_VISIBLE_DEVICES")
+		require.True(t, exists)
+		require.Equal(t, "all", value)
+	}
+}
@@ -0,0 +1,24 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package requirements evaluates the NVIDIA_REQUIRE_* requirements of container images (e.g.
+// "cuda>=12.0 brand=tesla,driver>=470") against the properties of a node. Space-separated
+// constraints are ORed together and comma-separated constraints are ANDed together.
+//
+// The properties can be queried from the driver (as in legacy and CSV mode) or embedded in and read
+// from a CDI specification using the NVIDIA_NODE_PROPERTIES environment variable, which allows the
+// requirements to also be checked in CDI mode and offline.
+package requirements
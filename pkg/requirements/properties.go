@@ -0,0 +1,119 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package requirements
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodePropertiesEnvvar is the environment variable in which the properties of a node are embedded
+// in a CDI specification. This allows the requirements of images to be checked against a
+// specification without querying the driver.
+const NodePropertiesEnvvar = "NVIDIA_NODE_PROPERTIES"
+
+// Properties are the properties of a node against which requirements are checked. Empty
+// properties are not set.
+type Properties struct {
+	CUDA   string
+	Driver string
+	Arch   string
+	Brand  string
+}
+
+// AddProperties adds the non-empty node properties to the requirements.
+func (r *Requirements) AddProperties(p Properties) {
+	if p.CUDA != "" {
+		r.AddVersionProperty(CUDA, p.CUDA)
+	}
+	if p.Driver != "" {
+		r.AddVersionProperty(DRIVER, p.Driver)
+	}
+	if p.Arch != "" {
+		r.AddVersionProperty(ARCH, p.Arch)
+	}
+	if p.Brand != "" {
+		r.AddStringProperty(BRAND, p.Brand)
+	}
+}
+
+// String returns the non-empty properties as a comma-separated list of name=value pairs
+// (e.g. cuda=12.0,driver=525.60.13,arch=8.0,brand=tesla).
+func (p Properties) String() string {
+	var pairs []string
+	for _, property := range []struct {
+		name  string
+		value string
+	}{
+		{CUDA, p.CUDA},
+		{DRIVER, p.Driver},
+		{ARCH, p.Arch},
+		{BRAND, p.Brand},
+	} {
+		if property.value == "" {
+			continue
+		}
+		pairs = append(pairs, property.name+"="+property.value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseProperties parses node properties from a comma-separated list of name=value pairs.
+func ParseProperties(value string) (*Properties, error) {
+	var p Properties
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(pair, "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("invalid node property %q", pair)
+		}
+		switch name {
+		case CUDA:
+			p.CUDA = v
+		case DRIVER:
+			p.Driver = v
+		case ARCH:
+			p.Arch = v
+		case BRAND:
+			p.Brand = v
+		default:
+			return nil, fmt.Errorf("unsupported node property %q", name)
+		}
+	}
+	return &p, nil
+}
+
+// GetPropertiesFromEnv returns the node properties embedded in the specified environment (as a list
+// of KEY=VALUE strings). If the properties are set more than once, the last value is used. If the
+// properties are not set, nil is returned.
+func GetPropertiesFromEnv(env []string) (*Properties, error) {
+	var value string
+	var found bool
+	for _, e := range env {
+		if k, v, ok := strings.Cut(e, "="); ok && k == NodePropertiesEnvvar {
+			value = v
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return ParseProperties(value)
+}
@@ -0,0 +1,117 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package requirements
+
+import (
+	"testing"
+
+	testlog "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProperties(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expected      *Properties
+		expectedError bool
+	}{
+		{
+			value:    "",
+			expected: &Properties{},
+		},
+		{
+			value:    "cuda=12.0,driver=525.60.13,arch=8.0,brand=tesla",
+			expected: &Properties{CUDA: "12.0", Driver: "525.60.13", Arch: "8.0", Brand: "tesla"},
+		},
+		{
+			value:    " driver=525.60.13 ,",
+			expected: &Properties{Driver: "525.60.13"},
+		},
+		{
+			value:         "driver",
+			expectedError: true,
+		},
+		{
+			value:         "memory=16G",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			p, err := ParseProperties(tc.value)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestPropertiesString(t *testing.T) {
+	p := Properties{Driver: "525.60.13", Brand: "tesla", CUDA: "12.0"}
+	require.Equal(t, "cuda=12.0,driver=525.60.13,brand=tesla", p.String())
+
+	parsed, err := ParseProperties(p.String())
+	require.NoError(t, err)
+	require.Equal(t, p, *parsed)
+}
+
+func TestGetPropertiesFromEnv(t *testing.T) {
+	p, err := GetPropertiesFromEnv([]string{"PATH=/usr/bin"})
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	p, err = GetPropertiesFromEnv([]string{
+		"NVIDIA_NODE_PROPERTIES=driver=470.82.01",
+		"NVIDIA_NODE_PROPERTIES=cuda=12.0,driver=525.60.13",
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Properties{CUDA: "12.0", Driver: "525.60.13"}, p)
+}
+
+func TestAssertWithProperties(t *testing.T) {
+	logger, _ := testlog.NewNullLogger()
+	properties := Properties{CUDA: "12.0", Driver: "525.60.13", Arch: "8.0", Brand: "tesla"}
+
+	testCases := []struct {
+		requirement   string
+		expectedError bool
+	}{
+		{requirement: "cuda>=11.8"},
+		{requirement: "cuda>=12.1", expectedError: true},
+		{requirement: "brand=tesla,driver>=470"},
+		{requirement: "brand=geforce driver>=470"},
+		{requirement: "brand=geforce,driver>=470", expectedError: true},
+		{requirement: "arch=8.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.requirement, func(t *testing.T) {
+			r := New(logger, []string{tc.requirement})
+			r.AddProperties(properties)
+			err := r.Assert()
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package state provides access to the devices allocated to containers by the NVIDIA Container
+// Runtime. If the recording of allocations is enabled (nvidia-container-runtime.allocations.enabled),
+// the runtime adds the allocation of each container as an annotation to its OCI specification and
+// records it in a state dir. Monitoring agents (e.g. DCGM exporter wrappers) can use Lookup to map a
+// container ID to the UUIDs of its GPUs without inspecting the processes of the container.
+package state
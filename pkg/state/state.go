@@ -0,0 +1,230 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// DefaultStateDir is the directory in which the allocation of each container is recorded. Since /run
+// is cleared on boot, only the containers created since boot are included.
+const DefaultStateDir = "/run/nvidia-container-toolkit/allocations"
+
+// AllocationAnnotation is the annotation that the allocation of a container is added as to its OCI
+// specification. Its value is the JSON representation of the Allocation.
+const AllocationAnnotation = "nvidia.com/container-toolkit.allocation"
+
+// ErrNotFound is returned if no allocation is recorded for a container.
+var ErrNotFound = errors.New("allocation not found")
+
+// Allocation is the set of devices allocated to a container.
+type Allocation struct {
+	ContainerID string    `json:"containerId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Devices are the GPUs injected into the container ordered by UUID. For MIG devices that are
+	// requested by UUID, the UUID of the MIG device is included.
+	Devices []Device `json:"devices"`
+}
+
+// Device is a device allocated to a container.
+type Device struct {
+	UUID  string `json:"uuid"`
+	BusID string `json:"busId,omitempty"`
+	// DeviceNode is the device node of the GPU (e.g. /dev/nvidia0).
+	DeviceNode string `json:"deviceNode,omitempty"`
+}
+
+// UUIDs returns the UUIDs of the allocated devices.
+func (a Allocation) UUIDs() []string {
+	var uuids []string
+	for _, d := range a.Devices {
+		uuids = append(uuids, d.UUID)
+	}
+	return uuids
+}
+
+// Option is a function that configures a lookup.
+type Option func(*lookup)
+
+type lookup struct {
+	stateDir string
+	bundle   string
+}
+
+// WithStateDir sets the directory in which allocations are recorded. If this option is not
+// specified, DefaultStateDir is used.
+func WithStateDir(dir string) Option {
+	return func(l *lookup) {
+		l.stateDir = dir
+	}
+}
+
+// WithBundle sets the OCI bundle of the container. If no allocation is recorded in the state dir, the
+// allocation annotation in the config.json of the bundle is used instead.
+func WithBundle(bundle string) Option {
+	return func(l *lookup) {
+		l.bundle = bundle
+	}
+}
+
+// Lookup returns the devices allocated to the specified container. The allocation is read from the
+// state dir and, if not recorded there and a bundle is specified, from the annotations of the OCI
+// specification of the container. If no allocation is found, ErrNotFound is returned.
+func Lookup(containerID string, opts ...Option) (Allocation, error) {
+	l := lookup{
+		stateDir: DefaultStateDir,
+	}
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	a, err := NewStore(l.stateDir).Lookup(containerID)
+	if !errors.Is(err, ErrNotFound) || l.bundle == "" {
+		return a, err
+	}
+
+	contents, err := os.ReadFile(filepath.Join(l.bundle, "config.json"))
+	if err != nil {
+		return Allocation{}, fmt.Errorf("failed to read OCI specification: %v", err)
+	}
+	var spec specs.Spec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return Allocation{}, fmt.Errorf("failed to parse OCI specification: %v", err)
+	}
+	a, err = FromAnnotations(spec.Annotations)
+	if err != nil {
+		return Allocation{}, err
+	}
+	if a.ContainerID == "" {
+		a.ContainerID = containerID
+	}
+	return a, nil
+}
+
+// FromAnnotations returns the allocation from the specified annotations of an OCI specification. If
+// the allocation annotation is not set, ErrNotFound is returned.
+func FromAnnotations(annotations map[string]string) (Allocation, error) {
+	value, ok := annotations[AllocationAnnotation]
+	if !ok {
+		return Allocation{}, ErrNotFound
+	}
+	var a Allocation
+	if err := json.Unmarshal([]byte(value), &a); err != nil {
+		return Allocation{}, fmt.Errorf("invalid %v annotation: %v", AllocationAnnotation, err)
+	}
+	return a, nil
+}
+
+// Store records the allocation of each container as a JSON file in a state dir.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store for the specified state dir. If the dir is empty, DefaultStateDir is used.
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultStateDir
+	}
+	return &Store{dir: dir}
+}
+
+// Save records the specified allocation. Since the file is replaced atomically, concurrent lookups
+// never read a partially-written allocation.
+func (s *Store) Save(a Allocation) error {
+	path, err := s.path(a.ContainerID)
+	if err != nil {
+		return err
+	}
+	contents, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allocation: %v", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state dir: %v", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".allocation-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write allocation: %v", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set permissions of allocation: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write allocation: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace allocation: %v", err)
+	}
+	return nil
+}
+
+// Lookup returns the allocation recorded for the specified container. If no allocation is recorded,
+// ErrNotFound is returned.
+func (s *Store) Lookup(containerID string) (Allocation, error) {
+	path, err := s.path(containerID)
+	if err != nil {
+		return Allocation{}, err
+	}
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Allocation{}, ErrNotFound
+	}
+	if err != nil {
+		return Allocation{}, fmt.Errorf("failed to read allocation: %v", err)
+	}
+	var a Allocation
+	if err := json.Unmarshal(contents, &a); err != nil {
+		return Allocation{}, fmt.Errorf("failed to parse allocation: %v", err)
+	}
+	return a, nil
+}
+
+// Remove removes the allocation recorded for the specified container. Removing an allocation that is
+// not recorded is not an error.
+func (s *Store) Remove(containerID string) error {
+	path, err := s.path(containerID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove allocation: %v", err)
+	}
+	return nil
+}
+
+// path returns the path of the file in which the allocation of the specified container is recorded.
+func (s *Store) path(containerID string) (string, error) {
+	if containerID == "" || strings.ContainsAny(containerID, "/\\") || strings.HasPrefix(containerID, ".") {
+		return "", fmt.Errorf("invalid container ID %q", containerID)
+	}
+	return filepath.Join(s.dir, containerID+".json"), nil
+}
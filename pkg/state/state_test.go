@@ -0,0 +1,133 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "allocations"))
+
+	_, err := store.Lookup("abc")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	a := Allocation{
+		ContainerID: "abc",
+		CreatedAt:   time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC),
+		Devices: []Device{
+			{UUID: "GPU-7ab1e2c4", BusID: "0000:5e:00.0", DeviceNode: "/dev/nvidia1"},
+			{UUID: "GPU-edfee158", BusID: "0000:3b:00.0", DeviceNode: "/dev/nvidia0"},
+		},
+	}
+	require.NoError(t, store.Save(a))
+
+	found, err := store.Lookup("abc")
+	require.NoError(t, err)
+	require.Equal(t, a, found)
+	require.Equal(t, []string{"GPU-7ab1e2c4", "GPU-edfee158"}, found.UUIDs())
+
+	require.NoError(t, store.Remove("abc"))
+	require.NoError(t, store.Remove("abc"))
+	_, err = store.Lookup("abc")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	for _, id := range []string{"", "../abc", ".hidden"} {
+		require.Error(t, store.Save(Allocation{ContainerID: id}))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	stateDir := t.TempDir()
+	recorded := Allocation{
+		ContainerID: "recorded",
+		CreatedAt:   time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC),
+		Devices:     []Device{{UUID: "GPU-edfee158"}},
+	}
+	require.NoError(t, NewStore(stateDir).Save(recorded))
+
+	annotated := Allocation{
+		CreatedAt: time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC),
+		Devices:   []Device{{UUID: "GPU-7ab1e2c4"}},
+	}
+	value, err := json.Marshal(annotated)
+	require.NoError(t, err)
+
+	bundle := t.TempDir()
+	config, err := json.Marshal(specs.Spec{Annotations: map[string]string{AllocationAnnotation: string(value)}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(bundle, "config.json"), config, 0644))
+
+	emptyBundle := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emptyBundle, "config.json"), []byte("{}"), 0644))
+
+	testCases := []struct {
+		description        string
+		containerID        string
+		opts               []Option
+		expectedAllocation Allocation
+		expectedError      error
+	}{
+		{
+			description:        "recorded allocation",
+			containerID:        "recorded",
+			opts:               []Option{WithStateDir(stateDir), WithBundle(bundle)},
+			expectedAllocation: recorded,
+		},
+		{
+			description:   "not recorded without bundle",
+			containerID:   "annotated",
+			opts:          []Option{WithStateDir(stateDir)},
+			expectedError: ErrNotFound,
+		},
+		{
+			description: "annotated allocation",
+			containerID: "annotated",
+			opts:        []Option{WithStateDir(stateDir), WithBundle(bundle)},
+			expectedAllocation: Allocation{
+				ContainerID: "annotated",
+				CreatedAt:   annotated.CreatedAt,
+				Devices:     annotated.Devices,
+			},
+		},
+		{
+			description:   "no annotation",
+			containerID:   "annotated",
+			opts:          []Option{WithStateDir(stateDir), WithBundle(emptyBundle)},
+			expectedError: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			a, err := Lookup(tc.containerID, tc.opts...)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedAllocation, a)
+		})
+	}
+}
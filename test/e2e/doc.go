@@ -0,0 +1,24 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+// Package e2e contains conformance tests that run the NVIDIA Container Toolkit
+// against real container engines. The tests are only built when the `e2e` build
+// tag is specified:
+//
+//	go test -tags e2e ./test/e2e/...
+//
+// See README.md for the required environment.
+package e2e
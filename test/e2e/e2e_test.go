@@ -0,0 +1,110 @@
+//go:build e2e
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+const engineReadyTimeout = 2 * time.Minute
+
+func TestEngines(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker CLI is required to start the engine containers")
+	}
+
+	binDir, err := getBinDir()
+	require.NoError(t, err)
+
+	for _, e := range getEngines() {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			if e.image == "" {
+				t.Skipf("no image specified for %v engine", e.name)
+			}
+			if e.configure == "" {
+				t.Skipf("no configure command defined for %v engine", e.name)
+			}
+
+			fixtures := createFixtures(t, binDir)
+			r := startEngine(t, e, fixtures)
+
+			r.mustExec(e.configure)
+			config := r.mustExec("cat " + e.configPath)
+			require.Contains(t, config, "nvidia", "runtime missing from %v config:\n%v", e.name, config)
+			require.Contains(t, config, e2eBinDir+"/nvidia-container-runtime")
+
+			r.mustExec("(" + e.start + ") &")
+			r.waitFor(e.ready, engineReadyTimeout)
+
+			// The container itself may fail to start since the fake driver root
+			// does not contain a functional driver. The spec is recorded by the
+			// low-level runtime before the container is created.
+			if out, err := r.exec(e.run); err != nil {
+				t.Logf("running container failed: %v: %s", err, out)
+			}
+
+			spec := getRecordedSpec(t, filepath.Join(fixtures, "specs"))
+			require.Contains(t, getMounts(spec), e2eDriverRoot+fakeDriverLibrary+":"+fakeDriverLibrary)
+		})
+	}
+}
+
+// getRecordedSpec returns the recorded OCI specification of the container
+// requesting GPUs. Specs for other containers (e.g. pod sandboxes) are ignored.
+func getRecordedSpec(t *testing.T, dir string) *specs.Spec {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		var spec specs.Spec
+		require.NoError(t, json.Unmarshal(contents, &spec), "invalid spec %v", file)
+		if spec.Process == nil {
+			continue
+		}
+		for _, env := range spec.Process.Env {
+			if strings.HasPrefix(env, "NVIDIA_VISIBLE_DEVICES=") {
+				return &spec
+			}
+		}
+	}
+	t.Fatalf("no spec requesting GPUs recorded in %v (found %d specs)", dir, len(files))
+	return nil
+}
+
+// getMounts returns the mounts in the specified spec as source:destination pairs.
+func getMounts(spec *specs.Spec) []string {
+	var mounts []string
+	for _, m := range spec.Mounts {
+		mounts = append(mounts, m.Source+":"+m.Destination)
+	}
+	return mounts
+}
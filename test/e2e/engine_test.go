@@ -0,0 +1,70 @@
+//go:build e2e
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package e2e
+
+// engine defines how a container engine is started, configured, and used to
+// launch a container in the e2e tests.
+type engine struct {
+	name string
+	// image is the container image in which the engine is run.
+	image string
+	// configPath is the path to the engine config that is updated by nvidia-ctk.
+	configPath string
+	// configure is the nvidia-ctk command used to configure the engine.
+	// If this is empty, the tests for the engine are skipped.
+	configure string
+	// start starts the engine daemon in the background.
+	start string
+	// ready succeeds once the engine is ready to run containers.
+	ready string
+	// run launches a GPU-stub container using the nvidia runtime.
+	run string
+}
+
+// getEngines returns the engines to run the e2e tests against. The images used
+// can be overridden using the E2E_<ENGINE>_IMAGE environment variables. If an
+// image is not set for an engine, the tests for that engine are skipped.
+func getEngines() []engine {
+	return []engine{
+		{
+			name:       "docker",
+			image:      getEnvDefault("E2E_DOCKER_IMAGE", "docker:dind"),
+			configPath: "/etc/docker/daemon.json",
+			configure:  "nvidia-ctk runtime configure --runtime=docker --config=/etc/docker/daemon.json --runtime-path=" + e2eBinDir + "/nvidia-container-runtime",
+			start:      "dockerd > /var/log/dockerd.log 2>&1",
+			ready:      "docker info",
+			run:        "docker run --rm --runtime=nvidia -e NVIDIA_VISIBLE_DEVICES=all busybox true",
+		},
+		{
+			name:       "crio",
+			image:      getEnvDefault("E2E_CRIO_IMAGE", ""),
+			configPath: "/etc/crio/crio.conf",
+			configure:  "nvidia-ctk runtime configure --runtime=crio --config=/etc/crio/crio.conf --runtime-path=" + e2eBinDir + "/nvidia-container-runtime",
+			start:      "crio > /var/log/crio.log 2>&1",
+			ready:      "crictl info",
+			run: "pod=$(crictl runp --runtime=nvidia " + e2eDir + "/crio/pod.json) && " +
+				"ctr=$(crictl create $pod " + e2eDir + "/crio/container.json " + e2eDir + "/crio/pod.json) && " +
+				"crictl start $ctr",
+		},
+		{
+			name:  "containerd",
+			image: getEnvDefault("E2E_CONTAINERD_IMAGE", ""),
+		},
+	}
+}
@@ -0,0 +1,119 @@
+//go:build e2e
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test/driverroot"
+)
+
+const (
+	fakeDriverLibrary = "/usr/lib64/libcuda.so." + driverroot.DefaultDriverVersion
+
+	recordingRuntime = `#!/bin/sh
+# Record the OCI specification of the container being created and forward the
+# command to runc.
+bundle=""
+previous=""
+for arg in "$@"; do
+	case "$previous" in --bundle|-b) bundle="$arg";; esac
+	case "$arg" in --bundle=*) bundle="${arg#--bundle=}";; esac
+	previous="$arg"
+done
+if [ -n "$bundle" ] && [ -f "$bundle/config.json" ]; then
+	cp "$bundle/config.json" "` + e2eSpecDir + `/$(basename "$bundle").json"
+fi
+exec runc "$@"
+`
+
+	toolkitConfig = `
+[nvidia-container-cli]
+root = "` + e2eDriverRoot + `"
+
+[nvidia-container-runtime]
+log-level = "debug"
+debug = "` + e2eDir + `/nvidia-container-runtime.log"
+mode = "csv"
+runtimes = ["` + e2eBinDir + `/recording-runc"]
+
+[nvidia-container-runtime.modes.csv]
+mount-spec-path = "` + e2eDir + `/csv"
+
+[nvidia-ctk]
+path = "` + e2eBinDir + `/nvidia-ctk"
+`
+
+	crioPodConfig = `{"metadata": {"name": "e2e", "namespace": "default", "uid": "e2e"}, "linux": {}}`
+
+	crioContainerConfig = `{
+	"metadata": {"name": "e2e"},
+	"image": {"image": "busybox"},
+	"command": ["true"],
+	"envs": [{"key": "NVIDIA_VISIBLE_DEVICES", "value": "all"}]
+}`
+)
+
+// createFixtures creates the files that are mounted into the engine container at /e2e.
+// These include the toolkit binaries, a recording low-level runtime, a toolkit config,
+// and a fake driver root with a CSV file describing the files to inject.
+func createFixtures(t *testing.T, binDir string) string {
+	fixtures := t.TempDir()
+
+	files := map[string]string{
+		"config/nvidia-container-runtime/config.toml": toolkitConfig,
+		"csv/drivers.csv":     "lib, " + fakeDriverLibrary + "\n",
+		"crio/pod.json":       crioPodConfig,
+		"crio/container.json": crioContainerConfig,
+	}
+	for name, contents := range files {
+		writeFile(t, filepath.Join(fixtures, name), contents, 0644)
+	}
+	writeFile(t, filepath.Join(fixtures, "bin", "recording-runc"), recordingRuntime, 0755)
+
+	if err := driverroot.Create(filepath.Join(fixtures, "driver-root"), driverroot.WithGPUs(1)); err != nil {
+		t.Fatalf("failed to create fake driver root: %v", err)
+	}
+
+	for _, executable := range []string{"nvidia-ctk", "nvidia-container-runtime"} {
+		contents, err := os.ReadFile(filepath.Join(binDir, executable))
+		if err != nil {
+			t.Fatalf("failed to read %v binary from %v (set E2E_BIN_DIR): %v", executable, binDir, err)
+		}
+		writeFile(t, filepath.Join(fixtures, "bin", executable), string(contents), 0755)
+	}
+
+	if err := os.MkdirAll(filepath.Join(fixtures, "specs"), 0777); err != nil {
+		t.Fatalf("failed to create spec directory: %v", err)
+	}
+
+	return fixtures
+}
+
+func writeFile(t *testing.T, path string, contents string, mode os.FileMode) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory for %v: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(contents), mode); err != nil {
+		t.Fatal(fmt.Errorf("failed to write %v: %v", path, err))
+	}
+}
@@ -0,0 +1,119 @@
+//go:build e2e
+
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/test"
+)
+
+const (
+	// The paths at which the test fixtures are mounted in the engine containers.
+	e2eDir        = "/e2e"
+	e2eBinDir     = e2eDir + "/bin"
+	e2eSpecDir    = e2eDir + "/specs"
+	e2eDriverRoot = e2eDir + "/driver-root"
+)
+
+// runner executes commands in an engine container using the docker CLI of the host.
+type runner struct {
+	t         *testing.T
+	container string
+}
+
+// startEngine starts the specified engine in a privileged container with the
+// fixtures directory mounted at /e2e.
+func startEngine(t *testing.T, e engine, fixtures string) *runner {
+	name := fmt.Sprintf("nvidia-container-toolkit-e2e-%s-%d", e.name, time.Now().UnixNano())
+	args := []string{
+		"run", "-d", "--rm", "--privileged",
+		"--name", name,
+		"-v", fixtures + ":" + e2eDir,
+		"-e", "XDG_CONFIG_HOME=" + e2eDir + "/config",
+		"-e", "PATH=" + e2eBinDir + ":/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"--entrypoint", "sh",
+		e.image,
+		"-c", "sleep infinity",
+	}
+	if out, err := exec.Command("docker", args...).CombinedOutput(); err != nil {
+		t.Fatalf("failed to start %v engine container: %v: %s", e.name, err, out)
+	}
+
+	r := &runner{t: t, container: name}
+	t.Cleanup(func() {
+		exec.Command("docker", "kill", name).Run()
+	})
+	return r
+}
+
+// exec runs the specified shell command in the engine container and returns its output.
+func (r *runner) exec(command string) (string, error) {
+	out, err := exec.Command("docker", "exec", r.container, "sh", "-c", command).CombinedOutput()
+	return string(out), err
+}
+
+// mustExec runs the specified shell command and fails the test on error.
+func (r *runner) mustExec(command string) string {
+	r.t.Helper()
+	out, err := r.exec(command)
+	if err != nil {
+		r.t.Fatalf("command %q failed: %v: %s", command, err, out)
+	}
+	return out
+}
+
+// waitFor retries the specified shell command until it succeeds or the timeout expires.
+func (r *runner) waitFor(command string, timeout time.Duration) {
+	r.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		out, err := r.exec(command)
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			r.t.Fatalf("timed out waiting for %q: %v: %s", command, err, out)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// getBinDir returns the directory containing the toolkit binaries under test.
+// This is read from the E2E_BIN_DIR environment variable and defaults to the module root.
+func getBinDir() (string, error) {
+	if dir := os.Getenv("E2E_BIN_DIR"); dir != "" {
+		return filepath.Abs(dir)
+	}
+	return test.GetModuleRoot()
+}
+
+// getEnvDefault returns the value of the specified environment variable or the default if it is not set.
+func getEnvDefault(name string, defaultValue string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,242 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/containerd"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateV3Config(t *testing.T) {
+	const runtimeDir = "/test/runtime/dir"
+
+	testCases := []struct {
+		config         map[string]interface{}
+		setAsDefault   bool
+		expectedConfig map[string]interface{}
+	}{
+		{
+			expectedConfig: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"nvidia":              runtimeMapV3("/test/runtime/dir/nvidia-container-runtime"),
+								"nvidia-experimental": runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.experimental"),
+								"nvidia-cdi":          runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.cdi"),
+								"nvidia-legacy":       runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.legacy"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			setAsDefault: true,
+			expectedConfig: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"nvidia":              runtimeMapV3("/test/runtime/dir/nvidia-container-runtime"),
+								"nvidia-experimental": runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.experimental"),
+								"nvidia-cdi":          runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.cdi"),
+								"nvidia-legacy":       runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.legacy"),
+							},
+							"default_runtime_name": "nvidia",
+						},
+					},
+				},
+			},
+		},
+		{
+			config: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"runc": runcMapV3("/runc-binary"),
+							},
+						},
+					},
+				},
+			},
+			expectedConfig: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"runc":                runcMapV3("/runc-binary"),
+								"nvidia":              runcRuntimeMapV3("/test/runtime/dir/nvidia-container-runtime"),
+								"nvidia-experimental": runcRuntimeMapV3("/test/runtime/dir/nvidia-container-runtime.experimental"),
+								"nvidia-cdi":          runcRuntimeMapV3("/test/runtime/dir/nvidia-container-runtime.cdi"),
+								"nvidia-legacy":       runcRuntimeMapV3("/test/runtime/dir/nvidia-container-runtime.legacy"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			o := &options{
+				runtimeClass: "nvidia",
+				runtimeType:  runtimeType,
+				runtimeDir:   runtimeDir,
+				setAsDefault: tc.setAsDefault,
+			}
+
+			config, err := toml.TreeFromMap(tc.config)
+			require.NoError(t, err)
+
+			v3 := &containerd.ConfigV3{
+				Tree:        config,
+				RuntimeType: runtimeType,
+			}
+
+			err = UpdateConfig(v3, o)
+			require.NoError(t, err)
+
+			expected, err := toml.TreeFromMap(tc.expectedConfig)
+			require.NoError(t, err)
+
+			require.Equal(t, expected.String(), config.String())
+		})
+	}
+}
+
+func TestRevertV3Config(t *testing.T) {
+	testCases := []struct {
+		config   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{},
+		{
+			config: map[string]interface{}{
+				"version": int64(3),
+			},
+		},
+		{
+			config: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"nvidia":              runtimeMapV3("/test/runtime/dir/nvidia-container-runtime"),
+								"nvidia-experimental": runtimeMapV3("/test/runtime/dir/nvidia-container-runtime.experimental"),
+							},
+							"default_runtime_name": "nvidia",
+						},
+					},
+				},
+			},
+		},
+		{
+			config: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"runc":   runcMapV3("/runc-binary"),
+								"nvidia": runtimeMapV3("/test/runtime/dir/nvidia-container-runtime"),
+							},
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"version": int64(3),
+				"plugins": map[string]interface{}{
+					"io.containerd.cri.v1.runtime": map[string]interface{}{
+						"containerd": map[string]interface{}{
+							"runtimes": map[string]interface{}{
+								"runc": runcMapV3("/runc-binary"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			o := &options{
+				runtimeClass: "nvidia",
+			}
+
+			config, err := toml.TreeFromMap(tc.config)
+			require.NoError(t, err)
+
+			expected, err := toml.TreeFromMap(tc.expected)
+			require.NoError(t, err)
+
+			v3 := &containerd.ConfigV3{
+				Tree:        config,
+				RuntimeType: runtimeType,
+			}
+
+			err = RevertConfig(v3, o)
+			require.NoError(t, err)
+
+			configContents, _ := toml.Marshal(config)
+			expectedContents, _ := toml.Marshal(expected)
+
+			require.Equal(t, string(expectedContents), string(configContents))
+		})
+	}
+}
+
+func runtimeMapV3(binary string) map[string]interface{} {
+	return map[string]interface{}{
+		"runtime_type":                    runtimeType,
+		"privileged_without_host_devices": false,
+		"container_annotations":           []string{"cdi.k8s.io/*"},
+		"options": map[string]interface{}{
+			"BinaryName": binary,
+		},
+	}
+}
+
+func runcMapV3(binary string) map[string]interface{} {
+	return map[string]interface{}{
+		"runtime_type":                    "runc_runtime_type",
+		"privileged_without_host_devices": true,
+		"options": map[string]interface{}{
+			"runc-option": "value",
+			"BinaryName":  binary,
+		},
+	}
+}
+
+func runcRuntimeMapV3(binary string) map[string]interface{} {
+	runtime := runcMapV3(binary)
+	runtime["container_annotations"] = []string{"cdi.k8s.io/*"}
+	return runtime
+}
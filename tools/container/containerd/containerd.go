@@ -72,6 +72,7 @@ type options struct {
 	hostRootMount   string
 	runtimeDir      string
 	useLegacyConfig bool
+	dryRun          bool
 }
 
 func main() {
@@ -173,6 +174,12 @@ func main() {
 			Destination: &options.useLegacyConfig,
 			EnvVars:     []string{"CONTAINERD_USE_LEGACY_CONFIG"},
 		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Print a unified diff of the changes to the containerd config instead of writing the config and restarting containerd. Fails if any changes would be made",
+			Destination: &options.dryRun,
+			EnvVars:     []string{"CONTAINERD_DRY_RUN"},
+		},
 	}
 
 	// Update the subcommand flags with the common subcommand flags
@@ -195,6 +202,14 @@ func Setup(c *cli.Context, o *options) error {
 	}
 	o.runtimeDir = runtimeDir
 
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
 	cfg, err := containerd.New(
 		containerd.WithPath(o.config),
 		containerd.WithRuntimeType(o.runtimeType),
@@ -209,6 +224,10 @@ func Setup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
 	log.Infof("Flushing containerd config to %v", o.config)
 	n, err := cfg.Save(o.config)
 	if err != nil {
@@ -237,6 +256,14 @@ func Cleanup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to parse args: %v", err)
 	}
 
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
 	cfg, err := containerd.New(
 		containerd.WithPath(o.config),
 		containerd.WithRuntimeType(o.runtimeType),
@@ -251,6 +278,10 @@ func Cleanup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
 	log.Infof("Flushing containerd config to %v", o.config)
 	n, err := cfg.Save(o.config)
 	if err != nil {
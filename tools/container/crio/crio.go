@@ -42,6 +42,12 @@ const (
 	defaultHookFilename = "oci-nvidia-hook.json"
 
 	// Config-based settings
+	configWriteModeDropIn  = "drop-in"
+	configWriteModeInPlace = "in-place"
+
+	defaultConfigWriteMode = configWriteModeDropIn
+	defaultDropInConfig    = "/etc/crio/crio.conf.d/99-nvidia.conf"
+
 	defaultConfig        = "/etc/crio/crio.conf"
 	defaultRuntimeClass  = "nvidia"
 	defaultSetAsDefault  = true
@@ -57,11 +63,16 @@ type options struct {
 	hookFilename string
 	runtimeDir   string
 
-	config        string
-	runtimeClass  string
-	setAsDefault  bool
-	restartMode   string
-	hostRootMount string
+	config          string
+	configWriteMode string
+	dropInConfig    string
+	runtimeClass    string
+	setAsDefault    bool
+	restartMode     string
+	hostRootMount   string
+
+	skipWorkloadCheck bool
+	dryRun            bool
 }
 
 func main() {
@@ -93,10 +104,23 @@ func main() {
 	cleanup.Action = func(c *cli.Context) error {
 		return Cleanup(c, &options)
 	}
+	// Create the 'migrate' subcommand
+	migrate := cli.Command{}
+	migrate.Name = "migrate"
+	migrate.Usage = "Replace the legacy NVIDIA OCI hooks with a runtime-class based cri-o config"
+	migrate.ArgsUsage = "<toolkit_dirname>"
+	migrate.Action = func(c *cli.Context) error {
+		return Migrate(c, &options)
+	}
+	migrate.Before = func(c *cli.Context) error {
+		return ParseArgs(c, &options)
+	}
+
 	// Register the subcommands with the top-level CLI
 	c.Commands = []*cli.Command{
 		&setup,
 		&cleanup,
+		&migrate,
 	}
 
 	// Setup common flags across both subcommands. All subcommands get the same
@@ -136,6 +160,20 @@ func main() {
 			Destination: &options.config,
 			EnvVars:     []string{"CRIO_CONFIG"},
 		},
+		&cli.StringFlag{
+			Name:        "config-write-mode",
+			Usage:       "how the cri-o config is updated in the config mode. With drop-in, the runtimes are added to the drop-in file specified by --drop-in-config and the config file specified by --config is not modified. With in-place, the config file specified by --config is updated. One of [drop-in | in-place]",
+			Value:       defaultConfigWriteMode,
+			Destination: &options.configWriteMode,
+			EnvVars:     []string{"CRIO_CONFIG_WRITE_MODE"},
+		},
+		&cli.StringFlag{
+			Name:        "drop-in-config",
+			Usage:       "Path to the cri-o drop-in config file that is created / removed for the drop-in config write mode",
+			Value:       defaultDropInConfig,
+			Destination: &options.dropInConfig,
+			EnvVars:     []string{"CRIO_DROP_IN_CONFIG"},
+		},
 		&cli.StringFlag{
 			Name:        "runtime-class",
 			Usage:       "The name of the runtime class to set for the nvidia-container-runtime",
@@ -166,11 +204,26 @@ func main() {
 			Destination: &options.hostRootMount,
 			EnvVars:     []string{"HOST_ROOT_MOUNT"},
 		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Print a unified diff of the changes to the cri-o config instead of writing the config and restarting cri-o. Fails if any changes would be made. This is only supported for the 'config' config-mode",
+			Destination: &options.dryRun,
+			EnvVars:     []string{"CRIO_DRY_RUN"},
+		},
 	}
 
 	// Update the subcommand flags with the common subcommand flags
 	setup.Flags = append([]cli.Flag{}, commonFlags...)
 	cleanup.Flags = append([]cli.Flag{}, commonFlags...)
+	migrate.Flags = append([]cli.Flag{}, commonFlags...)
+	migrate.Flags = append(migrate.Flags,
+		&cli.BoolFlag{
+			Name:        "skip-workload-check",
+			Usage:       "Migrate even if running workloads depend on the legacy hooks",
+			Destination: &options.skipWorkloadCheck,
+			EnvVars:     []string{"CRIO_SKIP_WORKLOAD_CHECK"},
+		},
+	)
 
 	// Run the top-level CLI
 	if err := c.Run(os.Args); err != nil {
@@ -182,6 +235,10 @@ func main() {
 func Setup(c *cli.Context, o *options) error {
 	log.Infof("Starting 'setup' for %v", c.App.Name)
 
+	if o.dryRun && o.configMode != "config" {
+		return fmt.Errorf("--dry-run is only supported for config-mode 'config'")
+	}
+
 	switch o.configMode {
 	case "hook":
 		return setupHook(o)
@@ -212,13 +269,37 @@ func setupHook(o *options) error {
 
 // setupConfig updates the cri-o config for the NVIDIA container runtime
 func setupConfig(o *options) error {
+	err := updateConfigFile(o)
+	if err != nil {
+		return err
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	err = RestartCrio(o)
+	if err != nil {
+		return fmt.Errorf("unable to restart crio: %v", err)
+	}
+
+	return nil
+}
+
+// updateConfigFile adds the NVIDIA container runtime to the cri-o config file
+func updateConfigFile(o *options) error {
 	log.Infof("Updating config file")
 
-	cfg, err := crio.New(
-		crio.WithPath(o.config),
-	)
+	if !o.dryRun {
+		lock, err := lockConfig(o)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
+	cfg, path, err := loadConfig(o)
 	if err != nil {
-		return fmt.Errorf("unable to load config: %v", err)
+		return err
 	}
 
 	err = UpdateConfig(cfg, o)
@@ -226,8 +307,12 @@ func setupConfig(o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
-	log.Infof("Flushing cri-o config to %v", o.config)
-	n, err := cfg.Save(o.config)
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, path)
+	}
+
+	log.Infof("Flushing cri-o config to %v", path)
+	n, err := cfg.Save(path)
 	if err != nil {
 		return fmt.Errorf("unable to flush config: %v", err)
 	}
@@ -235,11 +320,6 @@ func setupConfig(o *options) error {
 		log.Infof("Config file is empty, removed")
 	}
 
-	err = RestartCrio(o)
-	if err != nil {
-		return fmt.Errorf("unable to restart crio: %v", err)
-	}
-
 	return nil
 }
 
@@ -247,6 +327,10 @@ func setupConfig(o *options) error {
 func Cleanup(c *cli.Context, o *options) error {
 	log.Infof("Starting 'cleanup' for %v", c.App.Name)
 
+	if o.dryRun && o.configMode != "config" {
+		return fmt.Errorf("--dry-run is only supported for config-mode 'config'")
+	}
+
 	switch o.configMode {
 	case "hook":
 		return cleanupHook(o)
@@ -274,11 +358,17 @@ func cleanupHook(o *options) error {
 func cleanupConfig(o *options) error {
 	log.Infof("Reverting config file modifications")
 
-	cfg, err := crio.New(
-		crio.WithPath(o.config),
-	)
+	if !o.dryRun {
+		lock, err := lockConfig(o)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
+	cfg, path, err := loadConfig(o)
 	if err != nil {
-		return fmt.Errorf("unable to load config: %v", err)
+		return err
 	}
 
 	err = RevertConfig(cfg, o)
@@ -286,8 +376,12 @@ func cleanupConfig(o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
-	log.Infof("Flushing cri-o config to %v", o.config)
-	n, err := cfg.Save(o.config)
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, path)
+	}
+
+	log.Infof("Flushing cri-o config to %v", path)
+	n, err := cfg.Save(path)
 	if err != nil {
 		return fmt.Errorf("unable to flush config: %v", err)
 	}
@@ -303,6 +397,44 @@ func cleanupConfig(o *options) error {
 	return nil
 }
 
+// lockConfig acquires the lock that serializes updates to the cri-o config. For the drop-in write mode,
+// updates to the drop-in file are also serialized.
+func lockConfig(o *options) (*engine.FileLock, error) {
+	paths := []string{o.config}
+	if o.configWriteMode == configWriteModeDropIn {
+		paths = append(paths, o.dropInConfig)
+	}
+	lock, err := engine.Lock(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock config: %v", err)
+	}
+	return lock, nil
+}
+
+// loadConfig loads the cri-o config for the configured write mode and returns the path to which the
+// updated config must be saved. For the drop-in write mode, this is the drop-in file.
+func loadConfig(o *options) (engine.Interface, string, error) {
+	opts := []crio.Option{
+		crio.WithPath(o.config),
+	}
+	path := o.config
+
+	switch o.configWriteMode {
+	case configWriteModeDropIn:
+		opts = append(opts, crio.WithDropInPath(o.dropInConfig))
+		path = o.dropInConfig
+	case configWriteModeInPlace:
+	default:
+		return nil, "", fmt.Errorf("invalid config-write-mode '%v'", o.configWriteMode)
+	}
+
+	cfg, err := crio.New(opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to load config: %v", err)
+	}
+	return cfg, path, nil
+}
+
 // ParseArgs parses the command line arguments to the CLI
 func ParseArgs(c *cli.Context, o *options) error {
 	args := c.Args()
@@ -0,0 +1,304 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/tools/container/operator"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+)
+
+// legacyHookExecutables are the executables invoked by the OCI hooks installed by older
+// versions of the NVIDIA Container Toolkit.
+var legacyHookExecutables = map[string]bool{
+	"nvidia-container-runtime-hook": true,
+	"nvidia-container-toolkit":      true,
+}
+
+// legacyHook is an OCI hook file that invokes the NVIDIA Container Runtime Hook. The contents
+// are retained so that the file can be restored if the migration fails.
+type legacyHook struct {
+	path     string
+	contents []byte
+}
+
+// workload is a container running on the node.
+type workload struct {
+	name           string
+	runtimeHandler string
+	env            []string
+}
+
+// Migrate replaces the OCI hooks installed by older versions of the NVIDIA Container Toolkit
+// with a runtime-class based cri-o config.
+func Migrate(c *cli.Context, o *options) error {
+	log.Infof("Starting 'migrate' for %v", c.App.Name)
+
+	hooks, err := findLegacyHooks(o.hooksDir)
+	if err != nil {
+		return fmt.Errorf("unable to find legacy hooks: %v", err)
+	}
+	if len(hooks) == 0 {
+		log.Infof("No legacy hooks found in %v", o.hooksDir)
+	}
+
+	if o.skipWorkloadCheck {
+		log.Warnf("Skipping check of running workloads due to --skip-workload-check")
+	} else {
+		workloads, err := listWorkloads(o.hostRootMount)
+		if err != nil {
+			return fmt.Errorf("unable to list running workloads: %v", err)
+		}
+		if err := checkWorkloads(workloads, o); err != nil {
+			return err
+		}
+	}
+
+	if o.dryRun {
+		for _, hook := range hooks {
+			log.Infof("Would remove legacy hook %v", hook.path)
+		}
+		if err := updateConfigFile(o); err != nil {
+			return err
+		}
+		if len(hooks) > 0 {
+			return fmt.Errorf("legacy hooks would be removed from %v", o.hooksDir)
+		}
+		return nil
+	}
+
+	if err := removeHooks(hooks); err != nil {
+		restoreHooks(hooks)
+		return fmt.Errorf("unable to remove legacy hooks: %v", err)
+	}
+
+	if err := updateConfigFile(o); err != nil {
+		log.Warnf("Restoring legacy hooks")
+		restoreHooks(hooks)
+		return err
+	}
+
+	err = RestartCrio(o)
+	if err != nil {
+		return fmt.Errorf("unable to restart crio: %v", err)
+	}
+
+	return nil
+}
+
+// findLegacyHooks returns the OCI hooks in the specified directory that invoke the NVIDIA
+// Container Runtime Hook.
+func findLegacyHooks(hooksDir string) ([]legacyHook, error) {
+	paths, err := filepath.Glob(filepath.Join(hooksDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []legacyHook
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading hook file '%v': %v", path, err)
+		}
+		var hook podmanHook
+		if err := json.Unmarshal(contents, &hook); err != nil {
+			log.Warnf("Ignoring invalid hook file '%v': %v", path, err)
+			continue
+		}
+		if !legacyHookExecutables[filepath.Base(hook.Hook.Path)] {
+			continue
+		}
+		log.Infof("Found legacy hook %v", path)
+		hooks = append(hooks, legacyHook{path: path, contents: contents})
+	}
+	return hooks, nil
+}
+
+// removeHooks removes the specified hook files.
+func removeHooks(hooks []legacyHook) error {
+	lock, err := lockHooks(hooks)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	for _, hook := range hooks {
+		log.Infof("Removing legacy hook %v", hook.path)
+		err := os.Remove(hook.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing hook '%v': %v", hook.path, err)
+		}
+	}
+	return nil
+}
+
+// restoreHooks restores the specified hook files. Errors are logged since restoring the hooks
+// is only attempted if the migration has already failed.
+func restoreHooks(hooks []legacyHook) {
+	lock, err := lockHooks(hooks)
+	if err != nil {
+		log.Errorf("Error restoring hooks: %v", err)
+		return
+	}
+	defer lock.Unlock()
+
+	for _, hook := range hooks {
+		_, err := engine.WriteFile(hook.path, hook.contents)
+		if err != nil {
+			log.Errorf("Error restoring hook '%v': %v", hook.path, err)
+		}
+	}
+}
+
+// lockHooks acquires the lock that serializes updates to the specified hook files.
+// The lock is not held while the cri-o config is updated since this acquires its own lock.
+func lockHooks(hooks []legacyHook) (*engine.FileLock, error) {
+	var paths []string
+	for _, hook := range hooks {
+		paths = append(paths, hook.path)
+	}
+	lock, err := engine.Lock(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock hooks: %v", err)
+	}
+	return lock, nil
+}
+
+// checkWorkloads returns an error if any of the specified workloads depend on the behavior
+// of the legacy hooks. This is the case for workloads that request NVIDIA devices and do not
+// use a runtime class that is configured for the NVIDIA Container Runtime.
+func checkWorkloads(workloads []workload, o *options) error {
+	runtimes := operator.GetRuntimes(
+		operator.WithNvidiaRuntimeName(o.runtimeClass),
+		operator.WithSetAsDefault(o.setAsDefault),
+		operator.WithRoot(o.runtimeDir),
+	)
+
+	var dependent []string
+	for _, w := range workloads {
+		if !requestsDevices(w.env) {
+			continue
+		}
+		if _, ok := runtimes[w.runtimeHandler]; ok {
+			continue
+		}
+		if w.runtimeHandler == "" && o.setAsDefault {
+			continue
+		}
+		dependent = append(dependent, w.name)
+	}
+	if len(dependent) == 0 {
+		return nil
+	}
+	sort.Strings(dependent)
+	return fmt.Errorf("the following workloads depend on the legacy hooks and would lose access to NVIDIA devices: %v; "+
+		"use the '%v' runtime class for these workloads or specify --skip-workload-check", strings.Join(dependent, ", "), o.runtimeClass)
+}
+
+// requestsDevices checks whether the specified environment requests NVIDIA devices from the
+// NVIDIA Container Runtime Hook.
+func requestsDevices(env []string) bool {
+	var value string
+	var isSet bool
+	for _, e := range env {
+		if strings.HasPrefix(e, "NVIDIA_VISIBLE_DEVICES=") {
+			value = strings.TrimPrefix(e, "NVIDIA_VISIBLE_DEVICES=")
+			isSet = true
+		}
+	}
+	return isSet && value != "" && value != "void"
+}
+
+// listWorkloads returns the containers running on the node using crictl in the specified
+// host root.
+func listWorkloads(hostRootMount string) ([]workload, error) {
+	var pods struct {
+		Items []struct {
+			ID       string `json:"id"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			RuntimeHandler string `json:"runtimeHandler"`
+		} `json:"items"`
+	}
+	if err := crictl(hostRootMount, &pods, "pods", "--state", "ready", "-o", "json"); err != nil {
+		return nil, err
+	}
+
+	var containers struct {
+		Containers []struct {
+			ID           string `json:"id"`
+			PodSandboxID string `json:"podSandboxId"`
+			Metadata     struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"containers"`
+	}
+	if err := crictl(hostRootMount, &containers, "ps", "--state", "running", "-o", "json"); err != nil {
+		return nil, err
+	}
+
+	var workloads []workload
+	for _, c := range containers.Containers {
+		w := workload{name: c.Metadata.Name}
+		for _, p := range pods.Items {
+			if p.ID != c.PodSandboxID {
+				continue
+			}
+			w.name = fmt.Sprintf("%v/%v/%v", p.Metadata.Namespace, p.Metadata.Name, c.Metadata.Name)
+			w.runtimeHandler = p.RuntimeHandler
+		}
+
+		var status struct {
+			Info struct {
+				RuntimeSpec *specs.Spec `json:"runtimeSpec"`
+			} `json:"info"`
+		}
+		if err := crictl(hostRootMount, &status, "inspect", c.ID); err != nil {
+			return nil, err
+		}
+		if spec := status.Info.RuntimeSpec; spec != nil && spec.Process != nil {
+			w.env = spec.Process.Env
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+// crictl runs crictl in the specified host root and decodes its JSON output.
+func crictl(hostRootMount string, v interface{}, args ...string) error {
+	cmd := exec.Command("chroot", append([]string{hostRootMount, "crictl"}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("error running crictl %v: %v", strings.Join(args, " "), err)
+	}
+	if err := json.Unmarshal(output, v); err != nil {
+		return fmt.Errorf("error parsing output of crictl %v: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindLegacyHooks(t *testing.T) {
+	hooksDir := t.TempDir()
+
+	hooks := map[string]string{
+		"oci-nvidia-hook.json": `{"version": "1.0.0", "hook": {"path": "/usr/bin/nvidia-container-toolkit", "args": ["nvidia-container-toolkit", "prestart"]}, "when": {"always": true}, "stages": ["prestart"]}`,
+		"toolkit.json":         `{"version": "1.0.0", "hook": {"path": "/run/nvidia/toolkit/nvidia-container-runtime-hook"}, "when": {"always": true}, "stages": ["prestart"]}`,
+		"other.json":           `{"version": "1.0.0", "hook": {"path": "/usr/bin/other-hook"}, "when": {"always": true}, "stages": ["prestart"]}`,
+		"invalid.json":         `{`,
+		"nvidia.txt":           `{"version": "1.0.0", "hook": {"path": "/usr/bin/nvidia-container-toolkit"}}`,
+	}
+	for name, contents := range hooks {
+		require.NoError(t, os.WriteFile(filepath.Join(hooksDir, name), []byte(contents), 0644))
+	}
+
+	found, err := findLegacyHooks(hooksDir)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, hook := range found {
+		paths = append(paths, hook.path)
+		require.Equal(t, hooks[filepath.Base(hook.path)], string(hook.contents))
+	}
+	require.Equal(t,
+		[]string{
+			filepath.Join(hooksDir, "oci-nvidia-hook.json"),
+			filepath.Join(hooksDir, "toolkit.json"),
+		},
+		paths,
+	)
+
+	require.NoError(t, removeHooks(found))
+	for _, path := range paths {
+		require.NoFileExists(t, path)
+	}
+	require.FileExists(t, filepath.Join(hooksDir, "other.json"))
+
+	restoreHooks(found)
+	for _, hook := range found {
+		contents, err := os.ReadFile(hook.path)
+		require.NoError(t, err)
+		require.Equal(t, hook.contents, contents)
+	}
+}
+
+func TestCheckWorkloads(t *testing.T) {
+	gpu := []string{"PATH=/usr/bin", "NVIDIA_VISIBLE_DEVICES=all"}
+
+	testCases := []struct {
+		description   string
+		workloads     []workload
+		setAsDefault  bool
+		expectedError bool
+	}{
+		{
+			description: "no workloads",
+		},
+		{
+			description: "workloads without devices",
+			workloads: []workload{
+				{name: "default/a/a", env: []string{"PATH=/usr/bin"}},
+				{name: "default/b/b", env: []string{"NVIDIA_VISIBLE_DEVICES=void"}},
+				{name: "default/c/c", env: []string{"NVIDIA_VISIBLE_DEVICES=all", "NVIDIA_VISIBLE_DEVICES="}},
+			},
+		},
+		{
+			description: "workload using nvidia runtime class",
+			workloads: []workload{
+				{name: "default/a/a", runtimeHandler: "nvidia", env: gpu},
+				{name: "default/b/b", runtimeHandler: "nvidia-experimental", env: gpu},
+			},
+		},
+		{
+			description: "workload using default runtime class",
+			workloads: []workload{
+				{name: "default/a/a", env: gpu},
+			},
+			expectedError: true,
+		},
+		{
+			description: "workload using default runtime class set as default",
+			workloads: []workload{
+				{name: "default/a/a", env: gpu},
+			},
+			setAsDefault: true,
+		},
+		{
+			description: "workload using other runtime class",
+			workloads: []workload{
+				{name: "default/a/a", runtimeHandler: "runc", env: gpu},
+			},
+			setAsDefault:  true,
+			expectedError: true,
+		},
+		{
+			description: "none requests driver libraries",
+			workloads: []workload{
+				{name: "default/a/a", env: []string{"NVIDIA_VISIBLE_DEVICES=none"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			o := &options{
+				runtimeClass: "nvidia",
+				setAsDefault: tc.setAsDefault,
+				runtimeDir:   "/test/runtime/dir",
+			}
+			err := checkWorkloads(tc.workloads, o)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -39,8 +39,6 @@ const (
 	nvidiaExperimentalRuntimeName   = "nvidia-experimental"
 	nvidiaExperimentalRuntimeBinary = "nvidia-container-runtime.experimental"
 
-	defaultConfig       = "/etc/docker/daemon.json"
-	defaultSocket       = "/var/run/docker.sock"
 	defaultSetAsDefault = true
 	// defaultRuntimeName specifies the NVIDIA runtime to be use as the default runtime if setting the default runtime is enabled
 	defaultRuntimeName = nvidiaRuntimeName
@@ -61,12 +59,14 @@ var nvidiaRuntimeBinaries = map[string]string{
 
 // options stores the configuration from the command line or environment variables
 type options struct {
+	hostFlavor   string
 	config       string
 	socket       string
 	runtimeName  string
 	setAsDefault bool
 	runtimeDir   string
 	restartMode  string
+	dryRun       bool
 }
 
 func main() {
@@ -107,19 +107,24 @@ func main() {
 	// only require the user to specify one set of flags for both 'startup'
 	// and 'cleanup' to simplify things.
 	commonFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "host-flavor",
+			Usage:       "The flavor of the host on which docker is configured. This determines the default config and socket paths [default | balena]",
+			Value:       docker.FlavorDefault,
+			Destination: &options.hostFlavor,
+			EnvVars:     []string{"DOCKER_HOST_FLAVOR"},
+		},
 		&cli.StringFlag{
 			Name:        "config",
 			Aliases:     []string{"c"},
-			Usage:       "Path to docker config file",
-			Value:       defaultConfig,
+			Usage:       "Path to docker config file. If this is not specified, the path for the host flavor is used (/etc/docker/daemon.json by default)",
 			Destination: &options.config,
 			EnvVars:     []string{"DOCKER_CONFIG"},
 		},
 		&cli.StringFlag{
 			Name:        "socket",
 			Aliases:     []string{"s"},
-			Usage:       "Path to the docker socket file",
-			Value:       defaultSocket,
+			Usage:       "Path to the docker socket file. If this is not specified, the path for the host flavor is used (/var/run/docker.sock by default)",
 			Destination: &options.socket,
 			EnvVars:     []string{"DOCKER_SOCKET"},
 		},
@@ -148,6 +153,12 @@ func main() {
 			Destination: &options.restartMode,
 			EnvVars:     []string{"DOCKER_RESTART_MODE"},
 		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Print a unified diff of the changes to the docker config instead of writing the config and restarting docker. Fails if any changes would be made",
+			Destination: &options.dryRun,
+			EnvVars:     []string{"DOCKER_DRY_RUN"},
+		},
 	}
 
 	// Update the subcommand flags with the common subcommand flags
@@ -171,6 +182,19 @@ func Setup(c *cli.Context, o *options) error {
 	}
 	o.runtimeDir = runtimeDir
 
+	err = o.applyHostFlavor()
+	if err != nil {
+		return fmt.Errorf("unable to apply host flavor: %v", err)
+	}
+
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
 	cfg, err := docker.New(
 		docker.WithPath(o.config),
 	)
@@ -183,6 +207,10 @@ func Setup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
 	log.Infof("Flushing docker config to %v", o.config)
 	_, err = cfg.Save(o.config)
 	if err != nil {
@@ -208,6 +236,19 @@ func Cleanup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to parse args: %v", err)
 	}
 
+	err = o.applyHostFlavor()
+	if err != nil {
+		return fmt.Errorf("unable to apply host flavor: %v", err)
+	}
+
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
 	cfg, err := docker.New(
 		docker.WithPath(o.config),
 	)
@@ -220,6 +261,10 @@ func Cleanup(c *cli.Context, o *options) error {
 		return fmt.Errorf("unable to update config: %v", err)
 	}
 
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
 	log.Infof("Flushing docker config to %v", o.config)
 	n, err := cfg.Save(o.config)
 	if err != nil {
@@ -253,6 +298,23 @@ func ParseArgs(c *cli.Context) (string, error) {
 	return runtimeDir, nil
 }
 
+// applyHostFlavor sets the config and socket paths that have not been specified
+// to the defaults for the selected host flavor.
+func (o *options) applyHostFlavor() error {
+	flavor, err := docker.GetFlavor(o.hostFlavor)
+	if err != nil {
+		return err
+	}
+	if o.config == "" {
+		o.config = flavor.ConfigFilePath
+	}
+	if o.socket == "" {
+		o.socket = flavor.Socket
+	}
+	log.Infof("Using config %v and socket %v for host flavor %v", o.config, o.socket, flavor.Name)
+	return nil
+}
+
 // UpdateConfig updates the docker config to include the nvidia runtimes
 func UpdateConfig(cfg engine.Interface, o *options) error {
 	runtimes := operator.GetRuntimes(
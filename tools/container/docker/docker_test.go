@@ -470,3 +470,55 @@ func TestRevertConfig(t *testing.T) {
 		require.EqualValues(t, string(expectedContent), string(configContent), "%d: %v", i, tc)
 	}
 }
+
+func TestApplyHostFlavor(t *testing.T) {
+	testCases := []struct {
+		description    string
+		options        options
+		expectedConfig string
+		expectedSocket string
+		expectedError  bool
+	}{
+		{
+			description:    "default flavor",
+			options:        options{hostFlavor: docker.FlavorDefault},
+			expectedConfig: "/etc/docker/daemon.json",
+			expectedSocket: "/var/run/docker.sock",
+		},
+		{
+			description:    "balena flavor",
+			options:        options{hostFlavor: docker.FlavorBalena},
+			expectedConfig: "/mnt/data/balena-engine/daemon.json",
+			expectedSocket: "/var/run/balena-engine.sock",
+		},
+		{
+			description: "explicit paths take precedence",
+			options: options{
+				hostFlavor: docker.FlavorBalena,
+				config:     "/custom/daemon.json",
+				socket:     "/custom/engine.sock",
+			},
+			expectedConfig: "/custom/daemon.json",
+			expectedSocket: "/custom/engine.sock",
+		},
+		{
+			description:   "unknown flavor",
+			options:       options{hostFlavor: "unknown"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			o := tc.options
+			err := o.applyHostFlavor()
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedConfig, o.config)
+			require.Equal(t, tc.expectedSocket, o.socket)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/docker"
 	log "github.com/sirupsen/logrus"
 	cli "github.com/urfave/cli/v2"
 	unix "golang.org/x/sys/unix"
@@ -25,7 +26,7 @@ const (
 	defaultRuntimeArgs = ""
 )
 
-var availableRuntimes = map[string]struct{}{"docker": {}, "crio": {}, "containerd": {}}
+var availableRuntimes = map[string]struct{}{"docker": {}, "crio": {}, "containerd": {}, "podman": {}}
 
 var waitingForSignal = make(chan bool, 1)
 var signalReceived = make(chan bool, 1)
@@ -36,6 +37,7 @@ type options struct {
 	runtime     string
 	runtimeArgs string
 	root        string
+	hostFlavor  string
 }
 
 // Version defines the CLI version. This is set at build time using LD FLAGS
@@ -72,7 +74,7 @@ func main() {
 		&cli.StringFlag{
 			Name:        "runtime",
 			Aliases:     []string{"r"},
-			Usage:       "the runtime to setup on this node. One of {'docker', 'crio', 'containerd'}",
+			Usage:       "the runtime to setup on this node. One of {'docker', 'crio', 'containerd', 'podman'}",
 			Value:       defaultRuntime,
 			Destination: &options.runtime,
 			EnvVars:     []string{"RUNTIME"},
@@ -80,7 +82,7 @@ func main() {
 		&cli.StringFlag{
 			Name:        "runtime-args",
 			Aliases:     []string{"u"},
-			Usage:       "arguments to pass to 'docker', 'crio', or 'containerd' setup command",
+			Usage:       "arguments to pass to 'docker', 'crio', 'containerd', or 'podman' setup command",
 			Value:       defaultRuntimeArgs,
 			Destination: &options.runtimeArgs,
 			EnvVars:     []string{"RUNTIME_ARGS"},
@@ -92,6 +94,13 @@ func main() {
 			Destination: &options.root,
 			EnvVars:     []string{"ROOT"},
 		},
+		&cli.StringFlag{
+			Name:        "host-flavor",
+			Usage:       "the flavor of the host. This determines the default install root and is passed to the 'docker' setup command. One of {'default', 'balena'}",
+			Value:       docker.FlavorDefault,
+			Destination: &options.hostFlavor,
+			EnvVars:     []string{"HOST_FLAVOR"},
+		},
 	}
 
 	// Run the CLI
@@ -172,6 +181,15 @@ func ParseArgs(args []string) ([]string, string, error) {
 
 func verifyFlags(o *options) error {
 	log.Infof("Verifying Flags")
+	flavor, err := docker.GetFlavor(o.hostFlavor)
+	if err != nil {
+		return err
+	}
+	if o.root == "" && flavor.Name != docker.FlavorDefault {
+		// On hosts such as BalenaOS the root filesystem is read-only and the
+		// toolkit must be installed to a writable location.
+		o.root = flavor.InstallRoot
+	}
 	if o.root == "" {
 		return fmt.Errorf("the install root must be specified")
 	}
@@ -245,7 +263,7 @@ func setupRuntime(o *options) error {
 
 	log.Infof("Setting up runtime")
 
-	cmdline := fmt.Sprintf("%v setup %v %v\n", o.runtime, o.runtimeArgs, toolkitDir)
+	cmdline := fmt.Sprintf("%v setup %v %v\n", o.runtime, o.getRuntimeArgs(), toolkitDir)
 
 	cmd := exec.Command("sh", "-c", cmdline)
 	cmd.Stdout = os.Stdout
@@ -258,6 +276,16 @@ func setupRuntime(o *options) error {
 	return nil
 }
 
+// getRuntimeArgs returns the arguments for the runtime setup and cleanup commands.
+// For docker, the host flavor is passed so that the engine-specific config and
+// socket paths are used.
+func (o options) getRuntimeArgs() string {
+	if o.runtime != "docker" || o.hostFlavor == "" || o.hostFlavor == docker.FlavorDefault {
+		return o.runtimeArgs
+	}
+	return strings.TrimSpace(fmt.Sprintf("--host-flavor=%v %v", o.hostFlavor, o.runtimeArgs))
+}
+
 func waitForSignal() error {
 	log.Infof("Waiting for signal")
 	waitingForSignal <- true
@@ -270,7 +298,7 @@ func cleanupRuntime(o *options) error {
 
 	log.Infof("Cleaning up Runtime")
 
-	cmdline := fmt.Sprintf("%v cleanup %v %v\n", o.runtime, o.runtimeArgs, toolkitDir)
+	cmdline := fmt.Sprintf("%v cleanup %v %v\n", o.runtime, o.getRuntimeArgs(), toolkitDir)
 
 	cmd := exec.Command("sh", "-c", cmdline)
 	cmd.Stdout = os.Stdout
@@ -82,3 +82,56 @@ func TestParseArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyFlagsHostFlavor(t *testing.T) {
+	testCases := []struct {
+		description         string
+		options             options
+		expectedRoot        string
+		expectedRuntimeArgs string
+		expectedError       bool
+	}{
+		{
+			description:   "default flavor requires root",
+			options:       options{runtime: "docker", hostFlavor: "default"},
+			expectedError: true,
+		},
+		{
+			description:         "default flavor",
+			options:             options{runtime: "docker", hostFlavor: "default", root: "/usr/local/nvidia", runtimeArgs: "--socket=/run/docker.sock"},
+			expectedRoot:        "/usr/local/nvidia",
+			expectedRuntimeArgs: "--socket=/run/docker.sock",
+		},
+		{
+			description:         "balena flavor uses writable root",
+			options:             options{runtime: "docker", hostFlavor: "balena"},
+			expectedRoot:        "/mnt/data/nvidia",
+			expectedRuntimeArgs: "--host-flavor=balena",
+		},
+		{
+			description:         "balena flavor with explicit root",
+			options:             options{runtime: "docker", hostFlavor: "balena", root: "/data/nvidia", runtimeArgs: "--restart-mode=none"},
+			expectedRoot:        "/data/nvidia",
+			expectedRuntimeArgs: "--host-flavor=balena --restart-mode=none",
+		},
+		{
+			description:   "unknown flavor",
+			options:       options{runtime: "docker", hostFlavor: "unknown", root: "/usr/local/nvidia"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			o := tc.options
+			err := verifyFlags(&o)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRoot, o.root)
+			require.Equal(t, tc.expectedRuntimeArgs, o.getRuntimeArgs())
+		})
+	}
+}
@@ -0,0 +1,267 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine"
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/podman"
+	"github.com/NVIDIA/nvidia-container-toolkit/tools/container/operator"
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	log "github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+)
+
+const (
+	defaultConfig       = podman.DefaultConfigFilePath
+	defaultRuntimeName  = "nvidia"
+	defaultSetAsDefault = false
+)
+
+// options stores the configuration from the command line or environment variables
+type options struct {
+	runtimeDir string
+
+	config       string
+	runtimeName  string
+	setAsDefault bool
+	cdiSpecDirs  cli.StringSlice
+	dryRun       bool
+}
+
+func main() {
+	options := options{}
+
+	// Create the top-level CLI
+	c := cli.NewApp()
+	c.Name = "podman"
+	c.Usage = "Update the podman config to include the NVIDIA runtime"
+	c.ArgsUsage = "<toolkit_dirname>"
+	c.Version = "0.1.0"
+
+	// Create the 'setup' subcommand
+	setup := cli.Command{}
+	setup.Name = "setup"
+	setup.Usage = "Configure podman for NVIDIA GPU containers"
+	setup.ArgsUsage = "<toolkit_dirname>"
+	setup.Action = func(c *cli.Context) error {
+		return Setup(c, &options)
+	}
+	setup.Before = func(c *cli.Context) error {
+		return ParseArgs(c, &options)
+	}
+
+	// Create the 'cleanup' subcommand
+	cleanup := cli.Command{}
+	cleanup.Name = "cleanup"
+	cleanup.Usage = "Remove the NVIDIA-specific podman configuration"
+	cleanup.Action = func(c *cli.Context) error {
+		return Cleanup(c, &options)
+	}
+
+	// Register the subcommands with the top-level CLI
+	c.Commands = []*cli.Command{
+		&setup,
+		&cleanup,
+	}
+
+	// Setup common flags across both subcommands. All subcommands get the same
+	// set of flags even if they don't use some of them. This is so that we
+	// only require the user to specify one set of flags for both 'startup'
+	// and 'cleanup' to simplify things.
+	commonFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "Path to the podman config file. This is typically a drop-in file in a containers.conf.d directory so that containers.conf is not modified",
+			Value:       defaultConfig,
+			Destination: &options.config,
+			EnvVars:     []string{"PODMAN_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:        "runtime-name",
+			Usage:       "The name of the OCI runtime to set for the nvidia-container-runtime. This can be selected using the podman --runtime flag",
+			Value:       defaultRuntimeName,
+			Destination: &options.runtimeName,
+			EnvVars:     []string{"PODMAN_RUNTIME_NAME"},
+		},
+		&cli.StringSliceFlag{
+			Name:        "cdi-spec-dirs",
+			Usage:       "The directories that podman searches for CDI specifications",
+			Value:       cli.NewStringSlice(cdi.DefaultSpecDirs...),
+			Destination: &options.cdiSpecDirs,
+			EnvVars:     []string{"PODMAN_CDI_SPEC_DIRS"},
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Print a unified diff of the changes to the podman config instead of writing the config. Fails if any changes would be made",
+			Destination: &options.dryRun,
+			EnvVars:     []string{"PODMAN_DRY_RUN"},
+		},
+		// The flags below are only used by the 'setup' command.
+		&cli.BoolFlag{
+			Name:        "set-as-default",
+			Usage:       "Set nvidia-container-runtime as the default runtime",
+			Value:       defaultSetAsDefault,
+			Destination: &options.setAsDefault,
+			EnvVars:     []string{"PODMAN_SET_AS_DEFAULT"},
+		},
+	}
+
+	// Update the subcommand flags with the common subcommand flags
+	setup.Flags = append([]cli.Flag{}, commonFlags...)
+	cleanup.Flags = append([]cli.Flag{}, commonFlags...)
+
+	// Run the top-level CLI
+	if err := c.Run(os.Args); err != nil {
+		log.Fatal(fmt.Errorf("error: %v", err))
+	}
+}
+
+// Setup updates the podman config to include the NVIDIA container runtime. Since podman does not run
+// a daemon, the updated config is used for containers that are created from now on.
+func Setup(c *cli.Context, o *options) error {
+	log.Infof("Starting 'setup' for %v", c.App.Name)
+
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
+	cfg, err := podman.New(
+		podman.WithPath(o.config),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to load config: %v", err)
+	}
+
+	err = UpdateConfig(cfg.(*podman.Config), o)
+	if err != nil {
+		return fmt.Errorf("unable to update config: %v", err)
+	}
+
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
+	log.Infof("Flushing podman config to %v", o.config)
+	n, err := cfg.Save(o.config)
+	if err != nil {
+		return fmt.Errorf("unable to flush config: %v", err)
+	}
+	if n == 0 {
+		log.Infof("Config file is empty, removed")
+	}
+
+	log.Infof("Completed 'setup' for %v", c.App.Name)
+	return nil
+}
+
+// Cleanup reverts the podman config to remove the NVIDIA container runtime
+func Cleanup(c *cli.Context, o *options) error {
+	log.Infof("Starting 'cleanup' for %v", c.App.Name)
+
+	if !o.dryRun {
+		lock, err := engine.Lock(o.config)
+		if err != nil {
+			return fmt.Errorf("unable to lock config: %v", err)
+		}
+		defer lock.Unlock()
+	}
+
+	cfg, err := podman.New(
+		podman.WithPath(o.config),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to load config: %v", err)
+	}
+
+	err = RevertConfig(cfg.(*podman.Config), o)
+	if err != nil {
+		return fmt.Errorf("unable to update config: %v", err)
+	}
+
+	if o.dryRun {
+		return engine.DryRun(os.Stdout, cfg, o.config)
+	}
+
+	log.Infof("Flushing podman config to %v", o.config)
+	n, err := cfg.Save(o.config)
+	if err != nil {
+		return fmt.Errorf("unable to flush config: %v", err)
+	}
+	if n == 0 {
+		log.Infof("Config file is empty, removed")
+	}
+
+	log.Infof("Completed 'cleanup' for %v", c.App.Name)
+	return nil
+}
+
+// ParseArgs parses the command line arguments to the CLI
+func ParseArgs(c *cli.Context, o *options) error {
+	args := c.Args()
+
+	log.Infof("Parsing arguments: %v", args.Slice())
+	if c.NArg() != 1 {
+		return fmt.Errorf("incorrect number of arguments")
+	}
+	o.runtimeDir = args.Get(0)
+	log.Infof("Successfully parsed arguments")
+
+	return nil
+}
+
+// UpdateConfig updates the podman config to include the NVIDIA Container Runtime and the CDI spec dirs
+func UpdateConfig(cfg *podman.Config, o *options) error {
+	runtimes := operator.GetRuntimes(
+		operator.WithNvidiaRuntimeName(o.runtimeName),
+		operator.WithSetAsDefault(o.setAsDefault),
+		operator.WithRoot(o.runtimeDir),
+	)
+	for name, runtime := range runtimes {
+		err := cfg.AddRuntime(name, runtime.Path, runtime.SetAsDefault)
+		if err != nil {
+			return fmt.Errorf("unable to update config for runtime '%v': %v", name, err)
+		}
+	}
+	cfg.SetCDISpecDirs(o.cdiSpecDirs.Value()...)
+
+	return nil
+}
+
+// RevertConfig reverts the podman config to remove the NVIDIA Container Runtime and the CDI spec dirs
+func RevertConfig(cfg *podman.Config, o *options) error {
+	runtimes := operator.GetRuntimes(
+		operator.WithNvidiaRuntimeName(o.runtimeName),
+		operator.WithSetAsDefault(o.setAsDefault),
+		operator.WithRoot(o.runtimeDir),
+	)
+	for name := range runtimes {
+		err := cfg.RemoveRuntime(name)
+		if err != nil {
+			return fmt.Errorf("unable to revert config for runtime '%v': %v", name, err)
+		}
+	}
+	cfg.SetCDISpecDirs()
+
+	return nil
+}
@@ -0,0 +1,50 @@
+/**
+# Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/nvidia-container-toolkit/internal/config/engine/podman"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+	cli "github.com/urfave/cli/v2"
+)
+
+func TestUpdateAndRevertConfig(t *testing.T) {
+	o := &options{
+		runtimeDir:   "/test/runtime/dir",
+		runtimeName:  "nvidia",
+		setAsDefault: true,
+		cdiSpecDirs:  *cli.NewStringSlice("/etc/cdi", "/var/run/cdi"),
+	}
+
+	empty, err := toml.Load("")
+	require.NoError(t, err)
+	cfg := (*podman.Config)(empty)
+
+	require.NoError(t, UpdateConfig(cfg, o))
+	require.Equal(t, "nvidia", cfg.DefaultRuntime())
+	require.Equal(t, []string{"/etc/cdi", "/var/run/cdi"}, cfg.CDISpecDirs())
+
+	tree := (*toml.Tree)(cfg)
+	require.Equal(t, []string{"/test/runtime/dir/nvidia-container-runtime"}, tree.GetPath([]string{"engine", "runtimes", "nvidia"}))
+	require.Equal(t, []string{"/test/runtime/dir/nvidia-container-runtime.cdi"}, tree.GetPath([]string{"engine", "runtimes", "nvidia-cdi"}))
+
+	require.NoError(t, RevertConfig(cfg, o))
+	require.Empty(t, (*toml.Tree)(cfg).Keys())
+}
@@ -278,6 +278,12 @@ func Install(cli *cli.Context, opts *options) error {
 		return fmt.Errorf("error installing NVIDIA Container Toolkit CLI: %v", err)
 	}
 
+	// The nvidia-ctk-check binary is installed next to nvidia-ctk so that it can be located by the
+	// NVIDIA Container Runtime. Since it is injected into containers, it is not wrapped.
+	if _, err := installFileToFolder(opts.toolkitRoot, "/usr/bin/nvidia-ctk-check"); err != nil {
+		log.Warningf("Failed to install nvidia-ctk-check: %v", err)
+	}
+
 	err = installToolkitConfig(toolkitConfigPath, nvidiaContainerCliExecutable, nvidiaCTKPath, opts)
 	if err != nil {
 		return fmt.Errorf("error installing NVIDIA container toolkit config: %v", err)
@@ -608,13 +614,16 @@ func generateCDISpec(opts *options, nvidiaCTKPath string) error {
 		return nil
 	}
 
-	cdilib := nvcdi.New(
+	cdilib, err := nvcdi.New(
 		nvcdi.WithMode(nvcdi.ModeManagement),
 		nvcdi.WithDriverRoot(opts.DriverRootCtrPath),
 		nvcdi.WithNVIDIACTKPath(nvidiaCTKPath),
 		nvcdi.WithVendor(opts.cdiVendor),
 		nvcdi.WithClass(opts.cdiClass),
 	)
+	if err != nil {
+		return fmt.Errorf("failed to create CDI library for management containers: %v", err)
+	}
 
 	spec, err := cdilib.GetSpec()
 	if err != nil {